@@ -0,0 +1,95 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+
+	"github.com/cyberspacesec/go-acl/pkg/acl"
+)
+
+// explainOutput 是explain --format json输出的一行JSON
+//
+// 字段与acl.Explanation相同，Decision使用"allowed"/"denied"字符串，检查失败时Error为错误信息。
+type explainOutput struct {
+	Target      string          `json:"target"`
+	Kind        string          `json:"kind"`
+	Normalized  string          `json:"normalized"`
+	Steps       []acl.TraceStep `json:"steps"`
+	MatchedRule string          `json:"matched_rule,omitempty"`
+	Decision    string          `json:"decision"`
+	Reason      string          `json:"reason,omitempty"`
+	Error       string          `json:"error,omitempty"`
+}
+
+// runExplain 实现explain命令
+//
+// 按Manager.Explain输出每个目标的求值过程：标准化步骤、依次查询的ACL、匹配的规则和最终结果。
+// 默认输出便于阅读的文本，目标之间空一行；--format json每个目标输出一行JSON。
+func runExplain(args []string, stdin io.Reader, stdout, stderr io.Writer) int {
+	fs := flag.NewFlagSet("explain", flag.ContinueOnError)
+	fs.SetOutput(stderr)
+	policyPath := fs.String("policy", "", "策略文件（必需），格式见acl.Policy")
+	format := fs.String("format", "text", "输出格式: text或json")
+	fs.Usage = func() {
+		fmt.Fprintln(stderr, "用法: go-acl explain --policy policy.json [--format text|json] 目标...")
+		fs.PrintDefaults()
+	}
+	if err := fs.Parse(args); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			return exitOK
+		}
+		return exitUsage
+	}
+	if *policyPath == "" || fs.NArg() == 0 {
+		fs.Usage()
+		return exitUsage
+	}
+	if *format != "text" && *format != "json" {
+		fmt.Fprintf(stderr, "go-acl explain: 未知的格式 %q\n", *format)
+		return exitUsage
+	}
+
+	manager, err := acl.LoadPolicyFile(*policyPath)
+	if err != nil {
+		fmt.Fprintf(stderr, "go-acl explain: 加载策略失败: %v\n", err)
+		return exitError
+	}
+
+	enc := json.NewEncoder(stdout)
+	for i, target := range fs.Args() {
+		e := manager.Explain(target)
+		if *format == "json" {
+			err = enc.Encode(newExplainOutput(e))
+		} else {
+			if i > 0 {
+				fmt.Fprintln(stdout)
+			}
+			_, err = fmt.Fprintln(stdout, e)
+		}
+		if err != nil {
+			fmt.Fprintf(stderr, "go-acl explain: %v\n", err)
+			return exitError
+		}
+	}
+	return exitOK
+}
+
+// newExplainOutput 把求值过程转换为输出格式
+func newExplainOutput(e acl.Explanation) explainOutput {
+	out := explainOutput{
+		Target:      e.Target,
+		Kind:        e.Kind,
+		Normalized:  e.Normalized,
+		Steps:       e.Steps,
+		MatchedRule: e.MatchedRule,
+		Decision:    e.Decision.String(),
+		Reason:      e.Reason.String(),
+	}
+	if e.Err != nil {
+		out.Error = e.Err.Error()
+	}
+	return out
+}
@@ -0,0 +1,70 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+// TestExplainText 测试以文本形式输出求值过程
+func TestExplainText(t *testing.T) {
+	policy := writePolicy(t, `{"ip": {"type": "blacklist", "predefined": ["cloud_metadata"]}}`)
+
+	var stdout, stderr bytes.Buffer
+	if code := run([]string{"explain", "--policy", policy, "https://169.254.169.254/latest", "198.51.100.1"}, nil, &stdout, &stderr); code != exitOK {
+		t.Fatalf("退出码 = %d, stderr: %s", code, stderr.String())
+	}
+
+	out := stdout.String()
+	for _, want := range []string{
+		"target: https://169.254.169.254/latest (ip)\n",
+		"[normalize] strip_scheme: 169.254.169.254/latest\n",
+		"decision: denied (rule: 169.254.169.254/32) (reason: matched_blacklist_ip)\n\n",
+		"target: 198.51.100.1 (ip)\n",
+		"decision: allowed\n",
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("输出中缺少 %q:\n%s", want, out)
+		}
+	}
+}
+
+// TestExplainJSON 测试每个目标输出一行JSON
+func TestExplainJSON(t *testing.T) {
+	policy := writePolicy(t, `{"domain": {"type": "blacklist", "domains": ["ads.example.com"], "include_subdomains": true}}`)
+
+	var stdout, stderr bytes.Buffer
+	if code := run([]string{"explain", "--policy", policy, "--format", "json", "HTTPS://Tracker.Ads.Example.com/x"}, nil, &stdout, &stderr); code != exitOK {
+		t.Fatalf("退出码 = %d, stderr: %s", code, stderr.String())
+	}
+
+	var out explainOutput
+	if err := json.Unmarshal(stdout.Bytes(), &out); err != nil {
+		t.Fatalf("输出不是有效的JSON: %v\n%s", err, stdout.String())
+	}
+	if out.Kind != "domain" || out.Normalized != "tracker.ads.example.com" || out.Decision != "denied" ||
+		out.MatchedRule != "ads.example.com" || out.Reason != "matched_blacklist_domain" || len(out.Steps) == 0 {
+		t.Errorf("输出 = %+v", out)
+	}
+}
+
+// TestExplainUsage 测试无效的参数和无法加载的策略
+func TestExplainUsage(t *testing.T) {
+	policy := writePolicy(t, `{"ip": {"type": "blacklist"}}`)
+	tests := []struct {
+		args []string
+		code int
+	}{
+		{[]string{"explain", "8.8.8.8"}, exitUsage},
+		{[]string{"explain", "--policy", policy}, exitUsage},
+		{[]string{"explain", "--policy", policy, "--format", "yaml", "8.8.8.8"}, exitUsage},
+		{[]string{"explain", "--policy", policy + ".missing", "8.8.8.8"}, exitError},
+	}
+	for _, tt := range tests {
+		var stdout, stderr bytes.Buffer
+		if code := run(tt.args, nil, &stdout, &stderr); code != tt.code {
+			t.Errorf("run(%v) 退出码 = %d, 期望 %d", tt.args, code, tt.code)
+		}
+	}
+}
@@ -0,0 +1,112 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"net"
+	"time"
+
+	"github.com/cyberspacesec/go-acl/pkg/acl"
+)
+
+// lintOutput 是lint --format json输出的一行JSON，对应一个策略文件
+type lintOutput struct {
+	Policy   string        `json:"policy"`
+	Passed   bool          `json:"passed"`
+	Findings []lintFinding `json:"findings"`
+}
+
+// lintFinding 与acl.LintFinding相同，Severity使用"warning"/"error"字符串
+type lintFinding struct {
+	Check     string `json:"check"`
+	Severity  string `json:"severity"`
+	Component string `json:"component"`
+	Message   string `json:"message"`
+}
+
+// lintResolver 是--resolve使用的解析器，测试中替换为固定的结果
+var lintResolver acl.Resolver = net.DefaultResolver
+
+// runLint 实现lint命令
+//
+// 加载每个策略文件并用acl.Lint检查常见的配置错误，适合在CI中运行。
+// 指定--resolve时改用acl.LintWithResolver，解析白名单中的域名检查IPv4和IPv6的结果是否一致。
+// 任一文件有错误级别的问题时退出码为1，指定--strict时警告同样导致失败。
+func runLint(args []string, stdin io.Reader, stdout, stderr io.Writer) int {
+	fs := flag.NewFlagSet("lint", flag.ContinueOnError)
+	fs.SetOutput(stderr)
+	format := fs.String("format", "text", "输出格式: text或json")
+	strict := fs.Bool("strict", false, "警告同样导致失败")
+	resolve := fs.Bool("resolve", false, "解析白名单中的域名，检查双栈结果是否一致")
+	timeout := fs.Duration("timeout", 30*time.Second, "--resolve时每个策略文件的解析超时")
+	fs.Usage = func() {
+		fmt.Fprintln(stderr, "用法: go-acl lint [--format text|json] [--strict] [--resolve [--timeout 30s]] 策略文件...")
+		fs.PrintDefaults()
+	}
+	if err := fs.Parse(args); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			return exitOK
+		}
+		return exitUsage
+	}
+	if fs.NArg() == 0 {
+		fs.Usage()
+		return exitUsage
+	}
+	if *format != "text" && *format != "json" {
+		fmt.Fprintf(stderr, "go-acl lint: 未知的格式 %q\n", *format)
+		return exitUsage
+	}
+
+	code := exitOK
+	enc := json.NewEncoder(stdout)
+	for _, path := range fs.Args() {
+		manager, err := acl.LoadPolicyFile(path)
+		if err != nil {
+			fmt.Fprintf(stderr, "go-acl lint: %s: 加载策略失败: %v\n", path, err)
+			code = exitError
+			continue
+		}
+
+		var report acl.LintReport
+		if *resolve {
+			ctx, cancel := context.WithTimeout(context.Background(), *timeout)
+			report = acl.LintWithResolver(ctx, manager, lintResolver)
+			cancel()
+		} else {
+			report = acl.Lint(manager)
+		}
+		passed := report.Passed() && (!*strict || len(report.Findings) == 0)
+		if !passed {
+			code = exitError
+		}
+		if *format == "json" {
+			err = enc.Encode(newLintOutput(path, passed, report))
+		} else {
+			_, err = fmt.Fprintf(stdout, "%s: %s", path, report)
+		}
+		if err != nil {
+			fmt.Fprintf(stderr, "go-acl lint: %v\n", err)
+			return exitError
+		}
+	}
+	return code
+}
+
+// newLintOutput 把检查报告转换为输出格式
+func newLintOutput(path string, passed bool, report acl.LintReport) lintOutput {
+	out := lintOutput{Policy: path, Passed: passed, Findings: []lintFinding{}}
+	for _, f := range report.Findings {
+		out.Findings = append(out.Findings, lintFinding{
+			Check:     f.Check,
+			Severity:  f.Severity.String(),
+			Component: f.Component,
+			Message:   f.Message,
+		})
+	}
+	return out
+}
@@ -0,0 +1,109 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net"
+	"strings"
+	"testing"
+)
+
+// TestLint 测试检查结果决定退出码
+func TestLint(t *testing.T) {
+	clean := writePolicy(t, `{"ip": {"type": "blacklist", "predefined": ["cloud_metadata"]}}`)
+	warning := writePolicy(t, `{"ip": {"type": "whitelist", "ranges": ["10.0.0.0/8"]}}`)
+	broken := writePolicy(t, `{"ip": {"type": "whitelist"}}`)
+
+	tests := []struct {
+		name string
+		args []string
+		code int
+		want string
+	}{
+		{"没有问题", []string{clean}, exitOK, "发现0个问题"},
+		{"只有警告", []string{warning}, exitOK, "warning [non_public_whitelist] ip_acl"},
+		{"严格模式下警告导致失败", []string{"--strict", warning}, exitError, "non_public_whitelist"},
+		{"有错误", []string{clean, broken}, exitError, "error [empty_whitelist] ip_acl"},
+		{"策略无法加载", []string{clean + ".missing"}, exitError, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var stdout, stderr bytes.Buffer
+			if code := run(append([]string{"lint"}, tt.args...), nil, &stdout, &stderr); code != tt.code {
+				t.Errorf("退出码 = %d, 期望 %d, stderr: %s", code, tt.code, stderr.String())
+			}
+			if !strings.Contains(stdout.String(), tt.want) {
+				t.Errorf("输出中缺少 %q:\n%s", tt.want, stdout.String())
+			}
+		})
+	}
+}
+
+// TestLintJSON 测试每个策略文件输出一行JSON
+func TestLintJSON(t *testing.T) {
+	clean := writePolicy(t, `{"ip": {"type": "blacklist"}}`)
+	broken := writePolicy(t, `{"ip": {"type": "whitelist"}}`)
+
+	var stdout, stderr bytes.Buffer
+	if code := run([]string{"lint", "--format", "json", clean, broken}, nil, &stdout, &stderr); code != exitError {
+		t.Fatalf("退出码 = %d, 期望 %d", code, exitError)
+	}
+
+	lines := strings.Split(strings.TrimSpace(stdout.String()), "\n")
+	if len(lines) != 2 {
+		t.Fatalf("输出 %d 行, 期望 2 行:\n%s", len(lines), stdout.String())
+	}
+	var first, second lintOutput
+	if err := json.Unmarshal([]byte(lines[0]), &first); err != nil {
+		t.Fatal(err)
+	}
+	if err := json.Unmarshal([]byte(lines[1]), &second); err != nil {
+		t.Fatal(err)
+	}
+	if first.Policy != clean || !first.Passed || len(first.Findings) != 0 {
+		t.Errorf("第1行 = %+v", first)
+	}
+	if second.Passed || len(second.Findings) != 1 || second.Findings[0].Severity != "error" || second.Findings[0].Check != "empty_whitelist" {
+		t.Errorf("第2行 = %+v", second)
+	}
+}
+
+// fixedResolver 返回预设解析结果的解析器
+type fixedResolver map[string][]string
+
+func (r fixedResolver) LookupIPAddr(ctx context.Context, host string) ([]net.IPAddr, error) {
+	var addrs []net.IPAddr
+	for _, s := range r[host] {
+		addrs = append(addrs, net.IPAddr{IP: net.ParseIP(s)})
+	}
+	if len(addrs) == 0 {
+		return nil, &net.DNSError{Err: "no such host", Name: host, IsNotFound: true}
+	}
+	return addrs, nil
+}
+
+// TestLintResolve 测试--resolve检查白名单域名的双栈结果
+func TestLintResolve(t *testing.T) {
+	saved := lintResolver
+	defer func() { lintResolver = saved }()
+	lintResolver = fixedResolver{"api.example.com": {"203.0.113.10", "2001:db8::10"}}
+
+	policy := writePolicy(t, `{
+		"ip": {"type": "blacklist", "ranges": ["2001:db8::/32"]},
+		"domain": {"type": "whitelist", "domains": ["api.example.com"]}
+	}`)
+
+	var stdout, stderr bytes.Buffer
+	if code := run([]string{"lint", policy}, nil, &stdout, &stderr); code != exitOK || strings.Contains(stdout.String(), "dual_stack_mismatch") {
+		t.Errorf("不指定--resolve时退出码 = %d, 输出:\n%s", code, stdout.String())
+	}
+
+	stdout.Reset()
+	if code := run([]string{"lint", "--resolve", "--strict", policy}, nil, &stdout, &stderr); code != exitError {
+		t.Errorf("--resolve --strict 退出码 = %d, 期望 %d", code, exitError)
+	}
+	if want := "warning [dual_stack_mismatch] domain_acl"; !strings.Contains(stdout.String(), want) {
+		t.Errorf("输出中缺少 %q:\n%s", want, stdout.String())
+	}
+}
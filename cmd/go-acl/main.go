@@ -0,0 +1,85 @@
+// Command go-acl 是go-acl的命令行工具
+//
+// 用法:
+//
+//	go-acl <命令> [参数]
+//
+// 命令:
+//
+//	explain 输出目标的求值过程：标准化步骤、依次查询的ACL和匹配的规则
+//	lint    检查策略文件中常见的配置错误，有错误时退出码为1
+//	sets    输出预定义IP集合的清单（Markdown或JSON），用于生成文档
+//	watch   从标准输入或参数读取目标，逐行输出JSON格式的检查结果
+//
+// 示例:
+//
+//	go-acl explain --policy policy.json https://169.254.169.254/latest
+//	go-acl lint policy.json
+//	tail -f access.log | awk '{print $7}' | go-acl watch --policy policy.json --stdin | jq 'select(.decision == "denied")'
+package main
+
+import (
+	"fmt"
+	"io"
+	"os"
+)
+
+// 退出码
+const (
+	exitOK    = 0 // 成功
+	exitError = 1 // 运行时错误，如策略文件无法加载；lint发现问题时同样使用此退出码
+	exitUsage = 2 // 命令行参数错误
+)
+
+// command 是一个子命令
+type command struct {
+	name    string
+	summary string
+	run     func(args []string, stdin io.Reader, stdout, stderr io.Writer) int
+}
+
+// commands 是所有子命令，按名称排序
+var commands = []command{
+	{"explain", "输出目标的求值过程：标准化步骤、依次查询的ACL和匹配的规则", runExplain},
+	{"lint", "检查策略文件中常见的配置错误，有错误时退出码为1", runLint},
+	{"sets", "输出预定义IP集合的清单（Markdown或JSON），用于生成文档", runSets},
+	{"watch", "从标准输入或参数读取目标，逐行输出JSON格式的检查结果", runWatch},
+}
+
+func main() {
+	os.Exit(run(os.Args[1:], os.Stdin, os.Stdout, os.Stderr))
+}
+
+// run 根据第一个参数分派子命令，返回退出码
+func run(args []string, stdin io.Reader, stdout, stderr io.Writer) int {
+	if len(args) == 0 {
+		usage(stderr)
+		return exitUsage
+	}
+	switch args[0] {
+	case "-h", "-help", "--help", "help":
+		usage(stdout)
+		return exitOK
+	}
+
+	for _, c := range commands {
+		if c.name == args[0] {
+			return c.run(args[1:], stdin, stdout, stderr)
+		}
+	}
+	fmt.Fprintf(stderr, "go-acl: 未知命令 %q\n\n", args[0])
+	usage(stderr)
+	return exitUsage
+}
+
+// usage 输出命令列表
+func usage(w io.Writer) {
+	fmt.Fprintln(w, "用法: go-acl <命令> [参数]")
+	fmt.Fprintln(w)
+	fmt.Fprintln(w, "命令:")
+	for _, c := range commands {
+		fmt.Fprintf(w, "  %-8s %s\n", c.name, c.summary)
+	}
+	fmt.Fprintln(w)
+	fmt.Fprintln(w, "使用 go-acl <命令> -h 查看命令的参数")
+}
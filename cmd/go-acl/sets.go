@@ -0,0 +1,103 @@
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/cyberspacesec/go-acl/pkg/ip"
+)
+
+// runSets 实现sets命令
+//
+// 输出ip.PredefinedSetsManifest()返回的预定义集合清单，用于生成文档。
+// 默认输出Markdown，--format json输出JSON数组；指定集合名称时只输出这些集合。
+func runSets(args []string, stdin io.Reader, stdout, stderr io.Writer) int {
+	fs := flag.NewFlagSet("sets", flag.ContinueOnError)
+	fs.SetOutput(stderr)
+	format := fs.String("format", "markdown", "输出格式: markdown或json")
+	fs.Usage = func() {
+		fmt.Fprintln(stderr, "用法: go-acl sets [--format markdown|json] [集合名称...]")
+		fs.PrintDefaults()
+	}
+	if err := fs.Parse(args); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			return exitOK
+		}
+		return exitUsage
+	}
+	if *format != "markdown" && *format != "json" {
+		fmt.Fprintf(stderr, "go-acl sets: 未知的格式 %q\n", *format)
+		return exitUsage
+	}
+
+	manifest := ip.PredefinedSetsManifest()
+	if fs.NArg() > 0 {
+		var selected []ip.PredefinedSetInfo
+		for _, name := range fs.Args() {
+			set, ok := findSet(manifest, ip.PredefinedSet(name))
+			if !ok {
+				fmt.Fprintf(stderr, "go-acl sets: 未知的集合 %q\n", name)
+				return exitUsage
+			}
+			selected = append(selected, set)
+		}
+		manifest = selected
+	}
+
+	var err error
+	if *format == "json" {
+		enc := json.NewEncoder(stdout)
+		enc.SetIndent("", "  ")
+		err = enc.Encode(manifest)
+	} else {
+		err = writeSetsMarkdown(stdout, manifest)
+	}
+	if err != nil {
+		fmt.Fprintf(stderr, "go-acl sets: %v\n", err)
+		return exitError
+	}
+	return exitOK
+}
+
+// findSet 返回清单中指定名称的集合
+func findSet(manifest []ip.PredefinedSetInfo, name ip.PredefinedSet) (ip.PredefinedSetInfo, bool) {
+	for _, set := range manifest {
+		if set.Name == name {
+			return set, true
+		}
+	}
+	return ip.PredefinedSetInfo{}, false
+}
+
+// writeSetsMarkdown 以Markdown格式输出集合清单，每个集合一节，IP范围为一个表格
+func writeSetsMarkdown(w io.Writer, manifest []ip.PredefinedSetInfo) error {
+	bw := bufio.NewWriter(w)
+	for i, set := range manifest {
+		if i > 0 {
+			fmt.Fprintln(bw)
+		}
+		fmt.Fprintf(bw, "## %s\n\n", set.Name)
+		if set.Description != "" {
+			fmt.Fprintf(bw, "%s\n\n", set.Description)
+		}
+		if len(set.References) > 0 {
+			fmt.Fprintf(bw, "参考: %s\n\n", strings.Join(set.References, ", "))
+		}
+		fmt.Fprintln(bw, "| 范围 | 说明 | 参考 |")
+		fmt.Fprintln(bw, "| --- | --- | --- |")
+		for _, r := range set.Ranges {
+			fmt.Fprintf(bw, "| `%s` | %s | %s |\n", r.CIDR, markdownCell(r.Description), markdownCell(r.Reference))
+		}
+	}
+	return bw.Flush()
+}
+
+// markdownCell 转义表格单元格中的竖线
+func markdownCell(s string) string {
+	return strings.ReplaceAll(s, "|", `\|`)
+}
@@ -0,0 +1,64 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/cyberspacesec/go-acl/pkg/ip"
+)
+
+// TestSetsMarkdown 测试以Markdown格式输出预定义集合
+func TestSetsMarkdown(t *testing.T) {
+	var stdout, stderr bytes.Buffer
+	if code := run([]string{"sets", "private_networks", "cloud_metadata"}, nil, &stdout, &stderr); code != exitOK {
+		t.Fatalf("退出码 = %d, stderr: %s", code, stderr.String())
+	}
+
+	out := stdout.String()
+	for _, want := range []string{
+		"## private_networks\n",
+		"参考: RFC 1918\n",
+		"| `10.0.0.0/8` | 10.0.0.0 - 10.255.255.255 | RFC 1918 |\n",
+		"## cloud_metadata\n",
+		"| `169.254.169.254/32` | AWS/GCP/OpenStack 元数据服务 |  |\n",
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("输出中缺少 %q:\n%s", want, out)
+		}
+	}
+	if strings.Contains(out, "## public_dns") {
+		t.Error("指定集合名称时不应输出其他集合")
+	}
+}
+
+// TestSetsJSON 测试以JSON格式输出全部预定义集合
+func TestSetsJSON(t *testing.T) {
+	var stdout, stderr bytes.Buffer
+	if code := run([]string{"sets", "--format", "json"}, nil, &stdout, &stderr); code != exitOK {
+		t.Fatalf("退出码 = %d, stderr: %s", code, stderr.String())
+	}
+
+	var manifest []ip.PredefinedSetInfo
+	if err := json.Unmarshal(stdout.Bytes(), &manifest); err != nil {
+		t.Fatalf("输出不是有效的JSON: %v", err)
+	}
+	if len(manifest) != len(ip.PredefinedSetsManifest()) {
+		t.Errorf("输出了%d个集合, 期望%d个", len(manifest), len(ip.PredefinedSetsManifest()))
+	}
+}
+
+// TestSetsUsage 测试无效的参数
+func TestSetsUsage(t *testing.T) {
+	tests := [][]string{
+		{"sets", "--format", "yaml"},
+		{"sets", "no_such_set"},
+	}
+	for _, args := range tests {
+		var stdout, stderr bytes.Buffer
+		if code := run(args, nil, &stdout, &stderr); code != exitUsage {
+			t.Errorf("run(%v) 退出码 = %d, 期望 %d", args, code, exitUsage)
+		}
+	}
+}
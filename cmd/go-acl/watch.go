@@ -0,0 +1,114 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/cyberspacesec/go-acl/pkg/acl"
+	"github.com/cyberspacesec/go-acl/pkg/types"
+)
+
+// watchDecision 是watch输出的一行JSON
+//
+// 字段与types.CheckResult相同，Decision使用"allowed"/"denied"字符串，
+// 检查失败时Error为错误信息、Decision为"denied"。
+type watchDecision struct {
+	Target    string   `json:"target"`
+	Kind      string   `json:"kind,omitempty"`
+	Decision  string   `json:"decision"`
+	RuleID    string   `json:"rule_id,omitempty"`
+	Matches   []string `json:"matches,omitempty"`
+	Source    string   `json:"source,omitempty"`
+	Reason    string   `json:"reason,omitempty"`
+	LatencyNS int64    `json:"latency_ns"`
+	Error     string   `json:"error,omitempty"`
+}
+
+// runWatch 实现watch命令
+//
+// 依次检查命令行参数中的目标，指定--stdin时再逐行读取标准输入，每检查一个目标输出一行JSON。
+// 目标可以是IP、域名或URL，按Manager.CheckHost的规则检查。标准输入中的空行和#开头的行被忽略。
+func runWatch(args []string, stdin io.Reader, stdout, stderr io.Writer) int {
+	fs := flag.NewFlagSet("watch", flag.ContinueOnError)
+	fs.SetOutput(stderr)
+	policyPath := fs.String("policy", "", "JSON策略文件（必需），格式见acl.Policy")
+	fromStdin := fs.Bool("stdin", false, "从标准输入读取目标，每行一个")
+	fs.Usage = func() {
+		fmt.Fprintln(stderr, "用法: go-acl watch --policy policy.json [--stdin] [目标...]")
+		fs.PrintDefaults()
+	}
+	if err := fs.Parse(args); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			return exitOK
+		}
+		return exitUsage
+	}
+	if *policyPath == "" || (!*fromStdin && fs.NArg() == 0) {
+		fs.Usage()
+		return exitUsage
+	}
+
+	manager, err := acl.LoadPolicyFile(*policyPath)
+	if err != nil {
+		fmt.Fprintf(stderr, "go-acl watch: 加载策略失败: %v\n", err)
+		return exitError
+	}
+
+	ctx := context.Background()
+	enc := json.NewEncoder(stdout)
+	check := func(target string) error {
+		result, err := manager.CheckHostDetailed(ctx, target)
+		return enc.Encode(newWatchDecision(target, result, err))
+	}
+
+	for _, target := range fs.Args() {
+		if err := check(target); err != nil {
+			fmt.Fprintf(stderr, "go-acl watch: %v\n", err)
+			return exitError
+		}
+	}
+	if !*fromStdin {
+		return exitOK
+	}
+
+	scanner := bufio.NewScanner(stdin)
+	for scanner.Scan() {
+		target := strings.TrimSpace(scanner.Text())
+		if target == "" || strings.HasPrefix(target, "#") {
+			continue
+		}
+		if err := check(target); err != nil {
+			fmt.Fprintf(stderr, "go-acl watch: %v\n", err)
+			return exitError
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		fmt.Fprintf(stderr, "go-acl watch: 读取标准输入失败: %v\n", err)
+		return exitError
+	}
+	return exitOK
+}
+
+// newWatchDecision 把检查结果转换为输出格式，Target使用输入的原文
+func newWatchDecision(target string, result types.CheckResult, err error) watchDecision {
+	d := watchDecision{
+		Target:    target,
+		Kind:      result.Kind,
+		Decision:  result.Decision.String(),
+		RuleID:    result.RuleID,
+		Matches:   result.Matches,
+		Source:    result.Source,
+		Reason:    result.Reason.String(),
+		LatencyNS: result.Latency.Nanoseconds(),
+	}
+	if err != nil {
+		d.Error = err.Error()
+	}
+	return d
+}
@@ -0,0 +1,95 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// writePolicy 在临时目录中写入策略文件并返回路径
+func writePolicy(t *testing.T, data string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "policy.json")
+	if err := os.WriteFile(path, []byte(data), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+// TestWatch 测试从标准输入读取目标并逐行输出检查结果
+func TestWatch(t *testing.T) {
+	policy := writePolicy(t, `{
+		"ip": {"type": "blacklist", "predefined": ["cloud_metadata"]},
+		"domain": {"type": "blacklist", "domains": ["ads.example.com"], "include_subdomains": true}
+	}`)
+	stdin := strings.NewReader("# 注释\nhttp://169.254.169.254/latest\n\ntracker.ads.example.com\nexample.org\n")
+
+	var stdout, stderr bytes.Buffer
+	if code := run([]string{"watch", "--policy", policy, "--stdin"}, stdin, &stdout, &stderr); code != exitOK {
+		t.Fatalf("退出码 = %d, stderr: %s", code, stderr.String())
+	}
+
+	tests := []struct {
+		target   string
+		kind     string
+		decision string
+		source   string
+		reason   string
+	}{
+		{"http://169.254.169.254/latest", "ip", "denied", "ip_acl", "matched_blacklist_ip"},
+		{"tracker.ads.example.com", "domain", "denied", "domain_acl", "matched_blacklist_domain"},
+		{"example.org", "domain", "allowed", "domain_acl", ""},
+	}
+	lines := strings.Split(strings.TrimSpace(stdout.String()), "\n")
+	if len(lines) != len(tests) {
+		t.Fatalf("输出 %d 行, 期望 %d 行:\n%s", len(lines), len(tests), stdout.String())
+	}
+	for i, tt := range tests {
+		var d watchDecision
+		if err := json.Unmarshal([]byte(lines[i]), &d); err != nil {
+			t.Fatalf("第%d行不是JSON: %v", i+1, err)
+		}
+		if d.Target != tt.target || d.Kind != tt.kind || d.Decision != tt.decision || d.Source != tt.source || d.Reason != tt.reason || d.Error != "" {
+			t.Errorf("第%d行 = %+v", i+1, d)
+		}
+	}
+}
+
+// TestWatchErrors 测试参数错误、策略错误和检查错误
+func TestWatchErrors(t *testing.T) {
+	policy := writePolicy(t, `{"domain": {"type": "blacklist", "domains": ["example.com"]}}`)
+
+	tests := []struct {
+		name string
+		args []string
+		code int
+	}{
+		{"缺少命令", nil, exitUsage},
+		{"未知命令", []string{"serve"}, exitUsage},
+		{"缺少策略", []string{"watch", "--stdin"}, exitUsage},
+		{"缺少目标", []string{"watch", "--policy", policy}, exitUsage},
+		{"策略不存在", []string{"watch", "--policy", policy + ".missing", "example.com"}, exitError},
+		{"帮助", []string{"help"}, exitOK},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var stdout, stderr bytes.Buffer
+			if code := run(tt.args, strings.NewReader(""), &stdout, &stderr); code != tt.code {
+				t.Errorf("退出码 = %d, 期望 %d", code, tt.code)
+			}
+		})
+	}
+
+	// 检查错误写入结果行，不中断输出
+	var stdout, stderr bytes.Buffer
+	if code := run([]string{"watch", "--policy", policy, "10.0.0.1", "example.com"}, nil, &stdout, &stderr); code != exitOK {
+		t.Fatalf("退出码 = %d, stderr: %s", code, stderr.String())
+	}
+	lines := strings.Split(strings.TrimSpace(stdout.String()), "\n")
+	if len(lines) != 2 || !strings.Contains(lines[0], `"error":"no ACL configured"`) || !strings.Contains(lines[1], `"decision":"denied"`) {
+		t.Errorf("输出:\n%s", stdout.String())
+	}
+}
@@ -0,0 +1,55 @@
+// Package ebpfmap 提供创建和打开xdp.Exporter所用eBPF映射的辅助函数
+//
+// 这些函数依赖github.com/cilium/ebpf，因此位于独立的模块中，使go-acl的核心模块不依赖任何第三方库。
+// 返回的*ebpf.Map实现了xdp.Map，可以直接传给xdp.NewExporter:
+//
+//	m, err := ebpfmap.LoadPinnedMap("/sys/fs/bpf/xdp_deny")
+//	if err != nil {
+//	    log.Fatal(err)
+//	}
+//	exporter := xdp.NewExporter(manager, m)
+//	go exporter.Run(ctx, 5*time.Minute)
+package ebpfmap
+
+import (
+	"github.com/cilium/ebpf"
+	"github.com/cyberspacesec/go-acl/pkg/xdp"
+)
+
+// bpfNoPrealloc 是BPF_F_NO_PREALLOC，内核要求LPM trie映射设置此标志
+const bpfNoPrealloc = 1
+
+// 确保*ebpf.Map实现xdp.Map
+var _ xdp.Map = (*ebpf.Map)(nil)
+
+// NewLPMTrieMap 创建适合xdp.Exporter的LPM trie映射
+//
+// 参数:
+//   - name: 映射名称，内核截断为15个字符
+//   - maxEntries: 最多容纳的前缀数量，超出后写入失败
+//
+// 返回:
+//   - *ebpf.Map: 新建的映射，由调用方Pin到bpffs或传给加载XDP程序的代码
+//   - error: 创建失败（如权限不足）时返回的错误
+func NewLPMTrieMap(name string, maxEntries uint32) (*ebpf.Map, error) {
+	return ebpf.NewMap(&ebpf.MapSpec{
+		Name:       name,
+		Type:       ebpf.LPMTrie,
+		KeySize:    20,
+		ValueSize:  4,
+		MaxEntries: maxEntries,
+		Flags:      bpfNoPrealloc,
+	})
+}
+
+// LoadPinnedMap 打开XDP程序固定在bpffs中的映射
+//
+// 参数:
+//   - path: 映射在bpffs中的路径，如"/sys/fs/bpf/xdp_deny"
+//
+// 返回:
+//   - *ebpf.Map: 打开的映射
+//   - error: 映射不存在或无权访问时返回的错误
+func LoadPinnedMap(path string) (*ebpf.Map, error) {
+	return ebpf.LoadPinnedMap(path, nil)
+}
@@ -0,0 +1,12 @@
+module github.com/cyberspacesec/go-acl/ebpfmap
+
+go 1.25.0
+
+require (
+	github.com/cilium/ebpf v0.22.0
+	github.com/cyberspacesec/go-acl v0.0.0
+)
+
+require golang.org/x/sys v0.43.0 // indirect
+
+replace github.com/cyberspacesec/go-acl => ../
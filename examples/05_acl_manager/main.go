@@ -354,40 +354,22 @@ func example3(manager *acl.Manager, tmpDir string) {
 func displayManagerConfig(manager *acl.Manager) {
 	fmt.Println("\n当前ACL管理器配置:")
 
-	// 显示域名ACL信息
-	domainListType, err := manager.GetDomainACLType()
+	// 显示域名ACL信息，DomainConfig一次返回规则、类型和选项
+	domainConfig, err := manager.DomainConfig()
 	if err != nil {
 		if errors.Is(err, types.ErrNoACL) {
 			fmt.Println("  域名ACL: 未配置")
 		} else {
-			fmt.Printf("  域名ACL: 获取类型失败 - %v\n", err)
+			fmt.Printf("  域名ACL: 获取失败 - %v\n", err)
 		}
 	} else {
-		var typeStr string
-		if domainListType == types.Blacklist {
-			typeStr = "黑名单"
-		} else {
-			typeStr = "白名单"
-		}
-		domains := manager.GetDomains()
-		fmt.Printf("  域名ACL: %s (包含 %d 个域名)\n", typeStr, len(domains))
-		if len(domains) > 0 {
-			fmt.Println("  域名列表:")
-			maxShow := 5
-			if len(domains) < maxShow {
-				maxShow = len(domains)
-			}
-			for i := 0; i < maxShow; i++ {
-				fmt.Printf("    %d. %s\n", i+1, domains[i])
-			}
-			if len(domains) > maxShow {
-				fmt.Printf("    ...以及其他 %d 个域名\n", len(domains)-maxShow)
-			}
-		}
+		fmt.Printf("  域名ACL: %s (包含 %d 个域名, 包含子域名: %v)\n",
+			listTypeName(domainConfig.ListType), len(domainConfig.Entries), domainConfig.IncludeSubdomains)
+		printEntries("域名列表", domainConfig.Entries, "个域名")
 	}
 
 	// 显示IP ACL信息
-	ipAclType, err := manager.GetIPACLType()
+	ipConfig, err := manager.IPConfig()
 	if err != nil {
 		if errors.Is(err, types.ErrNoACL) {
 			fmt.Println("  IP ACL: 未配置")
@@ -395,27 +377,34 @@ func displayManagerConfig(manager *acl.Manager) {
 			fmt.Printf("  IP ACL: 获取失败 - %v\n", err)
 		}
 	} else {
-		var typeStr string
-		if ipAclType == types.Blacklist {
-			typeStr = "黑名单"
-		} else {
-			typeStr = "白名单"
-		}
-		ipRanges := manager.GetIPRanges()
-		fmt.Printf("  IP ACL: %s (包含 %d 个IP/CIDR)\n", typeStr, len(ipRanges))
-		if len(ipRanges) > 0 {
-			fmt.Println("  IP列表:")
-			maxShow := 5
-			if len(ipRanges) < maxShow {
-				maxShow = len(ipRanges)
-			}
-			for i := 0; i < maxShow; i++ {
-				fmt.Printf("    %d. %s\n", i+1, ipRanges[i])
-			}
-			if len(ipRanges) > maxShow {
-				fmt.Printf("    ...以及其他 %d 个IP/CIDR\n", len(ipRanges)-maxShow)
-			}
-		}
+		fmt.Printf("  IP ACL: %s (包含 %d 个IP/CIDR)\n", listTypeName(ipConfig.ListType), len(ipConfig.Entries))
+		printEntries("IP列表", ipConfig.Entries, "个IP/CIDR")
+	}
+}
+
+// 辅助函数：返回列表类型的中文名称
+func listTypeName(listType types.ListType) string {
+	if listType == types.Blacklist {
+		return "黑名单"
+	}
+	return "白名单"
+}
+
+// 辅助函数：显示列表中的前几个条目
+func printEntries(title string, entries []string, unit string) {
+	if len(entries) == 0 {
+		return
+	}
+	fmt.Printf("  %s:\n", title)
+	maxShow := 5
+	if len(entries) < maxShow {
+		maxShow = len(entries)
+	}
+	for i := 0; i < maxShow; i++ {
+		fmt.Printf("    %d. %s\n", i+1, entries[i])
+	}
+	if len(entries) > maxShow {
+		fmt.Printf("    ...以及其他 %d %s\n", len(entries)-maxShow, unit)
 	}
 }
 
@@ -1,6 +1,7 @@
 package main
 
 import (
+	"context"
 	"errors"
 	"fmt"
 	"os"
@@ -91,22 +92,9 @@ func (app *WebApp) InitAccessControl() {
 	}
 	app.AccessController.SetDomainACL(domainBlacklist, types.Blacklist, true)
 
-	// 保存黑名单到文件
-	domainsFile := filepath.Join(app.ConfigDir, "domain_blacklist.txt")
-	content := "# 域名黑名单\n# 以下域名及其子域名将被阻止\n\n"
-	for _, domain := range domainBlacklist {
-		content += domain + "\n"
-	}
-	err := os.WriteFile(domainsFile, []byte(content), 0644)
-	if err != nil {
-		fmt.Printf("保存域名黑名单失败: %v\n", err)
-	} else {
-		fmt.Printf("域名黑名单已保存到: %s\n", domainsFile)
-	}
-
 	// 2. 创建IP安全配置（防止SSRF攻击）
 	fmt.Println("- 创建IP访问控制")
-	err = app.AccessController.SetIPACLWithDefaults(
+	err := app.AccessController.SetIPACLWithDefaults(
 		[]string{}, // 没有额外自定义IP
 		types.Blacklist,
 		[]ip.PredefinedSet{
@@ -137,6 +125,15 @@ func (app *WebApp) InitAccessControl() {
 		fmt.Printf("IP黑名单已保存到: %s\n", ipFile)
 	}
 
+	// 域名和IP列表（包括列表类型和是否包含子域名）保存在同一个配置文件中
+	configFile := filepath.Join(app.ConfigDir, "acl.json")
+	err = app.AccessController.SaveConfig(configFile, acl.ConfigJSON)
+	if err != nil {
+		fmt.Printf("保存访问控制配置失败: %v\n", err)
+	} else {
+		fmt.Printf("访问控制配置已保存到: %s\n", configFile)
+	}
+
 	// 显示初始配置
 	app.PrintAccessControlConfig()
 	app.LastConfigChanged = time.Now()
@@ -213,30 +210,24 @@ func (app *WebApp) ProcessRequest(req *Request) bool {
 	var allowed bool
 	var reason string
 
-	// 提取域名或IP
-	host := extractHost(req.URL)
-
-	// 检查域名
-	domainPerm, domainErr := app.AccessController.CheckDomain(host)
-	if domainErr == nil && domainPerm == types.Denied {
+	// 检查目标URL: 主机是IP时检查IP ACL，是域名时检查域名ACL
+	result, err := app.AccessController.CheckURLDetailed(context.Background(), req.URL)
+	if err == nil && result.Decision == types.Denied {
 		allowed = false
-		reason = "域名黑名单"
+		if strings.HasPrefix(result.Target, "ip=") {
+			reason = "IP黑名单"
+		} else {
+			reason = "域名黑名单"
+		}
 	} else {
-		// 检查目标IP (如果host是IP地址)
-		ipPerm, ipErr := app.AccessController.CheckIP(host)
-		if ipErr == nil && ipPerm == types.Denied {
+		// 检查客户端IP
+		clientPerm, clientErr := app.AccessController.CheckIP(req.ClientIP)
+		if clientErr == nil && clientPerm == types.Denied {
 			allowed = false
-			reason = "IP黑名单"
+			reason = "客户端IP黑名单"
 		} else {
-			// 检查客户端IP
-			clientPerm, clientErr := app.AccessController.CheckIP(req.ClientIP)
-			if clientErr == nil && clientPerm == types.Denied {
-				allowed = false
-				reason = "客户端IP黑名单"
-			} else {
-				allowed = true
-				reason = "无限制"
-			}
+			allowed = true
+			reason = "无限制"
 		}
 	}
 
@@ -393,15 +384,10 @@ func (app *WebApp) LoadSecurityConfig() {
 		fmt.Printf("保存IP白名单失败: %v\n", err)
 	}
 
-	// 将白名单域名写入文件
-	domainsFile := filepath.Join(securityConfigDir, "domain_whitelist.txt")
-	content := "# 域名白名单\n# 仅允许以下域名及其子域名\n\n"
-	for _, domain := range trustedDomains {
-		content += domain + "\n"
-	}
-	err = os.WriteFile(domainsFile, []byte(content), 0644)
+	// 保存包含域名白名单的完整配置
+	err = app.AccessController.SaveConfig(filepath.Join(securityConfigDir, "acl.json"), acl.ConfigJSON)
 	if err != nil {
-		fmt.Printf("保存域名白名单失败: %v\n", err)
+		fmt.Printf("保存访问控制配置失败: %v\n", err)
 	}
 
 	app.LastConfigChanged = time.Now()
@@ -485,34 +471,6 @@ func (app *WebApp) ShowAccessLogSummary() {
 	}
 }
 
-// 提取URL中的主机部分（域名或IP）
-func extractHost(url string) string {
-	// 移除协议前缀
-	url = strings.TrimSpace(url)
-	if strings.HasPrefix(url, "http://") {
-		url = url[7:]
-	} else if strings.HasPrefix(url, "https://") {
-		url = url[8:]
-	} else if strings.HasPrefix(url, "//") {
-		url = url[2:]
-	}
-
-	// 移除路径部分
-	if pathIndex := strings.Index(url, "/"); pathIndex != -1 {
-		url = url[:pathIndex]
-	}
-
-	// 移除端口号
-	if portIndex := strings.LastIndex(url, ":"); portIndex != -1 {
-		// 确保不是IPv6地址的一部分
-		if !strings.Contains(url[:portIndex], "]") {
-			url = url[:portIndex]
-		}
-	}
-
-	return url
-}
-
 /*
 预期输出:
 
@@ -522,9 +480,9 @@ func extractHost(url string) string {
 
 1. 初始化访问控制系统
 - 创建域名黑名单
-域名黑名单已保存到: app_config/domain_blacklist.txt
 - 创建IP访问控制
 IP黑名单已保存到: app_config/ip_blacklist.txt
+访问控制配置已保存到: app_config/acl.json
 
 当前访问控制配置:
 域名 黑名单: 包含 4 个域名
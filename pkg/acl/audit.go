@@ -0,0 +1,319 @@
+package acl
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/cyberspacesec/go-acl/pkg/types"
+)
+
+// requestIDKey 是WithRequestID使用的默认上下文键类型
+type requestIDKey struct{}
+
+// DefaultRequestIDKey 是默认的请求ID上下文键
+// 可通过Manager.SetRequestIDKey替换为应用自身使用的键
+var DefaultRequestIDKey interface{} = requestIDKey{}
+
+// AuditEvent 表示一次访问检查的审计事件
+//
+// 字段说明:
+//   - Time: 检查发生的时间
+//   - Kind: 检查类型，"ip"、"domain"，或"rule"（带log的规则表达式匹配了请求）
+//   - Target: 被检查的IP或域名；Kind为"rule"时为请求的描述，如"ip=10.0.0.1 port=80"
+//   - Permission: 检查结果；Kind为"rule"时为CheckRequest的最终结果
+//   - Reason: 拒绝或出错的原因，允许访问时为空，见types.Reason
+//   - Error: 检查过程中的错误信息，无错误时为空
+//   - RequestID: 从上下文中提取的请求ID/关联ID，用于与应用的调用链关联
+//   - Rule: Kind为"rule"时匹配的规则原文
+//   - Override: 结果由紧急模式直接得出时为模式名称（"deny_all"或"allow_all"），见SetOverrideMode，否则为空
+//   - WouldDeny: 结果由开发模式从拒绝改为允许时为本应拒绝的原因，见SetDevMode，否则为空
+//   - Scope: 结果由上下文中的临时例外或子视图的规则得出时为其Source（"scoped"、"scoped:名称"或"scope:名称"），
+//     见WithScopedPolicy和Manager.Scope，否则为空
+//   - Input、Normalized、Transforms: 启用SetNormalizationTrace且输入在检查前被改变时，
+//     分别为原始输入、实际检查的值和依次执行的变换（如"lowercase"、"strip_www"、"canonicalize_ip"），否则为空
+//   - Suppressed: 汇总事件中被限流抑制的事件数量，见SetErrorAuditThrottle；普通事件为0
+type AuditEvent struct {
+	Time       time.Time        `json:"time"`
+	Kind       string           `json:"kind"`
+	Target     string           `json:"target"`
+	Permission types.Permission `json:"permission"`
+	Reason     types.Reason     `json:"reason,omitempty"`
+	Error      string           `json:"error,omitempty"`
+	RequestID  string           `json:"request_id,omitempty"`
+	Rule       string           `json:"rule,omitempty"`
+	Override   string           `json:"override,omitempty"`
+	WouldDeny  types.Reason     `json:"would_deny,omitempty"`
+	Scope      string           `json:"scope,omitempty"`
+	Input      string           `json:"input,omitempty"`
+	Normalized string           `json:"normalized,omitempty"`
+	Transforms []string         `json:"transforms,omitempty"`
+	Suppressed uint64           `json:"suppressed,omitempty"`
+}
+
+// AuditHook 是审计事件的处理函数
+// 在检查方法返回前被同步调用，实现中不应执行耗时操作
+type AuditHook func(event AuditEvent)
+
+// WithRequestID 返回携带请求ID的上下文，使用DefaultRequestIDKey作为键
+//
+// 参数:
+//   - ctx: 父上下文
+//   - id: 请求ID或关联ID
+//
+// 返回:
+//   - context.Context: 携带请求ID的新上下文
+//
+// 示例:
+//
+//	ctx := acl.WithRequestID(r.Context(), r.Header.Get("X-Request-ID"))
+//	perm, err := manager.CheckIPContext(ctx, clientIP)
+func WithRequestID(ctx context.Context, id string) context.Context {
+	return context.WithValue(ctx, DefaultRequestIDKey, id)
+}
+
+// SetAuditHook 设置审计事件处理函数
+//
+// 参数:
+//   - hook: 审计事件处理函数，传入nil表示关闭审计
+//
+// 设置后，每次CheckIP、CheckDomain及其Context版本的检查都会产生一个AuditEvent。
+//
+// 示例:
+//
+//	manager.SetAuditHook(func(e acl.AuditEvent) {
+//	    log.Printf("request_id=%s kind=%s target=%s permission=%s",
+//	        e.RequestID, e.Kind, e.Target, e.Permission)
+//	})
+func (m *Manager) SetAuditHook(hook AuditHook) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.auditHook = hook
+}
+
+// SetRequestIDKey 设置从上下文中提取请求ID时使用的键
+//
+// 参数:
+//   - key: 上下文键，传入nil时恢复为DefaultRequestIDKey
+//
+// 应用通常已经在自己的中间件中把请求ID放入上下文，
+// 通过此方法可以直接复用应用的键，无需再调用WithRequestID。
+// 上下文中的值可以是string或fmt.Stringer。
+//
+// 示例:
+//
+//	type ctxKey string
+//	manager.SetRequestIDKey(ctxKey("trace_id"))
+func (m *Manager) SetRequestIDKey(key interface{}) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.requestIDKey = key
+}
+
+// CheckIPContext 检查IP是否允许访问，并将上下文中的请求ID写入审计事件
+//
+// 参数:
+//   - ctx: 请求上下文，可携带请求ID
+//   - ip: 要检查的IP地址
+//
+// 返回:
+//   - types.Permission: 访问权限结果
+//   - error: 与CheckIP相同的错误
+//
+// 示例:
+//
+//	ctx := acl.WithRequestID(context.Background(), "req-123")
+//	perm, err := manager.CheckIPContext(ctx, "8.8.8.8")
+func (m *Manager) CheckIPContext(ctx context.Context, ip string) (types.Permission, error) {
+	result, err := m.checkIPContext(ctx, ip, false)
+	return result.Decision, err
+}
+
+// CheckDomainContext 检查域名是否允许访问，并将上下文中的请求ID写入审计事件
+//
+// 参数:
+//   - ctx: 请求上下文，可携带请求ID
+//   - domain: 要检查的域名
+//
+// 返回:
+//   - types.Permission: 访问权限结果
+//   - error: 与CheckDomain相同的错误
+func (m *Manager) CheckDomainContext(ctx context.Context, domain string) (types.Permission, error) {
+	result, err := m.checkDomainContext(ctx, domain, false)
+	return result.Decision, err
+}
+
+// checkIPContext 检查IP并更新统计、产生审计事件，detailed的含义见resolveIP
+func (m *Manager) checkIPContext(ctx context.Context, ip string, detailed bool) (types.CheckResult, error) {
+	ctx, cancel, budget := m.budgetContext(ctx)
+	defer cancel()
+	result, decided := m.overrideResult(ip, "ip")
+	if !decided {
+		result, decided = scopedResult(ctx, ip, "ip")
+	}
+	var err error
+	if !decided {
+		result, err = m.resolveIP(ctx, ip, detailed)
+		if err == nil {
+			result = m.devModeResult(ip, result)
+		}
+	}
+	if err != nil {
+		result.Reason = errorReason(err)
+	}
+	result, err, auditErr := m.applyBudget(budget, result, err)
+	m.stats.record(true, result.Decision, err)
+	m.audit(ctx, "ip", ip, result, auditErr)
+	if result.Source == "dev_mode" {
+		result.Reason = ""
+	}
+	return result, err
+}
+
+// checkDomainContext 检查域名并更新统计、产生审计事件，detailed的含义见resolveDomain
+func (m *Manager) checkDomainContext(ctx context.Context, domain string, detailed bool) (types.CheckResult, error) {
+	ctx, cancel, budget := m.budgetContext(ctx)
+	defer cancel()
+	result, decided := m.overrideResult(domain, "domain")
+	if !decided {
+		result, decided = scopedResult(ctx, domain, "domain")
+	}
+	var err error
+	if !decided {
+		result, err = m.resolveDomain(ctx, domain, detailed)
+	}
+	if err != nil {
+		result.Reason = errorReason(err)
+	}
+	result, err, auditErr := m.applyBudget(budget, result, err)
+	m.stats.record(false, result.Decision, err)
+	m.audit(ctx, "domain", domain, result, auditErr)
+	return result, err
+}
+
+// audit 构造审计事件并调用审计处理函数，未设置处理函数时不做任何事
+func (m *Manager) audit(ctx context.Context, kind, target string, result types.CheckResult, err error) {
+	m.auditRule(ctx, kind, target, "", result, err)
+}
+
+// auditRule 与audit相同，并在事件中记录匹配的规则
+func (m *Manager) auditRule(ctx context.Context, kind, target, rule string, result types.CheckResult, err error) {
+	m.mu.RLock()
+	hook := m.auditHook
+	key := m.requestIDKey
+	trace := m.traceNormalization
+	throttle := m.errorThrottle
+	now := m.now()
+	m.mu.RUnlock()
+
+	if hook == nil {
+		return
+	}
+	if key == nil {
+		key = DefaultRequestIDKey
+	}
+
+	event := AuditEvent{
+		Time:       now,
+		Kind:       kind,
+		Target:     target,
+		Permission: result.Decision,
+		Reason:     result.Reason,
+		RequestID:  requestIDFromContext(ctx, key),
+		Rule:       rule,
+	}
+	if err != nil {
+		event.Error = err.Error()
+	}
+	if result.Source == "override" {
+		// 按结果而不是当前模式填写，检查期间模式被切换时仍与结果一致
+		event.Override = OverrideAllowAll.String()
+		if result.Decision == types.Denied {
+			event.Override = OverrideDenyAll.String()
+		}
+	}
+	if result.Source == "dev_mode" {
+		event.Reason, event.WouldDeny = "", result.Reason
+	}
+	if strings.HasPrefix(result.Source, "scoped") || strings.HasPrefix(result.Source, "scope:") {
+		event.Scope = result.Source
+	}
+	if trace {
+		event.Input, event.Normalized, event.Transforms = traceNormalization(ctx, kind, target)
+	}
+	if throttle != nil && event.Error != "" {
+		summaries, ok := throttle.admit(event)
+		for _, summary := range summaries {
+			hook(summary)
+		}
+		if !ok {
+			return
+		}
+	}
+	hook(event)
+}
+
+// requestIDFromContext 从上下文中提取请求ID，值为string或fmt.Stringer时有效
+func requestIDFromContext(ctx context.Context, key interface{}) string {
+	if ctx == nil {
+		return ""
+	}
+	switch v := ctx.Value(key).(type) {
+	case string:
+		return v
+	case fmt.Stringer:
+		return v.String()
+	}
+	return ""
+}
+
+// DenyWebhook 返回一个将拒绝事件以JSON形式POST到指定URL的审计处理函数
+//
+// 参数:
+//   - url: Webhook地址
+//   - client: 发送请求使用的HTTP客户端，为nil时使用http.DefaultClient
+//   - onError: 发送失败时的回调，可为nil
+//
+// 返回:
+//   - AuditHook: 审计处理函数，只处理Permission为types.Denied的事件
+//
+// 请求在独立的goroutine中异步发送，不会阻塞访问检查。
+// 请求体为AuditEvent的JSON编码，包含request_id字段，便于与调用链关联。
+//
+// 示例:
+//
+//	manager.SetAuditHook(acl.DenyWebhook("https://alerts.example.com/acl", nil, func(err error) {
+//	    log.Printf("发送拒绝通知失败: %v", err)
+//	}))
+func DenyWebhook(url string, client *http.Client, onError func(error)) AuditHook {
+	if client == nil {
+		client = http.DefaultClient
+	}
+
+	return func(event AuditEvent) {
+		if event.Permission != types.Denied {
+			return
+		}
+
+		go func() {
+			body, err := json.Marshal(event)
+			if err == nil {
+				var resp *http.Response
+				resp, err = client.Post(url, "application/json", bytes.NewReader(body))
+				if err == nil {
+					resp.Body.Close()
+					if resp.StatusCode >= 300 {
+						err = fmt.Errorf("webhook返回状态码 %d", resp.StatusCode)
+					}
+				}
+			}
+			if err != nil && onError != nil {
+				onError(err)
+			}
+		}()
+	}
+}
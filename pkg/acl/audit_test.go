@@ -0,0 +1,140 @@
+package acl
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/cyberspacesec/go-acl/pkg/types"
+)
+
+type traceKey string
+
+type traceID string
+
+func (t traceID) String() string { return string(t) }
+
+// TestAuditRequestID 测试审计事件中的请求ID提取
+func TestAuditRequestID(t *testing.T) {
+	manager := NewManager()
+	if err := manager.SetIPACL([]string{"10.0.0.0/8"}, types.Blacklist); err != nil {
+		t.Fatalf("SetIPACL() 返回错误: %v", err)
+	}
+
+	var events []AuditEvent
+	manager.SetAuditHook(func(e AuditEvent) {
+		events = append(events, e)
+	})
+
+	tests := []struct {
+		name   string
+		key    interface{}
+		ctx    context.Context
+		wantID string
+	}{
+		{"默认键", nil, WithRequestID(context.Background(), "req-1"), "req-1"},
+		{"自定义键", traceKey("trace"), context.WithValue(context.Background(), traceKey("trace"), "trace-2"), "trace-2"},
+		{"Stringer值", traceKey("trace"), context.WithValue(context.Background(), traceKey("trace"), traceID("trace-3")), "trace-3"},
+		{"上下文中没有请求ID", nil, context.Background(), ""},
+		{"键不匹配", traceKey("other"), WithRequestID(context.Background(), "req-4"), ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			events = nil
+			manager.SetRequestIDKey(tt.key)
+
+			perm, err := manager.CheckIPContext(tt.ctx, "10.1.1.1")
+			if err != nil || perm != types.Denied {
+				t.Fatalf("CheckIPContext() = %v, %v", perm, err)
+			}
+			if len(events) != 1 {
+				t.Fatalf("审计事件数量 = %d, 期望 1", len(events))
+			}
+			e := events[0]
+			if e.RequestID != tt.wantID || e.Kind != "ip" || e.Target != "10.1.1.1" || e.Permission != types.Denied {
+				t.Errorf("审计事件 = %+v, 期望 RequestID %q", e, tt.wantID)
+			}
+		})
+	}
+
+	// 未配置域名ACL时，错误信息也应写入审计事件
+	events = nil
+	manager.SetRequestIDKey(nil)
+	if _, err := manager.CheckDomainContext(WithRequestID(context.Background(), "req-5"), "example.com"); err == nil {
+		t.Fatal("CheckDomainContext() 未配置域名ACL时应返回错误")
+	}
+	if len(events) != 1 || events[0].Kind != "domain" || events[0].Error == "" || events[0].RequestID != "req-5" {
+		t.Errorf("域名审计事件 = %+v", events)
+	}
+
+	// 不带上下文的检查也产生审计事件
+	events = nil
+	manager.CheckIP("8.8.8.8")
+	if len(events) != 1 || events[0].Permission != types.Allowed || events[0].RequestID != "" {
+		t.Errorf("CheckIP() 审计事件 = %+v", events)
+	}
+
+	// 关闭审计
+	events = nil
+	manager.SetAuditHook(nil)
+	manager.CheckIP("10.1.1.1")
+	if len(events) != 0 {
+		t.Errorf("关闭审计后仍产生了 %d 个事件", len(events))
+	}
+}
+
+// TestDenyWebhook 测试拒绝事件的Webhook通知
+func TestDenyWebhook(t *testing.T) {
+	received := make(chan AuditEvent, 1)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var e AuditEvent
+		if err := json.NewDecoder(r.Body).Decode(&e); err != nil {
+			t.Errorf("解码Webhook请求体失败: %v", err)
+		}
+		received <- e
+	}))
+	defer server.Close()
+
+	manager := NewManager()
+	if err := manager.SetIPACL([]string{"10.0.0.0/8"}, types.Blacklist); err != nil {
+		t.Fatalf("SetIPACL() 返回错误: %v", err)
+	}
+	manager.SetAuditHook(DenyWebhook(server.URL, server.Client(), func(err error) {
+		t.Errorf("发送Webhook失败: %v", err)
+	}))
+
+	// 允许的请求不发送通知
+	manager.CheckIPContext(WithRequestID(context.Background(), "req-allowed"), "8.8.8.8")
+	manager.CheckIPContext(WithRequestID(context.Background(), "req-denied"), "10.1.1.1")
+
+	select {
+	case e := <-received:
+		if e.RequestID != "req-denied" || e.Target != "10.1.1.1" {
+			t.Errorf("Webhook事件 = %+v", e)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("未收到Webhook通知")
+	}
+
+	// 服务端返回错误状态码时调用onError
+	failing := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer failing.Close()
+
+	errs := make(chan error, 1)
+	hook := DenyWebhook(failing.URL, nil, func(err error) { errs <- err })
+	hook(AuditEvent{Permission: types.Denied})
+	select {
+	case err := <-errs:
+		if err == nil {
+			t.Error("onError 收到 nil 错误")
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("Webhook失败时未调用onError")
+	}
+}
@@ -0,0 +1,312 @@
+package acl
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/cyberspacesec/go-acl/pkg/expr"
+	"github.com/cyberspacesec/go-acl/pkg/types"
+)
+
+// ErrInvalidAuthorizer 表示外部授权来源的配置无效
+var ErrInvalidAuthorizer = errors.New("无效的外部授权配置")
+
+// Authorizer 是外部的授权决定来源，如远程的策略服务
+//
+// 实现应在ctx结束时尽快返回；忽略ctx的实现在超时后仍会被视为弃权，但其goroutine会一直运行到返回。
+type Authorizer interface {
+	Authorize(ctx context.Context, req expr.Request) (types.Permission, error)
+}
+
+// AuthorizerFunc 把普通函数适配为Authorizer
+type AuthorizerFunc func(ctx context.Context, req expr.Request) (types.Permission, error)
+
+// Authorize 调用f
+func (f AuthorizerFunc) Authorize(ctx context.Context, req expr.Request) (types.Permission, error) {
+	return f(ctx, req)
+}
+
+// AuthorizerQuorum 是多个外部授权来源的结果合并方式
+type AuthorizerQuorum int
+
+const (
+	// QuorumFirstDeny 并发询问所有来源，任一来源拒绝即拒绝，并取消其余的询问；默认值
+	QuorumFirstDeny AuthorizerQuorum = iota
+	// QuorumMajority 并发询问所有来源，超过半数允许时允许，平票拒绝
+	QuorumMajority
+	// QuorumFallback 按顺序逐个询问，第一个在超时内给出结果的来源决定，出错或超时时询问下一个
+	QuorumFallback
+)
+
+// String 返回合并方式的名称："first_deny"、"majority"或"fallback"
+func (q AuthorizerQuorum) String() string {
+	switch q {
+	case QuorumFirstDeny:
+		return "first_deny"
+	case QuorumMajority:
+		return "majority"
+	case QuorumFallback:
+		return "fallback"
+	default:
+		return "unknown"
+	}
+}
+
+// AuthorizerSource 是一个外部授权来源
+//
+// 字段说明:
+//   - Name: 来源名称，用于检查结果中的逐来源决定，不能为空或重复
+//   - Authorizer: 授权的实现
+//   - Timeout: 每次询问的超时，0表示只受调用方上下文的限制
+type AuthorizerSource struct {
+	Name       string
+	Authorizer Authorizer
+	Timeout    time.Duration
+}
+
+// AuthorizerConfig 是外部授权的配置
+//
+// 字段说明:
+//   - Sources: 外部授权来源，QuorumFallback按此顺序询问
+//   - Quorum: 结果的合并方式
+//   - OrderByLatency: 仅用于QuorumFallback，按各来源观测到的平均延迟从低到高询问，
+//     尚未询问过的来源排在最前；出错和超时同样计入延迟，反复超时的来源会被排到后面
+//   - FailOpen: 出错或超时（弃权）的来源按允许计，默认按拒绝计
+type AuthorizerConfig struct {
+	Sources        []AuthorizerSource
+	Quorum         AuthorizerQuorum
+	OrderByLatency bool
+	FailOpen       bool
+}
+
+// authorizerSet 是SetAuthorizers设置的外部授权来源及其延迟统计
+type authorizerSet struct {
+	config AuthorizerConfig
+	// mu 保护latency，持有时不获取其他锁
+	mu sync.Mutex
+	// latency 是各来源的平均延迟，下标与config.Sources相同，0表示尚未询问过
+	latency []time.Duration
+}
+
+// SetAuthorizers 设置外部授权来源，在本地规则和ACL允许请求后再询问它们
+//
+// 参数:
+//   - config: 外部授权的配置，nil或没有来源时不再询问外部授权
+//
+// 返回:
+//   - error: 来源名称为空或重复、Authorizer为nil、超时为负数或合并方式无效时返回包装了ErrInvalidAuthorizer的错误
+//
+// 外部授权只用于CheckRequest、CheckRequestContext和CheckRequestDetailed（因此也用于gateway和middleware），
+// 只能进一步拒绝本地允许的请求：本地拒绝、检查出错和紧急模式下不询问外部授权。
+// 外部授权拒绝时结果的Source为"authorizers"，原因为types.ReasonExternalAuthorizer。
+// 询问过外部授权的结果在Authorizers中列出每个来源的决定、错误和延迟，便于观察各来源的表现。
+//
+// 出错或超时的来源视为弃权，按FailOpen计为允许或拒绝：QuorumFirstDeny下默认任一来源不可用即拒绝，
+// QuorumFallback下所有来源都不可用时按FailOpen决定。
+//
+// 示例:
+//
+//	err := manager.SetAuthorizers(&acl.AuthorizerConfig{
+//	    Sources: []acl.AuthorizerSource{
+//	        {Name: "opa-local", Authorizer: localOPA, Timeout: 5 * time.Millisecond},
+//	        {Name: "opa-central", Authorizer: centralOPA, Timeout: 50 * time.Millisecond},
+//	    },
+//	    Quorum:         acl.QuorumFallback,
+//	    OrderByLatency: true,
+//	})
+func (m *Manager) SetAuthorizers(config *AuthorizerConfig) error {
+	var set *authorizerSet
+	if config != nil && len(config.Sources) > 0 {
+		if err := validateAuthorizers(config); err != nil {
+			return err
+		}
+		set = &authorizerSet{config: *config, latency: make([]time.Duration, len(config.Sources))}
+		set.config.Sources = append([]AuthorizerSource(nil), config.Sources...)
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.authorizers = set
+	m.invalidateVerdicts()
+	return nil
+}
+
+// validateAuthorizers 检查外部授权配置的有效性
+func validateAuthorizers(config *AuthorizerConfig) error {
+	if config.Quorum < QuorumFirstDeny || config.Quorum > QuorumFallback {
+		return fmt.Errorf("%w: 合并方式%d", ErrInvalidAuthorizer, config.Quorum)
+	}
+	seen := make(map[string]bool, len(config.Sources))
+	for i, src := range config.Sources {
+		switch {
+		case src.Name == "":
+			return fmt.Errorf("%w: 第%d个来源没有名称", ErrInvalidAuthorizer, i+1)
+		case seen[src.Name]:
+			return fmt.Errorf("%w: 来源%s重复", ErrInvalidAuthorizer, src.Name)
+		case src.Authorizer == nil:
+			return fmt.Errorf("%w: 来源%s没有Authorizer", ErrInvalidAuthorizer, src.Name)
+		case src.Timeout < 0:
+			return fmt.Errorf("%w: 来源%s的超时为负数", ErrInvalidAuthorizer, src.Name)
+		}
+		seen[src.Name] = true
+	}
+	return nil
+}
+
+// authorize 在本地允许的结果上询问外部授权，未设置外部授权时原样返回结果
+func (m *Manager) authorize(ctx context.Context, req expr.Request, result types.CheckResult) types.CheckResult {
+	m.mu.RLock()
+	set := m.authorizers
+	m.mu.RUnlock()
+	if set == nil || result.Decision != types.Allowed {
+		return result
+	}
+
+	perm, verdicts := set.authorize(ctx, m.Clock(), req)
+	if perm == types.Denied {
+		result = types.CheckResult{Decision: types.Denied, Source: "authorizers", Reason: types.ReasonExternalAuthorizer}
+	}
+	result.Authorizers = verdicts
+	return result
+}
+
+// authorize 按配置的合并方式询问各来源
+func (s *authorizerSet) authorize(ctx context.Context, clock types.Clock, req expr.Request) (types.Permission, []types.AuthorizerVerdict) {
+	if s.config.Quorum == QuorumFallback {
+		var verdicts []types.AuthorizerVerdict
+		for _, i := range s.order() {
+			v := s.ask(ctx, clock, i, req)
+			verdicts = append(verdicts, v)
+			if v.Error == "" {
+				return v.Decision, verdicts
+			}
+		}
+		return s.abstention(), verdicts
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	type answer struct {
+		i int
+		v types.AuthorizerVerdict
+	}
+	answers := make(chan answer, len(s.config.Sources))
+	for i := range s.config.Sources {
+		go func(i int) {
+			answers <- answer{i, s.ask(ctx, clock, i, req)}
+		}(i)
+	}
+
+	// ask在ctx取消后立即返回，提前拒绝时仍等待所有来源，逐来源的结果总是完整的
+	verdicts := make([]types.AuthorizerVerdict, len(s.config.Sources))
+	allowed := 0
+	for range s.config.Sources {
+		a := <-answers
+		verdicts[a.i] = a.v
+		if s.vote(a.v) == types.Allowed {
+			allowed++
+		} else if s.config.Quorum == QuorumFirstDeny {
+			cancel()
+		}
+	}
+	switch {
+	case s.config.Quorum == QuorumFirstDeny && allowed < len(verdicts):
+		return types.Denied, verdicts
+	case s.config.Quorum == QuorumMajority && allowed*2 <= len(verdicts):
+		return types.Denied, verdicts
+	}
+	return types.Allowed, verdicts
+}
+
+// ask 询问一个来源，超时、出错或panic时Verdict.Error不为空
+func (s *authorizerSet) ask(ctx context.Context, clock types.Clock, i int, req expr.Request) types.AuthorizerVerdict {
+	src := s.config.Sources[i]
+	if src.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, src.Timeout)
+		defer cancel()
+	}
+
+	type reply struct {
+		perm types.Permission
+		err  error
+	}
+	replies := make(chan reply, 1)
+	start := clock.Now()
+	go func() {
+		var r reply
+		defer func() { replies <- r }()
+		defer types.CatchPanic(&r.err)
+		r.perm, r.err = src.Authorizer.Authorize(ctx, req)
+	}()
+
+	var r reply
+	select {
+	case r = <-replies:
+	case <-ctx.Done():
+		// 来源与取消同时完成时以来源的决定为准
+		select {
+		case r = <-replies:
+		default:
+			r.err = ctx.Err()
+		}
+	}
+	v := types.AuthorizerVerdict{Name: src.Name, Decision: r.perm, Latency: clock.Now().Sub(start)}
+	if r.err != nil {
+		v.Decision = types.Denied
+		v.Error = r.err.Error()
+	}
+	// 因其他来源已拒绝而取消的询问不反映该来源的延迟
+	if !errors.Is(r.err, context.Canceled) {
+		s.observe(i, v.Latency)
+	}
+	return v
+}
+
+// vote 返回一个来源的决定，弃权时按FailOpen计
+func (s *authorizerSet) vote(v types.AuthorizerVerdict) types.Permission {
+	if v.Error != "" {
+		return s.abstention()
+	}
+	return v.Decision
+}
+
+// abstention 返回弃权的来源计为的决定
+func (s *authorizerSet) abstention() types.Permission {
+	if s.config.FailOpen {
+		return types.Allowed
+	}
+	return types.Denied
+}
+
+// observe 把一次询问的延迟计入来源的平均延迟（权重1/8的指数移动平均）
+func (s *authorizerSet) observe(i int, d time.Duration) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.latency[i] == 0 {
+		s.latency[i] = d
+		return
+	}
+	s.latency[i] += (d - s.latency[i]) / 8
+}
+
+// order 返回QuorumFallback询问各来源的顺序
+func (s *authorizerSet) order() []int {
+	order := make([]int, len(s.config.Sources))
+	for i := range order {
+		order[i] = i
+	}
+	if !s.config.OrderByLatency {
+		return order
+	}
+	s.mu.Lock()
+	latency := append([]time.Duration(nil), s.latency...)
+	s.mu.Unlock()
+	sort.SliceStable(order, func(a, b int) bool {
+		return latency[order[a]] < latency[order[b]]
+	})
+	return order
+}
@@ -0,0 +1,207 @@
+package acl
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/cyberspacesec/go-acl/pkg/expr"
+	"github.com/cyberspacesec/go-acl/pkg/types"
+)
+
+// fixedAuthorizer 返回固定的决定
+func fixedAuthorizer(perm types.Permission) Authorizer {
+	return AuthorizerFunc(func(context.Context, expr.Request) (types.Permission, error) {
+		return perm, nil
+	})
+}
+
+// 测试使用的外部授权来源
+var (
+	allowAuthorizer = fixedAuthorizer(types.Allowed)
+	denyAuthorizer  = fixedAuthorizer(types.Denied)
+	errorAuthorizer = AuthorizerFunc(func(context.Context, expr.Request) (types.Permission, error) {
+		return types.Allowed, errors.New("连接被拒绝")
+	})
+	hangAuthorizer = AuthorizerFunc(func(ctx context.Context, _ expr.Request) (types.Permission, error) {
+		<-ctx.Done()
+		return types.Allowed, ctx.Err()
+	})
+	panicAuthorizer = AuthorizerFunc(func(context.Context, expr.Request) (types.Permission, error) {
+		panic("授权服务的客户端出错")
+	})
+)
+
+// TestSetAuthorizersValidation 测试无效的外部授权配置被拒绝
+func TestSetAuthorizersValidation(t *testing.T) {
+	tests := []struct {
+		name   string
+		config AuthorizerConfig
+	}{
+		{"没有名称", AuthorizerConfig{Sources: []AuthorizerSource{{Authorizer: allowAuthorizer}}}},
+		{"名称重复", AuthorizerConfig{Sources: []AuthorizerSource{{Name: "a", Authorizer: allowAuthorizer}, {Name: "a", Authorizer: denyAuthorizer}}}},
+		{"没有Authorizer", AuthorizerConfig{Sources: []AuthorizerSource{{Name: "a"}}}},
+		{"负数的超时", AuthorizerConfig{Sources: []AuthorizerSource{{Name: "a", Authorizer: allowAuthorizer, Timeout: -time.Second}}}},
+		{"无效的合并方式", AuthorizerConfig{Sources: []AuthorizerSource{{Name: "a", Authorizer: allowAuthorizer}}, Quorum: 7}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			manager := NewManager()
+			if err := manager.SetAuthorizers(&tt.config); !errors.Is(err, ErrInvalidAuthorizer) {
+				t.Errorf("SetAuthorizers() = %v, 期望 ErrInvalidAuthorizer", err)
+			}
+		})
+	}
+}
+
+// TestAuthorizerQuorum 测试各合并方式下外部授权的结果和逐来源的决定
+func TestAuthorizerQuorum(t *testing.T) {
+	const timeout = 20 * time.Millisecond
+	source := func(name string, a Authorizer) AuthorizerSource {
+		return AuthorizerSource{Name: name, Authorizer: a, Timeout: timeout}
+	}
+
+	tests := []struct {
+		name       string
+		config     AuthorizerConfig
+		want       types.Permission
+		wantErrors []bool
+	}{
+		{"全部允许", AuthorizerConfig{Sources: []AuthorizerSource{source("a", allowAuthorizer), source("b", allowAuthorizer)}},
+			types.Allowed, []bool{false, false}},
+		{"任一拒绝即拒绝", AuthorizerConfig{Sources: []AuthorizerSource{source("a", denyAuthorizer), source("b", hangAuthorizer)}},
+			types.Denied, []bool{false, true}},
+		{"默认弃权按拒绝计", AuthorizerConfig{Sources: []AuthorizerSource{source("a", errorAuthorizer), source("b", hangAuthorizer)}},
+			types.Denied, []bool{true, true}},
+		{"FailOpen时弃权按允许计", AuthorizerConfig{Sources: []AuthorizerSource{source("a", allowAuthorizer), source("b", hangAuthorizer)}, FailOpen: true},
+			types.Allowed, []bool{false, true}},
+		{"多数允许", AuthorizerConfig{Sources: []AuthorizerSource{source("a", allowAuthorizer), source("b", denyAuthorizer), source("c", allowAuthorizer)}, Quorum: QuorumMajority},
+			types.Allowed, []bool{false, false, false}},
+		{"平票拒绝", AuthorizerConfig{Sources: []AuthorizerSource{source("a", allowAuthorizer), source("b", denyAuthorizer)}, Quorum: QuorumMajority},
+			types.Denied, []bool{false, false}},
+		{"多数决定时超时的来源", AuthorizerConfig{Sources: []AuthorizerSource{source("a", allowAuthorizer), source("b", hangAuthorizer), source("c", allowAuthorizer)}, Quorum: QuorumMajority},
+			types.Allowed, []bool{false, true, false}},
+		{"回退到下一个来源", AuthorizerConfig{Sources: []AuthorizerSource{source("a", hangAuthorizer), source("b", panicAuthorizer), source("c", denyAuthorizer), source("d", allowAuthorizer)}, Quorum: QuorumFallback},
+			types.Denied, []bool{true, true, false}},
+		{"所有来源都不可用", AuthorizerConfig{Sources: []AuthorizerSource{source("a", errorAuthorizer), source("b", hangAuthorizer)}, Quorum: QuorumFallback, FailOpen: true},
+			types.Allowed, []bool{true, true}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			manager := NewManager()
+			if err := manager.SetIPACL([]string{"203.0.113.0/24"}, types.Blacklist); err != nil {
+				t.Fatal(err)
+			}
+			if err := manager.SetAuthorizers(&tt.config); err != nil {
+				t.Fatalf("SetAuthorizers() 返回错误: %v", err)
+			}
+
+			result, err := manager.CheckRequestDetailed(context.Background(), expr.Request{IP: "198.51.100.1", Port: 443})
+			if err != nil {
+				t.Fatalf("CheckRequestDetailed() 返回错误: %v", err)
+			}
+			if result.Decision != tt.want {
+				t.Errorf("结果 = %+v, 期望 %v", result, tt.want)
+			}
+			if tt.want == types.Denied && (result.Source != "authorizers" || result.Reason != types.ReasonExternalAuthorizer) {
+				t.Errorf("结果 = %+v, 期望由外部授权拒绝", result)
+			}
+			if len(result.Authorizers) != len(tt.wantErrors) {
+				t.Fatalf("逐来源的决定 = %+v, 期望 %d 项", result.Authorizers, len(tt.wantErrors))
+			}
+			for i, v := range result.Authorizers {
+				if (v.Error != "") != tt.wantErrors[i] {
+					t.Errorf("来源 %s 的决定 = %+v, 期望出错: %v", v.Name, v, tt.wantErrors[i])
+				}
+			}
+		})
+	}
+}
+
+// TestAuthorizerOnlyAfterLocalAllow 测试本地拒绝、出错和直接的IP检查不询问外部授权
+func TestAuthorizerOnlyAfterLocalAllow(t *testing.T) {
+	var calls int32
+	manager := NewManager()
+	if err := manager.SetIPACL([]string{"203.0.113.0/24"}, types.Blacklist); err != nil {
+		t.Fatal(err)
+	}
+	err := manager.SetAuthorizers(&AuthorizerConfig{Sources: []AuthorizerSource{{
+		Name: "remote",
+		Authorizer: AuthorizerFunc(func(context.Context, expr.Request) (types.Permission, error) {
+			atomic.AddInt32(&calls, 1)
+			return types.Denied, nil
+		}),
+	}}})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if perm, _ := manager.CheckRequest(expr.Request{IP: "203.0.113.9"}); perm != types.Denied {
+		t.Errorf("本地拒绝的请求 = %v", perm)
+	}
+	if _, err := manager.CheckRequest(expr.Request{IP: "not-an-ip"}); err == nil {
+		t.Error("无效的IP应返回错误")
+	}
+	if perm, _ := manager.CheckIP("198.51.100.1"); perm != types.Allowed {
+		t.Errorf("CheckIP() = %v, 外部授权不应影响直接的IP检查", perm)
+	}
+	if n := atomic.LoadInt32(&calls); n != 0 {
+		t.Fatalf("外部授权被询问了 %d 次, 期望 0", n)
+	}
+
+	if perm, _ := manager.CheckRequest(expr.Request{IP: "198.51.100.1"}); perm != types.Denied {
+		t.Errorf("本地允许的请求 = %v, 期望被外部授权拒绝", perm)
+	}
+	if err := manager.SetAuthorizers(nil); err != nil {
+		t.Fatal(err)
+	}
+	if perm, _ := manager.CheckRequest(expr.Request{IP: "198.51.100.1"}); perm != types.Allowed {
+		t.Errorf("清除外部授权后 = %v, 期望允许", perm)
+	}
+	if n := atomic.LoadInt32(&calls); n != 1 {
+		t.Errorf("外部授权被询问了 %d 次, 期望 1", n)
+	}
+}
+
+// TestAuthorizerOrderByLatency 测试QuorumFallback按观测到的延迟先询问较快的来源
+func TestAuthorizerOrderByLatency(t *testing.T) {
+	clock := types.NewManualClock(time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC))
+	manager := NewManager()
+	manager.SetClock(clock)
+	if err := manager.SetIPACL(nil, types.Blacklist); err != nil {
+		t.Fatal(err)
+	}
+	delayed := func(d time.Duration) Authorizer {
+		return AuthorizerFunc(func(context.Context, expr.Request) (types.Permission, error) {
+			clock.Advance(d)
+			return types.Allowed, nil
+		})
+	}
+	err := manager.SetAuthorizers(&AuthorizerConfig{
+		Sources: []AuthorizerSource{
+			{Name: "central", Authorizer: delayed(50 * time.Millisecond)},
+			{Name: "local", Authorizer: delayed(time.Millisecond)},
+		},
+		Quorum:         QuorumFallback,
+		OrderByLatency: true,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// 第一次按配置顺序询问central；之后local尚未测量，排在前面；测量后仍比central快
+	for i, want := range []string{"central", "local", "local"} {
+		result, err := manager.CheckRequestDetailed(context.Background(), expr.Request{IP: "198.51.100.1"})
+		if err != nil || len(result.Authorizers) != 1 {
+			t.Fatalf("第%d次: 结果 = %+v, %v", i+1, result, err)
+		}
+		if v := result.Authorizers[0]; v.Name != want {
+			t.Errorf("第%d次询问了 %s, 期望 %s", i+1, v.Name, want)
+		}
+	}
+	if got := manager.authorizers.latency; got[0] != 50*time.Millisecond || got[1] != time.Millisecond {
+		t.Errorf("平均延迟 = %v", got)
+	}
+}
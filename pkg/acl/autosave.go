@@ -0,0 +1,64 @@
+package acl
+
+import (
+	"context"
+	"errors"
+	"os"
+	"time"
+
+	"github.com/cyberspacesec/go-acl/pkg/config"
+)
+
+// AutoSave 周期性地将当前的ACL保存到文件，直到ctx被取消
+//
+// 参数:
+//   - ctx: 控制保存循环生命周期的上下文
+//   - filePath: 保存路径
+//   - interval: 保存间隔，必须大于0
+//   - overwrite: 文件在启动时已存在的情况下是否覆盖
+//     true: 覆盖已有文件
+//     false: 返回config.ErrFileExists，不启动保存循环
+//
+// 返回:
+//   - error: 参数错误、文件已存在，或ctx取消后最后一次保存的错误
+//
+// 保存的内容与SaveSnapshotFile相同（IP ACL、域名ACL、命名列表和地址族设置），
+// 每次都先写入临时文件再重命名，读取方不会看到写了一半的文件。
+// 启动时立即保存一次，之后每隔interval保存一次，ctx取消时再执行最后一次保存。
+// 周期性保存失败不会终止循环，下一个周期会重试。
+// 此方法会阻塞，通常在单独的goroutine中调用，重启后用LoadSnapshotFile恢复。
+//
+// 示例:
+//
+//	ctx, cancel := context.WithCancel(context.Background())
+//	defer cancel()
+//
+//	if err := manager.LoadSnapshotFile("./acl.snapshot"); err != nil && !os.IsNotExist(err) {
+//	    log.Printf("恢复ACL失败: %v", err)
+//	}
+//	go manager.AutoSave(ctx, "./acl.snapshot", time.Minute, true)
+func (m *Manager) AutoSave(ctx context.Context, filePath string, interval time.Duration, overwrite bool) error {
+	if interval <= 0 {
+		return errors.New("保存间隔必须大于0")
+	}
+	if !overwrite {
+		if _, err := os.Stat(filePath); err == nil {
+			return config.ErrFileExists
+		}
+	}
+	if err := m.SaveSnapshotFile(filePath); err != nil {
+		return err
+	}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return m.SaveSnapshotFile(filePath)
+		case <-ticker.C:
+			_ = m.SaveSnapshotFile(filePath)
+		}
+	}
+}
@@ -0,0 +1,92 @@
+package acl
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/cyberspacesec/go-acl/pkg/config"
+	"github.com/cyberspacesec/go-acl/pkg/types"
+)
+
+// TestAutoSave 测试ACL的周期性保存与重启恢复
+func TestAutoSave(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "acl.snapshot")
+
+	manager := NewManager()
+	if err := manager.SetIPACL([]string{"10.0.0.0/8"}, types.Blacklist); err != nil {
+		t.Fatalf("SetIPACL() 返回错误: %v", err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error, 1)
+	go func() {
+		done <- manager.AutoSave(ctx, path, 5*time.Millisecond, false)
+	}()
+
+	// 等待启动时的保存完成
+	deadline := time.Now().Add(time.Second)
+	for time.Now().Before(deadline) {
+		if _, err := os.Stat(path); err == nil {
+			break
+		}
+		time.Sleep(time.Millisecond)
+	}
+
+	manager.SetDomainACL([]string{"blocked.com"}, types.Blacklist, true)
+	cancel()
+	if err := <-done; err != nil {
+		t.Fatalf("AutoSave() 返回错误: %v", err)
+	}
+
+	// 模拟重启: 最后一次保存包含取消前的修改
+	restarted := NewManager()
+	if err := restarted.LoadSnapshotFile(path); err != nil {
+		t.Fatalf("LoadSnapshotFile() 返回错误: %v", err)
+	}
+	if got, _ := restarted.CheckIP("10.1.1.1"); got != types.Denied {
+		t.Errorf("恢复后 CheckIP(10.1.1.1) = %v, 期望 Denied", got)
+	}
+	if got, _ := restarted.CheckDomain("www.blocked.com"); got != types.Denied {
+		t.Errorf("恢复后 CheckDomain(www.blocked.com) = %v, 期望 Denied", got)
+	}
+}
+
+// TestAutoSaveErrors 测试AutoSave的参数检查
+func TestAutoSaveErrors(t *testing.T) {
+	dir := t.TempDir()
+	existing := filepath.Join(dir, "existing.snapshot")
+	if err := os.WriteFile(existing, []byte("keep"), 0644); err != nil {
+		t.Fatalf("写入文件失败: %v", err)
+	}
+	manager := NewManager()
+
+	if err := manager.AutoSave(context.Background(), existing, time.Second, false); !errors.Is(err, config.ErrFileExists) {
+		t.Errorf("文件已存在时应返回 ErrFileExists, 实际: %v", err)
+	}
+	if data, _ := os.ReadFile(existing); string(data) != "keep" {
+		t.Error("overwrite=false 时不应修改已有文件")
+	}
+
+	if err := manager.AutoSave(context.Background(), existing, 0, true); err == nil {
+		t.Error("AutoSave() 对于非正数间隔应返回错误")
+	}
+
+	missingDir := filepath.Join(dir, "missing", "acl.snapshot")
+	if err := manager.AutoSave(context.Background(), missingDir, time.Second, true); err == nil {
+		t.Error("目录不存在时应返回错误")
+	}
+
+	// overwrite=true时替换已有文件
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if err := manager.AutoSave(ctx, existing, time.Second, true); err != nil {
+		t.Fatalf("AutoSave() 返回错误: %v", err)
+	}
+	if err := NewManager().LoadSnapshotFile(existing); err != nil {
+		t.Errorf("覆盖后应为有效的快照: %v", err)
+	}
+}
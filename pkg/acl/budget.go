@@ -0,0 +1,111 @@
+package acl
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"time"
+
+	"github.com/cyberspacesec/go-acl/pkg/types"
+)
+
+// ErrBudgetExceeded 表示检查在时间预算内没有完成
+//
+// Manager的检查超出预算时使用兜底结果，此错误只出现在审计事件中；
+// guard.Dialer在预算内没有完成DNS解析时返回包装了此错误的错误。
+var ErrBudgetExceeded = errors.New("ACL检查超出时间预算")
+
+// BudgetConfig 是每次检查的时间预算配置
+//
+// 字段说明:
+//   - Timeout: 每次检查允许的最长时间，小于等于0表示不限制
+//   - FailOpen: 超出预算时的兜底结果，true为允许（fail-open），false为拒绝（fail-closed）
+//
+// 检查涉及的外部组件（如故障注入的延迟、guard.Dialer的DNS解析）在预算用尽时被中断。
+// 内存中的规则匹配不会被中断，已经得到的结果总是被使用。
+type BudgetConfig struct {
+	Timeout  time.Duration
+	FailOpen bool
+}
+
+// SetCheckBudget 设置每次检查的时间预算和超出预算时的兜底结果
+//
+// 参数:
+//   - cfg: 预算配置，传入nil表示不限制
+//
+// 设置后，CheckIP、CheckDomain、CheckRequest及其Context和Detailed变体
+// 在ctx上附加Timeout的截止时间（ctx已有更早的截止时间时以ctx为准）。
+// 检查因截止时间被中断时:
+//   - 返回兜底结果和nil错误，Detailed结果的Source为"budget"
+//   - Stats.BudgetExceeded加1，兜底结果计入对应的允许/拒绝次数
+//   - 审计事件的Error为ErrBudgetExceeded
+//
+// 示例:
+//
+//	// 每次检查最多10毫秒，超时拒绝
+//	manager.SetCheckBudget(&acl.BudgetConfig{Timeout: 10 * time.Millisecond})
+//
+//	// 可用性优先的场景中超时放行
+//	manager.SetCheckBudget(&acl.BudgetConfig{Timeout: 10 * time.Millisecond, FailOpen: true})
+func (m *Manager) SetCheckBudget(cfg *BudgetConfig) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if cfg == nil {
+		m.budget = nil
+		return
+	}
+	c := *cfg
+	m.budget = &c
+}
+
+// BudgetContext 返回附加了检查预算截止时间的ctx
+//
+// 参数:
+//   - ctx: 父上下文
+//
+// 返回:
+//   - context.Context: 未设置预算时为ctx本身
+//   - context.CancelFunc: 使用完毕后必须调用
+//
+// 用于让调用ACL检查之前的外部操作（如DNS解析）同样受预算限制。
+//
+// 示例:
+//
+//	lookupCtx, cancel := manager.BudgetContext(ctx)
+//	defer cancel()
+//	addrs, err := net.DefaultResolver.LookupIPAddr(lookupCtx, host)
+func (m *Manager) BudgetContext(ctx context.Context) (context.Context, context.CancelFunc) {
+	ctx, cancel, _ := m.budgetContext(ctx)
+	return ctx, cancel
+}
+
+// budgetContext 返回附加了预算截止时间的ctx和当前的预算配置，未设置预算时配置为nil
+func (m *Manager) budgetContext(ctx context.Context) (context.Context, context.CancelFunc, *BudgetConfig) {
+	m.mu.RLock()
+	cfg := m.budget
+	m.mu.RUnlock()
+
+	if cfg == nil || cfg.Timeout <= 0 {
+		return ctx, func() {}, nil
+	}
+	ctx, cancel := context.WithTimeout(ctx, cfg.Timeout)
+	return ctx, cancel, cfg
+}
+
+// applyBudget 在检查因截止时间被中断时用兜底结果替换检查结果和错误
+//
+// 返回替换后的结果、返回给调用方的错误和写入审计事件的错误。
+func (m *Manager) applyBudget(cfg *BudgetConfig, result types.CheckResult, err error) (types.CheckResult, error, error) {
+	if cfg == nil || !errors.Is(err, context.DeadlineExceeded) {
+		return result, err, err
+	}
+
+	atomic.AddUint64(&m.stats.budget, 1)
+	result.Decision, result.RuleID, result.Source = types.Denied, "", "budget"
+	result.Reason = types.ReasonBudgetExceeded
+	if cfg.FailOpen {
+		result.Decision, result.Reason = types.Allowed, ""
+	}
+	return result, nil, ErrBudgetExceeded
+}
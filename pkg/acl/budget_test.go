@@ -0,0 +1,92 @@
+package acl
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/cyberspacesec/go-acl/pkg/types"
+)
+
+// TestSetCheckBudget 测试检查超出时间预算时使用兜底结果
+func TestSetCheckBudget(t *testing.T) {
+	slow := &ChaosConfig{DelayRate: 1, Delay: time.Second, Rand: func() float64 { return 0.5 }}
+
+	tests := []struct {
+		name   string
+		budget *BudgetConfig
+		want   types.Permission
+	}{
+		{"fail-closed", &BudgetConfig{Timeout: 5 * time.Millisecond}, types.Denied},
+		{"fail-open", &BudgetConfig{Timeout: 5 * time.Millisecond, FailOpen: true}, types.Allowed},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			manager := NewManager()
+			if err := manager.SetIPACL([]string{"10.0.0.0/8"}, types.Blacklist); err != nil {
+				t.Fatalf("SetIPACL() 返回错误: %v", err)
+			}
+			var events []AuditEvent
+			manager.SetAuditHook(func(e AuditEvent) { events = append(events, e) })
+			manager.SetChaos(slow)
+			manager.SetCheckBudget(tt.budget)
+
+			start := time.Now()
+			result, err := manager.CheckIPDetailed(context.Background(), "10.1.2.3")
+			if elapsed := time.Since(start); elapsed > 500*time.Millisecond {
+				t.Errorf("检查应在预算用尽后返回, 实际耗时 %v", elapsed)
+			}
+			if err != nil {
+				t.Fatalf("CheckIPDetailed() 返回错误: %v", err)
+			}
+			if result.Decision != tt.want || result.Source != "budget" {
+				t.Errorf("CheckIPDetailed() = %v/%s, 期望 %v/budget", result.Decision, result.Source, tt.want)
+			}
+			if got := manager.Stats().BudgetExceeded; got != 1 {
+				t.Errorf("Stats().BudgetExceeded = %d, 期望 1", got)
+			}
+			if got := manager.Stats().Errors; got != 0 {
+				t.Errorf("超出预算不应计入Errors, 实际为 %d", got)
+			}
+			if len(events) != 1 || events[0].Error != ErrBudgetExceeded.Error() {
+				t.Errorf("审计事件 = %+v, 期望Error为ErrBudgetExceeded", events)
+			}
+
+			if perm, err := manager.CheckDomain("example.com"); err != nil || perm != tt.want {
+				t.Errorf("CheckDomain() = %v, %v, 期望 %v, nil", perm, err, tt.want)
+			}
+		})
+	}
+}
+
+// TestCheckBudgetNotExceeded 测试预算内完成的检查和关闭预算
+func TestCheckBudgetNotExceeded(t *testing.T) {
+	manager := NewManager()
+	if err := manager.SetIPACL([]string{"10.0.0.0/8"}, types.Blacklist); err != nil {
+		t.Fatalf("SetIPACL() 返回错误: %v", err)
+	}
+	manager.SetCheckBudget(&BudgetConfig{Timeout: time.Second, FailOpen: true})
+
+	if perm, err := manager.CheckIP("10.1.2.3"); err != nil || perm != types.Denied {
+		t.Errorf("预算内 CheckIP() = %v, %v, 期望 denied, nil", perm, err)
+	}
+
+	// 父上下文被取消不是超出预算，返回错误而不是兜底结果
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	manager.SetChaos(&ChaosConfig{DelayRate: 1, Delay: time.Second, Rand: func() float64 { return 0.5 }})
+	if _, err := manager.CheckIPContext(ctx, "8.8.8.8"); !errors.Is(err, context.Canceled) {
+		t.Errorf("上下文取消时 CheckIPContext() 错误 = %v, 期望 context.Canceled", err)
+	}
+
+	manager.SetChaos(&ChaosConfig{DelayRate: 1, Delay: 20 * time.Millisecond, Rand: func() float64 { return 0.5 }})
+	manager.SetCheckBudget(nil)
+	if perm, err := manager.CheckIP("10.1.2.3"); err != nil || perm != types.Denied {
+		t.Errorf("关闭预算后 CheckIP() = %v, %v, 期望 denied, nil", perm, err)
+	}
+	if got := manager.Stats().BudgetExceeded; got != 0 {
+		t.Errorf("Stats().BudgetExceeded = %d, 期望 0", got)
+	}
+}
@@ -0,0 +1,133 @@
+package acl
+
+import (
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// changeBuffer 是每个订阅者的通道缓冲大小
+const changeBuffer = 64
+
+// ChangeEvent 是Manager的IP或域名配置改变的通知
+//
+// 字段说明:
+//   - Kind: "ip"、"domain"或"rules"（SetRules）
+//   - Component: 改变的组件，如"ip_acl"、"ip_list:temp-bans"、"domain_acl"、"rules"；
+//     整体替换（Reset、LoadSnapshot）和影响多个列表的改变（规则组启停、到期清理）为"*"
+//   - Time: 改变的时间，来自Manager的时钟
+//   - Diff: 按差异重新加载文件（见ReloadIPACLFromFile）时加入和移除的条目，其他改变为nil
+type ChangeEvent struct {
+	Kind      string
+	Component string
+	Time      time.Time
+	Diff      *ReloadDiff
+}
+
+// changeWatchers 是WatchChanges的订阅者和修订号，使用独立的锁，可以在持有其他锁时发送通知
+type changeWatchers struct {
+	mu       sync.Mutex
+	watchers map[*chan ChangeEvent]struct{}
+	revision uint64
+	// generation 保存*uint64，每次改变以及影响检查结果的设置改变时换成新的指针，
+	// 不加锁即可比较配置是否改变，见SessionVerdict
+	generation atomic.Value
+}
+
+// WatchChanges 订阅IP和域名配置的改变
+//
+// 返回:
+//   - <-chan ChangeEvent: 每次修改之后收到一个事件
+//   - func(): 取消订阅，之后通道被关闭，可以重复调用
+//
+// 通知在修改生效之后发送，收到事件时读取Manager得到的是修改后（或更新）的状态。
+// 通道带有缓冲，订阅者处理不及时、缓冲已满时新的事件被丢弃而不会阻塞修改，
+// 因此订阅者应把事件当作"配置可能已改变"的信号重新读取状态，而不是依赖每个事件都送达。
+// 失败的修改也可能产生事件。列表条目按时间到期不产生事件，直到SweepExpired清理它们。
+//
+// 示例:
+//
+//	changes, stop := manager.WatchChanges()
+//	defer stop()
+//	for event := range changes {
+//	    if event.Kind == "ip" {
+//	        syncFirewall(manager.DeniedIPRanges())
+//	    }
+//	}
+func (m *Manager) WatchChanges() (<-chan ChangeEvent, func()) {
+	ch := make(chan ChangeEvent, changeBuffer)
+	w := &m.changes
+	w.mu.Lock()
+	if w.watchers == nil {
+		w.watchers = make(map[*chan ChangeEvent]struct{})
+	}
+	w.watchers[&ch] = struct{}{}
+	w.mu.Unlock()
+
+	var once sync.Once
+	return ch, func() {
+		once.Do(func() {
+			w.mu.Lock()
+			delete(w.watchers, &ch)
+			w.mu.Unlock()
+			close(ch)
+		})
+	}
+}
+
+// Revision 返回配置的修订号
+//
+// 返回:
+//   - uint64: 每次产生改变通知（见WatchChanges）时加一，新建的Manager为0
+//
+// 修订号只在进程内有意义，不同的Manager之间不可比较；判断两个Manager的配置是否相同
+// 应比较PolicyFeed的校验和。
+func (m *Manager) Revision() uint64 {
+	w := &m.changes
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.revision
+}
+
+// notifyChange 增加修订号并向所有订阅者发送改变通知，不阻塞，调用者可以持有Manager的任何锁
+func (m *Manager) notifyChange(kind, component string, now time.Time) {
+	m.publishChange(ChangeEvent{Kind: kind, Component: component, Time: now})
+}
+
+// notifyDiff 与notifyChange相同，事件中带有重新加载的差异
+func (m *Manager) notifyDiff(kind, component string, now time.Time, diff ReloadDiff) {
+	m.publishChange(ChangeEvent{Kind: kind, Component: component, Time: now, Diff: &diff})
+}
+
+// publishChange 增加修订号并向所有订阅者发送事件
+func (m *Manager) publishChange(event ChangeEvent) {
+	w := &m.changes
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.revision++
+	revision := w.revision
+	w.generation.Store(&revision)
+	for ch := range w.watchers {
+		select {
+		case *ch <- event:
+		default:
+		}
+	}
+}
+
+// generation 返回代表当前配置的指针，配置改变后返回不同的指针，没有改变过时返回nil
+func (m *Manager) generation() *uint64 {
+	g, _ := m.changes.generation.Load().(*uint64)
+	return g
+}
+
+// invalidateVerdicts 使SessionVerdict缓存的结果失效，用于紧急模式等不产生改变通知、但影响检查结果的设置
+//
+// 只更换generation，不增加修订号，也不通知WatchChanges的订阅者。调用方应在设置生效之后调用。
+func (m *Manager) invalidateVerdicts() {
+	w := &m.changes
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	revision := w.revision
+	w.generation.Store(&revision)
+}
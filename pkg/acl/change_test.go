@@ -0,0 +1,90 @@
+package acl
+
+import (
+	"testing"
+	"time"
+
+	"github.com/cyberspacesec/go-acl/pkg/ip"
+	"github.com/cyberspacesec/go-acl/pkg/types"
+)
+
+// TestWatchChanges 测试修改IP和域名配置后订阅者收到事件
+func TestWatchChanges(t *testing.T) {
+	manager := NewManager()
+	clock := types.NewManualClock(time.Date(2024, 5, 1, 8, 0, 0, 0, time.UTC))
+	manager.SetClock(clock)
+	changes, stop := manager.WatchChanges()
+
+	manager.SetIPACL([]string{"10.0.0.0/8"}, types.Blacklist)
+	manager.AddIP("192.0.2.1")
+	manager.SetNamedIPList("bans", []string{"198.51.100.7"}, types.Blacklist, 10)
+	manager.AddNamedIPListEntries("bans", "198.51.100.8")
+	manager.SetDomainACL([]string{"example.com"}, types.Whitelist, true)
+	manager.RemoveNamedIPList("bans")
+	manager.DenyIPFamily(ip.FamilyIPv6)
+	manager.DisableGroup("holiday")
+	manager.Reset()
+	// 只读操作不产生事件
+	manager.CheckIP("10.1.2.3")
+	manager.DeniedIPRanges()
+
+	want := []ChangeEvent{
+		{"ip", "ip_acl", clock.Now(), nil},
+		{"ip", "ip_acl", clock.Now(), nil},
+		{"ip", "ip_list:bans", clock.Now(), nil},
+		{"ip", "ip_list:bans", clock.Now(), nil},
+		{"domain", "domain_acl", clock.Now(), nil},
+		{"ip", "ip_list:bans", clock.Now(), nil},
+		{"ip", "family", clock.Now(), nil},
+		{"ip", "*", clock.Now(), nil},
+		{"domain", "*", clock.Now(), nil},
+		{"ip", "*", clock.Now(), nil},
+		{"domain", "*", clock.Now(), nil},
+	}
+	for i, w := range want {
+		select {
+		case got := <-changes:
+			if got != w {
+				t.Errorf("事件[%d] = %+v, 期望 %+v", i, got, w)
+			}
+		default:
+			t.Fatalf("只收到 %d 个事件, 期望 %d", i, len(want))
+		}
+	}
+	select {
+	case got := <-changes:
+		t.Errorf("多余的事件 %+v", got)
+	default:
+	}
+
+	stop()
+	stop()
+	if _, ok := <-changes; ok {
+		t.Error("取消订阅后通道应被关闭")
+	}
+	// 取消订阅后修改不会向已关闭的通道发送
+	manager.SetIPACL(nil, types.Blacklist)
+}
+
+// TestWatchChangesSlowSubscriber 测试订阅者不读取时修改不会阻塞
+func TestWatchChangesSlowSubscriber(t *testing.T) {
+	manager := NewManager()
+	changes, stop := manager.WatchChanges()
+	defer stop()
+
+	done := make(chan struct{})
+	go func() {
+		for i := 0; i < changeBuffer*3; i++ {
+			manager.SetDomainACL(nil, types.Blacklist, false)
+		}
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("缓冲已满时修改被阻塞")
+	}
+	if len(changes) != changeBuffer {
+		t.Errorf("缓冲中的事件 = %d, 期望 %d", len(changes), changeBuffer)
+	}
+}
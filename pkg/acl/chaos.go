@@ -0,0 +1,101 @@
+package acl
+
+import (
+	"context"
+	"errors"
+	"math/rand"
+	"time"
+)
+
+// ErrChaosInjected 表示检查失败是由故障注入（混沌测试模式）人为制造的
+// 只有在通过SetChaos显式启用故障注入后才会返回此错误
+var ErrChaosInjected = errors.New("故障注入: 模拟的ACL检查失败")
+
+// ChaosConfig 故障注入配置，用于韧性测试
+//
+// 启用后，Manager的检查方法会按照配置的概率随机延迟或直接失败，
+// 便于验证业务在ACL层性能下降或不可用时的降级逻辑。
+//
+// 字段说明:
+//   - FailureRate: 检查失败的概率，取值范围[0, 1]
+//   - DelayRate: 检查被延迟的概率，取值范围[0, 1]
+//   - Delay: 每次被选中延迟时的延迟时长
+//   - Err: 注入失败时返回的错误，为nil时使用ErrChaosInjected
+//   - Rand: 随机数来源，返回[0, 1)之间的值，为nil时使用math/rand
+//
+// 注意: 故障注入仅用于测试环境，生产环境中不应启用。
+type ChaosConfig struct {
+	FailureRate float64
+	DelayRate   float64
+	Delay       time.Duration
+	Err         error
+	Rand        func() float64
+}
+
+// SetChaos 启用或关闭故障注入模式
+//
+// 参数:
+//   - cfg: 故障注入配置，传入nil表示关闭故障注入
+//
+// 启用后，CheckDomain和CheckIP在执行实际检查前会先根据配置
+// 随机延迟或返回错误（权限为types.Denied）。
+//
+// 示例:
+//
+//	// 10%的检查失败，20%的检查延迟50毫秒
+//	manager.SetChaos(&acl.ChaosConfig{
+//	    FailureRate: 0.1,
+//	    DelayRate:   0.2,
+//	    Delay:       50 * time.Millisecond,
+//	})
+//
+//	// 测试结束后关闭
+//	manager.SetChaos(nil)
+func (m *Manager) SetChaos(cfg *ChaosConfig) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	defer m.invalidateVerdicts()
+
+	if cfg == nil {
+		m.chaos = nil
+		return
+	}
+	c := *cfg
+	m.chaos = &c
+}
+
+// injectChaos 根据故障注入配置执行随机延迟或返回注入的错误
+// 未启用故障注入时直接返回nil；延迟期间ctx结束时返回ctx.Err()
+func (m *Manager) injectChaos(ctx context.Context) error {
+	m.mu.RLock()
+	cfg := m.chaos
+	m.mu.RUnlock()
+
+	if cfg == nil {
+		return nil
+	}
+
+	random := cfg.Rand
+	if random == nil {
+		random = rand.Float64
+	}
+
+	if cfg.Delay > 0 && random() < cfg.DelayRate {
+		timer := time.NewTimer(cfg.Delay)
+		select {
+		case <-timer.C:
+		case <-ctx.Done():
+			timer.Stop()
+			return ctx.Err()
+		}
+	}
+
+	if random() < cfg.FailureRate {
+		if cfg.Err != nil {
+			return cfg.Err
+		}
+		return ErrChaosInjected
+	}
+	return nil
+}
@@ -0,0 +1,63 @@
+package acl
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/cyberspacesec/go-acl/pkg/types"
+)
+
+// TestSetChaos 测试故障注入模式
+func TestSetChaos(t *testing.T) {
+	manager := NewManager()
+	manager.SetDomainACL([]string{"example.com"}, types.Blacklist, true)
+	if err := manager.SetIPACL([]string{"10.0.0.0/8"}, types.Blacklist); err != nil {
+		t.Fatalf("SetIPACL() 返回错误: %v", err)
+	}
+
+	// 未启用时不应影响检查
+	if perm, err := manager.CheckIP("8.8.8.8"); err != nil || perm != types.Allowed {
+		t.Errorf("未启用故障注入时 CheckIP() = %v, %v, 期望 allowed, nil", perm, err)
+	}
+
+	// 必然失败
+	manager.SetChaos(&ChaosConfig{FailureRate: 1})
+	perm, err := manager.CheckIP("8.8.8.8")
+	if !errors.Is(err, ErrChaosInjected) {
+		t.Errorf("CheckIP() 错误 = %v, 期望 ErrChaosInjected", err)
+	}
+	if perm != types.Denied {
+		t.Errorf("注入失败时权限应为 denied, 实际为 %v", perm)
+	}
+	if _, err := manager.CheckDomain("example.org"); !errors.Is(err, ErrChaosInjected) {
+		t.Errorf("CheckDomain() 错误 = %v, 期望 ErrChaosInjected", err)
+	}
+
+	// 自定义错误
+	customErr := errors.New("custom failure")
+	manager.SetChaos(&ChaosConfig{FailureRate: 1, Err: customErr})
+	if _, err := manager.CheckIP("8.8.8.8"); !errors.Is(err, customErr) {
+		t.Errorf("CheckIP() 错误 = %v, 期望自定义错误", err)
+	}
+
+	// 必然延迟但不失败
+	manager.SetChaos(&ChaosConfig{
+		DelayRate: 1,
+		Delay:     10 * time.Millisecond,
+		Rand:      func() float64 { return 0.5 },
+	})
+	start := time.Now()
+	if perm, err := manager.CheckIP("8.8.8.8"); err != nil || perm != types.Allowed {
+		t.Errorf("仅延迟时 CheckIP() = %v, %v, 期望 allowed, nil", perm, err)
+	}
+	if elapsed := time.Since(start); elapsed < 10*time.Millisecond {
+		t.Errorf("检查应被延迟至少10ms, 实际耗时 %v", elapsed)
+	}
+
+	// 关闭故障注入
+	manager.SetChaos(nil)
+	if _, err := manager.CheckIP("8.8.8.8"); err != nil {
+		t.Errorf("关闭故障注入后 CheckIP() 返回错误: %v", err)
+	}
+}
@@ -0,0 +1,35 @@
+package acl
+
+import (
+	"context"
+
+	"github.com/cyberspacesec/go-acl/pkg/types"
+)
+
+// Checker 是Manager的检查方法组成的接口
+//
+// 应用代码依赖Checker而不是*Manager时，单元测试可以使用acltest.StaticManager代替真实的Manager，
+// 无需为每个测试构造规则。方法的语义与Manager的同名方法相同。
+//
+// 示例:
+//
+//	type Server struct {
+//	    ACL acl.Checker
+//	}
+//
+//	// 生产环境
+//	srv := &Server{ACL: manager}
+//	// 测试
+//	srv = &Server{ACL: acltest.NewStaticManager(decisions)}
+type Checker interface {
+	CheckIP(ip string) (types.Permission, error)
+	CheckDomain(domain string) (types.Permission, error)
+	CheckHost(host string) (types.Permission, error)
+	CheckIPContext(ctx context.Context, ip string) (types.Permission, error)
+	CheckDomainContext(ctx context.Context, domain string) (types.Permission, error)
+	CheckIPDetailed(ctx context.Context, ip string) (types.CheckResult, error)
+	CheckDomainDetailed(ctx context.Context, domain string) (types.CheckResult, error)
+	CheckHostDetailed(ctx context.Context, host string) (types.CheckResult, error)
+}
+
+var _ Checker = (*Manager)(nil)
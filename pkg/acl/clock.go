@@ -0,0 +1,50 @@
+package acl
+
+import (
+	"time"
+
+	"github.com/cyberspacesec/go-acl/pkg/types"
+)
+
+// SetClock 设置管理器使用的时间源
+//
+// 参数:
+//   - clock: 时间源，nil表示恢复为types.SystemClock
+//
+// 重新加载时间、健康检查报告、审计事件的时间、SaveIPACL和SaveDomainACL写入的文件头以及
+// 跟随此管理器的RateLimiter都从该时间源获取当前时间。
+//
+// 示例:
+//
+//	clock := types.NewManualClock(time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC))
+//	manager.SetClock(clock)
+//	manager.SetIPACLFromFile("./blacklist.txt", types.Blacklist)
+//	manager.Health().Components[0].LastReload // 2024-01-01 00:00:00
+func (m *Manager) SetClock(clock types.Clock) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.clock = clock
+}
+
+// Clock 返回管理器使用的时间源
+//
+// 返回:
+//   - types.Clock: 当前时间源，未设置时为types.SystemClock
+func (m *Manager) Clock() types.Clock {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return m.clockLocked()
+}
+
+// clockLocked 返回时间源，调用方需持有锁
+func (m *Manager) clockLocked() types.Clock {
+	if m.clock == nil {
+		return types.SystemClock
+	}
+	return m.clock
+}
+
+// now 返回时间源的当前时间，调用方需持有锁
+func (m *Manager) now() time.Time {
+	return m.clockLocked().Now()
+}
@@ -0,0 +1,52 @@
+package acl
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/cyberspacesec/go-acl/pkg/types"
+)
+
+// TestManagerClock 测试重新加载、健康检查和审计事件使用管理器的时间源
+func TestManagerClock(t *testing.T) {
+	start := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	clock := types.NewManualClock(start)
+
+	manager := NewManager()
+	if manager.Clock() != types.SystemClock {
+		t.Fatal("未设置时间源时 Clock() 应返回 types.SystemClock")
+	}
+	manager.SetClock(clock)
+
+	path := filepath.Join(t.TempDir(), "ips.txt")
+	if err := os.WriteFile(path, []byte("10.0.0.0/8\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := manager.SetIPACLFromFile(path, types.Blacklist); err != nil {
+		t.Fatalf("SetIPACLFromFile() 返回错误: %v", err)
+	}
+
+	clock.Advance(time.Minute)
+	report := manager.Health()
+	if !report.Time.Equal(start.Add(time.Minute)) {
+		t.Errorf("Health().Time = %v, 期望 %v", report.Time, start.Add(time.Minute))
+	}
+	if got := report.Components[0].LastReload; !got.Equal(start) {
+		t.Errorf("LastReload = %v, 期望 %v", got, start)
+	}
+
+	var events []AuditEvent
+	manager.SetAuditHook(func(e AuditEvent) { events = append(events, e) })
+	clock.Advance(time.Minute)
+	manager.CheckIP("10.1.2.3")
+	if len(events) != 1 || !events[0].Time.Equal(start.Add(2*time.Minute)) {
+		t.Errorf("审计事件 = %+v, 期望时间为 %v", events, start.Add(2*time.Minute))
+	}
+
+	manager.SetClock(nil)
+	if manager.Clock() != types.SystemClock {
+		t.Error("SetClock(nil) 后 Clock() 应返回 types.SystemClock")
+	}
+}
@@ -0,0 +1,49 @@
+package acl
+
+import (
+	"github.com/cyberspacesec/go-acl/pkg/ip"
+	"github.com/cyberspacesec/go-acl/pkg/types"
+)
+
+// 本文件中的方法是旧版文档中出现过的名称，仅为兼容保留。
+// 按照Go的命名惯例，缩写ACL在导出名称中应全部大写，新代码请使用对应的ACL版本。
+
+// ErrNoAcl 等同于types.ErrNoACL
+//
+// Deprecated: 请改用 types.ErrNoACL。
+var ErrNoAcl = types.ErrNoACL
+
+// SetDomainAcl 等同于SetDomainACL
+//
+// Deprecated: 请改用 SetDomainACL。
+func (m *Manager) SetDomainAcl(domains []string, listType types.ListType, includeSubdomains bool) {
+	m.SetDomainACL(domains, listType, includeSubdomains)
+}
+
+// SetIPAcl 等同于SetIPACL
+//
+// Deprecated: 请改用 SetIPACL。
+func (m *Manager) SetIPAcl(ipRanges []string, listType types.ListType) error {
+	return m.SetIPACL(ipRanges, listType)
+}
+
+// SetIPAclFromFile 等同于SetIPACLFromFile
+//
+// Deprecated: 请改用 SetIPACLFromFile。
+func (m *Manager) SetIPAclFromFile(filePath string, listType types.ListType) error {
+	return m.SetIPACLFromFile(filePath, listType)
+}
+
+// SaveIPAclToFile 等同于SaveIPACLToFile
+//
+// Deprecated: 请改用 SaveIPACLToFile。
+func (m *Manager) SaveIPAclToFile(filePath string, overwrite bool) error {
+	return m.SaveIPACLToFile(filePath, overwrite)
+}
+
+// SetIPAclWithDefaults 等同于SetIPACLWithDefaults
+//
+// Deprecated: 请改用 SetIPACLWithDefaults。
+func (m *Manager) SetIPAclWithDefaults(ipRanges []string, listType types.ListType, predefinedSets []ip.PredefinedSet, allowDefaultSets bool) error {
+	return m.SetIPACLWithDefaults(ipRanges, listType, predefinedSets, allowDefaultSets)
+}
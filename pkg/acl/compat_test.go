@@ -0,0 +1,96 @@
+package acl
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/cyberspacesec/go-acl/pkg/domain"
+	"github.com/cyberspacesec/go-acl/pkg/expr"
+	"github.com/cyberspacesec/go-acl/pkg/ip"
+	"github.com/cyberspacesec/go-acl/pkg/types"
+)
+
+// TestDeprecatedAliases 测试旧版名称与新名称的行为一致
+func TestDeprecatedAliases(t *testing.T) {
+	tempDir := setupTestDir(t)
+	defer cleanupTestDir(t, tempDir)
+
+	manager := NewManager()
+	manager.SetDomainAcl([]string{"example.com"}, types.Whitelist, true)
+	if got, _ := manager.CheckDomain("api.example.com"); got != types.Allowed {
+		t.Errorf("SetDomainAcl() 后 CheckDomain() = %v, 期望 Allowed", got)
+	}
+
+	if err := manager.SetIPAcl([]string{"10.0.0.0/8"}, types.Blacklist); err != nil {
+		t.Fatalf("SetIPAcl() 返回错误: %v", err)
+	}
+	path := filepath.Join(tempDir, "ips.txt")
+	if err := manager.SaveIPAclToFile(path, false); err != nil {
+		t.Fatalf("SaveIPAclToFile() 返回错误: %v", err)
+	}
+
+	restored := NewManager()
+	if err := restored.SetIPAclFromFile(path, types.Blacklist); err != nil {
+		t.Fatalf("SetIPAclFromFile() 返回错误: %v", err)
+	}
+	if !reflect.DeepEqual(restored.GetIPRanges(), manager.GetIPRanges()) {
+		t.Errorf("GetIPRanges() = %v, 期望 %v", restored.GetIPRanges(), manager.GetIPRanges())
+	}
+
+	if err := restored.SetIPAclWithDefaults(nil, types.Blacklist, []ip.PredefinedSet{ip.CloudMetadata}, false); err != nil {
+		t.Fatalf("SetIPAclWithDefaults() 返回错误: %v", err)
+	}
+	if got, _ := restored.CheckIP("169.254.169.254"); got != types.Denied {
+		t.Errorf("SetIPAclWithDefaults() 后 CheckIP() = %v, 期望 Denied", got)
+	}
+}
+
+// TestErrNoACL 测试未配置ACL时所有方法都返回同一个可以用errors.Is判断的错误
+func TestErrNoACL(t *testing.T) {
+	if ErrNoAcl != types.ErrNoACL || types.ErrNoAcl != types.ErrNoACL {
+		t.Fatal("ErrNoAcl 应与 types.ErrNoACL 是同一个值")
+	}
+
+	tempDir := setupTestDir(t)
+	defer cleanupTestDir(t, tempDir)
+	path := filepath.Join(tempDir, "acl.txt")
+
+	manager := NewManager()
+	ctx := context.Background()
+	calls := map[string]func() error{
+		"CheckIP":             func() error { _, err := manager.CheckIP("192.0.2.1"); return err },
+		"CheckDomain":         func() error { _, err := manager.CheckDomain("example.com"); return err },
+		"CheckHost":           func() error { _, err := manager.CheckHost("http://192.0.2.1/"); return err },
+		"CheckIPDetailed":     func() error { _, err := manager.CheckIPDetailed(ctx, "192.0.2.1"); return err },
+		"CheckDomainDetailed": func() error { _, err := manager.CheckDomainDetailed(ctx, "example.com"); return err },
+		"CheckRequest": func() error {
+			_, err := manager.CheckRequest(expr.Request{IP: "192.0.2.1", Domain: "example.com"})
+			return err
+		},
+		"AddIP":              func() error { return manager.AddIP("192.0.2.1") },
+		"RemoveIP":           func() error { return manager.RemoveIP("192.0.2.1") },
+		"AddDomain":          func() error { return manager.AddDomain("example.com") },
+		"RemoveDomain":       func() error { return manager.RemoveDomain("example.com") },
+		"AddIPFromFile":      func() error { return manager.AddIPFromFile(path) },
+		"AddPredefinedIPSet": func() error { return manager.AddPredefinedIPSet(ip.PrivateNetworks, false) },
+		"GetIPACLType":       func() error { _, err := manager.GetIPACLType(); return err },
+		"GetDomainACLType":   func() error { _, err := manager.GetDomainACLType(); return err },
+		"SaveIPACLToFile":    func() error { return manager.SaveIPACLToFile(path, true) },
+		"SaveIPACL":          func() error { return manager.SaveIPACL(path) },
+		"SaveDomainACL":      func() error { return manager.SaveDomainACL(path, domain.FormAsIs) },
+		"ExportIPSet":        func() error { return manager.ExportIPSet(&bytes.Buffer{}, ip.DefaultIPSetNames("x")) },
+		"Explain":            func() error { return manager.Explain("192.0.2.1").Err },
+	}
+	for name, call := range calls {
+		t.Run(name, func(t *testing.T) {
+			err := call()
+			if !errors.Is(err, types.ErrNoACL) || !errors.Is(err, ErrNoAcl) {
+				t.Errorf("%s() 错误 = %v, 期望 types.ErrNoACL", name, err)
+			}
+		})
+	}
+}
@@ -0,0 +1,196 @@
+package acl
+
+import (
+	"context"
+	"errors"
+	"path/filepath"
+	"testing"
+
+	"github.com/cyberspacesec/go-acl/pkg/types"
+)
+
+// concurrencyPolicies 是压力测试中交替应用的两个策略
+//
+// 两个策略都满足检查方用来发现中间状态的不变量：
+//   - 10.0.0.0/8和10.1.0.0/16总是一起加入和移除，10.1.2.3匹配的规则数只能是0或2
+//   - example.com和例外api.example.com总是一起出现，api.example.com总是被允许
+var concurrencyPolicies = []Policy{
+	{
+		IP:     &IPPolicy{Type: "blacklist", Ranges: []string{"192.0.2.1", "10.0.0.0/8", "10.1.0.0/16"}},
+		Domain: &DomainPolicy{Type: "blacklist", Domains: []string{"example.com"}, IncludeSubdomains: true, Exceptions: []string{"api.example.com"}},
+	},
+	{
+		IP:     &IPPolicy{Type: "blacklist", Ranges: []string{"192.0.2.1"}},
+		Domain: &DomainPolicy{Type: "blacklist", Domains: []string{"other.org"}, IncludeSubdomains: true},
+	},
+}
+
+// stressIterations 返回每个goroutine的循环次数，-short时减少
+func stressIterations() int {
+	if testing.Short() {
+		return 50
+	}
+	return 500
+}
+
+// TestManagerConcurrency 在检查的同时并发地设置、添加、移除、重置和从文件重新加载列表
+//
+// 用-race运行时同时检查数据竞争。检查方验证每次检查看到的都是某次修改之前或之后的完整状态：
+// 一次调用加入或移除的多个条目要么都可见、要么都不可见。
+func TestManagerConcurrency(t *testing.T) {
+	manager, err := NewManagerFromPolicy(concurrencyPolicies[0])
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	dir := t.TempDir()
+	ipFiles := []string{
+		writeList(t, filepath.Join(dir, "ips-full.txt"), "192.0.2.1\n10.0.0.0/8\n10.1.0.0/16\n"),
+		writeList(t, filepath.Join(dir, "ips-base.txt"), "192.0.2.1\n"),
+	}
+	domainFiles := []string{
+		writeList(t, filepath.Join(dir, "domains-full.txt"), "example.com\n!api.example.com\n"),
+		writeList(t, filepath.Join(dir, "domains-base.txt"), "other.org\n"),
+	}
+	n := stressIterations()
+
+	writers := []struct {
+		name string
+		run  func(i int)
+	}{
+		{"设置", func(i int) {
+			if i%2 == 0 {
+				_ = manager.SetIPACL([]string{"192.0.2.1", "10.0.0.0/8", "10.1.0.0/16"}, types.Blacklist)
+			} else {
+				_ = manager.SetIPACL([]string{"192.0.2.1"}, types.Blacklist)
+			}
+			manager.SetDomainACL([]string{"other.org"}, types.Blacklist, true)
+		}},
+		{"添加和移除", func(i int) {
+			// 列表可能刚被其他goroutine重置或替换，错误可以忽略
+			if i%2 == 0 {
+				_ = manager.AddIP("10.0.0.0/8", "10.1.0.0/16")
+			} else {
+				_ = manager.RemoveIP("10.0.0.0/8", "10.1.0.0/16")
+			}
+			_ = manager.AddDomain("ads.example.net")
+			_ = manager.RemoveDomain("ads.example.net")
+		}},
+		{"重置", func(i int) {
+			if i%10 == 0 {
+				manager.Reset()
+			}
+		}},
+		{"从文件重新加载", func(i int) {
+			_, _ = manager.ReloadIPACLFromFile(ipFiles[i%2], types.Blacklist)
+			_, _ = manager.ReloadDomainACLFromFile(domainFiles[i%2], types.Blacklist, true)
+			if i%5 == 0 {
+				_ = manager.SetIPACLFromFile(ipFiles[(i+1)%2], types.Blacklist)
+				_ = manager.SetDomainACLFromFile(domainFiles[(i+1)%2], types.Blacklist, true)
+			}
+		}},
+		{"应用策略", func(i int) {
+			_ = manager.ApplyPolicy(concurrencyPolicies[i%2])
+		}},
+	}
+
+	checkers := []struct {
+		name string
+		run  func(t *testing.T)
+	}{
+		{"IP检查", func(t *testing.T) {
+			result, err := manager.CheckIPDetailed(context.Background(), "10.1.2.3")
+			if errors.Is(err, types.ErrNoACL) {
+				return
+			}
+			if err != nil {
+				t.Errorf("CheckIPDetailed() 返回错误: %v", err)
+				return
+			}
+			if len(result.Matches) == 1 {
+				t.Errorf("检查看到了只加入一半的条目: %v", result.Matches)
+			}
+			if (len(result.Matches) > 0) != (result.Decision == types.Denied) {
+				t.Errorf("决定 %v 与匹配的规则 %v 不一致", result.Decision, result.Matches)
+			}
+		}},
+		{"域名检查", func(t *testing.T) {
+			perm, err := manager.CheckDomain("api.example.com")
+			if errors.Is(err, types.ErrNoACL) {
+				return
+			}
+			if err != nil || perm != types.Allowed {
+				t.Errorf("CheckDomain() = %v, %v, 检查看到了没有例外的规则", perm, err)
+			}
+		}},
+		{"修订号", func(t *testing.T) {
+			before := manager.Revision()
+			_, _ = manager.CheckIP("192.0.2.1")
+			if after := manager.Revision(); after < before {
+				t.Errorf("修订号从 %d 减小到 %d", before, after)
+			}
+		}},
+		{"读取配置", func(t *testing.T) {
+			policy := manager.Policy()
+			if policy.IP == nil {
+				return
+			}
+			count := 0
+			for _, r := range policy.IP.Ranges {
+				if r == "10.0.0.0/8" || r == "10.1.0.0/16" {
+					count++
+				}
+			}
+			if count == 1 {
+				t.Errorf("Policy() 看到了只加入一半的条目: %v", policy.IP.Ranges)
+			}
+		}},
+	}
+
+	for _, w := range writers {
+		w := w
+		t.Run(w.name, func(t *testing.T) {
+			t.Parallel()
+			for i := 0; i < n; i++ {
+				w.run(i)
+			}
+		})
+	}
+	for _, c := range checkers {
+		c := c
+		// 每种检查用两个goroutine，增加与修改交错的机会
+		for _, suffix := range []string{"1", "2"} {
+			t.Run(c.name+suffix, func(t *testing.T) {
+				t.Parallel()
+				for i := 0; i < 4*n && !t.Failed(); i++ {
+					c.run(t)
+				}
+			})
+		}
+	}
+}
+
+// TestManagerConcurrentStats 测试并发检查时统计计数不丢失
+func TestManagerConcurrentStats(t *testing.T) {
+	manager := NewManager()
+	if err := manager.SetIPACL([]string{"10.0.0.0/8"}, types.Blacklist); err != nil {
+		t.Fatal(err)
+	}
+	n := stressIterations()
+	const goroutines = 8
+
+	t.Run("检查", func(t *testing.T) {
+		for g := 0; g < goroutines; g++ {
+			t.Run("", func(t *testing.T) {
+				t.Parallel()
+				for i := 0; i < n; i++ {
+					_, _ = manager.CheckIP("10.1.2.3")
+				}
+			})
+		}
+	})
+
+	if got, want := manager.Stats().IPDenied, uint64(goroutines*n); got != want {
+		t.Errorf("拒绝的IP检查次数 = %d, 期望 %d", got, want)
+	}
+}
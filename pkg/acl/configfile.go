@@ -0,0 +1,81 @@
+package acl
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// ErrUnsupportedConfigFormat 表示配置文件的格式未知，或对应的编码模块没有导入
+var ErrUnsupportedConfigFormat = errors.New("不支持的配置文件格式")
+
+// ConfigFormat 是SaveConfig写入的配置文件格式，对应同名扩展名注册的PolicyCodec
+type ConfigFormat string
+
+const (
+	// ConfigJSON 缩进的JSON，核心包内置
+	ConfigJSON ConfigFormat = "json"
+	// ConfigYAML YAML，需要导入github.com/cyberspacesec/go-acl/yaml模块
+	ConfigYAML ConfigFormat = "yaml"
+)
+
+// codec 返回格式对应的策略编码
+func (f ConfigFormat) codec() (PolicyCodec, error) {
+	codecMu.RLock()
+	defer codecMu.RUnlock()
+	if codec, ok := codecs["."+strings.ToLower(string(f))]; ok {
+		return codec, nil
+	}
+	return nil, fmt.Errorf("%w: %q", ErrUnsupportedConfigFormat, string(f))
+}
+
+// SaveConfig 将Manager的完整配置保存为一个JSON或YAML文件
+//
+// 参数:
+//   - filePath: 配置文件路径，扩展名应与格式一致（如".json"、".yaml"），LoadManagerFromConfig按扩展名读取
+//   - format: 文件格式，ConfigJSON或ConfigYAML
+//
+// 返回:
+//   - error: 格式未知或YAML模块未导入时返回包装了ErrUnsupportedConfigFormat的错误，编码或写入失败时返回相应错误
+//
+// 文件内容是Manager.Policy描述的策略：IP和域名主列表（包括列表类型、是否包含子域名和例外）、
+// 端口限制、命名列表和条件规则，与手写的策略文件格式相同。
+// 文件通过临时文件加重命名的方式原子地写入，已存在的文件被替换。
+//
+// 示例:
+//
+//	if err := manager.SaveConfig("/etc/myapp/acl.json", acl.ConfigJSON); err != nil {
+//	    log.Printf("保存配置失败: %v", err)
+//	}
+//
+//	// 重启后恢复
+//	manager, err := acl.LoadManagerFromConfig("/etc/myapp/acl.json")
+func (m *Manager) SaveConfig(filePath string, format ConfigFormat) error {
+	codec, err := format.codec()
+	if err != nil {
+		return err
+	}
+	data, err := EncodePolicy(m.Policy(), codec)
+	if err != nil {
+		return err
+	}
+	return writeFileAtomic(filePath, func(w io.Writer) error {
+		_, err := w.Write(data)
+		return err
+	})
+}
+
+// LoadManagerFromConfig 读取SaveConfig保存的配置文件并创建Manager
+//
+// 参数:
+//   - filePath: 配置文件路径，按扩展名选择格式，与LoadPolicyFile相同
+//
+// 返回:
+//   - *Manager: 按配置创建的管理器
+//   - error: 读取、解析或应用配置失败时返回错误
+//
+// 读取YAML文件前需要导入github.com/cyberspacesec/go-acl/yaml模块，否则按JSON解析而失败。
+func LoadManagerFromConfig(filePath string) (*Manager, error) {
+	return LoadPolicyFile(filePath)
+}
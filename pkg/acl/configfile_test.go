@@ -0,0 +1,76 @@
+package acl
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/cyberspacesec/go-acl/pkg/types"
+)
+
+// TestSaveConfig 测试完整配置的保存和恢复
+func TestSaveConfig(t *testing.T) {
+	manager := NewManager()
+	if err := manager.SetIPACL([]string{"203.0.113.0/24", "2001:db8::/32"}, types.Blacklist); err != nil {
+		t.Fatal(err)
+	}
+	if err := manager.SetIPRulePorts("203.0.113.0/24", 443); err != nil {
+		t.Fatal(err)
+	}
+	manager.SetDomainACL([]string{"example.com"}, types.Blacklist, true)
+	manager.domainACL.AddException("status.example.com")
+	if err := manager.SetNamedIPList("partners", []string{"198.51.100.0/24"}, types.Whitelist, 10); err != nil {
+		t.Fatal(err)
+	}
+
+	path := filepath.Join(t.TempDir(), "acl.json")
+	if err := manager.SaveConfig(path, ConfigJSON); err != nil {
+		t.Fatalf("SaveConfig() 返回错误: %v", err)
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{`"include_subdomains": true`, `"exceptions"`, `"partners"`} {
+		if !strings.Contains(string(data), want) {
+			t.Errorf("保存的配置缺少 %s:\n%s", want, data)
+		}
+	}
+
+	restored, err := LoadManagerFromConfig(path)
+	if err != nil {
+		t.Fatalf("LoadManagerFromConfig() 返回错误: %v", err)
+	}
+	if !reflect.DeepEqual(restored.Policy(), manager.Policy()) {
+		t.Errorf("恢复的配置 = %+v, 期望 %+v", restored.Policy(), manager.Policy())
+	}
+	tests := []struct {
+		domain string
+		want   types.Permission
+	}{
+		{"api.example.com", types.Denied},
+		{"status.example.com", types.Allowed},
+	}
+	for _, tt := range tests {
+		if perm, _ := restored.CheckDomain(tt.domain); perm != tt.want {
+			t.Errorf("恢复后 CheckDomain(%q) = %v, 期望 %v", tt.domain, perm, tt.want)
+		}
+	}
+}
+
+// TestSaveConfigUnsupportedFormat 测试未知格式和未导入编码模块的格式
+func TestSaveConfigUnsupportedFormat(t *testing.T) {
+	manager := NewManager()
+	for _, format := range []ConfigFormat{ConfigYAML, "toml", ""} {
+		path := filepath.Join(t.TempDir(), "acl.conf")
+		if err := manager.SaveConfig(path, format); !errors.Is(err, ErrUnsupportedConfigFormat) {
+			t.Errorf("SaveConfig(%q) = %v, 期望 ErrUnsupportedConfigFormat", format, err)
+		}
+		if _, err := os.Stat(path); !os.IsNotExist(err) {
+			t.Errorf("格式不受支持时不应创建文件 %s", path)
+		}
+	}
+}
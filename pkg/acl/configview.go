@@ -0,0 +1,151 @@
+package acl
+
+import (
+	"time"
+
+	"github.com/cyberspacesec/go-acl/pkg/ip"
+	"github.com/cyberspacesec/go-acl/pkg/types"
+)
+
+// DomainConfig 是域名主列表当前配置的快照
+//
+// 字段说明:
+//   - Entries: 域名规则，与GetDomains相同
+//   - ListType: 列表类型，与GetDomainACLType相同
+//   - IncludeSubdomains: 是否包含子域名
+//   - Exceptions: 从列表中排除的例外域名，见domain.DomainACL.AddException
+//   - Policies: 附加在域名树节点上的策略，见domain.DomainACL.SetPolicy
+//   - Modified: 最近一次修改的时间
+//
+// 切片和映射都是副本，修改它们不影响Manager。
+type DomainConfig struct {
+	Entries           []string
+	ListType          types.ListType
+	IncludeSubdomains bool
+	Exceptions        []string
+	Policies          map[string]types.Permission
+	Modified          time.Time
+}
+
+// IPConfig 是IP主列表当前配置的快照
+//
+// 字段说明:
+//   - Entries: IP或CIDR规则，与GetIPRanges相同
+//   - ListType: 列表类型，与GetIPACLType相同
+//   - Family: 列表接受的地址族，见ip.IPACL.SetFamily
+//   - DeniedFamily: 被整体拒绝的地址族，见DenyIPFamily
+//   - Modified: 最近一次修改的时间
+//
+// Entries是副本，修改它不影响Manager。
+type IPConfig struct {
+	Entries      []string
+	ListType     types.ListType
+	Family       ip.Family
+	DeniedFamily ip.Family
+	Modified     time.Time
+}
+
+// DomainConfig 一次性返回域名主列表的规则、类型和选项
+//
+// 返回:
+//   - DomainConfig: 配置的快照，各字段在同一时刻读取，彼此一致
+//   - error: 未设置域名ACL时返回types.ErrNoACL
+//
+// 展示配置时可以代替GetDomains、GetDomainACLType等多次调用。
+//
+// 示例:
+//
+//	cfg, err := manager.DomainConfig()
+//	if errors.Is(err, types.ErrNoACL) {
+//	    fmt.Println("未设置域名ACL")
+//	    return
+//	}
+//	fmt.Printf("域名%s（包含子域名: %v）: %v\n", cfg.ListType, cfg.IncludeSubdomains, cfg.Entries)
+func (m *Manager) DomainConfig() (DomainConfig, error) {
+	m.domainMu.RLock()
+	defer m.domainMu.RUnlock()
+
+	if m.domainACL == nil {
+		return DomainConfig{}, types.ErrNoACL
+	}
+	return DomainConfig{
+		Entries:           m.domainACL.GetDomains(),
+		ListType:          m.domainACL.GetListType(),
+		IncludeSubdomains: m.domainACL.IncludesSubdomains(),
+		Exceptions:        m.domainACL.GetExceptions(),
+		Policies:          m.domainACL.GetPolicies(),
+		Modified:          m.domainModified,
+	}, nil
+}
+
+// IPConfig 一次性返回IP主列表的规则、类型和选项
+//
+// 返回:
+//   - IPConfig: 配置的快照，各字段在同一时刻读取，彼此一致
+//   - error: 未设置IP ACL时返回types.ErrNoACL
+//
+// 示例:
+//
+//	cfg, err := manager.IPConfig()
+//	if err == nil {
+//	    fmt.Printf("IP%s（%s）: %v\n", cfg.ListType, cfg.Family, cfg.Entries)
+//	}
+func (m *Manager) IPConfig() (IPConfig, error) {
+	m.ipMu.RLock()
+	defer m.ipMu.RUnlock()
+
+	if m.ipACL == nil {
+		return IPConfig{}, types.ErrNoACL
+	}
+	return IPConfig{
+		Entries:      m.ipACL.GetIPRanges(),
+		ListType:     m.ipACL.GetListType(),
+		Family:       m.ipACL.GetFamily(),
+		DeniedFamily: m.deniedFamily,
+		Modified:     m.ipModified,
+	}, nil
+}
+
+// DeniedIPRanges 返回所有IP黑名单中的条目，用于同步到防火墙、XDP等外部系统
+//
+// 返回:
+//   - []string: 黑名单类型的IP主列表和启用中的黑名单命名列表的条目，按求值顺序去重；没有时为nil
+//
+// 结果是黑名单条目的并集，不反映求值顺序：优先级更高的白名单命名列表、临时放行和规则表达式
+// 可能允许其中的IP，白名单和DenyIPFamily也不会体现在结果中。
+// 到期但尚未被SweepExpired清理的临时条目同样包含在内。
+//
+// 示例:
+//
+//	changes, stop := manager.WatchChanges()
+//	defer stop()
+//	for range changes {
+//	    firewall.Replace(manager.DeniedIPRanges())
+//	}
+func (m *Manager) DeniedIPRanges() []string {
+	disabled := m.disabledGroupSet()
+
+	m.ipMu.RLock()
+	defer m.ipMu.RUnlock()
+
+	var ranges []string
+	seen := make(map[string]struct{})
+	add := func(acl *ip.IPACL) {
+		if acl == nil || acl.GetListType() != types.Blacklist {
+			return
+		}
+		for _, r := range acl.GetIPRanges() {
+			if _, ok := seen[r]; !ok {
+				seen[r] = struct{}{}
+				ranges = append(ranges, r)
+			}
+		}
+	}
+	for _, l := range m.ipLists {
+		if groupEnabled(disabled, l.group) {
+			add(l.ip)
+		}
+	}
+	add(m.ipACL)
+	return ranges
+}
@@ -0,0 +1,100 @@
+package acl
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/cyberspacesec/go-acl/pkg/ip"
+	"github.com/cyberspacesec/go-acl/pkg/types"
+)
+
+// TestDomainConfig 测试DomainConfig一次返回域名主列表的规则、类型和选项
+func TestDomainConfig(t *testing.T) {
+	start := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	manager := NewManager()
+	manager.SetClock(types.NewManualClock(start))
+
+	if _, err := manager.DomainConfig(); !errors.Is(err, types.ErrNoACL) {
+		t.Fatalf("未设置域名ACL时 DomainConfig() 错误 = %v, 期望 ErrNoACL", err)
+	}
+
+	manager.SetDomainACL([]string{"example.com", "evil.org"}, types.Blacklist, true)
+	cfg, err := manager.DomainConfig()
+	if err != nil {
+		t.Fatalf("DomainConfig() 返回错误: %v", err)
+	}
+	if !reflect.DeepEqual(cfg.Entries, manager.GetDomains()) {
+		t.Errorf("Entries = %v, 期望与GetDomains()相同: %v", cfg.Entries, manager.GetDomains())
+	}
+	if cfg.ListType != types.Blacklist || !cfg.IncludeSubdomains {
+		t.Errorf("ListType = %v, IncludeSubdomains = %v, 期望黑名单且包含子域名", cfg.ListType, cfg.IncludeSubdomains)
+	}
+	if len(cfg.Exceptions) != 0 || len(cfg.Policies) != 0 {
+		t.Errorf("Exceptions = %v, Policies = %v, 期望为空", cfg.Exceptions, cfg.Policies)
+	}
+	if !cfg.Modified.Equal(start) {
+		t.Errorf("Modified = %v, 期望 %v", cfg.Modified, start)
+	}
+
+	// 返回的切片是副本
+	cfg.Entries[0] = "changed.example"
+	if manager.GetDomains()[0] == "changed.example" {
+		t.Error("修改DomainConfig().Entries不应影响Manager")
+	}
+}
+
+// TestIPConfig 测试IPConfig一次返回IP主列表的规则、类型和地址族
+func TestIPConfig(t *testing.T) {
+	manager := NewManager()
+	if _, err := manager.IPConfig(); !errors.Is(err, types.ErrNoACL) {
+		t.Fatalf("未设置IP ACL时 IPConfig() 错误 = %v, 期望 ErrNoACL", err)
+	}
+
+	if err := manager.SetIPACL([]string{"10.0.0.0/8", "2001:db8::/32"}, types.Whitelist); err != nil {
+		t.Fatalf("SetIPACL() 返回错误: %v", err)
+	}
+	manager.DenyIPFamily(ip.FamilyIPv6)
+	cfg, err := manager.IPConfig()
+	if err != nil {
+		t.Fatalf("IPConfig() 返回错误: %v", err)
+	}
+	want := IPConfig{
+		Entries:      []string{"10.0.0.0/8", "2001:db8::/32"},
+		ListType:     types.Whitelist,
+		Family:       ip.FamilyAny,
+		DeniedFamily: ip.FamilyIPv6,
+		Modified:     cfg.Modified,
+	}
+	if !reflect.DeepEqual(cfg, want) {
+		t.Errorf("IPConfig() = %+v, 期望 %+v", cfg, want)
+	}
+	if cfg.Modified.IsZero() {
+		t.Error("Modified 不应为零值")
+	}
+}
+
+// TestDeniedIPRanges 测试导出黑名单条目的并集，跳过白名单和停用的规则组
+func TestDeniedIPRanges(t *testing.T) {
+	manager := NewManager()
+	if got := manager.DeniedIPRanges(); got != nil {
+		t.Errorf("没有IP ACL时 DeniedIPRanges() = %v, 期望 nil", got)
+	}
+	manager.SetIPACL([]string{"10.0.0.0/8", "198.51.100.7"}, types.Blacklist)
+	manager.SetNamedIPList("partners", []string{"10.1.0.0/16"}, types.Whitelist, 10)
+	manager.SetNamedIPList("bans", []string{"198.51.100.7", "2001:db8::/32"}, types.Blacklist, 20)
+	manager.SetNamedIPList("freeze", []string{"192.0.2.0/24"}, types.Blacklist, 30)
+	manager.SetNamedIPListGroup("freeze", "holiday")
+	manager.DisableGroup("holiday")
+
+	want := []string{"198.51.100.7", "2001:db8::/32", "10.0.0.0/8"}
+	if got := manager.DeniedIPRanges(); !reflect.DeepEqual(got, want) {
+		t.Errorf("DeniedIPRanges() = %v, 期望 %v", got, want)
+	}
+
+	manager.SetIPACL([]string{"203.0.113.0/24"}, types.Whitelist)
+	if got := manager.DeniedIPRanges(); !reflect.DeepEqual(got, want[:2]) {
+		t.Errorf("白名单主列表时 DeniedIPRanges() = %v, 期望 %v", got, want[:2])
+	}
+}
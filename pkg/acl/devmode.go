@@ -0,0 +1,89 @@
+package acl
+
+import (
+	"github.com/cyberspacesec/go-acl/pkg/ip"
+	"github.com/cyberspacesec/go-acl/pkg/types"
+)
+
+// devModeNetworks 是开发模式下只记录不拒绝的地址：私有地址、环回地址和IPv6唯一本地地址
+var devModeNetworks = mustPredefinedACL(ip.PrivateNetworks, ip.LoopbackNetworks, ip.UniqueLocalAddresses)
+
+// devModeExcluded 是开发模式下仍然拒绝的地址，云元数据服务的部分地址（如fd00:ec2::254）位于以上范围中
+var devModeExcluded = mustPredefinedACL(ip.CloudMetadata)
+
+// mustPredefinedACL 返回包含指定内置集合的黑名单，集合中的地址检查结果为Denied
+func mustPredefinedACL(sets ...ip.PredefinedSet) *ip.IPACL {
+	acl, err := ip.NewIPACLWithDefaults(nil, types.Blacklist, sets, false)
+	if err != nil {
+		panic(err)
+	}
+	return acl
+}
+
+// SetDevMode 设置开发模式，启用后对私有地址和环回地址的拒绝只记录不执行
+//
+// 参数:
+//   - enabled: true启用开发模式，false恢复正常检查
+//
+// 本地集成环境中的服务常常需要访问127.0.0.1或内网中的依赖，开发模式让这些环境可以使用与生产相同的策略文件。
+// 启用后，CheckIP、CheckIPContext、CheckIPDetailed，以及CheckRequest中没有规则表达式匹配、由IP ACL决定的部分，
+// 在IP属于ip.PrivateNetworks、ip.LoopbackNetworks或ip.UniqueLocalAddresses且本应被拒绝时改为允许：
+// 结果的Source为"dev_mode"，RuleID和Matches保留本应拒绝的规则，审计事件的WouldDeny字段记录本应拒绝的原因。
+//
+// 以下情况不受影响，仍然按配置拒绝:
+//   - 云元数据地址（ip.CloudMetadata）和链路本地地址，开发机上访问它们同样可能泄露凭据
+//   - 公网地址、域名检查，以及规则表达式直接做出的拒绝
+//   - 紧急模式、上下文中的临时例外和子视图做出的决定，以及检查出错（如ErrNoACL）
+//
+// 启用期间Health()包含名为"dev_mode"的组件，状态为HealthDegraded，避免开发模式被误带到生产环境。
+//
+// 示例:
+//
+//	if os.Getenv("APP_ENV") == "development" {
+//	    manager.SetDevMode(true)
+//	}
+func (m *Manager) SetDevMode(enabled bool) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if enabled != m.devMode {
+		m.devMode = enabled
+		m.invalidateVerdicts()
+	}
+}
+
+// DevMode 返回是否启用了开发模式
+func (m *Manager) DevMode() bool {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return m.devMode
+}
+
+// devModeResult 在开发模式下把对私有地址和环回地址的拒绝改为允许，不适用时原样返回结果
+//
+// 返回的结果保留原来的Reason，由auditRule写入审计事件的WouldDeny后再由调用方清除。
+func (m *Manager) devModeResult(addr string, result types.CheckResult) types.CheckResult {
+	if result.Decision != types.Denied || !m.DevMode() {
+		return result
+	}
+	if perm, err := devModeNetworks.Check(addr); err != nil || perm != types.Denied {
+		return result
+	}
+	if perm, _ := devModeExcluded.Check(addr); perm == types.Denied {
+		return result
+	}
+	result.Decision = types.Allowed
+	result.Source = "dev_mode"
+	return result
+}
+
+// devModeHealth 返回开发模式的健康状态，未启用时ok为false
+func (m *Manager) devModeHealth() (ComponentHealth, bool) {
+	if !m.DevMode() {
+		return ComponentHealth{}, false
+	}
+	return ComponentHealth{
+		Name:    "dev_mode",
+		Status:  HealthDegraded,
+		Message: "开发模式已启用，对私有地址和环回地址的拒绝只记录不执行",
+	}, true
+}
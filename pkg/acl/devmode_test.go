@@ -0,0 +1,102 @@
+package acl
+
+import (
+	"context"
+	"testing"
+
+	"github.com/cyberspacesec/go-acl/pkg/ip"
+	"github.com/cyberspacesec/go-acl/pkg/types"
+)
+
+// TestSetDevMode 测试开发模式只放行私有地址和环回地址，并在审计事件中记录本应拒绝的原因
+func TestSetDevMode(t *testing.T) {
+	manager := NewManager()
+	if err := manager.SetIPACLWithDefaults([]string{"203.0.113.0/24"}, types.Blacklist,
+		[]ip.PredefinedSet{ip.PrivateNetworks, ip.LoopbackNetworks, ip.LinkLocalNetworks, ip.CloudMetadata, ip.UniqueLocalAddresses}, false); err != nil {
+		t.Fatalf("SetIPACLWithDefaults() 返回错误: %v", err)
+	}
+	var events []AuditEvent
+	manager.SetAuditHook(func(e AuditEvent) { events = append(events, e) })
+
+	if manager.DevMode() {
+		t.Fatal("DevMode() 默认应为false")
+	}
+	manager.SetDevMode(true)
+	if !manager.DevMode() {
+		t.Fatal("SetDevMode(true) 后 DevMode() 应为true")
+	}
+
+	tests := []struct {
+		name   string
+		ip     string
+		want   types.Permission
+		source string
+	}{
+		{"环回地址", "127.0.0.1", types.Allowed, "dev_mode"},
+		{"IPv6环回地址", "::1", types.Allowed, "dev_mode"},
+		{"私有地址", "10.1.2.3", types.Allowed, "dev_mode"},
+		{"唯一本地地址", "fd12:3456::1", types.Allowed, "dev_mode"},
+		{"公网地址", "203.0.113.7", types.Denied, "ip_acl"},
+		{"链路本地地址", "169.254.1.1", types.Denied, "ip_acl"},
+		{"云元数据地址", "169.254.169.254", types.Denied, "ip_acl"},
+		{"私有范围中的云元数据地址", "fd00:ec2::254", types.Denied, "ip_acl"},
+		{"未拒绝的地址", "198.51.100.1", types.Allowed, "ip_acl"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			events = nil
+			result, err := manager.CheckIPDetailed(context.Background(), tt.ip)
+			if err != nil {
+				t.Fatalf("CheckIPDetailed() 返回错误: %v", err)
+			}
+			if result.Decision != tt.want || result.Source != tt.source {
+				t.Errorf("CheckIPDetailed(%s) = %+v, 期望 %v 来自 %s", tt.ip, result, tt.want, tt.source)
+			}
+			if len(events) != 1 {
+				t.Fatalf("审计事件数量 = %d, 期望 1", len(events))
+			}
+			if tt.source != "dev_mode" {
+				if events[0].WouldDeny != "" {
+					t.Errorf("WouldDeny = %q, 期望为空", events[0].WouldDeny)
+				}
+				return
+			}
+			if result.Reason != "" || result.RuleID == "" {
+				t.Errorf("结果 = %+v, 期望原因为空且保留本应拒绝的规则", result)
+			}
+			if e := events[0]; e.Permission != types.Allowed || e.Reason != "" || e.WouldDeny != types.ReasonMatchedBlacklistIP {
+				t.Errorf("审计事件 = %+v, 期望WouldDeny为 %s", e, types.ReasonMatchedBlacklistIP)
+			}
+		})
+	}
+
+	if perm, _ := manager.CheckIP("127.0.0.1"); perm != types.Allowed {
+		t.Errorf("CheckIP(127.0.0.1) = %v, 期望开发模式下允许", perm)
+	}
+	if perm, err := manager.CheckIP("not-an-ip"); err == nil || perm != types.Denied {
+		t.Errorf("CheckIP(not-an-ip) = %v, %v, 期望开发模式不影响检查错误", perm, err)
+	}
+
+	health := manager.Health()
+	if !hasComponent(health, "dev_mode", HealthDegraded) {
+		t.Errorf("Health() = %+v, 期望包含状态为degraded的dev_mode组件", health)
+	}
+
+	manager.SetDevMode(false)
+	if perm, _ := manager.CheckIP("127.0.0.1"); perm != types.Denied {
+		t.Errorf("关闭后 CheckIP(127.0.0.1) = %v, 期望拒绝", perm)
+	}
+	if hasComponent(manager.Health(), "dev_mode", HealthDegraded) {
+		t.Error("关闭后 Health() 不应包含dev_mode组件")
+	}
+}
+
+// hasComponent 判断健康报告中是否有指定名称和状态的组件
+func hasComponent(report HealthReport, name string, status HealthStatus) bool {
+	for _, c := range report.Components {
+		if c.Name == name && c.Status == status {
+			return true
+		}
+	}
+	return false
+}
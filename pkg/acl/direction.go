@@ -0,0 +1,222 @@
+package acl
+
+import (
+	"context"
+	"errors"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/cyberspacesec/go-acl/pkg/domain"
+	"github.com/cyberspacesec/go-acl/pkg/expr"
+	"github.com/cyberspacesec/go-acl/pkg/ip"
+	"github.com/cyberspacesec/go-acl/pkg/types"
+)
+
+// ErrMissingClientIP 表示入站检查缺少有效的客户端IP
+var ErrMissingClientIP = errors.New("缺少有效的客户端IP")
+
+// defaultOutboundSchemes 是OutboundChecker.Schemes为空时允许的协议
+var defaultOutboundSchemes = []string{"http", "https"}
+
+// defaultPorts 是URL中没有端口时各协议使用的端口
+var defaultPorts = map[string]int{"http": 80, "https": 443, "ws": 80, "wss": 443, "ftp": 21, "ssh": 22}
+
+// InboundChecker 检查服务端收到的请求：客户端IP和请求的Host
+//
+// IP ACL用于客户端地址，域名ACL用于本服务对外提供的虚拟主机（Host头）。
+// Manager的各个检查方法本身不区分方向，InboundChecker按入站场景的惯例组合它们:
+//  1. 客户端IP必须来自连接本身（r.RemoteAddr）或经过信任链校验的转发头（见realip），
+//     不能直接使用X-Forwarded-For等客户端可以伪造的头
+//  2. Host去除端口后只按域名ACL检查；Host是IP字面量时不检查，不会误用客户端的IP ACL
+//  3. 两者连同规则表达式通过Manager.CheckRequestDetailed一次求值，规则可以同时引用ip和domain
+type InboundChecker struct {
+	// Manager 是执行访问控制的ACL管理器
+	Manager *Manager
+	// ClientIP 从HTTP请求中取得客户端IP，为nil时使用r.RemoteAddr中的地址；
+	// 位于反向代理之后时应设置为使用realip.ParseForwardedChain的函数
+	ClientIP func(r *http.Request) string
+}
+
+// Check 检查入站请求
+//
+// 参数:
+//   - ctx: 请求上下文，可携带请求ID
+//   - clientIP: 客户端IP，可以带端口（如r.RemoteAddr）
+//   - host: 请求的Host，可以带端口，为空时只检查客户端IP
+//
+// 返回:
+//   - types.CheckResult: Manager.CheckRequestDetailed的结果
+//   - error: clientIP不是有效的IP时返回ErrMissingClientIP，其他错误与CheckRequest相同
+//
+// 示例:
+//
+//	inbound := acl.InboundChecker{Manager: manager}
+//	result, err := inbound.Check(ctx, "203.0.113.7:52144", "api.example.com:443")
+func (c InboundChecker) Check(ctx context.Context, clientIP, host string) (types.CheckResult, error) {
+	addr := net.ParseIP(strings.Trim(stripPort(clientIP), "[]"))
+	if addr == nil {
+		return types.CheckResult{Target: clientIP, Kind: "request", Decision: types.Denied, Reason: types.ReasonInvalidInput}, ErrMissingClientIP
+	}
+
+	req := expr.Request{IP: addr.String()}
+	if name := domain.Normalize(host); name != "" {
+		if _, isIP := ip.CanonicalizeIP(name); !isIP {
+			req.Domain = name
+		}
+	}
+	return c.Manager.CheckRequestDetailed(ctx, req)
+}
+
+// CheckHTTP 检查服务端收到的HTTP请求，客户端IP由ClientIP取得，Host为r.Host
+//
+// 参数:
+//   - r: 服务端收到的请求
+//
+// 返回:
+//   - types.CheckResult: 与Check相同
+//   - error: 与Check相同
+//
+// 示例:
+//
+//	inbound := acl.InboundChecker{Manager: manager}
+//	if result, err := inbound.CheckHTTP(r); err != nil || !result.Allowed() {
+//	    http.Error(w, "Forbidden", http.StatusForbidden)
+//	    return
+//	}
+func (c InboundChecker) CheckHTTP(r *http.Request) (types.CheckResult, error) {
+	clientIP := r.RemoteAddr
+	if c.ClientIP != nil {
+		clientIP = c.ClientIP(r)
+	}
+	return c.Check(r.Context(), clientIP, r.Host)
+}
+
+// OutboundChecker 检查服务端代为发出的请求：目标主机、解析得到的IP、协议和端口
+//
+// 按出站场景（Webhook、URL抓取、代理）的惯例组合Manager的检查方法:
+//  1. 协议必须在Schemes中，默认只允许http和https，拒绝file、gopher等协议
+//  2. 目标主机按CheckHost的规则处理：混淆的IP写法转换为标准形式后检查IP ACL，域名检查域名ACL；
+//     连同端口通过Manager.CheckRequestDetailed求值，端口规则（如"port != 443 -> deny"）同样生效
+//  3. 域名解析得到的每个IP都必须被允许，防止允许的域名解析到内网地址（DNS重绑定）
+//
+// 第3步只能检查调用方传入的IP。实际连接使用的IP应与检查的IP相同，
+// 最可靠的做法是在建立连接时检查，见guard.Dialer。
+type OutboundChecker struct {
+	// Manager 是执行访问控制的ACL管理器
+	Manager *Manager
+	// Schemes 是允许的协议（不区分大小写），为空时使用http和https；没有协议的目标不检查此项
+	Schemes []string
+}
+
+// Check 检查出站请求的目标
+//
+// 参数:
+//   - ctx: 请求上下文，可携带请求ID
+//   - target: 目标URL或主机，例如"https://api.example.com:8443/hook"、"10.0.0.1:22"
+//   - resolved: 目标域名解析得到的IP，目标本身是IP时可以省略
+//
+// 返回:
+//   - types.CheckResult: 做出拒绝决定的检查的结果；都允许时为最后一次检查的结果。
+//     协议不被允许时Source为"scheme"，原因为types.ReasonSchemeNotAllowed；
+//     解析得到的IP被拒绝时Target为该IP
+//   - error: 与CheckRequest相同的错误；启用SetStrictHostnames时，主机部分无效返回包装了domain.ErrInvalidHostname的错误
+//
+// 示例:
+//
+//	outbound := acl.OutboundChecker{Manager: manager}
+//	addrs, _ := net.DefaultResolver.LookupIP(ctx, "ip", host)
+//	result, err := outbound.Check(ctx, webhookURL, addrs...)
+//	if err != nil || !result.Allowed() {
+//	    return fmt.Errorf("拒绝访问 %s: %s", result.Target, result.Reason)
+//	}
+func (c OutboundChecker) Check(ctx context.Context, target string, resolved ...net.IP) (types.CheckResult, error) {
+	scheme := ""
+	if i := strings.Index(target, "://"); i > 0 {
+		scheme = strings.ToLower(target[:i])
+		if !allowedScheme(c.schemes(), scheme) {
+			return types.CheckResult{
+				Target:   target,
+				Kind:     "request",
+				Decision: types.Denied,
+				Source:   "scheme",
+				Reason:   types.ReasonSchemeNotAllowed,
+			}, nil
+		}
+	}
+
+	if result, err := c.Manager.validateHost(ctx, target); err != nil {
+		return result, err
+	}
+	req := expr.Request{Port: targetPort(target, scheme)}
+	name := domain.Normalize(target)
+	if parsed, ok := ip.CanonicalizeIP(name); ok {
+		req.IP = parsed.String()
+	} else {
+		req.Domain = name
+	}
+	result, err := c.Manager.CheckRequestDetailed(ctx, req)
+	if err != nil || result.Decision == types.Denied {
+		return result, err
+	}
+
+	for _, addr := range resolved {
+		ipResult, err := c.Manager.CheckRequestDetailed(ctx, expr.Request{IP: addr.String(), Port: req.Port})
+		if errors.Is(err, types.ErrNoACL) {
+			// 没有IP ACL和匹配的规则，解析得到的IP无需检查
+			break
+		}
+		if err != nil || ipResult.Decision == types.Denied {
+			return ipResult, err
+		}
+		result = ipResult
+	}
+	return result, nil
+}
+
+// schemes 返回允许的协议
+func (c OutboundChecker) schemes() []string {
+	if len(c.Schemes) == 0 {
+		return defaultOutboundSchemes
+	}
+	return c.Schemes
+}
+
+// allowedScheme 判断scheme是否在允许的协议中
+func allowedScheme(schemes []string, scheme string) bool {
+	for _, s := range schemes {
+		if strings.EqualFold(s, scheme) {
+			return true
+		}
+	}
+	return false
+}
+
+// targetPort 返回目标中的端口，没有端口时返回协议的默认端口，都没有时返回0
+func targetPort(target, scheme string) int {
+	hostport := target
+	if scheme != "" {
+		hostport = target[len(scheme)+len("://"):]
+	}
+	if i := strings.IndexAny(hostport, "/?#"); i >= 0 {
+		hostport = hostport[:i]
+	}
+	if i := strings.LastIndex(hostport, "@"); i >= 0 {
+		hostport = hostport[i+1:]
+	}
+	if _, port, err := net.SplitHostPort(hostport); err == nil {
+		if n, err := strconv.Atoi(port); err == nil {
+			return n
+		}
+	}
+	return defaultPorts[scheme]
+}
+
+// stripPort 去除地址中的端口，没有端口时原样返回
+func stripPort(addr string) string {
+	if host, _, err := net.SplitHostPort(addr); err == nil {
+		return host
+	}
+	return addr
+}
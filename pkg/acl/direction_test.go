@@ -0,0 +1,123 @@
+package acl
+
+import (
+	"context"
+	"errors"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/cyberspacesec/go-acl/pkg/expr"
+	"github.com/cyberspacesec/go-acl/pkg/types"
+)
+
+// TestInboundChecker 测试入站请求的检查
+func TestInboundChecker(t *testing.T) {
+	manager := NewManager()
+	manager.SetIPACL([]string{"198.51.100.0/24"}, types.Blacklist)
+	manager.SetDomainACL([]string{"api.example.com"}, types.Whitelist, false)
+	inbound := InboundChecker{Manager: manager}
+
+	ctx := context.Background()
+	tests := []struct {
+		name     string
+		clientIP string
+		host     string
+		decision types.Permission
+		source   string
+	}{
+		{"允许", "203.0.113.7:52144", "api.example.com:443", types.Allowed, "domain_acl"},
+		{"客户端IP被拒绝", "198.51.100.7:52144", "api.example.com", types.Denied, "ip_acl"},
+		{"未知的虚拟主机", "203.0.113.7", "admin.example.com", types.Denied, "domain_acl"},
+		{"IPv6客户端", "[2001:db8::1]:443", "API.example.com.", types.Allowed, "domain_acl"},
+		// Host是IP字面量时不用IP ACL检查，否则服务端自己的地址会被当作客户端地址
+		{"Host为IP", "203.0.113.7", "198.51.100.1:8080", types.Allowed, "ip_acl"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, err := inbound.Check(ctx, tt.clientIP, tt.host)
+			if err != nil {
+				t.Fatalf("Check() 返回错误: %v", err)
+			}
+			if result.Decision != tt.decision || result.Source != tt.source {
+				t.Errorf("Check() = %+v, 期望 %v %s", result, tt.decision, tt.source)
+			}
+		})
+	}
+
+	if _, err := inbound.Check(ctx, "unknown", "api.example.com"); !errors.Is(err, ErrMissingClientIP) {
+		t.Errorf("无效的客户端IP返回 %v, 期望 ErrMissingClientIP", err)
+	}
+
+	r := httptest.NewRequest("GET", "http://api.example.com/", nil)
+	r.RemoteAddr = "198.51.100.9:40000"
+	r.Header.Set("X-Forwarded-For", "203.0.113.7")
+	if result, _ := inbound.CheckHTTP(r); result.Decision != types.Denied {
+		t.Errorf("CheckHTTP() 应使用RemoteAddr而不是X-Forwarded-For, 结果 = %+v", result)
+	}
+	inbound.ClientIP = func(*http.Request) string { return "203.0.113.7" }
+	if result, _ := inbound.CheckHTTP(r); result.Decision != types.Allowed {
+		t.Errorf("CheckHTTP() 应使用ClientIP取得的地址, 结果 = %+v", result)
+	}
+}
+
+// TestOutboundChecker 测试出站请求的检查
+func TestOutboundChecker(t *testing.T) {
+	manager := NewManager()
+	manager.SetIPACL([]string{"127.0.0.0/8", "10.0.0.0/8"}, types.Blacklist)
+	manager.SetDomainACL([]string{"internal.example.com"}, types.Blacklist, true)
+	rules, err := expr.CompileAll([]string{"port == 22 -> deny"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	manager.SetRules(rules)
+	outbound := OutboundChecker{Manager: manager}
+
+	ctx := context.Background()
+	tests := []struct {
+		name     string
+		target   string
+		resolved []net.IP
+		decision types.Permission
+		source   string
+		reason   types.Reason
+	}{
+		{"允许", "https://api.example.com/hook", []net.IP{net.ParseIP("93.184.216.34")}, types.Allowed, "ip_acl", ""},
+		{"不允许的协议", "file:///etc/passwd", nil, types.Denied, "scheme", types.ReasonSchemeNotAllowed},
+		{"gopher协议", "GOPHER://api.example.com/", nil, types.Denied, "scheme", types.ReasonSchemeNotAllowed},
+		{"混淆的IP", "http://2130706433/", nil, types.Denied, "ip_acl", types.ReasonMatchedBlacklistIP},
+		{"被拒绝的域名", "https://db.internal.example.com", nil, types.Denied, "domain_acl", types.ReasonMatchedBlacklistDomain},
+		{"端口规则", "ssh://bastion.example.com:22", nil, types.Denied, "scheme", types.ReasonSchemeNotAllowed},
+		{"没有协议的端口", "bastion.example.com:22", nil, types.Denied, "rule", types.ReasonMatchedRule},
+		{"DNS重绑定", "http://rebind.example.net/", []net.IP{net.ParseIP("93.184.216.34"), net.ParseIP("10.1.2.3")}, types.Denied, "ip_acl", types.ReasonMatchedBlacklistIP},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, err := outbound.Check(ctx, tt.target, tt.resolved...)
+			if err != nil {
+				t.Fatalf("Check() 返回错误: %v", err)
+			}
+			if result.Decision != tt.decision || result.Source != tt.source || result.Reason != tt.reason {
+				t.Errorf("Check() = %+v, 期望 %v %s %s", result, tt.decision, tt.source, tt.reason)
+			}
+		})
+	}
+
+	outbound.Schemes = []string{"https", "ssh"}
+	if result, _ := outbound.Check(ctx, "ssh://bastion.example.com"); result.Decision != types.Denied || result.Source != "rule" {
+		t.Errorf("ssh的默认端口22应匹配端口规则, 结果 = %+v", result)
+	}
+}
+
+// TestOutboundCheckerDomainOnly 测试只配置了域名ACL时解析得到的IP不需要检查
+func TestOutboundCheckerDomainOnly(t *testing.T) {
+	manager := NewManager()
+	manager.SetDomainACL([]string{"example.com"}, types.Whitelist, true)
+	outbound := OutboundChecker{Manager: manager}
+
+	result, err := outbound.Check(context.Background(), "https://api.example.com", net.ParseIP("10.0.0.1"))
+	if err != nil || result.Decision != types.Allowed || result.Source != "domain_acl" {
+		t.Errorf("Check() = %+v, %v; 期望由域名ACL允许", result, err)
+	}
+}
@@ -0,0 +1,140 @@
+package acl
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"strings"
+
+	"github.com/cyberspacesec/go-acl/pkg/domain"
+	"github.com/cyberspacesec/go-acl/pkg/types"
+)
+
+// Resolver 解析主机名得到IP地址，*net.Resolver实现了此接口
+type Resolver interface {
+	LookupIPAddr(ctx context.Context, host string) ([]net.IPAddr, error)
+}
+
+// LintWithResolver 在Lint的基础上解析白名单中的域名，检查IPv4和IPv6的结果是否一致
+//
+// 参数:
+//   - ctx: 控制解析的上下文，可设置超时
+//   - manager: 要检查的ACL管理器
+//   - resolver: 解析域名使用的解析器，为nil时使用net.DefaultResolver
+//
+// 返回:
+//   - LintReport: Lint的结果，加上dual_stack_mismatch问题
+//
+// 出站请求同时检查域名解析得到的IP时（OutboundChecker、guard.Dialer），
+// 白名单中的域名如果A记录的地址被IP ACL允许、AAAA记录的地址被拒绝（或者相反），
+// 只有走另一种地址族的客户端会失败，这类间歇性的故障很难排查。
+// 对主列表和命名列表中的每个域名白名单，解析其中的普通域名、exact:和suffix:规则的域名本身，
+// 两种地址族都有记录且一种全部被允许、另一种有地址被拒绝时报告dual_stack_mismatch（警告）。
+//
+// 没有IP ACL时不检查，解析失败或只有一种地址族的域名被跳过。
+// 检查不计入统计，也不产生审计事件。
+//
+// 示例:
+//
+//	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+//	defer cancel()
+//	report := acl.LintWithResolver(ctx, manager, nil)
+//	fmt.Print(report)
+func LintWithResolver(ctx context.Context, manager *Manager, resolver Resolver) LintReport {
+	report := Lint(manager)
+	if resolver == nil {
+		resolver = net.DefaultResolver
+	}
+
+	manager.ipMu.RLock()
+	hasIPACL := manager.ipACL != nil || len(manager.ipLists) > 0
+	manager.ipMu.RUnlock()
+	if !hasIPACL {
+		return report
+	}
+
+	// 先复制要解析的域名，解析和检查时不持有域名ACL的锁
+	type whitelist struct {
+		component string
+		names     []string
+	}
+	var lists []whitelist
+	manager.domainMu.RLock()
+	if manager.domainACL != nil && manager.domainACL.GetListType() == types.Whitelist {
+		lists = append(lists, whitelist{"domain_acl", dualStackNames(manager.domainACL.GetDomains())})
+	}
+	for _, l := range manager.domainLists {
+		if l.domain.GetListType() == types.Whitelist {
+			lists = append(lists, whitelist{"domain_list:" + l.name, dualStackNames(l.domain.GetDomains())})
+		}
+	}
+	manager.domainMu.RUnlock()
+
+	for _, l := range lists {
+		for _, name := range l.names {
+			if message, ok := manager.dualStackMismatch(ctx, resolver, name); ok {
+				report.add("dual_stack_mismatch", LintWarning, l.component, message)
+			}
+		}
+	}
+	return report
+}
+
+// dualStackNames 返回规则中可以解析的域名，正则表达式和只匹配子域名的规则没有确定的域名
+func dualStackNames(rules []string) []string {
+	var names []string
+	for _, rule := range rules {
+		parsed, err := domain.ParseRule(rule)
+		if err != nil || parsed.Kind == domain.MatchRegex || strings.HasPrefix(parsed.Value, ".") {
+			continue
+		}
+		names = append(names, parsed.Value)
+	}
+	return names
+}
+
+// dualStackMismatch 解析域名并分别检查两种地址族，结果不一致时返回问题说明
+func (m *Manager) dualStackMismatch(ctx context.Context, resolver Resolver, name string) (string, bool) {
+	addrs, err := resolver.LookupIPAddr(ctx, name)
+	if err != nil {
+		return "", false
+	}
+
+	var v4, v6 dualStackFamily
+	for _, addr := range addrs {
+		family := &v6
+		if addr.IP.To4() != nil {
+			family = &v4
+		}
+		family.total++
+		result, err := m.resolveIP(ctx, addr.IP.String(), false)
+		if err != nil {
+			// 没有IP ACL或检查失败时无法判断
+			return "", false
+		}
+		if result.Decision == types.Denied {
+			family.denied = append(family.denied, addr.IP.String())
+		}
+	}
+	if v4.total == 0 || v6.total == 0 || v4.allowed() == v6.allowed() {
+		return "", false
+	}
+
+	allowed, denied, deniedFamily := "IPv4", "IPv6", v6
+	if v6.allowed() {
+		allowed, denied, deniedFamily = "IPv6", "IPv4", v4
+	}
+	return fmt.Sprintf("%s 的%s地址被允许，%s地址 %s 被拒绝，只使用%s的客户端会失败",
+		name, allowed, denied, strings.Join(deniedFamily.denied, ", "), denied), true
+}
+
+// dualStackFamily 是一种地址族的解析结果中被拒绝的地址
+type dualStackFamily struct {
+	total  int
+	denied []string
+}
+
+// allowed 判断该地址族的所有地址是否都被允许
+func (f dualStackFamily) allowed() bool {
+	return len(f.denied) == 0
+}
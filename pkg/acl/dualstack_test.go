@@ -0,0 +1,108 @@
+package acl
+
+import (
+	"context"
+	"net"
+	"strings"
+	"testing"
+
+	"github.com/cyberspacesec/go-acl/pkg/ip"
+	"github.com/cyberspacesec/go-acl/pkg/types"
+)
+
+// staticResolver 返回预设解析结果的解析器
+type staticResolver map[string][]string
+
+func (r staticResolver) LookupIPAddr(ctx context.Context, host string) ([]net.IPAddr, error) {
+	ips, ok := r[host]
+	if !ok {
+		return nil, &net.DNSError{Err: "no such host", Name: host, IsNotFound: true}
+	}
+	addrs := make([]net.IPAddr, len(ips))
+	for i, s := range ips {
+		addrs[i] = net.IPAddr{IP: net.ParseIP(s)}
+	}
+	return addrs, nil
+}
+
+// TestLintWithResolver 测试白名单域名的IPv4和IPv6解析结果被IP ACL区别对待时报告问题
+func TestLintWithResolver(t *testing.T) {
+	resolver := staticResolver{
+		"api.example.com":    {"203.0.113.10", "2001:db8::10"},
+		"cdn.example.net":    {"198.51.100.1", "2001:db8:bad::1"},
+		"v4only.example.org": {"203.0.113.20"},
+		"both.example.org":   {"203.0.113.30", "2001:db8::30"},
+		"login.example.com":  {"192.0.2.1", "2001:db8::40"},
+	}
+
+	manager := NewManager()
+	if err := manager.SetIPACL([]string{"2001:db8:bad::/48", "192.0.2.0/24"}, types.Blacklist); err != nil {
+		t.Fatalf("SetIPACL() 返回错误: %v", err)
+	}
+	manager.SetDomainACL([]string{
+		"api.example.com",
+		"v4only.example.org",
+		"unresolvable.example",
+		"regex:^img[0-9]+\\.example\\.com$",
+	}, types.Whitelist, true)
+	manager.SetNamedDomainList("partners", []string{"suffix:cdn.example.net", "exact:login.example.com"}, types.Whitelist, false, 10)
+	// 黑名单中的域名不检查
+	manager.SetNamedDomainList("blocked", []string{"both.example.org"}, types.Blacklist, false, 20)
+
+	report := LintWithResolver(context.Background(), manager, resolver)
+	var findings []LintFinding
+	for _, f := range report.Findings {
+		if f.Check == "dual_stack_mismatch" {
+			findings = append(findings, f)
+		}
+	}
+	if len(findings) != 2 {
+		t.Fatalf("dual_stack_mismatch 问题数量 = %d, 期望 2: %v", len(findings), report.Findings)
+	}
+	for i, want := range []struct {
+		name, allowed, denied string
+	}{
+		{"cdn.example.net", "IPv4地址被允许", "2001:db8:bad::1"},
+		{"login.example.com", "IPv6地址被允许", "192.0.2.1"},
+	} {
+		f := findings[i]
+		if f.Component != "domain_list:partners" || f.Severity != LintWarning {
+			t.Errorf("findings[%d] = %+v, 期望domain_list:partners的警告", i, f)
+		}
+		for _, s := range []string{want.name, want.allowed, want.denied} {
+			if !strings.Contains(f.Message, s) {
+				t.Errorf("findings[%d].Message = %q, 期望包含 %q", i, f.Message, s)
+			}
+		}
+	}
+
+	// 检查不计入统计
+	if stats := manager.Stats(); stats.IPAllowed+stats.IPDenied != 0 {
+		t.Errorf("LintWithResolver 不应计入统计: %+v", stats)
+	}
+}
+
+// TestLintWithResolverDeniedFamily 测试整体拒绝一种地址族导致的不一致，以及没有IP ACL时不检查
+func TestLintWithResolverDeniedFamily(t *testing.T) {
+	resolver := staticResolver{"api.example.com": {"203.0.113.10", "2001:db8::10"}}
+	manager := NewManager()
+	manager.SetDomainACL([]string{"api.example.com"}, types.Whitelist, false)
+
+	report := LintWithResolver(context.Background(), manager, resolver)
+	if len(report.Findings) != 0 {
+		t.Errorf("没有IP ACL时 LintWithResolver() = %v, 期望没有问题", report.Findings)
+	}
+
+	if err := manager.SetIPACL(nil, types.Blacklist); err != nil {
+		t.Fatalf("SetIPACL() 返回错误: %v", err)
+	}
+	manager.DenyIPFamily(ip.FamilyIPv6)
+	report = LintWithResolver(context.Background(), manager, resolver)
+	if len(report.Findings) != 1 || report.Findings[0].Component != "domain_acl" ||
+		!strings.Contains(report.Findings[0].Message, "2001:db8::10") {
+		t.Errorf("拒绝IPv6后 LintWithResolver() = %v, 期望domain_acl的一个问题", report.Findings)
+	}
+	if !report.Passed() {
+		t.Error("dual_stack_mismatch 是警告，Passed() 应为 true")
+	}
+}
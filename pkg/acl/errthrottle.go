@@ -0,0 +1,154 @@
+package acl
+
+import (
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/cyberspacesec/go-acl/pkg/types"
+)
+
+// ErrorAuditThrottle 是出错检查的审计事件限流配置
+//
+// 字段说明:
+//   - Interval: 汇总周期，小于等于0表示不限流
+//   - Burst: 每个周期内每种检查类型和原因原样产生的事件数，小于1时按1处理
+//
+// 大量无效输入（如扫描器发来的垃圾IP或域名）会使每次检查都产生一个带错误的审计事件，
+// 限流后超出Burst的事件只计数，在周期结束后合并为一个汇总事件，避免压垮日志管道。
+type ErrorAuditThrottle struct {
+	Interval time.Duration
+	Burst    int
+}
+
+// errorThrottle 是ErrorAuditThrottle的运行状态，mu保护windows，持有时不获取其他锁
+type errorThrottle struct {
+	cfg     ErrorAuditThrottle
+	mu      sync.Mutex
+	windows map[errorKey]*errorWindow
+}
+
+// errorKey 是限流的粒度，每种检查类型和原因单独计数
+type errorKey struct {
+	kind   string
+	reason types.Reason
+}
+
+// errorWindow 是一种错误在当前周期内的计数
+type errorWindow struct {
+	start      time.Time
+	emitted    int
+	suppressed uint64
+	// last 是最后一个被抑制的事件，汇总事件沿用其权限和错误信息
+	last AuditEvent
+}
+
+// SetErrorAuditThrottle 设置带错误的审计事件的限流
+//
+// 参数:
+//   - cfg: 限流配置，传入nil表示不限流
+//
+// 限流只作用于Error不为空的事件（如无效输入、未配置ACL、超出时间预算），
+// 正常的允许/拒绝事件不受影响。检查类型（Kind）和原因（Reason）相同的事件共享一个周期:
+// 周期内前Burst个事件照常产生，其余的事件被抑制。下一个带错误的事件到来时，
+// 已经结束的周期中有被抑制的事件时，先产生一个汇总事件:
+//   - Suppressed为被抑制的事件数量，Kind和Reason为这类事件的类型和原因
+//   - Target和RequestID为空，Permission和Error与最后一个被抑制的事件相同
+//
+// 流量停止后剩余的计数不会自动上报，可以定期或在退出前调用FlushErrorAudit。
+// Stats.Errors仍然统计每一次出错的检查。重新设置时未上报的计数被丢弃。
+//
+// 示例:
+//
+//	// 每种错误每分钟最多记录10条，其余的合并为一条汇总
+//	manager.SetErrorAuditThrottle(&acl.ErrorAuditThrottle{Interval: time.Minute, Burst: 10})
+func (m *Manager) SetErrorAuditThrottle(cfg *ErrorAuditThrottle) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if cfg == nil || cfg.Interval <= 0 {
+		m.errorThrottle = nil
+		return
+	}
+	c := *cfg
+	if c.Burst < 1 {
+		c.Burst = 1
+	}
+	m.errorThrottle = &errorThrottle{cfg: c, windows: make(map[errorKey]*errorWindow)}
+}
+
+// FlushErrorAudit 立即为所有有被抑制事件的周期产生汇总事件，并开始新的周期
+//
+// 未设置审计处理函数或限流时不做任何事。
+//
+// 示例:
+//
+//	defer manager.FlushErrorAudit()
+func (m *Manager) FlushErrorAudit() {
+	m.mu.RLock()
+	hook := m.auditHook
+	throttle := m.errorThrottle
+	now := m.now()
+	m.mu.RUnlock()
+
+	if hook == nil || throttle == nil {
+		return
+	}
+	for _, summary := range throttle.flush(now, true) {
+		hook(summary)
+	}
+}
+
+// admit 判断事件是否应该产生，并返回需要先产生的汇总事件
+func (t *errorThrottle) admit(event AuditEvent) ([]AuditEvent, bool) {
+	summaries := t.flush(event.Time, false)
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	key := errorKey{kind: event.Kind, reason: event.Reason}
+	w, ok := t.windows[key]
+	if !ok {
+		t.windows[key] = &errorWindow{start: event.Time, emitted: 1}
+		return summaries, true
+	}
+	if w.emitted < t.cfg.Burst {
+		w.emitted++
+		return summaries, true
+	}
+	w.suppressed++
+	w.last = event
+	return summaries, false
+}
+
+// flush 结束已经到期的周期（all为true时结束所有周期），返回其中有被抑制事件的汇总
+func (t *errorThrottle) flush(now time.Time, all bool) []AuditEvent {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	var summaries []AuditEvent
+	for key, w := range t.windows {
+		if !all && now.Sub(w.start) < t.cfg.Interval {
+			continue
+		}
+		delete(t.windows, key)
+		if w.suppressed == 0 {
+			continue
+		}
+		summaries = append(summaries, AuditEvent{
+			Time:       now,
+			Kind:       key.kind,
+			Permission: w.last.Permission,
+			Reason:     key.reason,
+			Error:      w.last.Error,
+			Suppressed: w.suppressed,
+		})
+	}
+	// 按类型和原因排序，使汇总事件的顺序稳定
+	sort.Slice(summaries, func(i, j int) bool {
+		if summaries[i].Kind != summaries[j].Kind {
+			return summaries[i].Kind < summaries[j].Kind
+		}
+		return summaries[i].Reason < summaries[j].Reason
+	})
+	return summaries
+}
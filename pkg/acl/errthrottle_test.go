@@ -0,0 +1,111 @@
+package acl
+
+import (
+	"testing"
+	"time"
+
+	"github.com/cyberspacesec/go-acl/pkg/types"
+)
+
+// TestSetErrorAuditThrottle 测试带错误的审计事件被限流并按原因汇总
+func TestSetErrorAuditThrottle(t *testing.T) {
+	start := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	clock := types.NewManualClock(start)
+	manager := NewManager()
+	manager.SetClock(clock)
+	if err := manager.SetIPACL([]string{"203.0.113.0/24"}, types.Blacklist); err != nil {
+		t.Fatalf("SetIPACL() 返回错误: %v", err)
+	}
+	var events []AuditEvent
+	manager.SetAuditHook(func(e AuditEvent) { events = append(events, e) })
+	manager.SetErrorAuditThrottle(&ErrorAuditThrottle{Interval: time.Minute, Burst: 2})
+
+	for i := 0; i < 10; i++ {
+		if _, err := manager.CheckIP("not-an-ip"); err == nil {
+			t.Fatal("CheckIP(无效IP) 应返回错误")
+		}
+		manager.CheckIP("203.0.113.7")
+	}
+	// 域名没有配置ACL，属于另一种错误，单独计数
+	manager.CheckDomain("example.com")
+
+	errorEvents := 0
+	for _, e := range events {
+		if e.Error != "" {
+			errorEvents++
+		}
+	}
+	if errorEvents != 3 {
+		t.Errorf("同一周期内带错误的事件数量 = %d, 期望 3（每种错误Burst个）", errorEvents)
+	}
+	if len(events) != 13 {
+		t.Errorf("事件总数 = %d, 期望 13（正常的检查不受限流影响）", len(events))
+	}
+	if got := manager.Stats().Errors; got != 11 {
+		t.Errorf("Stats.Errors = %d, 期望 11", got)
+	}
+
+	// 周期结束后，下一个带错误的事件之前先产生汇总事件
+	events = nil
+	clock.Advance(time.Minute)
+	manager.CheckIP("still-not-an-ip")
+	if len(events) != 2 {
+		t.Fatalf("周期结束后的事件数量 = %d, 期望 2（汇总和新事件）", len(events))
+	}
+	summary := events[0]
+	if summary.Suppressed != 8 || summary.Kind != "ip" || summary.Reason != types.ReasonInvalidInput {
+		t.Errorf("汇总事件 = %+v, 期望ip/invalid_input的8个被抑制事件", summary)
+	}
+	if summary.Target != "" || summary.Error == "" || !summary.Time.Equal(start.Add(time.Minute)) {
+		t.Errorf("汇总事件 = %+v, 期望Target为空、Error不为空、Time为当前时间", summary)
+	}
+	if events[1].Target != "still-not-an-ip" || events[1].Suppressed != 0 {
+		t.Errorf("新周期的第一个事件 = %+v, 期望原样产生", events[1])
+	}
+}
+
+// TestFlushErrorAudit 测试FlushErrorAudit立即上报所有被抑制的计数
+func TestFlushErrorAudit(t *testing.T) {
+	manager := NewManager()
+	var events []AuditEvent
+	manager.SetAuditHook(func(e AuditEvent) { events = append(events, e) })
+
+	// 未设置限流时不产生汇总
+	manager.FlushErrorAudit()
+	if len(events) != 0 {
+		t.Fatalf("未设置限流时FlushErrorAudit产生了 %d 个事件", len(events))
+	}
+
+	manager.SetErrorAuditThrottle(&ErrorAuditThrottle{Interval: time.Hour})
+	for i := 0; i < 5; i++ {
+		manager.CheckDomain("example.com")
+		manager.CheckIP("10.0.0.1")
+	}
+	events = nil
+	manager.FlushErrorAudit()
+	if len(events) != 2 {
+		t.Fatalf("FlushErrorAudit产生的事件数量 = %d, 期望 2", len(events))
+	}
+	for i, kind := range []string{"domain", "ip"} {
+		if events[i].Kind != kind || events[i].Reason != types.ReasonNoACL || events[i].Suppressed != 4 {
+			t.Errorf("events[%d] = %+v, 期望%s/no_acl的4个被抑制事件", i, events[i], kind)
+		}
+	}
+
+	// 计数已清空，新的周期从头开始
+	events = nil
+	manager.FlushErrorAudit()
+	manager.CheckIP("10.0.0.1")
+	if len(events) != 1 || events[0].Suppressed != 0 {
+		t.Errorf("FlushErrorAudit之后的事件 = %+v, 期望一个原样产生的事件", events)
+	}
+
+	// 关闭限流后每个事件都原样产生
+	manager.SetErrorAuditThrottle(nil)
+	events = nil
+	manager.CheckIP("10.0.0.1")
+	manager.CheckIP("10.0.0.1")
+	if len(events) != 2 {
+		t.Errorf("关闭限流后的事件数量 = %d, 期望 2", len(events))
+	}
+}
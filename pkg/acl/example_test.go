@@ -0,0 +1,222 @@
+package acl_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http/httptest"
+	"strings"
+	"time"
+
+	"github.com/cyberspacesec/go-acl/pkg/acl"
+	"github.com/cyberspacesec/go-acl/pkg/expr"
+	"github.com/cyberspacesec/go-acl/pkg/ip"
+	"github.com/cyberspacesec/go-acl/pkg/types"
+)
+
+func ExampleManager() {
+	manager := acl.NewManager()
+	manager.SetDomainACL([]string{"example.com"}, types.Whitelist, true)
+	if err := manager.SetIPACLWithDefaults([]string{"203.0.113.0/24"}, types.Blacklist,
+		[]ip.PredefinedSet{ip.PrivateNetworks, ip.CloudMetadata}, false); err != nil {
+		fmt.Println("设置IP ACL失败:", err)
+		return
+	}
+
+	for _, d := range []string{"api.example.com", "example.org"} {
+		perm, _ := manager.CheckDomain(d)
+		fmt.Println(d, perm)
+	}
+	for _, addr := range []string{"203.0.113.9", "169.254.169.254", "198.51.100.1"} {
+		perm, _ := manager.CheckIP(addr)
+		fmt.Println(addr, perm)
+	}
+	// Output:
+	// api.example.com allowed
+	// example.org denied
+	// 203.0.113.9 denied
+	// 169.254.169.254 denied
+	// 198.51.100.1 allowed
+}
+
+func ExampleManager_SetNamedIPList() {
+	manager := acl.NewManager()
+	manager.SetIPACL([]string{"10.0.0.0/8"}, types.Blacklist)
+	// 命名列表按优先级先于主列表求值，第一个匹配的列表决定结果
+	manager.SetNamedIPList("ops", []string{"10.1.0.0/16"}, types.Whitelist, 10)
+
+	for _, addr := range []string{"10.1.2.3", "10.2.0.1"} {
+		perm, _ := manager.CheckIP(addr)
+		fmt.Println(addr, perm)
+	}
+	for _, l := range manager.NamedIPLists() {
+		fmt.Println(l.Name, l.Type, l.Priority, l.Size)
+	}
+	// Output:
+	// 10.1.2.3 allowed
+	// 10.2.0.1 denied
+	// ops whitelist 10 1
+}
+
+func ExampleManager_CheckRequest() {
+	manager := acl.NewManager()
+	manager.SetIPACL([]string{"203.0.113.0/24"}, types.Blacklist)
+	// 规则按顺序求值，没有规则匹配时由ACL决定
+	rules, err := expr.CompileAll([]string{
+		"port == 22 && !(ip in 10.0.0.0/8) -> deny",
+	})
+	if err != nil {
+		fmt.Println("编译规则失败:", err)
+		return
+	}
+	manager.SetRules(rules)
+
+	for _, req := range []expr.Request{
+		{IP: "198.51.100.1", Port: 22},
+		{IP: "10.1.2.3", Port: 22},
+		{IP: "198.51.100.1", Port: 443},
+	} {
+		perm, _ := manager.CheckRequest(req)
+		fmt.Println(req.IP, req.Port, perm)
+	}
+	// Output:
+	// 198.51.100.1 22 denied
+	// 10.1.2.3 22 allowed
+	// 198.51.100.1 443 allowed
+}
+
+func ExampleNewManagerFromPolicy() {
+	policy, err := acl.ReadPolicy(strings.NewReader(`{
+		"version": 2,
+		"ip": {"type": "blacklist", "ranges": ["203.0.113.0/24"]},
+		"lists": [{"name": "partners", "kind": "ip", "type": "whitelist", "entries": ["203.0.113.7"], "priority": 10}]
+	}`))
+	if err != nil {
+		fmt.Println("解析策略失败:", err)
+		return
+	}
+	manager, err := acl.NewManagerFromPolicy(policy)
+	if err != nil {
+		fmt.Println("应用策略失败:", err)
+		return
+	}
+	for _, addr := range []string{"203.0.113.7", "203.0.113.8"} {
+		perm, _ := manager.CheckIP(addr)
+		fmt.Println(addr, perm)
+	}
+	// Output:
+	// 203.0.113.7 allowed
+	// 203.0.113.8 denied
+}
+
+func ExampleManager_WatchChanges() {
+	manager := acl.NewManager()
+	changes, stop := manager.WatchChanges()
+	defer stop()
+
+	manager.SetIPACL([]string{"198.51.100.7"}, types.Blacklist)
+	manager.SetNamedIPList("temp-bans", []string{"192.0.2.1"}, types.Blacklist, 0)
+
+	for i := 0; i < 2; i++ {
+		event := <-changes
+		fmt.Println(event.Kind, event.Component)
+	}
+	fmt.Println("revision", manager.Revision())
+	// Output:
+	// ip ip_acl
+	// ip ip_list:temp-bans
+	// revision 2
+}
+
+func ExampleManager_PolicyFeedHandler() {
+	primary := acl.NewManager()
+	primary.SetIPACL([]string{"198.51.100.0/24"}, types.Blacklist)
+	server := httptest.NewServer(primary.PolicyFeedHandler())
+	defer server.Close()
+
+	// 其他实例通常用FollowPolicyFeed定期轮询，这里手动获取一次
+	client := acl.PolicyFeedClient{URL: server.URL}
+	feed, err := client.Fetch(context.Background(), "")
+	if err != nil {
+		fmt.Println("获取策略失败:", err)
+		return
+	}
+	policy, _ := feed.Verify()
+	replica := acl.NewManager()
+	replica.ApplyPolicy(policy)
+
+	perm, _ := replica.CheckIP("198.51.100.7")
+	fmt.Println(perm)
+	_, err = client.Fetch(context.Background(), feed.Checksum)
+	fmt.Println(err)
+	// Output:
+	// denied
+	// 策略未改变
+}
+
+func ExampleManager_SetFeedErrorBudget() {
+	manager := acl.NewManager()
+	feed := staticFeed{"203.0.113.0/24", "198.51.100.7", "not-an-ip"}
+	manager.SetIPFeed("threat-intel", feed, types.Blacklist, 0, time.Hour)
+
+	// 默认一行无效即放弃整次更新
+	fmt.Println(manager.RefreshFeed(context.Background(), "threat-intel") != nil)
+
+	// 容忍最多一半的行无效
+	manager.SetFeedErrorBudget("threat-intel", 0.5)
+	manager.RefreshFeed(context.Background(), "threat-intel")
+	status := manager.FeedStatus()[0]
+	fmt.Println(status.Entries, status.Invalid)
+	// Output:
+	// true
+	// 2 1
+}
+
+func ExampleManager_CheckURL() {
+	manager := acl.NewManager()
+	manager.SetIPACL([]string{"127.0.0.0/8", "169.254.169.254"}, types.Blacklist)
+	manager.SetDomainACL([]string{"evil.example"}, types.Blacklist, true)
+
+	for _, u := range []string{
+		"https://api.example.com/v1/items",
+		"http://0x7f000001:8080/admin", // 混淆写法的127.0.0.1
+		"https://cdn.evil.example/payload",
+		"api.example.com/v1", // 缺少协议
+	} {
+		perm, err := manager.CheckURL(u)
+		fmt.Println(u, perm, errors.Is(err, acl.ErrInvalidURL))
+	}
+	// Output:
+	// https://api.example.com/v1/items allowed false
+	// http://0x7f000001:8080/admin denied false
+	// https://cdn.evil.example/payload denied false
+	// api.example.com/v1 denied true
+}
+
+func ExampleManager_SetDevMode() {
+	manager := acl.NewManager()
+	manager.SetIPACLWithDefaults(nil, types.Blacklist,
+		[]ip.PredefinedSet{ip.PrivateNetworks, ip.LoopbackNetworks, ip.CloudMetadata}, false)
+	manager.SetAuditHook(func(e acl.AuditEvent) {
+		if e.WouldDeny != "" {
+			fmt.Println("本应拒绝:", e.Target, e.WouldDeny)
+		}
+	})
+
+	manager.SetDevMode(true)
+	for _, addr := range []string{"127.0.0.1", "169.254.169.254"} {
+		result, _ := manager.CheckIPDetailed(context.Background(), addr)
+		fmt.Println(addr, result.Decision, result.Source)
+	}
+	// Output:
+	// 本应拒绝: 127.0.0.1 matched_blacklist_ip
+	// 127.0.0.1 allowed dev_mode
+	// 169.254.169.254 denied ip_acl
+}
+
+// staticFeed 是返回固定规则的订阅源
+type staticFeed []string
+
+func (f staticFeed) Fetch(context.Context) ([]string, error) {
+	return f, nil
+}
@@ -0,0 +1,284 @@
+package acl
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/cyberspacesec/go-acl/pkg/domain"
+	"github.com/cyberspacesec/go-acl/pkg/ip"
+	"github.com/cyberspacesec/go-acl/pkg/types"
+)
+
+// TraceStep 表示Explain求值过程中的一个步骤
+//
+// 字段说明:
+//   - Stage: 步骤所属阶段，如"normalize"、"ip_family"、"ip_list"、"ip_acl"、"mixed_script"、"domain_list"、"domain_policy"、"domain_acl"
+//   - Detail: 该步骤的说明
+type TraceStep struct {
+	Stage  string `json:"stage"`
+	Detail string `json:"detail"`
+}
+
+// Explanation 表示一次访问检查的完整求值过程
+//
+// 字段说明:
+//   - Target: 原始输入
+//   - Kind: 识别出的目标类型，"ip"或"domain"
+//   - Normalized: 标准化后的值
+//   - Steps: 按执行顺序排列的求值步骤
+//   - MatchedRule: 决定结果的规则，未匹配任何规则（按默认行为决定）时为空
+//   - Decision: 最终结果
+//   - Reason: 拒绝或出错的原因，与Check*Detailed结果的Reason一致，允许访问时为空
+//   - Err: 检查过程中的错误，与CheckIP/CheckDomain返回的错误一致
+type Explanation struct {
+	Target      string           `json:"target"`
+	Kind        string           `json:"kind"`
+	Normalized  string           `json:"normalized"`
+	Steps       []TraceStep      `json:"steps"`
+	MatchedRule string           `json:"matched_rule,omitempty"`
+	Decision    types.Permission `json:"decision"`
+	Reason      types.Reason     `json:"reason,omitempty"`
+	Err         error            `json:"-"`
+}
+
+// String 以多行文本形式输出求值过程，便于在终端中阅读
+func (e Explanation) String() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "target: %s (%s)\n", e.Target, e.Kind)
+	for i, step := range e.Steps {
+		fmt.Fprintf(&b, "  %d. [%s] %s\n", i+1, step.Stage, step.Detail)
+	}
+	fmt.Fprintf(&b, "decision: %s", e.Decision)
+	if e.MatchedRule != "" {
+		fmt.Fprintf(&b, " (rule: %s)", e.MatchedRule)
+	}
+	if e.Reason != "" {
+		fmt.Fprintf(&b, " (reason: %s)", e.Reason)
+	}
+	if e.Err != nil {
+		fmt.Fprintf(&b, " (error: %v)", e.Err)
+	}
+	return b.String()
+}
+
+// Explain 返回检查目标的完整求值过程，类似于 iptables -v --check
+//
+// 参数:
+//   - target: 要检查的IP、域名或URL
+//     例如: "8.8.8.8", "sub.example.com", "https://169.254.169.254/latest"
+//
+// 返回:
+//   - Explanation: 包含标准化步骤、依次查询的ACL、测试和匹配的规则以及最终结果
+//
+// 目标先按域名规则标准化（去除协议、路径、端口等），标准化结果是IP地址
+// （包括十进制、八进制等混淆写法）时按CheckIP的顺序求值，否则按CheckDomain的顺序求值。Explain得出的结果与对应的
+// Check方法一致，但不会触发故障注入、统计和审计事件。
+//
+// 示例:
+//
+//	fmt.Println(manager.Explain("https://169.254.169.254/latest"))
+//	// target: https://169.254.169.254/latest (ip)
+//	//   1. [normalize] strip_scheme: 169.254.169.254/latest
+//	//   2. [normalize] strip_path: 169.254.169.254
+//	//   3. [ip_family] 未限制地址族
+//	//   4. [ip_acl] blacklist，共12条规则
+//	//   5. [ip_acl] 匹配规则 169.254.169.254
+//	// decision: denied (rule: 169.254.169.254) (reason: matched_blacklist_ip)
+func (m *Manager) Explain(target string) Explanation {
+	e := Explanation{Target: target, Decision: types.Denied}
+
+	normalized, steps := domain.NormalizeSteps(target)
+	for _, step := range steps {
+		e.addStep("normalize", "%s: %s", step.Step, step.Result)
+	}
+
+	disabled := m.disabledGroupSet()
+	now := m.expiryTime()
+	if parsed, ok := ip.CanonicalizeIP(normalized); ok {
+		e.Kind = "ip"
+		e.Normalized = parsed.String()
+		if e.Normalized != strings.TrimSuffix(strings.TrimPrefix(normalized, "["), "]") {
+			e.addStep("normalize", "canonicalize_ip: %s", e.Normalized)
+		}
+		m.ipMu.RLock()
+		defer m.ipMu.RUnlock()
+		m.explainIP(&e, disabled, now)
+	} else {
+		e.Kind = "domain"
+		e.Normalized = normalized
+		// 混合文字检查读取mu保护的配置，需在获取domainMu之前完成
+		if !m.explainMixedScript(&e) {
+			m.domainMu.RLock()
+			defer m.domainMu.RUnlock()
+			m.explainDomain(&e, disabled, now)
+		}
+	}
+
+	switch {
+	case e.Err != nil:
+		e.Reason = errorReason(e.Err)
+	case e.Decision == types.Allowed:
+		e.Reason = ""
+	}
+	return e
+}
+
+// explainIP 按checkIP的顺序记录IP的求值过程，调用方需持有ipMu的读锁
+func (m *Manager) explainIP(e *Explanation, disabled map[string]struct{}, now time.Time) {
+	if m.deniedFamily != ip.FamilyAny {
+		if m.isDeniedFamily(e.Normalized) {
+			e.addStep("ip_family", "地址族%s被整体拒绝", m.deniedFamily)
+			e.MatchedRule = "family:" + m.deniedFamily.String()
+			e.Decision, e.Reason = types.Denied, types.ReasonDeniedFamily
+			return
+		}
+		e.addStep("ip_family", "地址族%s被整体拒绝，目标不属于该地址族", m.deniedFamily)
+	} else {
+		e.addStep("ip_family", "未限制地址族")
+	}
+
+	if explainNamedLists(e, "ip_list", m.ipLists, disabled, now) {
+		return
+	}
+
+	if m.ipACL == nil {
+		if perm, ok := namedListsDefault(m.ipLists, disabled); ok {
+			e.Decision, e.Reason = perm, types.ReasonDefaultDeny
+			e.addStep("ip_acl", "未配置，命名列表均未命中，结果为%s", e.Decision)
+			return
+		}
+		e.addStep("ip_acl", "未配置")
+		e.Err = types.ErrNoACL
+		return
+	}
+
+	e.addStep("ip_acl", "%s，共%d条规则", m.ipACL.GetListType(), len(m.ipACL.GetIPRanges()))
+	e.Decision, e.Err = m.ipACL.Check(e.Normalized)
+	e.Reason = types.DenyReason("ip", m.ipACL.GetListType())
+	if e.Err != nil {
+		e.addStep("ip_acl", "检查失败: %v", e.Err)
+		return
+	}
+
+	if rule, matched, _ := m.ipACL.Match(e.Normalized); matched {
+		e.MatchedRule = rule
+		e.addStep("ip_acl", "匹配规则 %s", rule)
+	} else {
+		e.addStep("ip_acl", "未匹配任何规则，使用%s的默认行为", m.ipACL.GetListType())
+	}
+}
+
+// explainDomain 按checkDomain的顺序记录域名的求值过程，调用方需持有domainMu的读锁
+func (m *Manager) explainDomain(e *Explanation, disabled map[string]struct{}, now time.Time) {
+	if explainNamedLists(e, "domain_list", m.domainLists, disabled, now) {
+		return
+	}
+
+	if m.domainACL == nil {
+		if perm, ok := namedListsDefault(m.domainLists, disabled); ok {
+			e.Decision, e.Reason = perm, types.ReasonDefaultDeny
+			e.addStep("domain_acl", "未配置，命名列表均未命中，结果为%s", e.Decision)
+			return
+		}
+		e.addStep("domain_acl", "未配置")
+		e.Err = types.ErrNoACL
+		return
+	}
+
+	e.Decision, e.Err = m.domainACL.Check(e.Target)
+	e.Reason = types.DenyReason("domain", m.domainACL.GetListType())
+	if e.Err != nil {
+		e.addStep("domain_acl", "检查失败: %v", e.Err)
+		return
+	}
+
+	if policies := m.domainACL.GetPolicies(); len(policies) > 0 {
+		if node, perm, ok := m.domainACL.MatchPolicy(e.Target); ok {
+			e.addStep("domain_policy", "共%d条节点策略，命中节点 %s: %s", len(policies), node, perm)
+			e.MatchedRule = "policy:" + node
+			e.Reason = types.ReasonDomainPolicy
+			return
+		}
+		e.addStep("domain_policy", "共%d条节点策略，均未命中", len(policies))
+	}
+
+	subdomains := "不含子域名"
+	if m.domainACL.IncludesSubdomains() {
+		subdomains = "包含子域名"
+	}
+	e.addStep("domain_acl", "%s（%s），共%d条规则", m.domainACL.GetListType(), subdomains, len(m.domainACL.GetDomains()))
+
+	if rule, matched := m.domainACL.Match(e.Target); matched {
+		if exception, ok := m.domainACL.MatchException(e.Target); ok {
+			e.MatchedRule = "!" + exception
+			e.addStep("domain_acl", "匹配规则 %s，但命中例外 !%s，视为未匹配，使用%s的默认行为", rule, exception, m.domainACL.GetListType())
+			return
+		}
+		e.MatchedRule = rule
+		parsed, _ := domain.ParseRule(rule)
+		switch {
+		case parsed.Kind != domain.MatchDefault:
+			e.addStep("domain_acl", "按%s方式匹配规则 %s", parsed.Kind, rule)
+		case rule == e.Normalized:
+			e.addStep("domain_acl", "完全匹配规则 %s", rule)
+		default:
+			e.addStep("domain_acl", "作为子域名匹配规则 %s", rule)
+		}
+	} else {
+		e.addStep("domain_acl", "未匹配任何规则，使用%s的默认行为", m.domainACL.GetListType())
+	}
+}
+
+// explainNamedLists 按checkNamedIPLists/checkNamedDomainLists的顺序记录命名列表的求值过程
+// 返回true表示某个列表已决定结果（或出错），调用方需持有对应的读锁
+func explainNamedLists(e *Explanation, stage string, lists []namedList, disabled map[string]struct{}, now time.Time) bool {
+	for _, l := range lists {
+		info := l.info()
+		if !groupEnabled(disabled, l.group) {
+			e.addStep(stage, "列表 %s 所属的规则组 %s 已停用，跳过", info.Name, info.Group)
+			continue
+		}
+
+		var perm types.Permission
+		var err error
+		if l.ip != nil {
+			perm, err = l.ip.Check(e.Normalized)
+			if errors.Is(err, ip.ErrFamilyNotAllowed) {
+				e.addStep(stage, "列表 %s 限制了地址族，跳过", info.Name)
+				continue
+			}
+		} else {
+			perm, err = l.domain.Check(e.Target)
+		}
+		if err != nil {
+			e.Decision, e.Err = types.Denied, err
+			e.addStep(stage, "列表 %s 检查失败: %v", info.Name, err)
+			return true
+		}
+
+		if perm != defaultPermission(info.Type) {
+			if l.expiredHit(now, e.Normalized) {
+				e.addStep(stage, "列表 %s 命中的临时条目已到期，视为未命中", info.Name)
+				continue
+			}
+			e.Decision = perm
+			if l.ip != nil {
+				e.Reason = types.DenyReason("ip", info.Type)
+			} else {
+				e.Reason = domainDenyReason(l.domain, e.Target)
+			}
+			e.MatchedRule = "list:" + info.Name
+			e.addStep(stage, "列表 %s（%s，优先级%d）命中: %s", info.Name, info.Type, info.Priority, perm)
+			return true
+		}
+		e.addStep(stage, "列表 %s（%s，优先级%d）未命中", info.Name, info.Type, info.Priority)
+	}
+	return false
+}
+
+// addStep 追加一个求值步骤
+func (e *Explanation) addStep(stage, format string, args ...interface{}) {
+	e.Steps = append(e.Steps, TraceStep{Stage: stage, Detail: fmt.Sprintf(format, args...)})
+}
@@ -0,0 +1,95 @@
+package acl
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/cyberspacesec/go-acl/pkg/ip"
+	"github.com/cyberspacesec/go-acl/pkg/types"
+)
+
+// TestExplain 测试Explain的求值过程输出
+func TestExplain(t *testing.T) {
+	manager := NewManager()
+
+	// 未配置ACL
+	e := manager.Explain("8.8.8.8")
+	if e.Kind != "ip" || !errors.Is(e.Err, types.ErrNoACL) {
+		t.Errorf("未配置时 Explain() = %+v", e)
+	}
+
+	if err := manager.SetIPACL([]string{"10.0.0.0/8", "10.1.0.0/16", "169.254.169.254"}, types.Blacklist); err != nil {
+		t.Fatalf("SetIPACL() 返回错误: %v", err)
+	}
+	manager.SetDomainACL([]string{"example.com", "evil.org", `regex:^cdn[0-9]+\.net$`}, types.Whitelist, true)
+
+	tests := []struct {
+		name       string
+		target     string
+		kind       string
+		normalized string
+		decision   types.Permission
+		rule       string
+		stages     []string
+	}{
+		{"IP最具体的规则", "10.1.2.3", "ip", "10.1.2.3", types.Denied, "10.1.0.0/16", []string{"ip_family", "ip_acl", "ip_acl"}},
+		{"IP未匹配", "8.8.8.8", "ip", "8.8.8.8", types.Allowed, "", []string{"ip_family", "ip_acl", "ip_acl"}},
+		{"URL中的IP", "https://169.254.169.254/latest", "ip", "169.254.169.254", types.Denied, "169.254.169.254",
+			[]string{"normalize", "normalize", "ip_family", "ip_acl", "ip_acl"}},
+		{"域名完全匹配", "example.com", "domain", "example.com", types.Allowed, "example.com", []string{"domain_acl", "domain_acl"}},
+		{"子域名匹配", "HTTPS://WWW.Api.Example.com:443/x", "domain", "api.example.com", types.Allowed, "example.com",
+			[]string{"normalize", "normalize", "normalize", "normalize", "normalize", "domain_acl", "domain_acl"}},
+		{"白名单未匹配", "other.net", "domain", "other.net", types.Denied, "", []string{"domain_acl", "domain_acl"}},
+		{"正则表达式规则", "cdn42.net", "domain", "cdn42.net", types.Allowed, `regex:^cdn[0-9]+\.net$`, []string{"domain_acl", "domain_acl"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			e := manager.Explain(tt.target)
+			if e.Err != nil {
+				t.Fatalf("Explain(%q) 返回错误: %v", tt.target, e.Err)
+			}
+			if e.Kind != tt.kind || e.Normalized != tt.normalized || e.Decision != tt.decision || e.MatchedRule != tt.rule {
+				t.Errorf("Explain(%q) = kind %s, normalized %s, decision %v, rule %q; 期望 %s, %s, %v, %q",
+					tt.target, e.Kind, e.Normalized, e.Decision, e.MatchedRule, tt.kind, tt.normalized, tt.decision, tt.rule)
+			}
+			var stages []string
+			for _, s := range e.Steps {
+				stages = append(stages, s.Stage)
+			}
+			if strings.Join(stages, ",") != strings.Join(tt.stages, ",") {
+				t.Errorf("Explain(%q) 步骤 = %v, 期望 %v", tt.target, stages, tt.stages)
+			}
+		})
+	}
+
+	// 结果与Check方法一致
+	for _, target := range []string{"10.1.2.3", "8.8.8.8"} {
+		perm, _ := manager.CheckIP(target)
+		if e := manager.Explain(target); e.Decision != perm {
+			t.Errorf("Explain(%s).Decision = %v, CheckIP = %v", target, e.Decision, perm)
+		}
+	}
+
+	// 节点策略优先
+	if err := manager.domainACL.SetPolicy("bad.example.com", types.Denied); err != nil {
+		t.Fatalf("SetPolicy() 返回错误: %v", err)
+	}
+	e = manager.Explain("x.bad.example.com")
+	if e.Decision != types.Denied || e.MatchedRule != "policy:bad.example.com" {
+		t.Errorf("节点策略 Explain() = %+v", e)
+	}
+
+	// 地址族整体拒绝
+	manager.DenyIPFamily(ip.FamilyIPv6)
+	e = manager.Explain("[2001:db8::1]:443")
+	if e.Kind != "ip" || e.Normalized != "2001:db8::1" || e.Decision != types.Denied || e.MatchedRule != "family:ipv6" {
+		t.Errorf("地址族拒绝 Explain() = %+v", e)
+	}
+
+	out := e.String()
+	if !strings.Contains(out, "decision: denied") || !strings.Contains(out, "[ip_family]") {
+		t.Errorf("String() = %q", out)
+	}
+}
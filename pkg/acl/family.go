@@ -0,0 +1,59 @@
+package acl
+
+import (
+	"net"
+	"strings"
+
+	"github.com/cyberspacesec/go-acl/pkg/ip"
+)
+
+// DenyIPFamily 拒绝某个地址族的所有IP访问
+//
+// 参数:
+//   - family: 要整体拒绝的地址族
+//     ip.FamilyIPv6: 拒绝所有IPv6地址
+//     ip.FamilyIPv4: 拒绝所有IPv4地址
+//     ip.FamilyAny: 取消地址族拒绝
+//
+// 设置后，CheckIP对该地址族的所有地址直接返回types.Denied（无错误），
+// 不再查询IP ACL，即使尚未设置IP ACL也同样生效。
+// 适用于网络环境不支持IPv6等必须整体禁用某个地址族的场景。
+//
+// 示例:
+//
+//	// 遗留环境中禁用所有IPv6流量
+//	manager.DenyIPFamily(ip.FamilyIPv6)
+//
+//	perm, _ := manager.CheckIP("2001:db8::1") // types.Denied
+func (m *Manager) DenyIPFamily(family ip.Family) {
+	now := m.Clock().Now()
+
+	m.ipMu.Lock()
+	defer m.ipMu.Unlock()
+	m.deniedFamily = family
+	m.notifyChange("ip", "family", now)
+}
+
+// GetDeniedIPFamily 获取被整体拒绝的地址族
+//
+// 返回:
+//   - ip.Family: 被拒绝的地址族，ip.FamilyAny表示没有被拒绝的地址族
+func (m *Manager) GetDeniedIPFamily() ip.Family {
+	m.ipMu.RLock()
+	defer m.ipMu.RUnlock()
+	return m.deniedFamily
+}
+
+// isDeniedFamily 判断IP是否属于被整体拒绝的地址族
+// 调用者必须持有读锁；无效IP返回false，交由IP ACL报告错误
+func (m *Manager) isDeniedFamily(ipStr string) bool {
+	if m.deniedFamily == ip.FamilyAny {
+		return false
+	}
+
+	parsedIP := net.ParseIP(strings.TrimSpace(ipStr))
+	if parsedIP == nil {
+		return false
+	}
+	return m.deniedFamily.Contains(parsedIP)
+}
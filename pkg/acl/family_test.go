@@ -0,0 +1,59 @@
+package acl
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/cyberspacesec/go-acl/pkg/ip"
+	"github.com/cyberspacesec/go-acl/pkg/types"
+)
+
+// TestDenyIPFamily 测试整体拒绝某个地址族
+func TestDenyIPFamily(t *testing.T) {
+	manager := NewManager()
+
+	// 未设置IP ACL时也生效
+	manager.DenyIPFamily(ip.FamilyIPv6)
+	if got := manager.GetDeniedIPFamily(); got != ip.FamilyIPv6 {
+		t.Errorf("GetDeniedIPFamily() = %v, 期望 ipv6", got)
+	}
+	perm, err := manager.CheckIP("2001:db8::1")
+	if err != nil || perm != types.Denied {
+		t.Errorf("CheckIP(IPv6) = %v, %v, 期望 denied, nil", perm, err)
+	}
+	if _, err := manager.CheckIP("8.8.8.8"); !errors.Is(err, types.ErrNoACL) {
+		t.Errorf("CheckIP(IPv4) 错误 = %v, 期望 ErrNoACL", err)
+	}
+
+	// 白名单中包含的IPv6地址同样被拒绝
+	if err := manager.SetIPACL([]string{"2001:db8::/32", "8.8.8.8"}, types.Whitelist); err != nil {
+		t.Fatalf("SetIPACL() 返回错误: %v", err)
+	}
+	if perm, _ := manager.CheckIP("2001:db8::1"); perm != types.Denied {
+		t.Errorf("CheckIP(白名单IPv6) = %v, 期望 denied", perm)
+	}
+	if perm, _ := manager.CheckIP("8.8.8.8"); perm != types.Allowed {
+		t.Errorf("CheckIP(白名单IPv4) = %v, 期望 allowed", perm)
+	}
+
+	// 拒绝IPv4
+	manager.DenyIPFamily(ip.FamilyIPv4)
+	if perm, _ := manager.CheckIP("8.8.8.8"); perm != types.Denied {
+		t.Errorf("CheckIP(IPv4) = %v, 期望 denied", perm)
+	}
+	if perm, _ := manager.CheckIP("2001:db8::1"); perm != types.Allowed {
+		t.Errorf("CheckIP(IPv6) = %v, 期望 allowed", perm)
+	}
+
+	// 取消限制
+	manager.DenyIPFamily(ip.FamilyAny)
+	if perm, _ := manager.CheckIP("8.8.8.8"); perm != types.Allowed {
+		t.Errorf("取消限制后 CheckIP(IPv4) = %v, 期望 allowed", perm)
+	}
+
+	// 无效IP仍由IP ACL报告错误
+	manager.DenyIPFamily(ip.FamilyIPv6)
+	if _, err := manager.CheckIP("invalid"); !errors.Is(err, ip.ErrInvalidIP) {
+		t.Errorf("CheckIP(无效IP) 错误 = %v, 期望 ErrInvalidIP", err)
+	}
+}
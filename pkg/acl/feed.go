@@ -0,0 +1,544 @@
+package acl
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"sort"
+	"time"
+
+	"github.com/cyberspacesec/go-acl/pkg/config"
+	"github.com/cyberspacesec/go-acl/pkg/domain"
+	"github.com/cyberspacesec/go-acl/pkg/ip"
+	"github.com/cyberspacesec/go-acl/pkg/types"
+)
+
+var (
+	// ErrFeedNotFound 表示指定名称的订阅源不存在
+	ErrFeedNotFound = errors.New("订阅源不存在")
+
+	// ErrFeedErrorBudget 表示订阅源中无效行的比例超过了SetFeedErrorBudget设置的容忍度
+	ErrFeedErrorBudget = errors.New("订阅源无效行过多")
+)
+
+// DefaultFeedMaxBytes 是HTTPFeed和PolicyFeedClient未设置MaxBytes时最多读取的响应体大小
+const DefaultFeedMaxBytes = 64 << 20
+
+// feedMaxWait 是RunFeeds两次检查之间的最长等待时间，保证运行期间新增的订阅源能及时被刷新
+const feedMaxWait = time.Second
+
+// FeedSource 是订阅源的数据来源
+//
+// Fetch返回规则列表（IP/CIDR或域名），返回错误时保留上一次成功加载的列表。
+// 实现fmt.Stringer时，String()的结果会出现在FeedStatus.Source中。
+type FeedSource interface {
+	Fetch(ctx context.Context) ([]string, error)
+}
+
+// HTTPFeed 通过HTTP GET下载的订阅源
+//
+// 响应体按config.ParseLines的规则解析：每行一条规则，忽略空行和#注释。
+// 非2xx的响应和超过MaxBytes的响应体视为失败。
+//
+// 字段说明:
+//   - URL: 下载地址
+//   - Client: 使用的HTTP客户端，nil表示http.DefaultClient
+//   - Gzip: 响应体以gzip标识开头时解压，用于以".gz"文件发布的订阅源
+//     （Content-Encoding: gzip由http.Client处理，不需要此选项）
+//   - MaxBytes: 最多读取的字节数，解压前和解压后分别受此限制，0表示DefaultFeedMaxBytes
+type HTTPFeed struct {
+	URL      string
+	Client   *http.Client
+	Gzip     bool
+	MaxBytes int64
+}
+
+// Fetch 下载并解析规则列表
+func (f HTTPFeed) Fetch(ctx context.Context) ([]string, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, f.URL, nil)
+	if err != nil {
+		return nil, err
+	}
+	client := f.Client
+	if client == nil {
+		client = http.DefaultClient
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return nil, fmt.Errorf("下载 %s 失败: %s", f.URL, resp.Status)
+	}
+	opts := []config.ParseOption{config.WithMaxBytes(feedMaxBytes(f.MaxBytes))}
+	if f.Gzip {
+		opts = append(opts, config.WithGunzip())
+	}
+	return config.ParseLines(resp.Body, opts...)
+}
+
+// feedMaxBytes 返回MaxBytes字段对应的读取上限
+func feedMaxBytes(maxBytes int64) int64 {
+	if maxBytes <= 0 {
+		return DefaultFeedMaxBytes
+	}
+	return maxBytes
+}
+
+// String 返回下载地址
+func (f HTTPFeed) String() string {
+	return f.URL
+}
+
+// FileFeed 从本地文件读取的订阅源，文件格式与config.ReadLines相同
+//
+// 适用于由其他进程（如cron、配置管理工具）定期更新的文件。
+type FileFeed string
+
+// Fetch 读取并解析规则列表
+func (f FileFeed) Fetch(context.Context) ([]string, error) {
+	return config.ReadLines(string(f))
+}
+
+// String 返回文件路径
+func (f FileFeed) String() string {
+	return string(f)
+}
+
+// FeedStatus 表示订阅源的刷新状态
+//
+// 字段说明:
+//   - Name: 订阅源名称，与其维护的命名列表同名
+//   - Kind: "ip"或"domain"
+//   - Source: 数据来源的描述，如下载地址
+//   - Interval: 刷新间隔
+//   - LastAttempt: 最近一次刷新的时间，从未刷新时为零值
+//   - LastSuccess: 最近一次刷新成功的时间，从未成功时为零值
+//   - LastError: 最近一次刷新的错误信息，成功时为空
+//   - Entries: 最近一次成功加载的规则数量
+//   - Invalid: 最近一次成功加载时在容忍范围内被跳过的无效行数，见SetFeedErrorBudget
+//   - NextRefresh: 下一次计划刷新的时间
+//   - Checksum: 当前列表内容的SHA-256，只在设置了SetFeedCache时记录
+//   - FromCache: 当前列表是否从磁盘缓存加载（远端不可达时），见SetFeedCache
+//   - CachedAt: 从磁盘缓存加载时，缓存内容被下载的时间
+//   - Stale: 从磁盘缓存加载的内容是否已超过缓存有效期
+type FeedStatus struct {
+	Name        string        `json:"name"`
+	Kind        string        `json:"kind"`
+	Source      string        `json:"source"`
+	Interval    time.Duration `json:"interval"`
+	LastAttempt time.Time     `json:"last_attempt,omitempty"`
+	LastSuccess time.Time     `json:"last_success,omitempty"`
+	LastError   string        `json:"last_error,omitempty"`
+	Entries     int           `json:"entries"`
+	Invalid     int           `json:"invalid,omitempty"`
+	NextRefresh time.Time     `json:"next_refresh"`
+	Checksum    string        `json:"sha256,omitempty"`
+	FromCache   bool          `json:"from_cache,omitempty"`
+	CachedAt    time.Time     `json:"cached_at,omitempty"`
+	Stale       bool          `json:"stale,omitempty"`
+}
+
+// Current 判断订阅源是否处于最新状态：至少成功刷新过一次，且最近一次刷新没有失败
+func (s FeedStatus) Current() bool {
+	return !s.LastSuccess.IsZero() && s.LastError == ""
+}
+
+// feed 是Manager中的一个订阅源
+type feed struct {
+	status            FeedStatus
+	source            FeedSource
+	listType          types.ListType
+	includeSubdomains bool
+	priority          int
+	// errorBudget 是可容忍的无效行比例，小于0表示不逐行检查，由feedMu保护
+	errorBudget float64
+}
+
+// SetIPFeed 设置一个定期刷新的IP订阅源
+//
+// 参数:
+//   - name: 订阅源名称，刷新成功后以此名称调用SetNamedIPList，已存在同名订阅源时替换
+//   - source: 数据来源，如HTTPFeed、FileFeed
+//   - listType: 列表类型
+//   - priority: 命名列表的优先级
+//   - interval: 刷新间隔，必须大于0
+//
+// 返回:
+//   - error: interval不大于0时返回错误
+//
+// 订阅源在RunFeeds运行时按间隔刷新，也可以调用RefreshFeed立即刷新。
+// 刷新失败（下载失败、包含无效的IP等）时保留上一次成功加载的列表，
+// 失败信息可以通过FeedStatus查看。
+//
+// 示例:
+//
+//	manager.SetIPFeed("threat-intel", acl.HTTPFeed{URL: "https://feeds.example.com/ips.txt"},
+//	    types.Blacklist, 0, 15*time.Minute)
+//	go manager.RunFeeds(ctx)
+func (m *Manager) SetIPFeed(name string, source FeedSource, listType types.ListType, priority int, interval time.Duration) error {
+	return m.setFeed(&feed{
+		status:      FeedStatus{Name: name, Kind: "ip", Interval: interval},
+		source:      source,
+		listType:    listType,
+		priority:    priority,
+		errorBudget: -1,
+	})
+}
+
+// SetDomainFeed 设置一个定期刷新的域名订阅源
+//
+// 参数:
+//   - name: 订阅源名称，刷新成功后以此名称调用SetNamedDomainList，已存在同名订阅源时替换
+//   - source: 数据来源，如HTTPFeed、FileFeed
+//   - listType: 列表类型
+//   - includeSubdomains: 是否包含子域名
+//   - priority: 命名列表的优先级
+//   - interval: 刷新间隔，必须大于0
+//
+// 返回:
+//   - error: interval不大于0时返回错误
+//
+// 刷新行为与SetIPFeed相同。
+func (m *Manager) SetDomainFeed(name string, source FeedSource, listType types.ListType, includeSubdomains bool, priority int, interval time.Duration) error {
+	return m.setFeed(&feed{
+		status:            FeedStatus{Name: name, Kind: "domain", Interval: interval},
+		source:            source,
+		listType:          listType,
+		includeSubdomains: includeSubdomains,
+		priority:          priority,
+		errorBudget:       -1,
+	})
+}
+
+// SetFeedErrorBudget 设置订阅源可容忍的无效行比例
+//
+// 参数:
+//   - name: 订阅源名称
+//   - maxInvalid: 无效行占全部规则行的最大比例，如0.01表示最多1%；
+//     为0表示任何无效行都使刷新失败，小于0表示恢复默认行为
+//
+// 返回:
+//   - error: 订阅源不存在时返回ErrFeedNotFound，maxInvalid不小于1时返回错误
+//
+// 默认情况下，IP订阅源中只要有一行不是有效的IP或CIDR，整次刷新就会失败；
+// 域名订阅源中无法解析的规则（见domain.ParseRule）则被静默忽略。
+// 设置容忍度后每一行都会被检查：无效行的比例不超过maxInvalid时跳过无效行、加载其余的规则，
+// 跳过的行数记录在FeedStatus.Invalid中；超过时返回包装了ErrFeedErrorBudget的错误，
+// 保留上一次成功加载的列表。第三方订阅源偶尔混入的格式错误因此不会阻止更新，
+// 而被截断或替换成错误页面的下载仍会被拒绝。
+//
+// 用SetIPFeed或SetDomainFeed替换同名订阅源时保留此设置。
+//
+// 示例:
+//
+//	manager.SetIPFeed("threat-intel", acl.HTTPFeed{URL: url}, types.Blacklist, 0, 15*time.Minute)
+//	manager.SetFeedErrorBudget("threat-intel", 0.01)
+func (m *Manager) SetFeedErrorBudget(name string, maxInvalid float64) error {
+	if maxInvalid >= 1 {
+		return fmt.Errorf("无效行的比例必须小于1: %v", maxInvalid)
+	}
+	if maxInvalid < 0 {
+		maxInvalid = -1
+	}
+
+	m.feedMu.Lock()
+	defer m.feedMu.Unlock()
+
+	f, ok := m.feeds[name]
+	if !ok {
+		return ErrFeedNotFound
+	}
+	f.errorBudget = maxInvalid
+	return nil
+}
+
+// RemoveFeed 移除订阅源
+//
+// 参数:
+//   - name: 订阅源名称
+//
+// 返回:
+//   - error: 如果订阅源不存在，返回ErrFeedNotFound
+//
+// 订阅源已加载的命名列表保持不变，不再需要时请调用RemoveNamedIPList或RemoveNamedDomainList。
+func (m *Manager) RemoveFeed(name string) error {
+	m.feedMu.Lock()
+	defer m.feedMu.Unlock()
+
+	if _, ok := m.feeds[name]; !ok {
+		return ErrFeedNotFound
+	}
+	delete(m.feeds, name)
+	return nil
+}
+
+// FeedStatus 返回所有订阅源的刷新状态
+//
+// 返回:
+//   - []FeedStatus: 按名称排序的订阅源状态，没有订阅源时为空
+//
+// 示例:
+//
+//	for _, s := range manager.FeedStatus() {
+//	    if !s.Current() {
+//	        log.Printf("订阅源 %s 不是最新的: 最近成功于%s，错误: %s", s.Name, s.LastSuccess, s.LastError)
+//	    }
+//	}
+func (m *Manager) FeedStatus() []FeedStatus {
+	now := m.Clock().Now()
+
+	m.feedMu.Lock()
+	defer m.feedMu.Unlock()
+
+	if len(m.feeds) == 0 {
+		return nil
+	}
+	statuses := make([]FeedStatus, 0, len(m.feeds))
+	for _, f := range m.feeds {
+		status := f.status
+		status.Stale = status.FromCache && m.feedCacheMaxAge > 0 && now.Sub(status.CachedAt) > m.feedCacheMaxAge
+		statuses = append(statuses, status)
+	}
+	sort.Slice(statuses, func(i, j int) bool {
+		return statuses[i].Name < statuses[j].Name
+	})
+	return statuses
+}
+
+// RefreshFeed 立即刷新指定的订阅源
+//
+// 参数:
+//   - ctx: 控制下载的上下文
+//   - name: 订阅源名称
+//
+// 返回:
+//   - error: 订阅源不存在时返回ErrFeedNotFound，否则返回本次刷新的错误
+//
+// 无论成功与否，下一次计划刷新的时间都从本次刷新起重新计算。
+func (m *Manager) RefreshFeed(ctx context.Context, name string) error {
+	m.feedMu.Lock()
+	f, ok := m.feeds[name]
+	m.feedMu.Unlock()
+	if !ok {
+		return ErrFeedNotFound
+	}
+	return m.refreshFeed(ctx, f)
+}
+
+// RunFeeds 按各订阅源的间隔刷新订阅源，直到ctx被取消
+//
+// 参数:
+//   - ctx: 控制刷新循环生命周期的上下文
+//
+// 返回:
+//   - error: ctx被取消时返回ctx.Err()
+//
+// 启动时立即刷新所有从未刷新过的订阅源。运行期间新增的订阅源在一秒内开始刷新。
+// 刷新失败不会终止循环，下一个间隔会重试。
+// 此方法会阻塞，通常在单独的goroutine中调用。
+//
+// 示例:
+//
+//	ctx, cancel := context.WithCancel(context.Background())
+//	defer cancel()
+//	go manager.RunFeeds(ctx)
+func (m *Manager) RunFeeds(ctx context.Context) error {
+	for {
+		next := m.refreshDueFeeds(ctx)
+
+		wait := feedMaxWait
+		if !next.IsZero() {
+			if d := next.Sub(m.Clock().Now()); d < wait {
+				wait = d
+			}
+		}
+		if wait < 0 {
+			wait = 0
+		}
+
+		timer := time.NewTimer(wait)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return ctx.Err()
+		case <-timer.C:
+		}
+	}
+}
+
+// setFeed 加入或替换订阅源，替换时保留刷新状态
+func (m *Manager) setFeed(f *feed) error {
+	if f.status.Interval <= 0 {
+		return errors.New("刷新间隔必须大于0")
+	}
+	f.status.Source = fmt.Sprintf("%T", f.source)
+	if s, ok := f.source.(fmt.Stringer); ok {
+		f.status.Source = s.String()
+	}
+
+	m.feedMu.Lock()
+	defer m.feedMu.Unlock()
+
+	if old, ok := m.feeds[f.status.Name]; ok && old.status.Kind == f.status.Kind {
+		f.errorBudget = old.errorBudget
+		f.status.LastAttempt = old.status.LastAttempt
+		f.status.LastSuccess = old.status.LastSuccess
+		f.status.LastError = old.status.LastError
+		f.status.Entries = old.status.Entries
+		f.status.Invalid = old.status.Invalid
+		f.status.NextRefresh = old.status.NextRefresh
+		f.status.Checksum = old.status.Checksum
+		f.status.FromCache = old.status.FromCache
+		f.status.CachedAt = old.status.CachedAt
+	}
+	if m.feeds == nil {
+		m.feeds = make(map[string]*feed)
+	}
+	m.feeds[f.status.Name] = f
+	return nil
+}
+
+// refreshDueFeeds 刷新所有到期的订阅源，返回最早的下一次刷新时间，没有订阅源时返回零值
+func (m *Manager) refreshDueFeeds(ctx context.Context) time.Time {
+	now := m.Clock().Now()
+
+	m.feedMu.Lock()
+	var due []*feed
+	for _, f := range m.feeds {
+		if !f.status.NextRefresh.After(now) {
+			due = append(due, f)
+		}
+	}
+	m.feedMu.Unlock()
+
+	for _, f := range due {
+		if ctx.Err() != nil {
+			break
+		}
+		_ = m.refreshFeed(ctx, f)
+	}
+
+	m.feedMu.Lock()
+	defer m.feedMu.Unlock()
+	var next time.Time
+	for _, f := range m.feeds {
+		if next.IsZero() || f.status.NextRefresh.Before(next) {
+			next = f.status.NextRefresh
+		}
+	}
+	return next
+}
+
+// refreshFeed 下载订阅源并更新对应的命名列表和刷新状态
+//
+// 设置了磁盘缓存时，成功下载的内容写入缓存；从未成功过的订阅源刷新失败时从缓存加载。
+func (m *Manager) refreshFeed(ctx context.Context, f *feed) error {
+	entries, err := f.source.Fetch(ctx)
+	var invalid int
+	if err == nil {
+		entries, invalid, err = m.filterFeed(f, entries)
+	}
+	if err == nil {
+		err = m.applyFeed(f, entries)
+	}
+	now := m.Clock().Now()
+	cacheDir, _ := m.feedCache()
+
+	var checksum string
+	if err == nil && cacheDir != "" {
+		// 缓存写入失败不影响本次刷新，下一次刷新成功时重试
+		checksum, _ = storeFeedCache(cacheDir, feedCacheIndex{
+			Name:      f.status.Name,
+			Kind:      f.status.Kind,
+			Source:    f.status.Source,
+			FetchedAt: now,
+		}, entries)
+	}
+
+	var cached feedCacheIndex
+	var fromCache bool
+	if err != nil && cacheDir != "" && m.needsFeedCache(f) {
+		if index, cachedEntries, cacheErr := loadFeedCache(cacheDir, f.status.Name, f.status.Kind); cacheErr == nil {
+			cached, fromCache = index, m.applyFeed(f, cachedEntries) == nil
+		}
+	}
+
+	m.feedMu.Lock()
+	defer m.feedMu.Unlock()
+	f.status.LastAttempt = now
+	f.status.NextRefresh = now.Add(f.status.Interval)
+	if err != nil {
+		f.status.LastError = err.Error()
+		if fromCache {
+			f.status.Entries = cached.Entries
+			f.status.Checksum = cached.Checksum
+			f.status.FromCache = true
+			f.status.CachedAt = cached.FetchedAt
+		}
+		return err
+	}
+	f.status.LastSuccess = now
+	f.status.LastError = ""
+	f.status.Entries = len(entries)
+	f.status.Invalid = invalid
+	f.status.Checksum = checksum
+	f.status.FromCache = false
+	f.status.CachedAt = time.Time{}
+	return nil
+}
+
+// filterFeed 按订阅源的容忍度去掉无效的行，返回有效的规则和无效的行数
+//
+// 没有设置容忍度时原样返回规则。
+func (m *Manager) filterFeed(f *feed, entries []string) ([]string, int, error) {
+	m.feedMu.Lock()
+	budget := f.errorBudget
+	m.feedMu.Unlock()
+	if budget < 0 || len(entries) == 0 {
+		return entries, 0, nil
+	}
+
+	valid := make([]string, 0, len(entries))
+	var firstErr error
+	for _, entry := range entries {
+		var err error
+		if f.status.Kind == "ip" {
+			err = ip.ValidateRange(entry)
+		} else {
+			_, err = domain.ParseRule(entry)
+		}
+		if err != nil {
+			if firstErr == nil {
+				firstErr = fmt.Errorf("%q: %w", entry, err)
+			}
+			continue
+		}
+		valid = append(valid, entry)
+	}
+	invalid := len(entries) - len(valid)
+	if float64(invalid) > budget*float64(len(entries)) {
+		return nil, invalid, fmt.Errorf("%w: %d/%d行无效，容忍度为%g%%（第一个: %v）",
+			ErrFeedErrorBudget, invalid, len(entries), budget*100, firstErr)
+	}
+	return valid, invalid, nil
+}
+
+// applyFeed 将规则列表加载为订阅源对应的命名列表
+func (m *Manager) applyFeed(f *feed, entries []string) error {
+	if f.status.Kind == "ip" {
+		return m.SetNamedIPList(f.status.Name, entries, f.listType, f.priority)
+	}
+	return m.TrySetNamedDomainList(f.status.Name, entries, f.listType, f.includeSubdomains, f.priority)
+}
+
+// needsFeedCache 判断订阅源是否需要从磁盘缓存加载：从未刷新成功且尚未从缓存加载过
+func (m *Manager) needsFeedCache(f *feed) bool {
+	m.feedMu.Lock()
+	defer m.feedMu.Unlock()
+	return f.status.LastSuccess.IsZero() && !f.status.FromCache
+}
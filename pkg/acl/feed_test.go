@@ -0,0 +1,298 @@
+package acl
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/cyberspacesec/go-acl/pkg/config"
+	"github.com/cyberspacesec/go-acl/pkg/types"
+)
+
+// stubFeed 返回预设结果的订阅源
+type stubFeed struct {
+	entries []string
+	err     error
+}
+
+func (s *stubFeed) Fetch(context.Context) ([]string, error) {
+	return s.entries, s.err
+}
+
+// TestFeedStatus 测试订阅源刷新成功、失败后的状态和列表内容
+func TestFeedStatus(t *testing.T) {
+	start := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	clock := types.NewManualClock(start)
+	manager := NewManager()
+	manager.SetClock(clock)
+
+	source := &stubFeed{entries: []string{"203.0.113.0/24", "198.51.100.1"}}
+	if err := manager.SetIPFeed("threat-intel", source, types.Blacklist, 0, time.Hour); err != nil {
+		t.Fatalf("SetIPFeed() 返回错误: %v", err)
+	}
+
+	statuses := manager.FeedStatus()
+	if len(statuses) != 1 || statuses[0].Current() || statuses[0].Source != "*acl.stubFeed" {
+		t.Fatalf("刷新前 FeedStatus() = %+v", statuses)
+	}
+
+	if err := manager.RefreshFeed(context.Background(), "threat-intel"); err != nil {
+		t.Fatalf("RefreshFeed() 返回错误: %v", err)
+	}
+	status := manager.FeedStatus()[0]
+	if !status.Current() || status.Entries != 2 || !status.LastSuccess.Equal(start) || !status.NextRefresh.Equal(start.Add(time.Hour)) {
+		t.Errorf("刷新成功后 FeedStatus() = %+v", status)
+	}
+	if perm, _ := manager.CheckIP("203.0.113.7"); perm != types.Denied {
+		t.Errorf("CheckIP() = %v, 期望订阅源中的地址被拒绝", perm)
+	}
+
+	tests := []struct {
+		name    string
+		entries []string
+		err     error
+	}{
+		{"下载失败", nil, errors.New("连接超时")},
+		{"包含无效的IP", []string{"not-an-ip"}, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			clock.Advance(time.Hour)
+			source.entries, source.err = tt.entries, tt.err
+			if err := manager.RefreshFeed(context.Background(), "threat-intel"); err == nil {
+				t.Fatal("RefreshFeed() 应返回错误")
+			}
+
+			status := manager.FeedStatus()[0]
+			if status.Current() || status.LastError == "" || !status.LastSuccess.Equal(start) || status.Entries != 2 {
+				t.Errorf("刷新失败后 FeedStatus() = %+v", status)
+			}
+			if !status.LastAttempt.Equal(clock.Now()) {
+				t.Errorf("LastAttempt = %v, 期望 %v", status.LastAttempt, clock.Now())
+			}
+			// 保留上一次成功加载的列表
+			if perm, _ := manager.CheckIP("203.0.113.7"); perm != types.Denied {
+				t.Errorf("刷新失败后 CheckIP() = %v, 期望仍被拒绝", perm)
+			}
+		})
+	}
+
+	report := manager.Health()
+	if report.Status != HealthDegraded {
+		t.Errorf("订阅源刷新失败时 Health().Status = %v, 期望 %v", report.Status, HealthDegraded)
+	}
+	if c := report.Components[len(report.Components)-1]; c.Name != "feed:threat-intel" || c.RuleCount != 2 {
+		t.Errorf("订阅源组件 = %+v", c)
+	}
+}
+
+// TestFeedErrors 测试订阅源的参数错误和不存在的订阅源
+func TestFeedErrors(t *testing.T) {
+	manager := NewManager()
+
+	if err := manager.SetIPFeed("a", &stubFeed{}, types.Blacklist, 0, 0); err == nil {
+		t.Error("interval为0时 SetIPFeed() 应返回错误")
+	}
+	if err := manager.RefreshFeed(context.Background(), "missing"); !errors.Is(err, ErrFeedNotFound) {
+		t.Errorf("RefreshFeed() 错误 = %v, 期望 ErrFeedNotFound", err)
+	}
+	if err := manager.RemoveFeed("missing"); !errors.Is(err, ErrFeedNotFound) {
+		t.Errorf("RemoveFeed() 错误 = %v, 期望 ErrFeedNotFound", err)
+	}
+
+	if err := manager.SetDomainFeed("ads", &stubFeed{entries: []string{"ads.example.com"}}, types.Blacklist, true, 0, time.Minute); err != nil {
+		t.Fatalf("SetDomainFeed() 返回错误: %v", err)
+	}
+	if err := manager.RemoveFeed("ads"); err != nil {
+		t.Errorf("RemoveFeed() 返回错误: %v", err)
+	}
+	if statuses := manager.FeedStatus(); len(statuses) != 0 {
+		t.Errorf("移除后 FeedStatus() = %+v, 期望为空", statuses)
+	}
+}
+
+// TestFeedErrorBudget 测试无效行在容忍度以内时被跳过，超过时保留上一次的列表
+func TestFeedErrorBudget(t *testing.T) {
+	// 100行中的前n行无效
+	lines := func(invalid int) []string {
+		entries := make([]string, 100)
+		for i := range entries {
+			entries[i] = fmt.Sprintf("198.51.100.%d", i)
+			if i < invalid {
+				entries[i] = fmt.Sprintf("bad-%d", i)
+			}
+		}
+		return entries
+	}
+
+	manager := NewManager()
+	source := &stubFeed{entries: lines(1)}
+	if err := manager.SetIPFeed("threat-intel", source, types.Blacklist, 0, time.Hour); err != nil {
+		t.Fatalf("SetIPFeed() 返回错误: %v", err)
+	}
+	if err := manager.RefreshFeed(context.Background(), "threat-intel"); err == nil {
+		t.Fatal("未设置容忍度时 RefreshFeed() 应因无效行失败")
+	}
+	if err := manager.SetFeedErrorBudget("threat-intel", 0.01); err != nil {
+		t.Fatalf("SetFeedErrorBudget() 返回错误: %v", err)
+	}
+	// 替换订阅源时保留容忍度
+	if err := manager.SetIPFeed("threat-intel", source, types.Blacklist, 0, time.Hour); err != nil {
+		t.Fatalf("SetIPFeed() 返回错误: %v", err)
+	}
+
+	tests := []struct {
+		name        string
+		entries     []string
+		wantErr     bool
+		wantEntries int
+		wantInvalid int
+		wantDenied  string
+	}{
+		{"容忍度以内", lines(1), false, 99, 1, "198.51.100.50"},
+		{"没有无效行", lines(0), false, 100, 0, "198.51.100.0"},
+		{"超过容忍度", lines(2), true, 100, 0, "198.51.100.0"},
+		{"全部无效", []string{"<html>", "</html>"}, true, 100, 0, "198.51.100.0"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			source.entries = tt.entries
+			err := manager.RefreshFeed(context.Background(), "threat-intel")
+			if tt.wantErr != (err != nil) {
+				t.Fatalf("RefreshFeed() 错误 = %v, 期望错误 %v", err, tt.wantErr)
+			}
+			if tt.wantErr && !errors.Is(err, ErrFeedErrorBudget) {
+				t.Errorf("RefreshFeed() 错误 = %v, 期望 ErrFeedErrorBudget", err)
+			}
+			status := manager.FeedStatus()[0]
+			if status.Entries != tt.wantEntries || status.Invalid != tt.wantInvalid {
+				t.Errorf("Entries = %d, Invalid = %d, 期望 %d, %d", status.Entries, status.Invalid, tt.wantEntries, tt.wantInvalid)
+			}
+			if perm, _ := manager.CheckIP(tt.wantDenied); perm != types.Denied {
+				t.Errorf("CheckIP(%s) = %v, 期望 Denied", tt.wantDenied, perm)
+			}
+		})
+	}
+
+	// 设置容忍度后域名订阅源也逐行检查
+	if err := manager.SetDomainFeed("ads", &stubFeed{entries: []string{"ads.example.com", "regex:("}}, types.Blacklist, true, 0, time.Hour); err != nil {
+		t.Fatalf("SetDomainFeed() 返回错误: %v", err)
+	}
+	if err := manager.SetFeedErrorBudget("ads", 0); err != nil {
+		t.Fatalf("SetFeedErrorBudget() 返回错误: %v", err)
+	}
+	if err := manager.RefreshFeed(context.Background(), "ads"); !errors.Is(err, ErrFeedErrorBudget) {
+		t.Errorf("容忍度为0时 RefreshFeed() = %v, 期望 ErrFeedErrorBudget", err)
+	}
+	if err := manager.SetFeedErrorBudget("ads", -1); err != nil {
+		t.Fatalf("SetFeedErrorBudget() 返回错误: %v", err)
+	}
+	if err := manager.RefreshFeed(context.Background(), "ads"); err != nil {
+		t.Errorf("恢复默认后 RefreshFeed() 返回错误: %v", err)
+	}
+
+	if err := manager.SetFeedErrorBudget("missing", 0.01); !errors.Is(err, ErrFeedNotFound) {
+		t.Errorf("SetFeedErrorBudget(不存在) = %v, 期望 ErrFeedNotFound", err)
+	}
+	if err := manager.SetFeedErrorBudget("ads", 1); err == nil {
+		t.Error("比例为1时 SetFeedErrorBudget() 应返回错误")
+	}
+}
+
+// TestFeedSources 测试HTTP和文件订阅源
+func TestFeedSources(t *testing.T) {
+	var compressed bytes.Buffer
+	zw := gzip.NewWriter(&compressed)
+	zw.Write([]byte("203.0.113.0/24\n"))
+	zw.Write(bytes.Repeat([]byte("#\n"), 1<<16))
+	zw.Close()
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/ips.txt":
+			w.Write([]byte("# 威胁情报\n203.0.113.0/24\n198.51.100.1 # 扫描器\n"))
+		case "/ips.txt.gz":
+			w.Write(compressed.Bytes())
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer server.Close()
+
+	path := filepath.Join(t.TempDir(), "domains.txt")
+	if err := os.WriteFile(path, []byte("ads.example.com\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name    string
+		source  FeedSource
+		want    int
+		wantErr bool
+		errIs   error
+	}{
+		{"HTTP", HTTPFeed{URL: server.URL + "/ips.txt"}, 2, false, nil},
+		{"HTTP 404", HTTPFeed{URL: server.URL + "/missing.txt"}, 0, true, nil},
+		{"HTTP 超过MaxBytes", HTTPFeed{URL: server.URL + "/ips.txt", MaxBytes: 16}, 0, true, config.ErrInputTooLarge},
+		{"HTTP gzip", HTTPFeed{URL: server.URL + "/ips.txt.gz", Gzip: true}, 1, false, nil},
+		// 压缩数据未超过上限，解压后超过
+		{"HTTP gzip解压后超过MaxBytes", HTTPFeed{URL: server.URL + "/ips.txt.gz", Gzip: true, MaxBytes: 1 << 16}, 0, true, config.ErrInputTooLarge},
+		{"文件", FileFeed(path), 1, false, nil},
+		{"文件不存在", FileFeed(filepath.Join(t.TempDir(), "missing.txt")), 0, true, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			entries, err := tt.source.Fetch(context.Background())
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Fetch() 错误 = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.errIs != nil && !errors.Is(err, tt.errIs) {
+				t.Fatalf("Fetch() 错误 = %v, 期望 %v", err, tt.errIs)
+			}
+			if len(entries) != tt.want {
+				t.Errorf("Fetch() = %v, 期望 %d 条", entries, tt.want)
+			}
+		})
+	}
+}
+
+// TestRunFeeds 测试RunFeeds启动时刷新订阅源，并在ctx取消后返回
+func TestRunFeeds(t *testing.T) {
+	manager := NewManager()
+	if err := manager.SetDomainFeed("ads", &stubFeed{entries: []string{"ads.example.com"}}, types.Blacklist, true, 0, time.Hour); err != nil {
+		t.Fatalf("SetDomainFeed() 返回错误: %v", err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error, 1)
+	go func() { done <- manager.RunFeeds(ctx) }()
+
+	deadline := time.Now().Add(5 * time.Second)
+	for !manager.FeedStatus()[0].Current() {
+		if time.Now().After(deadline) {
+			t.Fatal("RunFeeds 没有刷新订阅源")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	if perm, _ := manager.CheckDomain("tracker.ads.example.com"); perm != types.Denied {
+		t.Errorf("CheckDomain() = %v, 期望订阅源中的域名被拒绝", perm)
+	}
+
+	cancel()
+	select {
+	case err := <-done:
+		if !errors.Is(err, context.Canceled) {
+			t.Errorf("RunFeeds() 返回 %v, 期望 context.Canceled", err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("ctx取消后 RunFeeds 没有返回")
+	}
+}
@@ -0,0 +1,211 @@
+package acl
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/cyberspacesec/go-acl/pkg/config"
+)
+
+var (
+	// ErrFeedCacheMiss 表示订阅源没有可用的磁盘缓存
+	ErrFeedCacheMiss = errors.New("订阅源没有磁盘缓存")
+	// ErrFeedCacheCorrupt 表示订阅源磁盘缓存的内容与记录的校验和不一致
+	ErrFeedCacheCorrupt = errors.New("订阅源磁盘缓存已损坏")
+)
+
+const (
+	// feedCacheListExt 是缓存内容文件的扩展名，文件名为内容的SHA-256
+	feedCacheListExt = ".list"
+	// feedCacheIndexExt 是订阅源索引文件的扩展名，文件名为订阅源名称的十六进制编码
+	feedCacheIndexExt = ".json"
+)
+
+// feedCacheIndex 记录订阅源最近一次成功下载的内容
+type feedCacheIndex struct {
+	Name      string    `json:"name"`
+	Kind      string    `json:"kind"`
+	Source    string    `json:"source"`
+	Checksum  string    `json:"sha256"`
+	Entries   int       `json:"entries"`
+	FetchedAt time.Time `json:"fetched_at"`
+}
+
+// SetFeedCache 设置订阅源的磁盘缓存
+//
+// 参数:
+//   - dir: 缓存目录，不存在时创建；为空时关闭缓存
+//   - maxAge: 缓存的有效期，从缓存内容被下载时算起，超过后使用缓存的订阅源被标记为过期；0表示永不过期
+//
+// 返回:
+//   - error: 创建目录失败时返回错误
+//
+// 每次刷新成功后，下载的内容以其SHA-256为文件名保存在dir中，内容相同的订阅源共用一个文件，
+// 另有一个索引文件记录每个订阅源当前内容的校验和与下载时间。
+//
+// 订阅源从未在本进程中刷新成功（如新实例启动时远端不可达）且刷新失败时，从缓存加载上一次成功下载的内容，
+// 校验和不一致的缓存不会被使用。此时FeedStatus.FromCache为true，LastError仍是本次刷新的错误，
+// 缓存超过maxAge时FeedStatus.Stale为true，Health中的提示也会说明。
+// 之后刷新成功即替换为下载的内容。已经刷新成功过的订阅源刷新失败时保留内存中的列表，不读取缓存。
+//
+// 多个实例可以共用同一个缓存目录，文件都以临时文件加重命名的方式写入。
+//
+// 示例:
+//
+//	manager.SetFeedCache("/var/cache/go-acl", 24*time.Hour)
+//	manager.SetIPFeed("threat-intel", acl.HTTPFeed{URL: "https://feeds.example.com/ips.txt"},
+//	    types.Blacklist, 0, 15*time.Minute)
+//	go manager.RunFeeds(ctx)
+//
+//	for _, s := range manager.FeedStatus() {
+//	    if s.Stale {
+//	        log.Printf("订阅源 %s 使用的是%s下载的缓存", s.Name, s.CachedAt)
+//	    }
+//	}
+func (m *Manager) SetFeedCache(dir string, maxAge time.Duration) error {
+	if dir != "" {
+		if err := os.MkdirAll(dir, 0o755); err != nil {
+			return err
+		}
+	}
+
+	m.feedMu.Lock()
+	defer m.feedMu.Unlock()
+	m.feedCacheDir = dir
+	m.feedCacheMaxAge = maxAge
+	return nil
+}
+
+// feedCache 返回缓存目录和有效期
+func (m *Manager) feedCache() (string, time.Duration) {
+	m.feedMu.Lock()
+	defer m.feedMu.Unlock()
+	return m.feedCacheDir, m.feedCacheMaxAge
+}
+
+// encodeFeedEntries 将规则列表编码为缓存内容，返回内容和校验和
+func encodeFeedEntries(entries []string) ([]byte, string) {
+	var buf bytes.Buffer
+	for _, entry := range entries {
+		buf.WriteString(entry)
+		buf.WriteByte('\n')
+	}
+	sum := sha256.Sum256(buf.Bytes())
+	return buf.Bytes(), hex.EncodeToString(sum[:])
+}
+
+// feedCacheIndexPath 返回订阅源索引文件的路径
+func feedCacheIndexPath(dir, name string) string {
+	return filepath.Join(dir, hex.EncodeToString([]byte(name))+feedCacheIndexExt)
+}
+
+// storeFeedCache 保存订阅源下载的内容，返回内容的校验和
+func storeFeedCache(dir string, index feedCacheIndex, entries []string) (string, error) {
+	data, checksum := encodeFeedEntries(entries)
+	index.Checksum = checksum
+	index.Entries = len(entries)
+
+	listPath := filepath.Join(dir, checksum+feedCacheListExt)
+	if _, err := os.Stat(listPath); os.IsNotExist(err) {
+		err = writeFileAtomic(listPath, func(w io.Writer) error {
+			_, err := w.Write(data)
+			return err
+		})
+		if err != nil {
+			return checksum, err
+		}
+	}
+
+	previous, _ := readFeedCacheIndex(dir, index.Name)
+	err := writeFileAtomic(feedCacheIndexPath(dir, index.Name), func(w io.Writer) error {
+		return json.NewEncoder(w).Encode(index)
+	})
+	if err != nil {
+		return checksum, err
+	}
+	if previous.Checksum != "" && previous.Checksum != checksum {
+		pruneFeedCache(dir)
+	}
+	return checksum, nil
+}
+
+// readFeedCacheIndex 读取订阅源的索引文件
+func readFeedCacheIndex(dir, name string) (feedCacheIndex, error) {
+	var index feedCacheIndex
+	data, err := os.ReadFile(feedCacheIndexPath(dir, name))
+	if os.IsNotExist(err) {
+		return index, ErrFeedCacheMiss
+	}
+	if err != nil {
+		return index, err
+	}
+	if err := json.Unmarshal(data, &index); err != nil {
+		return index, fmt.Errorf("%w: %v", ErrFeedCacheCorrupt, err)
+	}
+	return index, nil
+}
+
+// loadFeedCache 读取订阅源缓存的内容并校验
+func loadFeedCache(dir, name, kind string) (feedCacheIndex, []string, error) {
+	index, err := readFeedCacheIndex(dir, name)
+	if err != nil {
+		return index, nil, err
+	}
+	if index.Kind != kind {
+		return index, nil, ErrFeedCacheMiss
+	}
+
+	data, err := os.ReadFile(filepath.Join(dir, index.Checksum+feedCacheListExt))
+	if os.IsNotExist(err) {
+		return index, nil, fmt.Errorf("%w: 缺少内容文件", ErrFeedCacheCorrupt)
+	}
+	if err != nil {
+		return index, nil, err
+	}
+	if sum := sha256.Sum256(data); hex.EncodeToString(sum[:]) != index.Checksum {
+		return index, nil, fmt.Errorf("%w: 校验和不一致", ErrFeedCacheCorrupt)
+	}
+	entries, err := config.ParseLines(bytes.NewReader(data))
+	if err != nil {
+		return index, nil, err
+	}
+	return index, entries, nil
+}
+
+// pruneFeedCache 删除不再被任何索引引用的内容文件
+func pruneFeedCache(dir string) {
+	files, err := os.ReadDir(dir)
+	if err != nil {
+		return
+	}
+	referenced := make(map[string]bool)
+	for _, f := range files {
+		if filepath.Ext(f.Name()) != feedCacheIndexExt {
+			continue
+		}
+		data, err := os.ReadFile(filepath.Join(dir, f.Name()))
+		if err != nil {
+			// 无法确认引用关系时不删除任何文件
+			return
+		}
+		var index feedCacheIndex
+		if json.Unmarshal(data, &index) == nil {
+			referenced[index.Checksum] = true
+		}
+	}
+	for _, f := range files {
+		checksum := strings.TrimSuffix(f.Name(), feedCacheListExt)
+		if checksum != f.Name() && !referenced[checksum] {
+			os.Remove(filepath.Join(dir, f.Name()))
+		}
+	}
+}
@@ -0,0 +1,169 @@
+package acl
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/cyberspacesec/go-acl/pkg/types"
+)
+
+// TestFeedCache 测试远端不可达时新实例从磁盘缓存加载订阅源
+func TestFeedCache(t *testing.T) {
+	dir := t.TempDir()
+	start := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	ctx := context.Background()
+
+	// 第一个实例下载成功，写入缓存
+	first := NewManager()
+	first.SetClock(types.NewManualClock(start))
+	if err := first.SetFeedCache(dir, 24*time.Hour); err != nil {
+		t.Fatalf("SetFeedCache() 返回错误: %v", err)
+	}
+	source := &stubFeed{entries: []string{"203.0.113.0/24", "198.51.100.1"}}
+	if err := first.SetIPFeed("threat-intel", source, types.Blacklist, 0, time.Hour); err != nil {
+		t.Fatalf("SetIPFeed() 返回错误: %v", err)
+	}
+	if err := first.RefreshFeed(ctx, "threat-intel"); err != nil {
+		t.Fatalf("RefreshFeed() 返回错误: %v", err)
+	}
+	status := first.FeedStatus()[0]
+	if len(status.Checksum) != 64 || status.FromCache {
+		t.Fatalf("刷新成功后 FeedStatus() = %+v", status)
+	}
+	if _, err := os.Stat(filepath.Join(dir, status.Checksum+feedCacheListExt)); err != nil {
+		t.Errorf("缓存内容文件不存在: %v", err)
+	}
+
+	tests := []struct {
+		name      string
+		age       time.Duration
+		wantStale bool
+	}{
+		{"缓存未过期", time.Hour, false},
+		{"缓存已过期", 48 * time.Hour, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// 新实例启动时远端不可达
+			manager := NewManager()
+			manager.SetClock(types.NewManualClock(start.Add(tt.age)))
+			if err := manager.SetFeedCache(dir, 24*time.Hour); err != nil {
+				t.Fatalf("SetFeedCache() 返回错误: %v", err)
+			}
+			down := &stubFeed{err: errors.New("连接被拒绝")}
+			if err := manager.SetIPFeed("threat-intel", down, types.Blacklist, 0, time.Hour); err != nil {
+				t.Fatalf("SetIPFeed() 返回错误: %v", err)
+			}
+			if err := manager.RefreshFeed(ctx, "threat-intel"); err == nil {
+				t.Fatal("RefreshFeed() 应返回下载错误")
+			}
+
+			status := manager.FeedStatus()[0]
+			if !status.FromCache || status.Stale != tt.wantStale || status.Entries != 2 || !status.CachedAt.Equal(start) || status.Current() {
+				t.Errorf("从缓存加载后 FeedStatus() = %+v", status)
+			}
+			if perm, _ := manager.CheckIP("203.0.113.7"); perm != types.Denied {
+				t.Errorf("CheckIP() = %v, 期望缓存中的地址被拒绝", perm)
+			}
+			health := manager.Health()
+			feed := health.Components[len(health.Components)-1]
+			if feed.Status != HealthDegraded || !strings.Contains(feed.Message, "磁盘缓存") || strings.Contains(feed.Message, "已过期") != tt.wantStale {
+				t.Errorf("Health() 中的订阅源 = %+v", feed)
+			}
+
+			// 远端恢复后使用下载的内容
+			down.entries, down.err = []string{"192.0.2.1"}, nil
+			if err := manager.RefreshFeed(ctx, "threat-intel"); err != nil {
+				t.Fatalf("RefreshFeed() 返回错误: %v", err)
+			}
+			status = manager.FeedStatus()[0]
+			if status.FromCache || status.Stale || !status.Current() {
+				t.Errorf("远端恢复后 FeedStatus() = %+v", status)
+			}
+			if perm, _ := manager.CheckIP("203.0.113.7"); perm != types.Allowed {
+				t.Errorf("CheckIP() = %v, 期望使用下载的内容", perm)
+			}
+
+			// 恢复缓存为第一个实例的内容
+			if err := first.RefreshFeed(ctx, "threat-intel"); err != nil {
+				t.Fatalf("RefreshFeed() 返回错误: %v", err)
+			}
+		})
+	}
+
+	// 内容变化后不再被引用的内容文件被删除
+	files, _ := filepath.Glob(filepath.Join(dir, "*"+feedCacheListExt))
+	if len(files) != 1 {
+		t.Errorf("缓存目录中的内容文件 = %v, 期望只有1个", files)
+	}
+}
+
+// TestFeedCacheUnusable 测试缓存缺失、损坏或已刷新成功时不从缓存加载
+func TestFeedCacheUnusable(t *testing.T) {
+	ctx := context.Background()
+
+	tests := []struct {
+		name    string
+		prepare func(t *testing.T, dir, checksum string)
+		kind    string
+	}{
+		{"内容被篡改", func(t *testing.T, dir, checksum string) {
+			path := filepath.Join(dir, checksum+feedCacheListExt)
+			if err := os.WriteFile(path, []byte("192.0.2.1\n"), 0o644); err != nil {
+				t.Fatal(err)
+			}
+		}, "ip"},
+		{"内容文件缺失", func(t *testing.T, dir, checksum string) {
+			if err := os.Remove(filepath.Join(dir, checksum+feedCacheListExt)); err != nil {
+				t.Fatal(err)
+			}
+		}, "ip"},
+		{"类型不同", func(*testing.T, string, string) {}, "domain"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			writer := NewManager()
+			writer.SetFeedCache(dir, 0)
+			writer.SetIPFeed("feed", &stubFeed{entries: []string{"203.0.113.0/24"}}, types.Blacklist, 0, time.Hour)
+			if err := writer.RefreshFeed(ctx, "feed"); err != nil {
+				t.Fatalf("RefreshFeed() 返回错误: %v", err)
+			}
+			tt.prepare(t, dir, writer.FeedStatus()[0].Checksum)
+
+			manager := NewManager()
+			manager.SetFeedCache(dir, 0)
+			down := &stubFeed{err: errors.New("连接被拒绝")}
+			if tt.kind == "ip" {
+				manager.SetIPFeed("feed", down, types.Blacklist, 0, time.Hour)
+			} else {
+				manager.SetDomainFeed("feed", down, types.Blacklist, false, 0, time.Hour)
+			}
+			manager.RefreshFeed(ctx, "feed")
+			if status := manager.FeedStatus()[0]; status.FromCache || status.Entries != 0 {
+				t.Errorf("FeedStatus() = %+v, 期望不使用缓存", status)
+			}
+		})
+	}
+
+	t.Run("已刷新成功", func(t *testing.T) {
+		dir := t.TempDir()
+		manager := NewManager()
+		manager.SetFeedCache(dir, 0)
+		source := &stubFeed{entries: []string{"203.0.113.0/24"}}
+		manager.SetIPFeed("feed", source, types.Blacklist, 0, time.Hour)
+		if err := manager.RefreshFeed(ctx, "feed"); err != nil {
+			t.Fatalf("RefreshFeed() 返回错误: %v", err)
+		}
+		source.entries, source.err = nil, errors.New("连接被拒绝")
+		manager.RefreshFeed(ctx, "feed")
+		if status := manager.FeedStatus()[0]; status.FromCache || status.Entries != 1 {
+			t.Errorf("FeedStatus() = %+v, 期望保留内存中的列表", status)
+		}
+	})
+}
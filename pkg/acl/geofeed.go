@@ -0,0 +1,72 @@
+package acl
+
+import (
+	"io"
+	"strings"
+
+	"github.com/cyberspacesec/go-acl/pkg/ip"
+	"github.com/cyberspacesec/go-acl/pkg/types"
+)
+
+// GeofeedListPrefix 是ImportGeofeed导入的命名列表的名称前缀，列表名称为前缀加标签，如"geo:US-CA"
+const GeofeedListPrefix = "geo:"
+
+// ImportGeofeed 将geofeed（RFC 8805）中的前缀按国家和地区导入为命名IP列表
+//
+// 参数:
+//   - r: geofeed数据，格式见ip.ParseGeofeed
+//   - listType: 导入的列表类型
+//   - priority: 导入的列表的优先级
+//   - tags: 要导入的国家代码或地区代码（不区分大小写），如"US"、"US-CA"；为空时导入所有标签
+//
+// 返回:
+//   - []string: 导入的列表名称（GeofeedListPrefix加标签），指定了tags时按tags的顺序，否则按首次出现的顺序
+//   - error: 解析错误，见ip.ParseGeofeed；出错时不导入任何列表
+//
+// 已存在同名列表时替换其内容，与SetNamedIPList相同。指定的标签在数据中没有前缀时也会导入为空列表，
+// 定期用更新后的geofeed重新导入时，不再出现的国家或地区的列表随之清空。
+//
+// 示例:
+//
+//	// 只允许来自公司在美国和德国的网络
+//	f, _ := os.Open("geofeed.csv")
+//	defer f.Close()
+//	lists, err := manager.ImportGeofeed(f, types.Whitelist, 10, "US", "DE")
+//	// lists: []string{"geo:US", "geo:DE"}
+func (m *Manager) ImportGeofeed(r io.Reader, listType types.ListType, priority int, tags ...string) ([]string, error) {
+	entries, err := ip.ParseGeofeed(r)
+	if err != nil {
+		return nil, err
+	}
+	byTag := ip.GeofeedPrefixes(entries)
+
+	var selected []string
+	seen := make(map[string]struct{})
+	add := func(tag string) {
+		if _, ok := seen[tag]; !ok && tag != "" {
+			seen[tag] = struct{}{}
+			selected = append(selected, tag)
+		}
+	}
+	if len(tags) > 0 {
+		for _, tag := range tags {
+			add(strings.ToUpper(strings.TrimSpace(tag)))
+		}
+	} else {
+		for _, e := range entries {
+			for _, tag := range e.Tags() {
+				add(tag)
+			}
+		}
+	}
+
+	names := make([]string, 0, len(selected))
+	for _, tag := range selected {
+		name := GeofeedListPrefix + tag
+		if err := m.SetNamedIPList(name, byTag[tag], listType, priority); err != nil {
+			return nil, err
+		}
+		names = append(names, name)
+	}
+	return names, nil
+}
@@ -0,0 +1,64 @@
+package acl
+
+import (
+	"context"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/cyberspacesec/go-acl/pkg/types"
+)
+
+const testGeofeed = `192.0.2.0/25,US,US-CA,Mountain View,
+192.0.2.128/25,US,US-WA,Seattle,
+198.51.100.0/24,DE,,,
+203.0.113.0/24,,,,
+`
+
+// TestImportGeofeed 测试将geofeed导入为命名列表
+func TestImportGeofeed(t *testing.T) {
+	manager := NewManager()
+	lists, err := manager.ImportGeofeed(strings.NewReader(testGeofeed), types.Blacklist, 10)
+	if err != nil {
+		t.Fatalf("ImportGeofeed() 返回错误: %v", err)
+	}
+	want := []string{"geo:US", "geo:US-CA", "geo:US-WA", "geo:DE"}
+	if !reflect.DeepEqual(lists, want) {
+		t.Errorf("ImportGeofeed() = %v, 期望 %v", lists, want)
+	}
+
+	result, err := manager.CheckIPDetailed(context.Background(), "192.0.2.200")
+	if err != nil || result.Decision != types.Denied {
+		t.Fatalf("CheckIPDetailed() = %+v, %v; 期望被拒绝", result, err)
+	}
+	if result.Source != "ip_list:geo:US" && result.Source != "ip_list:geo:US-WA" {
+		t.Errorf("Source = %s, 期望来自geofeed列表", result.Source)
+	}
+	if perm, _ := manager.CheckIP("203.0.113.1"); perm != types.Allowed {
+		t.Errorf("没有国家代码的前缀不应导入, CheckIP() = %v", perm)
+	}
+}
+
+// TestImportGeofeedTags 测试只导入指定的标签
+func TestImportGeofeedTags(t *testing.T) {
+	manager := NewManager()
+	lists, err := manager.ImportGeofeed(strings.NewReader(testGeofeed), types.Whitelist, 10, "us-ca", "FR", "US-CA")
+	if err != nil {
+		t.Fatalf("ImportGeofeed() 返回错误: %v", err)
+	}
+	if want := []string{"geo:US-CA", "geo:FR"}; !reflect.DeepEqual(lists, want) {
+		t.Errorf("ImportGeofeed() = %v, 期望 %v", lists, want)
+	}
+	infos := manager.NamedIPLists()
+	sizes := make(map[string]int)
+	for _, info := range infos {
+		sizes[info.Name] = info.Size
+	}
+	if want := map[string]int{"geo:US-CA": 1, "geo:FR": 0}; !reflect.DeepEqual(sizes, want) {
+		t.Errorf("NamedIPLists() 的大小 = %v, 期望 %v", sizes, want)
+	}
+
+	if _, err := manager.ImportGeofeed(strings.NewReader("10.0.0.0/8,USA\n"), types.Whitelist, 10); err == nil {
+		t.Error("无效的geofeed应返回错误")
+	}
+}
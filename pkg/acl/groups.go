@@ -0,0 +1,204 @@
+package acl
+
+import (
+	"sort"
+
+	"github.com/cyberspacesec/go-acl/pkg/expr"
+)
+
+// SetNamedIPListGroup 将命名IP列表加入规则组
+//
+// 参数:
+//   - name: 列表名称
+//   - group: 规则组名称，空字符串表示移出所属的组
+//
+// 返回:
+//   - error: 如果列表不存在，返回ErrListNotFound
+//
+// 同一规则组中的命名列表和条件规则可以通过EnableGroup/DisableGroup整体启用或停用。
+//
+// 示例:
+//
+//	manager.SetNamedIPList("freeze-block", officeRanges, types.Blacklist, 5)
+//	manager.SetNamedIPListGroup("freeze-block", "holiday-freeze")
+func (m *Manager) SetNamedIPListGroup(name, group string) error {
+	now := m.Clock().Now()
+
+	m.ipMu.Lock()
+	defer m.ipMu.Unlock()
+	if err := setListGroup(m.ipLists, name, group); err != nil {
+		return err
+	}
+	m.notifyChange("ip", "ip_list:"+name, now)
+	return nil
+}
+
+// SetNamedDomainListGroup 将命名域名列表加入规则组
+//
+// 参数:
+//   - name: 列表名称
+//   - group: 规则组名称，空字符串表示移出所属的组
+//
+// 返回:
+//   - error: 如果列表不存在，返回ErrListNotFound
+func (m *Manager) SetNamedDomainListGroup(name, group string) error {
+	now := m.Clock().Now()
+
+	m.domainMu.Lock()
+	defer m.domainMu.Unlock()
+	if err := setListGroup(m.domainLists, name, group); err != nil {
+		return err
+	}
+	m.notifyChange("domain", "domain_list:"+name, now)
+	return nil
+}
+
+// EnableGroup 启用规则组
+//
+// 参数:
+//   - group: 规则组名称
+//
+// 规则组默认处于启用状态，此方法用于恢复被DisableGroup停用的组。
+// 组内所有命名列表和条件规则（expr.Rule.Group）在同一时刻恢复生效，
+// 并发的检查要么看到整个组停用，要么看到整个组启用。
+func (m *Manager) EnableGroup(group string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if _, ok := m.disabledGroups[group]; !ok {
+		return
+	}
+	disabled := make(map[string]struct{}, len(m.disabledGroups))
+	for g := range m.disabledGroups {
+		if g != group {
+			disabled[g] = struct{}{}
+		}
+	}
+	m.disabledGroups = disabled
+	m.notifyGroupChange()
+}
+
+// DisableGroup 停用规则组
+//
+// 参数:
+//   - group: 规则组名称，组内暂时没有列表或规则时也可以停用，之后加入的成员同样不生效
+//
+// 停用后，组内的命名列表在CheckIP、CheckDomain中被跳过，组内的条件规则在
+// CheckRequest中被跳过，就像它们不存在一样；列表和规则本身保留，EnableGroup后恢复。
+// 停用在同一时刻对组内所有成员生效。
+//
+// 示例:
+//
+//	// 预先配置节假日封版策略，平时停用
+//	manager.SetNamedDomainList("freeze", deployDomains, types.Blacklist, true, 0)
+//	manager.SetNamedDomainListGroup("freeze", "holiday-freeze")
+//	manager.DisableGroup("holiday-freeze")
+//
+//	// 封版开始时整体启用
+//	manager.EnableGroup("holiday-freeze")
+func (m *Manager) DisableGroup(group string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.disableGroupLocked(group)
+}
+
+// GroupEnabled 判断规则组是否处于启用状态
+//
+// 参数:
+//   - group: 规则组名称
+//
+// 返回:
+//   - bool: 未被DisableGroup停用时返回true
+func (m *Manager) GroupEnabled(group string) bool {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return groupEnabled(m.disabledGroups, group)
+}
+
+// DisabledGroups 返回所有被停用的规则组
+//
+// 返回:
+//   - []string: 按名称排序的规则组名称，没有停用的组时为空
+func (m *Manager) DisabledGroups() []string {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return m.disabledGroupNames()
+}
+
+// setListGroup 设置指定名称列表的规则组，调用方需持有写锁
+func setListGroup(lists []namedList, name, group string) error {
+	for i := range lists {
+		if lists[i].name == name {
+			lists[i].group = group
+			return nil
+		}
+	}
+	return ErrListNotFound
+}
+
+// disableGroupLocked 将规则组标记为停用，调用方需持有写锁
+//
+// disabledGroups按写时复制的方式更新，disabledGroupSet返回的集合不会再被修改。
+func (m *Manager) disableGroupLocked(group string) {
+	if _, ok := m.disabledGroups[group]; ok {
+		return
+	}
+	disabled := make(map[string]struct{}, len(m.disabledGroups)+1)
+	for g := range m.disabledGroups {
+		disabled[g] = struct{}{}
+	}
+	disabled[group] = struct{}{}
+	m.disabledGroups = disabled
+	m.notifyGroupChange()
+}
+
+// notifyGroupChange 通知规则组的启停，组内可能同时有IP和域名列表，调用方需持有写锁
+func (m *Manager) notifyGroupChange() {
+	now := m.now()
+	m.notifyChange("ip", "*", now)
+	m.notifyChange("domain", "*", now)
+}
+
+// disabledGroupSet 返回当前停用的规则组集合，返回的集合只读
+//
+// 命名列表由ipMu、domainMu保护，检查时先取得集合再持有对应的锁，避免嵌套加锁。
+func (m *Manager) disabledGroupSet() map[string]struct{} {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return m.disabledGroups
+}
+
+// disabledGroupNames 返回排序后的停用规则组，调用方需持有读锁
+func (m *Manager) disabledGroupNames() []string {
+	if len(m.disabledGroups) == 0 {
+		return nil
+	}
+	names := make([]string, 0, len(m.disabledGroups))
+	for group := range m.disabledGroups {
+		names = append(names, group)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// groupEnabled 判断规则组是否启用，不属于任何组视为启用
+func groupEnabled(disabled map[string]struct{}, group string) bool {
+	if group == "" {
+		return true
+	}
+	_, off := disabled[group]
+	return !off
+}
+
+// enabledRules 返回所属规则组已启用的条件规则，调用方需持有读锁
+func (m *Manager) enabledRules() expr.RuleSet {
+	if len(m.disabledGroups) == 0 {
+		return m.rules
+	}
+	rules := make(expr.RuleSet, 0, len(m.rules))
+	for _, rule := range m.rules {
+		if rule != nil && groupEnabled(m.disabledGroups, rule.Group) {
+			rules = append(rules, rule)
+		}
+	}
+	return rules
+}
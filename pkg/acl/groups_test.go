@@ -0,0 +1,116 @@
+package acl
+
+import (
+	"bytes"
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/cyberspacesec/go-acl/pkg/expr"
+	"github.com/cyberspacesec/go-acl/pkg/types"
+)
+
+// TestGroupNamedLists 测试规则组整体停用和启用命名列表
+func TestGroupNamedLists(t *testing.T) {
+	manager := NewManager()
+	manager.SetNamedDomainList("freeze", []string{"deploy.example.com"}, types.Blacklist, true, 0)
+	if err := manager.SetNamedIPList("freeze-ips", []string{"10.0.0.0/8"}, types.Blacklist, 0); err != nil {
+		t.Fatalf("SetNamedIPList() 返回错误: %v", err)
+	}
+	if err := manager.SetNamedDomainListGroup("freeze", "holiday-freeze"); err != nil {
+		t.Fatalf("SetNamedDomainListGroup() 返回错误: %v", err)
+	}
+	if err := manager.SetNamedIPListGroup("freeze-ips", "holiday-freeze"); err != nil {
+		t.Fatalf("SetNamedIPListGroup() 返回错误: %v", err)
+	}
+	if err := manager.SetNamedIPListGroup("missing", "holiday-freeze"); !errors.Is(err, ErrListNotFound) {
+		t.Errorf("不存在的列表 SetNamedIPListGroup() 错误 = %v, 期望 ErrListNotFound", err)
+	}
+
+	// 启用时生效
+	if got, _ := manager.CheckDomain("api.deploy.example.com"); got != types.Denied {
+		t.Errorf("启用时 CheckDomain() = %v, 期望 Denied", got)
+	}
+	if got, _ := manager.CheckIP("10.1.2.3"); got != types.Denied {
+		t.Errorf("启用时 CheckIP() = %v, 期望 Denied", got)
+	}
+
+	// 停用后组内列表全部跳过，没有其他ACL时按未配置处理
+	manager.DisableGroup("holiday-freeze")
+	if manager.GroupEnabled("holiday-freeze") {
+		t.Error("DisableGroup() 后 GroupEnabled() = true")
+	}
+	if _, err := manager.CheckDomain("api.deploy.example.com"); !errors.Is(err, types.ErrNoACL) {
+		t.Errorf("停用后 CheckDomain() 错误 = %v, 期望 ErrNoACL", err)
+	}
+	if _, err := manager.CheckIP("10.1.2.3"); !errors.Is(err, types.ErrNoACL) {
+		t.Errorf("停用后 CheckIP() 错误 = %v, 期望 ErrNoACL", err)
+	}
+	if e := manager.Explain("10.1.2.3"); !errors.Is(e.Err, types.ErrNoACL) {
+		t.Errorf("停用后 Explain().Err = %v, 期望 ErrNoACL", e.Err)
+	}
+
+	// 替换列表内容不改变所属组
+	if err := manager.SetNamedIPList("freeze-ips", []string{"192.168.0.0/16"}, types.Blacklist, 0); err != nil {
+		t.Fatalf("SetNamedIPList() 返回错误: %v", err)
+	}
+	if infos := manager.NamedIPLists(); infos[0].Group != "holiday-freeze" {
+		t.Errorf("替换后 Group = %q, 期望 holiday-freeze", infos[0].Group)
+	}
+
+	manager.EnableGroup("holiday-freeze")
+	if got, _ := manager.CheckIP("192.168.1.1"); got != types.Denied {
+		t.Errorf("重新启用后 CheckIP() = %v, 期望 Denied", got)
+	}
+	if groups := manager.DisabledGroups(); groups != nil {
+		t.Errorf("DisabledGroups() = %v, 期望为空", groups)
+	}
+}
+
+// TestGroupRules 测试规则组停用条件规则
+func TestGroupRules(t *testing.T) {
+	manager := NewManager()
+	freeze := expr.MustCompile("port == 22 -> deny")
+	freeze.Group = "holiday-freeze"
+	manager.SetRules(expr.RuleSet{freeze, expr.MustCompile("port >= 1 -> allow")})
+
+	req := expr.Request{IP: "10.0.0.1", Port: 22}
+	if got, _ := manager.CheckRequest(req); got != types.Denied {
+		t.Errorf("启用时 CheckRequest() = %v, 期望 Denied", got)
+	}
+
+	manager.DisableGroup("holiday-freeze")
+	if got, _ := manager.CheckRequest(req); got != types.Allowed {
+		t.Errorf("停用后 CheckRequest() = %v, 期望 Allowed", got)
+	}
+}
+
+// TestGroupSnapshot 测试快照保存命名列表的规则组和停用状态
+func TestGroupSnapshot(t *testing.T) {
+	manager := NewManager()
+	manager.SetNamedDomainList("freeze", []string{"deploy.example.com"}, types.Blacklist, true, 0)
+	manager.SetNamedDomainListGroup("freeze", "holiday-freeze")
+	manager.DisableGroup("holiday-freeze")
+	manager.DisableGroup("audit")
+
+	var buf bytes.Buffer
+	if err := manager.SaveSnapshot(&buf); err != nil {
+		t.Fatalf("SaveSnapshot() 返回错误: %v", err)
+	}
+	restored := NewManager()
+	if err := restored.LoadSnapshot(&buf); err != nil {
+		t.Fatalf("LoadSnapshot() 返回错误: %v", err)
+	}
+
+	if got, want := restored.DisabledGroups(), []string{"audit", "holiday-freeze"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("DisabledGroups() = %v, 期望 %v", got, want)
+	}
+	if got := restored.NamedDomainLists()[0].Group; got != "holiday-freeze" {
+		t.Errorf("Group = %q, 期望 holiday-freeze", got)
+	}
+
+	restored.Reset()
+	if groups := restored.DisabledGroups(); groups != nil {
+		t.Errorf("Reset() 后 DisabledGroups() = %v, 期望为空", groups)
+	}
+}
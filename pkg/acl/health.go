@@ -0,0 +1,178 @@
+package acl
+
+import (
+	"fmt"
+	"time"
+)
+
+// HealthStatus 表示组件或整体的健康状态
+type HealthStatus string
+
+const (
+	// HealthOK 表示组件工作正常
+	HealthOK HealthStatus = "ok"
+	// HealthDegraded 表示组件可用但存在问题（如最近一次文件加载失败，仍在使用旧规则）
+	HealthDegraded HealthStatus = "degraded"
+	// HealthUnconfigured 表示组件尚未配置
+	HealthUnconfigured HealthStatus = "unconfigured"
+)
+
+// ComponentHealth 表示单个组件的健康状态
+//
+// 字段说明:
+//   - Name: 组件名称，如"ip_acl"、"domain_acl"
+//   - Status: 组件健康状态
+//   - RuleCount: 组件当前的规则数量
+//   - LastReload: 最近一次从文件加载规则的时间，从未加载时为零值
+//   - LastReloadSource: 最近一次加载的文件路径
+//   - LastReloadError: 最近一次加载失败的错误信息，成功时为空
+//   - Message: 附加说明
+type ComponentHealth struct {
+	Name             string       `json:"name"`
+	Status           HealthStatus `json:"status"`
+	RuleCount        int          `json:"rule_count"`
+	LastReload       time.Time    `json:"last_reload,omitempty"`
+	LastReloadSource string       `json:"last_reload_source,omitempty"`
+	LastReloadError  string       `json:"last_reload_error,omitempty"`
+	Message          string       `json:"message,omitempty"`
+}
+
+// HealthReport 表示ACL子系统的整体健康报告
+//
+// 可以直接编码为JSON用于/healthz等健康检查端点。
+type HealthReport struct {
+	Status     HealthStatus      `json:"status"`
+	Time       time.Time         `json:"time"`
+	Components []ComponentHealth `json:"components"`
+}
+
+// Healthy 判断整体状态是否为HealthOK
+func (r HealthReport) Healthy() bool {
+	return r.Status == HealthOK
+}
+
+// reloadStatus 记录最近一次从文件加载规则的结果
+type reloadStatus struct {
+	time   time.Time
+	source string
+	err    error
+}
+
+// Health 返回ACL子系统的健康报告
+//
+// 返回:
+//   - HealthReport: 包含各组件（IP ACL、域名ACL、订阅源）的状态、规则数量
+//     和最近一次文件加载的时间与结果，订阅源的组件名称为"feed:名称"；
+//     启用紧急模式（见SetOverrideMode）时包含名为"override"的组件
+//
+// 整体状态规则:
+//   - 任一组件为HealthDegraded时，整体为HealthDegraded
+//   - 所有组件均未配置时，整体为HealthUnconfigured
+//   - 其他情况为HealthOK
+//
+// 运维人员可以据此对加载失败、规则过期等情况进行告警。
+//
+// 示例:
+//
+//	http.HandleFunc("/healthz", func(w http.ResponseWriter, r *http.Request) {
+//	    report := manager.Health()
+//	    if !report.Healthy() {
+//	        w.WriteHeader(http.StatusServiceUnavailable)
+//	    }
+//	    json.NewEncoder(w).Encode(report)
+//	})
+func (m *Manager) Health() HealthReport {
+	now := m.Clock().Now()
+
+	m.ipMu.RLock()
+	ipHealth := ComponentHealth{Name: "ip_acl", Status: HealthUnconfigured}
+	if m.ipACL != nil {
+		ipHealth.Status = HealthOK
+		ipHealth.RuleCount = len(m.ipACL.GetIPRanges())
+	}
+	applyReloadStatus(&ipHealth, m.ipReload)
+	m.ipMu.RUnlock()
+
+	m.domainMu.RLock()
+	domainHealth := ComponentHealth{Name: "domain_acl", Status: HealthUnconfigured}
+	if m.domainACL != nil {
+		domainHealth.Status = HealthOK
+		domainHealth.RuleCount = len(m.domainACL.GetDomains())
+	}
+	applyReloadStatus(&domainHealth, m.domainReload)
+	m.domainMu.RUnlock()
+
+	report := HealthReport{
+		Time:       now,
+		Components: []ComponentHealth{ipHealth, domainHealth},
+	}
+	for _, feed := range m.FeedStatus() {
+		report.Components = append(report.Components, feedHealth(feed))
+	}
+	if c, ok := m.overrideHealth(); ok {
+		report.Components = append(report.Components, c)
+	}
+	if c, ok := m.devModeHealth(); ok {
+		report.Components = append(report.Components, c)
+	}
+	report.Status = overallStatus(report.Components)
+	return report
+}
+
+// feedHealth 将订阅源的刷新状态转换为组件健康状态
+func feedHealth(status FeedStatus) ComponentHealth {
+	c := ComponentHealth{
+		Name:             "feed:" + status.Name,
+		Status:           HealthOK,
+		RuleCount:        status.Entries,
+		LastReload:       status.LastAttempt,
+		LastReloadSource: status.Source,
+		LastReloadError:  status.LastError,
+	}
+	switch {
+	case status.Stale:
+		c.Status = HealthDegraded
+		c.Message = fmt.Sprintf("最近一次刷新失败，正在使用%s下载的磁盘缓存，缓存已过期", status.CachedAt.Format(time.RFC3339))
+	case status.FromCache:
+		c.Status = HealthDegraded
+		c.Message = fmt.Sprintf("最近一次刷新失败，正在使用%s下载的磁盘缓存", status.CachedAt.Format(time.RFC3339))
+	case status.LastError != "":
+		c.Status = HealthDegraded
+		c.Message = "最近一次刷新失败"
+	case status.LastSuccess.IsZero():
+		c.Message = "等待首次刷新"
+	}
+	return c
+}
+
+// applyReloadStatus 将最近一次文件加载的结果写入组件健康状态
+func applyReloadStatus(c *ComponentHealth, status reloadStatus) {
+	if status.time.IsZero() {
+		return
+	}
+
+	c.LastReload = status.time
+	c.LastReloadSource = status.source
+	if status.err != nil {
+		c.LastReloadError = status.err.Error()
+		c.Status = HealthDegraded
+		c.Message = "最近一次加载失败"
+	}
+}
+
+// overallStatus 根据各组件状态计算整体状态
+func overallStatus(components []ComponentHealth) HealthStatus {
+	configured := false
+	for _, c := range components {
+		if c.Status == HealthDegraded {
+			return HealthDegraded
+		}
+		if c.Status != HealthUnconfigured {
+			configured = true
+		}
+	}
+	if !configured {
+		return HealthUnconfigured
+	}
+	return HealthOK
+}
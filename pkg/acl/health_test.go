@@ -0,0 +1,115 @@
+package acl
+
+import (
+	"encoding/json"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/cyberspacesec/go-acl/pkg/types"
+)
+
+// TestHealth 测试健康检查报告
+func TestHealth(t *testing.T) {
+	tempDir := setupTestDir(t)
+	defer cleanupTestDir(t, tempDir)
+
+	manager := NewManager()
+
+	// 未配置任何ACL
+	report := manager.Health()
+	if report.Status != HealthUnconfigured || report.Healthy() {
+		t.Errorf("未配置时 Status = %v, 期望 unconfigured", report.Status)
+	}
+	if len(report.Components) != 2 {
+		t.Fatalf("Components 数量 = %d, 期望 2", len(report.Components))
+	}
+
+	// 从文件成功加载
+	ipFile := filepath.Join(tempDir, "ips.txt")
+	createTestFile(t, ipFile, "10.0.0.0/8\n192.168.1.1\n")
+	if err := manager.SetIPACLFromFile(ipFile, types.Blacklist); err != nil {
+		t.Fatalf("SetIPACLFromFile() 返回错误: %v", err)
+	}
+	manager.SetDomainACL([]string{"example.com"}, types.Blacklist, true)
+
+	report = manager.Health()
+	if !report.Healthy() {
+		t.Errorf("加载成功后 Status = %v, 期望 ok", report.Status)
+	}
+	ipHealth := report.Components[0]
+	if ipHealth.Name != "ip_acl" || ipHealth.RuleCount != 2 {
+		t.Errorf("ip_acl 组件 = %+v, 期望2条规则", ipHealth)
+	}
+	if ipHealth.LastReload.IsZero() || ipHealth.LastReloadSource != ipFile || ipHealth.LastReloadError != "" {
+		t.Errorf("ip_acl 加载记录不正确: %+v", ipHealth)
+	}
+	if report.Components[1].RuleCount != 1 {
+		t.Errorf("domain_acl 规则数量 = %d, 期望 1", report.Components[1].RuleCount)
+	}
+
+	// 加载失败时保留旧规则，状态降级
+	badFile := filepath.Join(tempDir, "missing.txt")
+	if err := manager.SetIPACLFromFile(badFile, types.Blacklist); err == nil {
+		t.Fatal("SetIPACLFromFile() 对于不存在的文件应返回错误")
+	}
+	report = manager.Health()
+	if report.Status != HealthDegraded {
+		t.Errorf("加载失败后 Status = %v, 期望 degraded", report.Status)
+	}
+	ipHealth = report.Components[0]
+	if ipHealth.RuleCount != 2 || ipHealth.LastReloadError == "" || ipHealth.LastReloadSource != badFile {
+		t.Errorf("加载失败后 ip_acl 组件 = %+v", ipHealth)
+	}
+
+	// AddIPFromFile 成功后恢复
+	if err := manager.AddIPFromFile(ipFile); err != nil {
+		t.Fatalf("AddIPFromFile() 返回错误: %v", err)
+	}
+	if report := manager.Health(); !report.Healthy() {
+		t.Errorf("重新加载成功后 Status = %v, 期望 ok", report.Status)
+	}
+
+	// 可编码为JSON
+	data, err := json.Marshal(manager.Health())
+	if err != nil {
+		t.Fatalf("json.Marshal() 返回错误: %v", err)
+	}
+	if !strings.Contains(string(data), `"status":"ok"`) {
+		t.Errorf("JSON输出缺少状态字段: %s", data)
+	}
+
+	// 域名ACL的重新加载失败同样使状态降级，原有列表保持不变
+	domainFile := filepath.Join(tempDir, "domains.txt")
+	createTestFile(t, domainFile, "example.com\nexample.org\n")
+	if _, err := manager.ReloadDomainACLFromFile(domainFile, types.Blacklist, true); err != nil {
+		t.Fatalf("ReloadDomainACLFromFile() 返回错误: %v", err)
+	}
+	domainHealth := manager.Health().Components[1]
+	if domainHealth.RuleCount != 2 || domainHealth.LastReloadSource != domainFile || domainHealth.LastReloadError != "" {
+		t.Errorf("重新加载后 domain_acl 组件 = %+v", domainHealth)
+	}
+	if _, err := manager.ReloadDomainACLFromFile(badFile, types.Blacklist, true); err == nil {
+		t.Fatal("ReloadDomainACLFromFile() 对于不存在的文件应返回错误")
+	}
+	report = manager.Health()
+	domainHealth = report.Components[1]
+	if report.Status != HealthDegraded || domainHealth.Status != HealthDegraded {
+		t.Errorf("域名重新加载失败后 Status = %v, domain_acl = %v, 期望 degraded", report.Status, domainHealth.Status)
+	}
+	if domainHealth.RuleCount != 2 || domainHealth.LastReloadError == "" || domainHealth.LastReloadSource != badFile {
+		t.Errorf("域名重新加载失败后 domain_acl 组件 = %+v", domainHealth)
+	}
+	if err := manager.SetDomainACLFromFile(domainFile, types.Blacklist, true); err != nil {
+		t.Fatalf("SetDomainACLFromFile() 返回错误: %v", err)
+	}
+	if report := manager.Health(); !report.Healthy() {
+		t.Errorf("域名重新加载成功后 Status = %v, 期望 ok", report.Status)
+	}
+
+	manager.Reset()
+	if report := manager.Health(); report.Status != HealthUnconfigured ||
+		!report.Components[0].LastReload.IsZero() || !report.Components[1].LastReload.IsZero() {
+		t.Errorf("Reset() 后 Health() = %+v", report)
+	}
+}
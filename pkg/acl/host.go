@@ -0,0 +1,103 @@
+package acl
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"strings"
+
+	"github.com/cyberspacesec/go-acl/pkg/domain"
+	"github.com/cyberspacesec/go-acl/pkg/ip"
+	"github.com/cyberspacesec/go-acl/pkg/types"
+)
+
+// CheckHost 检查URL或主机名是否允许访问
+//
+// 参数:
+//   - host: 主机名、IP或URL
+//     例如: "api.example.com", "http://0x7f.0.0.1:8080/admin", "[::1]:443"
+//
+// 返回:
+//   - types.Permission: 访问权限结果
+//   - error: 与CheckIP或CheckDomain相同的错误；启用SetStrictHostnames时，
+//     主机部分无效返回包装了domain.ErrInvalidHostname的错误
+//
+// 主机部分包含百分号编码（IPv6地址的区域标识除外）时直接拒绝，返回包装了domain.ErrInvalidHostname的错误，
+// 原因为types.ReasonInvalidInput：解码后的主机可能是IP（如"%31%32%37.0.0.1"），不能按域名检查。
+//
+// 输入先按域名规则标准化（去除协议、用户信息、路径和端口），然后:
+//   - 主机部分是IP地址时（包括十进制、八进制、十六进制等混淆写法），
+//     转换为标准形式后调用CheckIP
+//   - 否则调用CheckDomain
+//
+// 在处理用户提供的URL（如Webhook地址、图片链接）时应使用此方法，
+// 避免"http://2130706433/"这类写法绕过IP黑名单。
+//
+// 示例:
+//
+//	manager.SetIPACL([]string{"127.0.0.0/8"}, types.Blacklist)
+//	perm, _ := manager.CheckHost("http://2130706433/") // types.Denied
+func (m *Manager) CheckHost(host string) (types.Permission, error) {
+	if result, err := m.validateHost(context.Background(), host); err != nil {
+		return result.Decision, err
+	}
+	if parsed, ok := ip.CanonicalizeIP(domain.Normalize(host)); ok {
+		return m.CheckIPContext(m.withHostInput(context.Background(), host), parsed.String())
+	}
+	return m.CheckDomain(host)
+}
+
+// SetStrictHostnames 设置CheckHost和CheckHostDetailed是否校验主机部分的语法
+//
+// 参数:
+//   - strict: true表示要求主机部分是语法上有效的DNS名称或IP地址，默认为false
+//
+// 包含百分号编码的主机总是被拒绝，见CheckHost。启用后，主机部分包含空白、控制字符或其他非法字符的输入
+// （如"http://a b.evil.com/"）同样直接被拒绝，返回包装了domain.ErrInvalidHostname的错误，原因为types.ReasonInvalidInput，
+// 不再按标准化后的结果检查。规则见domain.ValidateHostname。
+// 被拒绝的输入与其他检查错误一样计入统计和审计事件。guard使用CheckHostDetailed，同样受此设置影响。
+//
+// 示例:
+//
+//	manager.SetStrictHostnames(true)
+//	_, err := manager.CheckHost("http://api_internal.evil.com/")
+//	errors.Is(err, domain.ErrInvalidHostname) // true
+func (m *Manager) SetStrictHostnames(strict bool) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.strictHostnames = strict
+}
+
+// validateHost 拒绝含有百分号编码的主机部分，并在启用严格主机名校验时检查其语法，
+// 无效时记录统计和审计事件并返回错误
+func (m *Manager) validateHost(ctx context.Context, host string) (types.CheckResult, error) {
+	m.mu.RLock()
+	strict := m.strictHostnames
+	m.mu.RUnlock()
+
+	result := types.CheckResult{Target: host, Kind: "domain", Decision: types.Denied}
+	var err error
+	if hasPercentEncoding(host) {
+		// "%31%32%37.0.0.1"按域名检查会绕过IP黑名单，而解码它的客户端会连接127.0.0.1
+		err = fmt.Errorf("%w: %q 的主机部分包含百分号编码", domain.ErrInvalidHostname, host)
+	} else if strict {
+		err = domain.ValidateHostname(host)
+	}
+	if err != nil {
+		result.Reason = errorReason(err)
+		m.stats.record(false, result.Decision, err)
+		m.audit(ctx, "domain", host, result, err)
+	}
+	return result, err
+}
+
+// hasPercentEncoding 判断主机部分是否包含百分号编码，IPv6地址的区域标识（如"[fe80::1%25eth0]"）除外
+func hasPercentEncoding(host string) bool {
+	name := domain.Normalize(host)
+	i := strings.IndexByte(name, '%')
+	if i < 0 {
+		return false
+	}
+	literal := strings.TrimPrefix(name[:i], "[")
+	return !strings.Contains(literal, ":") || net.ParseIP(literal) == nil
+}
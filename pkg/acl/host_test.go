@@ -0,0 +1,127 @@
+package acl
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/cyberspacesec/go-acl/pkg/domain"
+	"github.com/cyberspacesec/go-acl/pkg/types"
+)
+
+// TestCheckHost 测试URL和主机名检查，包括混淆的IP写法
+func TestCheckHost(t *testing.T) {
+	manager := NewManager()
+	if err := manager.SetIPACL([]string{"127.0.0.0/8", "169.254.169.254", "::1"}, types.Blacklist); err != nil {
+		t.Fatalf("SetIPACL() 返回错误: %v", err)
+	}
+	manager.SetDomainACL([]string{"evil.com"}, types.Blacklist, true)
+
+	tests := []struct {
+		host string
+		want types.Permission
+	}{
+		{"http://2130706433/", types.Denied},
+		{"http://0177.0.0.1:8080/admin", types.Denied},
+		{"0x7f.0.0.1", types.Denied},
+		{"http://127.1/", types.Denied},
+		{"http://0xa9fea9fe/latest/meta-data", types.Denied},
+		{"http://[::1]:80/", types.Denied},
+		{"[::1]", types.Denied},
+		{"::ffff:127.0.0.1", types.Denied},
+		{"http://127.0.0.1#@example.com/", types.Denied},
+		{`http://127.0.0.1\@example.com/`, types.Denied},
+		{"http://example.com@127.0.0.1/", types.Denied},
+		{"evil.com.", types.Denied},
+		{"https://8.8.8.8/", types.Allowed},
+		{"https://api.evil.com/x", types.Denied},
+		{"https://example.com/", types.Allowed},
+	}
+
+	for _, tt := range tests {
+		got, err := manager.CheckHost(tt.host)
+		if err != nil {
+			t.Errorf("CheckHost(%q) 返回错误: %v", tt.host, err)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("CheckHost(%q) = %v, 期望 %v", tt.host, got, tt.want)
+		}
+	}
+
+	e := manager.Explain("http://2130706433/")
+	if e.Kind != "ip" || e.Normalized != "127.0.0.1" || e.Decision != types.Denied {
+		t.Errorf("Explain() 混淆IP = %+v", e)
+	}
+}
+
+// TestStrictHostnames 测试启用严格主机名校验后拒绝无效的主机部分
+func TestStrictHostnames(t *testing.T) {
+	manager := NewManager()
+	manager.SetDomainACL([]string{"evil.com"}, types.Whitelist, true)
+	var events []AuditEvent
+	manager.SetAuditHook(func(e AuditEvent) { events = append(events, e) })
+
+	// 默认只做标准化，含有下划线的主机被当作evil.com的子域名
+	if perm, err := manager.CheckHost("http://api_internal.evil.com/"); err != nil || perm != types.Allowed {
+		t.Errorf("未启用时 CheckHost() = %v, %v, 期望 allowed, nil", perm, err)
+	}
+
+	manager.SetStrictHostnames(true)
+	for _, host := range []string{"http://localhost%00.evil.com/", "http://api_internal.evil.com/", "http://a b.evil.com/", "x\x01.evil.com"} {
+		perm, err := manager.CheckHost(host)
+		if !errors.Is(err, domain.ErrInvalidHostname) || perm != types.Denied {
+			t.Errorf("CheckHost(%q) = %v, %v, 期望 denied, ErrInvalidHostname", host, perm, err)
+		}
+	}
+	result, err := manager.CheckHostDetailed(context.Background(), "http://localhost%00.evil.com/")
+	if !errors.Is(err, domain.ErrInvalidHostname) || result.Reason != types.ReasonInvalidInput {
+		t.Errorf("CheckHostDetailed() = %+v, %v, 期望原因 %q", result, err, types.ReasonInvalidInput)
+	}
+	if perm, err := manager.CheckHost("https://api.evil.com/x"); err != nil || perm != types.Allowed {
+		t.Errorf("有效主机 CheckHost() = %v, %v, 期望 allowed, nil", perm, err)
+	}
+
+	if got := manager.Stats().Errors; got != 5 {
+		t.Errorf("Stats().Errors = %d, 期望 5", got)
+	}
+	if len(events) != 7 || events[1].Reason != types.ReasonInvalidInput || events[1].Error == "" {
+		t.Errorf("审计事件 = %+v", events)
+	}
+}
+
+// TestCheckHostPercentEncoding 测试主机部分的百分号编码总是被拒绝，IPv6地址的区域标识除外
+func TestCheckHostPercentEncoding(t *testing.T) {
+	manager := NewManager()
+	if err := manager.SetIPACL([]string{"127.0.0.0/8"}, types.Blacklist); err != nil {
+		t.Fatal(err)
+	}
+	manager.SetDomainACL([]string{"evil.com"}, types.Blacklist, true)
+
+	tests := []struct {
+		host     string
+		rejected bool
+	}{
+		{"%31%32%37.0.0.1", true},
+		{"http://%31%32%37.0.0.1:8080/admin", true},
+		{"https://%65vil.com/", true},
+		{"http://localhost%00.evil.com/", true},
+		{"http://[fe80::1%25eth0]:8080/", false},
+		{"fe80::1%eth0", false},
+		{"https://example.com/a%20b", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.host, func(t *testing.T) {
+			result, err := manager.CheckHostDetailed(context.Background(), tt.host)
+			if got := errors.Is(err, domain.ErrInvalidHostname); got != tt.rejected {
+				t.Fatalf("CheckHostDetailed() 错误 = %v, 期望被拒绝 = %v", err, tt.rejected)
+			}
+			if tt.rejected && (result.Decision != types.Denied || result.Reason != types.ReasonInvalidInput) {
+				t.Errorf("CheckHostDetailed() = %+v, 期望 denied, %q", result, types.ReasonInvalidInput)
+			}
+			if perm, err := manager.CheckHost(tt.host); tt.rejected && (perm != types.Denied || !errors.Is(err, domain.ErrInvalidHostname)) {
+				t.Errorf("CheckHost() = %v, %v, 期望 denied, ErrInvalidHostname", perm, err)
+			}
+		})
+	}
+}
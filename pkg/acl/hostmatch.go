@@ -0,0 +1,125 @@
+package acl
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"strings"
+
+	"github.com/cyberspacesec/go-acl/pkg/ip"
+	"github.com/cyberspacesec/go-acl/pkg/types"
+)
+
+// ErrHostMismatch 表示请求的URL主机、Host头和TLS SNI不一致
+//
+// 三者不一致是请求走私和SSRF的常见手法：例如访问控制检查的是URL主机或SNI，
+// 而后端按Host头路由到了另一个（内部）虚拟主机。
+var ErrHostMismatch = errors.New("URL主机、Host头与SNI不一致")
+
+// HostConsistency 检查URL主机、Host头和TLS SNI是否指向同一主机
+//
+// 参数:
+//   - urlHost: URL中的主机，可以带端口，例如"example.com:8443"
+//   - hostHeader: Host头的值，可以带端口
+//   - sni: TLS握手中的服务器名称（SNI）
+//
+// 返回:
+//   - error: 不一致时返回包装了ErrHostMismatch的*types.ReasonError，原因为types.ReasonHostMismatch；
+//     一致时返回nil
+//
+// 比较前忽略端口、大小写、末尾的点和IPv6地址的方括号，IP地址按标准形式比较
+// （"0x7f.0.0.1"与"127.0.0.1"视为相同）。为空的参数不参与比较。
+//
+// 示例:
+//
+//	err := acl.HostConsistency("example.com", "internal.example.com", "")
+//	errors.Is(err, acl.ErrHostMismatch) // true
+func HostConsistency(urlHost, hostHeader, sni string) error {
+	var first, firstName string
+	for _, h := range []struct{ name, value string }{
+		{"url", urlHost},
+		{"host", hostHeader},
+		{"sni", sni},
+	} {
+		if h.value == "" {
+			continue
+		}
+		host := comparableHost(h.value)
+		if first == "" {
+			first, firstName = host, h.name
+			continue
+		}
+		if host != first {
+			return &types.ReasonError{
+				Reason: types.ReasonHostMismatch,
+				Err:    fmt.Errorf("%w: %s=%s, %s=%s", ErrHostMismatch, firstName, first, h.name, host),
+			}
+		}
+	}
+	return nil
+}
+
+// CheckHTTPHosts 检查HTTP请求的URL主机、Host头和TLS SNI是否一致
+//
+// 参数:
+//   - r: 服务端收到的请求或客户端将要发送的请求
+//
+// 返回:
+//   - error: 不一致时返回包装了ErrHostMismatch的*types.ReasonError，原因为types.ReasonHostMismatch
+//
+// 服务端请求中，r.Host是Host头（或绝对形式请求行中的主机），r.TLS.ServerName是SNI；
+// 客户端请求中，r.Host是覆盖URL主机的Host头。
+//
+// 示例:
+//
+//	if err := acl.CheckHTTPHosts(r); err != nil {
+//	    log.Printf("疑似域前置或请求走私: %v", err)
+//	}
+func CheckHTTPHosts(r *http.Request) error {
+	var urlHost, sni string
+	if r.URL != nil {
+		urlHost = r.URL.Host
+	}
+	if r.TLS != nil {
+		sni = r.TLS.ServerName
+	}
+	return HostConsistency(urlHost, r.Host, sni)
+}
+
+// RequireConsistentHosts 返回拒绝主机不一致请求的HTTP中间件
+//
+// 参数:
+//   - next: 被保护的处理器
+//
+// 返回:
+//   - http.Handler: CheckHTTPHosts失败时返回421 Misdirected Request，响应头X-ACL-Reason为"host_mismatch"，
+//     否则调用next
+//
+// 需要与访问控制一起使用时，可以改用middleware.WithConsistentHosts。
+//
+// 示例:
+//
+//	http.ListenAndServeTLS(":443", cert, key, acl.RequireConsistentHosts(mux))
+func RequireConsistentHosts(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if err := CheckHTTPHosts(r); err != nil {
+			w.Header().Set("X-ACL-Reason", types.ReasonOf(err).String())
+			http.Error(w, err.Error(), http.StatusMisdirectedRequest)
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// comparableHost 返回用于比较的主机形式
+func comparableHost(host string) string {
+	if h, _, err := net.SplitHostPort(host); err == nil {
+		host = h
+	}
+	host = strings.TrimSuffix(strings.ToLower(strings.Trim(host, "[]")), ".")
+	if parsed, ok := ip.CanonicalizeIP(host); ok {
+		return parsed.String()
+	}
+	return host
+}
@@ -0,0 +1,77 @@
+package acl
+
+import (
+	"crypto/tls"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/cyberspacesec/go-acl/pkg/types"
+)
+
+// TestHostConsistency 测试URL主机、Host头和SNI的一致性检查
+func TestHostConsistency(t *testing.T) {
+	tests := []struct {
+		name                 string
+		urlHost, header, sni string
+		wantErr              bool
+	}{
+		{"全部为空", "", "", "", false},
+		{"完全相同", "example.com", "example.com", "example.com", false},
+		{"忽略端口和大小写", "Example.COM:443", "example.com", "example.com.", false},
+		{"IPv6方括号", "[::1]:8080", "::1", "", false},
+		{"IP的混淆写法", "0x7f.0.0.1", "127.0.0.1:80", "", false},
+		{"Host头不同", "example.com", "internal.example.com", "", true},
+		{"SNI不同", "", "example.com", "other.com", true},
+		{"只有SNI", "", "", "example.com", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := HostConsistency(tt.urlHost, tt.header, tt.sni)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("HostConsistency() = %v, 期望错误: %v", err, tt.wantErr)
+			}
+			if err != nil && (!errors.Is(err, ErrHostMismatch) || types.ReasonOf(err) != types.ReasonHostMismatch) {
+				t.Errorf("错误应包装 ErrHostMismatch 并携带原因 %q: %v", types.ReasonHostMismatch, err)
+			}
+		})
+	}
+}
+
+// TestRequireConsistentHosts 测试拒绝主机不一致请求的中间件
+func TestRequireConsistentHosts(t *testing.T) {
+	handler := RequireConsistentHosts(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+
+	tests := []struct {
+		name       string
+		host       string
+		sni        string
+		wantStatus int
+	}{
+		{"没有TLS", "example.com", "", http.StatusOK},
+		{"SNI与Host一致", "example.com", "example.com", http.StatusOK},
+		{"域前置", "internal.example.com", "example.com", http.StatusMisdirectedRequest},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/", nil)
+			req.Host = tt.host
+			if tt.sni != "" {
+				req.TLS = &tls.ConnectionState{ServerName: tt.sni}
+			}
+			rec := httptest.NewRecorder()
+			handler.ServeHTTP(rec, req)
+			if rec.Code != tt.wantStatus {
+				t.Errorf("状态码 = %d, 期望 %d", rec.Code, tt.wantStatus)
+			}
+			if rec.Code != http.StatusOK && rec.Header().Get("X-ACL-Reason") != string(types.ReasonHostMismatch) {
+				t.Errorf("X-ACL-Reason = %q, 期望 %q", rec.Header().Get("X-ACL-Reason"), types.ReasonHostMismatch)
+			}
+		})
+	}
+}
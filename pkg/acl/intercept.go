@@ -0,0 +1,179 @@
+package acl
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/cyberspacesec/go-acl/pkg/types"
+)
+
+// ErrChangeRejected 表示修改被SetMutationInterceptor设置的拦截函数拒绝
+var ErrChangeRejected = errors.New("修改被拦截函数拒绝")
+
+// ChangeOp 是Change描述的修改方式
+type ChangeOp string
+
+const (
+	// ChangeSet 用Entries替换整个列表，如SetIPACL、SetNamedDomainList、SetRules
+	ChangeSet ChangeOp = "set"
+	// ChangeAdd 向已有的列表加入Entries，如AddIP、AddNamedIPListEntriesTTL
+	ChangeAdd ChangeOp = "add"
+	// ChangeRemove 从列表移除Entries，如RemoveIP、RevokeTemporaryAllow
+	ChangeRemove ChangeOp = "remove"
+	// ChangeDelete 删除整个命名列表，如RemoveNamedIPList，Entries为空
+	ChangeDelete ChangeOp = "delete"
+	// ChangeReload 从文件重新加载列表，Entries为文件中的全部条目，如ReloadIPACLFromFile
+	ChangeReload ChangeOp = "reload"
+	// ChangeReplace 整体替换所有列表和规则，Policy为替换后的配置，如ApplyPolicy、LoadSnapshot
+	ChangeReplace ChangeOp = "replace"
+	// ChangeReset 清除所有列表和规则，即Reset
+	ChangeReset ChangeOp = "reset"
+)
+
+// Change 是即将应用的修改，传给拦截函数
+//
+// 字段说明:
+//   - Op: 修改方式
+//   - Kind: "ip"、"domain"或"rules"，ChangeReplace和ChangeReset为"*"
+//   - Component: 被修改的组件，与ChangeEvent.Component相同，如"ip_acl"、"domain_list:partners"、"rules"
+//   - ListType: 修改后列表的类型；ChangeAdd、ChangeRemove、ChangeDelete和重新加载命名列表时为列表当前的类型，
+//     列表不存在时为types.Blacklist（TemporarilyAllow第一次调用时为types.Whitelist）
+//   - Entries: 涉及的条目（IP或CIDR、域名规则或条件规则表达式），含义见ChangeOp；
+//     来自调用方的参数时是原始写法，来自文件时是文件中的条目
+//   - Source: 条目来自文件时为文件路径，否则为空
+//   - Policy: ChangeReplace时替换后的配置，其他修改为nil
+type Change struct {
+	Op        ChangeOp
+	Kind      string
+	Component string
+	ListType  types.ListType
+	Entries   []string
+	Source    string
+	Policy    *Policy
+}
+
+// MutationInterceptor 在修改生效之前检查修改，返回非nil的错误拒绝修改
+type MutationInterceptor func(Change) error
+
+// RejectedChangeError 是被拦截函数拒绝的修改返回的错误
+//
+// 字段说明:
+//   - Change: 被拒绝的修改
+//   - Err: 拦截函数返回的错误
+//
+// errors.Is(err, ErrChangeRejected)为true，也可以用errors.Is或errors.As判断拦截函数返回的错误。
+type RejectedChangeError struct {
+	Change Change
+	Err    error
+}
+
+// Error 返回被拒绝的组件和拦截函数给出的原因
+func (e *RejectedChangeError) Error() string {
+	return fmt.Sprintf("%v: %s %s: %v", ErrChangeRejected, e.Change.Op, e.Change.Component, e.Err)
+}
+
+// Unwrap 返回拦截函数返回的错误
+func (e *RejectedChangeError) Unwrap() error {
+	return e.Err
+}
+
+// Is 使errors.Is(err, ErrChangeRejected)成立
+func (e *RejectedChangeError) Is(target error) bool {
+	return target == ErrChangeRejected
+}
+
+// SetMutationInterceptor 设置在修改列表和规则之前调用的拦截函数
+//
+// 参数:
+//   - interceptor: 拦截函数，返回非nil的错误时修改被拒绝；传入nil表示不再拦截
+//
+// 拦截函数用于在库的层面执行组织的变更规范，例如"白名单中不允许0.0.0.0/0"、"变更必须关联工单"。
+// 以下修改在生效之前调用拦截函数，被拒绝时Manager保持不变，方法返回*RejectedChangeError:
+//   - 主列表: SetIPACL、SetIPACLFromFile、SetIPACLWithDefaults、AddIP、AddIPFromFile、AddPredefinedIPSet、
+//     RemoveIP及对应的域名方法，ReloadIPACLFromFile、ReloadDomainACLFromFile
+//   - 命名列表: SetNamedIPList、SetNamedDomainList、RemoveNamedIPList、RemoveNamedDomainList、
+//     Add*ListEntries及其TTL版本、ReloadNamed*ListFromFile、TemporarilyAllow、RevokeTemporaryAllow，
+//     以及订阅源刷新后更新的列表
+//   - 整体: SetRules、ApplyPolicy、LoadSnapshot、Reset
+//
+// SetDomainACL、SetNamedDomainList、SetRules和Reset没有返回值，被拒绝时只是不做修改；
+// 需要得到拒绝的错误时改用对应的TrySetDomainACL、TrySetNamedDomainList、TrySetRules和TryReset。
+// 端口限制、地址族、规则组、配额等设置以及SweepExpired清理到期条目不经过拦截函数。
+//
+// 拦截函数在不持有Manager的锁时调用，可以调用Manager的只读方法，但不应修改Manager。
+// 多个修改可能同时调用拦截函数，在拦截函数返回和修改生效之间列表可能已被其他修改改变，
+// 因此判断应基于Change本身的内容。拦截函数发生panic时修改被拒绝，错误包装了types.ErrPanic。
+//
+// 示例:
+//
+//	manager.SetMutationInterceptor(func(c acl.Change) error {
+//	    if c.Kind == "ip" && c.ListType == types.Whitelist {
+//	        for _, entry := range c.Entries {
+//	            if entry == "0.0.0.0/0" || entry == "::/0" {
+//	                return errors.New("白名单中不允许所有地址")
+//	            }
+//	        }
+//	    }
+//	    return nil
+//	})
+//
+//	err := manager.AddIP("0.0.0.0/0") // 当前主列表是白名单时被拒绝
+//	if errors.Is(err, acl.ErrChangeRejected) {
+//	    log.Printf("修改被拒绝: %v", err)
+//	}
+func (m *Manager) SetMutationInterceptor(interceptor MutationInterceptor) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.interceptor = interceptor
+}
+
+// intercept 用拦截函数检查修改，没有设置拦截函数时返回nil，调用时不能持有Manager的锁
+func (m *Manager) intercept(change Change) (err error) {
+	m.mu.RLock()
+	interceptor := m.interceptor
+	m.mu.RUnlock()
+	if interceptor == nil {
+		return nil
+	}
+
+	if change.Op == ChangeAdd || change.Op == ChangeRemove || change.Op == ChangeDelete {
+		if listType, ok := m.componentListType(change.Component); ok {
+			change.ListType = listType
+		}
+	}
+	defer func() {
+		if err != nil {
+			err = &RejectedChangeError{Change: change, Err: err}
+		}
+	}()
+	defer types.CatchPanic(&err)
+	return interceptor(change)
+}
+
+// componentListType 返回组件当前的列表类型，列表不存在时第二个返回值为false
+func (m *Manager) componentListType(component string) (types.ListType, bool) {
+	switch {
+	case component == "ip_acl":
+		if listType, err := m.GetIPACLType(); err == nil {
+			return listType, true
+		}
+	case component == "domain_acl":
+		if listType, err := m.GetDomainACLType(); err == nil {
+			return listType, true
+		}
+	case strings.HasPrefix(component, "ip_list:"):
+		m.ipMu.RLock()
+		defer m.ipMu.RUnlock()
+		if l := findList(m.ipLists, strings.TrimPrefix(component, "ip_list:")); l != nil {
+			return l.ip.GetListType(), true
+		}
+	case strings.HasPrefix(component, "domain_list:"):
+		m.domainMu.RLock()
+		defer m.domainMu.RUnlock()
+		if l := findList(m.domainLists, strings.TrimPrefix(component, "domain_list:")); l != nil {
+			return l.domain.GetListType(), true
+		}
+	}
+	return types.Blacklist, false
+}
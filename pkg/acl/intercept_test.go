@@ -0,0 +1,167 @@
+package acl
+
+import (
+	"bytes"
+	"errors"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/cyberspacesec/go-acl/pkg/expr"
+	"github.com/cyberspacesec/go-acl/pkg/ip"
+	"github.com/cyberspacesec/go-acl/pkg/types"
+)
+
+// TestMutationInterceptorRejects 测试被拒绝的修改不改变Manager，并把修改的内容交给拦截函数
+func TestMutationInterceptorRejects(t *testing.T) {
+	dir := t.TempDir()
+	ipFile := writeList(t, filepath.Join(dir, "ips.txt"), "203.0.113.0/24\n")
+	domainFile := writeList(t, filepath.Join(dir, "domains.txt"), "ads.example.com\n!ok.ads.example.com\n")
+
+	source := NewManager()
+	if err := source.SetIPACL([]string{"198.51.100.1"}, types.Whitelist); err != nil {
+		t.Fatal(err)
+	}
+	var snapshot bytes.Buffer
+	if err := source.SaveSnapshot(&snapshot); err != nil {
+		t.Fatal(err)
+	}
+
+	manager := NewManager()
+	if err := manager.SetIPACL([]string{"10.0.0.0/8"}, types.Whitelist); err != nil {
+		t.Fatal(err)
+	}
+	manager.SetDomainACL([]string{"example.com"}, types.Blacklist, true)
+	if err := manager.SetNamedIPList("partners", []string{"192.0.2.0/24"}, types.Whitelist, 10); err != nil {
+		t.Fatal(err)
+	}
+	manager.SetNamedDomainList("trusted", []string{"example.org"}, types.Whitelist, true, 10)
+
+	var got Change
+	denied := errors.New("需要工单")
+	manager.SetMutationInterceptor(func(c Change) error {
+		got = c
+		return denied
+	})
+	before := manager.Policy()
+	revision := manager.Revision()
+
+	tests := []struct {
+		name   string
+		mutate func() error
+		want   Change
+	}{
+		{"SetIPACL", func() error { return manager.SetIPACL([]string{"0.0.0.0/0"}, types.Whitelist) },
+			Change{Op: ChangeSet, Kind: "ip", Component: "ip_acl", ListType: types.Whitelist, Entries: []string{"0.0.0.0/0"}}},
+		{"SetIPACLFromFile", func() error { return manager.SetIPACLFromFile(ipFile, types.Blacklist) },
+			Change{Op: ChangeSet, Kind: "ip", Component: "ip_acl", Entries: []string{"203.0.113.0/24"}, Source: ipFile}},
+		{"AddIP", func() error { return manager.AddIP("0.0.0.0/0") },
+			Change{Op: ChangeAdd, Kind: "ip", Component: "ip_acl", ListType: types.Whitelist, Entries: []string{"0.0.0.0/0"}}},
+		{"AddIPFromFile", func() error { return manager.AddIPFromFile(ipFile) },
+			Change{Op: ChangeAdd, Kind: "ip", Component: "ip_acl", ListType: types.Whitelist, Entries: []string{"203.0.113.0/24"}, Source: ipFile}},
+		{"AddPredefinedIPSet", func() error { return manager.AddPredefinedIPSet(ip.CloudMetadata, true) },
+			Change{Op: ChangeAdd, Kind: "ip", Component: "ip_acl", ListType: types.Whitelist, Entries: ip.GetPredefinedIPRanges(ip.CloudMetadata)}},
+		{"RemoveIP", func() error { return manager.RemoveIP("10.0.0.0/8") },
+			Change{Op: ChangeRemove, Kind: "ip", Component: "ip_acl", ListType: types.Whitelist, Entries: []string{"10.0.0.0/8"}}},
+		{"ReloadIPACLFromFile", func() error { _, err := manager.ReloadIPACLFromFile(ipFile, types.Whitelist); return err },
+			Change{Op: ChangeReload, Kind: "ip", Component: "ip_acl", ListType: types.Whitelist, Entries: []string{"203.0.113.0/24"}, Source: ipFile}},
+		{"SetDomainACLFromFile", func() error { return manager.SetDomainACLFromFile(domainFile, types.Blacklist, true) },
+			Change{Op: ChangeSet, Kind: "domain", Component: "domain_acl", Entries: []string{"ads.example.com", "!ok.ads.example.com"}, Source: domainFile}},
+		{"AddDomain", func() error { return manager.AddDomain("ads.example.net") },
+			Change{Op: ChangeAdd, Kind: "domain", Component: "domain_acl", Entries: []string{"ads.example.net"}}},
+		{"RemoveDomain", func() error { return manager.RemoveDomain("example.com") },
+			Change{Op: ChangeRemove, Kind: "domain", Component: "domain_acl", Entries: []string{"example.com"}}},
+		{"SetNamedIPList", func() error { return manager.SetNamedIPList("partners", []string{"::/0"}, types.Whitelist, 10) },
+			Change{Op: ChangeSet, Kind: "ip", Component: "ip_list:partners", ListType: types.Whitelist, Entries: []string{"::/0"}}},
+		{"AddNamedIPListEntriesTTL", func() error { return manager.AddNamedIPListEntriesTTL("partners", time.Hour, "198.51.100.0/24") },
+			Change{Op: ChangeAdd, Kind: "ip", Component: "ip_list:partners", ListType: types.Whitelist, Entries: []string{"198.51.100.0/24"}}},
+		{"AddNamedDomainListEntries", func() error { return manager.AddNamedDomainListEntries("trusted", "*") },
+			Change{Op: ChangeAdd, Kind: "domain", Component: "domain_list:trusted", ListType: types.Whitelist, Entries: []string{"*"}}},
+		{"ReloadNamedIPListFromFile", func() error { _, err := manager.ReloadNamedIPListFromFile("partners", ipFile); return err },
+			Change{Op: ChangeReload, Kind: "ip", Component: "ip_list:partners", ListType: types.Whitelist, Entries: []string{"203.0.113.0/24"}, Source: ipFile}},
+		{"RemoveNamedDomainList", func() error { return manager.RemoveNamedDomainList("trusted") },
+			Change{Op: ChangeDelete, Kind: "domain", Component: "domain_list:trusted", ListType: types.Whitelist}},
+		{"TemporarilyAllow", func() error { return manager.TemporarilyAllow("203.0.113.7", time.Hour) },
+			Change{Op: ChangeAdd, Kind: "ip", Component: "ip_list:" + TemporaryAllowList, ListType: types.Whitelist, Entries: []string{"203.0.113.7"}}},
+		{"SetDomainACL", func() error { return manager.TrySetDomainACL([]string{"*"}, types.Whitelist, true) },
+			Change{Op: ChangeSet, Kind: "domain", Component: "domain_acl", ListType: types.Whitelist, Entries: []string{"*"}}},
+		{"SetNamedDomainList", func() error { return manager.TrySetNamedDomainList("trusted", nil, types.Blacklist, false, 10) },
+			Change{Op: ChangeSet, Kind: "domain", Component: "domain_list:trusted", ListType: types.Blacklist}},
+		{"SetRules", func() error { return manager.TrySetRules(expr.RuleSet{expr.MustCompile("port == 22 -> allow")}) },
+			Change{Op: ChangeSet, Kind: "rules", Component: "rules", Entries: []string{"port == 22 -> allow"}}},
+		{"Reset", manager.TryReset, Change{Op: ChangeReset, Kind: "*", Component: "*"}},
+		{"ApplyPolicy", func() error { return manager.ApplyPolicy(Policy{Rules: []string{"port == 22 -> allow"}}) },
+			Change{Op: ChangeReplace, Kind: "*", Component: "*", Policy: &Policy{Rules: []string{"port == 22 -> allow"}}}},
+		{"LoadSnapshot", func() error { return manager.LoadSnapshot(bytes.NewReader(snapshot.Bytes())) },
+			Change{Op: ChangeReplace, Kind: "*", Component: "*", Policy: &Policy{
+				Version: PolicyVersion, IP: &IPPolicy{Type: "whitelist", Ranges: []string{"198.51.100.1"}},
+			}}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got = Change{}
+			err := tt.mutate()
+			if !errors.Is(err, ErrChangeRejected) || !errors.Is(err, denied) {
+				t.Fatalf("错误 = %v, 期望同时包装 ErrChangeRejected 和拦截函数的错误", err)
+			}
+			var rejected *RejectedChangeError
+			if !errors.As(err, &rejected) || !reflect.DeepEqual(rejected.Change, got) {
+				t.Errorf("RejectedChangeError.Change = %+v, 期望与拦截函数收到的相同", rejected)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("拦截函数收到 %+v, 期望 %+v", got, tt.want)
+			}
+		})
+	}
+
+	if after := manager.Policy(); !reflect.DeepEqual(after, before) {
+		t.Errorf("被拒绝的修改改变了配置:\n之前 %+v\n之后 %+v", before, after)
+	}
+	if manager.Revision() != revision {
+		t.Errorf("修订号 = %d, 期望保持 %d", manager.Revision(), revision)
+	}
+}
+
+// TestMutationInterceptorPolicy 测试按组织规则拒绝部分修改、panic视为拒绝以及取消拦截
+func TestMutationInterceptorPolicy(t *testing.T) {
+	manager := NewManager()
+	if err := manager.SetIPACL([]string{"10.0.0.0/8"}, types.Whitelist); err != nil {
+		t.Fatal(err)
+	}
+	manager.SetMutationInterceptor(func(c Change) error {
+		if c.Kind != "ip" || c.ListType != types.Whitelist {
+			return nil
+		}
+		for _, entry := range c.Entries {
+			if entry == "0.0.0.0/0" || entry == "::/0" {
+				return errors.New("白名单中不允许所有地址")
+			}
+		}
+		return nil
+	})
+
+	if err := manager.AddIP("192.0.2.0/24"); err != nil {
+		t.Errorf("AddIP() 返回错误: %v", err)
+	}
+	if err := manager.AddIP("0.0.0.0/0"); !errors.Is(err, ErrChangeRejected) {
+		t.Errorf("AddIP(\"0.0.0.0/0\") 错误 = %v, 期望 ErrChangeRejected", err)
+	}
+	if err := manager.SetIPACL([]string{"0.0.0.0/0"}, types.Blacklist); err != nil {
+		t.Errorf("黑名单中的所有地址 SetIPACL() 返回错误: %v", err)
+	}
+	if got := manager.GetIPRanges(); !reflect.DeepEqual(got, []string{"0.0.0.0/0"}) {
+		t.Errorf("GetIPRanges() = %v", got)
+	}
+
+	manager.SetMutationInterceptor(func(Change) error { panic("拦截函数的缺陷") })
+	if err := manager.AddIP("192.0.2.1"); !errors.Is(err, ErrChangeRejected) || !errors.Is(err, types.ErrPanic) {
+		t.Errorf("拦截函数panic时错误 = %v, 期望 ErrChangeRejected 和 types.ErrPanic", err)
+	}
+
+	manager.SetMutationInterceptor(nil)
+	if err := manager.AddIP("192.0.2.1"); err != nil {
+		t.Errorf("取消拦截后 AddIP() 返回错误: %v", err)
+	}
+}
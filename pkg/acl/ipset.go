@@ -0,0 +1,80 @@
+package acl
+
+import (
+	"io"
+
+	"github.com/cyberspacesec/go-acl/pkg/ip"
+	"github.com/cyberspacesec/go-acl/pkg/types"
+)
+
+// ExportIPSet 将IP主列表导出为ipset save格式
+//
+// 参数:
+//   - w: 输出目标
+//   - names: 各地址族的集合名称，例如ip.DefaultIPSetNames("go-acl")
+//
+// 返回:
+//   - error: 未设置IP ACL时返回types.ErrNoACL，其他错误见ip.ExportIPSet
+//
+// 示例:
+//
+//	f, _ := os.Create("deny.ipset")
+//	defer f.Close()
+//	manager.ExportIPSet(f, ip.DefaultIPSetNames("go-acl-deny"))
+//	// ipset restore -exist < deny.ipset
+func (m *Manager) ExportIPSet(w io.Writer, names ip.IPSetNames) error {
+	m.ipMu.RLock()
+	defer m.ipMu.RUnlock()
+
+	if m.ipACL == nil {
+		return types.ErrNoACL
+	}
+	return ip.ExportIPSet(w, m.ipACL, names)
+}
+
+// ImportIPSet 将ipset save格式中的每个集合导入为一个命名IP列表
+//
+// 参数:
+//   - r: ipset save的输出
+//   - listType: 导入的列表类型
+//   - priority: 导入的列表的优先级
+//   - rename: 集合名称到列表名称的映射，不在映射中的集合使用集合名称；
+//     映射到同一名称的集合合并为一个列表，例如把"deny"和"deny6"都映射为"deny"
+//
+// 返回:
+//   - []string: 导入的列表名称，按首次出现的顺序排列
+//   - error: 解析错误，见ip.ImportIPSet；出错时不导入任何列表
+//
+// 已存在同名列表时替换其内容，与SetNamedIPList相同。
+//
+// 示例:
+//
+//	out, _ := exec.Command("ipset", "save").Output()
+//	lists, err := manager.ImportIPSet(bytes.NewReader(out), types.Blacklist, 10,
+//	    map[string]string{"deny6": "deny"})
+func (m *Manager) ImportIPSet(r io.Reader, listType types.ListType, priority int, rename map[string]string) ([]string, error) {
+	sets, err := ip.ImportIPSet(r)
+	if err != nil {
+		return nil, err
+	}
+
+	var names []string
+	entries := make(map[string][]string)
+	for _, s := range sets {
+		name := s.Name
+		if mapped, ok := rename[name]; ok {
+			name = mapped
+		}
+		if _, ok := entries[name]; !ok {
+			names = append(names, name)
+		}
+		entries[name] = append(entries[name], s.Entries...)
+	}
+
+	for _, name := range names {
+		if err := m.SetNamedIPList(name, entries[name], listType, priority); err != nil {
+			return nil, err
+		}
+	}
+	return names, nil
+}
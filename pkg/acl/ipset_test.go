@@ -0,0 +1,46 @@
+package acl
+
+import (
+	"bytes"
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/cyberspacesec/go-acl/pkg/ip"
+	"github.com/cyberspacesec/go-acl/pkg/types"
+)
+
+// TestIPSetRoundTrip 测试导出主列表后按集合名称映射导入为命名列表
+func TestIPSetRoundTrip(t *testing.T) {
+	manager := NewManager()
+	if err := manager.ExportIPSet(&bytes.Buffer{}, ip.DefaultIPSetNames("deny")); !errors.Is(err, types.ErrNoACL) {
+		t.Errorf("未设置ACL时 ExportIPSet() 错误 = %v, 期望 ErrNoACL", err)
+	}
+
+	if err := manager.SetIPACL([]string{"203.0.113.0/24", "2001:db8::/32"}, types.Blacklist); err != nil {
+		t.Fatalf("SetIPACL() 返回错误: %v", err)
+	}
+	var buf bytes.Buffer
+	if err := manager.ExportIPSet(&buf, ip.DefaultIPSetNames("deny")); err != nil {
+		t.Fatalf("ExportIPSet() 返回错误: %v", err)
+	}
+
+	imported := NewManager()
+	names, err := imported.ImportIPSet(&buf, types.Blacklist, 0, map[string]string{"deny6": "deny"})
+	if err != nil {
+		t.Fatalf("ImportIPSet() 返回错误: %v", err)
+	}
+	if !reflect.DeepEqual(names, []string{"deny"}) {
+		t.Errorf("ImportIPSet() = %v, 期望 [deny]", names)
+	}
+	for _, addr := range []string{"203.0.113.5", "2001:db8::1"} {
+		if perm, _ := imported.CheckIP(addr); perm != types.Denied {
+			t.Errorf("CheckIP(%s) = %v, 期望 Denied", addr, perm)
+		}
+	}
+
+	if _, err := imported.ImportIPSet(strings.NewReader("add missing 10.0.0.1\n"), types.Blacklist, 0, nil); !errors.Is(err, ip.ErrInvalidIPSet) {
+		t.Errorf("ImportIPSet() 错误 = %v, 期望 ErrInvalidIPSet", err)
+	}
+}
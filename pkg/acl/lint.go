@@ -0,0 +1,208 @@
+package acl
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/cyberspacesec/go-acl/pkg/domain"
+	"github.com/cyberspacesec/go-acl/pkg/ip"
+	"github.com/cyberspacesec/go-acl/pkg/types"
+)
+
+// LintSeverity 表示检查发现的问题的严重程度
+type LintSeverity int
+
+const (
+	// LintWarning 可能是有意为之的配置，但经常导致意外拒绝
+	LintWarning LintSeverity = iota
+	// LintError 几乎可以肯定是配置错误
+	LintError
+)
+
+// String 返回严重程度的名称
+func (s LintSeverity) String() string {
+	if s == LintError {
+		return "error"
+	}
+	return "warning"
+}
+
+// LintFinding 是Lint发现的一个问题
+//
+// 字段说明:
+//   - Check: 检查项名称，如"empty_whitelist"、"non_public_whitelist"
+//   - Severity: 严重程度
+//   - Component: 有问题的ACL，名称与ComponentUsage.Name相同，如"ip_acl"、"domain_list:partners"
+//   - Message: 问题说明
+type LintFinding struct {
+	Check     string       `json:"check"`
+	Severity  LintSeverity `json:"severity"`
+	Component string       `json:"component"`
+	Message   string       `json:"message"`
+}
+
+// LintReport 是Lint的结果
+type LintReport struct {
+	Findings []LintFinding `json:"findings,omitempty"`
+}
+
+// Passed 判断是否没有LintError级别的问题，警告不影响结果
+func (r LintReport) Passed() bool {
+	for _, f := range r.Findings {
+		if f.Severity == LintError {
+			return false
+		}
+	}
+	return true
+}
+
+// String 返回适合打印的检查报告
+func (r LintReport) String() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "发现%d个问题\n", len(r.Findings))
+	for _, f := range r.Findings {
+		fmt.Fprintf(&b, "  %s [%s] %s: %s\n", f.Severity, f.Check, f.Component, f.Message)
+	}
+	return b.String()
+}
+
+// lintNonPublicSets 是不可从公网访问的预定义集合
+// AllSpecialNetworks包含公共DNS，不能用于判断地址是否为公网地址
+var lintNonPublicSets = []ip.PredefinedSet{
+	ip.PrivateNetworks,
+	ip.LoopbackNetworks,
+	ip.LinkLocalNetworks,
+	ip.CloudMetadata,
+	ip.DockerNetworks,
+	ip.BroadcastAddresses,
+	ip.MulticastAddresses,
+	ip.ReservedAddresses,
+	ip.TestNetworks,
+	ip.K8sServiceAddresses,
+	ip.CarrierGradeNAT,
+	ip.UniqueLocalAddresses,
+}
+
+// lintNonPublicSuffixes 是不会出现在公网上的域名后缀（RFC 6761、RFC 8375及常见的内部后缀）
+var lintNonPublicSuffixes = []string{"localhost", "local", "internal", "lan", "home.arpa", "invalid", "test"}
+
+// Lint 检查管理器当前的策略中常见的配置错误
+//
+// 参数:
+//   - manager: 要检查的ACL管理器
+//
+// 返回:
+//   - LintReport: 发现的问题，Passed()为true表示没有错误级别的问题
+//
+// 目前检查白名单"饿死"的情况，即白名单的条目不能匹配任何有用的目标:
+//   - empty_whitelist: 主列表是空白名单（域名白名单也没有允许的节点策略），等同于拒绝所有目标（错误）；
+//     空的命名白名单永远不会命中（警告）
+//   - non_public_whitelist: IP白名单只包含私有、回环、链路本地等非公网地址，
+//     或域名白名单只包含localhost、.internal等内部域名，所有公网目标（如调用的第三方API）都会被拒绝（警告）
+//
+// 所有命名列表都会被检查，包括所属规则组已停用的列表。
+// 需要解析域名的检查（dual_stack_mismatch）见LintWithResolver。
+//
+// 示例:
+//
+//	if report := acl.Lint(manager); !report.Passed() {
+//	    log.Fatalf("策略存在问题:\n%s", report)
+//	}
+func Lint(manager *Manager) LintReport {
+	var report LintReport
+
+	manager.ipMu.RLock()
+	if manager.ipACL != nil {
+		lintIPWhitelist(&report, "ip_acl", manager.ipACL, true)
+	}
+	for _, l := range manager.ipLists {
+		lintIPWhitelist(&report, "ip_list:"+l.name, l.ip, false)
+	}
+	manager.ipMu.RUnlock()
+
+	manager.domainMu.RLock()
+	if manager.domainACL != nil {
+		lintDomainWhitelist(&report, "domain_acl", manager.domainACL, true)
+	}
+	for _, l := range manager.domainLists {
+		lintDomainWhitelist(&report, "domain_list:"+l.name, l.domain, false)
+	}
+	manager.domainMu.RUnlock()
+
+	return report
+}
+
+// lintEmptyWhitelist 报告空白名单，main表示是否为主列表
+func lintEmptyWhitelist(report *LintReport, component string, main bool) {
+	if main {
+		report.add("empty_whitelist", LintError, component, "白名单为空，所有目标都会被拒绝")
+		return
+	}
+	report.add("empty_whitelist", LintWarning, component, "命名白名单为空，永远不会命中")
+}
+
+// lintIPWhitelist 检查IP白名单
+func lintIPWhitelist(report *LintReport, component string, acl *ip.IPACL, main bool) {
+	if acl.GetListType() != types.Whitelist {
+		return
+	}
+	ranges := acl.GetIPRanges()
+	if len(ranges) == 0 {
+		lintEmptyWhitelist(report, component, main)
+		return
+	}
+
+	nonPublic, err := ip.NewIPACLWithDefaults(nil, types.Blacklist, lintNonPublicSets, false)
+	if err != nil {
+		return
+	}
+	if len(ip.Covers(nonPublic, acl)) == len(ranges) {
+		report.add("non_public_whitelist", LintWarning, component,
+			fmt.Sprintf("白名单的%d条规则都是非公网地址，所有公网目标都会被拒绝", len(ranges)))
+	}
+}
+
+// lintDomainWhitelist 检查域名白名单
+func lintDomainWhitelist(report *LintReport, component string, acl *domain.DomainACL, main bool) {
+	if acl.GetListType() != types.Whitelist {
+		return
+	}
+	for _, perm := range acl.GetPolicies() {
+		if perm == types.Allowed {
+			return
+		}
+	}
+	rules := acl.GetDomains()
+	if len(rules) == 0 {
+		lintEmptyWhitelist(report, component, main)
+		return
+	}
+
+	for _, rule := range rules {
+		if !lintNonPublicDomain(rule) {
+			return
+		}
+	}
+	report.add("non_public_whitelist", LintWarning, component,
+		fmt.Sprintf("白名单的%d条规则都是内部域名，所有公网目标都会被拒绝", len(rules)))
+}
+
+// lintNonPublicDomain 判断规则是否只匹配内部域名，正则表达式规则无法判断，视为公网域名
+func lintNonPublicDomain(rule string) bool {
+	parsed, err := domain.ParseRule(rule)
+	if err != nil || parsed.Kind == domain.MatchRegex {
+		return false
+	}
+	name := strings.TrimPrefix(parsed.Value, ".")
+	for _, suffix := range lintNonPublicSuffixes {
+		if name == suffix || strings.HasSuffix(name, "."+suffix) {
+			return true
+		}
+	}
+	return false
+}
+
+// add 追加一个问题
+func (r *LintReport) add(check string, severity LintSeverity, component, message string) {
+	r.Findings = append(r.Findings, LintFinding{Check: check, Severity: severity, Component: component, Message: message})
+}
@@ -0,0 +1,78 @@
+package acl
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/cyberspacesec/go-acl/pkg/types"
+)
+
+// TestLintWhitelist 测试白名单饿死的检查
+func TestLintWhitelist(t *testing.T) {
+	tests := []struct {
+		name     string
+		setup    func(m *Manager)
+		want     []string // "检查项 组件"
+		wantPass bool
+	}{
+		{"黑名单", func(m *Manager) {
+			m.SetIPACL(nil, types.Blacklist)
+			m.SetDomainACL(nil, types.Blacklist, true)
+		}, nil, true},
+		{"空IP白名单", func(m *Manager) {
+			m.SetIPACL(nil, types.Whitelist)
+		}, []string{"empty_whitelist ip_acl"}, false},
+		{"空域名白名单", func(m *Manager) {
+			m.SetDomainACL(nil, types.Whitelist, true)
+		}, []string{"empty_whitelist domain_acl"}, false},
+		{"有允许策略的空域名白名单", func(m *Manager) {
+			m.SetDomainACL(nil, types.Whitelist, true)
+			m.domainACL.SetPolicy("api.example.com", types.Allowed)
+		}, nil, true},
+		{"空命名白名单", func(m *Manager) {
+			m.SetNamedIPList("partners", nil, types.Whitelist, 0)
+		}, []string{"empty_whitelist ip_list:partners"}, true},
+		{"只有私有地址的IP白名单", func(m *Manager) {
+			m.SetIPACL([]string{"10.0.0.0/8", "192.168.1.10", "fd00::/8"}, types.Whitelist)
+		}, []string{"non_public_whitelist ip_acl"}, true},
+		{"包含公网地址的IP白名单", func(m *Manager) {
+			m.SetIPACL([]string{"10.0.0.0/8", "8.8.8.8"}, types.Whitelist)
+		}, nil, true},
+		{"只有内部域名的域名白名单", func(m *Manager) {
+			m.SetNamedDomainList("internal", []string{"localhost", "api.corp.internal", "suffix:.svc.local"}, types.Whitelist, true, 0)
+		}, []string{"non_public_whitelist domain_list:internal"}, true},
+		{"包含正则表达式的域名白名单", func(m *Manager) {
+			m.SetDomainACL([]string{"db.internal", `regex:^api[0-9]\.example\.com$`}, types.Whitelist, true)
+		}, nil, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			manager := NewManager()
+			tt.setup(manager)
+			report := Lint(manager)
+
+			var got []string
+			for _, f := range report.Findings {
+				got = append(got, f.Check+" "+f.Component)
+			}
+			if strings.Join(got, ",") != strings.Join(tt.want, ",") {
+				t.Errorf("Findings = %v, 期望 %v", got, tt.want)
+			}
+			if report.Passed() != tt.wantPass {
+				t.Errorf("Passed() = %v, 期望 %v", report.Passed(), tt.wantPass)
+			}
+		})
+	}
+}
+
+// TestLintReportString 测试检查报告的文本形式
+func TestLintReportString(t *testing.T) {
+	manager := NewManager()
+	manager.SetIPACL(nil, types.Whitelist)
+
+	want := "发现1个问题\n  error [empty_whitelist] ip_acl: 白名单为空，所有目标都会被拒绝\n"
+	if got := Lint(manager).String(); got != want {
+		t.Errorf("String() = %q, 期望 %q", got, want)
+	}
+}
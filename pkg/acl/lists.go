@@ -0,0 +1,336 @@
+package acl
+
+import (
+	"errors"
+	"sort"
+	"time"
+
+	"github.com/cyberspacesec/go-acl/pkg/domain"
+	"github.com/cyberspacesec/go-acl/pkg/ip"
+	"github.com/cyberspacesec/go-acl/pkg/types"
+)
+
+// ErrListNotFound 表示指定名称的命名列表不存在
+var ErrListNotFound = errors.New("命名列表不存在")
+
+// ListInfo 命名列表的概要信息
+//
+// 字段说明:
+//   - Name: 列表名称，如"geo-block"、"partners"、"temp-bans"
+//   - Type: 列表类型（黑名单或白名单）
+//   - Priority: 优先级，数值越小越先求值
+//   - Size: 列表中的规则数量
+//   - Group: 所属的规则组，空表示不属于任何组
+//   - Temporary: Size中带有到期时间的临时条目数量，见AddNamedIPListEntriesTTL
+type ListInfo struct {
+	Name      string
+	Type      types.ListType
+	Priority  int
+	Size      int
+	Group     string
+	Temporary int
+}
+
+// namedList 是Manager中的一个命名列表，ip和domain中只有一个非nil
+type namedList struct {
+	name     string
+	priority int
+	// seq 是列表首次加入的顺序，优先级相同时先加入的列表先求值
+	seq uint64
+	// group 是所属的规则组，空表示不属于任何组
+	group string
+	// modified 是列表内容最近一次被设置的时间
+	modified time.Time
+	// expiry 是临时条目（标准化后的写法）的到期时间，nextExpiry 是其中最早的时间，
+	// 可能早于实际最早的时间（条目被延期后），由sweep重新计算
+	expiry     map[string]time.Time
+	nextExpiry time.Time
+	ip         *ip.IPACL
+	domain     *domain.DomainACL
+}
+
+// info 返回列表的概要信息
+func (l namedList) info() ListInfo {
+	if l.ip != nil {
+		return ListInfo{Name: l.name, Type: l.ip.GetListType(), Priority: l.priority, Size: len(l.ip.GetIPRanges()), Group: l.group, Temporary: len(l.expiry)}
+	}
+	return ListInfo{Name: l.name, Type: l.domain.GetListType(), Priority: l.priority, Size: len(l.domain.GetDomains()), Group: l.group, Temporary: len(l.expiry)}
+}
+
+// SetNamedIPList 设置一个命名IP列表
+//
+// 参数:
+//   - name: 列表名称，已存在同名列表时替换其内容和优先级，所属的规则组保持不变
+//   - ipRanges: IP或CIDR列表
+//   - listType: 列表类型
+//     types.Blacklist: 命中时拒绝
+//     types.Whitelist: 命中时允许
+//   - priority: 优先级，数值越小越先求值，相同优先级按加入顺序求值
+//
+// 返回:
+//   - error: 如果ipRanges中包含无效的IP或CIDR，返回相应错误；
+//     超出列表的配额时返回包装了ErrQuotaExceeded的错误（见SetListQuota）。出错时原有列表保持不变
+//
+// 命名列表与SetIPACL设置的主列表共存。CheckIP按优先级依次查询命名列表，
+// 第一个命中的列表决定结果；未命中的列表不影响结果（白名单未命中不会拒绝）。
+// 所有命名列表均未命中时:
+//   - 设置了主列表: 由主列表按其类型决定
+//   - 未设置主列表: 存在任一命名白名单时拒绝，否则允许
+//
+// 示例:
+//
+//	manager.SetNamedIPList("partners", partnerRanges, types.Whitelist, 10)
+//	manager.SetNamedIPList("temp-bans", bannedIPs, types.Blacklist, 20)
+//	manager.SetNamedIPList("geo-block", geoRanges, types.Blacklist, 30)
+//
+//	// 合作方的IP即使在geo-block中也被允许
+func (m *Manager) SetNamedIPList(name string, ipRanges []string, listType types.ListType, priority int) error {
+	acl, err := ip.NewIPACL(ipRanges, listType)
+	if err != nil {
+		return err
+	}
+	if err := m.CheckQuota("ip_list:"+name, len(acl.GetIPRanges())); err != nil {
+		return err
+	}
+	if err := m.intercept(Change{Op: ChangeSet, Kind: "ip", Component: "ip_list:" + name, ListType: listType, Entries: ipRanges}); err != nil {
+		return err
+	}
+	now := m.Clock().Now()
+
+	m.ipMu.Lock()
+	defer m.ipMu.Unlock()
+	m.ipLists = putList(m.ipLists, namedList{name: name, priority: priority, modified: now, ip: acl})
+	m.notifyChange("ip", "ip_list:"+name, now)
+	return nil
+}
+
+// SetNamedDomainList 设置一个命名域名列表
+//
+// 参数:
+//   - name: 列表名称，已存在同名列表时替换其内容和优先级，所属的规则组保持不变
+//   - domains: 域名列表
+//   - listType: 列表类型
+//   - includeSubdomains: 是否包含子域名
+//   - priority: 优先级，数值越小越先求值，相同优先级按加入顺序求值
+//
+// 求值规则与SetNamedIPList相同，CheckDomain先按优先级查询命名域名列表，
+// 均未命中时再查询SetDomainACL设置的主列表。
+// 修改被SetMutationInterceptor设置的拦截函数拒绝时不做任何修改，需要得到拒绝的错误时使用TrySetNamedDomainList。
+//
+// 示例:
+//
+//	manager.SetNamedDomainList("trusted", []string{"example.com"}, types.Whitelist, true, 10)
+//	manager.SetNamedDomainList("malware", malwareDomains, types.Blacklist, true, 20)
+func (m *Manager) SetNamedDomainList(name string, domains []string, listType types.ListType, includeSubdomains bool, priority int) {
+	_ = m.TrySetNamedDomainList(name, domains, listType, includeSubdomains, priority)
+}
+
+// TrySetNamedDomainList 与SetNamedDomainList相同，但返回拦截函数拒绝修改的错误
+//
+// 返回:
+//   - error: 修改被SetMutationInterceptor设置的拦截函数拒绝时返回*RejectedChangeError，原有列表保持不变
+func (m *Manager) TrySetNamedDomainList(name string, domains []string, listType types.ListType, includeSubdomains bool, priority int) error {
+	if err := m.intercept(Change{Op: ChangeSet, Kind: "domain", Component: "domain_list:" + name, ListType: listType, Entries: domains}); err != nil {
+		return err
+	}
+	acl := domain.NewDomainACL(domains, listType, includeSubdomains)
+	now := m.Clock().Now()
+
+	m.domainMu.Lock()
+	defer m.domainMu.Unlock()
+	m.domainLists = putList(m.domainLists, namedList{name: name, priority: priority, modified: now, domain: acl})
+	m.notifyChange("domain", "domain_list:"+name, now)
+	return nil
+}
+
+// RemoveNamedIPList 移除命名IP列表
+//
+// 参数:
+//   - name: 列表名称
+//
+// 返回:
+//   - error: 如果列表不存在，返回ErrListNotFound
+func (m *Manager) RemoveNamedIPList(name string) error {
+	if err := m.intercept(Change{Op: ChangeDelete, Kind: "ip", Component: "ip_list:" + name}); err != nil {
+		return err
+	}
+	now := m.Clock().Now()
+
+	m.ipMu.Lock()
+	defer m.ipMu.Unlock()
+
+	lists, ok := removeList(m.ipLists, name)
+	if !ok {
+		return ErrListNotFound
+	}
+	m.ipLists = lists
+	m.notifyChange("ip", "ip_list:"+name, now)
+	return nil
+}
+
+// RemoveNamedDomainList 移除命名域名列表
+//
+// 参数:
+//   - name: 列表名称
+//
+// 返回:
+//   - error: 如果列表不存在，返回ErrListNotFound
+func (m *Manager) RemoveNamedDomainList(name string) error {
+	if err := m.intercept(Change{Op: ChangeDelete, Kind: "domain", Component: "domain_list:" + name}); err != nil {
+		return err
+	}
+	now := m.Clock().Now()
+
+	m.domainMu.Lock()
+	defer m.domainMu.Unlock()
+
+	lists, ok := removeList(m.domainLists, name)
+	if !ok {
+		return ErrListNotFound
+	}
+	m.domainLists = lists
+	m.notifyChange("domain", "domain_list:"+name, now)
+	return nil
+}
+
+// NamedIPLists 返回所有命名IP列表的概要信息
+//
+// 返回:
+//   - []ListInfo: 按求值顺序排列的列表信息，没有命名列表时为空
+func (m *Manager) NamedIPLists() []ListInfo {
+	m.ipMu.RLock()
+	defer m.ipMu.RUnlock()
+	return listInfos(m.ipLists)
+}
+
+// NamedDomainLists 返回所有命名域名列表的概要信息
+//
+// 返回:
+//   - []ListInfo: 按求值顺序排列的列表信息，没有命名列表时为空
+func (m *Manager) NamedDomainLists() []ListInfo {
+	m.domainMu.RLock()
+	defer m.domainMu.RUnlock()
+	return listInfos(m.domainLists)
+}
+
+// putList 加入或替换命名列表并保持求值顺序，调用方需持有对应的写锁
+func putList(lists []namedList, list namedList) []namedList {
+	replaced := false
+	for i := range lists {
+		if lists[i].name == list.name {
+			list.seq = lists[i].seq
+			list.group = lists[i].group
+			lists[i] = list
+			replaced = true
+			break
+		}
+	}
+	if !replaced {
+		// 新列表排在同优先级的已有列表之后
+		for _, l := range lists {
+			if l.seq >= list.seq {
+				list.seq = l.seq + 1
+			}
+		}
+		lists = append(lists, list)
+	}
+
+	sort.Slice(lists, func(i, j int) bool {
+		if lists[i].priority != lists[j].priority {
+			return lists[i].priority < lists[j].priority
+		}
+		return lists[i].seq < lists[j].seq
+	})
+	return lists
+}
+
+// removeList 移除指定名称的列表，返回新的列表和是否找到
+func removeList(lists []namedList, name string) ([]namedList, bool) {
+	for i := range lists {
+		if lists[i].name == name {
+			return append(lists[:i:i], lists[i+1:]...), true
+		}
+	}
+	return lists, false
+}
+
+// listInfos 返回列表的概要信息
+func listInfos(lists []namedList) []ListInfo {
+	if len(lists) == 0 {
+		return nil
+	}
+	infos := make([]ListInfo, len(lists))
+	for i, l := range lists {
+		infos[i] = l.info()
+	}
+	return infos
+}
+
+// defaultPermission 返回列表类型在未命中时的结果
+func defaultPermission(listType types.ListType) types.Permission {
+	if listType == types.Whitelist {
+		return types.Denied
+	}
+	return types.Allowed
+}
+
+// namedListsDefault 返回所有命名列表均未命中且没有主列表时的结果，调用方需持有对应的读锁
+//
+// ok为false表示没有启用的命名列表，此时应按未配置ACL处理。
+// TemporaryAllowList只用于临时放行，不参与默认结果的计算。
+func namedListsDefault(lists []namedList, disabled map[string]struct{}) (perm types.Permission, ok bool) {
+	perm = types.Allowed
+	for _, l := range lists {
+		if !groupEnabled(disabled, l.group) || (l.ip != nil && l.name == TemporaryAllowList) {
+			continue
+		}
+		ok = true
+		if l.info().Type == types.Whitelist {
+			perm = types.Denied
+		}
+	}
+	return perm, ok
+}
+
+// checkNamedIPLists 按求值顺序查询命名IP列表，调用方需持有ipMu的读锁
+//
+// 返回第一个命中的列表给出的结果；decided为false表示没有列表命中。
+// 地址族不符合列表限制时视为未命中，只命中到期条目的列表也视为未命中（见expiredHit）。
+func (m *Manager) checkNamedIPLists(ipStr string, disabled map[string]struct{}, now time.Time) (perm types.Permission, list *namedList, decided bool, err error) {
+	for i, l := range m.ipLists {
+		if !groupEnabled(disabled, l.group) {
+			continue
+		}
+		perm, err := l.ip.Check(ipStr)
+		if errors.Is(err, ip.ErrFamilyNotAllowed) {
+			continue
+		}
+		if err != nil {
+			return types.Denied, nil, true, err
+		}
+		if perm != defaultPermission(l.ip.GetListType()) && !l.expiredHit(now, ipStr) {
+			return perm, &m.ipLists[i], true, nil
+		}
+	}
+	return types.Denied, nil, false, nil
+}
+
+// checkNamedDomainLists 按求值顺序查询命名域名列表，调用方需持有domainMu的读锁
+//
+// 返回第一个命中的列表给出的结果；decided为false表示没有列表命中。
+// 只命中到期条目的列表视为未命中（见expiredHit）。
+func (m *Manager) checkNamedDomainLists(domainName string, disabled map[string]struct{}, now time.Time) (perm types.Permission, list *namedList, decided bool, err error) {
+	for i, l := range m.domainLists {
+		if !groupEnabled(disabled, l.group) {
+			continue
+		}
+		perm, err := l.domain.Check(domainName)
+		if err != nil {
+			return types.Denied, nil, true, err
+		}
+		if perm != defaultPermission(l.domain.GetListType()) && !l.expiredHit(now, domainName) {
+			return perm, &m.domainLists[i], true, nil
+		}
+	}
+	return types.Denied, nil, false, nil
+}
@@ -0,0 +1,166 @@
+package acl
+
+import (
+	"bytes"
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/cyberspacesec/go-acl/pkg/types"
+)
+
+// TestNamedIPLists 测试命名IP列表的求值顺序
+func TestNamedIPLists(t *testing.T) {
+	manager := NewManager()
+	if err := manager.SetNamedIPList("geo-block", []string{"203.0.113.0/24"}, types.Blacklist, 30); err != nil {
+		t.Fatalf("SetNamedIPList() 返回错误: %v", err)
+	}
+	if err := manager.SetNamedIPList("partners", []string{"203.0.113.10"}, types.Whitelist, 10); err != nil {
+		t.Fatalf("SetNamedIPList() 返回错误: %v", err)
+	}
+	if err := manager.SetNamedIPList("temp-bans", []string{"198.51.100.7"}, types.Blacklist, 20); err != nil {
+		t.Fatalf("SetNamedIPList() 返回错误: %v", err)
+	}
+
+	tests := []struct {
+		name string
+		ip   string
+		want types.Permission
+	}{
+		{"合作方优先于地区封禁", "203.0.113.10", types.Allowed},
+		{"地区封禁", "203.0.113.11", types.Denied},
+		{"临时封禁", "198.51.100.7", types.Denied},
+		{"均未命中且存在白名单时拒绝", "8.8.8.8", types.Denied},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := manager.CheckIP(tt.ip)
+			if err != nil {
+				t.Fatalf("CheckIP() 返回错误: %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("CheckIP(%s) = %v, 期望 %v", tt.ip, got, tt.want)
+			}
+		})
+	}
+
+	// 主列表在命名列表均未命中时决定结果
+	manager.SetIPACL([]string{"10.0.0.0/8"}, types.Blacklist)
+	if got, _ := manager.CheckIP("8.8.8.8"); got != types.Allowed {
+		t.Errorf("设置主黑名单后 CheckIP(8.8.8.8) = %v, 期望 Allowed", got)
+	}
+	if got, _ := manager.CheckIP("203.0.113.11"); got != types.Denied {
+		t.Errorf("设置主黑名单后 CheckIP(203.0.113.11) = %v, 期望 Denied", got)
+	}
+
+	if _, err := manager.CheckIP("not-an-ip"); err == nil {
+		t.Error("无效IP应返回错误")
+	}
+}
+
+// TestNamedListsOnlyBlacklists 测试只有命名黑名单时的默认结果
+func TestNamedListsOnlyBlacklists(t *testing.T) {
+	manager := NewManager()
+	manager.SetNamedDomainList("malware", []string{"evil.com"}, types.Blacklist, true, 0)
+
+	if got, err := manager.CheckDomain("example.com"); err != nil || got != types.Allowed {
+		t.Errorf("CheckDomain(example.com) = %v, %v, 期望 Allowed", got, err)
+	}
+	if got, _ := manager.CheckDomain("www.evil.com"); got != types.Denied {
+		t.Errorf("CheckDomain(www.evil.com) = %v, 期望 Denied", got)
+	}
+
+	if err := manager.RemoveNamedDomainList("malware"); err != nil {
+		t.Fatalf("RemoveNamedDomainList() 返回错误: %v", err)
+	}
+	if _, err := manager.CheckDomain("example.com"); !errors.Is(err, types.ErrNoACL) {
+		t.Errorf("移除所有列表后应返回 ErrNoACL, 实际: %v", err)
+	}
+	if err := manager.RemoveNamedDomainList("malware"); !errors.Is(err, ErrListNotFound) {
+		t.Errorf("移除不存在的列表应返回 ErrListNotFound, 实际: %v", err)
+	}
+}
+
+// TestNamedListOrder 测试列表的排序与替换
+func TestNamedListOrder(t *testing.T) {
+	manager := NewManager()
+	manager.SetNamedDomainList("b", []string{"b.com"}, types.Blacklist, false, 5)
+	manager.SetNamedDomainList("a", []string{"a.com", "a.org"}, types.Whitelist, true, 5)
+	manager.SetNamedDomainList("c", nil, types.Blacklist, false, 1)
+
+	names := func() []string {
+		var result []string
+		for _, info := range manager.NamedDomainLists() {
+			result = append(result, info.Name)
+		}
+		return result
+	}
+	if got := names(); !reflect.DeepEqual(got, []string{"c", "b", "a"}) {
+		t.Errorf("求值顺序 = %v, 期望 [c b a]", got)
+	}
+
+	// 替换时保留加入顺序，只更新优先级和内容
+	manager.SetNamedDomainList("b", []string{"b.com"}, types.Blacklist, false, 0)
+	if got := names(); !reflect.DeepEqual(got, []string{"b", "c", "a"}) {
+		t.Errorf("替换后求值顺序 = %v, 期望 [b c a]", got)
+	}
+
+	infos := manager.NamedDomainLists()
+	if infos[2] != (ListInfo{Name: "a", Type: types.Whitelist, Priority: 5, Size: 2}) {
+		t.Errorf("列表信息 = %+v", infos[2])
+	}
+
+	if err := manager.SetNamedIPList("bad", []string{"invalid"}, types.Blacklist, 0); err == nil {
+		t.Error("无效的IP列表应返回错误")
+	}
+	if lists := manager.NamedIPLists(); lists != nil {
+		t.Errorf("设置失败后不应添加列表: %v", lists)
+	}
+
+	manager.Reset()
+	if lists := manager.NamedDomainLists(); lists != nil {
+		t.Errorf("Reset() 后应清除命名列表: %v", lists)
+	}
+}
+
+// TestNamedListsExplainAndSnapshot 测试命名列表在Explain和快照中的表现
+func TestNamedListsExplainAndSnapshot(t *testing.T) {
+	manager := NewManager()
+	manager.SetNamedIPList("partners", []string{"192.0.2.0/24"}, types.Whitelist, 1)
+	manager.SetNamedIPList("bans", []string{"192.0.2.66", "2001:db8::/32"}, types.Blacklist, 2)
+	manager.SetNamedDomainList("trusted", []string{"example.com"}, types.Whitelist, true, 1)
+
+	e := manager.Explain("198.51.100.1")
+	if e.Decision != types.Denied || e.Err != nil || e.MatchedRule != "" {
+		t.Errorf("Explain(198.51.100.1) = %v", e)
+	}
+	e = manager.Explain("192.0.2.66")
+	if e.Decision != types.Allowed || e.MatchedRule != "list:partners" {
+		t.Errorf("Explain(192.0.2.66) = %v", e)
+	}
+	if !strings.Contains(e.String(), "[ip_list] 列表 partners") {
+		t.Errorf("Explain 输出应包含命名列表步骤:\n%s", e)
+	}
+
+	var buf bytes.Buffer
+	if err := manager.SaveSnapshot(&buf); err != nil {
+		t.Fatalf("SaveSnapshot() 返回错误: %v", err)
+	}
+	restored := NewManager()
+	if err := restored.LoadSnapshot(&buf); err != nil {
+		t.Fatalf("LoadSnapshot() 返回错误: %v", err)
+	}
+	if !reflect.DeepEqual(restored.NamedIPLists(), manager.NamedIPLists()) {
+		t.Errorf("恢复的命名IP列表 = %v, 期望 %v", restored.NamedIPLists(), manager.NamedIPLists())
+	}
+	for _, target := range []string{"192.0.2.66", "2001:db8::1", "8.8.8.8"} {
+		want, _ := manager.CheckIP(target)
+		if got, _ := restored.CheckIP(target); got != want {
+			t.Errorf("恢复后 CheckIP(%s) = %v, 期望 %v", target, got, want)
+		}
+	}
+	if got, _ := restored.CheckDomain("api.example.com"); got != types.Allowed {
+		t.Errorf("恢复后 CheckDomain(api.example.com) = %v, 期望 Allowed", got)
+	}
+}
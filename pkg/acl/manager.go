@@ -1,16 +1,33 @@
 package acl
 
 import (
+	"context"
 	"sync"
+	"time"
 
+	"github.com/cyberspacesec/go-acl/pkg/config"
 	"github.com/cyberspacesec/go-acl/pkg/domain"
+	"github.com/cyberspacesec/go-acl/pkg/expr"
 	"github.com/cyberspacesec/go-acl/pkg/ip"
 	"github.com/cyberspacesec/go-acl/pkg/types"
 )
 
 // Manager 是访问控制列表管理器，整合了域名和IP访问控制
 // 它提供了一个统一的接口来管理不同类型的访问控制规则
-// 内部使用读写锁确保并发安全
+// 内部使用读写锁确保并发安全，IP和域名ACL各有一把锁，
+// 更新其中一个不会阻塞对另一个的检查
+//
+// 并发保证（公开约定的一部分，由TestManagerConcurrency在-race下验证）：
+//   - 所有方法都可以被多个goroutine同时调用
+//   - 一次IP检查在同一把读锁下读取主列表和所有命名列表，看到的是某次修改调用之前或之后的完整状态，
+//     不会看到修改了一半的列表：AddIP("a", "b")加入的两个条目要么都可见、要么都不可见，
+//     SetIPACL、RemoveIP、Reset、ReloadIPACLFromFile和ApplyPolicy同样如此。域名检查与此相同
+//   - 从文件加载或重新加载时先在锁外读取和校验文件，文件无效时列表保持不变
+//   - 修改调用返回错误时不回滚已经完成的部分，例如AddIP在遇到无效条目之前加入的条目会保留
+//   - IP和域名使用不同的锁，Reset和ApplyPolicy虽然同时替换两者，但先后进行的一次IP检查和一次域名检查
+//     可能看到不同的版本；需要一致的整体视图时使用Policy
+//   - 规则组的启停状态和判断临时条目是否过期的当前时间在获取列表锁之前读取，与列表的修改之间没有原子性
+//   - Revision在修改的写锁内递增，检查返回后读取的修订号不小于检查所见状态的修订号
 //
 // 主要功能：
 //   - 管理域名访问控制（黑/白名单）
@@ -33,9 +50,89 @@ import (
 //	domainPerm, _ := manager.CheckDomain("sub.example.com")
 //	ipPerm, _ := manager.CheckIP("8.8.8.8")
 type Manager struct {
-	mu        sync.RWMutex
+	// stats 必须是第一个字段，保证原子操作的64位对齐
+	stats statsCounters
+	// nextExpiry 是命名列表中最早的临时条目到期时间（UnixNano），0表示没有临时条目，
+	// 通过sync/atomic访问，紧跟在stats之后以保证64位对齐。
+	// 在持有对应列表的写锁时更新，SweepExpired同时持有ipMu和domainMu时重新计算
+	nextExpiry int64
+
+	// mu 保护override、devMode、chaos、budget、strictHostnames、mixedScript、traceNormalization、quotas、rules、authorizers、auditHook、errorThrottle、scopes、requestIDKey、clock、interceptor和disabledGroups，
+	// ipMu 保护IP ACL相关的字段，domainMu 保护域名ACL相关的字段。
+	// 需要同时持有多把锁时，按mu、ipMu、domainMu的顺序加锁。
+	// feedMu 保护feeds、feedCacheDir和feedCacheMaxAge，持有时不获取其他锁
+	mu       sync.RWMutex
+	ipMu     sync.RWMutex
+	domainMu sync.RWMutex
+	feedMu   sync.Mutex
+
+	// 以下字段由domainMu保护
 	domainACL *domain.DomainACL
-	ipACL     *ip.IPACL
+	// domainLists 是按求值顺序排列的命名域名列表
+	domainLists []namedList
+	// domainReload 记录最近一次从文件加载域名规则的结果，用于健康检查
+	domainReload reloadStatus
+	// domainModified 是域名ACL最近一次被修改的时间
+	domainModified time.Time
+
+	// 以下字段由ipMu保护
+	ipACL *ip.IPACL
+	// deniedFamily 表示被整体拒绝的IP地址族，FamilyAny表示不拒绝
+	deniedFamily ip.Family
+	// ipLists 是按求值顺序排列的命名IP列表
+	ipLists []namedList
+	// ipReload 记录最近一次从文件加载IP规则的结果，用于健康检查
+	ipReload reloadStatus
+	// ipModified 是IP ACL最近一次被修改的时间
+	ipModified time.Time
+	// ipPorts 是附加在IP范围上的允许端口，按写时复制的方式更新，见SetIPRulePorts
+	ipPorts []portRule
+
+	// 以下字段由mu保护
+	// override 是紧急模式，overrideSince 是进入该模式的时间，见SetOverrideMode
+	override      OverrideMode
+	overrideSince time.Time
+	// devMode 表示是否对私有地址和环回地址的拒绝只记录不执行，见SetDevMode
+	devMode bool
+	chaos   *ChaosConfig
+	// budget 是每次检查的时间预算，nil表示不限制
+	budget *BudgetConfig
+	// strictHostnames 表示CheckHost是否要求主机部分是有效的DNS名称或IP，见SetStrictHostnames
+	strictHostnames bool
+	// mixedScript 是域名混合文字检查的配置，nil表示不检查，见SetMixedScriptPolicy
+	mixedScript *MixedScriptPolicy
+	// traceNormalization 表示审计事件是否报告输入的标准化过程，见SetNormalizationTrace
+	traceNormalization bool
+	// quotas 是按组件名称索引的规则数量上限，按写时复制的方式更新，见SetListQuota
+	quotas map[string]int
+	// rules 是在CheckRequest中优先求值的条件规则
+	rules expr.RuleSet
+	// authorizers 是本地允许请求后再询问的外部授权来源，nil表示不询问，见SetAuthorizers
+	authorizers *authorizerSet
+	// auditHook 接收每次检查产生的审计事件
+	auditHook AuditHook
+	// errorThrottle 是带错误的审计事件的限流状态，nil表示不限流，见SetErrorAuditThrottle
+	errorThrottle *errorThrottle
+	// scopes 是按名称索引的子视图，见Scope
+	scopes map[string]*Scope
+	// requestIDKey 是从上下文中提取请求ID使用的键，nil表示DefaultRequestIDKey
+	requestIDKey interface{}
+	// clock 是时间源，nil表示types.SystemClock
+	clock types.Clock
+	// interceptor 在修改列表和规则之前检查修改，nil表示不拦截，见SetMutationInterceptor
+	interceptor MutationInterceptor
+	// feeds 是按名称索引的订阅源，由feedMu保护
+	feeds map[string]*feed
+	// feedCacheDir 是订阅源的磁盘缓存目录，为空表示不缓存，见SetFeedCache；由feedMu保护
+	feedCacheDir string
+	// feedCacheMaxAge 是磁盘缓存的有效期，0表示永不过期；由feedMu保护
+	feedCacheMaxAge time.Duration
+	// disabledGroups 是被停用的规则组，组内的命名列表和规则不参与求值，
+	// 按写时复制的方式更新
+	disabledGroups map[string]struct{}
+
+	// changes 是WatchChanges的订阅者，使用自己的锁，可以在持有以上任何锁时发送通知
+	changes changeWatchers
 }
 
 // NewManager 创建一个新的ACL管理器
@@ -66,7 +163,8 @@ func NewManager() *Manager {
 //     false: 只匹配完全相同的域名
 //
 // 此方法会覆盖之前设置的任何域名访问控制列表。
-// 域名会被自动标准化（移除"www."前缀、协议、端口等）。
+// 修改被SetMutationInterceptor设置的拦截函数拒绝时不做任何修改，需要得到拒绝的错误时使用TrySetDomainACL。
+// 域名会被自动标准化（移除"www."前缀、协议、端口等），格式无效的域名被忽略。
 //
 // 示例:
 //
@@ -76,9 +174,77 @@ func NewManager() *Manager {
 //	// 设置黑名单，阻止特定域名（不含子域名）
 //	manager.SetDomainACL([]string{"ads.example.com", "malware.com"}, types.Blacklist, false)
 func (m *Manager) SetDomainACL(domains []string, listType types.ListType, includeSubdomains bool) {
-	m.mu.Lock()
-	defer m.mu.Unlock()
-	m.domainACL = domain.NewDomainACL(domains, listType, includeSubdomains)
+	_ = m.TrySetDomainACL(domains, listType, includeSubdomains)
+}
+
+// TrySetDomainACL 与SetDomainACL相同，但返回拦截函数拒绝修改的错误
+//
+// 参数:
+//   - domains、listType、includeSubdomains: 与SetDomainACL相同
+//
+// 返回:
+//   - error: 修改被SetMutationInterceptor设置的拦截函数拒绝时返回*RejectedChangeError，原有列表保持不变
+//
+// 示例:
+//
+//	if err := manager.TrySetDomainACL([]string{"example.com"}, types.Whitelist, true); err != nil {
+//	    log.Printf("修改被拒绝: %v", err)
+//	}
+func (m *Manager) TrySetDomainACL(domains []string, listType types.ListType, includeSubdomains bool) error {
+	if err := m.intercept(Change{Op: ChangeSet, Kind: "domain", Component: "domain_acl", ListType: listType, Entries: domains}); err != nil {
+		return err
+	}
+	acl := domain.NewDomainACL(domains, listType, includeSubdomains)
+	now := m.Clock().Now()
+
+	m.domainMu.Lock()
+	defer m.domainMu.Unlock()
+	m.domainACL = acl
+	m.domainModified = now
+	m.notifyChange("domain", "domain_acl", now)
+	return nil
+}
+
+// SetDomainACLFromFile 从文件加载域名访问控制列表
+//
+// 参数:
+//   - filePath: 包含域名列表的文件路径
+//   - listType: 列表类型（黑名单或白名单）
+//   - includeSubdomains: 是否包含子域名
+//
+// 返回:
+//   - error: 与domain.NewDomainACLFromFile相同的错误，出错时原有列表保持不变
+//
+// 文件中"!"开头的行是例外，见domain.NewDomainACLFromFile。
+//
+// 示例:
+//
+//	// blocked.txt:
+//	//   *.example.com
+//	//   !status.example.com
+//	err := manager.SetDomainACLFromFile("./blocked.txt", types.Blacklist, true)
+func (m *Manager) SetDomainACLFromFile(filePath string, listType types.ListType, includeSubdomains bool) error {
+	acl, err := domain.NewDomainACLFromFile(filePath, listType, includeSubdomains)
+	if err == nil {
+		if err := m.intercept(Change{
+			Op: ChangeSet, Kind: "domain", Component: "domain_acl", ListType: listType,
+			Entries: domainEntries(acl), Source: filePath,
+		}); err != nil {
+			return err
+		}
+	}
+	now := m.Clock().Now()
+
+	m.domainMu.Lock()
+	defer m.domainMu.Unlock()
+	m.domainReload = reloadStatus{time: now, source: filePath, err: err}
+	if err != nil {
+		return err
+	}
+	m.domainACL = acl
+	m.domainModified = now
+	m.notifyChange("domain", "domain_acl", now)
+	return nil
 }
 
 // SetIPACL 设置IP访问控制列表
@@ -112,10 +278,16 @@ func (m *Manager) SetIPACL(ipRanges []string, listType types.ListType) error {
 	if err != nil {
 		return err
 	}
+	if err := m.intercept(Change{Op: ChangeSet, Kind: "ip", Component: "ip_acl", ListType: listType, Entries: ipRanges}); err != nil {
+		return err
+	}
+	now := m.Clock().Now()
 
-	m.mu.Lock()
-	defer m.mu.Unlock()
+	m.ipMu.Lock()
+	defer m.ipMu.Unlock()
 	m.ipACL = acl
+	m.ipModified = now
+	m.notifyChange("ip", "ip_acl", now)
 	return nil
 }
 
@@ -152,13 +324,23 @@ func (m *Manager) SetIPACL(ipRanges []string, listType types.ListType) error {
 //	}
 func (m *Manager) SetIPACLFromFile(filePath string, listType types.ListType) error {
 	acl, err := ip.NewIPACLFromFile(filePath, listType)
+	if err == nil {
+		change := Change{Op: ChangeSet, Kind: "ip", Component: "ip_acl", ListType: listType, Entries: acl.GetIPRanges(), Source: filePath}
+		if err := m.intercept(change); err != nil {
+			return err
+		}
+	}
+	now := m.Clock().Now()
+
+	m.ipMu.Lock()
+	defer m.ipMu.Unlock()
+	m.ipReload = reloadStatus{time: now, source: filePath, err: err}
 	if err != nil {
 		return err
 	}
-
-	m.mu.Lock()
-	defer m.mu.Unlock()
 	m.ipACL = acl
+	m.ipModified = now
+	m.notifyChange("ip", "ip_acl", now)
 	return nil
 }
 
@@ -197,18 +379,100 @@ func (m *Manager) SetIPACLFromFile(filePath string, listType types.ListType) err
 //	    }
 //	}
 func (m *Manager) SaveIPACLToFile(filePath string, overwrite bool) error {
-	m.mu.RLock()
-	defer m.mu.RUnlock()
+	return m.SaveIPACL(filePath, config.WithOverwrite(overwrite))
+}
 
+// SaveIPACL 按选项将当前IP访问控制列表保存到文件
+//
+// 参数:
+//   - filePath: 要保存的文件路径
+//   - opts: 保存选项，见config.SaveOptions
+//
+// 返回:
+//   - error: 未设置IP ACL时返回types.ErrNoACL，其他错误见config.SaveLines
+//
+// 列表内容和当时的修订号（见Revision）在同一次加锁中复制，保存的文件总是对应某个确定的修订，
+// 即使其他goroutine正在修改列表；修订号写入文件头的"# Revision:"，可用config.WithRevision替换。
+// 文件头的生成时间来自SetClock设置的时间源。
+// 写文件时不持有锁，慢速的磁盘不会阻塞检查和修改。
+//
+// 示例:
+//
+//	// 加密保存，并原子地替换旧文件
+//	err := manager.SaveIPACL("./targets.enc",
+//	    config.WithOverwrite(true),
+//	    config.WithEncryption(config.StaticKey(key)),
+//	    config.WithAtomic(),
+//	)
+func (m *Manager) SaveIPACL(filePath string, opts ...config.SaveOption) error {
+	clock := m.Clock()
+	m.ipMu.RLock()
 	if m.ipACL == nil {
+		m.ipMu.RUnlock()
 		return types.ErrNoACL
 	}
+	ranges, listType := m.ipACL.GetIPRanges(), m.ipACL.GetListType()
+	// IP ACL的修改在持有ipMu写锁时增加修订号，此时读到的修订号与复制的内容一致
+	revision := m.Revision()
+	m.ipMu.RUnlock()
+
+	return ip.SaveRanges(filePath, ranges, listType, append([]config.SaveOption{config.WithRevision(revision), config.WithClock(clock)}, opts...)...)
+}
 
-	return m.ipACL.SaveToFile(filePath, overwrite)
+// SaveDomainACLToFile 将当前域名访问控制列表保存到文件
+//
+// 参数:
+//   - filePath: 要保存的文件路径
+//   - form: 域名的书写形式（domain.FormAsIs、domain.FormASCII或domain.FormUnicode）
+//   - overwrite: 是否覆盖已存在的文件
+//
+// 返回:
+//   - error: 可能的错误:
+//   - types.ErrNoACL: 如果未设置域名ACL
+//   - config.ErrFileExists: 如果文件已存在且overwrite=false
+//   - domain.ErrInvalidDomain: 如果某个域名无法转换为指定的书写形式
+//
+// 生成的文件可以通过SetDomainACLFromFile重新加载。
+//
+// 示例:
+//
+//	// 导出Punycode形式，供只接受ASCII域名的DNS服务器使用
+//	err := manager.SaveDomainACLToFile("./domains.txt", domain.FormASCII, true)
+func (m *Manager) SaveDomainACLToFile(filePath string, form domain.Form, overwrite bool) error {
+	return m.SaveDomainACL(filePath, form, config.WithOverwrite(overwrite))
+}
+
+// SaveDomainACL 按选项将当前域名访问控制列表保存到文件
+//
+// 参数:
+//   - filePath: 要保存的文件路径
+//   - form: 域名的书写形式（domain.FormAsIs、domain.FormASCII或domain.FormUnicode）
+//   - opts: 保存选项，见config.SaveOptions
+//
+// 返回:
+//   - error: 未设置域名ACL时返回types.ErrNoACL，其他错误见domain.DomainACL.Save
+//
+// 与SaveIPACL一样，域名、例外和修订号在同一次加锁中复制，写文件时不持有锁。
+//
+// 示例:
+//
+//	err := manager.SaveDomainACL("./domains.txt", domain.FormASCII, config.WithBackup(), config.WithAtomic())
+func (m *Manager) SaveDomainACL(filePath string, form domain.Form, opts ...config.SaveOption) error {
+	clock := m.Clock()
+	m.domainMu.RLock()
+	if m.domainACL == nil {
+		m.domainMu.RUnlock()
+		return types.ErrNoACL
+	}
+	domains, exceptions := m.domainACL.GetDomains(), m.domainACL.GetExceptions()
+	listType := m.domainACL.GetListType()
+	revision := m.Revision()
+	m.domainMu.RUnlock()
+
+	return domain.SaveDomains(filePath, domains, exceptions, listType, form, append([]config.SaveOption{config.WithRevision(revision), config.WithClock(clock)}, opts...)...)
 }
 
 // SaveIPACLToFileWithOverwrite 兼容旧版API，默认覆盖已存在的文件
-// 已废弃：请改用 SaveIPACLToFile
 //
 // 参数:
 //   - filePath: 要保存的文件路径
@@ -218,10 +482,7 @@ func (m *Manager) SaveIPACLToFile(filePath string, overwrite bool) error {
 //
 // 此方法等同于调用 SaveIPACLToFile(filePath, true)
 //
-// 示例:
-//
-//	// 保存并覆盖现有文件
-//	err := manager.SaveIPACLToFileWithOverwrite("./my_acl.txt")
+// Deprecated: 请改用 SaveIPACLToFile(filePath, true)。
 func (m *Manager) SaveIPACLToFileWithOverwrite(filePath string) error {
 	return m.SaveIPACLToFile(filePath, true)
 }
@@ -253,14 +514,29 @@ func (m *Manager) SaveIPACLToFileWithOverwrite(filePath string) error {
 //	    }
 //	}
 func (m *Manager) AddIPFromFile(filePath string) error {
-	m.mu.Lock()
-	defer m.mu.Unlock()
+	// 先在锁外读取文件，拦截函数需要知道加入的条目
+	ranges, err := config.ReadIPACL(filePath)
+	if err == nil {
+		if err := m.intercept(Change{Op: ChangeAdd, Kind: "ip", Component: "ip_acl", Entries: ranges, Source: filePath}); err != nil {
+			return err
+		}
+	}
+	now := m.Clock().Now()
+
+	m.ipMu.Lock()
+	defer m.ipMu.Unlock()
 
 	if m.ipACL == nil {
 		return types.ErrNoACL
 	}
 
-	return m.ipACL.AddFromFile(filePath)
+	if err == nil {
+		err = m.ipACL.Add(ranges...)
+	}
+	m.ipReload = reloadStatus{time: now, source: filePath, err: err}
+	m.ipModified = now
+	m.notifyChange("ip", "ip_acl", now)
+	return err
 }
 
 // SetIPACLWithDefaults 设置IP访问控制列表，并包含预定义的安全IP集合
@@ -308,10 +584,17 @@ func (m *Manager) SetIPACLWithDefaults(ipRanges []string, listType types.ListTyp
 	if err != nil {
 		return err
 	}
+	// 预定义集合以展开后的范围交给拦截函数
+	if err := m.intercept(Change{Op: ChangeSet, Kind: "ip", Component: "ip_acl", ListType: listType, Entries: acl.GetIPRanges()}); err != nil {
+		return err
+	}
+	now := m.Clock().Now()
 
-	m.mu.Lock()
-	defer m.mu.Unlock()
+	m.ipMu.Lock()
+	defer m.ipMu.Unlock()
 	m.ipACL = acl
+	m.ipModified = now
+	m.notifyChange("ip", "ip_acl", now)
 	return nil
 }
 
@@ -326,6 +609,7 @@ func (m *Manager) SetIPACLWithDefaults(ipRanges []string, listType types.ListTyp
 //   - types.ErrNoACL: 如果未设置IP ACL
 //   - ip.ErrInvalidIP: 如果提供了无效IP
 //   - ip.ErrInvalidCIDR: 如果提供了无效CIDR
+//   - ErrQuotaExceeded: 如果添加后超出"ip_acl"的配额（见SetListQuota），此时不添加任何规则
 //
 // 此方法可用于在不替换整个ACL的情况下添加单个或多个IP范围。
 //
@@ -344,13 +628,24 @@ func (m *Manager) SetIPACLWithDefaults(ipRanges []string, listType types.ListTyp
 //	    }
 //	}
 func (m *Manager) AddIP(ipRanges ...string) error {
-	m.mu.Lock()
-	defer m.mu.Unlock()
+	if err := m.intercept(Change{Op: ChangeAdd, Kind: "ip", Component: "ip_acl", Entries: ipRanges}); err != nil {
+		return err
+	}
+	limit := m.quotaLimit("ip_acl")
+	now := m.Clock().Now()
+
+	m.ipMu.Lock()
+	defer m.ipMu.Unlock()
 
 	if m.ipACL == nil {
 		return types.ErrNoACL
 	}
+	if err := quotaError("ip_acl", limit, len(m.ipACL.GetIPRanges())+newIPEntries(m.ipACL, ipRanges)); err != nil {
+		return err
+	}
 
+	m.ipModified = now
+	m.notifyChange("ip", "ip_acl", now)
 	return m.ipACL.Add(ipRanges...)
 }
 
@@ -382,13 +677,20 @@ func (m *Manager) AddIP(ipRanges ...string) error {
 //	    }
 //	}
 func (m *Manager) RemoveIP(ipRanges ...string) error {
-	m.mu.Lock()
-	defer m.mu.Unlock()
+	if err := m.intercept(Change{Op: ChangeRemove, Kind: "ip", Component: "ip_acl", Entries: ipRanges}); err != nil {
+		return err
+	}
+	now := m.Clock().Now()
+
+	m.ipMu.Lock()
+	defer m.ipMu.Unlock()
 
 	if m.ipACL == nil {
 		return types.ErrNoACL
 	}
 
+	m.ipModified = now
+	m.notifyChange("ip", "ip_acl", now)
 	return m.ipACL.Remove(ipRanges...)
 }
 
@@ -422,13 +724,23 @@ func (m *Manager) RemoveIP(ipRanges ...string) error {
 //	    log.Printf("添加预定义集合失败: %v", err)
 //	}
 func (m *Manager) AddPredefinedIPSet(setName ip.PredefinedSet, allowSet bool) error {
-	m.mu.Lock()
-	defer m.mu.Unlock()
+	if ranges := ip.GetPredefinedIPRanges(setName); ranges != nil {
+		entries := append([]string(nil), ranges...)
+		if err := m.intercept(Change{Op: ChangeAdd, Kind: "ip", Component: "ip_acl", Entries: entries}); err != nil {
+			return err
+		}
+	}
+	now := m.Clock().Now()
+
+	m.ipMu.Lock()
+	defer m.ipMu.Unlock()
 
 	if m.ipACL == nil {
 		return types.ErrNoACL
 	}
 
+	m.ipModified = now
+	m.notifyChange("ip", "ip_acl", now)
 	return m.ipACL.AddPredefinedSet(setName, allowSet)
 }
 
@@ -486,13 +798,73 @@ func (m *Manager) AddAllSpecialNetworks() error {
 //	    log.Println("拒绝访问此域名")
 //	}
 func (m *Manager) CheckDomain(domain string) (types.Permission, error) {
-	m.mu.RLock()
-	defer m.mu.RUnlock()
+	return m.CheckDomainContext(context.Background(), domain)
+}
+
+// checkDomain 执行域名检查的核心逻辑，不更新统计
+func (m *Manager) checkDomain(domain string) (types.Permission, error) {
+	result, err := m.resolveDomain(context.Background(), domain, false)
+	return result.Decision, err
+}
+
+// resolveDomain 执行域名检查并记录做出决定的组件，不更新统计
+//
+// detailed为true时查询命中的规则并写入RuleID，开销高于只求结果的检查。
+// ctx结束时中断故障注入的延迟并返回ctx.Err()。
+func (m *Manager) resolveDomain(ctx context.Context, domain string, detailed bool) (types.CheckResult, error) {
+	result := types.CheckResult{Target: domain, Kind: "domain", Decision: types.Denied}
+	if err := m.injectChaos(ctx); err != nil {
+		return result, err
+	}
+	if m.denyMixedScript(domain) {
+		result.Source, result.Reason = "mixed_script", types.ReasonMixedScript
+		return result, nil
+	}
+
+	disabled := m.disabledGroupSet()
+	now := m.expiryTime()
+	m.domainMu.RLock()
+	defer m.domainMu.RUnlock()
+
+	if perm, list, decided, err := m.checkNamedDomainLists(domain, disabled, now); decided {
+		result.Decision = perm
+		if list != nil {
+			result.Source = "domain_list:" + list.name
+			if detailed {
+				r, _ := list.domain.CheckDetailed(domain)
+				result.RuleID = r.RuleID
+			}
+			if err == nil && perm == types.Denied {
+				result.Reason = domainDenyReason(list.domain, domain)
+			}
+		}
+		return result, err
+	}
 
 	if m.domainACL == nil {
-		return types.Denied, types.ErrNoACL
+		if perm, ok := namedListsDefault(m.domainLists, disabled); ok {
+			result.Decision, result.Source = perm, "default"
+			if perm == types.Denied {
+				result.Reason = types.ReasonDefaultDeny
+			}
+			return result, nil
+		}
+		return result, types.ErrNoACL
+	}
+
+	var err error
+	result.Source = "domain_acl"
+	if detailed {
+		var r types.CheckResult
+		r, err = m.domainACL.CheckDetailed(domain)
+		result.Decision, result.RuleID = r.Decision, r.RuleID
+	} else {
+		result.Decision, err = m.domainACL.Check(domain)
+	}
+	if err == nil && result.Decision == types.Denied {
+		result.Reason = domainDenyReason(m.domainACL, domain)
 	}
-	return m.domainACL.Check(domain)
+	return result, err
 }
 
 // CheckIP 检查IP是否允许访问
@@ -529,13 +901,76 @@ func (m *Manager) CheckDomain(domain string) (types.Permission, error) {
 //	    log.Println("拒绝访问此IP")
 //	}
 func (m *Manager) CheckIP(ip string) (types.Permission, error) {
-	m.mu.RLock()
-	defer m.mu.RUnlock()
+	return m.CheckIPContext(context.Background(), ip)
+}
+
+// checkIP 执行IP检查的核心逻辑，不更新统计
+func (m *Manager) checkIP(ip string) (types.Permission, error) {
+	result, err := m.resolveIP(context.Background(), ip, false)
+	return result.Decision, err
+}
+
+// resolveIP 执行IP检查并记录做出决定的组件，不更新统计
+//
+// detailed为true时查询命中的规则并写入RuleID，开销高于只求结果的检查。
+// ctx结束时中断故障注入的延迟并返回ctx.Err()。
+func (m *Manager) resolveIP(ctx context.Context, ip string, detailed bool) (types.CheckResult, error) {
+	result := types.CheckResult{Target: ip, Kind: "ip", Decision: types.Denied}
+	if err := m.injectChaos(ctx); err != nil {
+		return result, err
+	}
+
+	disabled := m.disabledGroupSet()
+	now := m.expiryTime()
+	m.ipMu.RLock()
+	defer m.ipMu.RUnlock()
+
+	if m.isDeniedFamily(ip) {
+		result.Source, result.Reason = "family", types.ReasonDeniedFamily
+		return result, nil
+	}
+
+	if perm, list, decided, err := m.checkNamedIPLists(ip, disabled, now); decided {
+		result.Decision = perm
+		if list != nil {
+			result.Source = "ip_list:" + list.name
+			if detailed {
+				result.Matches, _ = list.ip.MatchAll(ip)
+				if len(result.Matches) > 0 {
+					result.RuleID = result.Matches[0]
+				}
+			}
+			if err == nil && perm == types.Denied {
+				result.Reason = types.DenyReason("ip", list.ip.GetListType())
+			}
+		}
+		return result, err
+	}
 
 	if m.ipACL == nil {
-		return types.Denied, types.ErrNoACL
+		if perm, ok := namedListsDefault(m.ipLists, disabled); ok {
+			result.Decision, result.Source = perm, "default"
+			if perm == types.Denied {
+				result.Reason = types.ReasonDefaultDeny
+			}
+			return result, nil
+		}
+		return result, types.ErrNoACL
+	}
+
+	var err error
+	result.Source = "ip_acl"
+	if detailed {
+		var r types.CheckResult
+		r, err = m.ipACL.CheckDetailed(ip)
+		result.Decision, result.RuleID, result.Matches = r.Decision, r.RuleID, r.Matches
+	} else {
+		result.Decision, err = m.ipACL.Check(ip)
+	}
+	if err == nil && result.Decision == types.Denied {
+		result.Reason = types.DenyReason("ip", m.ipACL.GetListType())
 	}
-	return m.ipACL.Check(ip)
+	return result, err
 }
 
 // GetIPRanges 获取当前IP访问控制列表中的所有IP范围
@@ -561,8 +996,8 @@ func (m *Manager) CheckIP(ip string) (types.Permission, error) {
 //	    }
 //	}
 func (m *Manager) GetIPRanges() []string {
-	m.mu.RLock()
-	defer m.mu.RUnlock()
+	m.ipMu.RLock()
+	defer m.ipMu.RUnlock()
 
 	if m.ipACL == nil {
 		return nil
@@ -595,8 +1030,8 @@ func (m *Manager) GetIPRanges() []string {
 //	    log.Println("当前IP ACL为白名单模式")
 //	}
 func (m *Manager) GetIPACLType() (types.ListType, error) {
-	m.mu.RLock()
-	defer m.mu.RUnlock()
+	m.ipMu.RLock()
+	defer m.ipMu.RUnlock()
 
 	if m.ipACL == nil {
 		return 0, types.ErrNoACL
@@ -613,6 +1048,7 @@ func (m *Manager) GetIPACLType() (types.ListType, error) {
 // 返回:
 //   - error: 可能的错误:
 //   - types.ErrNoACL: 如果未设置域名ACL
+//   - ErrQuotaExceeded: 如果添加后超出"domain_acl"的配额（见SetListQuota），此时不添加任何域名
 //
 // 域名会自动标准化（移除协议、www前缀、端口号等）。
 // 空域名或格式无效的域名会被忽略。
@@ -632,14 +1068,25 @@ func (m *Manager) GetIPACLType() (types.ListType, error) {
 //	    }
 //	}
 func (m *Manager) AddDomain(domains ...string) error {
-	m.mu.Lock()
-	defer m.mu.Unlock()
+	if err := m.intercept(Change{Op: ChangeAdd, Kind: "domain", Component: "domain_acl", Entries: domains}); err != nil {
+		return err
+	}
+	limit := m.quotaLimit("domain_acl")
+	now := m.Clock().Now()
+
+	m.domainMu.Lock()
+	defer m.domainMu.Unlock()
 
 	if m.domainACL == nil {
 		return types.ErrNoACL
 	}
+	if err := quotaError("domain_acl", limit, len(m.domainACL.GetDomains())+newDomainEntries(m.domainACL, domains)); err != nil {
+		return err
+	}
 
 	m.domainACL.Add(domains...)
+	m.domainModified = now
+	m.notifyChange("domain", "domain_acl", now)
 	return nil
 }
 
@@ -675,13 +1122,20 @@ func (m *Manager) AddDomain(domains ...string) error {
 //	    }
 //	}
 func (m *Manager) RemoveDomain(domains ...string) error {
-	m.mu.Lock()
-	defer m.mu.Unlock()
+	if err := m.intercept(Change{Op: ChangeRemove, Kind: "domain", Component: "domain_acl", Entries: domains}); err != nil {
+		return err
+	}
+	now := m.Clock().Now()
+
+	m.domainMu.Lock()
+	defer m.domainMu.Unlock()
 
 	if m.domainACL == nil {
 		return types.ErrNoACL
 	}
 
+	m.domainModified = now
+	m.notifyChange("domain", "domain_acl", now)
 	return m.domainACL.Remove(domains...)
 }
 
@@ -706,8 +1160,8 @@ func (m *Manager) RemoveDomain(domains ...string) error {
 //	    }
 //	}
 func (m *Manager) GetDomains() []string {
-	m.mu.RLock()
-	defer m.mu.RUnlock()
+	m.domainMu.RLock()
+	defer m.domainMu.RUnlock()
 
 	if m.domainACL == nil {
 		return nil
@@ -740,8 +1194,8 @@ func (m *Manager) GetDomains() []string {
 //	    log.Println("当前域名ACL为白名单模式")
 //	}
 func (m *Manager) GetDomainACLType() (types.ListType, error) {
-	m.mu.RLock()
-	defer m.mu.RUnlock()
+	m.domainMu.RLock()
+	defer m.domainMu.RUnlock()
 
 	if m.domainACL == nil {
 		return 0, types.ErrNoACL
@@ -753,7 +1207,8 @@ func (m *Manager) GetDomainACLType() (types.ListType, error) {
 //
 // 此方法会清除所有域名和IP访问控制设置，使管理器恢复到初始状态。
 // 调用此方法后，CheckDomain和CheckIP等方法将返回ErrNoACL错误，
-// 直到重新设置相应的ACL。
+// 直到重新设置相应的ACL。修改被SetMutationInterceptor设置的拦截函数拒绝时所有设置保持不变，
+// 需要得到拒绝的错误时使用TryReset。
 //
 // 示例:
 //
@@ -766,9 +1221,37 @@ func (m *Manager) GetDomainACLType() (types.ListType, error) {
 //	    log.Println("域名ACL已成功重置")
 //	}
 func (m *Manager) Reset() {
+	_ = m.TryReset()
+}
+
+// TryReset 与Reset相同，但返回拦截函数拒绝修改的错误
+//
+// 返回:
+//   - error: 修改被SetMutationInterceptor设置的拦截函数拒绝时返回*RejectedChangeError，所有设置保持不变
+func (m *Manager) TryReset() error {
+	if err := m.intercept(Change{Op: ChangeReset, Kind: "*", Component: "*"}); err != nil {
+		return err
+	}
 	m.mu.Lock()
 	defer m.mu.Unlock()
+	m.ipMu.Lock()
+	defer m.ipMu.Unlock()
+	m.domainMu.Lock()
+	defer m.domainMu.Unlock()
 
 	m.domainACL = nil
 	m.ipACL = nil
+	m.rules = nil
+	m.ipLists = nil
+	m.ipPorts = nil
+	m.domainLists = nil
+	m.disabledGroups = nil
+	m.ipReload = reloadStatus{}
+	m.domainReload = reloadStatus{}
+	m.ipModified = time.Time{}
+	m.domainModified = time.Time{}
+	now := m.now()
+	m.notifyChange("ip", "*", now)
+	m.notifyChange("domain", "*", now)
+	return nil
 }
@@ -1,12 +1,17 @@
 package acl
 
 import (
+	"errors"
+	"fmt"
 	"os"
 	"path/filepath"
 	"reflect"
 	"strings"
 	"testing"
+	"time"
 
+	"github.com/cyberspacesec/go-acl/pkg/config"
+	"github.com/cyberspacesec/go-acl/pkg/domain"
 	"github.com/cyberspacesec/go-acl/pkg/ip"
 	"github.com/cyberspacesec/go-acl/pkg/types"
 )
@@ -124,6 +129,40 @@ func TestSetIPACLFromFile(t *testing.T) {
 	}
 }
 
+// TestSetDomainACLFromFile 测试从文件加载域名ACL（包含例外）
+func TestSetDomainACLFromFile(t *testing.T) {
+	tempDir := setupTestDir(t)
+	defer cleanupTestDir(t, tempDir)
+
+	testFile := filepath.Join(tempDir, "domains.txt")
+	createTestFile(t, testFile, "*.example.com\n!status.example.com\n")
+
+	manager := NewManager()
+	if err := manager.SetDomainACLFromFile(testFile, types.Blacklist, true); err != nil {
+		t.Fatalf("SetDomainACLFromFile() 返回错误: %v", err)
+	}
+
+	if got, _ := manager.CheckDomain("api.example.com"); got != types.Denied {
+		t.Errorf("CheckDomain(api.example.com) = %v, 期望 Denied", got)
+	}
+	if got, _ := manager.CheckDomain("status.example.com"); got != types.Allowed {
+		t.Errorf("CheckDomain(status.example.com) = %v, 期望 Allowed", got)
+	}
+
+	e := manager.Explain("status.example.com")
+	if e.MatchedRule != "!status.example.com" || !strings.Contains(e.String(), "命中例外") {
+		t.Errorf("Explain() 应说明命中的例外:\n%s", e)
+	}
+
+	// 加载失败时保留原有列表
+	if err := manager.SetDomainACLFromFile("/nonexistent/file.txt", types.Whitelist, true); err == nil {
+		t.Error("SetDomainACLFromFile() 对于不存在的文件应返回错误")
+	}
+	if listType, _ := manager.GetDomainACLType(); listType != types.Blacklist {
+		t.Error("加载失败后不应替换原有列表")
+	}
+}
+
 // TestSaveIPACLToFile 测试保存IP ACL到文件
 func TestSaveIPACLToFile(t *testing.T) {
 	tempDir := setupTestDir(t)
@@ -164,6 +203,159 @@ func TestSaveIPACLToFile(t *testing.T) {
 	}
 }
 
+// TestSaveDomainACLToFile 测试按指定书写形式保存域名ACL
+func TestSaveDomainACLToFile(t *testing.T) {
+	tempDir := setupTestDir(t)
+	defer cleanupTestDir(t, tempDir)
+
+	testFile := filepath.Join(tempDir, "domains.txt")
+	manager := NewManager()
+	if err := manager.SaveDomainACLToFile(testFile, domain.FormASCII, true); !errors.Is(err, types.ErrNoACL) {
+		t.Errorf("未设置域名ACL时 SaveDomainACLToFile() 错误 = %v, 期望 ErrNoACL", err)
+	}
+
+	manager.SetDomainACL([]string{"例子.com"}, types.Blacklist, true)
+	if err := manager.SaveDomainACLToFile(testFile, domain.FormASCII, true); err != nil {
+		t.Fatalf("SaveDomainACLToFile() 返回错误: %v", err)
+	}
+	content, err := os.ReadFile(testFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(content), "\nxn--fsqu00a.com\n") {
+		t.Errorf("文件内容应包含Punycode形式的域名:\n%s", content)
+	}
+
+	// 重新加载后按Punycode形式匹配
+	restored := NewManager()
+	if err := restored.SetDomainACLFromFile(testFile, types.Blacklist, true); err != nil {
+		t.Fatalf("SetDomainACLFromFile() 返回错误: %v", err)
+	}
+	if got, _ := restored.CheckDomain("www.xn--fsqu00a.com"); got != types.Denied {
+		t.Errorf("CheckDomain(www.xn--fsqu00a.com) = %v, 期望 Denied", got)
+	}
+}
+
+// TestSaveWithOptions 测试按选项保存IP和域名ACL
+func TestSaveWithOptions(t *testing.T) {
+	tempDir := setupTestDir(t)
+	defer cleanupTestDir(t, tempDir)
+
+	manager := NewManager()
+	ipFile := filepath.Join(tempDir, "ips.txt")
+	if err := manager.SaveIPACL(ipFile); !errors.Is(err, types.ErrNoACL) {
+		t.Errorf("未设置IP ACL时 SaveIPACL() 错误 = %v, 期望 ErrNoACL", err)
+	}
+
+	manager.SetIPACL([]string{"192.0.2.1"}, types.Blacklist)
+	if err := manager.SaveIPACL(ipFile, config.WithHeader("封禁列表")); err != nil {
+		t.Fatalf("SaveIPACL() 返回错误: %v", err)
+	}
+	manager.SetIPACL([]string{"192.0.2.2"}, types.Blacklist)
+	if err := manager.SaveIPACL(ipFile); !errors.Is(err, config.ErrFileExists) {
+		t.Errorf("未允许覆盖时 SaveIPACL() 错误 = %v, 期望 ErrFileExists", err)
+	}
+	if err := manager.SaveIPACL(ipFile, config.WithBackup(), config.WithAtomic()); err != nil {
+		t.Fatalf("SaveIPACL() 返回错误: %v", err)
+	}
+	content, _ := os.ReadFile(ipFile)
+	if !strings.HasPrefix(string(content), "# IP Blacklist") || !strings.Contains(string(content), "192.0.2.2") {
+		t.Errorf("文件内容应使用默认标题和新的列表:\n%s", content)
+	}
+	backup, _ := os.ReadFile(ipFile + config.BackupSuffix)
+	if !strings.HasPrefix(string(backup), "# 封禁列表\n") {
+		t.Errorf("备份应为上一版本:\n%s", backup)
+	}
+
+	domainFile := filepath.Join(tempDir, "domains.txt")
+	manager.SetDomainACL([]string{"例子.com"}, types.Whitelist, true)
+	if err := manager.SaveDomainACL(domainFile, domain.FormASCII, config.WithAtomic()); err != nil {
+		t.Fatalf("SaveDomainACL() 返回错误: %v", err)
+	}
+	if lines, err := config.ReadLines(domainFile); err != nil || len(lines) != 1 || lines[0] != "xn--fsqu00a.com" {
+		t.Errorf("ReadLines() = %v, %v; 期望Punycode形式的域名", lines, err)
+	}
+
+	// 文件头的生成时间来自管理器的时间源
+	generated := time.Date(2024, 1, 2, 3, 4, 5, 0, time.Local)
+	manager.SetClock(types.NewManualClock(generated))
+	if err := manager.SaveIPACL(ipFile, config.WithOverwrite(true)); err != nil {
+		t.Fatalf("SaveIPACL() 返回错误: %v", err)
+	}
+	if _, meta, err := config.ReadIPACLWithMetadata(ipFile); err != nil || !meta.Generated.Equal(generated) {
+		t.Errorf("Generated = %v, %v, 期望 %v", meta.Generated, err, generated)
+	}
+}
+
+// TestSaveDuringMutation 测试并发修改时保存的文件总是对应文件头中记录的修订
+func TestSaveDuringMutation(t *testing.T) {
+	tempDir := setupTestDir(t)
+	defer cleanupTestDir(t, tempDir)
+
+	manager := NewManager()
+	manager.SetIPACL([]string{"192.0.2.1"}, types.Blacklist)
+	manager.SetDomainACL([]string{"example.com"}, types.Blacklist, true)
+	base := manager.Revision()
+
+	// 每次修改使修订号加一、条目加一，因此条目数量可以由修订号推算
+	const adds = 200
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for i := 0; i < adds; i++ {
+			if i%2 == 0 {
+				manager.AddIP(fmt.Sprintf("198.51.%d.%d", i/256, i%256))
+			} else {
+				manager.AddDomain(fmt.Sprintf("host%d.example.net", i))
+			}
+		}
+	}()
+
+	ipFile := filepath.Join(tempDir, "ips.txt")
+	domainFile := filepath.Join(tempDir, "domains.txt")
+	for saving := true; saving; {
+		select {
+		case <-done:
+			saving = false
+		default:
+		}
+		if err := manager.SaveIPACL(ipFile, config.WithOverwrite(true), config.WithAtomic()); err != nil {
+			t.Fatalf("SaveIPACL() 返回错误: %v", err)
+		}
+		if err := manager.SaveDomainACL(domainFile, domain.FormAsIs, config.WithOverwrite(true), config.WithAtomic()); err != nil {
+			t.Fatalf("SaveDomainACL() 返回错误: %v", err)
+		}
+
+		ips, ipMeta, err := config.ReadIPACLWithMetadata(ipFile)
+		if err != nil {
+			t.Fatal(err)
+		}
+		domains, domainMeta, err := config.ReadIPACLWithMetadata(domainFile)
+		if err != nil {
+			t.Fatal(err)
+		}
+		// 修订号之前的修改中，IP和域名交替进行
+		changes := func(meta config.Metadata, even bool) int {
+			n := int(meta.Revision - base)
+			if even {
+				return (n + 1) / 2
+			}
+			return n / 2
+		}
+		if want := 1 + changes(ipMeta, true); len(ips) != want {
+			t.Fatalf("修订 %d 的IP文件有 %d 个条目, 期望 %d", ipMeta.Revision, len(ips), want)
+		}
+		if want := 1 + changes(domainMeta, false); len(domains) != want {
+			t.Fatalf("修订 %d 的域名文件有 %d 个条目, 期望 %d", domainMeta.Revision, len(domains), want)
+		}
+	}
+
+	_, meta, _ := config.ReadIPACLWithMetadata(ipFile)
+	if meta.Revision != manager.Revision() {
+		t.Errorf("最后保存的修订 = %d, 期望 %d", meta.Revision, manager.Revision())
+	}
+}
+
 // TestSaveIPACLToFileWithOverwrite 测试带覆盖的保存IP ACL
 func TestSaveIPACLToFileWithOverwrite(t *testing.T) {
 	tempDir := setupTestDir(t)
@@ -644,3 +836,60 @@ func TestReset(t *testing.T) {
 		t.Error("GetIPRanges() 在重置后应返回空列表")
 	}
 }
+
+// TestIndependentLocks 测试IP和域名ACL使用独立的锁，持有一方的写锁不阻塞另一方的检查
+func TestIndependentLocks(t *testing.T) {
+	manager := NewManager()
+	manager.SetDomainACL([]string{"blocked.com"}, types.Blacklist, true)
+	if err := manager.SetIPACL([]string{"10.0.0.0/8"}, types.Blacklist); err != nil {
+		t.Fatalf("SetIPACL() 返回错误: %v", err)
+	}
+
+	tests := []struct {
+		name  string
+		lock  func() func()
+		check func() (types.Permission, error)
+		want  types.Permission
+	}{
+		{
+			name: "IP写锁不阻塞域名检查",
+			lock: func() func() {
+				manager.ipMu.Lock()
+				return manager.ipMu.Unlock
+			},
+			check: func() (types.Permission, error) { return manager.CheckDomain("blocked.com") },
+			want:  types.Denied,
+		},
+		{
+			name: "域名写锁不阻塞IP检查",
+			lock: func() func() {
+				manager.domainMu.Lock()
+				return manager.domainMu.Unlock
+			},
+			check: func() (types.Permission, error) { return manager.CheckIP("10.1.2.3") },
+			want:  types.Denied,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			unlock := tt.lock()
+			defer unlock()
+
+			done := make(chan types.Permission, 1)
+			go func() {
+				perm, _ := tt.check()
+				done <- perm
+			}()
+
+			select {
+			case perm := <-done:
+				if perm != tt.want {
+					t.Errorf("检查结果 = %v, 期望 %v", perm, tt.want)
+				}
+			case <-time.After(time.Second):
+				t.Fatal("检查被另一个ACL的写锁阻塞")
+			}
+		})
+	}
+}
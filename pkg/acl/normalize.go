@@ -0,0 +1,110 @@
+package acl
+
+import (
+	"context"
+	"net"
+	"strings"
+
+	"github.com/cyberspacesec/go-acl/pkg/domain"
+	"github.com/cyberspacesec/go-acl/pkg/ip"
+)
+
+// hostInputKey 是CheckHost把原始输入传给审计事件使用的上下文键
+type hostInputKey struct{}
+
+// SetNormalizationTrace 设置审计事件是否报告输入的标准化过程
+//
+// 参数:
+//   - enabled: true表示报告，默认为false
+//
+// 检查前输入会被标准化：域名去除协议、端口、路径和"www."并转换为小写，IP转换为标准形式，
+// CheckHost还会把"2130706433"这样的混淆写法转换为"127.0.0.1"。启用后，输入被改变时
+// 审计事件（见SetAuditHook）的Input、Normalized和Transforms字段记录原始输入、实际检查的值和依次执行的变换，
+// 便于排查某个输入为什么匹配（或没有匹配）某条规则。没有被改变的输入不填写这些字段。
+//
+// 每次检查都要重新执行一次标准化，只应在调试时启用。单个输入的完整过程可以用Explain查看。
+//
+// 示例:
+//
+//	manager.SetNormalizationTrace(true)
+//	manager.SetAuditHook(func(e acl.AuditEvent) {
+//	    if e.Normalized != "" {
+//	        log.Printf("%s -> %s %v", e.Input, e.Normalized, e.Transforms)
+//	        // HTTPS://WWW.Example.com:443/x -> example.com [lowercase strip_scheme strip_path strip_port strip_www]
+//	    }
+//	})
+func (m *Manager) SetNormalizationTrace(enabled bool) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.traceNormalization = enabled
+}
+
+// normalizationTrace 返回是否报告标准化过程
+func (m *Manager) normalizationTrace() bool {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return m.traceNormalization
+}
+
+// withHostInput 在启用标准化报告时把CheckHost的原始输入放入上下文
+func (m *Manager) withHostInput(ctx context.Context, host string) context.Context {
+	if !m.normalizationTrace() {
+		return ctx
+	}
+	return context.WithValue(ctx, hostInputKey{}, host)
+}
+
+// traceNormalization 重现检查对输入的标准化，返回原始输入、标准化结果和变换步骤
+//
+// 输入没有被改变或无法标准化时返回空值。
+func traceNormalization(ctx context.Context, kind, target string) (string, string, []string) {
+	var input string
+	var fromHost bool
+	if ctx != nil {
+		input, fromHost = ctx.Value(hostInputKey{}).(string)
+	}
+	if !fromHost {
+		input = target
+	}
+
+	var normalized string
+	var transforms []string
+	switch {
+	case kind == "domain" || fromHost:
+		var steps []domain.NormalizationStep
+		normalized, steps = domain.NormalizeSteps(input)
+		for _, step := range steps {
+			transforms = append(transforms, step.Step)
+		}
+		if kind == "ip" {
+			parsed, ok := ip.CanonicalizeIP(normalized)
+			if !ok {
+				return "", "", nil
+			}
+			if s := parsed.String(); s != strings.Trim(normalized, "[]") {
+				transforms = append(transforms, "canonicalize_ip")
+			}
+			normalized = parsed.String()
+		}
+	case kind == "ip":
+		trimmed := strings.TrimSpace(input)
+		if trimmed != input {
+			transforms = append(transforms, "trim_space")
+		}
+		parsed := net.ParseIP(trimmed)
+		if parsed == nil {
+			return "", "", nil
+		}
+		normalized = parsed.String()
+		if normalized != trimmed {
+			transforms = append(transforms, "canonicalize_ip")
+		}
+	default:
+		return "", "", nil
+	}
+
+	if len(transforms) == 0 || normalized == "" {
+		return "", "", nil
+	}
+	return input, normalized, transforms
+}
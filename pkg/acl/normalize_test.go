@@ -0,0 +1,62 @@
+package acl
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	"github.com/cyberspacesec/go-acl/pkg/types"
+)
+
+// TestNormalizationTrace 测试审计事件报告输入的标准化过程
+func TestNormalizationTrace(t *testing.T) {
+	manager := NewManager()
+	manager.SetIPACL([]string{"127.0.0.0/8"}, types.Blacklist)
+	manager.SetDomainACL([]string{"example.com"}, types.Blacklist, false)
+
+	var events []AuditEvent
+	manager.SetAuditHook(func(e AuditEvent) { events = append(events, e) })
+
+	check := func() {
+		events = nil
+		manager.CheckDomain("HTTPS://WWW.Example.com:443/x")
+		manager.CheckDomain("example.com")
+		manager.CheckIP(" ::ffff:127.0.0.1")
+		manager.CheckHost("http://2130706433/admin")
+		manager.CheckHostDetailed(context.Background(), "127.0.0.1")
+	}
+
+	// 默认不报告
+	check()
+	for _, e := range events {
+		if e.Input != "" || e.Normalized != "" || e.Transforms != nil {
+			t.Errorf("未启用时事件 = %+v, 期望不包含标准化信息", e)
+		}
+	}
+
+	manager.SetNormalizationTrace(true)
+	check()
+	want := []struct {
+		input      string
+		normalized string
+		transforms []string
+	}{
+		{"HTTPS://WWW.Example.com:443/x", "example.com", []string{"lowercase", "strip_scheme", "strip_path", "strip_port", "strip_www"}},
+		{},
+		{" ::ffff:127.0.0.1", "127.0.0.1", []string{"trim_space", "canonicalize_ip"}},
+		{"http://2130706433/admin", "127.0.0.1", []string{"strip_scheme", "strip_path", "canonicalize_ip"}},
+		{},
+	}
+	if len(events) != len(want) {
+		t.Fatalf("产生了%d个事件, 期望%d个", len(events), len(want))
+	}
+	for i, w := range want {
+		e := events[i]
+		if e.Input != w.input || e.Normalized != w.normalized || !reflect.DeepEqual(e.Transforms, w.transforms) {
+			t.Errorf("事件%d = %q -> %q %v, 期望 %q -> %q %v", i, e.Input, e.Normalized, e.Transforms, w.input, w.normalized, w.transforms)
+		}
+	}
+	if events[3].Target != "127.0.0.1" || events[3].Permission != types.Denied {
+		t.Errorf("CheckHost的事件 = %+v, Target应保持标准形式", events[3])
+	}
+}
@@ -0,0 +1,132 @@
+package acl
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/cyberspacesec/go-acl/pkg/types"
+)
+
+// ErrInvalidOverrideMode 表示无法识别的紧急模式名称
+var ErrInvalidOverrideMode = errors.New("无效的紧急模式")
+
+// OverrideMode 是管理器的紧急模式，启用后所有检查不再查看任何规则，直接得出同一个结果
+type OverrideMode int
+
+const (
+	// OverrideNone 正常检查，默认值
+	OverrideNone OverrideMode = iota
+	// OverrideDenyAll 全部拒绝，用于发现入侵等情况下的紧急封锁
+	OverrideDenyAll
+	// OverrideAllowAll 全部允许，用于规则误伤正常流量时的紧急放行（break-glass）
+	OverrideAllowAll
+)
+
+// String 返回紧急模式的名称："none"、"deny_all"或"allow_all"
+func (o OverrideMode) String() string {
+	switch o {
+	case OverrideNone:
+		return "none"
+	case OverrideDenyAll:
+		return "deny_all"
+	case OverrideAllowAll:
+		return "allow_all"
+	default:
+		return "unknown"
+	}
+}
+
+// ParseOverrideMode 解析紧急模式名称，是String的逆操作
+//
+// 参数:
+//   - s: "none"、"deny_all"或"allow_all"，不区分大小写，"-"与"_"等价
+//
+// 返回:
+//   - OverrideMode: 对应的紧急模式
+//   - error: 无法识别时返回包装了ErrInvalidOverrideMode的错误
+//
+// 主要用于解析管理接口的参数和环境变量。
+func ParseOverrideMode(s string) (OverrideMode, error) {
+	switch strings.ReplaceAll(strings.ToLower(strings.TrimSpace(s)), "-", "_") {
+	case "none":
+		return OverrideNone, nil
+	case "deny_all":
+		return OverrideDenyAll, nil
+	case "allow_all":
+		return OverrideAllowAll, nil
+	default:
+		return OverrideNone, fmt.Errorf("%w: %q", ErrInvalidOverrideMode, s)
+	}
+}
+
+// SetOverrideMode 设置紧急模式，可在运行时随时切换
+//
+// 参数:
+//   - mode: OverrideDenyAll拒绝所有检查，OverrideAllowAll允许所有检查，OverrideNone恢复正常检查
+//
+// 紧急模式下CheckIP、CheckDomain、CheckHost、CheckRequest及其Context、Detailed版本
+// 不再求值规则表达式、命名列表和ACL，也不返回ErrNoACL等检查错误（CheckHost在启用SetStrictHostnames时
+// 对无效主机的校验除外），结果的Source为"override"；全部拒绝时原因为types.ReasonOverrideDenyAll。
+// 这些检查的审计事件的Override字段记录当时的模式，便于事后区分紧急模式期间的决定。
+//
+// 启用期间Health()包含名为"override"的组件，状态为HealthDegraded，提醒运维人员及时恢复。
+// 规则和列表的内容不受影响，恢复OverrideNone后立即按原有配置检查。
+// SelfAudit检验的是配置本身，不受紧急模式影响。
+//
+// 示例:
+//
+//	// 发现入侵，立即封锁所有出站请求
+//	manager.SetOverrideMode(acl.OverrideDenyAll)
+//
+//	// 处置完成后恢复
+//	manager.SetOverrideMode(acl.OverrideNone)
+func (m *Manager) SetOverrideMode(mode OverrideMode) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if mode != m.override {
+		m.override = mode
+		m.overrideSince = m.now()
+		m.invalidateVerdicts()
+	}
+}
+
+// OverrideMode 返回当前的紧急模式
+func (m *Manager) OverrideMode() OverrideMode {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return m.override
+}
+
+// overrideState 返回当前的紧急模式和进入该模式的时间
+func (m *Manager) overrideState() (OverrideMode, time.Time) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return m.override, m.overrideSince
+}
+
+// overrideResult 在紧急模式下返回直接得出的检查结果，正常模式下ok为false
+func (m *Manager) overrideResult(target, kind string) (types.CheckResult, bool) {
+	switch m.OverrideMode() {
+	case OverrideDenyAll:
+		return types.CheckResult{Target: target, Kind: kind, Decision: types.Denied, Source: "override", Reason: types.ReasonOverrideDenyAll}, true
+	case OverrideAllowAll:
+		return types.CheckResult{Target: target, Kind: kind, Decision: types.Allowed, Source: "override"}, true
+	default:
+		return types.CheckResult{}, false
+	}
+}
+
+// overrideHealth 返回紧急模式的健康状态，正常模式下ok为false
+func (m *Manager) overrideHealth() (ComponentHealth, bool) {
+	mode, since := m.overrideState()
+	if mode == OverrideNone {
+		return ComponentHealth{}, false
+	}
+	return ComponentHealth{
+		Name:    "override",
+		Status:  HealthDegraded,
+		Message: fmt.Sprintf("紧急模式%s自%s起生效，所有检查不查看规则", mode, since.Format(time.RFC3339)),
+	}, true
+}
@@ -0,0 +1,124 @@
+package acl
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/cyberspacesec/go-acl/pkg/expr"
+	"github.com/cyberspacesec/go-acl/pkg/types"
+)
+
+// TestSetOverrideMode 测试紧急模式短路所有检查、标注审计事件并反映在健康报告中
+func TestSetOverrideMode(t *testing.T) {
+	start := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	manager := NewManager()
+	manager.SetClock(types.NewManualClock(start))
+	if err := manager.SetIPACL([]string{"203.0.113.0/24"}, types.Blacklist); err != nil {
+		t.Fatalf("SetIPACL() 返回错误: %v", err)
+	}
+	manager.SetRules(expr.RuleSet{expr.MustCompile("port == 22 -> deny")})
+
+	var events []AuditEvent
+	manager.SetAuditHook(func(e AuditEvent) { events = append(events, e) })
+
+	tests := []struct {
+		name       string
+		mode       OverrideMode
+		want       types.Permission
+		wantReason types.Reason
+	}{
+		{"全部拒绝", OverrideDenyAll, types.Denied, types.ReasonOverrideDenyAll},
+		{"全部允许", OverrideAllowAll, types.Allowed, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			manager.SetOverrideMode(tt.mode)
+			if got := manager.OverrideMode(); got != tt.mode {
+				t.Fatalf("OverrideMode() = %v, 期望 %v", got, tt.mode)
+			}
+			events = nil
+
+			ctx := context.Background()
+			checks := map[string]func() (types.CheckResult, error){
+				"IP":     func() (types.CheckResult, error) { return manager.CheckIPDetailed(ctx, "203.0.113.7") },
+				"未配置的域名": func() (types.CheckResult, error) { return manager.CheckDomainDetailed(ctx, "example.com") },
+				"无效的IP":  func() (types.CheckResult, error) { return manager.CheckIPDetailed(ctx, "not-an-ip") },
+				"主机":     func() (types.CheckResult, error) { return manager.CheckHostDetailed(ctx, "http://192.0.2.1/") },
+				"规则": func() (types.CheckResult, error) {
+					return manager.CheckRequestDetailed(ctx, expr.Request{IP: "192.0.2.1", Port: 22})
+				},
+				"只有端口": func() (types.CheckResult, error) {
+					return manager.CheckRequestDetailed(ctx, expr.Request{Port: 22})
+				},
+			}
+			for name, check := range checks {
+				result, err := check()
+				if err != nil || result.Decision != tt.want || result.Source != "override" || result.Reason != tt.wantReason {
+					t.Errorf("%s: 结果 = %+v, %v", name, result, err)
+				}
+			}
+
+			if len(events) == 0 {
+				t.Fatal("没有产生审计事件")
+			}
+			for _, e := range events {
+				if e.Override != tt.mode.String() || e.Permission != tt.want {
+					t.Errorf("审计事件 = %+v, 期望Override为%s", e, tt.mode)
+				}
+			}
+
+			report := manager.Health()
+			last := report.Components[len(report.Components)-1]
+			if report.Status != HealthDegraded || last.Name != "override" || last.Status != HealthDegraded {
+				t.Errorf("Health() = %+v", report)
+			}
+		})
+	}
+
+	// 恢复后按原有配置检查
+	manager.SetOverrideMode(OverrideNone)
+	if perm, _ := manager.CheckIP("203.0.113.7"); perm != types.Denied {
+		t.Errorf("恢复后 CheckIP() = %v, 期望 %v", perm, types.Denied)
+	}
+	if perm, _ := manager.CheckIP("192.0.2.1"); perm != types.Allowed {
+		t.Errorf("恢复后 CheckIP() = %v, 期望 %v", perm, types.Allowed)
+	}
+	if _, err := manager.CheckDomain("example.com"); !errors.Is(err, types.ErrNoACL) {
+		t.Errorf("恢复后 CheckDomain() 错误 = %v, 期望 %v", err, types.ErrNoACL)
+	}
+	if report := manager.Health(); report.Status != HealthOK {
+		t.Errorf("恢复后 Health() = %+v", report)
+	}
+}
+
+// TestParseOverrideMode 测试紧急模式名称的解析
+func TestParseOverrideMode(t *testing.T) {
+	tests := []struct {
+		input   string
+		want    OverrideMode
+		wantErr bool
+	}{
+		{"none", OverrideNone, false},
+		{"DENY_ALL", OverrideDenyAll, false},
+		{" allow-all ", OverrideAllowAll, false},
+		{"lockdown", OverrideNone, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			got, err := ParseOverrideMode(tt.input)
+			if (err != nil) != tt.wantErr || got != tt.want {
+				t.Errorf("ParseOverrideMode(%q) = %v, %v", tt.input, got, err)
+			}
+			if err != nil && !errors.Is(err, ErrInvalidOverrideMode) {
+				t.Errorf("错误 = %v, 期望包装 %v", err, ErrInvalidOverrideMode)
+			}
+			if err == nil {
+				if back, _ := ParseOverrideMode(got.String()); back != got {
+					t.Errorf("String()无法解析回原值: %s", got)
+				}
+			}
+		})
+	}
+}
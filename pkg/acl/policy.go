@@ -0,0 +1,381 @@
+package acl
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	"github.com/cyberspacesec/go-acl/pkg/expr"
+	"github.com/cyberspacesec/go-acl/pkg/ip"
+	"github.com/cyberspacesec/go-acl/pkg/types"
+)
+
+// Policy 是JSON策略文件的内容，描述一个Manager的IP ACL、域名ACL、命名列表和条件规则
+//
+// 字段说明:
+//   - Version: 文件格式版本，见PolicyVersion；读取时旧版本的文件会被自动升级
+//   - IP: IP ACL，为nil表示不设置
+//   - Domain: 域名ACL，为nil表示不设置
+//   - Lists: 命名列表，见ListPolicy
+//   - Rules: 条件规则表达式，按顺序求值，见expr.Compile
+//
+// 示例文件:
+//
+//	{
+//	    "version": 2,
+//	    "ip": {"type": "blacklist", "ranges": ["203.0.113.0/24"], "predefined": ["private_networks", "cloud_metadata"]},
+//	    "domain": {"type": "blacklist", "domains": ["ads.example.com"], "include_subdomains": true},
+//	    "lists": [{"name": "partners", "kind": "ip", "type": "whitelist", "entries": ["198.51.100.0/24"], "priority": 10}],
+//	    "rules": ["port == 22 -> deny"]
+//	}
+//
+// 字段同时带有yaml标签，其他格式通过PolicyCodec支持，见RegisterPolicyCodec。
+type Policy struct {
+	Version int           `json:"version,omitempty" yaml:"version,omitempty"`
+	IP      *IPPolicy     `json:"ip,omitempty" yaml:"ip,omitempty"`
+	Domain  *DomainPolicy `json:"domain,omitempty" yaml:"domain,omitempty"`
+	Lists   []ListPolicy  `json:"lists,omitempty" yaml:"lists,omitempty"`
+	Rules   []string      `json:"rules,omitempty" yaml:"rules,omitempty"`
+}
+
+// IPPolicy 是策略文件中的IP ACL
+//
+// 字段说明:
+//   - Type: "blacklist"或"whitelist"
+//   - Ranges: IP或CIDR列表，"*"表示所有IPv4和IPv6地址，展开为"0.0.0.0/0"和"::/0"
+//   - Predefined: 预定义IP集合，按列表类型加入（黑名单中拒绝、白名单中允许）
+//   - Ports: IP或CIDR到允许端口的映射，见Manager.SetIPRulePorts
+//   - AllowAny: 确认白名单有意包含"*"、"0.0.0.0/0"或"::/0"等通配条目，
+//     未设置时白名单中的通配条目返回ErrWildcardNotConfirmed
+type IPPolicy struct {
+	Type       string             `json:"type" yaml:"type"`
+	Ranges     []string           `json:"ranges,omitempty" yaml:"ranges,omitempty"`
+	Predefined []ip.PredefinedSet `json:"predefined,omitempty" yaml:"predefined,omitempty"`
+	Ports      map[string][]int   `json:"ports,omitempty" yaml:"ports,omitempty"`
+	AllowAny   bool               `json:"allow_any,omitempty" yaml:"allow_any,omitempty"`
+}
+
+// DomainPolicy 是策略文件中的域名ACL
+//
+// 字段说明:
+//   - Type: "blacklist"或"whitelist"
+//   - Domains: 域名规则列表，支持domain.ParseRule中的前缀；
+//     "*"是匹配所有域名的通配条目，见domain.DomainACL.SetMatchAnyDomain
+//   - IncludeSubdomains: 是否包含子域名
+//   - Exceptions: 从列表中排除的例外域名，见domain.DomainACL.AddException
+//   - AllowAny: 确认白名单有意包含通配条目"*"，与IPPolicy.AllowAny相同
+type DomainPolicy struct {
+	Type              string   `json:"type" yaml:"type"`
+	Domains           []string `json:"domains,omitempty" yaml:"domains,omitempty"`
+	IncludeSubdomains bool     `json:"include_subdomains" yaml:"include_subdomains"`
+	Exceptions        []string `json:"exceptions,omitempty" yaml:"exceptions,omitempty"`
+	AllowAny          bool     `json:"allow_any,omitempty" yaml:"allow_any,omitempty"`
+}
+
+// ListPolicy 是策略文件中的一个命名列表，自版本2起支持
+//
+// 字段说明:
+//   - Name: 列表名称，同名的列表后出现的替换先出现的
+//   - Kind: "ip"或"domain"
+//   - Type: "blacklist"或"whitelist"
+//   - Entries: IP或CIDR（Kind为"ip"），或域名规则（Kind为"domain"）
+//   - Priority: 优先级，见Manager.SetNamedIPList
+//   - IncludeSubdomains: 是否包含子域名，仅用于域名列表
+//   - Group: 所属的规则组，为空表示不属于任何组，见Manager.SetNamedIPListGroup
+//   - AllowAny: 确认白名单有意包含通配条目，与IPPolicy.AllowAny相同
+type ListPolicy struct {
+	Name              string   `json:"name" yaml:"name"`
+	Kind              string   `json:"kind" yaml:"kind"`
+	Type              string   `json:"type" yaml:"type"`
+	Entries           []string `json:"entries,omitempty" yaml:"entries,omitempty"`
+	Priority          int      `json:"priority,omitempty" yaml:"priority,omitempty"`
+	IncludeSubdomains bool     `json:"include_subdomains,omitempty" yaml:"include_subdomains,omitempty"`
+	Group             string   `json:"group,omitempty" yaml:"group,omitempty"`
+	AllowAny          bool     `json:"allow_any,omitempty" yaml:"allow_any,omitempty"`
+}
+
+// PolicyCodec 是策略文件的编码格式
+//
+// 核心包只内置JSON（JSONCodec），其他格式由独立的模块实现并注册，
+// 使核心包不依赖任何第三方库。例如YAML支持位于github.com/cyberspacesec/go-acl/yaml模块。
+//
+// Unmarshal应将未知字段视为错误，与ReadPolicy的行为一致。
+type PolicyCodec interface {
+	Marshal(v interface{}) ([]byte, error)
+	Unmarshal(data []byte, v interface{}) error
+}
+
+// JSONCodec 是JSON格式的策略编码，Unmarshal将未知字段视为错误，Marshal输出缩进的JSON
+var JSONCodec PolicyCodec = jsonCodec{}
+
+type jsonCodec struct{}
+
+func (jsonCodec) Marshal(v interface{}) ([]byte, error) {
+	data, err := json.MarshalIndent(v, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	return append(data, '\n'), nil
+}
+
+func (jsonCodec) Unmarshal(data []byte, v interface{}) error {
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.DisallowUnknownFields()
+	return dec.Decode(v)
+}
+
+var (
+	codecMu sync.RWMutex
+	// codecs 是按文件扩展名（小写，含点）索引的策略编码
+	codecs = map[string]PolicyCodec{".json": JSONCodec}
+)
+
+// RegisterPolicyCodec 注册文件扩展名对应的策略编码，LoadPolicyFile按扩展名选择编码
+//
+// 参数:
+//   - ext: 文件扩展名，含点，如".yaml"，不区分大小写
+//   - codec: 策略编码，已注册的扩展名会被替换
+//
+// 通常由编码模块在init中调用，使用方只需导入对应的模块:
+//
+//	import _ "github.com/cyberspacesec/go-acl/yaml"
+//
+//	manager, err := acl.LoadPolicyFile("./policy.yaml")
+func RegisterPolicyCodec(ext string, codec PolicyCodec) {
+	codecMu.Lock()
+	defer codecMu.Unlock()
+	codecs[strings.ToLower(ext)] = codec
+}
+
+// PolicyCodecFor 返回文件路径对应的策略编码
+//
+// 参数:
+//   - filePath: 策略文件路径
+//
+// 返回:
+//   - PolicyCodec: 按扩展名注册的编码，未注册的扩展名使用JSONCodec
+func PolicyCodecFor(filePath string) PolicyCodec {
+	codecMu.RLock()
+	defer codecMu.RUnlock()
+	if codec, ok := codecs[strings.ToLower(filepath.Ext(filePath))]; ok {
+		return codec
+	}
+	return JSONCodec
+}
+
+// DecodePolicy 用指定的编码解析策略
+//
+// 参数:
+//   - data: 策略数据
+//   - codec: 编码，如JSONCodec
+//
+// 返回:
+//   - Policy: 解析后的策略，Version为PolicyVersion
+//   - error: 格式错误、包含未知字段或版本不受支持（ErrUnsupportedPolicyVersion）时返回错误
+//
+// 旧版本的策略先用MigratePolicy升级，再按当前格式解析。
+func DecodePolicy(data []byte, codec PolicyCodec) (Policy, error) {
+	var doc map[string]interface{}
+	if err := codec.Unmarshal(data, &doc); err != nil {
+		return Policy{}, fmt.Errorf("解析策略失败: %w", err)
+	}
+	if doc == nil {
+		doc = make(map[string]interface{})
+	}
+	from, err := MigratePolicy(doc)
+	if err != nil {
+		return Policy{}, err
+	}
+	if from != PolicyVersion {
+		if data, err = codec.Marshal(doc); err != nil {
+			return Policy{}, fmt.Errorf("解析策略失败: %w", err)
+		}
+	}
+
+	var policy Policy
+	if err := codec.Unmarshal(data, &policy); err != nil {
+		return Policy{}, fmt.Errorf("解析策略失败: %w", err)
+	}
+	policy.Version = PolicyVersion
+	return policy, nil
+}
+
+// EncodePolicy 用指定的编码序列化策略
+//
+// 参数:
+//   - policy: 策略
+//   - codec: 编码，如JSONCodec
+//
+// 返回:
+//   - []byte: 编码后的策略，version字段总是PolicyVersion，可以再用DecodePolicy读取
+//   - error: 编码失败时返回错误
+func EncodePolicy(policy Policy, codec PolicyCodec) ([]byte, error) {
+	policy.Version = PolicyVersion
+	return codec.Marshal(policy)
+}
+
+// ReadPolicy 从r中读取JSON策略
+//
+// 参数:
+//   - r: JSON策略数据
+//
+// 返回:
+//   - Policy: 解析后的策略
+//   - error: JSON格式错误、包含未知字段或版本不受支持时返回错误
+//
+// 未知字段视为错误，避免拼写错误的字段被静默忽略。等同于用JSONCodec调用DecodePolicy。
+func ReadPolicy(r io.Reader) (Policy, error) {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return Policy{}, err
+	}
+	return DecodePolicy(data, JSONCodec)
+}
+
+// NewManagerFromPolicy 根据策略创建Manager
+//
+// 参数:
+//   - policy: 策略
+//
+// 返回:
+//   - *Manager: 按策略配置好的管理器
+//   - error: 列表类型、IP、预定义集合或规则无效时返回错误，
+//     白名单包含通配条目而没有设置AllowAny时返回包装了ErrWildcardNotConfirmed的错误
+//
+// 通配条目让白名单放行所有目标，适用于有意不做限制、但仍希望经过ACL记录审计日志的环境。
+// 为避免一个误写的"0.0.0.0/0"悄悄关闭访问控制，白名单中的通配条目必须用allow_any显式确认。
+// IP通配条目是基数树的根节点；域名通配条目启用domain.DomainACL.SetMatchAnyDomain，
+// 在查询引擎之前直接命中，检查的开销是常数。只有策略中的"*"是通配条目，
+// SetDomainACL、AddDomain等方法和订阅源中的"*"只是普通的域名。
+//
+// 示例:
+//
+//	manager, err := acl.NewManagerFromPolicy(acl.Policy{
+//	    IP: &acl.IPPolicy{Type: "blacklist", Predefined: []ip.PredefinedSet{ip.PrivateNetworks}},
+//	})
+func NewManagerFromPolicy(policy Policy) (*Manager, error) {
+	m := NewManager()
+
+	if p := policy.IP; p != nil {
+		listType, err := types.ParseListType(p.Type)
+		if err != nil {
+			return nil, fmt.Errorf("ip: %w", err)
+		}
+		if err := checkWildcard(p.Ranges, listType, p.AllowAny, isIPWildcard); err != nil {
+			return nil, fmt.Errorf("ip: %w", err)
+		}
+		if err := m.SetIPACLWithDefaults(expandIPWildcard(p.Ranges), listType, p.Predefined, listType == types.Whitelist); err != nil {
+			return nil, fmt.Errorf("ip: %w", err)
+		}
+		for ipRange, ports := range p.Ports {
+			if err := m.SetIPRulePorts(ipRange, ports...); err != nil {
+				return nil, fmt.Errorf("ip.ports: %w", err)
+			}
+		}
+	}
+
+	if p := policy.Domain; p != nil {
+		listType, err := types.ParseListType(p.Type)
+		if err != nil {
+			return nil, fmt.Errorf("domain: %w", err)
+		}
+		if err := checkWildcard(p.Domains, listType, p.AllowAny, isDomainWildcard); err != nil {
+			return nil, fmt.Errorf("domain: %w", err)
+		}
+		domains, matchAny := stripDomainWildcard(p.Domains)
+		if err := m.TrySetDomainACL(domains, listType, p.IncludeSubdomains); err != nil {
+			return nil, fmt.Errorf("domain: %w", err)
+		}
+		// m尚未返回给调用方，可以不加锁直接修改
+		m.domainACL.AddException(p.Exceptions...)
+		m.domainACL.SetMatchAnyDomain(matchAny)
+	}
+
+	for i, l := range policy.Lists {
+		if err := applyListPolicy(m, l); err != nil {
+			return nil, fmt.Errorf("lists[%d]: %w", i, err)
+		}
+	}
+
+	if len(policy.Rules) > 0 {
+		rules, err := expr.CompileAll(policy.Rules)
+		if err != nil {
+			return nil, fmt.Errorf("rules: %w", err)
+		}
+		if err := m.TrySetRules(rules); err != nil {
+			return nil, fmt.Errorf("rules: %w", err)
+		}
+	}
+	return m, nil
+}
+
+// applyListPolicy 把策略中的一个命名列表加入Manager
+func applyListPolicy(m *Manager, l ListPolicy) error {
+	listType, err := types.ParseListType(l.Type)
+	if err != nil {
+		return err
+	}
+	switch l.Kind {
+	case "ip":
+		if err := checkWildcard(l.Entries, listType, l.AllowAny, isIPWildcard); err != nil {
+			return err
+		}
+		if err := m.SetNamedIPList(l.Name, expandIPWildcard(l.Entries), listType, l.Priority); err != nil {
+			return err
+		}
+		if l.Group != "" {
+			return m.SetNamedIPListGroup(l.Name, l.Group)
+		}
+	case "domain":
+		if err := checkWildcard(l.Entries, listType, l.AllowAny, isDomainWildcard); err != nil {
+			return err
+		}
+		entries, matchAny := stripDomainWildcard(l.Entries)
+		if err := m.TrySetNamedDomainList(l.Name, entries, listType, l.IncludeSubdomains, l.Priority); err != nil {
+			return err
+		}
+		if matchAny {
+			// m尚未返回给调用方，列表一定存在
+			findList(m.domainLists, l.Name).domain.SetMatchAnyDomain(true)
+		}
+		if l.Group != "" {
+			return m.SetNamedDomainListGroup(l.Name, l.Group)
+		}
+	default:
+		return fmt.Errorf("未知的列表种类 %q，应为\"ip\"或\"domain\"", l.Kind)
+	}
+	return nil
+}
+
+// LoadPolicyFile 读取策略文件并创建Manager
+//
+// 参数:
+//   - filePath: 策略文件路径，按扩展名选择编码（见PolicyCodecFor），默认为JSON
+//
+// 返回:
+//   - *Manager: 按策略配置好的管理器
+//   - error: 读取、解析或应用策略失败时返回错误
+//
+// 示例:
+//
+//	manager, err := acl.LoadPolicyFile("./policy.json")
+//	if err != nil {
+//	    log.Fatalf("加载策略失败: %v", err)
+//	}
+func LoadPolicyFile(filePath string) (*Manager, error) {
+	data, err := os.ReadFile(filePath)
+	if err != nil {
+		return nil, err
+	}
+
+	policy, err := DecodePolicy(data, PolicyCodecFor(filePath))
+	if err != nil {
+		return nil, err
+	}
+	return NewManagerFromPolicy(policy)
+}
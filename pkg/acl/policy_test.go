@@ -0,0 +1,149 @@
+package acl
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/cyberspacesec/go-acl/pkg/expr"
+	"github.com/cyberspacesec/go-acl/pkg/types"
+)
+
+// TestLoadPolicyFile 测试从JSON策略文件创建Manager
+func TestLoadPolicyFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "policy.json")
+	data := `{
+		"ip": {"type": "blacklist", "ranges": ["203.0.113.0/24"], "predefined": ["cloud_metadata"]},
+		"domain": {"type": "whitelist", "domains": ["example.com"], "include_subdomains": true},
+		"rules": ["port == 22 -> deny"]
+	}`
+	if err := os.WriteFile(path, []byte(data), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	manager, err := LoadPolicyFile(path)
+	if err != nil {
+		t.Fatalf("LoadPolicyFile() 返回错误: %v", err)
+	}
+
+	tests := []struct {
+		name string
+		req  expr.Request
+		want types.Permission
+	}{
+		{"IP范围", expr.Request{IP: "203.0.113.9"}, types.Denied},
+		{"预定义集合", expr.Request{IP: "169.254.169.254"}, types.Denied},
+		{"允许的IP", expr.Request{IP: "198.51.100.1"}, types.Allowed},
+		{"白名单子域名", expr.Request{Domain: "api.example.com"}, types.Allowed},
+		{"白名单外的域名", expr.Request{Domain: "example.org"}, types.Denied},
+		{"规则", expr.Request{IP: "198.51.100.1", Port: 22}, types.Denied},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if perm, err := manager.CheckRequest(tt.req); err != nil || perm != tt.want {
+				t.Errorf("CheckRequest(%s) = %v, %v; 期望 %v", tt.req, perm, err, tt.want)
+			}
+		})
+	}
+
+	if _, err := LoadPolicyFile(filepath.Join(t.TempDir(), "missing.json")); err == nil {
+		t.Error("文件不存在时 LoadPolicyFile() 应返回错误")
+	}
+}
+
+// TestPolicyErrors 测试无效的策略
+func TestPolicyErrors(t *testing.T) {
+	tests := []struct {
+		name    string
+		data    string
+		wantErr error
+	}{
+		{"未知字段", `{"ips": {}}`, nil},
+		{"无效的列表类型", `{"domain": {"type": "allowlist"}}`, types.ErrInvalidListType},
+		{"无效的IP", `{"ip": {"type": "blacklist", "ranges": ["not-an-ip"]}}`, nil},
+		{"无效的规则", `{"rules": ["port == -> deny"]}`, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			policy, err := ReadPolicy(strings.NewReader(tt.data))
+			if err == nil {
+				_, err = NewManagerFromPolicy(policy)
+			}
+			if err == nil {
+				t.Fatal("应返回错误")
+			}
+			if tt.wantErr != nil && !errors.Is(err, tt.wantErr) {
+				t.Errorf("错误 = %v, 期望 %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+// upperCodec 是用于测试的编码，把JSON数据整体转换为大写后保存
+type upperCodec struct{}
+
+func (upperCodec) Marshal(v interface{}) ([]byte, error) {
+	data, err := JSONCodec.Marshal(v)
+	return []byte(strings.ToUpper(string(data))), err
+}
+
+func (upperCodec) Unmarshal(data []byte, v interface{}) error {
+	return JSONCodec.Unmarshal([]byte(strings.ToLower(string(data))), v)
+}
+
+// TestPolicyCodec 测试策略编码的注册和选择
+func TestPolicyCodec(t *testing.T) {
+	policy := Policy{
+		Version: PolicyVersion,
+		IP:      &IPPolicy{Type: "blacklist", Ranges: []string{"203.0.113.0/24"}},
+		Domain:  &DomainPolicy{Type: "blacklist", Domains: []string{"ads.example.com"}, IncludeSubdomains: true},
+		Rules:   []string{"port == 22 -> deny"},
+	}
+
+	data, err := EncodePolicy(policy, JSONCodec)
+	if err != nil {
+		t.Fatalf("EncodePolicy() 返回错误: %v", err)
+	}
+	decoded, err := DecodePolicy(data, JSONCodec)
+	if err != nil {
+		t.Fatalf("DecodePolicy() 返回错误: %v", err)
+	}
+	if !reflect.DeepEqual(decoded, policy) {
+		t.Errorf("DecodePolicy() = %+v, 期望 %+v", decoded, policy)
+	}
+	if _, err := DecodePolicy([]byte(`{"ip": {"type": "blacklist", "rangez": []}}`), JSONCodec); err == nil {
+		t.Error("包含未知字段时 DecodePolicy() 应返回错误")
+	}
+
+	RegisterPolicyCodec(".UPPER", upperCodec{})
+	tests := []struct {
+		path string
+		want PolicyCodec
+	}{
+		{"policy.json", JSONCodec},
+		{"policy.upper", upperCodec{}},
+		{"POLICY.Upper", upperCodec{}},
+		{"policy.conf", JSONCodec},
+	}
+	for _, tt := range tests {
+		if got := PolicyCodecFor(tt.path); got != tt.want {
+			t.Errorf("PolicyCodecFor(%s) = %T, 期望 %T", tt.path, got, tt.want)
+		}
+	}
+
+	data, _ = EncodePolicy(policy, upperCodec{})
+	path := filepath.Join(t.TempDir(), "policy.upper")
+	if err := os.WriteFile(path, data, 0o644); err != nil {
+		t.Fatal(err)
+	}
+	manager, err := LoadPolicyFile(path)
+	if err != nil {
+		t.Fatalf("LoadPolicyFile() 返回错误: %v", err)
+	}
+	if perm, _ := manager.CheckDomain("x.ads.example.com"); perm != types.Denied {
+		t.Errorf("CheckDomain() = %v, 期望 %v", perm, types.Denied)
+	}
+}
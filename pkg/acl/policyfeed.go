@@ -0,0 +1,347 @@
+package acl
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"strconv"
+	"sync/atomic"
+	"time"
+
+	"github.com/cyberspacesec/go-acl/pkg/config"
+)
+
+var (
+	// ErrPolicyFeedNotModified 表示策略与客户端已有的校验和相同，服务端返回了304 Not Modified
+	ErrPolicyFeedNotModified = errors.New("策略未改变")
+
+	// ErrPolicyFeedChecksum 表示策略内容与附带的校验和不符
+	ErrPolicyFeedChecksum = errors.New("策略校验和不匹配")
+)
+
+// PolicyFeed 是供其他实例轮询的当前策略
+//
+// 字段说明:
+//   - Revision: 生成时的修订号，见Manager.Revision，只用于日志和排查
+//   - Checksum: Policy字段原始JSON的SHA-256（十六进制），内容相同的策略校验和相同
+//   - Generated: 生成的时间，来自Manager的时钟
+//   - Policy: 编码为紧凑JSON的策略，见Manager.Policy
+//
+// Policy保留编码后的原始字节，接收方可以对收到的字节直接校验，不依赖重新编码的结果。
+type PolicyFeed struct {
+	Revision  uint64          `json:"revision"`
+	Checksum  string          `json:"sha256"`
+	Generated time.Time       `json:"generated"`
+	Policy    json.RawMessage `json:"policy"`
+}
+
+// Verify 检查Policy与Checksum是否一致并解码策略
+//
+// 返回:
+//   - Policy: 解码后的策略
+//   - error: 校验和不符时返回ErrPolicyFeedChecksum，策略无法解码时返回DecodePolicy的错误
+func (f PolicyFeed) Verify() (Policy, error) {
+	if policyChecksum(f.Policy) != f.Checksum {
+		return Policy{}, ErrPolicyFeedChecksum
+	}
+	return DecodePolicy(f.Policy, JSONCodec)
+}
+
+// policyChecksum 返回编码后的策略的SHA-256（十六进制）
+func policyChecksum(data []byte) string {
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
+
+// Policy 返回描述当前配置的策略
+//
+// 返回:
+//   - Policy: IP和域名主列表、端口限制、命名列表及所属的规则组和条件规则，
+//     用NewManagerFromPolicy或ApplyPolicy可以得到匹配结果相同的配置
+//
+// 预定义集合已展开在Ranges中。策略文件无法表示的设置不包含在内：域名的节点策略、
+// 地址族限制（SetFamily、DenyIPFamily）、临时条目的到期时间（条目作为永久条目输出）
+// 以及规则组的启停状态（停用组中的列表照常输出）。
+func (m *Manager) Policy() Policy {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	m.ipMu.RLock()
+	defer m.ipMu.RUnlock()
+	m.domainMu.RLock()
+	defer m.domainMu.RUnlock()
+
+	policy := Policy{Version: PolicyVersion}
+	if m.ipACL != nil {
+		ranges := m.ipACL.GetIPRanges()
+		policy.IP = &IPPolicy{
+			Type: m.ipACL.GetListType().String(), Ranges: ranges,
+			AllowAny: hasWildcard(ranges, m.ipACL.GetListType(), isIPWildcard),
+		}
+		for _, rule := range m.ipPorts {
+			ports := make([]int, 0, len(rule.ports))
+			for port := range rule.ports {
+				ports = append(ports, port)
+			}
+			sort.Ints(ports)
+			if policy.IP.Ports == nil {
+				policy.IP.Ports = make(map[string][]int, len(m.ipPorts))
+			}
+			policy.IP.Ports[rule.source] = ports
+		}
+	}
+	if m.domainACL != nil {
+		policy.Domain = &DomainPolicy{
+			Type:              m.domainACL.GetListType().String(),
+			Domains:           withDomainWildcard(m.domainACL),
+			IncludeSubdomains: m.domainACL.IncludesSubdomains(),
+		}
+		policy.Domain.AllowAny = confirmedDomainWildcard(m.domainACL)
+		if exceptions := m.domainACL.GetExceptions(); len(exceptions) > 0 {
+			policy.Domain.Exceptions = exceptions
+		}
+	}
+	// 列表按求值顺序输出，按顺序重新加入后优先级相同的列表顺序不变
+	for _, l := range m.ipLists {
+		entries := l.ip.GetIPRanges()
+		policy.Lists = append(policy.Lists, ListPolicy{
+			Name: l.name, Kind: "ip", Type: l.ip.GetListType().String(),
+			Entries: entries, Priority: l.priority, Group: l.group,
+			AllowAny: hasWildcard(entries, l.ip.GetListType(), isIPWildcard),
+		})
+	}
+	for _, l := range m.domainLists {
+		entries := withDomainWildcard(l.domain)
+		policy.Lists = append(policy.Lists, ListPolicy{
+			Name: l.name, Kind: "domain", Type: l.domain.GetListType().String(),
+			Entries: entries, Priority: l.priority, Group: l.group,
+			IncludeSubdomains: l.domain.IncludesSubdomains(),
+			AllowAny:          confirmedDomainWildcard(l.domain),
+		})
+	}
+	for _, rule := range m.rules {
+		policy.Rules = append(policy.Rules, rule.Source)
+	}
+	return policy
+}
+
+// PolicyFeed 生成当前策略及其修订号和校验和
+//
+// 返回:
+//   - PolicyFeed: 当前的策略
+//   - error: 编码失败时返回错误
+func (m *Manager) PolicyFeed() (PolicyFeed, error) {
+	// 先读修订号：生成期间发生的改变会使下一次生成的修订号更大
+	revision := m.Revision()
+	// 使用紧凑的编码：json.Marshal输出PolicyFeed时会压缩RawMessage中的空白，改变校验的字节
+	data, err := json.Marshal(m.Policy())
+	if err != nil {
+		return PolicyFeed{}, err
+	}
+	return PolicyFeed{
+		Revision:  revision,
+		Checksum:  policyChecksum(data),
+		Generated: m.Clock().Now(),
+		Policy:    data,
+	}, nil
+}
+
+// PolicyFeedHandler 返回以JSON提供当前策略的只读HTTP接口
+//
+// 返回:
+//   - http.Handler: 响应GET和HEAD请求，其他方法返回405 Method Not Allowed
+//
+// 响应体为PolicyFeed，ETag为带引号的校验和；请求的If-None-Match与之相同时返回304 Not Modified，
+// 轮询的实例在策略没有改变时不必传输策略内容。接口不做身份验证，应只暴露给可信的网络
+// 或由调用方包装认证中间件。
+//
+// 示例:
+//
+//	// 主实例
+//	http.Handle("/acl/policy", manager.PolicyFeedHandler())
+//
+//	// 其他实例
+//	client := acl.PolicyFeedClient{URL: "http://primary:8080/acl/policy"}
+//	go replica.FollowPolicyFeed(ctx, client, 30*time.Second)
+func (m *Manager) PolicyFeedHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet && r.Method != http.MethodHead {
+			w.Header().Set("Allow", "GET, HEAD")
+			http.Error(w, http.StatusText(http.StatusMethodNotAllowed), http.StatusMethodNotAllowed)
+			return
+		}
+		feed, err := m.PolicyFeed()
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+
+		etag := strconv.Quote(feed.Checksum)
+		w.Header().Set("ETag", etag)
+		w.Header().Set("Cache-Control", "no-cache")
+		if r.Header.Get("If-None-Match") == etag {
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+		body, err := json.Marshal(feed)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.Header().Set("Content-Length", strconv.Itoa(len(body)))
+		if r.Method == http.MethodGet {
+			w.Write(body)
+		}
+	})
+}
+
+// PolicyFeedClient 从PolicyFeedHandler获取策略
+//
+// 字段说明:
+//   - URL: PolicyFeedHandler的地址
+//   - Client: 使用的HTTP客户端，nil表示http.DefaultClient
+//   - MaxBytes: 最多读取的响应体字节数，0表示DefaultFeedMaxBytes
+type PolicyFeedClient struct {
+	URL      string
+	Client   *http.Client
+	MaxBytes int64
+}
+
+// Fetch 获取策略并检查校验和
+//
+// 参数:
+//   - ctx: 请求的上下文
+//   - checksum: 已有策略的校验和，非空时作为If-None-Match发送
+//
+// 返回:
+//   - PolicyFeed: 获取到的策略，校验和已检查，用Verify解码
+//   - error: 策略与checksum相同时返回ErrPolicyFeedNotModified；非2xx响应、
+//     响应体超过MaxBytes（config.ErrInputTooLarge）、格式错误或校验和不符（ErrPolicyFeedChecksum）时返回错误
+func (c PolicyFeedClient) Fetch(ctx context.Context, checksum string) (PolicyFeed, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, c.URL, nil)
+	if err != nil {
+		return PolicyFeed{}, err
+	}
+	if checksum != "" {
+		req.Header.Set("If-None-Match", strconv.Quote(checksum))
+	}
+	client := c.Client
+	if client == nil {
+		client = http.DefaultClient
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return PolicyFeed{}, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusNotModified {
+		return PolicyFeed{}, ErrPolicyFeedNotModified
+	}
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return PolicyFeed{}, fmt.Errorf("下载 %s 失败: %s", c.URL, resp.Status)
+	}
+	maxBytes := feedMaxBytes(c.MaxBytes)
+	data, err := io.ReadAll(io.LimitReader(resp.Body, maxBytes+1))
+	if err != nil {
+		return PolicyFeed{}, err
+	}
+	if int64(len(data)) > maxBytes {
+		return PolicyFeed{}, fmt.Errorf("%w: 策略超过%d字节", config.ErrInputTooLarge, maxBytes)
+	}
+	var feed PolicyFeed
+	if err := json.Unmarshal(data, &feed); err != nil {
+		return PolicyFeed{}, fmt.Errorf("解析策略失败: %w", err)
+	}
+	if policyChecksum(feed.Policy) != feed.Checksum {
+		return PolicyFeed{}, ErrPolicyFeedChecksum
+	}
+	return feed, nil
+}
+
+// ApplyPolicy 用策略整体替换当前的列表和规则
+//
+// 参数:
+//   - policy: 策略，与NewManagerFromPolicy的参数相同
+//
+// 返回:
+//   - error: 策略无效时返回错误，此时配置不变
+//
+// IP和域名主列表、端口限制、命名列表和条件规则在一次操作中替换，检查不会看到新旧混合的配置。
+// 策略无法表示的设置（地址族限制、规则组的启停状态）以及钩子、统计等运行状态保持不变。
+func (m *Manager) ApplyPolicy(policy Policy) error {
+	tmp, err := NewManagerFromPolicy(policy)
+	if err != nil {
+		return err
+	}
+	if err := m.intercept(Change{Op: ChangeReplace, Kind: "*", Component: "*", Policy: &policy}); err != nil {
+		return err
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.ipMu.Lock()
+	defer m.ipMu.Unlock()
+	m.domainMu.Lock()
+	defer m.domainMu.Unlock()
+
+	now := m.now()
+	for i := range tmp.ipLists {
+		tmp.ipLists[i].modified = now
+	}
+	for i := range tmp.domainLists {
+		tmp.domainLists[i].modified = now
+	}
+	m.ipACL = tmp.ipACL
+	m.ipPorts = tmp.ipPorts
+	m.ipLists = tmp.ipLists
+	m.domainACL = tmp.domainACL
+	m.domainLists = tmp.domainLists
+	m.rules = tmp.rules
+	// 策略中没有临时条目
+	atomic.StoreInt64(&m.nextExpiry, 0)
+	m.ipModified = now
+	m.domainModified = now
+	m.notifyChange("ip", "*", now)
+	m.notifyChange("domain", "*", now)
+	m.notifyChange("rules", "*", now)
+	return nil
+}
+
+// FollowPolicyFeed 定期从client获取策略，策略改变时用ApplyPolicy应用，直到ctx被取消
+//
+// 参数:
+//   - ctx: 控制轮询生命周期的上下文
+//   - client: 策略的来源
+//   - interval: 轮询间隔，必须大于0
+//
+// 返回:
+//   - error: ctx被取消时返回ctx.Err()
+//
+// 启动时立即获取一次。获取或应用失败时保留当前配置，在下一次轮询时重试，
+// 与订阅源一样，失败不会清空已有的规则。
+func (m *Manager) FollowPolicyFeed(ctx context.Context, client PolicyFeedClient, interval time.Duration) error {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	var checksum string
+	for {
+		if feed, err := client.Fetch(ctx, checksum); err == nil {
+			if policy, err := feed.Verify(); err == nil && m.ApplyPolicy(policy) == nil {
+				checksum = feed.Checksum
+			}
+		}
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-ticker.C:
+		}
+	}
+}
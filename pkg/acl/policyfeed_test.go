@@ -0,0 +1,283 @@
+package acl
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/cyberspacesec/go-acl/pkg/config"
+	"github.com/cyberspacesec/go-acl/pkg/expr"
+	"github.com/cyberspacesec/go-acl/pkg/types"
+)
+
+// newPolicyFeedManager 返回配置了主列表、端口、命名列表和规则的Manager
+func newPolicyFeedManager(t *testing.T) *Manager {
+	t.Helper()
+	manager := NewManager()
+	if err := manager.SetIPACL([]string{"203.0.113.0/24", "10.0.0.0/8"}, types.Blacklist); err != nil {
+		t.Fatal(err)
+	}
+	if err := manager.SetIPRulePorts("10.0.0.0/8", 443, 22); err != nil {
+		t.Fatal(err)
+	}
+	manager.SetDomainACL([]string{"ads.example.com"}, types.Blacklist, true)
+	if err := manager.SetNamedIPList("partners", []string{"203.0.113.7"}, types.Whitelist, 10); err != nil {
+		t.Fatal(err)
+	}
+	manager.SetNamedDomainList("holiday", []string{"shop.example.org"}, types.Blacklist, false, 5)
+	if err := manager.SetNamedDomainListGroup("holiday", "sales"); err != nil {
+		t.Fatal(err)
+	}
+	rules, err := expr.CompileAll([]string{"port == 23 -> deny"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	manager.SetRules(rules)
+	return manager
+}
+
+// TestManagerPolicy 测试当前配置导出为策略后可以重建相同的配置
+func TestManagerPolicy(t *testing.T) {
+	manager := newPolicyFeedManager(t)
+	policy := manager.Policy()
+
+	want := Policy{
+		Version: PolicyVersion,
+		IP: &IPPolicy{
+			Type:   "blacklist",
+			Ranges: []string{"203.0.113.0/24", "10.0.0.0/8"},
+			Ports:  map[string][]int{"10.0.0.0/8": {22, 443}},
+		},
+		Domain: &DomainPolicy{Type: "blacklist", Domains: []string{"ads.example.com"}, IncludeSubdomains: true},
+		Lists: []ListPolicy{
+			{Name: "partners", Kind: "ip", Type: "whitelist", Entries: []string{"203.0.113.7"}, Priority: 10},
+			{Name: "holiday", Kind: "domain", Type: "blacklist", Entries: []string{"shop.example.org"}, Priority: 5, Group: "sales"},
+		},
+		Rules: []string{"port == 23 -> deny"},
+	}
+	if !reflect.DeepEqual(policy, want) {
+		t.Fatalf("Policy() = %+v\n期望 %+v", policy, want)
+	}
+
+	rebuilt, err := NewManagerFromPolicy(policy)
+	if err != nil {
+		t.Fatalf("NewManagerFromPolicy() 返回错误: %v", err)
+	}
+	if got := rebuilt.Policy(); !reflect.DeepEqual(got, want) {
+		t.Errorf("重建后 Policy() = %+v\n期望 %+v", got, want)
+	}
+	if empty := NewManager().Policy(); !reflect.DeepEqual(empty, Policy{Version: PolicyVersion}) {
+		t.Errorf("空Manager Policy() = %+v", empty)
+	}
+}
+
+// TestPolicyFeedChecksum 测试校验和只取决于策略内容，修订号随改变增加
+func TestPolicyFeedChecksum(t *testing.T) {
+	a := newPolicyFeedManager(t)
+	b := newPolicyFeedManager(t)
+
+	feedA, err := a.PolicyFeed()
+	if err != nil {
+		t.Fatalf("PolicyFeed() 返回错误: %v", err)
+	}
+	feedB, _ := b.PolicyFeed()
+	if feedA.Checksum != feedB.Checksum {
+		t.Errorf("相同配置的校验和不同: %s, %s", feedA.Checksum, feedB.Checksum)
+	}
+	if feedA.Revision == 0 || feedA.Revision != a.Revision() {
+		t.Errorf("Revision = %d, 期望 %d", feedA.Revision, a.Revision())
+	}
+	if _, err := feedA.Verify(); err != nil {
+		t.Errorf("Verify() 返回错误: %v", err)
+	}
+
+	a.AddIP("192.0.2.1")
+	changed, _ := a.PolicyFeed()
+	if changed.Checksum == feedA.Checksum || changed.Revision <= feedA.Revision {
+		t.Errorf("修改后 Checksum = %s, Revision = %d, 期望都改变", changed.Checksum, changed.Revision)
+	}
+
+	tampered := feedA
+	tampered.Policy = json.RawMessage(`{"version": 2}`)
+	if _, err := tampered.Verify(); !errors.Is(err, ErrPolicyFeedChecksum) {
+		t.Errorf("篡改后 Verify() = %v, 期望 ErrPolicyFeedChecksum", err)
+	}
+}
+
+// TestPolicyFeedHandler 测试HTTP接口的方法限制和条件请求
+func TestPolicyFeedHandler(t *testing.T) {
+	manager := newPolicyFeedManager(t)
+	handler := manager.PolicyFeedHandler()
+	feed, _ := manager.PolicyFeed()
+
+	tests := []struct {
+		name        string
+		method      string
+		ifNoneMatch string
+		wantStatus  int
+		wantBody    bool
+	}{
+		{"GET", http.MethodGet, "", http.StatusOK, true},
+		{"HEAD", http.MethodHead, "", http.StatusOK, false},
+		{"校验和相同", http.MethodGet, strconv.Quote(feed.Checksum), http.StatusNotModified, false},
+		{"校验和不同", http.MethodGet, `"stale"`, http.StatusOK, true},
+		{"POST", http.MethodPost, "", http.StatusMethodNotAllowed, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := httptest.NewRequest(tt.method, "/acl/policy", nil)
+			if tt.ifNoneMatch != "" {
+				r.Header.Set("If-None-Match", tt.ifNoneMatch)
+			}
+			w := httptest.NewRecorder()
+			handler.ServeHTTP(w, r)
+			if w.Code != tt.wantStatus {
+				t.Fatalf("状态码 = %d, 期望 %d", w.Code, tt.wantStatus)
+			}
+			if got := w.Body.Len() > 0; got != tt.wantBody {
+				t.Errorf("响应体非空 = %v, 期望 %v", got, tt.wantBody)
+			}
+			if tt.wantStatus == http.StatusMethodNotAllowed {
+				if allow := w.Header().Get("Allow"); allow != "GET, HEAD" {
+					t.Errorf("Allow = %q", allow)
+				}
+				return
+			}
+			if etag := w.Header().Get("ETag"); etag != strconv.Quote(feed.Checksum) {
+				t.Errorf("ETag = %s, 期望 %q", etag, feed.Checksum)
+			}
+		})
+	}
+}
+
+// TestPolicyFeedClient 测试客户端获取策略、未改变和校验和错误
+func TestPolicyFeedClient(t *testing.T) {
+	manager := newPolicyFeedManager(t)
+	server := httptest.NewServer(manager.PolicyFeedHandler())
+	defer server.Close()
+	client := PolicyFeedClient{URL: server.URL}
+	ctx := context.Background()
+
+	feed, err := client.Fetch(ctx, "")
+	if err != nil {
+		t.Fatalf("Fetch() 返回错误: %v", err)
+	}
+	policy, err := feed.Verify()
+	if err != nil || !reflect.DeepEqual(policy, manager.Policy()) {
+		t.Errorf("Verify() = %+v, %v, 期望与主实例相同", policy, err)
+	}
+	if _, err := client.Fetch(ctx, feed.Checksum); !errors.Is(err, ErrPolicyFeedNotModified) {
+		t.Errorf("Fetch(相同校验和) = %v, 期望 ErrPolicyFeedNotModified", err)
+	}
+
+	corrupt := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(PolicyFeed{Checksum: feed.Checksum, Policy: json.RawMessage(`{}`)})
+	}))
+	defer corrupt.Close()
+	if _, err := (PolicyFeedClient{URL: corrupt.URL}).Fetch(ctx, ""); !errors.Is(err, ErrPolicyFeedChecksum) {
+		t.Errorf("Fetch(内容被修改) = %v, 期望 ErrPolicyFeedChecksum", err)
+	}
+
+	missing := httptest.NewServer(http.NotFoundHandler())
+	defer missing.Close()
+	if _, err := (PolicyFeedClient{URL: missing.URL}).Fetch(ctx, ""); err == nil {
+		t.Error("Fetch(404) 应返回错误")
+	}
+
+	limited := PolicyFeedClient{URL: server.URL, MaxBytes: 16}
+	if _, err := limited.Fetch(ctx, ""); !errors.Is(err, config.ErrInputTooLarge) {
+		t.Errorf("Fetch(超过MaxBytes) = %v, 期望 config.ErrInputTooLarge", err)
+	}
+}
+
+// TestApplyPolicy 测试应用策略整体替换列表和规则，保留策略无法表示的设置
+func TestApplyPolicy(t *testing.T) {
+	source := newPolicyFeedManager(t)
+	replica := NewManager()
+	replica.AddIP("192.0.2.1")
+	replica.DisableGroup("sales")
+	changes, stop := replica.WatchChanges()
+	defer stop()
+
+	if err := replica.ApplyPolicy(source.Policy()); err != nil {
+		t.Fatalf("ApplyPolicy() 返回错误: %v", err)
+	}
+	if got, want := replica.Policy(), source.Policy(); !reflect.DeepEqual(got, want) {
+		t.Errorf("应用后 Policy() = %+v\n期望 %+v", got, want)
+	}
+	if len(changes) != 3 {
+		t.Errorf("收到 %d 个改变通知, 期望 3", len(changes))
+	}
+
+	tests := []struct {
+		name string
+		req  expr.Request
+		want types.Permission
+	}{
+		{"旧条目被替换", expr.Request{IP: "192.0.2.1"}, types.Allowed},
+		{"主列表", expr.Request{IP: "203.0.113.9"}, types.Denied},
+		{"命名列表", expr.Request{IP: "203.0.113.7"}, types.Allowed},
+		{"规则", expr.Request{IP: "198.51.100.1", Port: 23}, types.Denied},
+		{"停用的规则组保持停用", expr.Request{Domain: "shop.example.org"}, types.Allowed},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if perm, err := replica.CheckRequest(tt.req); err != nil || perm != tt.want {
+				t.Errorf("CheckRequest(%s) = %v, %v; 期望 %v", tt.req, perm, err, tt.want)
+			}
+		})
+	}
+
+	before := replica.Policy()
+	if err := replica.ApplyPolicy(Policy{IP: &IPPolicy{Type: "greylist"}}); err == nil {
+		t.Error("无效的策略应返回错误")
+	}
+	if !reflect.DeepEqual(replica.Policy(), before) {
+		t.Error("无效的策略不应改变配置")
+	}
+}
+
+// TestFollowPolicyFeed 测试其他实例轮询策略并与主实例收敛
+func TestFollowPolicyFeed(t *testing.T) {
+	primary := newPolicyFeedManager(t)
+	server := httptest.NewServer(primary.PolicyFeedHandler())
+	defer server.Close()
+
+	replica := NewManager()
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error, 1)
+	go func() { done <- replica.FollowPolicyFeed(ctx, PolicyFeedClient{URL: server.URL}, 5*time.Millisecond) }()
+
+	waitFor := func() {
+		t.Helper()
+		want, _ := primary.PolicyFeed()
+		deadline := time.Now().Add(2 * time.Second)
+		for {
+			got, _ := replica.PolicyFeed()
+			if got.Checksum == want.Checksum {
+				return
+			}
+			if time.Now().After(deadline) {
+				t.Fatalf("副本的校验和 = %s, 期望 %s", got.Checksum, want.Checksum)
+			}
+			time.Sleep(time.Millisecond)
+		}
+	}
+	waitFor()
+	primary.AddIP("192.0.2.1")
+	waitFor()
+	if perm, _ := replica.CheckIP("192.0.2.1"); perm != types.Denied {
+		t.Errorf("副本 CheckIP(192.0.2.1) = %v, 期望 Denied", perm)
+	}
+
+	cancel()
+	if err := <-done; !errors.Is(err, context.Canceled) {
+		t.Errorf("FollowPolicyFeed() = %v, 期望 context.Canceled", err)
+	}
+}
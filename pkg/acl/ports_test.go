@@ -0,0 +1,139 @@
+package acl
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/cyberspacesec/go-acl/pkg/expr"
+	"github.com/cyberspacesec/go-acl/pkg/ip"
+	"github.com/cyberspacesec/go-acl/pkg/types"
+)
+
+// TestSetIPRulePorts 测试附加在IP范围上的允许端口由CheckRequest求值
+func TestSetIPRulePorts(t *testing.T) {
+	manager := NewManager()
+	if err := manager.SetIPACL([]string{"10.7.0.0/16", "10.8.0.0/16", "2001:db8::/32"}, types.Whitelist); err != nil {
+		t.Fatalf("SetIPACL() 返回错误: %v", err)
+	}
+	for ipRange, ports := range map[string][]int{
+		"10.7.0.0/16":   {5432},
+		"10.7.9.0/24":   {5432, 6432},
+		"2001:db8::/48": {443},
+	} {
+		if err := manager.SetIPRulePorts(ipRange, ports...); err != nil {
+			t.Fatalf("SetIPRulePorts(%q) 返回错误: %v", ipRange, err)
+		}
+	}
+
+	tests := []struct {
+		name       string
+		req        expr.Request
+		want       types.Permission
+		wantRuleID string
+	}{
+		{"允许的端口", expr.Request{IP: "10.7.1.1", Port: 5432}, types.Allowed, "10.7.0.0/16"},
+		{"不允许的端口", expr.Request{IP: "10.7.1.1", Port: 22}, types.Denied, "10.7.0.0/16"},
+		{"没有端口", expr.Request{IP: "10.7.1.1"}, types.Denied, "10.7.0.0/16"},
+		{"更具体的范围优先", expr.Request{IP: "10.7.9.5", Port: 6432}, types.Allowed, "10.7.9.0/24"},
+		{"没有端口限制的范围", expr.Request{IP: "10.8.1.1", Port: 22}, types.Allowed, "10.8.0.0/16"},
+		{"IP ACL拒绝的IP", expr.Request{IP: "192.0.2.1", Port: 5432}, types.Denied, ""},
+		{"IPv6范围", expr.Request{IP: "2001:db8::1", Port: 80}, types.Denied, "2001:db8::/48"},
+		{"IPv4映射的IPv6地址", expr.Request{IP: "::ffff:10.7.1.1", Port: 22}, types.Denied, "10.7.0.0/16"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, err := manager.CheckRequestDetailed(context.Background(), tt.req)
+			if err != nil {
+				t.Fatalf("CheckRequestDetailed() 返回错误: %v", err)
+			}
+			if result.Decision != tt.want {
+				t.Errorf("CheckRequestDetailed(%v) = %v, 期望 %v", tt.req, result.Decision, tt.want)
+			}
+			if result.Source == "ip_ports" {
+				if result.RuleID != tt.wantRuleID || result.Reason != types.ReasonPortNotAllowed {
+					t.Errorf("端口拒绝的结果 = %+v, 期望RuleID %q、原因port_not_allowed", result, tt.wantRuleID)
+				}
+			} else if tt.want == types.Denied && tt.wantRuleID != "" {
+				t.Errorf("Source = %q, 期望 \"ip_ports\"", result.Source)
+			}
+		})
+	}
+
+	// 只检查IP的方法不受影响
+	if perm, err := manager.CheckIP("10.7.1.1"); err != nil || perm != types.Allowed {
+		t.Errorf("CheckIP(10.7.1.1) = %v, %v, 期望 Allowed", perm, err)
+	}
+
+	// 规则表达式先于端口限制求值
+	rules, err := expr.CompileAll([]string{"ip in 10.7.0.0/16 && port == 22 -> allow"})
+	if err != nil {
+		t.Fatalf("CompileAll() 返回错误: %v", err)
+	}
+	manager.SetRules(rules)
+	if perm, _ := manager.CheckRequest(expr.Request{IP: "10.7.1.1", Port: 22}); perm != types.Allowed {
+		t.Errorf("规则允许的请求 = %v, 期望 Allowed", perm)
+	}
+}
+
+// TestSetIPRulePortsUpdate 测试替换、取消和查询端口限制
+func TestSetIPRulePortsUpdate(t *testing.T) {
+	manager := NewManager()
+	if err := manager.SetIPACL([]string{"10.0.0.0/8"}, types.Blacklist); err != nil {
+		t.Fatalf("SetIPACL() 返回错误: %v", err)
+	}
+	manager.SetIPRulePorts("172.16.0.1", 443, 80)
+	manager.SetIPRulePorts("172.16.0.0/12", 22)
+	// 同一网段的不同写法替换原来的限制
+	manager.SetIPRulePorts("172.16.5.0/12", 8080, 8443)
+
+	want := map[string][]int{"172.16.0.1": {80, 443}, "172.16.5.0/12": {8080, 8443}}
+	if got := manager.GetIPRulePorts(); !reflect.DeepEqual(got, want) {
+		t.Errorf("GetIPRulePorts() = %v, 期望 %v", got, want)
+	}
+	// 黑名单中允许的IP同样受端口限制
+	if perm, _ := manager.CheckRequest(expr.Request{IP: "172.16.0.1", Port: 8080}); perm != types.Denied {
+		t.Errorf("CheckRequest(172.16.0.1:8080) = %v, 期望 Denied", perm)
+	}
+
+	manager.SetIPRulePorts("172.16.0.1")
+	if perm, _ := manager.CheckRequest(expr.Request{IP: "172.16.0.1", Port: 8080}); perm != types.Allowed {
+		t.Errorf("取消单个IP的限制后 CheckRequest(172.16.0.1:8080) = %v, 期望 Allowed（属于/12的允许端口）", perm)
+	}
+
+	manager.Reset()
+	if got := manager.GetIPRulePorts(); len(got) != 0 {
+		t.Errorf("Reset() 后 GetIPRulePorts() = %v, 期望为空", got)
+	}
+
+	if err := manager.SetIPRulePorts("not-an-ip", 80); !errors.Is(err, ip.ErrInvalidIP) {
+		t.Errorf("SetIPRulePorts(无效范围) 错误 = %v, 期望 ip.ErrInvalidIP", err)
+	}
+	if err := manager.SetIPRulePorts("10.0.0.1", 0); err == nil {
+		t.Error("SetIPRulePorts(端口0) 应返回错误")
+	}
+}
+
+// TestPolicyIPPorts 测试策略文件中的端口限制
+func TestPolicyIPPorts(t *testing.T) {
+	policy, err := ReadPolicy(strings.NewReader(`{
+		"ip": {"type": "whitelist", "ranges": ["10.7.0.0/16"], "ports": {"10.7.0.0/16": [5432]}}
+	}`))
+	if err != nil {
+		t.Fatalf("ReadPolicy() 返回错误: %v", err)
+	}
+	manager, err := NewManagerFromPolicy(policy)
+	if err != nil {
+		t.Fatalf("NewManagerFromPolicy() 返回错误: %v", err)
+	}
+	if perm, _ := manager.CheckRequest(expr.Request{IP: "10.7.0.1", Port: 22}); perm != types.Denied {
+		t.Errorf("CheckRequest(10.7.0.1:22) = %v, 期望 Denied", perm)
+	}
+
+	policy.IP.Ports = map[string][]int{"10.7.0.0/16": {70000}}
+	if _, err := NewManagerFromPolicy(policy); err == nil {
+		t.Error("NewManagerFromPolicy(无效端口) 应返回错误")
+	}
+}
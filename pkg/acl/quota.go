@@ -0,0 +1,315 @@
+package acl
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/cyberspacesec/go-acl/pkg/domain"
+	"github.com/cyberspacesec/go-acl/pkg/ip"
+)
+
+// ErrQuotaExceeded 表示添加规则后列表的规则数量将超出配额
+var ErrQuotaExceeded = errors.New("超出列表的规则数量配额")
+
+// 命名列表的默认配额使用的组件名称，对没有单独设置配额的命名列表生效
+const (
+	// DefaultIPListQuota 是所有命名IP列表的默认配额
+	DefaultIPListQuota = "ip_list:*"
+	// DefaultDomainListQuota 是所有命名域名列表的默认配额
+	DefaultDomainListQuota = "domain_list:*"
+)
+
+// QuotaUsage 表示一个ACL的配额使用情况
+//
+// 字段说明:
+//   - Component: 组件名称，与ComponentUsage.Name相同，如"ip_acl"、"domain_list:tenant-42"
+//   - Used: 当前的规则数量
+//   - Limit: 规则数量上限，0表示不限制
+type QuotaUsage struct {
+	Component string `json:"component"`
+	Used      int    `json:"used"`
+	Limit     int    `json:"limit,omitempty"`
+}
+
+// Remaining 返回还能添加的规则数量，不限制时返回-1
+func (u QuotaUsage) Remaining() int {
+	if u.Limit <= 0 {
+		return -1
+	}
+	if u.Used >= u.Limit {
+		return 0
+	}
+	return u.Limit - u.Used
+}
+
+// SetListQuota 设置ACL的规则数量上限
+//
+// 参数:
+//   - component: 组件名称，主列表为"ip_acl"、"domain_acl"，命名列表为"ip_list:名称"、"domain_list:名称"；
+//     DefaultIPListQuota和DefaultDomainListQuota为没有单独设置配额的命名列表设置默认配额
+//   - limit: 规则数量上限，小于等于0表示取消配额
+//
+// 配额在以下方法添加规则时检查，超出时返回包装了ErrQuotaExceeded的错误，列表保持不变:
+//   - AddIP、AddDomain: 按去重后新增的规则计算
+//   - AddNamedIPListEntries、AddNamedDomainListEntries: 同上
+//   - SetNamedIPList: 按替换后的规则数量计算
+//
+// 配额不影响已有的规则，也不限制从文件、预定义集合、订阅源和快照加载的规则，
+// 这些来源由运维方控制。没有返回错误的方法（如SetNamedDomainList）可以先调用CheckQuota。
+//
+// 示例:
+//
+//	// 每个租户的命名列表最多1000条规则，付费租户10000条
+//	manager.SetListQuota(acl.DefaultIPListQuota, 1000)
+//	manager.SetListQuota("ip_list:tenant-42", 10000)
+//
+//	if err := manager.AddNamedIPListEntries("tenant-7", cidrs...); errors.Is(err, acl.ErrQuotaExceeded) {
+//	    http.Error(w, err.Error(), http.StatusForbidden)
+//	}
+func (m *Manager) SetListQuota(component string, limit int) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if limit <= 0 {
+		if _, ok := m.quotas[component]; !ok {
+			return
+		}
+		quotas := make(map[string]int, len(m.quotas))
+		for k, v := range m.quotas {
+			if k != component {
+				quotas[k] = v
+			}
+		}
+		m.quotas = quotas
+		return
+	}
+	quotas := make(map[string]int, len(m.quotas)+1)
+	for k, v := range m.quotas {
+		quotas[k] = v
+	}
+	quotas[component] = limit
+	m.quotas = quotas
+}
+
+// CheckQuota 检查ACL包含count条规则时是否超出配额
+//
+// 参数:
+//   - component: 组件名称，见SetListQuota
+//   - count: 规则数量
+//
+// 返回:
+//   - error: 超出配额时返回包装了ErrQuotaExceeded的错误
+//
+// 用于在调用不检查配额的方法前检查，例如整体替换命名域名列表。
+//
+// 示例:
+//
+//	if err := manager.CheckQuota("domain_list:tenant-7", len(domains)); err != nil {
+//	    return err
+//	}
+//	manager.SetNamedDomainList("tenant-7", domains, types.Blacklist, true, 100)
+func (m *Manager) CheckQuota(component string, count int) error {
+	return quotaError(component, m.quotaLimit(component), count)
+}
+
+// QuotaUsage 返回ACL的配额使用情况
+//
+// 参数:
+//   - component: 组件名称，如"ip_acl"、"domain_list:tenant-42"
+//
+// 返回:
+//   - QuotaUsage: 当前的规则数量和上限
+//   - bool: ACL不存在时返回false
+func (m *Manager) QuotaUsage(component string) (QuotaUsage, bool) {
+	for _, u := range m.QuotaUsages() {
+		if u.Component == component {
+			return u, true
+		}
+	}
+	return QuotaUsage{}, false
+}
+
+// QuotaUsages 返回所有已配置的ACL的配额使用情况
+//
+// 返回:
+//   - []QuotaUsage: 依次为IP主列表、命名IP列表、域名主列表、命名域名列表，与Usage的顺序相同
+func (m *Manager) QuotaUsages() []QuotaUsage {
+	m.mu.RLock()
+	quotas := m.quotas
+	m.mu.RUnlock()
+
+	var usages []QuotaUsage
+	add := func(component string, used int) {
+		usages = append(usages, QuotaUsage{Component: component, Used: used, Limit: lookupQuota(quotas, component)})
+	}
+
+	m.ipMu.RLock()
+	if m.ipACL != nil {
+		add("ip_acl", len(m.ipACL.GetIPRanges()))
+	}
+	for _, l := range m.ipLists {
+		add("ip_list:"+l.name, len(l.ip.GetIPRanges()))
+	}
+	m.ipMu.RUnlock()
+
+	m.domainMu.RLock()
+	if m.domainACL != nil {
+		add("domain_acl", len(m.domainACL.GetDomains()))
+	}
+	for _, l := range m.domainLists {
+		add("domain_list:"+l.name, len(l.domain.GetDomains()))
+	}
+	m.domainMu.RUnlock()
+
+	return usages
+}
+
+// AddNamedIPListEntries 向命名IP列表添加一个或多个IP或CIDR
+//
+// 参数:
+//   - name: 列表名称
+//   - ipRanges: 要添加的IP或CIDR
+//
+// 返回:
+//   - error: 列表不存在时返回ErrListNotFound，超出配额时返回包装了ErrQuotaExceeded的错误，
+//     IP无效时返回ip.ErrInvalidIP等错误
+//
+// 已经是临时条目（见AddNamedIPListEntriesTTL）的条目成为永久条目。
+func (m *Manager) AddNamedIPListEntries(name string, ipRanges ...string) error {
+	component := "ip_list:" + name
+	if err := m.intercept(Change{Op: ChangeAdd, Kind: "ip", Component: component, Entries: ipRanges}); err != nil {
+		return err
+	}
+	limit := m.quotaLimit(component)
+	now := m.Clock().Now()
+
+	m.ipMu.Lock()
+	defer m.ipMu.Unlock()
+
+	l := findList(m.ipLists, name)
+	if l == nil {
+		return ErrListNotFound
+	}
+	if err := quotaError(component, limit, len(l.ip.GetIPRanges())+newIPEntries(l.ip, ipRanges)); err != nil {
+		return err
+	}
+	l.modified = now
+	m.notifyChange("ip", component, now)
+	err := l.ip.Add(ipRanges...)
+	l.clearDeadlines(ipKeys(ipRanges))
+	return err
+}
+
+// AddNamedDomainListEntries 向命名域名列表添加一个或多个域名
+//
+// 参数:
+//   - name: 列表名称
+//   - domains: 要添加的域名，格式无效的域名被忽略
+//
+// 返回:
+//   - error: 列表不存在时返回ErrListNotFound，超出配额时返回包装了ErrQuotaExceeded的错误
+//
+// 已经是临时条目的条目成为永久条目。
+func (m *Manager) AddNamedDomainListEntries(name string, domains ...string) error {
+	component := "domain_list:" + name
+	if err := m.intercept(Change{Op: ChangeAdd, Kind: "domain", Component: component, Entries: domains}); err != nil {
+		return err
+	}
+	limit := m.quotaLimit(component)
+	now := m.Clock().Now()
+
+	m.domainMu.Lock()
+	defer m.domainMu.Unlock()
+
+	l := findList(m.domainLists, name)
+	if l == nil {
+		return ErrListNotFound
+	}
+	if err := quotaError(component, limit, len(l.domain.GetDomains())+newDomainEntries(l.domain, domains)); err != nil {
+		return err
+	}
+	l.domain.Add(domains...)
+	l.clearDeadlines(domainKeys(domains))
+	l.modified = now
+	m.notifyChange("domain", component, now)
+	return nil
+}
+
+// quotaLimit 返回组件的配额，0表示不限制
+func (m *Manager) quotaLimit(component string) int {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return lookupQuota(m.quotas, component)
+}
+
+// lookupQuota 返回组件的配额，命名列表没有单独的配额时使用默认配额
+func lookupQuota(quotas map[string]int, component string) int {
+	if limit, ok := quotas[component]; ok {
+		return limit
+	}
+	switch {
+	case strings.HasPrefix(component, "ip_list:"):
+		return quotas[DefaultIPListQuota]
+	case strings.HasPrefix(component, "domain_list:"):
+		return quotas[DefaultDomainListQuota]
+	}
+	return 0
+}
+
+// quotaError 在规则数量超出配额时返回错误
+func quotaError(component string, limit, count int) error {
+	if limit > 0 && count > limit {
+		return fmt.Errorf("%w: %s 最多%d条规则，添加后为%d条", ErrQuotaExceeded, component, limit, count)
+	}
+	return nil
+}
+
+// findList 返回指定名称的列表，不存在时返回nil，调用方需持有对应的锁
+func findList(lists []namedList, name string) *namedList {
+	for i := range lists {
+		if lists[i].name == name {
+			return &lists[i]
+		}
+	}
+	return nil
+}
+
+// newIPEntries 返回ipRanges中不在列表里的规则数量，与ip.IPACL.Add的去重方式相同
+func newIPEntries(acl *ip.IPACL, ipRanges []string) int {
+	seen := make(map[string]struct{})
+	for _, r := range acl.GetIPRanges() {
+		seen[r] = struct{}{}
+	}
+	count := 0
+	for _, r := range ipRanges {
+		r = strings.TrimSpace(r)
+		if _, ok := seen[r]; ok || r == "" {
+			continue
+		}
+		seen[r] = struct{}{}
+		count++
+	}
+	return count
+}
+
+// newDomainEntries 返回domains中不在列表里的有效规则数量，与domain.DomainACL.Add的去重方式相同
+func newDomainEntries(acl *domain.DomainACL, domains []string) int {
+	seen := make(map[string]struct{})
+	for _, d := range acl.GetDomains() {
+		seen[d] = struct{}{}
+	}
+	count := 0
+	for _, d := range domains {
+		rule, err := domain.ParseRule(d)
+		if err != nil {
+			continue
+		}
+		if _, ok := seen[rule.String()]; ok {
+			continue
+		}
+		seen[rule.String()] = struct{}{}
+		count++
+	}
+	return count
+}
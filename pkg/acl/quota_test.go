@@ -0,0 +1,123 @@
+package acl
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/cyberspacesec/go-acl/pkg/types"
+)
+
+// TestListQuota 测试添加规则时的配额检查
+func TestListQuota(t *testing.T) {
+	manager := NewManager()
+	if err := manager.SetIPACL([]string{"10.0.0.1"}, types.Blacklist); err != nil {
+		t.Fatalf("SetIPACL() 返回错误: %v", err)
+	}
+	manager.SetDomainACL([]string{"example.com"}, types.Blacklist, true)
+	if err := manager.SetNamedIPList("tenant-1", []string{"203.0.113.1"}, types.Blacklist, 0); err != nil {
+		t.Fatalf("SetNamedIPList() 返回错误: %v", err)
+	}
+	manager.SetNamedDomainList("tenant-1", []string{"ads.example.net"}, types.Blacklist, true, 0)
+
+	manager.SetListQuota("ip_acl", 2)
+	manager.SetListQuota("domain_acl", 2)
+	manager.SetListQuota(DefaultIPListQuota, 2)
+	manager.SetListQuota(DefaultDomainListQuota, 2)
+
+	tests := []struct {
+		name    string
+		add     func() error
+		wantErr error
+	}{
+		{"IP主列表在配额内", func() error { return manager.AddIP("10.0.0.2") }, nil},
+		{"重复的IP不计入配额", func() error { return manager.AddIP(" 10.0.0.1", "10.0.0.2") }, nil},
+		{"IP主列表超出配额", func() error { return manager.AddIP("10.0.0.3") }, ErrQuotaExceeded},
+		{"域名主列表超出配额", func() error { return manager.AddDomain("a.example.org", "b.example.org") }, ErrQuotaExceeded},
+		{"标准化后重复的域名不计入配额", func() error { return manager.AddDomain("https://www.example.com", "a.example.org") }, nil},
+		{"命名IP列表在默认配额内", func() error { return manager.AddNamedIPListEntries("tenant-1", "203.0.113.2") }, nil},
+		{"命名IP列表超出默认配额", func() error { return manager.AddNamedIPListEntries("tenant-1", "203.0.113.3") }, ErrQuotaExceeded},
+		{"命名域名列表超出默认配额", func() error {
+			return manager.AddNamedDomainListEntries("tenant-1", "x.example.net", "y.example.net")
+		}, ErrQuotaExceeded},
+		{"替换命名IP列表超出默认配额", func() error {
+			return manager.SetNamedIPList("tenant-2", []string{"198.51.100.1", "198.51.100.2", "198.51.100.3"}, types.Blacklist, 0)
+		}, ErrQuotaExceeded},
+		{"命名列表不存在", func() error { return manager.AddNamedIPListEntries("missing", "192.0.2.1") }, ErrListNotFound},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := tt.add(); !errors.Is(err, tt.wantErr) {
+				t.Errorf("错误 = %v, 期望 %v", err, tt.wantErr)
+			}
+		})
+	}
+
+	// 超出配额时不添加任何规则
+	if perm, _ := manager.CheckIP("10.0.0.3"); perm != types.Allowed {
+		t.Errorf("超出配额的IP被添加: CheckIP(10.0.0.3) = %v", perm)
+	}
+	if got := len(manager.GetDomains()); got != 2 {
+		t.Errorf("域名数量 = %d, 期望 2", got)
+	}
+	if len(manager.NamedIPLists()) != 1 {
+		t.Errorf("超出配额的命名列表被加入: %+v", manager.NamedIPLists())
+	}
+
+	// 单独的配额优先于默认配额，取消配额后不再限制
+	manager.SetListQuota("ip_list:tenant-1", 3)
+	if err := manager.AddNamedIPListEntries("tenant-1", "203.0.113.3"); err != nil {
+		t.Errorf("单独配额内 AddNamedIPListEntries() 返回错误: %v", err)
+	}
+	manager.SetListQuota("ip_acl", 0)
+	if err := manager.AddIP("10.0.0.3", "10.0.0.4"); err != nil {
+		t.Errorf("取消配额后 AddIP() 返回错误: %v", err)
+	}
+}
+
+// TestQuotaUsage 测试配额使用情况
+func TestQuotaUsage(t *testing.T) {
+	manager := NewManager()
+	if _, ok := manager.QuotaUsage("ip_acl"); ok {
+		t.Error("未配置时 QuotaUsage(ip_acl) 返回了结果")
+	}
+
+	if err := manager.SetIPACL([]string{"10.0.0.1", "10.0.0.2"}, types.Blacklist); err != nil {
+		t.Fatalf("SetIPACL() 返回错误: %v", err)
+	}
+	manager.SetNamedDomainList("tenant-1", []string{"a.example.net"}, types.Blacklist, true, 0)
+	manager.SetListQuota("ip_acl", 5)
+	manager.SetListQuota(DefaultDomainListQuota, 1)
+
+	tests := []struct {
+		component string
+		want      QuotaUsage
+		remaining int
+	}{
+		{"ip_acl", QuotaUsage{Component: "ip_acl", Used: 2, Limit: 5}, 3},
+		{"domain_list:tenant-1", QuotaUsage{Component: "domain_list:tenant-1", Used: 1, Limit: 1}, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.component, func(t *testing.T) {
+			got, ok := manager.QuotaUsage(tt.component)
+			if !ok || got != tt.want {
+				t.Fatalf("QuotaUsage() = %+v, %v, 期望 %+v", got, ok, tt.want)
+			}
+			if got.Remaining() != tt.remaining {
+				t.Errorf("Remaining() = %d, 期望 %d", got.Remaining(), tt.remaining)
+			}
+		})
+	}
+
+	if got := len(manager.QuotaUsages()); got != 2 {
+		t.Errorf("QuotaUsages() 数量 = %d, 期望 2", got)
+	}
+	if r := (QuotaUsage{Used: 10}).Remaining(); r != -1 {
+		t.Errorf("不限制时 Remaining() = %d, 期望 -1", r)
+	}
+	if err := manager.CheckQuota("domain_list:tenant-1", 2); !errors.Is(err, ErrQuotaExceeded) {
+		t.Errorf("CheckQuota() 错误 = %v, 期望 ErrQuotaExceeded", err)
+	}
+	if err := manager.CheckQuota("domain_acl", 1000); err != nil {
+		t.Errorf("没有配额时 CheckQuota() 返回错误: %v", err)
+	}
+}
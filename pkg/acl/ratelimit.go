@@ -0,0 +1,224 @@
+package acl
+
+import (
+	"math"
+	"net"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/cyberspacesec/go-acl/pkg/types"
+)
+
+// RateLimiter 是按客户端IP限流的令牌桶限流器
+//
+// 每个IP拥有独立的令牌桶，桶容量为burst，每秒补充rate个令牌。
+// 当关联的Manager配置了IP白名单时，白名单中的IP不受限流约束，
+// 这样可信来源（如内部服务、健康检查）与ACL共用同一份配置。
+//
+// RateLimiter可以安全地在多个goroutine中并发使用。
+type RateLimiter struct {
+	manager *Manager
+	rate    float64
+	burst   float64
+
+	mu      sync.Mutex
+	buckets map[string]*tokenBucket
+	// clock 是时间源，nil表示跟随manager的时间源
+	clock types.Clock
+}
+
+// tokenBucket 单个IP的令牌桶状态
+type tokenBucket struct {
+	tokens float64
+	last   time.Time
+}
+
+// NewRateLimiter 创建一个按IP限流的令牌桶限流器
+//
+// 参数:
+//   - manager: 提供豁免名单的ACL管理器，为nil时不豁免任何IP
+//   - rate: 每秒补充的令牌数（即每个IP的平均请求速率）
+//   - burst: 令牌桶容量（即每个IP允许的突发请求数），小于1时按1处理
+//
+// 返回:
+//   - *RateLimiter: 限流器实例
+//
+// 示例:
+//
+//	// 每个IP每秒10个请求，允许突发20个，IP白名单中的地址不限流
+//	limiter := acl.NewRateLimiter(manager, 10, 20)
+//	if !limiter.Allow(clientIP) {
+//	    http.Error(w, "Too Many Requests", http.StatusTooManyRequests)
+//	    return
+//	}
+func NewRateLimiter(manager *Manager, rate float64, burst int) *RateLimiter {
+	if burst < 1 {
+		burst = 1
+	}
+	return &RateLimiter{
+		manager: manager,
+		rate:    rate,
+		burst:   float64(burst),
+		buckets: make(map[string]*tokenBucket),
+	}
+}
+
+// SetClock 设置限流器使用的时间源
+//
+// 参数:
+//   - clock: 时间源，nil表示跟随Manager.SetClock设置的时间源（没有Manager时使用types.SystemClock）
+//
+// 注意: 此方法不能与Allow、Cleanup并发调用。
+func (l *RateLimiter) SetClock(clock types.Clock) {
+	l.clock = clock
+}
+
+// now 返回时间源的当前时间
+func (l *RateLimiter) now() time.Time {
+	if l.clock != nil {
+		return l.clock.Now()
+	}
+	if l.manager != nil {
+		return l.manager.Clock().Now()
+	}
+	return types.SystemClock.Now()
+}
+
+// Allow 判断来自指定IP的请求是否允许通过，允许时消耗一个令牌
+//
+// 参数:
+//   - ip: 客户端IP地址
+//
+// 返回:
+//   - bool: true表示允许，false表示超出速率限制
+//
+// 豁免的IP（参见Exempt）始终返回true且不消耗令牌。
+// 同一地址的不同写法（如"::ffff:1.2.3.4"和"1.2.3.4"）共享同一个令牌桶。
+func (l *RateLimiter) Allow(ip string) bool {
+	if l.Exempt(ip) {
+		return true
+	}
+
+	key := rateLimitKey(ip)
+	now := l.now()
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	b, ok := l.buckets[key]
+	if !ok {
+		b = &tokenBucket{tokens: l.burst, last: now}
+		l.buckets[key] = b
+	} else if elapsed := now.Sub(b.last).Seconds(); elapsed > 0 {
+		b.tokens = math.Min(l.burst, b.tokens+elapsed*l.rate)
+		b.last = now
+	}
+
+	if b.tokens < 1 {
+		return false
+	}
+	b.tokens--
+	return true
+}
+
+// Exempt 判断IP是否免于限流
+//
+// 参数:
+//   - ip: 客户端IP地址
+//
+// 返回:
+//   - bool: 当Manager配置了IP白名单且该IP在白名单中时返回true
+//
+// 黑名单模式下没有"可信"的概念，因此不豁免任何IP。
+func (l *RateLimiter) Exempt(ip string) bool {
+	if l.manager == nil {
+		return false
+	}
+
+	l.manager.ipMu.RLock()
+	defer l.manager.ipMu.RUnlock()
+
+	if l.manager.ipACL == nil || l.manager.ipACL.GetListType() != types.Whitelist {
+		return false
+	}
+	perm, err := l.manager.ipACL.Check(ip)
+	return err == nil && perm == types.Allowed
+}
+
+// Cleanup 删除长时间未使用的令牌桶，释放内存
+//
+// 参数:
+//   - idle: 空闲时长，超过此时长未访问的令牌桶会被删除
+//
+// 返回:
+//   - int: 删除的令牌桶数量
+//
+// 空闲时长足够令牌桶重新填满时，删除令牌桶不会改变限流结果。
+// 建议在后台定期调用，例如:
+//
+//	go func() {
+//	    for range time.Tick(time.Minute) {
+//	        limiter.Cleanup(10 * time.Minute)
+//	    }
+//	}()
+func (l *RateLimiter) Cleanup(idle time.Duration) int {
+	now := l.now()
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	removed := 0
+	for key, b := range l.buckets {
+		if now.Sub(b.last) >= idle {
+			delete(l.buckets, key)
+			removed++
+		}
+	}
+	return removed
+}
+
+// Middleware 返回对请求按客户端IP限流的HTTP中间件
+//
+// 参数:
+//   - next: 被保护的处理器
+//
+// 返回:
+//   - http.Handler: 超出限制时返回429 Too Many Requests，否则调用next
+//
+// 客户端IP取自r.RemoteAddr。部署在反向代理之后时，应先由前置中间件
+// 将RemoteAddr改写为真实客户端地址（可以用realip.ParseForwardedChain确定）。
+//
+// 示例:
+//
+//	limiter := acl.NewRateLimiter(manager, 10, 20)
+//	http.ListenAndServe(":8080", limiter.Middleware(mux))
+func (l *RateLimiter) Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !l.Allow(remoteIP(r)) {
+			w.Header().Set("Retry-After", "1")
+			http.Error(w, http.StatusText(http.StatusTooManyRequests), http.StatusTooManyRequests)
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// rateLimitKey 返回IP在令牌桶表中的键，有效IP使用规范化形式
+func rateLimitKey(ip string) string {
+	ip = strings.TrimSpace(ip)
+	if parsed := net.ParseIP(ip); parsed != nil {
+		return parsed.String()
+	}
+	return ip
+}
+
+// remoteIP 从请求的RemoteAddr中提取客户端IP
+func remoteIP(r *http.Request) string {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+	return host
+}
@@ -0,0 +1,115 @@
+package acl
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/cyberspacesec/go-acl/pkg/types"
+)
+
+// TestRateLimiter 测试令牌桶限流和白名单豁免
+func TestRateLimiter(t *testing.T) {
+	manager := NewManager()
+	if err := manager.SetIPACL([]string{"10.0.0.0/8"}, types.Whitelist); err != nil {
+		t.Fatalf("SetIPACL() 返回错误: %v", err)
+	}
+
+	clock := types.NewManualClock(time.Unix(1700000000, 0))
+	manager.SetClock(clock)
+	limiter := NewRateLimiter(manager, 2, 3)
+
+	// 突发容量
+	for i := 0; i < 3; i++ {
+		if !limiter.Allow("8.8.8.8") {
+			t.Fatalf("第%d次 Allow() = false, 期望 true", i+1)
+		}
+	}
+	if limiter.Allow("8.8.8.8") {
+		t.Error("超出突发容量后 Allow() = true, 期望 false")
+	}
+
+	// 同一地址的不同写法共享令牌桶
+	if limiter.Allow("::ffff:8.8.8.8") {
+		t.Error("IPv4映射地址应与IPv4地址共享令牌桶")
+	}
+
+	// 其他IP不受影响
+	if !limiter.Allow("1.1.1.1") {
+		t.Error("其他IP的 Allow() = false, 期望 true")
+	}
+
+	// 补充令牌: 每秒2个
+	clock.Advance(500 * time.Millisecond)
+	if !limiter.Allow("8.8.8.8") {
+		t.Error("补充令牌后 Allow() = false, 期望 true")
+	}
+	if limiter.Allow("8.8.8.8") {
+		t.Error("令牌耗尽后 Allow() = true, 期望 false")
+	}
+
+	// 白名单IP豁免
+	for i := 0; i < 10; i++ {
+		if !limiter.Allow("10.1.2.3") {
+			t.Fatal("白名单IP不应被限流")
+		}
+	}
+
+	// 清理空闲令牌桶
+	clock.Advance(time.Hour)
+	if removed := limiter.Cleanup(time.Minute); removed != 2 {
+		t.Errorf("Cleanup() = %d, 期望 2", removed)
+	}
+}
+
+// TestRateLimiterExempt 测试豁免规则
+func TestRateLimiterExempt(t *testing.T) {
+	manager := NewManager()
+
+	tests := []struct {
+		name     string
+		setup    func()
+		ip       string
+		expected bool
+	}{
+		{"未配置IP ACL", func() {}, "10.1.1.1", false},
+		{"黑名单模式不豁免", func() { manager.SetIPACL([]string{"10.0.0.0/8"}, types.Blacklist) }, "10.1.1.1", false},
+		{"白名单中的IP", func() { manager.SetIPACL([]string{"10.0.0.0/8"}, types.Whitelist) }, "10.1.1.1", true},
+		{"不在白名单中的IP", func() {}, "8.8.8.8", false},
+		{"无效IP", func() {}, "invalid", false},
+	}
+
+	limiter := NewRateLimiter(manager, 1, 1)
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.setup()
+			if got := limiter.Exempt(tt.ip); got != tt.expected {
+				t.Errorf("Exempt(%s) = %v, 期望 %v", tt.ip, got, tt.expected)
+			}
+		})
+	}
+
+	if NewRateLimiter(nil, 1, 1).Exempt("10.1.1.1") {
+		t.Error("没有Manager时不应豁免任何IP")
+	}
+}
+
+// TestRateLimiterMiddleware 测试HTTP中间件
+func TestRateLimiterMiddleware(t *testing.T) {
+	limiter := NewRateLimiter(nil, 0, 1)
+	handler := limiter.Middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+
+	codes := []int{http.StatusOK, http.StatusTooManyRequests}
+	for i, want := range codes {
+		req := httptest.NewRequest(http.MethodGet, "/", nil)
+		req.RemoteAddr = "192.0.2.1:12345"
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+		if rec.Code != want {
+			t.Errorf("第%d次请求状态码 = %d, 期望 %d", i+1, rec.Code, want)
+		}
+	}
+}
@@ -0,0 +1,35 @@
+package acl
+
+import (
+	"errors"
+
+	"github.com/cyberspacesec/go-acl/pkg/domain"
+	"github.com/cyberspacesec/go-acl/pkg/ip"
+	"github.com/cyberspacesec/go-acl/pkg/types"
+)
+
+// errorReason 返回检查错误对应的原因
+func errorReason(err error) types.Reason {
+	switch {
+	case errors.Is(err, types.ErrNoACL):
+		return types.ReasonNoACL
+	case errors.Is(err, ip.ErrInvalidIP), errors.Is(err, domain.ErrInvalidDomain), errors.Is(err, domain.ErrInvalidHostname), errors.Is(err, ErrInvalidURL):
+		return types.ReasonInvalidInput
+	case errors.Is(err, ip.ErrFamilyNotAllowed):
+		return types.ReasonDeniedFamily
+	case errors.Is(err, ErrBudgetExceeded):
+		return types.ReasonBudgetExceeded
+	case errors.Is(err, ErrHostMismatch):
+		return types.ReasonHostMismatch
+	default:
+		return types.ReasonCheckFailed
+	}
+}
+
+// domainDenyReason 返回域名列表拒绝目标的原因，区分节点策略和列表规则
+func domainDenyReason(acl *domain.DomainACL, target string) types.Reason {
+	if _, perm, ok := acl.MatchPolicy(target); ok && perm == types.Denied {
+		return types.ReasonDomainPolicy
+	}
+	return types.DenyReason("domain", acl.GetListType())
+}
@@ -0,0 +1,95 @@
+package acl
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/cyberspacesec/go-acl/pkg/expr"
+	"github.com/cyberspacesec/go-acl/pkg/ip"
+	"github.com/cyberspacesec/go-acl/pkg/types"
+)
+
+// TestCheckReasons 测试检查结果、审计事件和Explain使用相同的拒绝原因
+func TestCheckReasons(t *testing.T) {
+	blacklist := NewManager()
+	if err := blacklist.SetIPACL([]string{"10.0.0.0/8"}, types.Blacklist); err != nil {
+		t.Fatalf("SetIPACL() 返回错误: %v", err)
+	}
+	blacklist.SetDomainACL([]string{"example.com"}, types.Blacklist, true)
+	blacklist.SetNamedIPList("threat-intel", []string{"203.0.113.0/24"}, types.Blacklist, 0)
+
+	whitelist := NewManager()
+	if err := whitelist.SetIPACL([]string{"192.0.2.0/24"}, types.Whitelist); err != nil {
+		t.Fatalf("SetIPACL() 返回错误: %v", err)
+	}
+	whitelist.SetDomainACL([]string{"example.com"}, types.Whitelist, true)
+	if err := whitelist.domainACL.SetPolicy("legacy.example.com", types.Denied); err != nil {
+		t.Fatalf("SetPolicy() 返回错误: %v", err)
+	}
+	whitelist.DenyIPFamily(ip.FamilyIPv6)
+
+	listsOnly := NewManager()
+	listsOnly.SetNamedIPList("partners", []string{"198.51.100.0/24"}, types.Whitelist, 0)
+
+	tests := []struct {
+		name    string
+		manager *Manager
+		target  string
+		want    types.Reason
+	}{
+		{"IP黑名单", blacklist, "10.1.2.3", types.ReasonMatchedBlacklistIP},
+		{"IP命名黑名单", blacklist, "203.0.113.7", types.ReasonMatchedBlacklistIP},
+		{"域名黑名单", blacklist, "api.example.com", types.ReasonMatchedBlacklistDomain},
+		{"允许时没有原因", blacklist, "8.8.8.8", ""},
+		{"无效输入", blacklist, "", types.ReasonInvalidInput},
+		{"IP白名单", whitelist, "8.8.8.8", types.ReasonNotInWhitelistIP},
+		{"域名白名单", whitelist, "example.org", types.ReasonNotInWhitelistDomain},
+		{"节点策略", whitelist, "legacy.example.com", types.ReasonDomainPolicy},
+		{"地址族", whitelist, "2001:db8::1", types.ReasonDeniedFamily},
+		{"命名列表的默认结果", listsOnly, "8.8.8.8", types.ReasonDefaultDeny},
+		{"未配置ACL", listsOnly, "example.com", types.ReasonNoACL},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var event AuditEvent
+			tt.manager.SetAuditHook(func(e AuditEvent) { event = e })
+
+			result, _ := tt.manager.CheckHostDetailed(context.Background(), tt.target)
+			if result.Reason != tt.want {
+				t.Errorf("CheckHostDetailed().Reason = %q, 期望 %q", result.Reason, tt.want)
+			}
+			if event.Reason != tt.want {
+				t.Errorf("AuditEvent.Reason = %q, 期望 %q", event.Reason, tt.want)
+			}
+			if got := tt.manager.Explain(tt.target).Reason; got != tt.want {
+				t.Errorf("Explain().Reason = %q, 期望 %q", got, tt.want)
+			}
+		})
+	}
+}
+
+// TestRequestAndBudgetReasons 测试规则表达式和检查预算的拒绝原因
+func TestRequestAndBudgetReasons(t *testing.T) {
+	manager := NewManager()
+	if err := manager.SetIPACL(nil, types.Blacklist); err != nil {
+		t.Fatalf("SetIPACL() 返回错误: %v", err)
+	}
+	rules, err := expr.CompileAll([]string{"port == 22 -> deny"})
+	if err != nil {
+		t.Fatalf("CompileAll() 返回错误: %v", err)
+	}
+	manager.SetRules(rules)
+
+	result, err := manager.CheckRequestDetailed(context.Background(), expr.Request{IP: "8.8.8.8", Port: 22})
+	if err != nil || result.Reason != types.ReasonMatchedRule {
+		t.Errorf("规则拒绝时 Reason = %q, %v, 期望 %q", result.Reason, err, types.ReasonMatchedRule)
+	}
+
+	manager.SetChaos(&ChaosConfig{DelayRate: 1, Delay: time.Second, Rand: func() float64 { return 0.5 }})
+	manager.SetCheckBudget(&BudgetConfig{Timeout: time.Millisecond})
+	if result, _ := manager.CheckIPDetailed(context.Background(), "8.8.8.8"); result.Reason != types.ReasonBudgetExceeded {
+		t.Errorf("超出预算时 Reason = %q, 期望 %q", result.Reason, types.ReasonBudgetExceeded)
+	}
+}
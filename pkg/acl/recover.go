@@ -0,0 +1,75 @@
+package acl
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/cyberspacesec/go-acl/pkg/types"
+)
+
+// RecoverAuditHook 返回一个把hook中的panic转换为错误的审计处理函数
+//
+// 参数:
+//   - hook: 被包装的审计处理函数
+//   - onPanic: 发生panic时接收*types.PanicError的回调，可为nil
+//
+// 返回:
+//   - AuditHook: 发生panic时调用onPanic，检查方法照常返回结果
+//
+// 审计处理函数在检查方法返回前被同步调用，其中的panic会传播到调用CheckIP等方法的请求处理代码。
+// 处理函数依赖外部系统（日志管道、消息队列等）时建议用此函数包装。
+//
+// 示例:
+//
+//	manager.SetAuditHook(acl.RecoverAuditHook(sendToPipeline, func(err error) {
+//	    log.Printf("审计处理失败: %v", err)
+//	}))
+func RecoverAuditHook(hook AuditHook, onPanic func(error)) AuditHook {
+	return func(event AuditEvent) {
+		var err error
+		defer func() {
+			if err != nil && onPanic != nil {
+				onPanic(err)
+			}
+		}()
+		defer types.CatchPanic(&err)
+		hook(event)
+	}
+}
+
+// RecoverFeed 返回一个把source.Fetch中的panic转换为错误的订阅源
+//
+// 参数:
+//   - source: 被包装的订阅源，通常是应用自己实现的FeedSource
+//
+// 返回:
+//   - FeedSource: 发生panic时Fetch返回*types.PanicError，与其他刷新失败一样保留上一次成功加载的列表，
+//     错误信息出现在FeedStatus.LastError中
+//
+// RunFeeds在单独的goroutine中运行，未恢复的panic会使整个进程退出。
+//
+// 示例:
+//
+//	manager.SetIPFeed("vendor", acl.RecoverFeed(vendorFeed), types.Blacklist, 0, time.Hour)
+func RecoverFeed(source FeedSource) FeedSource {
+	return recoverFeed{source: source}
+}
+
+// recoverFeed 是RecoverFeed返回的订阅源
+type recoverFeed struct {
+	source FeedSource
+}
+
+// Fetch 调用被包装订阅源的Fetch，发生panic时返回*types.PanicError
+func (f recoverFeed) Fetch(ctx context.Context) (entries []string, err error) {
+	defer types.CatchPanic(&err)
+	return f.source.Fetch(ctx)
+}
+
+// String 返回被包装订阅源的描述，使FeedStatus.Source保持不变
+func (f recoverFeed) String() string {
+	if s, ok := f.source.(fmt.Stringer); ok {
+		return s.String()
+	}
+	return fmt.Sprintf("%T", f.source)
+}
@@ -0,0 +1,73 @@
+package acl
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/cyberspacesec/go-acl/pkg/expr"
+	"github.com/cyberspacesec/go-acl/pkg/types"
+)
+
+// panicFeed 在Fetch中panic的订阅源
+type panicFeed struct{}
+
+func (panicFeed) Fetch(context.Context) ([]string, error) {
+	panic("解析器缺陷")
+}
+
+// TestRecoverAuditHook 测试审计处理函数中的panic不会影响检查结果
+func TestRecoverAuditHook(t *testing.T) {
+	manager := NewManager()
+	manager.SetDomainACL([]string{"example.com"}, types.Blacklist, true)
+
+	var recovered error
+	manager.SetAuditHook(RecoverAuditHook(func(AuditEvent) {
+		panic("日志管道已关闭")
+	}, func(err error) {
+		recovered = err
+	}))
+
+	perm, err := manager.CheckDomain("api.example.com")
+	if err != nil || perm != types.Denied {
+		t.Errorf("CheckDomain() = %v, %v; 期望 Denied, nil", perm, err)
+	}
+	if !errors.Is(recovered, types.ErrPanic) {
+		t.Errorf("onPanic 收到 %v, 期望 ErrPanic", recovered)
+	}
+
+	// onPanic为nil时同样不会panic
+	manager.SetAuditHook(RecoverAuditHook(func(AuditEvent) { panic("boom") }, nil))
+	if _, err := manager.CheckDomain("example.org"); err != nil {
+		t.Errorf("CheckDomain() 返回错误: %v", err)
+	}
+}
+
+// TestRecoverFeed 测试订阅源中的panic记录为刷新失败
+func TestRecoverFeed(t *testing.T) {
+	manager := NewManager()
+	if err := manager.SetIPFeed("vendor", RecoverFeed(panicFeed{}), types.Blacklist, 0, time.Hour); err != nil {
+		t.Fatalf("SetIPFeed() 返回错误: %v", err)
+	}
+
+	if err := manager.RefreshFeed(context.Background(), "vendor"); !errors.Is(err, types.ErrPanic) {
+		t.Errorf("RefreshFeed() 错误 = %v, 期望 ErrPanic", err)
+	}
+	status := manager.FeedStatus()[0]
+	if status.LastError == "" || status.Source != "acl.panicFeed" {
+		t.Errorf("FeedStatus() = %+v", status)
+	}
+}
+
+// TestNilRules 测试规则集中的nil规则在停用规则组时也不会panic
+func TestNilRules(t *testing.T) {
+	manager := NewManager()
+	manager.SetRules(expr.RuleSet{nil, expr.MustCompile("port == 22 -> deny")})
+	manager.DisableGroup("maintenance")
+
+	perm, err := manager.CheckRequest(expr.Request{IP: "10.0.0.1", Port: 22})
+	if err != nil || perm != types.Denied {
+		t.Errorf("CheckRequest() = %v, %v; 期望 Denied, nil", perm, err)
+	}
+}
@@ -0,0 +1,312 @@
+package acl
+
+import (
+	"github.com/cyberspacesec/go-acl/pkg/domain"
+	"github.com/cyberspacesec/go-acl/pkg/ip"
+	"github.com/cyberspacesec/go-acl/pkg/types"
+)
+
+// ReloadDiff 是按差异重新加载文件时列表的改变
+//
+// 字段说明:
+//   - Added: 加入列表的条目，按文件中的顺序
+//   - Removed: 从列表移除的条目，按列表中原来的顺序
+//
+// 条目是列表中的写法（IP为去除空白的原始输入，域名为标准化后的规则），
+// 域名列表的例外与文件中一样以"!"开头。
+type ReloadDiff struct {
+	Added   []string
+	Removed []string
+}
+
+// Empty 报告文件与列表的内容是否相同
+func (d ReloadDiff) Empty() bool {
+	return len(d.Added) == 0 && len(d.Removed) == 0
+}
+
+// ReloadIPACLFromFile 从文件重新加载IP访问控制列表，只应用与当前列表的差异
+//
+// 参数:
+//   - filePath: 包含IP列表的文件路径，格式与SetIPACLFromFile相同
+//   - listType: 列表类型
+//
+// 返回:
+//   - ReloadDiff: 加入和移除的条目
+//   - error: 与SetIPACLFromFile相同的错误，出错时原有列表保持不变
+//
+// 与SetIPACLFromFile替换整个列表不同，只加入文件中新增的条目、移除文件中删去的条目，
+// 文件只有少量改动时不必为整个列表重建匹配器，订阅者也能从事件中得知具体改变了哪些条目。
+// 文件内容与列表相同时不产生改变通知，修订号不变；否则发送一个Diff为本次差异的ChangeEvent。
+// 尚未设置列表或列表类型不同时与SetIPACLFromFile相同，替换整个列表。
+//
+// 示例:
+//
+//	diff, err := manager.ReloadIPACLFromFile("./blacklist.txt", types.Blacklist)
+//	if err == nil && !diff.Empty() {
+//	    log.Printf("黑名单新增 %v，移除 %v", diff.Added, diff.Removed)
+//	}
+func (m *Manager) ReloadIPACLFromFile(filePath string, listType types.ListType) (ReloadDiff, error) {
+	loaded, err := ip.NewIPACLFromFile(filePath, listType)
+	if err == nil {
+		change := Change{Op: ChangeReload, Kind: "ip", Component: "ip_acl", ListType: listType, Entries: loaded.GetIPRanges(), Source: filePath}
+		if err := m.intercept(change); err != nil {
+			return ReloadDiff{}, err
+		}
+	}
+	now := m.Clock().Now()
+
+	m.ipMu.Lock()
+	defer m.ipMu.Unlock()
+	m.ipReload = reloadStatus{time: now, source: filePath, err: err}
+	if err != nil {
+		return ReloadDiff{}, err
+	}
+
+	var diff ReloadDiff
+	if m.ipACL == nil || m.ipACL.GetListType() != listType {
+		diff.Added = loaded.GetIPRanges()
+		if m.ipACL != nil {
+			diff.Removed = m.ipACL.GetIPRanges()
+		}
+		m.ipACL = loaded
+	} else {
+		diff = diffEntries(m.ipACL.GetIPRanges(), loaded.GetIPRanges())
+		if diff.Empty() {
+			return diff, nil
+		}
+		applyIPDiff(m.ipACL, diff)
+	}
+	m.ipModified = now
+	m.notifyDiff("ip", "ip_acl", now, diff)
+	return diff, nil
+}
+
+// ReloadDomainACLFromFile 从文件重新加载域名访问控制列表，只应用与当前列表的差异
+//
+// 参数:
+//   - filePath: 包含域名列表的文件路径，格式与SetDomainACLFromFile相同
+//   - listType: 列表类型
+//   - includeSubdomains: 是否包含子域名
+//
+// 返回:
+//   - ReloadDiff: 加入和移除的域名和例外，例外以"!"开头
+//   - error: 与SetDomainACLFromFile相同的错误，出错时原有列表保持不变
+//
+// 行为与ReloadIPACLFromFile相同。列表上的节点策略（domain.DomainACL.SetPolicy）不在文件中，
+// 重新加载后保持不变；尚未设置列表，或列表类型、includeSubdomains不同时替换整个列表。
+func (m *Manager) ReloadDomainACLFromFile(filePath string, listType types.ListType, includeSubdomains bool) (ReloadDiff, error) {
+	loaded, err := domain.NewDomainACLFromFile(filePath, listType, includeSubdomains)
+	if err == nil {
+		change := Change{Op: ChangeReload, Kind: "domain", Component: "domain_acl", ListType: listType, Entries: domainEntries(loaded), Source: filePath}
+		if err := m.intercept(change); err != nil {
+			return ReloadDiff{}, err
+		}
+	}
+	now := m.Clock().Now()
+
+	m.domainMu.Lock()
+	defer m.domainMu.Unlock()
+	m.domainReload = reloadStatus{time: now, source: filePath, err: err}
+	if err != nil {
+		return ReloadDiff{}, err
+	}
+
+	current := m.domainACL
+	var diff ReloadDiff
+	if current == nil || current.GetListType() != listType || current.IncludesSubdomains() != includeSubdomains {
+		diff.Added = domainEntries(loaded)
+		if current != nil {
+			diff.Removed = domainEntries(current)
+		}
+		m.domainACL = loaded
+	} else {
+		diff = diffEntries(domainEntries(current), domainEntries(loaded))
+		if diff.Empty() {
+			return diff, nil
+		}
+		applyDomainDiff(current, diff)
+	}
+	m.domainModified = now
+	m.notifyDiff("domain", "domain_acl", now, diff)
+	return diff, nil
+}
+
+// ReloadNamedIPListFromFile 从文件重新加载命名IP列表，只应用与当前列表的差异
+//
+// 参数:
+//   - name: 列表名称，列表必须已存在，其类型和优先级保持不变
+//   - filePath: 包含IP列表的文件路径，格式与SetIPACLFromFile相同
+//
+// 返回:
+//   - ReloadDiff: 加入和移除的条目
+//   - error: 列表不存在时返回ErrListNotFound，超出配额时返回包装了ErrQuotaExceeded的错误，
+//     其他与SetIPACLFromFile相同。出错时原有列表保持不变
+//
+// 文件中仍然存在的条目保持原状，由AddNamedIPListEntriesTTL加入的临时条目的到期时间不变；
+// 从文件中删去的条目被移除，即使它尚未到期。改变通知与ReloadIPACLFromFile相同。
+//
+// 示例:
+//
+//	manager.SetNamedIPList("partners", nil, types.Whitelist, 10)
+//	diff, err := manager.ReloadNamedIPListFromFile("partners", "/etc/acl/partners.txt")
+func (m *Manager) ReloadNamedIPListFromFile(name, filePath string) (ReloadDiff, error) {
+	ranges, err := readIPFile(filePath)
+	if err != nil {
+		return ReloadDiff{}, err
+	}
+	component := "ip_list:" + name
+	listType, _ := m.componentListType(component)
+	change := Change{Op: ChangeReload, Kind: "ip", Component: component, ListType: listType, Entries: ranges, Source: filePath}
+	if err := m.intercept(change); err != nil {
+		return ReloadDiff{}, err
+	}
+	limit := m.quotaLimit(component)
+	now := m.Clock().Now()
+
+	m.ipMu.Lock()
+	defer m.ipMu.Unlock()
+
+	l := findList(m.ipLists, name)
+	if l == nil {
+		return ReloadDiff{}, ErrListNotFound
+	}
+	if err := quotaError(component, limit, len(ranges)); err != nil {
+		return ReloadDiff{}, err
+	}
+	diff := diffEntries(l.ip.GetIPRanges(), ranges)
+	if diff.Empty() {
+		return diff, nil
+	}
+	applyIPDiff(l.ip, diff)
+	l.clearDeadlines(diff.Removed)
+	l.modified = now
+	m.notifyDiff("ip", component, now, diff)
+	return diff, nil
+}
+
+// ReloadNamedDomainListFromFile 从文件重新加载命名域名列表，只应用与当前列表的差异
+//
+// 参数:
+//   - name: 列表名称，列表必须已存在，其类型、是否包含子域名和优先级保持不变
+//   - filePath: 包含域名列表的文件路径，格式与SetDomainACLFromFile相同
+//
+// 返回:
+//   - ReloadDiff: 加入和移除的域名和例外，例外以"!"开头
+//   - error: 与ReloadNamedIPListFromFile相同的错误
+//
+// 临时条目的处理与ReloadNamedIPListFromFile相同。
+func (m *Manager) ReloadNamedDomainListFromFile(name, filePath string) (ReloadDiff, error) {
+	// 列表类型和是否包含子域名不影响文件中条目的写法，可以在加锁前读取
+	loaded, err := domain.NewDomainACLFromFile(filePath, types.Blacklist, false)
+	if err != nil {
+		return ReloadDiff{}, err
+	}
+	component := "domain_list:" + name
+	listType, _ := m.componentListType(component)
+	change := Change{
+		Op: ChangeReload, Kind: "domain", Component: component, ListType: listType,
+		Entries: domainEntries(loaded), Source: filePath,
+	}
+	if err := m.intercept(change); err != nil {
+		return ReloadDiff{}, err
+	}
+	limit := m.quotaLimit(component)
+	now := m.Clock().Now()
+
+	m.domainMu.Lock()
+	defer m.domainMu.Unlock()
+
+	l := findList(m.domainLists, name)
+	if l == nil {
+		return ReloadDiff{}, ErrListNotFound
+	}
+	if err := quotaError(component, limit, len(loaded.GetDomains())); err != nil {
+		return ReloadDiff{}, err
+	}
+	diff := diffEntries(domainEntries(l.domain), domainEntries(loaded))
+	if diff.Empty() {
+		return diff, nil
+	}
+	removed := applyDomainDiff(l.domain, diff)
+	l.clearDeadlines(removed)
+	l.modified = now
+	m.notifyDiff("domain", component, now, diff)
+	return diff, nil
+}
+
+// readIPFile 读取并校验IP列表文件，返回列表中的写法
+func readIPFile(filePath string) ([]string, error) {
+	loaded, err := ip.NewIPACLFromFile(filePath, types.Blacklist)
+	if err != nil {
+		return nil, err
+	}
+	return loaded.GetIPRanges(), nil
+}
+
+// diffEntries 返回从current变为loaded需要加入和移除的条目
+func diffEntries(current, loaded []string) ReloadDiff {
+	var diff ReloadDiff
+	before := stringSet(current)
+	after := stringSet(loaded)
+	for _, entry := range loaded {
+		if _, ok := before[entry]; !ok {
+			diff.Added = append(diff.Added, entry)
+			// 文件中重复的条目只加入一次
+			before[entry] = struct{}{}
+		}
+	}
+	for _, entry := range current {
+		if _, ok := after[entry]; !ok {
+			diff.Removed = append(diff.Removed, entry)
+		}
+	}
+	return diff
+}
+
+// applyIPDiff 在列表上应用差异，条目都已校验过，不会出错
+func applyIPDiff(acl *ip.IPACL, diff ReloadDiff) {
+	if len(diff.Removed) > 0 {
+		_ = acl.Remove(diff.Removed...)
+	}
+	if len(diff.Added) > 0 {
+		_ = acl.Add(diff.Added...)
+	}
+}
+
+// domainEntries 返回域名列表中的域名和以"!"开头的例外
+func domainEntries(acl *domain.DomainACL) []string {
+	entries := acl.GetDomains()
+	for _, e := range acl.GetExceptions() {
+		entries = append(entries, "!"+e)
+	}
+	return entries
+}
+
+// applyDomainDiff 在域名列表上应用差异，返回移除的域名（不含例外）
+func applyDomainDiff(acl *domain.DomainACL, diff ReloadDiff) []string {
+	split := func(entries []string) (domains, exceptions []string) {
+		for _, e := range entries {
+			if len(e) > 0 && e[0] == '!' {
+				exceptions = append(exceptions, e[1:])
+			} else {
+				domains = append(domains, e)
+			}
+		}
+		return domains, exceptions
+	}
+	removed, removedExceptions := split(diff.Removed)
+	added, addedExceptions := split(diff.Added)
+	if len(removed) > 0 {
+		_ = acl.Remove(removed...)
+	}
+	if len(removedExceptions) > 0 {
+		_ = acl.RemoveException(removedExceptions...)
+	}
+	if len(added) > 0 {
+		acl.Add(added...)
+	}
+	if len(addedExceptions) > 0 {
+		acl.AddException(addedExceptions...)
+	}
+	return removed
+}
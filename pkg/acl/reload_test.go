@@ -0,0 +1,195 @@
+package acl
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/cyberspacesec/go-acl/pkg/types"
+)
+
+// writeList 把内容写入临时目录中的列表文件，返回文件路径
+func writeList(t *testing.T, path, content string) string {
+	t.Helper()
+	if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+// TestReloadIPACLFromFile 测试只应用差异、相同内容不产生事件以及出错时保持原有列表
+func TestReloadIPACLFromFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "ips.txt")
+	manager := NewManager()
+	changes, stop := manager.WatchChanges()
+	defer stop()
+
+	// 尚未设置列表时加载整个文件
+	writeList(t, path, "10.0.0.0/8\n198.51.100.7 # 工单123\n")
+	diff, err := manager.ReloadIPACLFromFile(path, types.Blacklist)
+	if err != nil {
+		t.Fatalf("ReloadIPACLFromFile() 返回错误: %v", err)
+	}
+	if !reflect.DeepEqual(diff, ReloadDiff{Added: []string{"10.0.0.0/8", "198.51.100.7"}}) {
+		t.Errorf("首次加载的差异 = %+v", diff)
+	}
+	<-changes
+
+	writeList(t, path, "10.0.0.0/8\n203.0.113.0/24\n203.0.113.0/24\n")
+	revision := manager.Revision()
+	diff, err = manager.ReloadIPACLFromFile(path, types.Blacklist)
+	if err != nil {
+		t.Fatalf("ReloadIPACLFromFile() 返回错误: %v", err)
+	}
+	want := ReloadDiff{Added: []string{"203.0.113.0/24"}, Removed: []string{"198.51.100.7"}}
+	if !reflect.DeepEqual(diff, want) {
+		t.Errorf("差异 = %+v, 期望 %+v", diff, want)
+	}
+	event := <-changes
+	if event.Component != "ip_acl" || event.Diff == nil || !reflect.DeepEqual(*event.Diff, want) {
+		t.Errorf("事件 = %+v, 期望带有差异", event)
+	}
+	if got := manager.GetIPRanges(); !reflect.DeepEqual(got, []string{"10.0.0.0/8", "203.0.113.0/24"}) {
+		t.Errorf("重新加载后的列表 = %v", got)
+	}
+	if manager.Revision() != revision+1 {
+		t.Errorf("修订号 = %d, 期望 %d", manager.Revision(), revision+1)
+	}
+
+	// 内容相同时没有事件
+	revision = manager.Revision()
+	if diff, err := manager.ReloadIPACLFromFile(path, types.Blacklist); err != nil || !diff.Empty() {
+		t.Errorf("相同内容 = %+v, %v, 期望没有差异", diff, err)
+	}
+	if manager.Revision() != revision {
+		t.Error("相同内容不应增加修订号")
+	}
+
+	// 无效的文件不改变列表
+	writeList(t, path, "10.0.0.0/8\nnot-an-ip\n")
+	if _, err := manager.ReloadIPACLFromFile(path, types.Blacklist); err == nil {
+		t.Error("无效的文件应返回错误")
+	}
+	if got := manager.GetIPRanges(); len(got) != 2 {
+		t.Errorf("出错后的列表 = %v, 期望保持不变", got)
+	}
+
+	// 列表类型不同时替换整个列表
+	writeList(t, path, "10.0.0.0/8\n")
+	diff, err = manager.ReloadIPACLFromFile(path, types.Whitelist)
+	if err != nil {
+		t.Fatalf("ReloadIPACLFromFile() 返回错误: %v", err)
+	}
+	if len(diff.Added) != 1 || len(diff.Removed) != 2 {
+		t.Errorf("改变类型的差异 = %+v, 期望替换整个列表", diff)
+	}
+	if listType, _ := manager.GetIPACLType(); listType != types.Whitelist {
+		t.Errorf("列表类型 = %v, 期望白名单", listType)
+	}
+}
+
+// TestReloadDomainACLFromFile 测试域名和例外的差异，以及节点策略在重新加载后保留
+func TestReloadDomainACLFromFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "domains.txt")
+	manager := NewManager()
+	writeList(t, path, "example.com\n!status.example.com\nads.example.net\n")
+	if _, err := manager.ReloadDomainACLFromFile(path, types.Blacklist, true); err != nil {
+		t.Fatalf("ReloadDomainACLFromFile() 返回错误: %v", err)
+	}
+	if err := manager.domainACL.SetPolicy("beta.example.com", types.Allowed); err != nil {
+		t.Fatal(err)
+	}
+
+	writeList(t, path, "Example.COM\n!docs.example.com\ntracker.example.org\n")
+	diff, err := manager.ReloadDomainACLFromFile(path, types.Blacklist, true)
+	if err != nil {
+		t.Fatalf("ReloadDomainACLFromFile() 返回错误: %v", err)
+	}
+	want := ReloadDiff{
+		Added:   []string{"tracker.example.org", "!docs.example.com"},
+		Removed: []string{"ads.example.net", "!status.example.com"},
+	}
+	if !reflect.DeepEqual(diff, want) {
+		t.Errorf("差异 = %+v, 期望 %+v", diff, want)
+	}
+
+	tests := []struct {
+		domain string
+		want   types.Permission
+	}{
+		{"api.example.com", types.Denied},
+		{"docs.example.com", types.Allowed},
+		{"status.example.com", types.Denied},
+		{"ads.example.net", types.Allowed},
+		{"tracker.example.org", types.Denied},
+		{"beta.example.com", types.Allowed},
+	}
+	for _, tt := range tests {
+		if perm, _ := manager.CheckDomain(tt.domain); perm != tt.want {
+			t.Errorf("CheckDomain(%q) = %v, 期望 %v", tt.domain, perm, tt.want)
+		}
+	}
+}
+
+// TestReloadNamedListFromFile 测试重新加载命名列表时保留未改变的临时条目的到期时间
+func TestReloadNamedListFromFile(t *testing.T) {
+	manager, clock := newTTLManager(t)
+	dir := t.TempDir()
+
+	if err := manager.AddNamedIPListEntries("temp-bans", "198.51.100.7"); err != nil {
+		t.Fatal(err)
+	}
+	if err := manager.AddNamedIPListEntriesTTL("temp-bans", time.Hour, "198.51.100.8", "198.51.100.9"); err != nil {
+		t.Fatal(err)
+	}
+	ipPath := writeList(t, filepath.Join(dir, "ips.txt"), "198.51.100.7\n198.51.100.8\n203.0.113.1\n")
+	diff, err := manager.ReloadNamedIPListFromFile("temp-bans", ipPath)
+	if err != nil {
+		t.Fatalf("ReloadNamedIPListFromFile() 返回错误: %v", err)
+	}
+	want := ReloadDiff{Added: []string{"203.0.113.1"}, Removed: []string{"198.51.100.9"}}
+	if !reflect.DeepEqual(diff, want) {
+		t.Errorf("差异 = %+v, 期望 %+v", diff, want)
+	}
+	if info := manager.NamedIPLists()[0]; info.Size != 3 || info.Temporary != 1 {
+		t.Errorf("列表信息 = %+v, 期望3个条目、1个临时条目", info)
+	}
+
+	if err := manager.AddNamedDomainListEntriesTTL("temp-blocks", time.Hour, "bad.example.com"); err != nil {
+		t.Fatal(err)
+	}
+	domainPath := writeList(t, filepath.Join(dir, "domains.txt"), "bad.example.com\nworse.example.com\n")
+	if _, err := manager.ReloadNamedDomainListFromFile("temp-blocks", domainPath); err != nil {
+		t.Fatalf("ReloadNamedDomainListFromFile() 返回错误: %v", err)
+	}
+
+	// 保留下来的临时条目按原来的时间到期，新加入的条目是永久的
+	clock.Advance(2 * time.Hour)
+	checks := []struct {
+		check  func(string) (types.Permission, error)
+		target string
+		want   types.Permission
+	}{
+		{manager.CheckIP, "198.51.100.7", types.Denied},
+		{manager.CheckIP, "198.51.100.8", types.Allowed},
+		{manager.CheckIP, "203.0.113.1", types.Denied},
+		{manager.CheckDomain, "bad.example.com", types.Allowed},
+		{manager.CheckDomain, "worse.example.com", types.Denied},
+	}
+	for _, c := range checks {
+		if perm, _ := c.check(c.target); perm != c.want {
+			t.Errorf("检查 %s = %v, 期望 %v", c.target, perm, c.want)
+		}
+	}
+
+	if _, err := manager.ReloadNamedIPListFromFile("missing", ipPath); !errors.Is(err, ErrListNotFound) {
+		t.Errorf("不存在的列表 = %v, 期望 ErrListNotFound", err)
+	}
+	manager.SetListQuota("ip_list:temp-bans", 2)
+	if _, err := manager.ReloadNamedIPListFromFile("temp-bans", ipPath); !errors.Is(err, ErrQuotaExceeded) {
+		t.Errorf("超出配额 = %v, 期望 ErrQuotaExceeded", err)
+	}
+}
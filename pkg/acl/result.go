@@ -0,0 +1,99 @@
+package acl
+
+import (
+	"context"
+
+	"github.com/cyberspacesec/go-acl/pkg/domain"
+	"github.com/cyberspacesec/go-acl/pkg/expr"
+	"github.com/cyberspacesec/go-acl/pkg/ip"
+	"github.com/cyberspacesec/go-acl/pkg/types"
+)
+
+// CheckIPDetailed 检查IP是否允许访问，并返回包含命中规则和决定组件的结果
+//
+// 参数:
+//   - ctx: 请求上下文，可携带请求ID
+//   - ip: 要检查的IP地址
+//
+// 返回:
+//   - types.CheckResult: 检查结果，Source为"family"、"ip_list:名称"、"default"或"ip_acl"
+//   - error: 与CheckIP相同的错误
+//
+// 结果、统计和审计事件与CheckIPContext相同。Latency使用Manager的时钟测量（见SetClock）。
+// 查询命中的规则需要额外的开销，只需要结果时应使用CheckIP。
+//
+// 示例:
+//
+//	result, err := manager.CheckIPDetailed(ctx, "10.1.2.3")
+//	if err == nil && !result.Allowed() {
+//	    log.Printf("拒绝 %s: %s 的规则 %s", result.Target, result.Source, result.RuleID)
+//	}
+func (m *Manager) CheckIPDetailed(ctx context.Context, ip string) (types.CheckResult, error) {
+	start := m.Clock().Now()
+	result, err := m.checkIPContext(ctx, ip, true)
+	result.Latency = m.Clock().Now().Sub(start)
+	return result, err
+}
+
+// CheckDomainDetailed 检查域名是否允许访问，并返回包含命中规则和决定组件的结果
+//
+// 参数:
+//   - ctx: 请求上下文，可携带请求ID
+//   - domain: 要检查的域名
+//
+// 返回:
+//   - types.CheckResult: 检查结果，Source为"domain_list:名称"、"default"或"domain_acl"，
+//     RuleID的写法见domain.DomainACL.CheckDetailed
+//   - error: 与CheckDomain相同的错误
+//
+// 结果、统计和审计事件与CheckDomainContext相同。
+func (m *Manager) CheckDomainDetailed(ctx context.Context, domain string) (types.CheckResult, error) {
+	start := m.Clock().Now()
+	result, err := m.checkDomainContext(ctx, domain, true)
+	result.Latency = m.Clock().Now().Sub(start)
+	return result, err
+}
+
+// CheckHostDetailed 与CheckHost相同，返回CheckIPDetailed或CheckDomainDetailed的结果
+//
+// 参数:
+//   - ctx: 请求上下文，可携带请求ID
+//   - host: 主机名、IP或URL
+//
+// 返回:
+//   - types.CheckResult: 检查结果；主机部分是IP时Target为其标准形式
+//   - error: 与CheckHost相同的错误
+func (m *Manager) CheckHostDetailed(ctx context.Context, host string) (types.CheckResult, error) {
+	if result, err := m.validateHost(ctx, host); err != nil {
+		return result, err
+	}
+	if parsed, ok := ip.CanonicalizeIP(domain.Normalize(host)); ok {
+		return m.CheckIPDetailed(m.withHostInput(ctx, host), parsed.String())
+	}
+	return m.CheckDomainDetailed(ctx, host)
+}
+
+// CheckRequestDetailed 与CheckRequestContext相同，并返回包含命中规则和决定组件的结果
+//
+// 参数:
+//   - ctx: 请求上下文，可携带请求ID
+//   - req: 请求上下文，包含IP、域名和端口
+//
+// 返回:
+//   - types.CheckResult: Kind为"request"、Target为req.String()的检查结果:
+//   - 规则表达式决定结果时，Source为"rule"，RuleID为规则原文
+//   - 否则为做出决定的ACL的Source和RuleID；IP和域名ACL都允许时为最后检查的ACL
+//   - error: 与CheckRequest相同的错误
+//
+// 涉及端口的决定来自规则表达式（Source为"rule"）或SetIPRulePorts设置的端口限制（Source为"ip_ports"）。
+//
+// 示例:
+//
+//	result, _ := manager.CheckRequestDetailed(ctx, expr.Request{IP: "10.0.0.5", Port: 22})
+//	metrics.Inc("acl_decisions", result.Source, result.Decision.String())
+func (m *Manager) CheckRequestDetailed(ctx context.Context, req expr.Request) (types.CheckResult, error) {
+	start := m.Clock().Now()
+	result, err := m.checkRequest(ctx, req, true)
+	result.Latency = m.Clock().Now().Sub(start)
+	return result, err
+}
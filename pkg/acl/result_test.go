@@ -0,0 +1,145 @@
+package acl
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/cyberspacesec/go-acl/pkg/expr"
+	"github.com/cyberspacesec/go-acl/pkg/types"
+)
+
+// TestCheckDetailed 测试IP、域名和主机的详细检查结果
+func TestCheckDetailed(t *testing.T) {
+	manager := NewManager()
+	if err := manager.SetIPACL([]string{"10.0.0.0/8"}, types.Blacklist); err != nil {
+		t.Fatalf("SetIPACL() 返回错误: %v", err)
+	}
+	if err := manager.SetNamedIPList("threat-intel", []string{"203.0.113.0/24"}, types.Blacklist, 0); err != nil {
+		t.Fatalf("SetNamedIPList() 返回错误: %v", err)
+	}
+	manager.SetDomainACL([]string{"example.com"}, types.Blacklist, true)
+	manager.SetNamedDomainList("ads", []string{"ads.example.net"}, types.Blacklist, true, 0)
+
+	ctx := context.Background()
+	tests := []struct {
+		name     string
+		check    func(string) (types.CheckResult, error)
+		target   string
+		kind     string
+		decision types.Permission
+		ruleID   string
+		source   string
+	}{
+		{"IP主列表", func(s string) (types.CheckResult, error) { return manager.CheckIPDetailed(ctx, s) },
+			"10.1.2.3", "ip", types.Denied, "10.0.0.0/8", "ip_acl"},
+		{"IP命名列表", func(s string) (types.CheckResult, error) { return manager.CheckIPDetailed(ctx, s) },
+			"203.0.113.7", "ip", types.Denied, "203.0.113.0/24", "ip_list:threat-intel"},
+		{"IP未匹配", func(s string) (types.CheckResult, error) { return manager.CheckIPDetailed(ctx, s) },
+			"8.8.8.8", "ip", types.Allowed, "", "ip_acl"},
+		{"域名主列表", func(s string) (types.CheckResult, error) { return manager.CheckDomainDetailed(ctx, s) },
+			"api.example.com", "domain", types.Denied, "example.com", "domain_acl"},
+		{"域名命名列表", func(s string) (types.CheckResult, error) { return manager.CheckDomainDetailed(ctx, s) },
+			"x.ads.example.net", "domain", types.Denied, "ads.example.net", "domain_list:ads"},
+		{"主机为IP", func(s string) (types.CheckResult, error) { return manager.CheckHostDetailed(ctx, s) },
+			"http://10.0.0.1:8080/", "ip", types.Denied, "10.0.0.0/8", "ip_acl"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, err := tt.check(tt.target)
+			if err != nil {
+				t.Fatalf("返回错误: %v", err)
+			}
+			if result.Kind != tt.kind || result.Decision != tt.decision || result.RuleID != tt.ruleID || result.Source != tt.source {
+				t.Errorf("结果 = %+v, 期望 kind=%s decision=%v rule=%q source=%s", result, tt.kind, tt.decision, tt.ruleID, tt.source)
+			}
+		})
+	}
+
+	// 详细检查与普通检查一样计入统计
+	if stats := manager.Stats(); stats.IPDenied != 3 || stats.IPAllowed != 1 || stats.DomainDenied != 2 {
+		t.Errorf("Stats() = %+v, 期望3次IP拒绝、1次IP允许和2次域名拒绝", stats)
+	}
+
+	if _, err := NewManager().CheckIPDetailed(ctx, "10.0.0.1"); !errors.Is(err, types.ErrNoACL) {
+		t.Errorf("未设置ACL时 CheckIPDetailed() 错误 = %v, 期望 ErrNoACL", err)
+	}
+}
+
+// TestCheckDetailedMatches 测试详细检查结果列出重叠的所有范围
+func TestCheckDetailedMatches(t *testing.T) {
+	manager := NewManager()
+	if err := manager.SetIPACL([]string{"10.0.0.0/8", "10.1.2.0/24", "10.1.0.0/16"}, types.Blacklist); err != nil {
+		t.Fatalf("SetIPACL() 返回错误: %v", err)
+	}
+	if err := manager.SetNamedIPList("feeds", []string{"203.0.113.0/24", "203.0.113.7"}, types.Blacklist, 0); err != nil {
+		t.Fatalf("SetNamedIPList() 返回错误: %v", err)
+	}
+
+	ctx := context.Background()
+	tests := []struct {
+		ip      string
+		matches []string
+	}{
+		{"10.1.2.3", []string{"10.1.2.0/24", "10.1.0.0/16", "10.0.0.0/8"}},
+		// 只列出做出决定的列表中的范围
+		{"203.0.113.7", []string{"203.0.113.7", "203.0.113.0/24"}},
+		{"8.8.8.8", nil},
+	}
+	for _, tt := range tests {
+		result, err := manager.CheckIPDetailed(ctx, tt.ip)
+		if err != nil {
+			t.Fatalf("CheckIPDetailed(%s) 返回错误: %v", tt.ip, err)
+		}
+		if !reflect.DeepEqual(result.Matches, tt.matches) {
+			t.Errorf("CheckIPDetailed(%s).Matches = %v, 期望 %v", tt.ip, result.Matches, tt.matches)
+		}
+		if len(tt.matches) > 0 && result.RuleID != tt.matches[0] {
+			t.Errorf("CheckIPDetailed(%s).RuleID = %q, 期望最具体的范围 %q", tt.ip, result.RuleID, tt.matches[0])
+		}
+	}
+}
+
+// TestCheckRequestDetailed 测试请求检查的结果来自规则表达式或ACL
+func TestCheckRequestDetailed(t *testing.T) {
+	manager := NewManager()
+	if err := manager.SetIPACL([]string{"10.0.0.0/8"}, types.Blacklist); err != nil {
+		t.Fatalf("SetIPACL() 返回错误: %v", err)
+	}
+	manager.SetDomainACL([]string{"example.com"}, types.Blacklist, true)
+	rules, err := expr.CompileAll([]string{"port == 22 -> deny"})
+	if err != nil {
+		t.Fatalf("CompileAll() 返回错误: %v", err)
+	}
+	manager.SetRules(rules)
+
+	tests := []struct {
+		name     string
+		req      expr.Request
+		decision types.Permission
+		ruleID   string
+		source   string
+	}{
+		{"规则表达式", expr.Request{IP: "192.0.2.1", Port: 22}, types.Denied, "port == 22 -> deny", "rule"},
+		{"IP ACL拒绝", expr.Request{IP: "10.0.0.1", Domain: "example.org", Port: 443}, types.Denied, "10.0.0.0/8", "ip_acl"},
+		{"域名ACL拒绝", expr.Request{IP: "192.0.2.1", Domain: "api.example.com"}, types.Denied, "example.com", "domain_acl"},
+		{"都允许", expr.Request{IP: "192.0.2.1", Domain: "example.org"}, types.Allowed, "", "domain_acl"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, err := manager.CheckRequestDetailed(context.Background(), tt.req)
+			if err != nil {
+				t.Fatalf("CheckRequestDetailed() 返回错误: %v", err)
+			}
+			if result.Target != tt.req.String() || result.Kind != "request" {
+				t.Errorf("Target, Kind = %q, %q", result.Target, result.Kind)
+			}
+			if result.Decision != tt.decision || result.RuleID != tt.ruleID || result.Source != tt.source {
+				t.Errorf("结果 = %+v, 期望 decision=%v rule=%q source=%s", result, tt.decision, tt.ruleID, tt.source)
+			}
+		})
+	}
+}
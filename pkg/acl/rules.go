@@ -0,0 +1,190 @@
+package acl
+
+import (
+	"context"
+	"errors"
+
+	"github.com/cyberspacesec/go-acl/pkg/expr"
+	"github.com/cyberspacesec/go-acl/pkg/types"
+)
+
+// SetRules 设置条件规则表达式
+//
+// 参数:
+//   - rules: 编译后的规则集，传入nil表示清除规则
+//
+// 规则在CheckRequest中先于IP和域名ACL求值，第一条匹配的规则决定结果。
+// 修改被SetMutationInterceptor设置的拦截函数拒绝时原有规则保持不变，需要得到拒绝的错误时使用TrySetRules。
+//
+// 示例:
+//
+//	rules, err := expr.LoadFile("./rules.txt")
+//	if err != nil {
+//	    log.Fatalf("加载规则失败: %v", err)
+//	}
+//	manager.SetRules(rules)
+func (m *Manager) SetRules(rules expr.RuleSet) {
+	_ = m.TrySetRules(rules)
+}
+
+// TrySetRules 与SetRules相同，但返回拦截函数拒绝修改的错误
+//
+// 返回:
+//   - error: 修改被SetMutationInterceptor设置的拦截函数拒绝时返回*RejectedChangeError，原有规则保持不变
+func (m *Manager) TrySetRules(rules expr.RuleSet) error {
+	sources := make([]string, 0, len(rules))
+	for _, rule := range rules {
+		if rule != nil {
+			sources = append(sources, rule.Source)
+		}
+	}
+	if err := m.intercept(Change{Op: ChangeSet, Kind: "rules", Component: "rules", Entries: sources}); err != nil {
+		return err
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.rules = rules
+	m.notifyChange("rules", "rules", m.now())
+	return nil
+}
+
+// CheckRequest 综合规则表达式和ACL检查一个请求
+//
+// 参数:
+//   - req: 请求上下文，包含IP、域名和端口
+//
+// 返回:
+//   - types.Permission: 访问权限结果
+//   - error: 可能的错误:
+//   - types.ErrNoACL: 没有规则匹配，且请求涉及的ACL均未设置
+//   - ip.ErrInvalidIP、domain.ErrInvalidDomain等ACL检查错误
+//
+// 检查顺序:
+//  1. 按顺序求值SetRules设置的规则，第一条匹配的规则决定结果，所属规则组被停用的规则跳过
+//  2. 没有规则匹配时，若请求包含IP则检查IP ACL，包含域名则检查域名ACL
+//  3. 任一ACL拒绝即拒绝；未设置的ACL会被跳过
+//  4. IP ACL允许时，IP所在范围设置了允许的端口（见SetIPRulePorts）而请求的端口不在其中则拒绝
+//  5. 以上得出允许时，询问SetAuthorizers设置的外部授权来源
+//
+// 动作带有log的规则匹配时，会在得出最终结果后产生Kind为"rule"的审计事件，
+// 见CheckRequestContext。
+//
+// 示例:
+//
+//	perm, err := manager.CheckRequest(expr.Request{
+//	    IP:     "10.0.0.5",
+//	    Domain: "api.example.com",
+//	    Port:   8080,
+//	})
+func (m *Manager) CheckRequest(req expr.Request) (types.Permission, error) {
+	return m.CheckRequestContext(context.Background(), req)
+}
+
+// CheckRequestContext 与CheckRequest相同，并将上下文中的请求ID写入审计事件
+//
+// 参数:
+//   - ctx: 请求上下文，可携带请求ID
+//   - req: 请求上下文，包含IP、域名和端口
+//
+// 返回:
+//   - types.Permission: 访问权限结果
+//   - error: 与CheckRequest相同的错误
+//
+// 每条匹配请求且动作带有log的规则（log、allow log、deny log）都会产生一个审计事件，
+// 事件的Permission是请求的最终结果。这样可以先用log规则观察可疑范围的流量，
+// 确认不会误伤后再把动作改为deny。
+//
+// 示例:
+//
+//	rules, _ := expr.CompileAll([]string{"ip in 198.51.100.0/24 -> log"})
+//	manager.SetRules(rules)
+//	manager.SetAuditHook(func(e acl.AuditEvent) {
+//	    if e.Kind == "rule" {
+//	        log.Printf("观察: %s 匹配 %q，结果 %s", e.Target, e.Rule, e.Permission)
+//	    }
+//	})
+func (m *Manager) CheckRequestContext(ctx context.Context, req expr.Request) (types.Permission, error) {
+	result, err := m.checkRequest(ctx, req, false)
+	return result.Decision, err
+}
+
+// checkRequest 执行CheckRequestContext的检查，detailed为true时结果中包含命中的规则
+func (m *Manager) checkRequest(ctx context.Context, req expr.Request, detailed bool) (types.CheckResult, error) {
+	if override, ok := m.overrideResult(req.String(), "request"); ok {
+		// 紧急模式下跳过规则，IP和域名的检查各自产生带有Override的审计事件
+		result, err := m.checkRequestACL(ctx, req, detailed)
+		if errors.Is(err, types.ErrNoACL) {
+			result, err = override, nil
+		}
+		result.Target, result.Kind = req.String(), "request"
+		return result, err
+	}
+
+	m.mu.RLock()
+	rules := m.enabledRules()
+	m.mu.RUnlock()
+
+	var logged []*expr.Rule
+	perm, rule, ok := rules.EvaluateWithLog(req, func(rule *expr.Rule) {
+		logged = append(logged, rule)
+	})
+
+	var result types.CheckResult
+	var err error
+	if ok {
+		result = types.CheckResult{Decision: perm, RuleID: rule.Source, Source: "rule"}
+		if perm == types.Denied {
+			result.Reason = types.ReasonMatchedRule
+		}
+	} else {
+		result, err = m.checkRequestACL(ctx, req, detailed)
+	}
+	if err == nil {
+		result = m.authorize(ctx, req, result)
+	}
+	result.Target, result.Kind = req.String(), "request"
+
+	for _, rule := range logged {
+		m.auditRule(ctx, "rule", req.String(), rule.Source, result, err)
+	}
+	return result, err
+}
+
+// checkRequestACL 在没有规则匹配时依次检查IP ACL和域名ACL
+//
+// 返回做出决定的ACL的检查结果；都允许时返回最后检查的ACL的结果。
+func (m *Manager) checkRequestACL(ctx context.Context, req expr.Request, detailed bool) (types.CheckResult, error) {
+	var last types.CheckResult
+	checked := false
+	if req.IP != "" {
+		result, err := m.checkIPContext(ctx, req.IP, detailed)
+		if err == nil {
+			checked, last = true, result
+			if result.Decision == types.Denied {
+				return result, nil
+			}
+			if denied, ok := m.checkPorts(req); !ok {
+				return denied, nil
+			}
+		} else if !errors.Is(err, types.ErrNoACL) {
+			return result, err
+		}
+	}
+
+	if req.Domain != "" {
+		result, err := m.checkDomainContext(ctx, req.Domain, detailed)
+		if err == nil {
+			checked, last = true, result
+			if result.Decision == types.Denied {
+				return result, nil
+			}
+		} else if !errors.Is(err, types.ErrNoACL) {
+			return result, err
+		}
+	}
+
+	if !checked {
+		return types.CheckResult{Decision: types.Denied, Reason: types.ReasonNoACL}, types.ErrNoACL
+	}
+	return last, nil
+}
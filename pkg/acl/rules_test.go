@@ -0,0 +1,127 @@
+package acl
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/cyberspacesec/go-acl/pkg/expr"
+	"github.com/cyberspacesec/go-acl/pkg/ip"
+	"github.com/cyberspacesec/go-acl/pkg/types"
+)
+
+// TestCheckRequest 测试规则表达式与ACL的综合检查
+func TestCheckRequest(t *testing.T) {
+	manager := NewManager()
+
+	// 未配置任何内容
+	if _, err := manager.CheckRequest(expr.Request{IP: "8.8.8.8"}); !errors.Is(err, types.ErrNoACL) {
+		t.Errorf("CheckRequest() 错误 = %v, 期望 ErrNoACL", err)
+	}
+
+	rules, err := expr.CompileAll([]string{
+		"ip in private_networks && port != 443 -> deny",
+		"ip in private_networks -> allow",
+	})
+	if err != nil {
+		t.Fatalf("CompileAll() 返回错误: %v", err)
+	}
+	manager.SetRules(rules)
+	manager.SetDomainACL([]string{"evil.com"}, types.Blacklist, true)
+	if err := manager.SetIPACL([]string{"203.0.113.0/24"}, types.Blacklist); err != nil {
+		t.Fatalf("SetIPACL() 返回错误: %v", err)
+	}
+
+	tests := []struct {
+		name    string
+		req     expr.Request
+		want    types.Permission
+		wantErr error
+	}{
+		{"规则拒绝", expr.Request{IP: "10.0.0.1", Port: 80}, types.Denied, nil},
+		{"规则允许", expr.Request{IP: "10.0.0.1", Port: 443}, types.Allowed, nil},
+		{"回退到IP ACL-拒绝", expr.Request{IP: "203.0.113.1", Port: 80}, types.Denied, nil},
+		{"回退到域名ACL-拒绝", expr.Request{IP: "8.8.8.8", Domain: "a.evil.com"}, types.Denied, nil},
+		{"回退到ACL-允许", expr.Request{IP: "8.8.8.8", Domain: "good.com"}, types.Allowed, nil},
+		{"无效IP", expr.Request{IP: "invalid"}, types.Denied, ip.ErrInvalidIP},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := manager.CheckRequest(tt.req)
+			if !errors.Is(err, tt.wantErr) {
+				t.Errorf("CheckRequest() 错误 = %v, 期望 %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("CheckRequest() = %v, 期望 %v", got, tt.want)
+			}
+		})
+	}
+
+	// 只设置了域名ACL时，IP检查被跳过
+	manager.Reset()
+	manager.SetDomainACL([]string{"evil.com"}, types.Blacklist, true)
+	if got, err := manager.CheckRequest(expr.Request{IP: "8.8.8.8", Domain: "good.com"}); err != nil || got != types.Allowed {
+		t.Errorf("CheckRequest() = %v, %v, 期望 allowed, nil", got, err)
+	}
+}
+
+// TestCheckRequestLogRules 测试log规则产生的审计事件
+func TestCheckRequestLogRules(t *testing.T) {
+	manager := NewManager()
+	manager.SetIPACL([]string{"203.0.113.0/24"}, types.Blacklist)
+	rules, err := expr.CompileAll([]string{
+		"ip in 198.51.100.0/24 -> log",
+		"ip in 192.0.2.0/24 && port == 22 -> deny log",
+	})
+	if err != nil {
+		t.Fatalf("CompileAll() 返回错误: %v", err)
+	}
+	manager.SetRules(rules)
+
+	var events []AuditEvent
+	manager.SetAuditHook(func(e AuditEvent) {
+		if e.Kind == "rule" {
+			events = append(events, e)
+		}
+	})
+
+	tests := []struct {
+		name     string
+		req      expr.Request
+		wantPerm types.Permission
+		wantRule string
+	}{
+		{"观察中的范围由ACL决定结果", expr.Request{IP: "198.51.100.9", Port: 80}, types.Allowed, "ip in 198.51.100.0/24 -> log"},
+		{"记录并拒绝", expr.Request{IP: "192.0.2.1", Port: 22}, types.Denied, "ip in 192.0.2.0/24 && port == 22 -> deny log"},
+		{"未匹配log规则不产生事件", expr.Request{IP: "203.0.113.1", Port: 80}, types.Denied, ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			events = nil
+			ctx := WithRequestID(context.Background(), "req-1")
+			perm, err := manager.CheckRequestContext(ctx, tt.req)
+			if err != nil {
+				t.Fatalf("CheckRequestContext() 返回错误: %v", err)
+			}
+			if perm != tt.wantPerm {
+				t.Errorf("CheckRequestContext() = %v, 期望 %v", perm, tt.wantPerm)
+			}
+
+			if tt.wantRule == "" {
+				if len(events) != 0 {
+					t.Errorf("不应产生规则审计事件: %+v", events)
+				}
+				return
+			}
+			if len(events) != 1 {
+				t.Fatalf("规则审计事件数量 = %d, 期望 1", len(events))
+			}
+			e := events[0]
+			if e.Rule != tt.wantRule || e.Permission != tt.wantPerm || e.Target != tt.req.String() || e.RequestID != "req-1" {
+				t.Errorf("审计事件 = %+v", e)
+			}
+		})
+	}
+}
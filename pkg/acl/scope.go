@@ -0,0 +1,161 @@
+package acl
+
+import (
+	"context"
+	"sync"
+
+	"github.com/cyberspacesec/go-acl/pkg/expr"
+	"github.com/cyberspacesec/go-acl/pkg/types"
+)
+
+// Scope 是Manager的一个子视图，在Manager的配置之上叠加额外的规则
+//
+// 典型用法是为HTTP服务的部分路由（如/admin）设置更严格的规则，同时共用全局的基础策略:
+// Scope的规则先求值，命中时直接决定结果，未命中时按Manager的配置检查。
+// 规则的语义与ScopedPolicy相同，求值位置在WithScopedPolicy附加的例外之后。
+//
+// Scope的检查与Manager的检查共用统计、审计、紧急模式和检查预算，
+// 由Scope的规则做出的决定在检查结果中的Source为"scope:名称"，审计事件的Scope记录同样的值。
+// Scope实现了Checker，可以安全地在多个goroutine中并发使用。
+type Scope struct {
+	manager *Manager
+	name    string
+
+	mu   sync.RWMutex
+	acls *scopedACLs
+}
+
+var _ Checker = (*Scope)(nil)
+
+// routeScopeKey 是上下文中保存检查所在Scope的键
+type routeScopeKey struct{}
+
+// Scope 返回指定名称的子视图，同名的Scope只创建一次
+//
+// 参数:
+//   - name: 子视图的名称，如"admin"
+//
+// 返回:
+//   - *Scope: 子视图，新创建时没有规则，检查结果与Manager相同
+//
+// 示例:
+//
+//	admin := manager.Scope("admin")
+//	err := admin.SetPolicy(acl.ScopedPolicy{
+//	    AllowIPs:     []string{"203.0.113.0/24"}, // 办公网
+//	    DenyUnlisted: true,                       // 其他IP一律拒绝
+//	})
+//
+//	// 路由中使用
+//	perm, err := manager.Scope("admin").CheckIP(clientIP)
+func (m *Manager) Scope(name string) *Scope {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if s, ok := m.scopes[name]; ok {
+		return s
+	}
+	if m.scopes == nil {
+		m.scopes = make(map[string]*Scope)
+	}
+	s := &Scope{manager: m, name: name}
+	m.scopes[name] = s
+	return s
+}
+
+// Name 返回子视图的名称
+func (s *Scope) Name() string {
+	return s.name
+}
+
+// SetPolicy 替换子视图的规则
+//
+// 参数:
+//   - policy: 叠加的规则，Name字段被忽略，决定的Source总是"scope:名称"；传入零值表示清除规则
+//
+// 返回:
+//   - error: IP或域名规则无效时返回包装了ip.ErrInvalidIP或domain.ErrInvalidDomain的错误，原有规则保持不变
+func (s *Scope) SetPolicy(policy ScopedPolicy) error {
+	acls, err := compileScopedPolicy(policy, "scope:"+s.name)
+	if err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.acls = acls
+	return nil
+}
+
+// compiled 返回编译后的规则，s为nil或没有规则时返回nil
+func (s *Scope) compiled() *scopedACLs {
+	if s == nil {
+		return nil
+	}
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.acls
+}
+
+// context 返回标记了检查所在Scope的上下文
+func (s *Scope) context(ctx context.Context) context.Context {
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	return context.WithValue(ctx, routeScopeKey{}, s)
+}
+
+// routeScopeFromContext 返回检查所在的Scope，没有时返回nil
+func routeScopeFromContext(ctx context.Context) *Scope {
+	if ctx == nil {
+		return nil
+	}
+	s, _ := ctx.Value(routeScopeKey{}).(*Scope)
+	return s
+}
+
+// CheckIP 与Manager.CheckIP相同，先求值子视图的规则
+func (s *Scope) CheckIP(ip string) (types.Permission, error) {
+	return s.CheckIPContext(context.Background(), ip)
+}
+
+// CheckDomain 与Manager.CheckDomain相同，先求值子视图的规则
+func (s *Scope) CheckDomain(domain string) (types.Permission, error) {
+	return s.CheckDomainContext(context.Background(), domain)
+}
+
+// CheckHost 与Manager.CheckHost相同，先求值子视图的规则
+func (s *Scope) CheckHost(host string) (types.Permission, error) {
+	result, err := s.CheckHostDetailed(context.Background(), host)
+	return result.Decision, err
+}
+
+// CheckIPContext 与Manager.CheckIPContext相同，先求值子视图的规则
+func (s *Scope) CheckIPContext(ctx context.Context, ip string) (types.Permission, error) {
+	return s.manager.CheckIPContext(s.context(ctx), ip)
+}
+
+// CheckDomainContext 与Manager.CheckDomainContext相同，先求值子视图的规则
+func (s *Scope) CheckDomainContext(ctx context.Context, domain string) (types.Permission, error) {
+	return s.manager.CheckDomainContext(s.context(ctx), domain)
+}
+
+// CheckIPDetailed 与Manager.CheckIPDetailed相同，先求值子视图的规则
+func (s *Scope) CheckIPDetailed(ctx context.Context, ip string) (types.CheckResult, error) {
+	return s.manager.CheckIPDetailed(s.context(ctx), ip)
+}
+
+// CheckDomainDetailed 与Manager.CheckDomainDetailed相同，先求值子视图的规则
+func (s *Scope) CheckDomainDetailed(ctx context.Context, domain string) (types.CheckResult, error) {
+	return s.manager.CheckDomainDetailed(s.context(ctx), domain)
+}
+
+// CheckHostDetailed 与Manager.CheckHostDetailed相同，先求值子视图的规则
+func (s *Scope) CheckHostDetailed(ctx context.Context, host string) (types.CheckResult, error) {
+	return s.manager.CheckHostDetailed(s.context(ctx), host)
+}
+
+// CheckRequestContext 与Manager.CheckRequestContext相同，规则表达式之后、ACL之前求值子视图的规则
+func (s *Scope) CheckRequestContext(ctx context.Context, req expr.Request) (types.Permission, error) {
+	return s.manager.CheckRequestContext(s.context(ctx), req)
+}
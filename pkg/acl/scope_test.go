@@ -0,0 +1,130 @@
+package acl
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/cyberspacesec/go-acl/pkg/expr"
+	"github.com/cyberspacesec/go-acl/pkg/ip"
+	"github.com/cyberspacesec/go-acl/pkg/types"
+)
+
+// TestScope 测试子视图的规则先于Manager的配置求值，未命中时按Manager的配置检查
+func TestScope(t *testing.T) {
+	manager := NewManager()
+	if err := manager.SetIPACL([]string{"198.51.100.0/24"}, types.Blacklist); err != nil {
+		t.Fatalf("SetIPACL() 返回错误: %v", err)
+	}
+	manager.SetDomainACL([]string{"example.com"}, types.Whitelist, true)
+	var events []AuditEvent
+	manager.SetAuditHook(func(e AuditEvent) { events = append(events, e) })
+
+	admin := manager.Scope("admin")
+	if err := admin.SetPolicy(ScopedPolicy{
+		AllowIPs:     []string{"203.0.113.0/24"},
+		DenyIPs:      []string{"203.0.113.66"},
+		DenyDomains:  []string{"public.example.com"},
+		DenyUnlisted: true,
+	}); err != nil {
+		t.Fatalf("SetPolicy() 返回错误: %v", err)
+	}
+	if manager.Scope("admin") != admin || admin.Name() != "admin" {
+		t.Error("Scope() 对同一名称应返回同一个子视图")
+	}
+
+	tests := []struct {
+		name   string
+		check  func(c Checker) (types.CheckResult, error)
+		scoped types.Permission
+		global types.Permission
+		source string
+	}{
+		{"办公网IP", func(c Checker) (types.CheckResult, error) {
+			return c.CheckIPDetailed(context.Background(), "203.0.113.10")
+		}, types.Allowed, types.Allowed, "scope:admin"},
+		{"子视图拒绝的IP", func(c Checker) (types.CheckResult, error) {
+			return c.CheckIPDetailed(context.Background(), "203.0.113.66")
+		}, types.Denied, types.Allowed, "scope:admin"},
+		{"不在允许列表中的IP", func(c Checker) (types.CheckResult, error) {
+			return c.CheckIPDetailed(context.Background(), "192.0.2.1")
+		}, types.Denied, types.Allowed, "scope:admin"},
+		{"子视图拒绝的域名", func(c Checker) (types.CheckResult, error) {
+			return c.CheckDomainDetailed(context.Background(), "public.example.com")
+		}, types.Denied, types.Allowed, "scope:admin"},
+		{"未命中的域名按Manager检查", func(c Checker) (types.CheckResult, error) {
+			return c.CheckDomainDetailed(context.Background(), "api.example.com")
+		}, types.Allowed, types.Allowed, "domain_acl"},
+		{"Manager拒绝的域名", func(c Checker) (types.CheckResult, error) {
+			return c.CheckHostDetailed(context.Background(), "https://other.org/")
+		}, types.Denied, types.Denied, "domain_acl"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, err := tt.check(admin)
+			if err != nil {
+				t.Fatalf("子视图检查返回错误: %v", err)
+			}
+			if result.Decision != tt.scoped || result.Source != tt.source {
+				t.Errorf("子视图检查 = %v（%s），期望 %v（%s）", result.Decision, result.Source, tt.scoped, tt.source)
+			}
+			result, err = tt.check(manager)
+			if err != nil {
+				t.Fatalf("Manager检查返回错误: %v", err)
+			}
+			if result.Decision != tt.global {
+				t.Errorf("Manager检查 = %v, 期望 %v（不受子视图影响）", result.Decision, tt.global)
+			}
+		})
+	}
+
+	if perm, _ := admin.CheckRequestContext(context.Background(), expr.Request{IP: "192.0.2.1", Domain: "api.example.com"}); perm != types.Denied {
+		t.Errorf("子视图 CheckRequestContext() = %v, 期望 Denied", perm)
+	}
+	if events[0].Scope != "scope:admin" {
+		t.Errorf("审计事件的Scope = %q, 期望 \"scope:admin\"", events[0].Scope)
+	}
+	if stats := manager.Stats(); stats.IPAllowed+stats.IPDenied == 0 {
+		t.Error("子视图的检查应计入Manager的统计")
+	}
+}
+
+// TestScopeWithScopedPolicy 测试上下文中的临时例外先于子视图的规则求值，清除规则后与Manager相同
+func TestScopeWithScopedPolicy(t *testing.T) {
+	manager := NewManager()
+	if err := manager.SetIPACL(nil, types.Blacklist); err != nil {
+		t.Fatalf("SetIPACL() 返回错误: %v", err)
+	}
+	admin := manager.Scope("admin")
+	if err := admin.SetPolicy(ScopedPolicy{AllowIPs: []string{"203.0.113.0/24"}, DenyUnlisted: true}); err != nil {
+		t.Fatalf("SetPolicy() 返回错误: %v", err)
+	}
+
+	ctx, err := WithScopedPolicy(context.Background(), ScopedPolicy{Name: "oncall", AllowIPs: []string{"192.0.2.1"}})
+	if err != nil {
+		t.Fatalf("WithScopedPolicy() 返回错误: %v", err)
+	}
+	result, err := admin.CheckIPDetailed(ctx, "192.0.2.1")
+	if err != nil || result.Decision != types.Allowed || result.Source != "scoped:oncall" {
+		t.Errorf("CheckIPDetailed(192.0.2.1) = %+v, %v, 期望由scoped:oncall允许", result, err)
+	}
+
+	// 无效的规则不替换原有规则
+	if err := admin.SetPolicy(ScopedPolicy{AllowIPs: []string{"not-an-ip"}}); !errors.Is(err, ip.ErrInvalidIP) {
+		t.Errorf("SetPolicy(无效IP) 错误 = %v, 期望 ip.ErrInvalidIP", err)
+	}
+	if perm, _ := admin.CheckIP("192.0.2.1"); perm != types.Denied {
+		t.Errorf("CheckIP(192.0.2.1) = %v, 期望 Denied", perm)
+	}
+
+	if err := admin.SetPolicy(ScopedPolicy{}); err != nil {
+		t.Fatalf("SetPolicy(零值) 返回错误: %v", err)
+	}
+	if perm, err := admin.CheckIP("192.0.2.1"); err != nil || perm != types.Allowed {
+		t.Errorf("清除规则后 CheckIP(192.0.2.1) = %v, %v, 期望 Allowed", perm, err)
+	}
+	// 无效输入仍由Manager报告错误
+	if _, err := admin.CheckIP("not-an-ip"); err == nil {
+		t.Error("CheckIP(无效IP) 应返回错误")
+	}
+}
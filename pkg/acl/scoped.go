@@ -0,0 +1,198 @@
+package acl
+
+import (
+	"context"
+
+	"github.com/cyberspacesec/go-acl/pkg/domain"
+	"github.com/cyberspacesec/go-acl/pkg/ip"
+	"github.com/cyberspacesec/go-acl/pkg/types"
+)
+
+// ScopedPolicy 是附加在上下文上的临时例外，只影响使用该上下文的检查，不修改Manager的配置
+//
+// 字段说明:
+//   - Name: 例外的名称，如任务ID，用于检查结果的Source（"scoped:名称"）和审计事件的Scope
+//   - AllowIPs: 允许的IP或CIDR
+//   - DenyIPs: 拒绝的IP或CIDR
+//   - AllowDomains: 允许的域名规则，支持domain.ParseRule中的前缀
+//   - DenyDomains: 拒绝的域名规则
+//   - IncludeSubdomains: 域名规则是否包含子域名
+//   - DenyUnlisted: 为true时，AllowIPs（或AllowDomains）不为空而目标未命中例外中的任何列表时直接拒绝，
+//     不再按外层的例外和Manager的配置检查，原因为not_in_whitelist_ip/not_in_whitelist_domain
+//
+// 同一个例外中拒绝优先于允许。
+type ScopedPolicy struct {
+	Name              string
+	AllowIPs          []string
+	DenyIPs           []string
+	AllowDomains      []string
+	DenyDomains       []string
+	IncludeSubdomains bool
+	DenyUnlisted      bool
+}
+
+// scopedKey 是上下文中保存scopedACLs的键
+type scopedKey struct{}
+
+// scopedACLs 是编译后的ScopedPolicy，parent为外层上下文中的例外
+type scopedACLs struct {
+	source string
+	// ip和domain按求值顺序（拒绝在前）保存各类目标的列表
+	ip     []scopedList
+	domain []scopedList
+	// denyUnlistedIP和denyUnlistedDomain表示未命中时直接拒绝，见ScopedPolicy.DenyUnlisted
+	denyUnlistedIP     bool
+	denyUnlistedDomain bool
+	parent             *scopedACLs
+}
+
+// scopedList 是例外中的一个列表，检查结果为want时表示命中了列表中的条目
+//
+// 允许列表是白名单、拒绝列表是黑名单，因此结果与列表的动作相同即为命中。
+type scopedList struct {
+	want  types.Permission
+	check func(target string) (types.CheckResult, error)
+}
+
+// WithScopedPolicy 返回携带临时例外的上下文
+//
+// 参数:
+//   - ctx: 父上下文
+//   - policy: 例外的内容
+//
+// 返回:
+//   - context.Context: 携带例外的新上下文
+//   - error: IP或域名规则无效时返回包装了ip.ErrInvalidIP或domain.ErrInvalidDomain的错误
+//
+// CheckIPContext、CheckDomainContext、CheckHostDetailed、CheckRequestContext等接受上下文的检查在
+// 紧急模式（SetOverrideMode）之后、其他所有列表之前求值例外：命中拒绝的条目时拒绝，命中允许的条目时允许，
+// 都未命中时按Manager的配置检查。CheckRequest的规则表达式先于例外求值。不接受上下文的检查不受影响。
+//
+// 例外在创建上下文时编译，检查时不再解析。父上下文中已有例外时，新的例外先求值，未命中再求值外层的例外。
+// 例外做出的决定在检查结果中的Source为"scoped"（Name为空时）或"scoped:名称"，审计事件的Scope记录同样的值。
+//
+// 示例:
+//
+//	// 只为这次任务允许访问合作方的域名
+//	ctx, err := acl.WithScopedPolicy(ctx, acl.ScopedPolicy{
+//	    Name:              "job-1842",
+//	    AllowDomains:      []string{"partner.example.com"},
+//	    IncludeSubdomains: true,
+//	})
+//	if err != nil {
+//	    return err
+//	}
+//	perm, err := manager.CheckDomainContext(ctx, "api.partner.example.com") // types.Allowed
+func WithScopedPolicy(ctx context.Context, policy ScopedPolicy) (context.Context, error) {
+	source := "scoped"
+	if policy.Name != "" {
+		source += ":" + policy.Name
+	}
+	s, err := compileScopedPolicy(policy, source)
+	if err != nil {
+		return ctx, err
+	}
+	s.parent = scopedFromContext(ctx)
+	return context.WithValue(ctx, scopedKey{}, s), nil
+}
+
+// compileScopedPolicy 编译例外，source为其决定在检查结果中的Source
+func compileScopedPolicy(policy ScopedPolicy, source string) (*scopedACLs, error) {
+	s := &scopedACLs{
+		source:             source,
+		denyUnlistedIP:     policy.DenyUnlisted && len(policy.AllowIPs) > 0,
+		denyUnlistedDomain: policy.DenyUnlisted && len(policy.AllowDomains) > 0,
+	}
+
+	for _, l := range []struct {
+		ranges []string
+		want   types.Permission
+	}{{policy.DenyIPs, types.Denied}, {policy.AllowIPs, types.Allowed}} {
+		if len(l.ranges) == 0 {
+			continue
+		}
+		acl, err := ip.NewIPACL(l.ranges, scopedListType(l.want))
+		if err != nil {
+			return nil, err
+		}
+		s.ip = append(s.ip, scopedList{want: l.want, check: acl.CheckDetailed})
+	}
+
+	for _, l := range []struct {
+		rules []string
+		want  types.Permission
+	}{{policy.DenyDomains, types.Denied}, {policy.AllowDomains, types.Allowed}} {
+		if len(l.rules) == 0 {
+			continue
+		}
+		for _, rule := range l.rules {
+			if _, err := domain.ParseRule(rule); err != nil {
+				return nil, err
+			}
+		}
+		acl := domain.NewDomainACL(l.rules, scopedListType(l.want), policy.IncludeSubdomains)
+		s.domain = append(s.domain, scopedList{want: l.want, check: acl.CheckDetailed})
+	}
+	return s, nil
+}
+
+// scopedListType 返回动作为want的列表的类型
+func scopedListType(want types.Permission) types.ListType {
+	if want == types.Denied {
+		return types.Blacklist
+	}
+	return types.Whitelist
+}
+
+// scopedFromContext 返回上下文中的例外，没有时返回nil
+func scopedFromContext(ctx context.Context) *scopedACLs {
+	if ctx == nil {
+		return nil
+	}
+	s, _ := ctx.Value(scopedKey{}).(*scopedACLs)
+	return s
+}
+
+// scopedResult 返回上下文中的例外对目标的决定，kind为"ip"或"domain"，没有例外命中时ok为false
+//
+// 先求值WithScopedPolicy附加的例外，再求值检查所在的Scope的规则。
+func scopedResult(ctx context.Context, target, kind string) (types.CheckResult, bool) {
+	for s := scopedFromContext(ctx); s != nil; s = s.parent {
+		if result, ok := s.result(target, kind); ok {
+			return result, true
+		}
+	}
+	if s := routeScopeFromContext(ctx).compiled(); s != nil {
+		return s.result(target, kind)
+	}
+	return types.CheckResult{}, false
+}
+
+// result 返回这一层例外对目标的决定，不包括外层的例外
+func (s *scopedACLs) result(target, kind string) (types.CheckResult, bool) {
+	lists, denyUnlisted := s.domain, s.denyUnlistedDomain
+	if kind == "ip" {
+		lists, denyUnlisted = s.ip, s.denyUnlistedIP
+	}
+	for _, l := range lists {
+		result, err := l.check(target)
+		if err != nil {
+			// 无效的输入交给Manager检查并报告错误
+			return types.CheckResult{}, false
+		}
+		if result.Decision == l.want {
+			result.Source = s.source
+			return result, true
+		}
+	}
+	if denyUnlisted {
+		return types.CheckResult{
+			Target:   target,
+			Kind:     kind,
+			Decision: types.Denied,
+			Source:   s.source,
+			Reason:   types.DenyReason(kind, types.Whitelist),
+		}, true
+	}
+	return types.CheckResult{}, false
+}
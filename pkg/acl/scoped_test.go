@@ -0,0 +1,152 @@
+package acl
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/cyberspacesec/go-acl/pkg/domain"
+	"github.com/cyberspacesec/go-acl/pkg/ip"
+	"github.com/cyberspacesec/go-acl/pkg/types"
+)
+
+// TestWithScopedPolicy 测试上下文中的临时例外只影响使用该上下文的检查
+func TestWithScopedPolicy(t *testing.T) {
+	manager := NewManager()
+	manager.SetDomainACL([]string{"example.com"}, types.Whitelist, true)
+	if err := manager.SetIPACL([]string{"203.0.113.0/24"}, types.Blacklist); err != nil {
+		t.Fatalf("SetIPACL() 返回错误: %v", err)
+	}
+	var events []AuditEvent
+	manager.SetAuditHook(func(e AuditEvent) { events = append(events, e) })
+
+	outer, err := WithScopedPolicy(context.Background(), ScopedPolicy{
+		AllowDomains: []string{"partner.org"},
+		DenyIPs:      []string{"198.51.100.0/24"},
+	})
+	if err != nil {
+		t.Fatalf("WithScopedPolicy() 返回错误: %v", err)
+	}
+	ctx, err := WithScopedPolicy(outer, ScopedPolicy{
+		Name:              "job-1842",
+		AllowDomains:      []string{"partner.example.net"},
+		DenyDomains:       []string{"api.example.com"},
+		AllowIPs:          []string{"203.0.113.7", "198.51.100.9"},
+		IncludeSubdomains: true,
+	})
+	if err != nil {
+		t.Fatalf("WithScopedPolicy() 返回错误: %v", err)
+	}
+
+	tests := []struct {
+		name   string
+		check  func(ctx context.Context) (types.CheckResult, error)
+		want   types.Permission
+		source string
+	}{
+		{"允许不在白名单中的域名", func(ctx context.Context) (types.CheckResult, error) {
+			return manager.CheckDomainDetailed(ctx, "cdn.partner.example.net")
+		}, types.Allowed, "scoped:job-1842"},
+		{"拒绝白名单中的域名", func(ctx context.Context) (types.CheckResult, error) {
+			return manager.CheckDomainDetailed(ctx, "api.example.com")
+		}, types.Denied, "scoped:job-1842"},
+		{"允许黑名单中的IP", func(ctx context.Context) (types.CheckResult, error) {
+			return manager.CheckIPDetailed(ctx, "203.0.113.7")
+		}, types.Allowed, "scoped:job-1842"},
+		{"内层的允许先于外层的拒绝", func(ctx context.Context) (types.CheckResult, error) {
+			return manager.CheckIPDetailed(ctx, "198.51.100.9")
+		}, types.Allowed, "scoped:job-1842"},
+		{"外层的拒绝", func(ctx context.Context) (types.CheckResult, error) {
+			return manager.CheckIPDetailed(ctx, "198.51.100.10")
+		}, types.Denied, "scoped"},
+		{"外层的允许", func(ctx context.Context) (types.CheckResult, error) {
+			return manager.CheckHostDetailed(ctx, "https://partner.org/x")
+		}, types.Allowed, "scoped"},
+		{"未命中时按配置检查", func(ctx context.Context) (types.CheckResult, error) {
+			return manager.CheckDomainDetailed(ctx, "other.org")
+		}, types.Denied, "domain_acl"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			events = nil
+			result, err := tt.check(ctx)
+			if err != nil || result.Decision != tt.want || result.Source != tt.source {
+				t.Errorf("结果 = %+v, %v, 期望 %v（%s）", result, err, tt.want, tt.source)
+			}
+			wantScope := ""
+			if tt.source != "domain_acl" {
+				wantScope = tt.source
+			}
+			if len(events) != 1 || events[0].Scope != wantScope {
+				t.Errorf("审计事件 = %+v, 期望Scope为%q", events, wantScope)
+			}
+		})
+	}
+
+	// 不携带例外的检查和不接受上下文的检查不受影响
+	if perm, _ := manager.CheckDomainContext(context.Background(), "partner.example.net"); perm != types.Denied {
+		t.Errorf("没有例外时 CheckDomainContext() = %v, 期望 Denied", perm)
+	}
+	if perm, _ := manager.CheckIP("203.0.113.7"); perm != types.Denied {
+		t.Errorf("CheckIP() = %v, 期望 Denied", perm)
+	}
+
+	// 紧急模式优先于例外
+	manager.SetOverrideMode(OverrideDenyAll)
+	if result, _ := manager.CheckDomainDetailed(ctx, "partner.example.net"); result.Source != "override" {
+		t.Errorf("紧急模式下 Source = %s, 期望 override", result.Source)
+	}
+}
+
+// TestWithScopedPolicyErrors 测试无效的例外
+func TestWithScopedPolicyErrors(t *testing.T) {
+	tests := []struct {
+		name   string
+		policy ScopedPolicy
+		want   error
+	}{
+		{"无效的IP", ScopedPolicy{AllowIPs: []string{"not-an-ip"}}, ip.ErrInvalidIP},
+		{"无效的正则表达式", ScopedPolicy{DenyDomains: []string{"regex:("}}, domain.ErrInvalidDomain},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			got, err := WithScopedPolicy(ctx, tt.policy)
+			if !errors.Is(err, tt.want) {
+				t.Errorf("WithScopedPolicy() 错误 = %v, 期望 %v", err, tt.want)
+			}
+			if got != ctx {
+				t.Error("出错时应返回原上下文")
+			}
+		})
+	}
+}
+
+// TestScopedPolicyDenyUnlisted 测试DenyUnlisted拒绝未命中允许列表的目标，只对设置了允许列表的目标类型生效
+func TestScopedPolicyDenyUnlisted(t *testing.T) {
+	manager := NewManager()
+	manager.SetDomainACL(nil, types.Blacklist, false)
+	if err := manager.SetIPACL(nil, types.Blacklist); err != nil {
+		t.Fatalf("SetIPACL() 返回错误: %v", err)
+	}
+	ctx, err := WithScopedPolicy(context.Background(), ScopedPolicy{
+		Name:         "admin",
+		AllowIPs:     []string{"10.0.0.0/8"},
+		DenyUnlisted: true,
+	})
+	if err != nil {
+		t.Fatalf("WithScopedPolicy() 返回错误: %v", err)
+	}
+
+	result, err := manager.CheckIPDetailed(ctx, "192.0.2.1")
+	if err != nil || result.Decision != types.Denied || result.Source != "scoped:admin" || result.Reason != types.DenyReason("ip", types.Whitelist) {
+		t.Errorf("CheckIPDetailed(192.0.2.1) = %+v, %v, 期望由scoped:admin以not_in_whitelist_ip拒绝", result, err)
+	}
+	if perm, _ := manager.CheckIPContext(ctx, "10.1.2.3"); perm != types.Allowed {
+		t.Errorf("CheckIPContext(10.1.2.3) = %v, 期望 Allowed", perm)
+	}
+	// 没有设置域名的允许列表，域名按Manager的配置检查
+	if result, _ := manager.CheckDomainDetailed(ctx, "example.com"); result.Decision != types.Allowed || result.Source != "domain_acl" {
+		t.Errorf("CheckDomainDetailed(example.com) = %+v, 期望由domain_acl允许", result)
+	}
+}
@@ -0,0 +1,97 @@
+package acl
+
+import (
+	"github.com/cyberspacesec/go-acl/pkg/domain"
+	"github.com/cyberspacesec/go-acl/pkg/types"
+)
+
+// MixedScriptPolicy 是域名混合文字检查的配置，见SetMixedScriptPolicy
+//
+// 字段说明:
+//   - Deny: true表示拒绝混用多种文字的域名；false表示只通过OnDetect报告，仍按ACL检查
+//   - AllowedZones: 合法使用多种文字的区域（如面向多语言用户的品牌域名），区域本身及其子域名不检查
+//   - OnDetect: 发现混用多种文字的域名时调用，可为nil；err包装了domain.ErrMixedScript，
+//     在检查路径上同步调用，不应执行耗时的操作
+type MixedScriptPolicy struct {
+	Deny         bool
+	AllowedZones []string
+	OnDetect     func(domain string, err error)
+}
+
+// SetMixedScriptPolicy 设置域名混合文字（同形异义字）检查
+//
+// 参数:
+//   - policy: 检查的配置，nil表示不检查（默认）
+//
+// 启用后，CheckDomain、CheckHost等所有域名检查在查询ACL之前先检查域名的每个标签是否只使用一种文字，
+// 规则见domain.CheckMixedScript。"pаypal.com"（其中的"а"是西里尔字母）这样的域名看起来与
+// 白名单或黑名单中的域名相同，实际上是另一个域名。
+// Deny为true时这类域名直接被拒绝，Source为"mixed_script"，原因为types.ReasonMixedScript，
+// 不返回错误，与其他拒绝一样计入统计和审计事件。
+//
+// 示例:
+//
+//	manager.SetMixedScriptPolicy(&acl.MixedScriptPolicy{
+//	    Deny:         true,
+//	    AllowedZones: []string{"example.jp"},
+//	    OnDetect: func(name string, err error) {
+//	        log.Printf("可疑域名 %s: %v", name, err)
+//	    },
+//	})
+func (m *Manager) SetMixedScriptPolicy(policy *MixedScriptPolicy) {
+	if policy != nil {
+		p := *policy
+		p.AllowedZones = append([]string(nil), policy.AllowedZones...)
+		policy = &p
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.mixedScript = policy
+}
+
+// mixedScriptPolicy 返回混合文字检查的配置，未设置时返回nil
+func (m *Manager) mixedScriptPolicy() *MixedScriptPolicy {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return m.mixedScript
+}
+
+// denyMixedScript 执行混合文字检查，报告发现的域名，返回是否应拒绝
+func (m *Manager) denyMixedScript(name string) bool {
+	policy := m.mixedScriptPolicy()
+	if policy == nil {
+		return false
+	}
+	err := domain.CheckMixedScript(name, policy.AllowedZones...)
+	if err == nil {
+		return false
+	}
+	if policy.OnDetect != nil {
+		policy.OnDetect(name, err)
+	}
+	return policy.Deny
+}
+
+// explainMixedScript 记录混合文字检查的步骤，返回true表示域名因此被拒绝
+//
+// 不调用OnDetect，Explain只用于诊断。
+func (m *Manager) explainMixedScript(e *Explanation) bool {
+	policy := m.mixedScriptPolicy()
+	if policy == nil {
+		return false
+	}
+	err := domain.CheckMixedScript(e.Normalized, policy.AllowedZones...)
+	switch {
+	case err == nil:
+		e.addStep("mixed_script", "每个标签只使用一种文字")
+		return false
+	case !policy.Deny:
+		e.addStep("mixed_script", "%v，只报告不拒绝", err)
+		return false
+	}
+	e.addStep("mixed_script", "%v，拒绝", err)
+	e.MatchedRule = "mixed_script"
+	e.Decision, e.Reason = types.Denied, types.ReasonMixedScript
+	return true
+}
@@ -0,0 +1,86 @@
+package acl
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/cyberspacesec/go-acl/pkg/domain"
+	"github.com/cyberspacesec/go-acl/pkg/types"
+)
+
+// TestMixedScriptPolicy 测试混用多种文字的域名在ACL之前被拒绝
+func TestMixedScriptPolicy(t *testing.T) {
+	manager := NewManager()
+	manager.SetDomainACL([]string{"paypal.com", "pаypal.example.jp", "example.org"}, types.Whitelist, true)
+
+	var detected []string
+	manager.SetMixedScriptPolicy(&MixedScriptPolicy{
+		Deny:         true,
+		AllowedZones: []string{"example.jp"},
+		OnDetect: func(name string, err error) {
+			if !errors.Is(err, domain.ErrMixedScript) {
+				t.Errorf("OnDetect() 的错误 = %v, 期望包装 domain.ErrMixedScript", err)
+			}
+			detected = append(detected, name)
+		},
+	})
+
+	ctx := context.Background()
+	tests := []struct {
+		name     string
+		target   string
+		decision types.Permission
+		source   string
+		reason   types.Reason
+	}{
+		{"同形异义字", "pаypal.com", types.Denied, "mixed_script", types.ReasonMixedScript},
+		{"Punycode形式", "https://xn--pypal-4ve.com/", types.Denied, "mixed_script", types.ReasonMixedScript},
+		{"正常域名", "paypal.com", types.Allowed, "domain_acl", ""},
+		{"允许的区域", "pаypal.example.jp", types.Allowed, "domain_acl", ""},
+		{"白名单外的域名", "example.net", types.Denied, "domain_acl", types.ReasonNotInWhitelistDomain},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, err := manager.CheckHostDetailed(ctx, tt.target)
+			if err != nil {
+				t.Fatalf("CheckHostDetailed() 返回错误: %v", err)
+			}
+			if result.Decision != tt.decision || result.Source != tt.source || result.Reason != tt.reason {
+				t.Errorf("结果 = %+v, 期望 %v %s %s", result, tt.decision, tt.source, tt.reason)
+			}
+		})
+	}
+	if len(detected) != 2 {
+		t.Errorf("OnDetect() 调用了%d次, 期望2次: %v", len(detected), detected)
+	}
+
+	e := manager.Explain("pаypal.com")
+	if e.Decision != types.Denied || e.Reason != types.ReasonMixedScript {
+		t.Errorf("Explain() = %v %s, 期望与检查一致", e.Decision, e.Reason)
+	}
+	if len(detected) != 2 {
+		t.Error("Explain() 不应调用OnDetect")
+	}
+}
+
+// TestMixedScriptPolicyReportOnly 测试只报告不拒绝
+func TestMixedScriptPolicyReportOnly(t *testing.T) {
+	manager := NewManager()
+	manager.SetDomainACL([]string{"ads.example.com"}, types.Blacklist, true)
+
+	reported := 0
+	manager.SetMixedScriptPolicy(&MixedScriptPolicy{OnDetect: func(string, error) { reported++ }})
+	if perm, err := manager.CheckDomain("pаypal.com"); err != nil || perm != types.Allowed {
+		t.Errorf("CheckDomain() = %v, %v; 期望按ACL允许", perm, err)
+	}
+	if reported != 1 {
+		t.Errorf("OnDetect() 调用了%d次, 期望1次", reported)
+	}
+
+	manager.SetMixedScriptPolicy(nil)
+	manager.CheckDomain("pаypal.com")
+	if reported != 1 {
+		t.Error("SetMixedScriptPolicy(nil) 后不应再检查")
+	}
+}
@@ -0,0 +1,308 @@
+package acl
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"strconv"
+	"strings"
+
+	"github.com/cyberspacesec/go-acl/pkg/domain"
+	"github.com/cyberspacesec/go-acl/pkg/ip"
+	"github.com/cyberspacesec/go-acl/pkg/types"
+)
+
+// maxAuditTargets 是自检从每类列表中取出的目标数量上限，避免大型列表的自检耗时过长
+const maxAuditTargets = 256
+
+// auditProbeDomain 是白名单策略下用作被拒绝目标的域名，.invalid保证它不会被解析
+const auditProbeDomain = "go-acl-audit.invalid"
+
+// auditSensitiveIPs 是无论列表内容如何都会探测的常见敏感地址
+var auditSensitiveIPs = []string{
+	"127.0.0.1",
+	"169.254.169.254",
+	"10.0.0.1",
+	"172.16.0.1",
+	"192.168.0.1",
+	"::1",
+}
+
+// BypassFinding 是自检发现的一种可以绕过访问控制的写法
+//
+// 字段说明:
+//   - Technique: 绕过手法，如"decimal"、"nat64"、"trailing_dot"、"fragment_userinfo"
+//   - Target: 按标准写法检查时被拒绝的目标
+//   - Variant: 同一目标的变形写法
+//   - Permission: 变形写法的检查结果
+//   - Error: 变形写法的检查错误，通常是types.ErrNoACL（变形写法落到了未配置的ACL上）
+type BypassFinding struct {
+	Technique  string           `json:"technique"`
+	Target     string           `json:"target"`
+	Variant    string           `json:"variant"`
+	Permission types.Permission `json:"permission"`
+	Error      string           `json:"error,omitempty"`
+}
+
+// SelfAuditReport 是Audit的结果
+//
+// 字段说明:
+//   - Targets: 按标准写法被拒绝、因而参与探测的目标数量
+//   - Probes: 检查过的变形写法数量
+//   - Findings: 未被拒绝的变形写法
+type SelfAuditReport struct {
+	Targets  int             `json:"targets"`
+	Probes   int             `json:"probes"`
+	Findings []BypassFinding `json:"findings,omitempty"`
+}
+
+// Passed 判断自检是否没有发现绕过
+func (r SelfAuditReport) Passed() bool {
+	return len(r.Findings) == 0
+}
+
+// String 返回适合打印的自检报告
+func (r SelfAuditReport) String() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "目标%d个，探测%d次，发现%d处绕过\n", r.Targets, r.Probes, len(r.Findings))
+	for _, f := range r.Findings {
+		fmt.Fprintf(&b, "  [%s] %s -> %q: %s", f.Technique, f.Target, f.Variant, f.Permission)
+		if f.Error != "" {
+			fmt.Fprintf(&b, " (%s)", f.Error)
+		}
+		b.WriteString("\n")
+	}
+	return b.String()
+}
+
+// auditVariant 是目标的一种变形写法
+type auditVariant struct {
+	technique string
+	host      string
+}
+
+// Audit 用已知的绕过写法探测管理器当前的策略，报告哪些写法没有被拒绝
+//
+// 参数:
+//   - manager: 要自检的ACL管理器
+//
+// 返回:
+//   - SelfAuditReport: 自检结果，Passed()为true表示没有发现绕过
+//
+// 探测目标包括常见敏感地址（回环、云元数据、私有网络）以及IP列表和域名列表中的条目
+// （每类最多取maxAuditTargets个），只有按标准写法检查时被拒绝的目标才参与探测。
+// 对每个目标生成以下变形写法，并按CheckHost的方式检查:
+//   - IPv4的其他写法: 十进制、十六进制、八进制、省略部分
+//   - IPv6中嵌入的IPv4: IPv4映射地址、NAT64（64:ff9b::/96）、6to4（2002::/16）
+//   - 域名的其他写法: 末尾的点、大写、端口、URL形式、Punycode与Unicode形式
+//   - 用户信息技巧: "http://允许的主机@目标/"，以及"#@"、"?@"、"/@"、"\@"等
+//     让不同的URL解析器得出不同主机的写法
+//
+// 检查结果为允许、或因对应的ACL未配置而返回types.ErrNoACL的写法都会被报告。
+// 自检不更新统计，也不产生审计事件。DNS解析相关的绕过（如解析到内网的域名）
+// 不在自检范围内，请使用guard包在连接时检查解析结果。
+//
+// 示例:
+//
+//	report := acl.Audit(manager)
+//	if !report.Passed() {
+//	    log.Printf("访问控制配置存在绕过:\n%s", report)
+//	}
+func Audit(manager *Manager) SelfAuditReport {
+	ipTargets, domainTargets, decoy := manager.auditTargets()
+
+	var report SelfAuditReport
+	probe := func(target string, variants []auditVariant) {
+		if !manager.probeDenied(target) {
+			return
+		}
+		report.Targets++
+		for _, v := range variants {
+			report.Probes++
+			perm, err := manager.probeHost(v.host)
+			if err == nil && perm == types.Denied {
+				continue
+			}
+			if err != nil && !errors.Is(err, types.ErrNoACL) {
+				// 其他错误（如无效输入）调用方通常按拒绝处理
+				continue
+			}
+			finding := BypassFinding{Technique: v.technique, Target: target, Variant: v.host, Permission: perm}
+			if err != nil {
+				finding.Error = err.Error()
+			}
+			report.Findings = append(report.Findings, finding)
+		}
+	}
+
+	for _, target := range ipTargets {
+		probe(target, ipVariants(net.ParseIP(target), decoy))
+	}
+	for _, target := range domainTargets {
+		probe(target, domainVariants(target, decoy))
+	}
+	return report
+}
+
+// auditTargets 收集自检的目标和用户信息技巧中使用的"允许的主机"
+func (m *Manager) auditTargets() (ipTargets, domainTargets []string, decoy string) {
+	m.ipMu.RLock()
+	defer m.ipMu.RUnlock()
+	m.domainMu.RLock()
+	defer m.domainMu.RUnlock()
+
+	seen := make(map[string]bool)
+	addIP := func(s string) {
+		if parsed, ok := ip.CanonicalizeIP(s); ok && !seen[parsed.String()] {
+			seen[parsed.String()] = true
+			ipTargets = append(ipTargets, parsed.String())
+		}
+	}
+	for _, s := range auditSensitiveIPs {
+		addIP(s)
+	}
+
+	var ipACLs []*ip.IPACL
+	if m.ipACL != nil {
+		ipACLs = append(ipACLs, m.ipACL)
+	}
+	for _, l := range m.ipLists {
+		ipACLs = append(ipACLs, l.ip)
+	}
+	for _, acl := range ipACLs {
+		for i, r := range acl.GetIPRanges() {
+			if i == maxAuditTargets {
+				break
+			}
+			// 取范围的起始地址，如"10.0.0.0/8"取"10.0.0.0"
+			addIP(strings.SplitN(strings.SplitN(r, "/", 2)[0], "-", 2)[0])
+		}
+	}
+
+	decoy = "audit-decoy.example"
+	var domainACLs []*domain.DomainACL
+	if m.domainACL != nil {
+		domainACLs = append(domainACLs, m.domainACL)
+	}
+	for _, l := range m.domainLists {
+		domainACLs = append(domainACLs, l.domain)
+	}
+	for _, acl := range domainACLs {
+		domains := acl.GetDomains()
+		if acl.GetListType() == types.Whitelist {
+			for _, d := range domains {
+				if target, ok := auditRuleTarget(d); ok && decoy == "audit-decoy.example" {
+					decoy = target
+					break
+				}
+			}
+			continue
+		}
+		for i, d := range domains {
+			if i == maxAuditTargets {
+				break
+			}
+			target, ok := auditRuleTarget(d)
+			if ok && !seen[target] {
+				seen[target] = true
+				domainTargets = append(domainTargets, target)
+			}
+		}
+	}
+	domainTargets = append(domainTargets, auditProbeDomain)
+	return ipTargets, domainTargets, decoy
+}
+
+// auditRuleTarget 返回域名规则覆盖的一个具体域名，正则表达式规则没有确定的目标
+func auditRuleTarget(rule string) (string, bool) {
+	parsed, err := domain.ParseRule(rule)
+	if err != nil || parsed.Kind == domain.MatchRegex {
+		return "", false
+	}
+	if strings.HasPrefix(parsed.Value, ".") {
+		// 只匹配子域名的后缀规则，取一个子域名作为目标
+		return "audit" + parsed.Value, true
+	}
+	return parsed.Value, true
+}
+
+// probeDenied 判断目标按标准写法检查时是否被拒绝
+func (m *Manager) probeDenied(target string) bool {
+	perm, err := m.probeHost(target)
+	return err == nil && perm == types.Denied
+}
+
+// probeHost 与CheckHost相同，但不更新统计，也不产生审计事件
+func (m *Manager) probeHost(host string) (types.Permission, error) {
+	if parsed, ok := ip.CanonicalizeIP(domain.Normalize(host)); ok {
+		return m.checkIP(parsed.String())
+	}
+	return m.checkDomain(host)
+}
+
+// ipVariants 返回IP地址的常见变形写法
+func ipVariants(addr net.IP, decoy string) []auditVariant {
+	var variants []auditVariant
+	if v4 := addr.To4(); v4 != nil {
+		a, b, c, d := int(v4[0]), int(v4[1]), int(v4[2]), int(v4[3])
+		value := uint32(a)<<24 | uint32(b)<<16 | uint32(c)<<8 | uint32(d)
+		hi, lo := value>>16, value&0xffff
+		variants = append(variants,
+			auditVariant{"decimal", strconv.FormatUint(uint64(value), 10)},
+			auditVariant{"hex", fmt.Sprintf("0x%08x", value)},
+			auditVariant{"hex_dotted", fmt.Sprintf("0x%x.0x%x.0x%x.0x%x", a, b, c, d)},
+			auditVariant{"octal", fmt.Sprintf("0%o.0%o.0%o.0%o", a, b, c, d)},
+			auditVariant{"short_form", fmt.Sprintf("%d.%d.%d", a, b, c<<8|d)},
+			auditVariant{"ipv4_mapped", "::ffff:" + v4.String()},
+			auditVariant{"ipv4_mapped_hex", fmt.Sprintf("[::ffff:%x:%x]", hi, lo)},
+			auditVariant{"nat64", fmt.Sprintf("64:ff9b::%x:%x", hi, lo)},
+			auditVariant{"6to4", fmt.Sprintf("2002:%x:%x::", hi, lo)},
+		)
+	} else {
+		expanded := make([]string, 8)
+		for i := range expanded {
+			expanded[i] = strconv.FormatUint(uint64(addr[2*i])<<8|uint64(addr[2*i+1]), 16)
+		}
+		variants = append(variants,
+			auditVariant{"ipv6_expanded", strings.Join(expanded, ":")},
+			auditVariant{"ipv6_bracketed", "[" + addr.String() + "]"},
+		)
+	}
+
+	host := addr.String()
+	if addr.To4() == nil {
+		host = "[" + host + "]"
+	}
+	return append(variants, urlVariants(host, decoy)...)
+}
+
+// domainVariants 返回域名的常见变形写法
+func domainVariants(target, decoy string) []auditVariant {
+	variants := []auditVariant{
+		{"trailing_dot", target + "."},
+		{"uppercase", strings.ToUpper(target)},
+	}
+	if ascii, err := domain.ToASCII(target); err == nil && ascii != target {
+		variants = append(variants, auditVariant{"punycode", ascii})
+	}
+	if unicode, err := domain.ToUnicode(target); err == nil && unicode != target {
+		variants = append(variants, auditVariant{"unicode", unicode})
+	}
+	return append(variants, urlVariants(target, decoy)...)
+}
+
+// urlVariants 返回把主机嵌入URL的变形写法
+//
+// 其中的用户信息技巧让"允许的主机"出现在URL中，而真正的主机仍然是host:
+// 按URL规范，"#"、"?"、"/"之后的"@"不是用户信息的分隔符，浏览器还会把"\"当作"/"。
+func urlVariants(host, decoy string) []auditVariant {
+	return []auditVariant{
+		{"port", host + ":443"},
+		{"url", "https://" + host + "/path"},
+		{"userinfo", "http://" + decoy + "@" + host + "/"},
+		{"fragment_userinfo", "http://" + host + "#@" + decoy + "/"},
+		{"query_userinfo", "http://" + host + "?@" + decoy + "/"},
+		{"path_userinfo", "http://" + host + "/@" + decoy + "/"},
+		{"backslash_userinfo", "http://" + host + `\@` + decoy + "/"},
+	}
+}
@@ -0,0 +1,75 @@
+package acl
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/cyberspacesec/go-acl/pkg/ip"
+	"github.com/cyberspacesec/go-acl/pkg/types"
+)
+
+// TestAudit 测试自检报告配置中的绕过
+func TestAudit(t *testing.T) {
+	manager := NewManager()
+	if err := manager.SetIPACLWithDefaults(nil, types.Blacklist,
+		[]ip.PredefinedSet{ip.PrivateNetworks, ip.LoopbackNetworks, ip.CloudMetadata}, false); err != nil {
+		t.Fatalf("SetIPACLWithDefaults() 返回错误: %v", err)
+	}
+	manager.SetDomainACL([]string{"evil.com", "例子.com"}, types.Blacklist, true)
+
+	report := Audit(manager)
+	if report.Passed() || report.Targets == 0 || report.Probes == 0 {
+		t.Fatalf("Audit() = %+v, 期望发现绕过", report)
+	}
+
+	techniques := make(map[string]bool)
+	for _, f := range report.Findings {
+		techniques[f.Technique] = true
+		if f.Permission != types.Allowed {
+			t.Errorf("发现的绕过 %+v 的结果应为Allowed", f)
+		}
+	}
+
+	// 列表没有覆盖的IPv6嵌入形式和IDN的另一种写法会被报告
+	for _, want := range []string{"nat64", "6to4", "punycode"} {
+		if !techniques[want] {
+			t.Errorf("Audit() 未报告 %s 绕过:\n%s", want, report)
+		}
+	}
+	// CheckHost已处理的写法不应被报告
+	for _, safe := range []string{"decimal", "hex", "octal", "short_form", "ipv4_mapped", "trailing_dot", "userinfo", "fragment_userinfo", "backslash_userinfo"} {
+		if techniques[safe] {
+			t.Errorf("Audit() 不应报告 %s:\n%s", safe, report)
+		}
+	}
+
+	// 补全列表后自检通过
+	if err := manager.AddIP("64:ff9b::/96", "2002::/16"); err != nil {
+		t.Fatalf("AddIP() 返回错误: %v", err)
+	}
+	manager.AddDomain("xn--fsqu00a.com")
+	if report := Audit(manager); !report.Passed() {
+		t.Errorf("补全列表后 Audit() 仍发现绕过:\n%s", report)
+	}
+
+	if stats := manager.Stats(); stats.IPDenied != 0 || stats.DomainDenied != 0 {
+		t.Errorf("自检不应更新统计: %+v", stats)
+	}
+}
+
+// TestAuditWhitelist 测试白名单策略下的自检
+func TestAuditWhitelist(t *testing.T) {
+	manager := NewManager()
+	manager.SetDomainACL([]string{"trusted.com"}, types.Whitelist, true)
+	if err := manager.SetIPACL([]string{"8.8.8.8"}, types.Whitelist); err != nil {
+		t.Fatalf("SetIPACL() 返回错误: %v", err)
+	}
+
+	report := Audit(manager)
+	if !report.Passed() {
+		t.Errorf("Audit() 发现绕过:\n%s", report)
+	}
+	if !strings.Contains(report.String(), "发现0处绕过") {
+		t.Errorf("String() = %q", report.String())
+	}
+}
@@ -0,0 +1,129 @@
+package acl
+
+import (
+	"context"
+	"errors"
+	"net"
+	"sync/atomic"
+
+	"github.com/cyberspacesec/go-acl/pkg/types"
+)
+
+// SessionVerdict 缓存一个长连接（或会话）的客户端IP的检查结果
+//
+// 同一个连接上的请求来自同一个客户端地址，检查结果只在配置改变时才可能不同。
+// SessionVerdict第一次检查时按InboundChecker的惯例检查客户端IP，之后的检查在配置未改变时
+// 只比较一次指针，直接返回缓存的结果。以下情况之后的下一次检查重新求值:
+//   - Manager产生改变通知（见WatchChanges）
+//   - SetOverrideMode、SetDevMode、SetAuthorizers或SetChaos改变了设置，因此紧急封锁对已建立的连接立即生效
+//   - 有临时条目到期
+//
+// 其他原因需要重新检查时调用Invalidate。缓存命中时不更新统计，也不调用审计函数。
+// SessionVerdict可以在多个goroutine中并发使用。
+type SessionVerdict struct {
+	manager  *Manager
+	clientIP string
+	// cached 保存*sessionResult，为空表示还没有可用的结果
+	cached atomic.Value
+}
+
+// sessionResult 是SessionVerdict缓存的一次检查
+type sessionResult struct {
+	generation *uint64
+	result     types.CheckResult
+	err        error
+}
+
+// sessionVerdictKey 是上下文中保存SessionVerdict的键
+type sessionVerdictKey struct{}
+
+// NewSessionVerdict 创建缓存一个客户端IP检查结果的SessionVerdict
+//
+// 参数:
+//   - clientIP: 客户端IP，可以带端口（如r.RemoteAddr）
+//
+// 返回:
+//   - *SessionVerdict: 还没有检查过的SessionVerdict，第一次调用Check时才检查
+func (m *Manager) NewSessionVerdict(clientIP string) *SessionVerdict {
+	return &SessionVerdict{manager: m, clientIP: clientIP}
+}
+
+// NewConnVerdict 创建缓存一个连接的检查结果的SessionVerdict，客户端IP为conn.RemoteAddr()
+//
+// 参数:
+//   - conn: 服务端接受的连接
+//
+// 返回:
+//   - *SessionVerdict: 与NewSessionVerdict相同
+//
+// 位于反向代理之后时连接的对端是代理，应使用NewSessionVerdict并传入经过信任链校验的客户端IP。
+//
+// 示例:
+//
+//	srv := &http.Server{
+//	    Handler: handler,
+//	    ConnContext: func(ctx context.Context, c net.Conn) context.Context {
+//	        return acl.WithSessionVerdict(ctx, manager.NewConnVerdict(c))
+//	    },
+//	}
+//
+//	// 处理器中
+//	if v := acl.SessionVerdictFromContext(r.Context()); v != nil {
+//	    if result, err := v.Check(r.Context()); err != nil || !result.Allowed() {
+//	        http.Error(w, "Forbidden", http.StatusForbidden)
+//	        return
+//	    }
+//	}
+func (m *Manager) NewConnVerdict(conn net.Conn) *SessionVerdict {
+	var clientIP string
+	if addr := conn.RemoteAddr(); addr != nil {
+		clientIP = addr.String()
+	}
+	return m.NewSessionVerdict(clientIP)
+}
+
+// Check 返回客户端IP的检查结果，配置未改变时使用缓存
+//
+// 参数:
+//   - ctx: 请求上下文，只在需要重新检查时使用
+//
+// 返回:
+//   - types.CheckResult: 与InboundChecker.Check不带Host时相同
+//   - error: 与InboundChecker.Check相同；只有成功的检查和types.ErrNoACL被缓存，
+//     其他错误（如超出检查预算）下一次检查时重试
+//
+// 缓存的结果来自第一次求值时的ctx，WithScopedPolicy等随请求附加的规则只在求值的那次检查中生效，
+// 需要逐个请求考虑这些规则时应直接使用InboundChecker。
+func (v *SessionVerdict) Check(ctx context.Context) (types.CheckResult, error) {
+	m := v.manager
+	generation := m.generation()
+	if cached, _ := v.cached.Load().(*sessionResult); cached != nil && cached.generation == generation {
+		// 有临时条目到期时到期的条目在SweepExpired清理之前仍在列表中，每次都重新检查
+		if m.expiryTime().IsZero() {
+			return cached.result, cached.err
+		}
+	}
+
+	// 修订号在修改的写锁内增加，先读取generation再检查，检查期间配置改变时下一次检查会重新求值
+	result, err := InboundChecker{Manager: m}.Check(ctx, v.clientIP, "")
+	if err == nil || errors.Is(err, types.ErrNoACL) {
+		v.cached.Store(&sessionResult{generation: generation, result: result, err: err})
+	}
+	return result, err
+}
+
+// Invalidate 丢弃缓存的结果，下一次Check重新检查
+func (v *SessionVerdict) Invalidate() {
+	v.cached.Store((*sessionResult)(nil))
+}
+
+// WithSessionVerdict 返回保存了SessionVerdict的上下文，通常在http.Server.ConnContext中调用
+func WithSessionVerdict(ctx context.Context, v *SessionVerdict) context.Context {
+	return context.WithValue(ctx, sessionVerdictKey{}, v)
+}
+
+// SessionVerdictFromContext 返回WithSessionVerdict保存的SessionVerdict，没有时返回nil
+func SessionVerdictFromContext(ctx context.Context) *SessionVerdict {
+	v, _ := ctx.Value(sessionVerdictKey{}).(*SessionVerdict)
+	return v
+}
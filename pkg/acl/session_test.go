@@ -0,0 +1,109 @@
+package acl
+
+import (
+	"context"
+	"errors"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/cyberspacesec/go-acl/pkg/expr"
+	"github.com/cyberspacesec/go-acl/pkg/types"
+)
+
+// TestSessionVerdict 测试连接的检查结果在配置改变、临时条目到期和Invalidate后重新求值
+func TestSessionVerdict(t *testing.T) {
+	manager, clock := newTTLManager(t)
+	ctx := context.Background()
+
+	server, client := net.Pipe()
+	defer server.Close()
+	defer client.Close()
+	// net.Pipe的地址不是IP
+	if _, err := manager.NewConnVerdict(server).Check(ctx); !errors.Is(err, ErrMissingClientIP) {
+		t.Errorf("没有客户端IP时错误 = %v, 期望 ErrMissingClientIP", err)
+	}
+
+	verdict := manager.NewSessionVerdict("203.0.113.7:52144")
+	expect := func(step string, want types.Permission) {
+		t.Helper()
+		result, err := verdict.Check(ctx)
+		if err != nil || result.Decision != want {
+			t.Errorf("%s: Check() = %v, %v, 期望 %v", step, result.Decision, err, want)
+		}
+	}
+
+	expect("第一次检查", types.Allowed)
+
+	// 紧急模式不产生改变通知，但立即对已缓存的连接生效
+	revision := manager.Revision()
+	manager.SetOverrideMode(OverrideDenyAll)
+	expect("紧急封锁后", types.Denied)
+	manager.SetOverrideMode(OverrideNone)
+	expect("关闭紧急模式", types.Allowed)
+	if manager.Revision() != revision {
+		t.Errorf("紧急模式改变了修订号: %d -> %d", revision, manager.Revision())
+	}
+
+	deny := AuthorizerFunc(func(context.Context, expr.Request) (types.Permission, error) {
+		return types.Denied, nil
+	})
+	if err := manager.SetAuthorizers(&AuthorizerConfig{Sources: []AuthorizerSource{{Name: "deny", Authorizer: deny}}}); err != nil {
+		t.Fatal(err)
+	}
+	expect("设置外部授权后", types.Denied)
+	if err := manager.SetAuthorizers(nil); err != nil {
+		t.Fatal(err)
+	}
+	expect("取消外部授权后", types.Allowed)
+
+	// 开发模式放行被拒绝的内网地址
+	internal := manager.NewSessionVerdict("10.0.0.5")
+	if err := manager.AddNamedIPListEntries("temp-bans", "10.0.0.5"); err != nil {
+		t.Fatal(err)
+	}
+	for _, step := range []struct {
+		devMode bool
+		want    types.Permission
+	}{{false, types.Denied}, {true, types.Allowed}, {false, types.Denied}} {
+		manager.SetDevMode(step.devMode)
+		if result, _ := internal.Check(ctx); result.Decision != step.want {
+			t.Errorf("开发模式=%v: Check() = %v, 期望 %v", step.devMode, result.Decision, step.want)
+		}
+	}
+
+	if err := manager.AddNamedIPListEntriesTTL("temp-bans", time.Minute, "203.0.113.7"); err != nil {
+		t.Fatal(err)
+	}
+	expect("封禁后", types.Denied)
+	expect("再次检查", types.Denied)
+
+	clock.Advance(2 * time.Minute)
+	expect("封禁到期后", types.Allowed)
+	manager.SweepExpired()
+	expect("清理后", types.Allowed)
+}
+
+// TestSessionVerdictContext 测试通过上下文传递SessionVerdict
+func TestSessionVerdictContext(t *testing.T) {
+	if v := SessionVerdictFromContext(context.Background()); v != nil {
+		t.Errorf("SessionVerdictFromContext() = %v, 期望 nil", v)
+	}
+	manager := NewManager()
+	verdict := manager.NewSessionVerdict("192.0.2.1")
+	ctx := WithSessionVerdict(context.Background(), verdict)
+	if got := SessionVerdictFromContext(ctx); got != verdict {
+		t.Errorf("SessionVerdictFromContext() = %p, 期望 %p", got, verdict)
+	}
+
+	// 没有设置ACL时的结果同样被缓存，设置后重新检查
+	if _, err := verdict.Check(ctx); !errors.Is(err, types.ErrNoACL) {
+		t.Errorf("没有ACL时错误 = %v, 期望 ErrNoACL", err)
+	}
+	if err := manager.SetIPACL([]string{"192.0.2.0/24"}, types.Blacklist); err != nil {
+		t.Fatal(err)
+	}
+	if result, err := verdict.Check(ctx); err != nil || result.Decision != types.Denied {
+		t.Errorf("设置ACL后 Check() = %v, %v, 期望 Denied", result.Decision, err)
+	}
+}
@@ -0,0 +1,291 @@
+package acl
+
+import (
+	"bufio"
+	"encoding/gob"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sync/atomic"
+	"time"
+
+	"github.com/cyberspacesec/go-acl/pkg/domain"
+	"github.com/cyberspacesec/go-acl/pkg/ip"
+)
+
+// ErrInvalidSnapshot 表示快照数据格式无效或版本不受支持
+var ErrInvalidSnapshot = errors.New("无效的ACL快照")
+
+// snapshotMagic 是快照文件的文件头，用于识别格式
+const snapshotMagic = "GO-ACL-SNAPSHOT\n"
+
+// snapshotVersion 是当前的快照格式版本
+const snapshotVersion = 1
+
+// managerSnapshot 是Manager状态的可序列化形式
+type managerSnapshot struct {
+	Version      int
+	IP           *ip.IPACLSnapshot
+	Domain       *domain.DomainACLSnapshot
+	DeniedFamily ip.Family
+	IPLists      []namedListSnapshot
+	DomainLists  []namedListSnapshot
+	// DisabledGroups 是被停用的规则组
+	DisabledGroups []string
+}
+
+// namedListSnapshot 是命名列表的可序列化形式
+type namedListSnapshot struct {
+	Name     string
+	Priority int
+	Group    string
+	IP       *ip.IPACLSnapshot
+	Domain   *domain.DomainACLSnapshot
+	// Expiry 是临时条目的到期时间，加载后继续生效
+	Expiry map[string]time.Time
+}
+
+// snapshotLists 返回命名列表的快照，调用方需持有读锁
+func snapshotLists(lists []namedList) []namedListSnapshot {
+	snaps := make([]namedListSnapshot, len(lists))
+	for i, l := range lists {
+		snaps[i] = namedListSnapshot{Name: l.name, Priority: l.priority, Group: l.group}
+		if len(l.expiry) > 0 {
+			// 编码在释放读锁之后进行，不能引用列表中的映射
+			snaps[i].Expiry = make(map[string]time.Time, len(l.expiry))
+			for key, deadline := range l.expiry {
+				snaps[i].Expiry[key] = deadline
+			}
+		}
+		if l.ip != nil {
+			s := l.ip.Snapshot()
+			snaps[i].IP = &s
+		} else {
+			s := l.domain.Snapshot()
+			snaps[i].Domain = &s
+		}
+	}
+	return snaps
+}
+
+// restoreLists 从快照恢复命名列表，快照中的顺序即求值顺序
+func restoreLists(snaps []namedListSnapshot, modified time.Time) ([]namedList, error) {
+	var lists []namedList
+	for i, s := range snaps {
+		l := namedList{name: s.Name, priority: s.Priority, seq: uint64(i + 1), group: s.Group, modified: modified, expiry: s.Expiry}
+		for _, deadline := range s.Expiry {
+			l.nextExpiry = earlier(l.nextExpiry, deadline)
+		}
+		switch {
+		case s.IP != nil:
+			acl, err := ip.NewIPACLFromSnapshot(*s.IP)
+			if err != nil {
+				return nil, err
+			}
+			l.ip = acl
+		case s.Domain != nil:
+			l.domain = domain.NewDomainACLFromSnapshot(*s.Domain)
+		default:
+			return nil, fmt.Errorf("%w: 列表 %s 没有内容", ErrInvalidSnapshot, s.Name)
+		}
+		lists = append(lists, l)
+	}
+	return lists, nil
+}
+
+// SaveSnapshot 将IP ACL、域名ACL、命名列表、停用的规则组和地址族拒绝设置以二进制快照格式写入w
+//
+// 参数:
+//   - w: 输出目标
+//
+// 返回:
+//   - error: 编码或写入过程中的错误
+//
+// 快照使用encoding/gob编码，IP范围以解析后的二进制形式保存，
+// 加载时无需重新解析文本，适合在启动时快速恢复几十万条规则。
+// IP ACL编译好的匹配器也一并保存，加载时直接使用而不必重新构建（见ip.IPACLSnapshot）。
+// 快照格式只用于本库不同版本之间的数据交换，不适合人工编辑；
+// 需要可读的格式时请使用SaveIPACLToFile。
+//
+// 临时条目（AddNamedIPListEntriesTTL、TemporarilyAllow等）连同到期时间一起保存，
+// 进程重启后加载快照不会提前解封仍在封禁期内的地址；加载时已经过了到期时间的条目立即失效。
+//
+// 示例:
+//
+//	var buf bytes.Buffer
+//	if err := manager.SaveSnapshot(&buf); err != nil {
+//	    log.Fatal(err)
+//	}
+func (m *Manager) SaveSnapshot(w io.Writer) error {
+	m.mu.RLock()
+	m.ipMu.RLock()
+	m.domainMu.RLock()
+	snap := managerSnapshot{Version: snapshotVersion, DeniedFamily: m.deniedFamily}
+	if m.ipACL != nil {
+		s := m.ipACL.Snapshot()
+		snap.IP = &s
+	}
+	if m.domainACL != nil {
+		s := m.domainACL.Snapshot()
+		snap.Domain = &s
+	}
+	snap.IPLists = snapshotLists(m.ipLists)
+	snap.DomainLists = snapshotLists(m.domainLists)
+	snap.DisabledGroups = m.disabledGroupNames()
+	m.domainMu.RUnlock()
+	m.ipMu.RUnlock()
+	m.mu.RUnlock()
+
+	writer := bufio.NewWriter(w)
+	if _, err := writer.WriteString(snapshotMagic); err != nil {
+		return err
+	}
+	if err := gob.NewEncoder(writer).Encode(snap); err != nil {
+		return err
+	}
+	return writer.Flush()
+}
+
+// LoadSnapshot 从r读取SaveSnapshot生成的快照，替换当前的IP ACL、域名ACL、命名列表、停用的规则组和地址族拒绝设置
+//
+// 参数:
+//   - r: 快照数据来源
+//
+// 返回:
+//   - error: 可能的错误:
+//   - ErrInvalidSnapshot: 数据不是有效的快照或版本不受支持
+//   - ip.ErrInvalidIP: 快照中的地址数据损坏
+//
+// 加载失败时Manager保持原状态不变。
+// 快照中没有的ACL会被清除，与保存时的状态保持一致。
+func (m *Manager) LoadSnapshot(r io.Reader) error {
+	reader := bufio.NewReader(r)
+
+	header := make([]byte, len(snapshotMagic))
+	if _, err := io.ReadFull(reader, header); err != nil || string(header) != snapshotMagic {
+		return ErrInvalidSnapshot
+	}
+
+	var snap managerSnapshot
+	if err := gob.NewDecoder(reader).Decode(&snap); err != nil {
+		return fmt.Errorf("%w: %v", ErrInvalidSnapshot, err)
+	}
+	if snap.Version != snapshotVersion {
+		return fmt.Errorf("%w: 不支持的版本 %d", ErrInvalidSnapshot, snap.Version)
+	}
+
+	var ipACL *ip.IPACL
+	if snap.IP != nil {
+		var err error
+		if ipACL, err = ip.NewIPACLFromSnapshot(*snap.IP); err != nil {
+			return err
+		}
+	}
+	var domainACL *domain.DomainACL
+	if snap.Domain != nil {
+		domainACL = domain.NewDomainACLFromSnapshot(*snap.Domain)
+	}
+
+	now := m.Clock().Now()
+	ipLists, err := restoreLists(snap.IPLists, now)
+	if err != nil {
+		return err
+	}
+	domainLists, err := restoreLists(snap.DomainLists, now)
+	if err != nil {
+		return err
+	}
+	// 拦截函数通过策略了解快照的内容
+	restored := &Manager{ipACL: ipACL, domainACL: domainACL, ipLists: ipLists, domainLists: domainLists}
+	policy := restored.Policy()
+	if err := m.intercept(Change{Op: ChangeReplace, Kind: "*", Component: "*", Policy: &policy}); err != nil {
+		return err
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.ipMu.Lock()
+	defer m.ipMu.Unlock()
+	m.domainMu.Lock()
+	defer m.domainMu.Unlock()
+	m.ipACL = ipACL
+	m.domainACL = domainACL
+	m.deniedFamily = snap.DeniedFamily
+	m.ipLists = ipLists
+	m.domainLists = domainLists
+	var next time.Time
+	for _, l := range append(ipLists[:len(ipLists):len(ipLists)], domainLists...) {
+		next = earlier(next, l.nextExpiry)
+	}
+	atomic.StoreInt64(&m.nextExpiry, unixNano(next))
+	m.ipModified = now
+	m.domainModified = now
+	m.notifyChange("ip", "*", now)
+	m.notifyChange("domain", "*", now)
+	m.disabledGroups = nil
+	for _, group := range snap.DisabledGroups {
+		m.disableGroupLocked(group)
+	}
+	return nil
+}
+
+// SaveSnapshotFile 将快照原子地写入文件
+//
+// 参数:
+//   - filePath: 快照文件路径，已存在时会被替换
+//
+// 返回:
+//   - error: 写入过程中的错误
+//
+// 先写入同目录下的临时文件再重命名，进程在保存过程中退出也不会留下损坏的快照。
+//
+// 示例:
+//
+//	// 关闭前保存，下次启动时快速恢复
+//	manager.SaveSnapshotFile("/var/lib/myapp/acl.snapshot")
+func (m *Manager) SaveSnapshotFile(filePath string) error {
+	return writeFileAtomic(filePath, m.SaveSnapshot)
+}
+
+// LoadSnapshotFile 从文件加载快照
+//
+// 参数:
+//   - filePath: 快照文件路径
+//
+// 返回:
+//   - error: 文件读取错误或LoadSnapshot返回的错误
+//
+// 示例:
+//
+//	if err := manager.LoadSnapshotFile("/var/lib/myapp/acl.snapshot"); err != nil {
+//	    // 快照不存在或已损坏，回退到从文本文件加载
+//	    manager.SetIPACLFromFile("/etc/myapp/blocklist.txt", types.Blacklist)
+//	}
+func (m *Manager) LoadSnapshotFile(filePath string) error {
+	file, err := os.Open(filePath)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+	return m.LoadSnapshot(file)
+}
+
+// writeFileAtomic 通过临时文件加重命名的方式原子地写入文件
+func writeFileAtomic(filePath string, write func(w io.Writer) error) error {
+	tmp, err := os.CreateTemp(filepath.Dir(filePath), "."+filepath.Base(filePath)+".tmp-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	if err := write(tmp); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), filePath)
+}
@@ -0,0 +1,176 @@
+package acl
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/cyberspacesec/go-acl/pkg/ip"
+	"github.com/cyberspacesec/go-acl/pkg/types"
+)
+
+// TestSnapshotRoundTrip 测试快照的保存和加载
+func TestSnapshotRoundTrip(t *testing.T) {
+	source := NewManager()
+	if err := source.SetIPACL([]string{"192.168.0.0/16", "2001:db8::/32"}, types.Blacklist); err != nil {
+		t.Fatalf("SetIPACL() 返回错误: %v", err)
+	}
+	source.SetDomainACL([]string{"blocked.com"}, types.Blacklist, true)
+	source.DenyIPFamily(ip.FamilyIPv6)
+
+	var buf bytes.Buffer
+	if err := source.SaveSnapshot(&buf); err != nil {
+		t.Fatalf("SaveSnapshot() 返回错误: %v", err)
+	}
+
+	target := NewManager()
+	target.SetIPACL([]string{"8.8.8.8"}, types.Whitelist)
+	if err := target.LoadSnapshot(&buf); err != nil {
+		t.Fatalf("LoadSnapshot() 返回错误: %v", err)
+	}
+
+	if got := target.GetDeniedIPFamily(); got != ip.FamilyIPv6 {
+		t.Errorf("GetDeniedIPFamily() = %v, 期望 IPv6", got)
+	}
+	if listType, _ := target.GetIPACLType(); listType != types.Blacklist {
+		t.Errorf("GetIPACLType() = %v, 期望 Blacklist", listType)
+	}
+
+	ipTests := []struct {
+		ip   string
+		want types.Permission
+	}{
+		{"192.168.1.1", types.Denied},
+		{"8.8.8.8", types.Allowed},
+		{"2001:db9::1", types.Denied},
+	}
+	for _, tt := range ipTests {
+		if got, _ := target.CheckIP(tt.ip); got != tt.want {
+			t.Errorf("CheckIP(%s) = %v, 期望 %v", tt.ip, got, tt.want)
+		}
+	}
+	if got, _ := target.CheckDomain("www.blocked.com"); got != types.Denied {
+		t.Errorf("CheckDomain(www.blocked.com) = %v, 期望 Denied", got)
+	}
+}
+
+// TestSnapshotMissingSections 测试快照中没有的ACL会被清除
+func TestSnapshotMissingSections(t *testing.T) {
+	var buf bytes.Buffer
+	if err := NewManager().SaveSnapshot(&buf); err != nil {
+		t.Fatalf("SaveSnapshot() 返回错误: %v", err)
+	}
+
+	manager := NewManager()
+	manager.SetDomainACL([]string{"example.com"}, types.Whitelist, true)
+	if err := manager.LoadSnapshot(&buf); err != nil {
+		t.Fatalf("LoadSnapshot() 返回错误: %v", err)
+	}
+	if _, err := manager.CheckDomain("example.com"); !errors.Is(err, types.ErrNoACL) {
+		t.Errorf("CheckDomain() 错误 = %v, 期望 ErrNoACL", err)
+	}
+}
+
+// TestSnapshotInvalid 测试无效快照不会修改Manager
+func TestSnapshotInvalid(t *testing.T) {
+	var valid bytes.Buffer
+	NewManager().SaveSnapshot(&valid)
+
+	tests := []struct {
+		name string
+		data []byte
+	}{
+		{"空数据", nil},
+		{"文件头错误", []byte("192.168.1.0/24\n10.0.0.0/8\n")},
+		{"数据被截断", valid.Bytes()[:len(snapshotMagic)+2]},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			manager := NewManager()
+			manager.SetIPACL([]string{"10.0.0.0/8"}, types.Blacklist)
+
+			err := manager.LoadSnapshot(bytes.NewReader(tt.data))
+			if !errors.Is(err, ErrInvalidSnapshot) {
+				t.Errorf("LoadSnapshot() 错误 = %v, 期望 ErrInvalidSnapshot", err)
+			}
+			if got, _ := manager.CheckIP("10.1.1.1"); got != types.Denied {
+				t.Error("加载失败后Manager的状态不应改变")
+			}
+		})
+	}
+}
+
+// TestSnapshotFile 测试快照文件的保存和加载
+func TestSnapshotFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "acl.snapshot")
+
+	source := NewManager()
+	source.SetIPACL([]string{"172.16.0.0/12"}, types.Whitelist)
+	if err := source.SaveSnapshotFile(path); err != nil {
+		t.Fatalf("SaveSnapshotFile() 返回错误: %v", err)
+	}
+	// 再次保存应替换已有文件
+	if err := source.SaveSnapshotFile(path); err != nil {
+		t.Fatalf("再次SaveSnapshotFile() 返回错误: %v", err)
+	}
+
+	entries, _ := os.ReadDir(filepath.Dir(path))
+	if len(entries) != 1 {
+		t.Errorf("目录中有 %d 个文件, 临时文件应已清理", len(entries))
+	}
+
+	target := NewManager()
+	if err := target.LoadSnapshotFile(path); err != nil {
+		t.Fatalf("LoadSnapshotFile() 返回错误: %v", err)
+	}
+	if got, _ := target.CheckIP("172.20.0.1"); got != types.Allowed {
+		t.Errorf("CheckIP(172.20.0.1) = %v, 期望 Allowed", got)
+	}
+
+	if err := target.LoadSnapshotFile(filepath.Join(t.TempDir(), "missing")); !os.IsNotExist(err) {
+		t.Errorf("加载不存在的文件应返回文件不存在错误: %v", err)
+	}
+}
+
+// snapshotBenchRanges 生成基准测试使用的IP范围
+func snapshotBenchRanges(n int) []string {
+	ranges := make([]string, n)
+	for i := range ranges {
+		v := uint32(i) * 0x9E3779B1
+		ranges[i] = fmt.Sprintf("%d.%d.%d.0/24", v>>24, (v>>16)&0xff, (v>>8)&0xff)
+	}
+	return ranges
+}
+
+// BenchmarkLoadText 测试从文本解析加载10万条规则
+func BenchmarkLoadText(b *testing.B) {
+	ranges := snapshotBenchRanges(100000)
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if err := NewManager().SetIPACL(ranges, types.Blacklist); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+// BenchmarkLoadSnapshot 测试从快照加载10万条规则
+func BenchmarkLoadSnapshot(b *testing.B) {
+	source := NewManager()
+	source.SetIPACL(snapshotBenchRanges(100000), types.Blacklist)
+	var buf bytes.Buffer
+	if err := source.SaveSnapshot(&buf); err != nil {
+		b.Fatal(err)
+	}
+	data := buf.Bytes()
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if err := NewManager().LoadSnapshot(bytes.NewReader(data)); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
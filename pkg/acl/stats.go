@@ -0,0 +1,202 @@
+package acl
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"os"
+	"sync/atomic"
+	"time"
+
+	"github.com/cyberspacesec/go-acl/pkg/types"
+)
+
+// Stats 表示Manager的检查命中统计
+//
+// 字段说明:
+//   - IPAllowed / IPDenied: IP检查被允许/拒绝的次数
+//   - DomainAllowed / DomainDenied: 域名检查被允许/拒绝的次数
+//   - Errors: 检查返回错误的次数（如未配置ACL、输入无效等）
+//   - BudgetExceeded: 检查超出时间预算、使用兜底结果的次数，见SetCheckBudget。
+//     这些检查不计入Errors，兜底结果计入对应的允许/拒绝次数
+//   - ExpiredRules: 因到期被清理的临时条目数量，见AddNamedIPListEntriesTTL
+type Stats struct {
+	IPAllowed      uint64 `json:"ip_allowed"`
+	IPDenied       uint64 `json:"ip_denied"`
+	DomainAllowed  uint64 `json:"domain_allowed"`
+	DomainDenied   uint64 `json:"domain_denied"`
+	Errors         uint64 `json:"errors"`
+	BudgetExceeded uint64 `json:"budget_exceeded"`
+	ExpiredRules   uint64 `json:"expired_rules"`
+}
+
+// statsCounters 保存统计计数器，所有字段通过sync/atomic访问
+// 必须放在Manager的第一个字段以保证32位平台上的64位对齐
+type statsCounters struct {
+	ipAllowed     uint64
+	ipDenied      uint64
+	domainAllowed uint64
+	domainDenied  uint64
+	errors        uint64
+	budget        uint64
+	expired       uint64
+}
+
+// record 根据检查结果更新对应的计数器
+func (c *statsCounters) record(isIP bool, perm types.Permission, err error) {
+	switch {
+	case err != nil:
+		atomic.AddUint64(&c.errors, 1)
+	case isIP && perm == types.Allowed:
+		atomic.AddUint64(&c.ipAllowed, 1)
+	case isIP:
+		atomic.AddUint64(&c.ipDenied, 1)
+	case perm == types.Allowed:
+		atomic.AddUint64(&c.domainAllowed, 1)
+	default:
+		atomic.AddUint64(&c.domainDenied, 1)
+	}
+}
+
+// StatsStore 是统计数据的持久化存储接口
+//
+// 实现者负责保存和加载Stats，使统计数据在进程重启（如发布部署）后不会丢失。
+// 当存储中尚无数据时，LoadStats应返回零值Stats和nil错误。
+type StatsStore interface {
+	LoadStats() (Stats, error)
+	SaveStats(stats Stats) error
+}
+
+// FileStatsStore 是基于JSON文件的StatsStore实现
+//
+// 保存时先写入临时文件再重命名，保证文件内容始终完整。
+//
+// 示例:
+//
+//	store := acl.FileStatsStore{Path: "/var/lib/myapp/acl-stats.json"}
+type FileStatsStore struct {
+	Path string
+}
+
+// LoadStats 从文件加载统计数据，文件不存在时返回零值Stats
+func (s FileStatsStore) LoadStats() (Stats, error) {
+	var stats Stats
+
+	data, err := os.ReadFile(s.Path)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return stats, nil
+		}
+		return stats, err
+	}
+
+	if err := json.Unmarshal(data, &stats); err != nil {
+		return Stats{}, err
+	}
+	return stats, nil
+}
+
+// SaveStats 将统计数据以JSON格式原子地写入文件
+func (s FileStatsStore) SaveStats(stats Stats) error {
+	data, err := json.Marshal(stats)
+	if err != nil {
+		return err
+	}
+
+	return writeFileAtomic(s.Path, func(w io.Writer) error {
+		_, err := w.Write(data)
+		return err
+	})
+}
+
+// Stats 返回当前的检查命中统计快照
+//
+// 示例:
+//
+//	stats := manager.Stats()
+//	log.Printf("IP拒绝次数: %d, 域名拒绝次数: %d", stats.IPDenied, stats.DomainDenied)
+func (m *Manager) Stats() Stats {
+	return Stats{
+		IPAllowed:      atomic.LoadUint64(&m.stats.ipAllowed),
+		IPDenied:       atomic.LoadUint64(&m.stats.ipDenied),
+		DomainAllowed:  atomic.LoadUint64(&m.stats.domainAllowed),
+		DomainDenied:   atomic.LoadUint64(&m.stats.domainDenied),
+		Errors:         atomic.LoadUint64(&m.stats.errors),
+		BudgetExceeded: atomic.LoadUint64(&m.stats.budget),
+		ExpiredRules:   atomic.LoadUint64(&m.stats.expired),
+	}
+}
+
+// ResetStats 将所有统计计数器清零
+func (m *Manager) ResetStats() {
+	m.restoreStats(Stats{})
+}
+
+// LoadStats 从存储中加载统计数据并覆盖当前计数器
+//
+// 参数:
+//   - store: 统计数据存储
+//
+// 返回:
+//   - error: 加载过程中的错误
+func (m *Manager) LoadStats(store StatsStore) error {
+	stats, err := store.LoadStats()
+	if err != nil {
+		return err
+	}
+	m.restoreStats(stats)
+	return nil
+}
+
+// PersistStats 周期性地将统计数据保存到存储中，直到ctx被取消
+//
+// 参数:
+//   - ctx: 控制持久化循环生命周期的上下文
+//   - store: 统计数据存储
+//   - interval: 保存间隔，必须大于0
+//
+// 返回:
+//   - error: 启动时加载失败的错误，或ctx取消后最后一次保存的错误
+//
+// 启动时会先从存储加载已有的统计数据（重启后恢复），
+// 之后每隔interval保存一次，ctx取消时再执行最后一次保存。
+// 此方法会阻塞，通常在单独的goroutine中调用。
+//
+// 示例:
+//
+//	ctx, cancel := context.WithCancel(context.Background())
+//	defer cancel()
+//	go manager.PersistStats(ctx, acl.FileStatsStore{Path: "./stats.json"}, time.Minute)
+func (m *Manager) PersistStats(ctx context.Context, store StatsStore, interval time.Duration) error {
+	if interval <= 0 {
+		return errors.New("持久化间隔必须大于0")
+	}
+	if err := m.LoadStats(store); err != nil {
+		return err
+	}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return store.SaveStats(m.Stats())
+		case <-ticker.C:
+			// 周期性保存失败不终止循环，下一个周期会重试
+			_ = store.SaveStats(m.Stats())
+		}
+	}
+}
+
+// restoreStats 用给定的值覆盖所有计数器
+func (m *Manager) restoreStats(stats Stats) {
+	atomic.StoreUint64(&m.stats.ipAllowed, stats.IPAllowed)
+	atomic.StoreUint64(&m.stats.ipDenied, stats.IPDenied)
+	atomic.StoreUint64(&m.stats.domainAllowed, stats.DomainAllowed)
+	atomic.StoreUint64(&m.stats.domainDenied, stats.DomainDenied)
+	atomic.StoreUint64(&m.stats.errors, stats.Errors)
+	atomic.StoreUint64(&m.stats.budget, stats.BudgetExceeded)
+	atomic.StoreUint64(&m.stats.expired, stats.ExpiredRules)
+}
@@ -0,0 +1,115 @@
+package acl
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/cyberspacesec/go-acl/pkg/types"
+)
+
+// TestStats 测试检查命中统计
+func TestStats(t *testing.T) {
+	manager := NewManager()
+
+	// 未配置ACL时计为错误
+	_, _ = manager.CheckIP("8.8.8.8")
+
+	manager.SetDomainACL([]string{"example.com"}, types.Blacklist, true)
+	if err := manager.SetIPACL([]string{"10.0.0.0/8"}, types.Blacklist); err != nil {
+		t.Fatalf("SetIPACL() 返回错误: %v", err)
+	}
+
+	_, _ = manager.CheckIP("10.1.1.1")
+	_, _ = manager.CheckIP("8.8.8.8")
+	_, _ = manager.CheckIP("8.8.4.4")
+	_, _ = manager.CheckDomain("api.example.com")
+	_, _ = manager.CheckDomain("example.org")
+
+	want := Stats{IPAllowed: 2, IPDenied: 1, DomainAllowed: 1, DomainDenied: 1, Errors: 1}
+	if got := manager.Stats(); got != want {
+		t.Errorf("Stats() = %+v, 期望 %+v", got, want)
+	}
+
+	manager.ResetStats()
+	if got := manager.Stats(); got != (Stats{}) {
+		t.Errorf("ResetStats() 后 Stats() = %+v, 期望全部为0", got)
+	}
+}
+
+// TestFileStatsStore 测试基于文件的统计存储
+func TestFileStatsStore(t *testing.T) {
+	tempDir := setupTestDir(t)
+	defer cleanupTestDir(t, tempDir)
+
+	store := FileStatsStore{Path: filepath.Join(tempDir, "stats.json")}
+
+	// 文件不存在时返回零值
+	stats, err := store.LoadStats()
+	if err != nil {
+		t.Fatalf("LoadStats() 返回错误: %v", err)
+	}
+	if stats != (Stats{}) {
+		t.Errorf("LoadStats() = %+v, 期望零值", stats)
+	}
+
+	want := Stats{IPAllowed: 3, DomainDenied: 7, Errors: 1}
+	if err := store.SaveStats(want); err != nil {
+		t.Fatalf("SaveStats() 返回错误: %v", err)
+	}
+	got, err := store.LoadStats()
+	if err != nil {
+		t.Fatalf("LoadStats() 返回错误: %v", err)
+	}
+	if got != want {
+		t.Errorf("LoadStats() = %+v, 期望 %+v", got, want)
+	}
+}
+
+// TestPersistStats 测试统计数据的周期性持久化与重启恢复
+func TestPersistStats(t *testing.T) {
+	tempDir := setupTestDir(t)
+	defer cleanupTestDir(t, tempDir)
+
+	store := FileStatsStore{Path: filepath.Join(tempDir, "stats.json")}
+	if err := store.SaveStats(Stats{IPDenied: 5}); err != nil {
+		t.Fatalf("SaveStats() 返回错误: %v", err)
+	}
+
+	manager := NewManager()
+	if err := manager.SetIPACL([]string{"10.0.0.0/8"}, types.Blacklist); err != nil {
+		t.Fatalf("SetIPACL() 返回错误: %v", err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error, 1)
+	go func() {
+		done <- manager.PersistStats(ctx, store, 5*time.Millisecond)
+	}()
+
+	// 等待启动时的加载完成
+	deadline := time.Now().Add(time.Second)
+	for manager.Stats().IPDenied != 5 && time.Now().Before(deadline) {
+		time.Sleep(time.Millisecond)
+	}
+	_, _ = manager.CheckIP("10.0.0.1")
+
+	cancel()
+	if err := <-done; err != nil {
+		t.Fatalf("PersistStats() 返回错误: %v", err)
+	}
+
+	// 模拟重启: 新的Manager从存储恢复
+	restarted := NewManager()
+	if err := restarted.LoadStats(store); err != nil {
+		t.Fatalf("LoadStats() 返回错误: %v", err)
+	}
+	if got := restarted.Stats().IPDenied; got != 6 {
+		t.Errorf("恢复后 IPDenied = %d, 期望 6", got)
+	}
+
+	if err := manager.PersistStats(context.Background(), store, 0); err == nil {
+		t.Error("PersistStats() 对于非正数间隔应返回错误")
+	}
+}
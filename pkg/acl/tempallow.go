@@ -0,0 +1,111 @@
+package acl
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"time"
+
+	"github.com/cyberspacesec/go-acl/pkg/ip"
+	"github.com/cyberspacesec/go-acl/pkg/types"
+)
+
+// TemporaryAllowList 是TemporarilyAllow使用的命名IP列表的名称
+//
+// 此列表在第一次调用TemporarilyAllow时创建，优先级为TemporaryAllowPriority，
+// 检查结果的Source为"ip_list:temporary-allow"。
+const TemporaryAllowList = "temporary-allow"
+
+// TemporaryAllowPriority 是TemporaryAllowList的优先级，先于其他所有命名列表求值
+const TemporaryAllowPriority = math.MinInt
+
+// ErrTemporaryAllowList 表示名为TemporaryAllowList的命名列表已存在，但不是优先级为
+// TemporaryAllowPriority的白名单（如由SetNamedIPList创建）
+var ErrTemporaryAllowList = errors.New("临时放行列表的类型或优先级不正确")
+
+// TemporarilyAllow 在有效期内允许一个IP或CIDR，无论其他列表如何设置
+//
+// 参数:
+//   - ipRange: 要允许的IP或CIDR
+//   - ttl: 有效期，必须大于0
+//
+// 返回:
+//   - error: ipRange无效时返回ip.ErrInvalidIP等错误，ttl不大于0时返回错误，
+//     超出TemporaryAllowList的配额时返回包装了ErrQuotaExceeded的错误；
+//     已存在的同名列表不是优先级为TemporaryAllowPriority的白名单时返回ErrTemporaryAllowList
+//
+// 条目加入名为TemporaryAllowList的命名白名单，它先于其他命名列表和主列表求值，
+// 即使目标在黑名单中也会被允许，适用于客服等临时放行的场景（"让这位客户的IP访问两小时"）。
+// 被SetDeniedFamily整体拒绝的地址族、紧急模式（SetOverrideMode）和规则表达式仍然优先。
+//
+// 与普通的命名白名单不同，此列表不改变其他命名列表均未命中时的默认结果，也不会使没有配置ACL的
+// Manager变为已配置。到期和清理的方式与AddNamedIPListEntriesTTL相同，对同一条目重复调用时
+// 到期时间取两者中较晚的一个。
+//
+// 示例:
+//
+//	// 让这位客户的IP访问两小时
+//	manager.TemporarilyAllow("203.0.113.7", 2*time.Hour)
+//	go manager.RunTTLSweeper(ctx, 0)
+//
+//	// 提前结束
+//	manager.RevokeTemporaryAllow("203.0.113.7")
+func (m *Manager) TemporarilyAllow(ipRange string, ttl time.Duration) error {
+	if ttl <= 0 {
+		return errInvalidTTL
+	}
+	if _, err := ip.NewIPACL([]string{ipRange}, types.Whitelist); err != nil {
+		return err
+	}
+	change := Change{Op: ChangeAdd, Kind: "ip", Component: "ip_list:" + TemporaryAllowList, ListType: types.Whitelist, Entries: []string{ipRange}}
+	if err := m.intercept(change); err != nil {
+		return err
+	}
+
+	limit := m.quotaLimit("ip_list:" + TemporaryAllowList)
+	now := m.Clock().Now()
+
+	m.ipMu.Lock()
+	defer m.ipMu.Unlock()
+
+	l := findList(m.ipLists, TemporaryAllowList)
+	if l == nil {
+		acl, _ := ip.NewIPACL(nil, types.Whitelist)
+		m.ipLists = putList(m.ipLists, namedList{name: TemporaryAllowList, priority: TemporaryAllowPriority, modified: now, ip: acl})
+		l = findList(m.ipLists, TemporaryAllowList)
+	} else if l.ip.GetListType() != types.Whitelist || l.priority != TemporaryAllowPriority {
+		// 同名的普通列表（尤其是黑名单）不能当作临时放行使用
+		return fmt.Errorf("%w: %s为%s，优先级%d", ErrTemporaryAllowList, TemporaryAllowList, l.ip.GetListType(), l.priority)
+	}
+	return m.addIPListEntriesTTL(l, limit, now, ttl, []string{ipRange})
+}
+
+// RevokeTemporaryAllow 提前结束TemporarilyAllow对一个IP或CIDR的放行
+//
+// 参数:
+//   - ipRange: TemporarilyAllow添加的IP或CIDR，写法需与添加时相同
+//
+// 返回:
+//   - error: 没有调用过TemporarilyAllow时返回ErrListNotFound，条目不存在时返回ip.ErrIPNotFound
+func (m *Manager) RevokeTemporaryAllow(ipRange string) error {
+	change := Change{Op: ChangeRemove, Kind: "ip", Component: "ip_list:" + TemporaryAllowList, Entries: []string{ipRange}}
+	if err := m.intercept(change); err != nil {
+		return err
+	}
+	now := m.Clock().Now()
+
+	m.ipMu.Lock()
+	defer m.ipMu.Unlock()
+
+	l := findList(m.ipLists, TemporaryAllowList)
+	if l == nil {
+		return ErrListNotFound
+	}
+	if err := l.ip.Remove(ipRange); err != nil {
+		return err
+	}
+	l.clearDeadlines(ipKeys([]string{ipRange}))
+	l.modified = now
+	m.notifyChange("ip", "ip_list:"+TemporaryAllowList, now)
+	return nil
+}
@@ -0,0 +1,144 @@
+package acl
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/cyberspacesec/go-acl/pkg/ip"
+	"github.com/cyberspacesec/go-acl/pkg/types"
+)
+
+// TestTemporarilyAllow 测试临时放行优先于黑名单，到期或撤销后恢复
+func TestTemporarilyAllow(t *testing.T) {
+	start := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	clock := types.NewManualClock(start)
+	manager := NewManager()
+	manager.SetClock(clock)
+	if err := manager.SetIPACL([]string{"203.0.113.0/24"}, types.Blacklist); err != nil {
+		t.Fatalf("SetIPACL() 返回错误: %v", err)
+	}
+	if err := manager.SetNamedIPList("geo-block", []string{"198.51.100.0/24"}, types.Blacklist, -100); err != nil {
+		t.Fatalf("SetNamedIPList() 返回错误: %v", err)
+	}
+
+	if err := manager.TemporarilyAllow("203.0.113.7", 2*time.Hour); err != nil {
+		t.Fatalf("TemporarilyAllow() 返回错误: %v", err)
+	}
+	if err := manager.TemporarilyAllow("198.51.100.0/28", time.Hour); err != nil {
+		t.Fatalf("TemporarilyAllow() 返回错误: %v", err)
+	}
+
+	tests := []struct {
+		name    string
+		advance time.Duration
+		ip      string
+		want    types.Permission
+	}{
+		{"主黑名单中的IP被放行", 0, "203.0.113.7", types.Allowed},
+		{"命名黑名单中的网段被放行", 0, "198.51.100.3", types.Allowed},
+		{"其他IP不受影响", 0, "203.0.113.8", types.Denied},
+		{"较短的放行到期", 90 * time.Minute, "198.51.100.3", types.Denied},
+		{"较长的放行仍有效", 0, "203.0.113.7", types.Allowed},
+		{"全部到期", time.Hour, "203.0.113.7", types.Denied},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			clock.Advance(tt.advance)
+			result, err := manager.CheckIPDetailed(nil, tt.ip)
+			if err != nil {
+				t.Fatalf("CheckIPDetailed() 返回错误: %v", err)
+			}
+			if result.Decision != tt.want {
+				t.Errorf("CheckIPDetailed(%s) = %+v, 期望 %v", tt.ip, result, tt.want)
+			}
+			if tt.want == types.Allowed && result.Source != "ip_list:"+TemporaryAllowList {
+				t.Errorf("Source = %s, 期望来自临时放行列表", result.Source)
+			}
+		})
+	}
+
+	if err := manager.TemporarilyAllow("203.0.113.9", time.Hour); err != nil {
+		t.Fatalf("TemporarilyAllow() 返回错误: %v", err)
+	}
+	if err := manager.RevokeTemporaryAllow("203.0.113.9"); err != nil {
+		t.Fatalf("RevokeTemporaryAllow() 返回错误: %v", err)
+	}
+	if perm, _ := manager.CheckIP("203.0.113.9"); perm != types.Denied {
+		t.Errorf("撤销后 CheckIP() = %v, 期望 %v", perm, types.Denied)
+	}
+	if err := manager.RevokeTemporaryAllow("203.0.113.9"); !errors.Is(err, ip.ErrIPNotFound) {
+		t.Errorf("重复撤销的错误 = %v, 期望 %v", err, ip.ErrIPNotFound)
+	}
+}
+
+// TestTemporarilyAllowDefault 测试临时放行列表不改变默认结果
+func TestTemporarilyAllowDefault(t *testing.T) {
+	manager := NewManager()
+	if err := manager.RevokeTemporaryAllow("192.0.2.1"); !errors.Is(err, ErrListNotFound) {
+		t.Errorf("RevokeTemporaryAllow() 错误 = %v, 期望 %v", err, ErrListNotFound)
+	}
+	if err := manager.TemporarilyAllow("192.0.2.1", time.Hour); err != nil {
+		t.Fatalf("TemporarilyAllow() 返回错误: %v", err)
+	}
+
+	// 只有临时放行列表时，未命中的IP仍按未配置ACL处理，而不是被白名单拒绝
+	if _, err := manager.CheckIP("192.0.2.2"); !errors.Is(err, types.ErrNoACL) {
+		t.Errorf("CheckIP() 错误 = %v, 期望 %v", err, types.ErrNoACL)
+	}
+
+	if err := manager.SetNamedIPList("bans", []string{"198.51.100.1"}, types.Blacklist, 0); err != nil {
+		t.Fatalf("SetNamedIPList() 返回错误: %v", err)
+	}
+	if perm, err := manager.CheckIP("192.0.2.2"); err != nil || perm != types.Allowed {
+		t.Errorf("CheckIP() = %v, %v, 期望只有黑名单时默认允许", perm, err)
+	}
+
+	tests := []struct {
+		name    string
+		ipRange string
+		ttl     time.Duration
+	}{
+		{"无效的IP", "not-an-ip", time.Hour},
+		{"有效期为0", "192.0.2.3", 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := manager.TemporarilyAllow(tt.ipRange, tt.ttl); err == nil {
+				t.Error("TemporarilyAllow() 应返回错误")
+			}
+		})
+	}
+}
+
+// TestTemporarilyAllowExistingList 测试同名列表不是临时放行白名单时返回错误且不添加条目
+func TestTemporarilyAllowExistingList(t *testing.T) {
+	tests := []struct {
+		name     string
+		listType types.ListType
+		priority int
+		wantErr  error
+	}{
+		{"黑名单", types.Blacklist, TemporaryAllowPriority, ErrTemporaryAllowList},
+		{"优先级不同的白名单", types.Whitelist, 0, ErrTemporaryAllowList},
+		{"临时放行白名单", types.Whitelist, TemporaryAllowPriority, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			manager := NewManager()
+			if err := manager.SetNamedIPList(TemporaryAllowList, nil, tt.listType, tt.priority); err != nil {
+				t.Fatalf("SetNamedIPList() 返回错误: %v", err)
+			}
+			if err := manager.TemporarilyAllow("203.0.113.7", time.Hour); !errors.Is(err, tt.wantErr) {
+				t.Fatalf("TemporarilyAllow() 错误 = %v, 期望 %v", err, tt.wantErr)
+			}
+			want := 0
+			if tt.wantErr == nil {
+				want = 1
+			}
+			if lists := manager.NamedIPLists(); len(lists) != 1 || lists[0].Size != want {
+				t.Errorf("NamedIPLists() = %+v, 期望 %d 条规则", lists, want)
+			}
+		})
+	}
+}
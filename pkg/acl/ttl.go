@@ -0,0 +1,356 @@
+package acl
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"github.com/cyberspacesec/go-acl/pkg/domain"
+)
+
+// DefaultSweepInterval 是RunTTLSweeper的interval不大于0时使用的清理间隔
+const DefaultSweepInterval = time.Minute
+
+// errInvalidTTL 表示临时条目的有效期不大于0
+var errInvalidTTL = errors.New("有效期必须大于0")
+
+// AddNamedIPListEntriesTTL 向命名IP列表添加一个或多个临时的IP或CIDR
+//
+// 参数:
+//   - name: 列表名称
+//   - ttl: 有效期，必须大于0
+//   - ipRanges: 要添加的IP或CIDR
+//
+// 返回:
+//   - error: 与AddNamedIPListEntries相同，ttl不大于0时返回错误
+//
+// 条目在ttl之后到期。到期的条目在检查时立即失效，并由RunTTLSweeper（或SweepExpired）批量移除，
+// 不为每个条目创建定时器，适合十万级的临时封禁。
+//
+// 已经是临时条目的，到期时间延长到两者中较晚的一个；已经是永久条目的保持永久。
+// 之后用AddNamedIPListEntries添加同一条目会使其成为永久条目，
+// SetNamedIPList替换列表内容时所有条目都成为永久条目。
+//
+// 示例:
+//
+//	manager.SetNamedIPList("temp-bans", nil, types.Blacklist, 20)
+//	go manager.RunTTLSweeper(ctx, 30*time.Second)
+//
+//	// 封禁一小时
+//	manager.AddNamedIPListEntriesTTL("temp-bans", time.Hour, "198.51.100.7")
+func (m *Manager) AddNamedIPListEntriesTTL(name string, ttl time.Duration, ipRanges ...string) error {
+	if ttl <= 0 {
+		return errInvalidTTL
+	}
+	if err := m.intercept(Change{Op: ChangeAdd, Kind: "ip", Component: "ip_list:" + name, Entries: ipRanges}); err != nil {
+		return err
+	}
+	component := "ip_list:" + name
+	limit := m.quotaLimit(component)
+	now := m.Clock().Now()
+
+	m.ipMu.Lock()
+	defer m.ipMu.Unlock()
+
+	l := findList(m.ipLists, name)
+	if l == nil {
+		return ErrListNotFound
+	}
+	return m.addIPListEntriesTTL(l, limit, now, ttl, ipRanges)
+}
+
+// addIPListEntriesTTL 向命名IP列表l添加临时条目，调用方需持有ipMu的写锁
+func (m *Manager) addIPListEntriesTTL(l *namedList, limit int, now time.Time, ttl time.Duration, ipRanges []string) error {
+	if err := quotaError("ip_list:"+l.name, limit, len(l.ip.GetIPRanges())+newIPEntries(l.ip, ipRanges)); err != nil {
+		return err
+	}
+	before := stringSet(l.ip.GetIPRanges())
+	err := l.ip.Add(ipRanges...)
+	// 出错前已加入的条目同样是临时的
+	l.setDeadlines(ipKeys(ipRanges), before, l.ip.GetIPRanges(), now.Add(ttl))
+	l.modified = now
+	m.notifyChange("ip", "ip_list:"+l.name, now)
+	m.noteExpiry(l.nextExpiry)
+	return err
+}
+
+// AddNamedDomainListEntriesTTL 向命名域名列表添加一个或多个临时的域名
+//
+// 参数:
+//   - name: 列表名称
+//   - ttl: 有效期，必须大于0
+//   - domains: 要添加的域名，格式无效的域名被忽略
+//
+// 返回:
+//   - error: 与AddNamedDomainListEntries相同，ttl不大于0时返回错误
+//
+// 到期和清理的方式与AddNamedIPListEntriesTTL相同。
+func (m *Manager) AddNamedDomainListEntriesTTL(name string, ttl time.Duration, domains ...string) error {
+	if ttl <= 0 {
+		return errInvalidTTL
+	}
+	component := "domain_list:" + name
+	if err := m.intercept(Change{Op: ChangeAdd, Kind: "domain", Component: component, Entries: domains}); err != nil {
+		return err
+	}
+	limit := m.quotaLimit(component)
+	now := m.Clock().Now()
+	deadline := now.Add(ttl)
+
+	m.domainMu.Lock()
+	defer m.domainMu.Unlock()
+
+	l := findList(m.domainLists, name)
+	if l == nil {
+		return ErrListNotFound
+	}
+	if err := quotaError(component, limit, len(l.domain.GetDomains())+newDomainEntries(l.domain, domains)); err != nil {
+		return err
+	}
+	before := stringSet(l.domain.GetDomains())
+	l.domain.Add(domains...)
+	l.setDeadlines(domainKeys(domains), before, l.domain.GetDomains(), deadline)
+	l.modified = now
+	m.notifyChange("domain", "domain_list:"+name, now)
+	m.noteExpiry(l.nextExpiry)
+	return nil
+}
+
+// SweepExpired 立即移除所有命名列表中已到期的临时条目
+//
+// 返回:
+//   - int: 移除的条目数量，同时计入Stats.ExpiredRules
+//
+// 每个列表中到期的条目一次性移除，匹配器只重建一次。通常由RunTTLSweeper周期性调用。
+func (m *Manager) SweepExpired() int {
+	now := m.Clock().Now()
+
+	m.ipMu.Lock()
+	defer m.ipMu.Unlock()
+	m.domainMu.Lock()
+	defer m.domainMu.Unlock()
+
+	removed := 0
+	var next time.Time
+	for _, lists := range [][]namedList{m.ipLists, m.domainLists} {
+		for i := range lists {
+			removed += lists[i].sweep(now)
+			next = earlier(next, lists[i].nextExpiry)
+		}
+	}
+	atomic.StoreInt64(&m.nextExpiry, unixNano(next))
+	atomic.AddUint64(&m.stats.expired, uint64(removed))
+	if removed > 0 {
+		m.notifyChange("ip", "*", now)
+		m.notifyChange("domain", "*", now)
+	}
+	return removed
+}
+
+// RunTTLSweeper 每隔interval移除一次到期的临时条目，直到ctx被取消
+//
+// 参数:
+//   - ctx: 控制清理循环生命周期的上下文
+//   - interval: 清理间隔，不大于0时使用DefaultSweepInterval
+//
+// 返回:
+//   - error: ctx被取消时返回ctx.Err()
+//
+// 清理间隔只影响到期条目占用的内存和检查开销，不影响检查结果:
+// 到期但尚未清理的条目在检查时就已失效，只是命中它们的检查需要逐条核对所在列表的条目。
+// 间隔越短，这一开销持续的时间越短，但每次清理都会重建含有到期条目的列表的匹配器。
+// 此方法会阻塞，通常在单独的goroutine中调用。
+//
+// 示例:
+//
+//	ctx, cancel := context.WithCancel(context.Background())
+//	defer cancel()
+//	go manager.RunTTLSweeper(ctx, 30*time.Second)
+func (m *Manager) RunTTLSweeper(ctx context.Context, interval time.Duration) error {
+	if interval <= 0 {
+		interval = DefaultSweepInterval
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-ticker.C:
+			m.SweepExpired()
+		}
+	}
+}
+
+// expiryTime 返回校验临时条目使用的当前时间，没有到期的条目时返回零值
+//
+// 没有临时条目时不读取时钟，检查的开销与不使用临时条目时相同。
+func (m *Manager) expiryTime() time.Time {
+	next := atomic.LoadInt64(&m.nextExpiry)
+	if next == 0 {
+		return time.Time{}
+	}
+	now := m.Clock().Now()
+	if now.UnixNano() < next {
+		return time.Time{}
+	}
+	return now
+}
+
+// noteExpiry 将最早的到期时间更新为不晚于deadline，调用方需持有对应列表的写锁
+func (m *Manager) noteExpiry(deadline time.Time) {
+	if deadline.IsZero() {
+		return
+	}
+	d := deadline.UnixNano()
+	for {
+		cur := atomic.LoadInt64(&m.nextExpiry)
+		if cur != 0 && cur <= d {
+			return
+		}
+		if atomic.CompareAndSwapInt64(&m.nextExpiry, cur, d) {
+			return
+		}
+	}
+}
+
+// setDeadlines 记录临时条目的到期时间，调用方需持有对应的写锁
+//
+// keys是标准化后的条目，before是添加前列表中的条目，entries是添加后列表中的条目。
+// 添加前已存在的永久条目保持永久，没有加入列表的条目被忽略。
+func (l *namedList) setDeadlines(keys []string, before map[string]struct{}, entries []string, deadline time.Time) {
+	present := stringSet(entries)
+	for _, key := range keys {
+		if _, ok := present[key]; !ok {
+			continue
+		}
+		old, temporary := l.expiry[key]
+		if _, existed := before[key]; existed && !temporary {
+			continue
+		}
+		if temporary && old.After(deadline) {
+			continue
+		}
+		if l.expiry == nil {
+			l.expiry = make(map[string]time.Time)
+		}
+		l.expiry[key] = deadline
+		l.nextExpiry = earlier(l.nextExpiry, deadline)
+	}
+}
+
+// clearDeadlines 使条目成为永久条目，调用方需持有对应的写锁
+func (l *namedList) clearDeadlines(keys []string) {
+	for _, key := range keys {
+		delete(l.expiry, key)
+	}
+}
+
+// sweep 移除到期的条目并重新计算nextExpiry，返回移除的数量，调用方需持有对应的写锁
+func (l *namedList) sweep(now time.Time) int {
+	if l.nextExpiry.IsZero() || now.Before(l.nextExpiry) {
+		return 0
+	}
+
+	var expired []string
+	l.nextExpiry = time.Time{}
+	for key, deadline := range l.expiry {
+		if !now.Before(deadline) {
+			expired = append(expired, key)
+			delete(l.expiry, key)
+			continue
+		}
+		l.nextExpiry = earlier(l.nextExpiry, deadline)
+	}
+	if len(expired) == 0 {
+		return 0
+	}
+
+	// 条目可能已被其他方式移除，未找到的条目不算错误
+	if l.ip != nil {
+		_ = l.ip.Remove(expired...)
+	} else {
+		_ = l.domain.Remove(expired...)
+	}
+	return len(expired)
+}
+
+// expiredHit 判断列表对target的命中是否只来自到期的条目，调用方需持有对应的读锁
+//
+// now为零值（没有到期的条目）或列表中没有到期的条目时直接返回false；
+// 否则逐条核对命中的条目，开销与列表长度成正比，直到下一次清理。
+// 节点策略（domain.DomainACL.SetPolicy）不是临时条目，命中时总是有效。
+func (l namedList) expiredHit(now time.Time, target string) bool {
+	if now.IsZero() || l.nextExpiry.IsZero() || now.Before(l.nextExpiry) {
+		return false
+	}
+
+	var matched []string
+	if l.ip != nil {
+		matched, _ = l.ip.MatchAll(target)
+	} else {
+		if _, _, ok := l.domain.MatchPolicy(target); ok {
+			return false
+		}
+		matched = l.domain.MatchAll(target)
+	}
+	if len(matched) == 0 {
+		return false
+	}
+	for _, key := range matched {
+		if deadline, ok := l.expiry[key]; !ok || now.Before(deadline) {
+			return false
+		}
+	}
+	return true
+}
+
+// ipKeys 返回IP条目在列表中的写法，与ip.IPACL.Add相同
+func ipKeys(ipRanges []string) []string {
+	keys := make([]string, 0, len(ipRanges))
+	for _, r := range ipRanges {
+		if r = strings.TrimSpace(r); r != "" {
+			keys = append(keys, r)
+		}
+	}
+	return keys
+}
+
+// domainKeys 返回域名条目在列表中的写法，与domain.DomainACL.Add相同，无效的域名被忽略
+func domainKeys(domains []string) []string {
+	keys := make([]string, 0, len(domains))
+	for _, d := range domains {
+		if rule, err := domain.ParseRule(d); err == nil {
+			keys = append(keys, rule.String())
+		}
+	}
+	return keys
+}
+
+// stringSet 返回字符串集合
+func stringSet(values []string) map[string]struct{} {
+	set := make(map[string]struct{}, len(values))
+	for _, v := range values {
+		set[v] = struct{}{}
+	}
+	return set
+}
+
+// earlier 返回两个时间中较早的一个，零值表示没有时间
+func earlier(a, b time.Time) time.Time {
+	if a.IsZero() || (!b.IsZero() && b.Before(a)) {
+		return b
+	}
+	return a
+}
+
+// unixNano 返回时间的UnixNano，零值返回0
+func unixNano(t time.Time) int64 {
+	if t.IsZero() {
+		return 0
+	}
+	return t.UnixNano()
+}
@@ -0,0 +1,234 @@
+package acl
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/cyberspacesec/go-acl/pkg/types"
+)
+
+// newTTLManager 返回使用手动时钟、带有一个空的命名黑名单的管理器
+func newTTLManager(t *testing.T) (*Manager, *types.ManualClock) {
+	t.Helper()
+	clock := types.NewManualClock(time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC))
+	manager := NewManager()
+	manager.SetClock(clock)
+	if err := manager.SetNamedIPList("temp-bans", nil, types.Blacklist, 10); err != nil {
+		t.Fatalf("SetNamedIPList() 返回错误: %v", err)
+	}
+	manager.SetNamedDomainList("temp-blocks", nil, types.Blacklist, false, 10)
+	return manager, clock
+}
+
+// TestNamedListEntriesTTL 测试临时条目的到期、惰性校验和批量清理
+func TestNamedListEntriesTTL(t *testing.T) {
+	manager, clock := newTTLManager(t)
+
+	if err := manager.AddNamedIPListEntriesTTL("temp-bans", time.Minute, "198.51.100.7"); err != nil {
+		t.Fatalf("AddNamedIPListEntriesTTL() 返回错误: %v", err)
+	}
+	if err := manager.AddNamedIPListEntriesTTL("temp-bans", time.Hour, "198.51.100.8", "203.0.113.0/24"); err != nil {
+		t.Fatalf("AddNamedIPListEntriesTTL() 返回错误: %v", err)
+	}
+	if err := manager.AddNamedDomainListEntriesTTL("temp-blocks", time.Minute, "Bad.Example.COM"); err != nil {
+		t.Fatalf("AddNamedDomainListEntriesTTL() 返回错误: %v", err)
+	}
+
+	checks := func(stage string, want map[string]types.Permission) {
+		t.Helper()
+		for target, perm := range want {
+			var got types.Permission
+			var err error
+			if strings.Contains(target, ".com") {
+				got, err = manager.CheckDomain(target)
+			} else {
+				got, err = manager.CheckIP(target)
+			}
+			if err != nil || got != perm {
+				t.Errorf("%s: 检查 %s = %v, %v; 期望 %v", stage, target, got, err, perm)
+			}
+		}
+	}
+
+	checks("到期前", map[string]types.Permission{
+		"198.51.100.7":    types.Denied,
+		"198.51.100.8":    types.Denied,
+		"203.0.113.9":     types.Denied,
+		"bad.example.com": types.Denied,
+	})
+
+	// 到期后立即失效，不依赖清理
+	clock.Advance(time.Minute)
+	checks("到期后", map[string]types.Permission{
+		"198.51.100.7":    types.Allowed,
+		"198.51.100.8":    types.Denied,
+		"bad.example.com": types.Allowed,
+	})
+	if e := manager.Explain("198.51.100.7"); e.Decision != types.Allowed {
+		t.Errorf("Explain() 的结果 = %v, 期望与检查一致", e.Decision)
+	}
+	if infos := manager.NamedIPLists(); infos[0].Size != 3 || infos[0].Temporary != 3 {
+		t.Errorf("清理前 NamedIPLists() = %+v, 期望3条临时条目", infos[0])
+	}
+
+	if removed := manager.SweepExpired(); removed != 2 {
+		t.Errorf("SweepExpired() = %d, 期望 2", removed)
+	}
+	if got := manager.Stats().ExpiredRules; got != 2 {
+		t.Errorf("Stats().ExpiredRules = %d, 期望 2", got)
+	}
+	if infos := manager.NamedIPLists(); infos[0].Size != 2 || infos[0].Temporary != 2 {
+		t.Errorf("清理后 NamedIPLists() = %+v, 期望2条临时条目", infos[0])
+	}
+	if infos := manager.NamedDomainLists(); infos[0].Size != 0 {
+		t.Errorf("清理后 NamedDomainLists() = %+v, 期望为空", infos[0])
+	}
+
+	clock.Advance(time.Hour)
+	checks("全部到期后", map[string]types.Permission{"198.51.100.8": types.Allowed, "203.0.113.9": types.Allowed})
+	if removed := manager.SweepExpired(); removed != 2 {
+		t.Errorf("SweepExpired() = %d, 期望 2", removed)
+	}
+	if removed := manager.SweepExpired(); removed != 0 {
+		t.Errorf("再次 SweepExpired() = %d, 期望 0", removed)
+	}
+}
+
+// TestNamedListEntriesTTLOverlap 测试到期条目与列表中其他条目重叠时的结果
+func TestNamedListEntriesTTLOverlap(t *testing.T) {
+	manager, clock := newTTLManager(t)
+	if err := manager.AddNamedIPListEntries("temp-bans", "203.0.113.0/24"); err != nil {
+		t.Fatalf("AddNamedIPListEntries() 返回错误: %v", err)
+	}
+	if err := manager.AddNamedIPListEntriesTTL("temp-bans", time.Minute, "203.0.113.7"); err != nil {
+		t.Fatalf("AddNamedIPListEntriesTTL() 返回错误: %v", err)
+	}
+
+	clock.Advance(time.Minute)
+	// 永久的/24仍然命中
+	if perm, err := manager.CheckIP("203.0.113.7"); err != nil || perm != types.Denied {
+		t.Errorf("CheckIP() = %v, %v; 期望 Denied", perm, err)
+	}
+}
+
+// TestNamedListEntriesTTLRenewal 测试临时条目的延期和转为永久条目
+func TestNamedListEntriesTTLRenewal(t *testing.T) {
+	manager, clock := newTTLManager(t)
+
+	manager.AddNamedIPListEntriesTTL("temp-bans", time.Minute, "198.51.100.7", "198.51.100.8")
+	// 延期到较晚的时间，较短的有效期不会缩短已有的期限
+	manager.AddNamedIPListEntriesTTL("temp-bans", time.Hour, "198.51.100.7")
+	manager.AddNamedIPListEntriesTTL("temp-bans", time.Second, "198.51.100.7")
+	// 转为永久条目
+	manager.AddNamedIPListEntries("temp-bans", "198.51.100.8")
+	// 已有的永久条目不会变成临时条目
+	manager.AddNamedIPListEntries("temp-bans", "198.51.100.9")
+	manager.AddNamedIPListEntriesTTL("temp-bans", time.Second, "198.51.100.9")
+
+	clock.Advance(2 * time.Minute)
+	manager.SweepExpired()
+	for _, addr := range []string{"198.51.100.7", "198.51.100.8", "198.51.100.9"} {
+		if perm, _ := manager.CheckIP(addr); perm != types.Denied {
+			t.Errorf("CheckIP(%s) = %v, 期望 Denied", addr, perm)
+		}
+	}
+
+	clock.Advance(time.Hour)
+	if perm, _ := manager.CheckIP("198.51.100.7"); perm != types.Allowed {
+		t.Errorf("延期的条目到期后 CheckIP() = %v, 期望 Allowed", perm)
+	}
+
+	// 替换列表内容后不再有临时条目
+	manager.AddNamedIPListEntriesTTL("temp-bans", time.Minute, "192.0.2.1")
+	manager.SetNamedIPList("temp-bans", []string{"192.0.2.1"}, types.Blacklist, 10)
+	clock.Advance(time.Hour)
+	if perm, _ := manager.CheckIP("192.0.2.1"); perm != types.Denied {
+		t.Errorf("SetNamedIPList() 后 CheckIP() = %v, 期望 Denied", perm)
+	}
+}
+
+// TestNamedListEntriesTTLErrors 测试临时条目的参数错误
+func TestNamedListEntriesTTLErrors(t *testing.T) {
+	manager, _ := newTTLManager(t)
+
+	if err := manager.AddNamedIPListEntriesTTL("temp-bans", 0, "192.0.2.1"); err == nil {
+		t.Error("ttl为0时应返回错误")
+	}
+	if err := manager.AddNamedIPListEntriesTTL("missing", time.Minute, "192.0.2.1"); !errors.Is(err, ErrListNotFound) {
+		t.Errorf("列表不存在时返回 %v, 期望 ErrListNotFound", err)
+	}
+	if err := manager.AddNamedDomainListEntriesTTL("missing", time.Minute, "example.com"); !errors.Is(err, ErrListNotFound) {
+		t.Errorf("列表不存在时返回 %v, 期望 ErrListNotFound", err)
+	}
+
+	manager.SetListQuota("ip_list:temp-bans", 1)
+	if err := manager.AddNamedIPListEntriesTTL("temp-bans", time.Minute, "192.0.2.1", "192.0.2.2"); !errors.Is(err, ErrQuotaExceeded) {
+		t.Errorf("超出配额时返回 %v, 期望 ErrQuotaExceeded", err)
+	}
+
+	// 无效的条目之前已加入的条目同样是临时的
+	manager.SetListQuota("ip_list:temp-bans", 0)
+	if err := manager.AddNamedIPListEntriesTTL("temp-bans", time.Minute, "192.0.2.1", "invalid"); err == nil {
+		t.Error("无效的IP应返回错误")
+	}
+	if infos := manager.NamedIPLists(); infos[0].Temporary != 1 {
+		t.Errorf("NamedIPLists() = %+v, 期望1条临时条目", infos[0])
+	}
+}
+
+// TestNamedListEntriesTTLSnapshot 测试快照保存临时条目的到期时间
+func TestNamedListEntriesTTLSnapshot(t *testing.T) {
+	manager, clock := newTTLManager(t)
+	manager.AddNamedDomainListEntriesTTL("temp-blocks", time.Minute, "suffix:.ads.example.com")
+
+	var buf bytes.Buffer
+	if err := manager.SaveSnapshot(&buf); err != nil {
+		t.Fatalf("SaveSnapshot() 返回错误: %v", err)
+	}
+	restored := NewManager()
+	restored.SetClock(clock)
+	if err := restored.LoadSnapshot(&buf); err != nil {
+		t.Fatalf("LoadSnapshot() 返回错误: %v", err)
+	}
+	if perm, _ := restored.CheckDomain("x.ads.example.com"); perm != types.Denied {
+		t.Errorf("到期前 CheckDomain() = %v, 期望 Denied", perm)
+	}
+
+	clock.Advance(time.Minute)
+	if perm, _ := restored.CheckDomain("x.ads.example.com"); perm != types.Allowed {
+		t.Errorf("到期后 CheckDomain() = %v, 期望 Allowed", perm)
+	}
+	if removed := restored.SweepExpired(); removed != 1 {
+		t.Errorf("SweepExpired() = %d, 期望 1", removed)
+	}
+	if infos := restored.NamedDomainLists(); infos[0].Size != 0 {
+		t.Errorf("清理后 NamedDomainLists() = %+v, 期望为空", infos[0])
+	}
+}
+
+// TestRunTTLSweeper 测试后台清理循环
+func TestRunTTLSweeper(t *testing.T) {
+	manager, clock := newTTLManager(t)
+	manager.AddNamedIPListEntriesTTL("temp-bans", time.Minute, "198.51.100.7")
+	clock.Advance(time.Minute)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error, 1)
+	go func() { done <- manager.RunTTLSweeper(ctx, time.Millisecond) }()
+
+	deadline := time.Now().Add(5 * time.Second)
+	for manager.Stats().ExpiredRules == 0 && time.Now().Before(deadline) {
+		time.Sleep(time.Millisecond)
+	}
+	cancel()
+	if err := <-done; !errors.Is(err, context.Canceled) {
+		t.Errorf("RunTTLSweeper() = %v, 期望 context.Canceled", err)
+	}
+	if infos := manager.NamedIPLists(); infos[0].Size != 0 {
+		t.Errorf("NamedIPLists() = %+v, 期望到期的条目已被清理", infos[0])
+	}
+}
@@ -0,0 +1,86 @@
+package acl
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"github.com/cyberspacesec/go-acl/pkg/domain"
+	"github.com/cyberspacesec/go-acl/pkg/expr"
+	"github.com/cyberspacesec/go-acl/pkg/ip"
+	"github.com/cyberspacesec/go-acl/pkg/types"
+)
+
+// ErrInvalidURL 表示CheckURL的输入不是带协议和主机的URL
+var ErrInvalidURL = errors.New("无效的URL")
+
+// CheckURL 检查完整的URL是否允许访问
+//
+// 参数:
+//   - rawURL: 带协议和主机的URL，例如"https://api.example.com:8443/v1/items?id=7"
+//
+// 返回:
+//   - types.Permission: 访问权限结果
+//   - error: 与CheckRequest相同的错误；输入无法解析或缺少协议、主机时返回包装了ErrInvalidURL的错误；
+//     启用SetStrictHostnames时，主机部分无效返回包装了domain.ErrInvalidHostname的错误
+//
+// 与CheckHost不同，CheckURL按net/url解析输入，检查的主机与net/http实际连接的主机相同，
+// 利用解析差异的输入（如"http://a.example.com\@127.0.0.1/"）在net/url中无效，直接被拒绝。
+// 主机是IP（包括十进制、十六进制等混淆写法）时检查IP ACL，否则检查域名ACL；
+// 连同URL中的端口（没有时为协议的默认端口，如https为443）通过CheckRequest求值，
+// 因此规则表达式、SetIPRulePorts的端口限制和外部授权同样生效。
+//
+// CheckURL不限制协议，需要拒绝file、gopher等协议时使用OutboundChecker。
+//
+// 示例:
+//
+//	perm, err := manager.CheckURL("http://0x7f000001:8080/admin")
+//	if err != nil || perm == types.Denied {
+//	    return fmt.Errorf("拒绝访问 %s", webhookURL)
+//	}
+func (m *Manager) CheckURL(rawURL string) (types.Permission, error) {
+	result, err := m.CheckURLDetailed(context.Background(), rawURL)
+	return result.Decision, err
+}
+
+// CheckURLDetailed 与CheckURL相同，返回CheckRequestDetailed的结果
+//
+// 参数:
+//   - ctx: 请求上下文，可携带请求ID
+//   - rawURL: 带协议和主机的URL
+//
+// 返回:
+//   - types.CheckResult: Kind为"request"的检查结果，Target为按主机和端口描述的请求（如"domain=api.example.com port=443"）；
+//     URL无效时Target为原始输入，原因为types.ReasonInvalidInput
+//   - error: 与CheckURL相同的错误
+func (m *Manager) CheckURLDetailed(ctx context.Context, rawURL string) (types.CheckResult, error) {
+	u, err := url.Parse(strings.TrimSpace(rawURL))
+	if err != nil || u.Scheme == "" || u.Hostname() == "" {
+		invalid := types.CheckResult{Target: rawURL, Kind: "request", Decision: types.Denied, Reason: types.ReasonInvalidInput}
+		err = fmt.Errorf("%w: %q", ErrInvalidURL, rawURL)
+		// 与无效的主机名一样计入域名检查的统计和审计事件
+		m.stats.record(false, invalid.Decision, err)
+		m.audit(ctx, "domain", rawURL, invalid, err)
+		return invalid, err
+	}
+	if result, err := m.validateHost(ctx, u.Host); err != nil {
+		return result, err
+	}
+
+	scheme := strings.ToLower(u.Scheme)
+	req := expr.Request{Port: defaultPorts[scheme]}
+	if port, err := strconv.Atoi(u.Port()); err == nil {
+		req.Port = port
+	}
+	// 与CheckHost相同，先标准化再判断是否为IP，"127.0.0.1."这类带末尾点的IP同样按IP检查
+	host := domain.Normalize(u.Hostname())
+	if parsed, ok := ip.CanonicalizeIP(host); ok {
+		req.IP = parsed.String()
+	} else {
+		req.Domain = host
+	}
+	return m.CheckRequestDetailed(ctx, req)
+}
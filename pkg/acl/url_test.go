@@ -0,0 +1,74 @@
+package acl
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/cyberspacesec/go-acl/pkg/domain"
+	"github.com/cyberspacesec/go-acl/pkg/expr"
+	"github.com/cyberspacesec/go-acl/pkg/types"
+)
+
+// TestCheckURL 测试按URL的主机和端口选择检查的ACL
+func TestCheckURL(t *testing.T) {
+	manager := NewManager()
+	if err := manager.SetIPACL([]string{"127.0.0.0/8", "10.0.0.0/8"}, types.Blacklist); err != nil {
+		t.Fatal(err)
+	}
+	manager.SetDomainACL([]string{"evil.example"}, types.Blacklist, true)
+	manager.SetRules(expr.RuleSet{expr.MustCompile("domain == api.example.com && port != 443 -> deny")})
+
+	tests := []struct {
+		url     string
+		want    types.Permission
+		target  string
+		wantErr error
+	}{
+		{"https://api.example.com/v1/items?id=7", types.Allowed, "domain=api.example.com port=443", nil},
+		{"http://api.example.com/", types.Denied, "domain=api.example.com port=80", nil},
+		{"HTTPS://Download.Evil.Example./file", types.Denied, "domain=download.evil.example port=443", nil},
+		{"http://0x7f000001:8080/admin", types.Denied, "ip=127.0.0.1 port=8080", nil},
+		{"http://127.0.0.1./", types.Denied, "ip=127.0.0.1 port=80", nil},
+		{"http://2130706433.:8080/", types.Denied, "ip=127.0.0.1 port=8080", nil},
+		{"http://0x7f.1./", types.Denied, "ip=127.0.0.1 port=80", nil},
+		{"http://user:pw@10.1.2.3/", types.Denied, "ip=10.1.2.3 port=80", nil},
+		{"http://[::1]/", types.Allowed, "ip=::1 port=80", nil},
+		{"ftp://198.51.100.7/pub", types.Allowed, "ip=198.51.100.7 port=21", nil},
+		{"api.example.com/v1", types.Denied, "api.example.com/v1", ErrInvalidURL},
+		{"//api.example.com/v1", types.Denied, "//api.example.com/v1", ErrInvalidURL},
+		{"http://a.example.com\\@127.0.0.1/", types.Denied, "http://a.example.com\\@127.0.0.1/", ErrInvalidURL},
+		{"http://localhost%00.evil.com/", types.Denied, "http://localhost%00.evil.com/", ErrInvalidURL},
+	}
+	for _, tt := range tests {
+		t.Run(tt.url, func(t *testing.T) {
+			result, err := manager.CheckURLDetailed(context.Background(), tt.url)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("CheckURLDetailed() 错误 = %v, 期望 %v", err, tt.wantErr)
+			}
+			if result.Decision != tt.want || result.Target != tt.target {
+				t.Errorf("CheckURLDetailed() = %+v, 期望 %v, Target %q", result, tt.want, tt.target)
+			}
+			if tt.wantErr != nil && result.Reason != types.ReasonInvalidInput {
+				t.Errorf("原因 = %q, 期望 %q", result.Reason, types.ReasonInvalidInput)
+			}
+			if perm, _ := manager.CheckURL(tt.url); perm != tt.want {
+				t.Errorf("CheckURL() = %v, 期望 %v", perm, tt.want)
+			}
+		})
+	}
+}
+
+// TestCheckURLStrictHostnames 测试严格主机名校验同样用于CheckURL
+func TestCheckURLStrictHostnames(t *testing.T) {
+	manager := NewManager()
+	manager.SetDomainACL([]string{"example.com"}, types.Whitelist, true)
+	manager.SetStrictHostnames(true)
+
+	if _, err := manager.CheckURL("http://api_internal.example.com/"); !errors.Is(err, domain.ErrInvalidHostname) {
+		t.Errorf("CheckURL() 错误 = %v, 期望 ErrInvalidHostname", err)
+	}
+	if perm, err := manager.CheckURL("https://www.example.com:8443/"); err != nil || perm != types.Allowed {
+		t.Errorf("CheckURL() = %v, %v, 期望允许", perm, err)
+	}
+}
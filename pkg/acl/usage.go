@@ -0,0 +1,116 @@
+package acl
+
+import (
+	"time"
+)
+
+// ComponentUsage 表示单个ACL的规模与资源占用
+//
+// 字段说明:
+//   - Name: 组件名称，主列表为"ip_acl"、"domain_acl"，命名列表为"ip_list:名称"、"domain_list:名称"
+//   - RuleCount: 规则数量
+//   - MemoryBytes: 占用的近似字节数，见ip.IPACL.MemoryUsage和domain.DomainACL.MemoryUsage
+//   - LastModified: 最近一次修改的时间，从未设置时为零值
+type ComponentUsage struct {
+	Name         string    `json:"name"`
+	RuleCount    int       `json:"rule_count"`
+	MemoryBytes  int       `json:"memory_bytes"`
+	LastModified time.Time `json:"last_modified,omitempty"`
+}
+
+// UsageReport 表示Manager中所有ACL的规模与资源占用
+//
+// 字段说明:
+//   - Time: 生成报告的时间
+//   - Components: 已配置的ACL，依次为IP主列表、命名IP列表、域名主列表、命名域名列表，未配置的ACL不会出现
+//   - TotalRules: 所有组件的规则数量之和
+//   - TotalMemoryBytes: 所有组件的近似字节数之和
+type UsageReport struct {
+	Time             time.Time        `json:"time"`
+	Components       []ComponentUsage `json:"components"`
+	TotalRules       int              `json:"total_rules"`
+	TotalMemoryBytes int              `json:"total_memory_bytes"`
+}
+
+// Component 返回指定名称的组件
+//
+// 参数:
+//   - name: 组件名称，如"ip_acl"、"domain_list:ads"
+//
+// 返回:
+//   - ComponentUsage: 组件的资源占用
+//   - bool: 组件不存在时返回false
+func (r UsageReport) Component(name string) (ComponentUsage, bool) {
+	for _, c := range r.Components {
+		if c.Name == name {
+			return c, true
+		}
+	}
+	return ComponentUsage{}, false
+}
+
+// Usage 返回所有ACL当前的规则数量、近似内存占用和最近修改时间
+//
+// 返回:
+//   - UsageReport: 各ACL的资源占用及合计
+//
+// 内存占用是估算值，用于容量规划和发现异常，例如订阅的规则源突然膨胀数倍。
+// 所有命名列表都会被统计，包括所属规则组已停用的列表。
+//
+// 示例:
+//
+//	usage := manager.Usage()
+//	if c, ok := usage.Component("ip_list:threat-feed"); ok && c.RuleCount > 3*lastCount {
+//	    log.Printf("威胁情报列表规模异常: %d条规则，约%dKB", c.RuleCount, c.MemoryBytes/1024)
+//	}
+func (m *Manager) Usage() UsageReport {
+	report := UsageReport{Time: m.Clock().Now()}
+
+	m.ipMu.RLock()
+	if m.ipACL != nil {
+		report.add(ComponentUsage{
+			Name:         "ip_acl",
+			RuleCount:    len(m.ipACL.GetIPRanges()),
+			MemoryBytes:  m.ipACL.MemoryUsage(),
+			LastModified: m.ipModified,
+		})
+	}
+	for _, l := range m.ipLists {
+		report.add(l.usage("ip_list:"))
+	}
+	m.ipMu.RUnlock()
+
+	m.domainMu.RLock()
+	if m.domainACL != nil {
+		report.add(ComponentUsage{
+			Name:         "domain_acl",
+			RuleCount:    len(m.domainACL.GetDomains()),
+			MemoryBytes:  m.domainACL.MemoryUsage(),
+			LastModified: m.domainModified,
+		})
+	}
+	for _, l := range m.domainLists {
+		report.add(l.usage("domain_list:"))
+	}
+	m.domainMu.RUnlock()
+
+	return report
+}
+
+// add 加入一个组件并更新合计
+func (r *UsageReport) add(c ComponentUsage) {
+	r.Components = append(r.Components, c)
+	r.TotalRules += c.RuleCount
+	r.TotalMemoryBytes += c.MemoryBytes
+}
+
+// usage 返回命名列表的资源占用，prefix是组件名称的前缀
+func (l namedList) usage(prefix string) ComponentUsage {
+	c := ComponentUsage{Name: prefix + l.name, RuleCount: l.info().Size, LastModified: l.modified}
+	if l.ip != nil {
+		c.MemoryBytes = l.ip.MemoryUsage()
+	} else {
+		c.MemoryBytes = l.domain.MemoryUsage()
+	}
+	return c
+}
@@ -0,0 +1,119 @@
+package acl
+
+import (
+	"bytes"
+	"testing"
+	"time"
+
+	"github.com/cyberspacesec/go-acl/pkg/types"
+)
+
+// TestUsage 测试规则数量、内存占用和最近修改时间
+func TestUsage(t *testing.T) {
+	start := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	clock := types.NewManualClock(start)
+	manager := NewManager()
+	manager.SetClock(clock)
+
+	if usage := manager.Usage(); len(usage.Components) != 0 || usage.TotalRules != 0 {
+		t.Fatalf("未配置时 Usage() = %+v, 期望没有组件", usage)
+	}
+
+	if err := manager.SetIPACL([]string{"10.0.0.0/8", "192.168.1.1"}, types.Blacklist); err != nil {
+		t.Fatalf("SetIPACL() 返回错误: %v", err)
+	}
+	clock.Advance(time.Minute)
+	manager.SetDomainACL([]string{"example.com"}, types.Blacklist, true)
+	clock.Advance(time.Minute)
+	if err := manager.SetNamedIPList("feed", []string{"203.0.113.0/24"}, types.Blacklist, 0); err != nil {
+		t.Fatalf("SetNamedIPList() 返回错误: %v", err)
+	}
+	manager.SetNamedDomainList("ads", []string{"ads.example.net", "tracker.example.net"}, types.Blacklist, true, 0)
+
+	usage := manager.Usage()
+	tests := []struct {
+		name     string
+		rules    int
+		modified time.Time
+	}{
+		{"ip_acl", 2, start},
+		{"ip_list:feed", 1, start.Add(2 * time.Minute)},
+		{"domain_acl", 1, start.Add(time.Minute)},
+		{"domain_list:ads", 2, start.Add(2 * time.Minute)},
+	}
+	if len(usage.Components) != len(tests) {
+		t.Fatalf("组件数量 = %d, 期望 %d: %+v", len(usage.Components), len(tests), usage.Components)
+	}
+	for i, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := usage.Components[i]
+			if c.Name != tt.name {
+				t.Fatalf("第%d个组件 = %s, 期望 %s", i, c.Name, tt.name)
+			}
+			if c.RuleCount != tt.rules {
+				t.Errorf("RuleCount = %d, 期望 %d", c.RuleCount, tt.rules)
+			}
+			if c.MemoryBytes <= 0 {
+				t.Errorf("MemoryBytes = %d, 期望大于0", c.MemoryBytes)
+			}
+			if !c.LastModified.Equal(tt.modified) {
+				t.Errorf("LastModified = %v, 期望 %v", c.LastModified, tt.modified)
+			}
+		})
+	}
+	if usage.TotalRules != 6 {
+		t.Errorf("TotalRules = %d, 期望 6", usage.TotalRules)
+	}
+	if !usage.Time.Equal(start.Add(2 * time.Minute)) {
+		t.Errorf("Time = %v, 期望 %v", usage.Time, start.Add(2*time.Minute))
+	}
+
+	// 修改后内存占用增加，修改时间更新
+	before, _ := usage.Component("ip_acl")
+	clock.Advance(time.Hour)
+	if err := manager.AddIP("172.16.0.0/12", "198.51.100.0/24"); err != nil {
+		t.Fatalf("AddIP() 返回错误: %v", err)
+	}
+	after, ok := manager.Usage().Component("ip_acl")
+	if !ok {
+		t.Fatal("Component(\"ip_acl\") 应存在")
+	}
+	if after.RuleCount != 4 || after.MemoryBytes <= before.MemoryBytes {
+		t.Errorf("AddIP后 = %+v, 期望4条规则且占用大于 %d", after, before.MemoryBytes)
+	}
+	if !after.LastModified.Equal(start.Add(time.Hour + 2*time.Minute)) {
+		t.Errorf("AddIP后 LastModified = %v", after.LastModified)
+	}
+
+	if _, ok := manager.Usage().Component("ip_list:missing"); ok {
+		t.Error("不存在的组件不应被找到")
+	}
+}
+
+// TestUsageAfterResetAndSnapshot 测试重置清除修改时间，加载快照更新修改时间
+func TestUsageAfterResetAndSnapshot(t *testing.T) {
+	start := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	clock := types.NewManualClock(start)
+	manager := NewManager()
+	manager.SetClock(clock)
+	manager.SetDomainACL([]string{"example.com"}, types.Blacklist, true)
+
+	var buf bytes.Buffer
+	if err := manager.SaveSnapshot(&buf); err != nil {
+		t.Fatalf("SaveSnapshot() 返回错误: %v", err)
+	}
+
+	manager.Reset()
+	if usage := manager.Usage(); len(usage.Components) != 0 {
+		t.Errorf("Reset后 Usage() = %+v, 期望没有组件", usage)
+	}
+
+	clock.Advance(time.Hour)
+	if err := manager.LoadSnapshot(&buf); err != nil {
+		t.Fatalf("LoadSnapshot() 返回错误: %v", err)
+	}
+	c, ok := manager.Usage().Component("domain_acl")
+	if !ok || !c.LastModified.Equal(start.Add(time.Hour)) {
+		t.Errorf("LoadSnapshot后 domain_acl = %+v, 期望修改时间为加载时间", c)
+	}
+}
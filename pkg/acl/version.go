@@ -0,0 +1,116 @@
+package acl
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/cyberspacesec/go-acl/pkg/config"
+)
+
+// PolicyVersion 是当前的策略文件格式版本，EncodePolicy写入的文件带有此版本号
+//
+// 版本历史:
+//   - 1: 包含ip、domain和rules，文件中没有version字段
+//   - 2: 增加version和lists（命名列表，见ListPolicy）
+const PolicyVersion = 2
+
+// ErrUnsupportedPolicyVersion 表示策略文件的版本高于本库支持的版本，或不是有效的版本号
+var ErrUnsupportedPolicyVersion = errors.New("不支持的策略版本")
+
+// policyMigrations 是各版本之间的迁移，policyMigrations[i]把版本i+1的文档升级为版本i+2
+var policyMigrations = []func(doc map[string]interface{}) error{
+	migratePolicyV1,
+}
+
+// Version 返回go-acl的版本
+//
+// 返回:
+//   - string: 构建信息中本模块的版本，如"v1.4.0"；无法取得时（如在本仓库中直接构建）为"(devel)"
+//
+// 可以与PolicyVersion一起记录在日志或健康检查中，便于确认运行的库和支持的策略格式。
+func Version() string {
+	if v := config.ModuleVersion(); v != "" {
+		return v
+	}
+	return "(devel)"
+}
+
+// PolicyDocumentVersion 返回解码后的策略文档的版本
+//
+// 参数:
+//   - doc: 用PolicyCodec解码到map[string]interface{}的策略文档
+//
+// 返回:
+//   - int: 文档的version字段，没有时为1
+//   - error: version不是正整数时返回包装了ErrUnsupportedPolicyVersion的错误
+func PolicyDocumentVersion(doc map[string]interface{}) (int, error) {
+	v, ok := doc["version"]
+	if !ok {
+		return 1, nil
+	}
+	var version int
+	switch n := v.(type) {
+	case int:
+		version = n
+	case int64:
+		version = int(n)
+	case uint64:
+		version = int(n)
+	case float64:
+		version = int(n)
+		if float64(version) != n {
+			return 0, fmt.Errorf("%w: %v", ErrUnsupportedPolicyVersion, v)
+		}
+	default:
+		return 0, fmt.Errorf("%w: %v", ErrUnsupportedPolicyVersion, v)
+	}
+	if version < 1 {
+		return 0, fmt.Errorf("%w: %d", ErrUnsupportedPolicyVersion, version)
+	}
+	return version, nil
+}
+
+// MigratePolicy 把策略文档原地升级为PolicyVersion
+//
+// 参数:
+//   - doc: 用PolicyCodec解码到map[string]interface{}的策略文档，升级后version字段为PolicyVersion
+//
+// 返回:
+//   - int: 文档升级前的版本
+//   - error: 版本高于PolicyVersion或无效时返回包装了ErrUnsupportedPolicyVersion的错误
+//
+// 迁移作用于通用的文档而不是Policy，因此旧版本中被改名或删除的字段也能被转换。
+// DecodePolicy和LoadPolicyFile会自动调用此函数，长期保存的旧版本文件无需修改即可加载；
+// 需要把旧文件改写为新格式时，可以解码、升级后用EncodePolicy重新保存。
+//
+// 示例:
+//
+//	var doc map[string]interface{}
+//	json.Unmarshal(data, &doc)
+//	from, err := acl.MigratePolicy(doc)
+//	if err == nil && from < acl.PolicyVersion {
+//	    log.Printf("策略文件格式为版本%d，建议升级", from)
+//	}
+func MigratePolicy(doc map[string]interface{}) (int, error) {
+	from, err := PolicyDocumentVersion(doc)
+	if err != nil {
+		return 0, err
+	}
+	if from > PolicyVersion {
+		return from, fmt.Errorf("%w: 文件版本为%d，本库支持到%d（go-acl %s）", ErrUnsupportedPolicyVersion, from, PolicyVersion, Version())
+	}
+	for v := from; v < PolicyVersion; v++ {
+		if err := policyMigrations[v-1](doc); err != nil {
+			return from, fmt.Errorf("策略从版本%d升级到%d失败: %w", v, v+1, err)
+		}
+	}
+	return from, nil
+}
+
+// migratePolicyV1 把版本1的文档升级为版本2
+//
+// 版本2只增加了字段，版本1的ip、domain和rules含义不变。
+func migratePolicyV1(doc map[string]interface{}) error {
+	doc["version"] = 2
+	return nil
+}
@@ -0,0 +1,111 @@
+package acl
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/cyberspacesec/go-acl/pkg/types"
+)
+
+// TestVersion 测试库版本
+func TestVersion(t *testing.T) {
+	// 测试二进制中本模块是主模块且没有版本标签
+	if got := Version(); got != "(devel)" {
+		t.Errorf("Version() = %q, 期望 (devel)", got)
+	}
+}
+
+// TestMigratePolicy 测试策略文档的版本识别和升级
+func TestMigratePolicy(t *testing.T) {
+	tests := []struct {
+		name    string
+		doc     map[string]interface{}
+		from    int
+		wantErr bool
+	}{
+		{"没有版本号", map[string]interface{}{"rules": []interface{}{"port == 22 -> deny"}}, 1, false},
+		{"版本1", map[string]interface{}{"version": float64(1)}, 1, false},
+		{"当前版本", map[string]interface{}{"version": 2}, 2, false},
+		{"未来版本", map[string]interface{}{"version": float64(3)}, 3, true},
+		{"版本号为0", map[string]interface{}{"version": 0}, 0, true},
+		{"版本号不是整数", map[string]interface{}{"version": 1.5}, 0, true},
+		{"版本号是字符串", map[string]interface{}{"version": "2"}, 0, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			from, err := MigratePolicy(tt.doc)
+			if tt.wantErr {
+				if !errors.Is(err, ErrUnsupportedPolicyVersion) {
+					t.Errorf("MigratePolicy() 错误 = %v, 期望 ErrUnsupportedPolicyVersion", err)
+				}
+				return
+			}
+			if err != nil || from != tt.from {
+				t.Fatalf("MigratePolicy() = %d, %v, 期望 %d", from, err, tt.from)
+			}
+			if v, _ := PolicyDocumentVersion(tt.doc); v != PolicyVersion {
+				t.Errorf("升级后版本 = %d, 期望 %d", v, PolicyVersion)
+			}
+		})
+	}
+}
+
+// TestDecodePolicyVersions 测试不同版本的策略文件都能加载
+func TestDecodePolicyVersions(t *testing.T) {
+	// 版本1的文件没有version字段
+	v1 := `{"ip": {"type": "blacklist", "ranges": ["203.0.113.0/24"]}, "rules": ["port == 22 -> deny"]}`
+	policy, err := DecodePolicy([]byte(v1), JSONCodec)
+	if err != nil {
+		t.Fatalf("DecodePolicy(版本1) 返回错误: %v", err)
+	}
+	if policy.Version != PolicyVersion || policy.IP == nil || policy.IP.Ranges[0] != "203.0.113.0/24" || len(policy.Rules) != 1 {
+		t.Errorf("DecodePolicy(版本1) = %+v", policy)
+	}
+
+	v2 := `{
+		"version": 2,
+		"ip": {"type": "blacklist", "ranges": ["203.0.113.0/24"]},
+		"domain": {"type": "blacklist", "domains": ["tracker.example.net"]},
+		"lists": [
+			{"name": "partners", "kind": "ip", "type": "whitelist", "entries": ["203.0.113.7"], "priority": 10},
+			{"name": "ads", "kind": "domain", "type": "blacklist", "entries": ["ads.example.com"], "include_subdomains": true, "group": "holiday"}
+		]
+	}`
+	policy, err = ReadPolicy(strings.NewReader(v2))
+	if err != nil {
+		t.Fatalf("ReadPolicy(版本2) 返回错误: %v", err)
+	}
+	manager, err := NewManagerFromPolicy(policy)
+	if err != nil {
+		t.Fatalf("NewManagerFromPolicy() 返回错误: %v", err)
+	}
+	checks := []struct {
+		name string
+		perm func() (types.Permission, error)
+		want types.Permission
+	}{
+		{"白名单命名列表优先", func() (types.Permission, error) { return manager.CheckIP("203.0.113.7") }, types.Allowed},
+		{"IP主列表", func() (types.Permission, error) { return manager.CheckIP("203.0.113.8") }, types.Denied},
+		{"域名命名列表", func() (types.Permission, error) { return manager.CheckDomain("x.ads.example.com") }, types.Denied},
+	}
+	for _, c := range checks {
+		if perm, err := c.perm(); err != nil || perm != c.want {
+			t.Errorf("%s: 结果 = %v, %v; 期望 %v", c.name, perm, err, c.want)
+		}
+	}
+	manager.DisableGroup("holiday")
+	if perm, _ := manager.CheckDomain("x.ads.example.com"); perm != types.Allowed {
+		t.Error("停用规则组后域名命名列表不应生效")
+	}
+
+	if _, err := DecodePolicy([]byte(`{"version": 3, "ip": {"type": "blacklist"}}`), JSONCodec); !errors.Is(err, ErrUnsupportedPolicyVersion) {
+		t.Errorf("DecodePolicy(版本3) 错误 = %v, 期望 ErrUnsupportedPolicyVersion", err)
+	}
+	if _, err := DecodePolicy([]byte(`{"version": 2, "ip": {"type": "blacklist", "rangez": []}}`), JSONCodec); err == nil {
+		t.Error("包含未知字段时 DecodePolicy() 应返回错误")
+	}
+	if _, err := NewManagerFromPolicy(Policy{Lists: []ListPolicy{{Name: "x", Kind: "asn", Type: "blacklist"}}}); err == nil {
+		t.Error("未知的列表种类应返回错误")
+	}
+}
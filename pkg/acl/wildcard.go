@@ -0,0 +1,116 @@
+package acl
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"strings"
+
+	"github.com/cyberspacesec/go-acl/pkg/domain"
+	"github.com/cyberspacesec/go-acl/pkg/types"
+)
+
+// ErrWildcardNotConfirmed 表示策略中的白名单包含通配条目，但没有设置allow_any确认
+var ErrWildcardNotConfirmed = errors.New("白名单包含通配条目，需要设置allow_any确认")
+
+// anyIP 是IP列表中的通配条目，展开为anyIPRanges
+const anyIP = "*"
+
+// anyIPRanges 是覆盖所有IPv4和IPv6地址的网段
+//
+// 它们是基数树的根节点，查找时在第一个节点就命中，不需要沿树向下比较。
+var anyIPRanges = []string{"0.0.0.0/0", "::/0"}
+
+// isIPWildcard 判断IP条目是否匹配一个地址族的所有地址，即"*"或前缀长度为0的网段
+func isIPWildcard(entry string) bool {
+	if entry == anyIP {
+		return true
+	}
+	_, ipNet, err := net.ParseCIDR(entry)
+	if err != nil {
+		return false
+	}
+	ones, _ := ipNet.Mask.Size()
+	return ones == 0
+}
+
+// isDomainWildcard 判断域名条目是否为通配条目domain.AnyDomain
+func isDomainWildcard(entry string) bool {
+	return strings.TrimSpace(entry) == domain.AnyDomain
+}
+
+// stripDomainWildcard 从域名条目中去掉通配条目，返回其余的条目和是否包含通配条目
+//
+// 通配条目只在策略中有意义，由domain.DomainACL.SetMatchAnyDomain实现；
+// 直接传给DomainACL的"*"只是一个普通的域名。
+func stripDomainWildcard(entries []string) ([]string, bool) {
+	var rest []string
+	found := false
+	for _, entry := range entries {
+		if isDomainWildcard(entry) {
+			found = true
+			continue
+		}
+		rest = append(rest, entry)
+	}
+	if !found {
+		return entries, false
+	}
+	return rest, true
+}
+
+// expandIPWildcard 把IP条目中的"*"展开为anyIPRanges，没有"*"时原样返回
+func expandIPWildcard(entries []string) []string {
+	for i, entry := range entries {
+		if entry != anyIP {
+			continue
+		}
+		expanded := make([]string, 0, len(entries)+1)
+		expanded = append(expanded, entries[:i]...)
+		expanded = append(expanded, anyIPRanges...)
+		for _, rest := range entries[i+1:] {
+			if rest != anyIP {
+				expanded = append(expanded, rest)
+			}
+		}
+		return expanded
+	}
+	return entries
+}
+
+// checkWildcard 检查白名单中的通配条目是否已用allowAny确认
+//
+// 黑名单中的通配条目只会拒绝更多目标，不需要确认。
+func checkWildcard(entries []string, listType types.ListType, allowAny bool, isWildcard func(string) bool) error {
+	if listType != types.Whitelist || allowAny {
+		return nil
+	}
+	for _, entry := range entries {
+		if isWildcard(entry) {
+			return fmt.Errorf("%w: %s", ErrWildcardNotConfirmed, entry)
+		}
+	}
+	return nil
+}
+
+// hasWildcard 判断白名单是否包含通配条目，用于Policy输出allow_any
+func hasWildcard(entries []string, listType types.ListType, isWildcard func(string) bool) bool {
+	return checkWildcard(entries, listType, false, isWildcard) != nil
+}
+
+// withDomainWildcard 返回列表的域名条目，启用了SetMatchAnyDomain时在最前面加上通配条目
+func withDomainWildcard(acl *domain.DomainACL) []string {
+	domains := acl.GetDomains()
+	if !acl.MatchesAnyDomain() {
+		return domains
+	}
+	return append([]string{domain.AnyDomain}, domains...)
+}
+
+// confirmedDomainWildcard 判断导出的域名白名单是否应设置allow_any
+//
+// 只有启用了SetMatchAnyDomain的白名单才确认通配条目；列表中作为普通域名加入的"*"不确认，
+// 导出的策略重新加载时返回ErrWildcardNotConfirmed，而不会悄悄变成放行所有域名。
+func confirmedDomainWildcard(acl *domain.DomainACL) bool {
+	return acl.MatchesAnyDomain() && acl.GetListType() == types.Whitelist
+}
@@ -0,0 +1,111 @@
+package acl
+
+import (
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/cyberspacesec/go-acl/pkg/types"
+)
+
+// TestPolicyWildcardConfirmation 测试白名单中的通配条目必须用allow_any确认
+func TestPolicyWildcardConfirmation(t *testing.T) {
+	tests := []struct {
+		name   string
+		policy string
+	}{
+		{"IP星号", `{"ip": {"type": "whitelist", "ranges": ["*"]}}`},
+		{"IPv4所有地址", `{"ip": {"type": "whitelist", "ranges": ["203.0.113.0/24", "0.0.0.0/0"]}}`},
+		{"IPv6所有地址", `{"ip": {"type": "whitelist", "ranges": ["::/0"]}}`},
+		{"域名", `{"domain": {"type": "whitelist", "domains": ["*"]}}`},
+		{"命名IP列表", `{"version": 2, "lists": [{"name": "all", "kind": "ip", "type": "whitelist", "entries": ["0.0.0.0/0"]}]}`},
+		{"命名域名列表", `{"version": 2, "lists": [{"name": "all", "kind": "domain", "type": "whitelist", "entries": ["*"]}]}`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			policy, err := ReadPolicy(strings.NewReader(tt.policy))
+			if err != nil {
+				t.Fatalf("ReadPolicy() 返回错误: %v", err)
+			}
+			if _, err := NewManagerFromPolicy(policy); !errors.Is(err, ErrWildcardNotConfirmed) {
+				t.Errorf("未确认时错误 = %v, 期望 ErrWildcardNotConfirmed", err)
+			}
+
+			confirmed := strings.Replace(tt.policy, `"type": "whitelist"`, `"type": "whitelist", "allow_any": true`, 1)
+			policy, err = ReadPolicy(strings.NewReader(confirmed))
+			if err != nil {
+				t.Fatalf("ReadPolicy() 返回错误: %v", err)
+			}
+			if _, err := NewManagerFromPolicy(policy); err != nil {
+				t.Errorf("确认后 NewManagerFromPolicy() 返回错误: %v", err)
+			}
+		})
+	}
+
+	// 黑名单中的通配条目不需要确认
+	blacklist := Policy{IP: &IPPolicy{Type: "blacklist", Ranges: []string{"0.0.0.0/0"}}}
+	if _, err := NewManagerFromPolicy(blacklist); err != nil {
+		t.Errorf("黑名单 NewManagerFromPolicy() 返回错误: %v", err)
+	}
+}
+
+// TestPolicyWildcard 测试通配白名单放行所有目标、例外仍然生效，并且能原样导出
+func TestPolicyWildcard(t *testing.T) {
+	manager, err := NewManagerFromPolicy(Policy{
+		IP: &IPPolicy{Type: "whitelist", Ranges: []string{"*"}, AllowAny: true},
+		Domain: &DomainPolicy{
+			Type: "whitelist", Domains: []string{"*"}, AllowAny: true,
+			Exceptions: []string{"blocked.example.com"},
+		},
+	})
+	if err != nil {
+		t.Fatalf("NewManagerFromPolicy() 返回错误: %v", err)
+	}
+
+	checks := []struct {
+		check  func(string) (types.Permission, error)
+		target string
+		want   types.Permission
+	}{
+		{manager.CheckIP, "203.0.113.9", types.Allowed},
+		{manager.CheckIP, "2001:db8::1", types.Allowed},
+		{manager.CheckDomain, "api.example.net", types.Allowed},
+		{manager.CheckDomain, "blocked.example.com", types.Denied},
+	}
+	for _, c := range checks {
+		if perm, _ := c.check(c.target); perm != c.want {
+			t.Errorf("检查 %s = %v, 期望 %v", c.target, perm, c.want)
+		}
+	}
+
+	policy := manager.Policy()
+	if !reflect.DeepEqual(policy.IP.Ranges, anyIPRanges) || !policy.IP.AllowAny {
+		t.Errorf("导出的IP策略 = %+v, 期望展开的通配网段并确认", *policy.IP)
+	}
+	if !policy.Domain.AllowAny {
+		t.Errorf("导出的域名策略 = %+v, 期望确认通配规则", *policy.Domain)
+	}
+	if _, err := NewManagerFromPolicy(policy); err != nil {
+		t.Errorf("重新加载导出的策略返回错误: %v", err)
+	}
+}
+
+// TestDomainWildcardOnlyInPolicy 测试策略之外写入的"*"只是普通的域名，不会放行所有域名
+func TestDomainWildcardOnlyInPolicy(t *testing.T) {
+	manager := NewManager()
+	manager.SetDomainACL([]string{"*"}, types.Whitelist, true)
+	if err := manager.AddDomain("*"); err != nil {
+		t.Fatal(err)
+	}
+	manager.SetNamedDomainList("all", []string{"*"}, types.Whitelist, false, 10)
+
+	if perm, _ := manager.CheckDomain("example.org"); perm != types.Denied {
+		t.Errorf("CheckDomain() = %v, 期望\"*\"不匹配所有域名", perm)
+	}
+	// 导出的策略不确认普通的"*"，重新加载时不会悄悄放行所有域名
+	if _, err := NewManagerFromPolicy(manager.Policy()); !errors.Is(err, ErrWildcardNotConfirmed) {
+		t.Errorf("重新加载导出的策略错误 = %v, 期望 ErrWildcardNotConfirmed", err)
+	}
+}
@@ -0,0 +1,229 @@
+// Package acltest 提供用于测试应用代码的ACL替身
+//
+// 测试依赖ACL的应用代码（如中间件、Webhook发送器）时，通常只关心"某个IP或域名被允许还是拒绝"，
+// 而不是规则本身。StaticManager按预先给定的映射返回结果，行为确定，
+// 实现了acl.Checker，可以在应用代码依赖acl.Checker的地方代替*acl.Manager。
+//
+// 用法示例:
+//
+//	checker := acltest.NewStaticManager(map[string]types.Permission{
+//	    "203.0.113.7": types.Denied,
+//	    "example.com": types.Allowed,
+//	})
+//	srv := &Server{ACL: checker}
+//	// ... 调用被测代码 ...
+//	if got := checker.Calls(); len(got) != 1 {
+//	    t.Errorf("期望检查一次，实际 %v", got)
+//	}
+package acltest
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"sync"
+
+	"github.com/cyberspacesec/go-acl/pkg/acl"
+	"github.com/cyberspacesec/go-acl/pkg/domain"
+	"github.com/cyberspacesec/go-acl/pkg/ip"
+	"github.com/cyberspacesec/go-acl/pkg/types"
+)
+
+// StaticManager 是按固定映射返回检查结果的acl.Checker
+//
+// 目标按Manager的规则标准化后查找: IP使用标准形式（如"::ffff:10.0.0.1"与"10.0.0.1"相同），
+// 域名和URL使用domain.Normalize的结果（如"https://WWW.Example.com/x"与"example.com"相同）。
+// 映射中没有的目标返回Default，默认为types.Denied。
+//
+// StaticManager可以安全地在多个goroutine中并发使用。
+type StaticManager struct {
+	// Default 是映射中没有的目标的结果，在开始检查之前设置
+	Default types.Permission
+
+	mu        sync.Mutex
+	decisions map[string]types.Permission
+	errs      map[string]error
+	calls     []string
+}
+
+var _ acl.Checker = (*StaticManager)(nil)
+
+// NewStaticManager 创建按decisions返回结果的StaticManager
+//
+// 参数:
+//   - decisions: 目标（IP、域名或URL）到检查结果的映射，键按与检查时相同的规则标准化
+//
+// 返回:
+//   - *StaticManager: 新的StaticManager，不引用decisions，之后修改decisions不影响它
+//
+// 示例:
+//
+//	checker := acltest.NewStaticManager(map[string]types.Permission{"10.0.0.1": types.Allowed})
+//	perm, _ := checker.CheckIP("10.0.0.1") // types.Allowed
+//	perm, _ = checker.CheckIP("10.0.0.2")  // types.Denied
+func NewStaticManager(decisions map[string]types.Permission) *StaticManager {
+	s := &StaticManager{
+		decisions: make(map[string]types.Permission, len(decisions)),
+		errs:      make(map[string]error),
+	}
+	for target, perm := range decisions {
+		s.decisions[normalize(target)] = perm
+	}
+	return s
+}
+
+// Set 设置目标的检查结果，覆盖已有的结果
+//
+// 参数:
+//   - target: IP、域名或URL
+//   - perm: 检查结果
+func (s *StaticManager) Set(target string, perm types.Permission) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.decisions[normalize(target)] = perm
+}
+
+// SetError 使目标的检查返回err，用于测试应用代码的错误处理
+//
+// 参数:
+//   - target: IP、域名或URL
+//   - err: 检查返回的错误，传入nil表示取消
+//
+// 返回错误时结果为types.Denied，Detailed结果的Reason与Manager对同类错误给出的原因相同，
+// 如ip.ErrInvalidIP对应types.ReasonInvalidInput。
+//
+// 示例:
+//
+//	checker.SetError("not-an-ip", ip.ErrInvalidIP)
+func (s *StaticManager) SetError(target string, err error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if err == nil {
+		delete(s.errs, normalize(target))
+		return
+	}
+	s.errs[normalize(target)] = err
+}
+
+// Calls 返回按调用顺序记录的被检查的目标（调用方传入的原文）
+func (s *StaticManager) Calls() []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]string(nil), s.calls...)
+}
+
+// Reset 清空Calls记录的目标，保留映射和错误
+func (s *StaticManager) Reset() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.calls = nil
+}
+
+// CheckIP 返回IP的检查结果
+func (s *StaticManager) CheckIP(ip string) (types.Permission, error) {
+	result, err := s.check(ip, "ip")
+	return result.Decision, err
+}
+
+// CheckDomain 返回域名的检查结果
+func (s *StaticManager) CheckDomain(domain string) (types.Permission, error) {
+	result, err := s.check(domain, "domain")
+	return result.Decision, err
+}
+
+// CheckHost 返回主机名、IP或URL的检查结果
+func (s *StaticManager) CheckHost(host string) (types.Permission, error) {
+	result, err := s.check(host, hostKind(host))
+	return result.Decision, err
+}
+
+// CheckIPContext 与CheckIP相同，忽略ctx
+func (s *StaticManager) CheckIPContext(_ context.Context, ip string) (types.Permission, error) {
+	return s.CheckIP(ip)
+}
+
+// CheckDomainContext 与CheckDomain相同，忽略ctx
+func (s *StaticManager) CheckDomainContext(_ context.Context, domain string) (types.Permission, error) {
+	return s.CheckDomain(domain)
+}
+
+// CheckIPDetailed 返回IP的检查结果，Source为"static"
+func (s *StaticManager) CheckIPDetailed(_ context.Context, ip string) (types.CheckResult, error) {
+	return s.check(ip, "ip")
+}
+
+// CheckDomainDetailed 返回域名的检查结果，Source为"static"
+func (s *StaticManager) CheckDomainDetailed(_ context.Context, domain string) (types.CheckResult, error) {
+	return s.check(domain, "domain")
+}
+
+// CheckHostDetailed 返回主机名、IP或URL的检查结果，Source为"static"
+func (s *StaticManager) CheckHostDetailed(_ context.Context, host string) (types.CheckResult, error) {
+	return s.check(host, hostKind(host))
+}
+
+// check 记录调用并查找目标的结果
+func (s *StaticManager) check(target, kind string) (types.CheckResult, error) {
+	key := normalize(target)
+	result := types.CheckResult{Target: target, Kind: kind, Source: "static"}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.calls = append(s.calls, target)
+
+	if err, ok := s.errs[key]; ok {
+		result.Decision, result.Reason = types.Denied, errorReason(err)
+		return result, err
+	}
+	perm, ok := s.decisions[key]
+	if ok {
+		result.RuleID = key
+	} else {
+		perm = s.Default
+	}
+	result.Decision = perm
+	if perm == types.Denied {
+		// 映射中的拒绝相当于命中黑名单
+		result.Reason = types.ReasonDefaultDeny
+		if ok {
+			result.Reason = types.DenyReason(kind, types.Blacklist)
+		}
+	}
+	return result, nil
+}
+
+// errorReason 返回错误对应的原因，与Manager的分类一致
+func errorReason(err error) types.Reason {
+	switch {
+	case errors.Is(err, types.ErrNoACL):
+		return types.ReasonNoACL
+	case errors.Is(err, ip.ErrInvalidIP), errors.Is(err, domain.ErrInvalidDomain), errors.Is(err, domain.ErrInvalidHostname):
+		return types.ReasonInvalidInput
+	case types.ReasonOf(err) != "":
+		return types.ReasonOf(err)
+	default:
+		return types.ReasonCheckFailed
+	}
+}
+
+// normalize 按检查时的规则标准化目标，无法标准化时使用去掉首尾空白的小写原文
+func normalize(target string) string {
+	if parsed, ok := ip.CanonicalizeIP(target); ok {
+		return parsed.String()
+	}
+	if host := domain.Normalize(target); host != "" {
+		if parsed, ok := ip.CanonicalizeIP(host); ok {
+			return parsed.String()
+		}
+		return host
+	}
+	return strings.ToLower(strings.TrimSpace(target))
+}
+
+// hostKind 返回CheckHost对目标使用的检查类型
+func hostKind(host string) string {
+	if _, ok := ip.CanonicalizeIP(domain.Normalize(host)); ok {
+		return "ip"
+	}
+	return "domain"
+}
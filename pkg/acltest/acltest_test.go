@@ -0,0 +1,130 @@
+package acltest
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"sync"
+	"testing"
+
+	"github.com/cyberspacesec/go-acl/pkg/ip"
+	"github.com/cyberspacesec/go-acl/pkg/types"
+)
+
+// TestStaticManager 测试StaticManager按映射返回结果，目标按检查时的规则标准化
+func TestStaticManager(t *testing.T) {
+	checker := NewStaticManager(map[string]types.Permission{
+		"203.0.113.7":     types.Denied,
+		"10.0.0.1":        types.Allowed,
+		"WWW.Example.com": types.Allowed,
+		"evil.example":    types.Denied,
+	})
+
+	tests := []struct {
+		name   string
+		check  func(string) (types.Permission, error)
+		target string
+		want   types.Permission
+	}{
+		{"映射中允许的IP", checker.CheckIP, "10.0.0.1", types.Allowed},
+		{"映射中拒绝的IP", checker.CheckIP, "203.0.113.7", types.Denied},
+		{"IPv4映射的IPv6地址", checker.CheckIP, "::ffff:10.0.0.1", types.Allowed},
+		{"映射中没有的IP", checker.CheckIP, "10.0.0.2", types.Denied},
+		{"域名大小写和www", checker.CheckDomain, "example.COM", types.Allowed},
+		{"映射中拒绝的域名", checker.CheckDomain, "evil.example", types.Denied},
+		{"映射中没有的域名", checker.CheckDomain, "other.example", types.Denied},
+		{"URL中的域名", checker.CheckHost, "https://www.example.com/path", types.Allowed},
+		{"URL中的IP", checker.CheckHost, "http://10.0.0.1:8080/", types.Allowed},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := tt.check(tt.target)
+			if err != nil {
+				t.Fatalf("检查 %q 返回错误: %v", tt.target, err)
+			}
+			if got != tt.want {
+				t.Errorf("检查 %q = %v, 期望 %v", tt.target, got, tt.want)
+			}
+		})
+	}
+}
+
+// TestStaticManagerDetailed 测试Detailed结果的类型、来源和原因
+func TestStaticManagerDetailed(t *testing.T) {
+	checker := NewStaticManager(map[string]types.Permission{"203.0.113.7": types.Denied})
+	ctx := context.Background()
+
+	result, err := checker.CheckIPDetailed(ctx, "203.0.113.7")
+	if err != nil {
+		t.Fatalf("CheckIPDetailed() 返回错误: %v", err)
+	}
+	want := types.CheckResult{
+		Target:   "203.0.113.7",
+		Kind:     "ip",
+		Decision: types.Denied,
+		RuleID:   "203.0.113.7",
+		Source:   "static",
+		Reason:   types.ReasonMatchedBlacklistIP,
+	}
+	if !reflect.DeepEqual(result, want) {
+		t.Errorf("CheckIPDetailed() = %+v, 期望 %+v", result, want)
+	}
+
+	result, _ = checker.CheckHostDetailed(ctx, "https://unknown.example/")
+	if result.Kind != "domain" || result.Reason != types.ReasonDefaultDeny || result.Matched() {
+		t.Errorf("CheckHostDetailed(未知域名) = %+v, 期望按默认结果拒绝", result)
+	}
+
+	checker.Default = types.Allowed
+	result, _ = checker.CheckDomainDetailed(ctx, "unknown.example")
+	if !result.Allowed() || result.Reason != "" {
+		t.Errorf("Default为Allowed时 CheckDomainDetailed() = %+v, 期望允许", result)
+	}
+}
+
+// TestStaticManagerErrors 测试SetError使检查返回错误及对应的原因
+func TestStaticManagerErrors(t *testing.T) {
+	checker := NewStaticManager(nil)
+	checker.Set("10.0.0.1", types.Allowed)
+	checker.SetError("not-an-ip", ip.ErrInvalidIP)
+	custom := errors.New("故障")
+	checker.SetError("10.0.0.1", custom)
+
+	result, err := checker.CheckIPDetailed(context.Background(), "not-an-ip")
+	if !errors.Is(err, ip.ErrInvalidIP) || result.Reason != types.ReasonInvalidInput {
+		t.Errorf("CheckIPDetailed(not-an-ip) = %+v, %v, 期望ErrInvalidIP和invalid_input", result, err)
+	}
+	perm, err := checker.CheckIP("10.0.0.1")
+	if !errors.Is(err, custom) || perm != types.Denied {
+		t.Errorf("CheckIP(10.0.0.1) = %v, %v, 期望Denied和设置的错误", perm, err)
+	}
+
+	checker.SetError("10.0.0.1", nil)
+	if perm, err := checker.CheckIP("10.0.0.1"); err != nil || perm != types.Allowed {
+		t.Errorf("取消错误后 CheckIP(10.0.0.1) = %v, %v, 期望Allowed", perm, err)
+	}
+}
+
+// TestStaticManagerCalls 测试Calls按顺序记录检查的目标，并可以并发使用
+func TestStaticManagerCalls(t *testing.T) {
+	checker := NewStaticManager(nil)
+	checker.CheckIP("10.0.0.1")
+	checker.CheckDomainContext(context.Background(), "Example.com")
+	if got, want := checker.Calls(), []string{"10.0.0.1", "Example.com"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("Calls() = %v, 期望 %v", got, want)
+	}
+
+	checker.Reset()
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			checker.CheckIPContext(context.Background(), "10.0.0.1")
+		}()
+	}
+	wg.Wait()
+	if got := len(checker.Calls()); got != 10 {
+		t.Errorf("并发检查后 len(Calls()) = %d, 期望 10", got)
+	}
+}
@@ -0,0 +1,152 @@
+// Package benchmarks 是比较go-acl各种实现方式性能的基准测试和可复现的测试数据生成器
+//
+// 基准测试位于本包的测试文件中，按规则数量（Sizes）比较:
+//   - IP匹配引擎: 逐条比较（NewSliceEngine）、基数树（ip.NewTrieEngine）和哈希表（ip.NewHashEngine）
+//   - 域名匹配引擎: 默认的逐条比较和domain.NewSuffixEngine
+//   - 域名否定缓存: 反复检查未列出的域名时启用和关闭DomainACL.EnableNegativeCache
+//   - 锁策略: 读多写少时Mutex、RWMutex、原子替换（写时复制）和Manager本身
+//
+// 生成器对相同的参数总是返回相同的数据，因此不同提交之间的结果可以直接比较。
+// 修改匹配、缓存或加锁相关的代码时，在修改前后各运行一次并用benchstat比较:
+//
+//	go test ./pkg/benchmarks -run '^$' -bench . -count 10 > old.txt
+//	# 修改代码
+//	go test ./pkg/benchmarks -run '^$' -bench . -count 10 > new.txt
+//	benchstat old.txt new.txt
+//
+// 规则数量为100万的基准测试构建列表需要数秒，可以用-bench '/.*/size=(100|10000)$'跳过。
+// 锁策略的比较只使用前两种规则数量：写时复制在100万条规则时每次修改都要复制整个列表。
+package benchmarks
+
+import (
+	"encoding/binary"
+	"fmt"
+	"math/rand"
+	"net"
+)
+
+// Sizes 是基准测试使用的规则数量
+var Sizes = []int{100, 10000, 1000000}
+
+// Seed 是基准测试使用的默认随机种子
+const Seed = 1
+
+// ipv4PrefixLens 是IPv4Prefixes生成的前缀长度，混合多种长度使各引擎的差异更接近真实的封禁列表
+var ipv4PrefixLens = []int{16, 20, 24, 24, 28, 32, 32, 32}
+
+// IPv4Prefixes 生成n个分散在整个IPv4地址空间中的CIDR
+//
+// 参数:
+//   - n: 生成的数量，不超过2^24
+//   - seed: 随机种子，相同的种子和数量总是生成相同的列表
+//
+// 返回:
+//   - []string: 前缀长度在16到32之间的CIDR，长度为32的写作单个地址
+func IPv4Prefixes(n int, seed int64) []string {
+	r := rand.New(rand.NewSource(seed))
+	prefixes := make([]string, n)
+	for i := range prefixes {
+		// 乘以奇数在2^24范围内是双射，使各前缀的高24位互不相同
+		addr := make(net.IP, net.IPv4len)
+		binary.BigEndian.PutUint32(addr, ((uint32(i)*0x9E3779B1+uint32(seed))&0xFFFFFF)<<8|uint32(r.Intn(256)))
+		bits := ipv4PrefixLens[r.Intn(len(ipv4PrefixLens))]
+		if bits == 32 {
+			prefixes[i] = addr.String()
+			continue
+		}
+		prefixes[i] = fmt.Sprintf("%s/%d", addr.Mask(net.CIDRMask(bits, 32)), bits)
+	}
+	return prefixes
+}
+
+// IPv4Probes 生成n个用于检查的IPv4地址
+//
+// 参数:
+//   - prefixes: IPv4Prefixes生成的列表
+//   - n: 生成的数量
+//   - hitRatio: 落在prefixes中某个前缀内的地址的比例，0到1之间
+//   - seed: 随机种子
+//
+// 返回:
+//   - []string: 命中的地址取自随机选中的前缀，其余为随机地址（仍可能偶然命中）
+func IPv4Probes(prefixes []string, n int, hitRatio float64, seed int64) []string {
+	r := rand.New(rand.NewSource(seed))
+	probes := make([]string, n)
+	for i := range probes {
+		addr := make(net.IP, net.IPv4len)
+		binary.BigEndian.PutUint32(addr, r.Uint32())
+		if len(prefixes) > 0 && r.Float64() < hitRatio {
+			network := parseNet(prefixes[r.Intn(len(prefixes))])
+			for j := range addr {
+				addr[j] = network.IP[j] | addr[j]&^network.Mask[j]
+			}
+		}
+		probes[i] = addr.String()
+	}
+	return probes
+}
+
+// parseNet 解析生成器输出的IP或CIDR
+func parseNet(s string) *net.IPNet {
+	if _, network, err := net.ParseCIDR(s); err == nil {
+		return &net.IPNet{IP: network.IP.To4(), Mask: network.Mask}
+	}
+	return &net.IPNet{IP: net.ParseIP(s).To4(), Mask: net.CIDRMask(32, 32)}
+}
+
+// tlds 是Domains生成的域名使用的顶级域
+var tlds = []string{"com", "net", "org", "io", "example", "test"}
+
+// Domains 生成n个互不相同的注册域名
+//
+// 参数:
+//   - n: 生成的数量
+//   - seed: 随机种子，相同的种子和数量总是生成相同的列表
+//
+// 返回:
+//   - []string: 形如"k3xq9a-17.net"的域名，序号保证互不相同
+func Domains(n int, seed int64) []string {
+	r := rand.New(rand.NewSource(seed))
+	domains := make([]string, n)
+	for i := range domains {
+		domains[i] = fmt.Sprintf("%s-%d.%s", randomLabel(r, 4+r.Intn(8)), i, tlds[r.Intn(len(tlds))])
+	}
+	return domains
+}
+
+// DomainProbes 生成n个用于检查的域名
+//
+// 参数:
+//   - domains: Domains生成的列表
+//   - n: 生成的数量
+//   - hitRatio: 列表中的域名或其子域名所占的比例，0到1之间
+//   - seed: 随机种子
+//
+// 返回:
+//   - []string: 命中的域名一半是列表中的域名、一半是它的子域名，其余为不在列表中的域名
+func DomainProbes(domains []string, n int, hitRatio float64, seed int64) []string {
+	r := rand.New(rand.NewSource(seed))
+	probes := make([]string, n)
+	for i := range probes {
+		if len(domains) == 0 || r.Float64() >= hitRatio {
+			probes[i] = fmt.Sprintf("www.%s.unlisted.%s", randomLabel(r, 6+r.Intn(6)), tlds[r.Intn(len(tlds))])
+			continue
+		}
+		d := domains[r.Intn(len(domains))]
+		if r.Intn(2) == 0 {
+			d = randomLabel(r, 3+r.Intn(5)) + "." + d
+		}
+		probes[i] = d
+	}
+	return probes
+}
+
+// randomLabel 返回由小写字母和数字组成的n个字符的标签
+func randomLabel(r *rand.Rand, n int) string {
+	const alphabet = "abcdefghijklmnopqrstuvwxyz0123456789"
+	b := make([]byte, n)
+	for i := range b {
+		b[i] = alphabet[r.Intn(len(alphabet))]
+	}
+	return string(b)
+}
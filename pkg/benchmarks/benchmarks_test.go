@@ -0,0 +1,340 @@
+package benchmarks
+
+import (
+	"fmt"
+	"reflect"
+	"sync"
+	"sync/atomic"
+	"testing"
+
+	"github.com/cyberspacesec/go-acl/pkg/acl"
+	"github.com/cyberspacesec/go-acl/pkg/domain"
+	"github.com/cyberspacesec/go-acl/pkg/ip"
+	"github.com/cyberspacesec/go-acl/pkg/types"
+)
+
+// probeCount 是每个基准测试轮流检查的目标数量
+const probeCount = 4096
+
+// ipEngines 是参与比较的IP匹配引擎
+var ipEngines = []struct {
+	name string
+	new  func() ip.MatchEngine
+}{
+	{"slice", NewSliceEngine},
+	{"trie", func() ip.MatchEngine { return ip.NewTrieEngine(ip.DefaultMatcherOptions) }},
+	{"hash", ip.NewHashEngine},
+}
+
+// domainEngines 是参与比较的域名匹配引擎，nil表示默认的逐条比较
+var domainEngines = []struct {
+	name string
+	new  func() domain.MatchEngine
+}{
+	{"slice", func() domain.MatchEngine { return nil }},
+	{"suffix", domain.NewSuffixEngine},
+}
+
+// newIPACL 创建使用指定引擎的IP黑名单
+func newIPACL(tb testing.TB, prefixes []string, engine ip.MatchEngine) *ip.IPACL {
+	tb.Helper()
+	a, err := ip.NewIPACL(prefixes, types.Blacklist)
+	if err != nil {
+		tb.Fatalf("NewIPACL() 返回错误: %v", err)
+	}
+	a.SetMatchEngine(engine)
+	return a
+}
+
+// TestGeneratorsReproducible 测试生成器对相同的参数返回相同的数据
+func TestGeneratorsReproducible(t *testing.T) {
+	prefixes := IPv4Prefixes(1000, Seed)
+	if !reflect.DeepEqual(prefixes, IPv4Prefixes(1000, Seed)) {
+		t.Error("IPv4Prefixes() 对相同的种子返回了不同的结果")
+	}
+	if reflect.DeepEqual(prefixes, IPv4Prefixes(1000, Seed+1)) {
+		t.Error("IPv4Prefixes() 对不同的种子返回了相同的结果")
+	}
+	if _, err := ip.NewIPACL(prefixes, types.Blacklist); err != nil {
+		t.Errorf("IPv4Prefixes() 生成了无效的CIDR: %v", err)
+	}
+	if !reflect.DeepEqual(IPv4Probes(prefixes, 100, 0.5, Seed), IPv4Probes(prefixes, 100, 0.5, Seed)) {
+		t.Error("IPv4Probes() 对相同的种子返回了不同的结果")
+	}
+
+	domains := Domains(1000, Seed)
+	if !reflect.DeepEqual(domains, Domains(1000, Seed)) {
+		t.Error("Domains() 对相同的种子返回了不同的结果")
+	}
+	seen := make(map[string]bool)
+	for _, d := range domains {
+		if seen[d] {
+			t.Fatalf("Domains() 生成了重复的域名 %s", d)
+		}
+		seen[d] = true
+	}
+	if !reflect.DeepEqual(DomainProbes(domains, 100, 0.5, Seed), DomainProbes(domains, 100, 0.5, Seed)) {
+		t.Error("DomainProbes() 对相同的种子返回了不同的结果")
+	}
+}
+
+// TestProbeHitRatio 测试检查目标的命中比例
+func TestProbeHitRatio(t *testing.T) {
+	prefixes := IPv4Prefixes(1000, Seed)
+	ipACL := newIPACL(t, prefixes, nil)
+	domains := Domains(1000, Seed)
+	domainACL := domain.NewDomainACL(domains, types.Blacklist, true)
+
+	tests := []struct {
+		name  string
+		check func(target string) (types.Permission, error)
+		probe func(ratio float64) []string
+	}{
+		{"IP", ipACL.Check, func(ratio float64) []string { return IPv4Probes(prefixes, 1000, ratio, Seed) }},
+		{"域名", domainACL.Check, func(ratio float64) []string { return DomainProbes(domains, 1000, ratio, Seed) }},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			for _, ratio := range []float64{0, 0.5, 1} {
+				hits := 0
+				for _, target := range tt.probe(ratio) {
+					if perm, _ := tt.check(target); perm == types.Denied {
+						hits++
+					}
+				}
+				// 随机地址偶然命中的概率很小
+				if got := float64(hits) / 1000; got < ratio-0.05 || got > ratio+0.05 {
+					t.Errorf("hitRatio=%v 时命中比例 = %v", ratio, got)
+				}
+			}
+		})
+	}
+}
+
+// TestEnginesAgree 测试所有引擎对相同的列表和目标给出相同的结果
+func TestEnginesAgree(t *testing.T) {
+	prefixes := IPv4Prefixes(10000, Seed)
+	probes := IPv4Probes(prefixes, 2000, 0.5, Seed)
+	reference := newIPACL(t, prefixes, NewSliceEngine())
+	for _, e := range ipEngines[1:] {
+		a := newIPACL(t, prefixes, e.new())
+		for _, p := range probes {
+			want, _ := reference.Check(p)
+			if got, _ := a.Check(p); got != want {
+				t.Fatalf("%s: Check(%s) = %v, 期望 %v", e.name, p, got, want)
+			}
+		}
+	}
+
+	domains := Domains(10000, Seed)
+	domainProbes := DomainProbes(domains, 2000, 0.5, Seed)
+	linear := domain.NewDomainACL(domains, types.Blacklist, true)
+	suffix := domain.NewDomainACL(domains, types.Blacklist, true)
+	suffix.SetMatchEngine(domain.NewSuffixEngine())
+	for _, p := range domainProbes {
+		want, _ := linear.Check(p)
+		if got, _ := suffix.Check(p); got != want {
+			t.Fatalf("suffix: Check(%s) = %v, 期望 %v", p, got, want)
+		}
+	}
+}
+
+// BenchmarkIPMatch 比较各IP匹配引擎在不同规则数量下的检查耗时
+func BenchmarkIPMatch(b *testing.B) {
+	for _, size := range Sizes {
+		prefixes := IPv4Prefixes(size, Seed)
+		probes := IPv4Probes(prefixes, probeCount, 0.5, Seed)
+		for _, e := range ipEngines {
+			b.Run(fmt.Sprintf("engine=%s/size=%d", e.name, size), func(b *testing.B) {
+				a := newIPACL(b, prefixes, e.new())
+				b.ResetTimer()
+				for i := 0; i < b.N; i++ {
+					a.Check(probes[i%len(probes)])
+				}
+			})
+		}
+	}
+}
+
+// BenchmarkDomainMatch 比较各域名匹配引擎在不同规则数量下的检查耗时
+func BenchmarkDomainMatch(b *testing.B) {
+	for _, size := range Sizes {
+		domains := Domains(size, Seed)
+		probes := DomainProbes(domains, probeCount, 0.5, Seed)
+		for _, e := range domainEngines {
+			b.Run(fmt.Sprintf("engine=%s/size=%d", e.name, size), func(b *testing.B) {
+				a := domain.NewDomainACL(domains, types.Blacklist, true)
+				a.SetMatchEngine(e.new())
+				b.ResetTimer()
+				for i := 0; i < b.N; i++ {
+					a.Check(probes[i%len(probes)])
+				}
+			})
+		}
+	}
+}
+
+// BenchmarkDomainCache 比较反复检查未列出的域名时启用和关闭否定缓存的耗时
+//
+// 目标数量小于缓存容量，稳定后每次检查都命中缓存。
+func BenchmarkDomainCache(b *testing.B) {
+	for _, size := range Sizes {
+		domains := Domains(size, Seed)
+		probes := DomainProbes(domains, 256, 0, Seed)
+		for _, cache := range []int{0, 1024} {
+			name := "uncached"
+			if cache > 0 {
+				name = "cached"
+			}
+			b.Run(fmt.Sprintf("mode=%s/size=%d", name, size), func(b *testing.B) {
+				a := domain.NewDomainACL(domains, types.Blacklist, true)
+				a.EnableNegativeCache(cache)
+				b.ResetTimer()
+				for i := 0; i < b.N; i++ {
+					a.Check(probes[i%len(probes)])
+				}
+			})
+		}
+	}
+}
+
+// toggledIP 是锁策略基准测试中被反复加入和移除的条目
+const toggledIP = "192.0.2.1"
+
+// guard 是保护IP列表的一种加锁方式，Check与Write可以并发调用
+type guard interface {
+	Check(target string) (types.Permission, error)
+	// Write 加入（add为true）或移除toggledIP
+	Write(add bool)
+}
+
+// write 在已加锁的列表上加入或移除toggledIP
+func write(a *ip.IPACL, add bool) {
+	if add {
+		a.Add(toggledIP)
+	} else {
+		a.Remove(toggledIP)
+	}
+}
+
+// mutexGuard 用互斥锁保护读写
+type mutexGuard struct {
+	mu  sync.Mutex
+	acl *ip.IPACL
+}
+
+func (g *mutexGuard) Check(target string) (types.Permission, error) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	return g.acl.Check(target)
+}
+
+func (g *mutexGuard) Write(add bool) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	write(g.acl, add)
+}
+
+// rwMutexGuard 用读写锁保护读写，与Manager的方式相同
+type rwMutexGuard struct {
+	mu  sync.RWMutex
+	acl *ip.IPACL
+}
+
+func (g *rwMutexGuard) Check(target string) (types.Permission, error) {
+	g.mu.RLock()
+	defer g.mu.RUnlock()
+	return g.acl.Check(target)
+}
+
+func (g *rwMutexGuard) Write(add bool) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	write(g.acl, add)
+}
+
+// atomicGuard 写时复制：修改时复制出新的列表并原子替换，读取不加锁
+type atomicGuard struct {
+	mu  sync.Mutex
+	acl atomic.Value
+}
+
+func newAtomicGuard(a *ip.IPACL) *atomicGuard {
+	g := &atomicGuard{}
+	g.acl.Store(a)
+	return g
+}
+
+func (g *atomicGuard) Check(target string) (types.Permission, error) {
+	return g.acl.Load().(*ip.IPACL).Check(target)
+}
+
+func (g *atomicGuard) Write(add bool) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	next, _ := ip.NewIPACL(g.acl.Load().(*ip.IPACL).GetIPRanges(), types.Blacklist)
+	write(next, add)
+	g.acl.Store(next)
+}
+
+// managerGuard 通过Manager读写
+type managerGuard struct {
+	m *acl.Manager
+}
+
+func (g managerGuard) Check(target string) (types.Permission, error) {
+	return g.m.CheckIP(target)
+}
+
+func (g managerGuard) Write(add bool) {
+	if add {
+		g.m.AddIP(toggledIP)
+	} else {
+		g.m.RemoveIP(toggledIP)
+	}
+}
+
+// BenchmarkLocking 比较读多写少时各加锁方式的并发检查耗时
+//
+// 每个goroutine每检查writeEvery-1次修改一次列表（加入或移除一个条目）。
+// 写时复制的修改需要复制整个列表，读取最快而修改最慢，结果取决于读写比例和列表大小。
+func BenchmarkLocking(b *testing.B) {
+	const writeEvery = 1000
+	for _, size := range Sizes[:2] {
+		prefixes := IPv4Prefixes(size, Seed)
+		probes := IPv4Probes(prefixes, probeCount, 0.5, Seed)
+
+		guards := []struct {
+			name string
+			new  func() guard
+		}{
+			{"mutex", func() guard { return &mutexGuard{acl: newIPACL(b, prefixes, nil)} }},
+			{"rwmutex", func() guard { return &rwMutexGuard{acl: newIPACL(b, prefixes, nil)} }},
+			{"atomic", func() guard { return newAtomicGuard(newIPACL(b, prefixes, nil)) }},
+			{"manager", func() guard {
+				m := acl.NewManager()
+				if err := m.SetIPACL(prefixes, types.Blacklist); err != nil {
+					b.Fatal(err)
+				}
+				return managerGuard{m: m}
+			}},
+		}
+		for _, gd := range guards {
+			b.Run(fmt.Sprintf("strategy=%s/size=%d", gd.name, size), func(b *testing.B) {
+				g := gd.new()
+				b.ResetTimer()
+				b.RunParallel(func(pb *testing.PB) {
+					i := 0
+					for pb.Next() {
+						if i%writeEvery == writeEvery-1 {
+							g.Write(i/writeEvery%2 == 0)
+						} else {
+							g.Check(probes[i%len(probes)])
+						}
+						i++
+					}
+				})
+			})
+		}
+	}
+}
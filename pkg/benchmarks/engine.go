@@ -0,0 +1,57 @@
+package benchmarks
+
+import (
+	"net"
+
+	"github.com/cyberspacesec/go-acl/pkg/ip"
+)
+
+// sliceEngine 逐条比较的IP匹配引擎，是基数树之前的实现方式，作为比较的基准
+type sliceEngine struct {
+	nets []*net.IPNet
+}
+
+// NewSliceEngine 创建逐条比较的IP匹配引擎
+//
+// 返回:
+//   - ip.MatchEngine: 查找耗时与规则数量成正比的引擎
+//
+// 该引擎只用于基准测试和验证其他引擎的结果，不适合在生产环境中使用。
+func NewSliceEngine() ip.MatchEngine {
+	return &sliceEngine{}
+}
+
+// Insert 实现ip.MatchEngine
+func (e *sliceEngine) Insert(network *net.IPNet) {
+	for _, n := range e.nets {
+		if sameNet(n, network) {
+			return
+		}
+	}
+	e.nets = append(e.nets, network)
+}
+
+// Remove 实现ip.MatchEngine
+func (e *sliceEngine) Remove(network *net.IPNet) {
+	for i, n := range e.nets {
+		if sameNet(n, network) {
+			e.nets = append(e.nets[:i], e.nets[i+1:]...)
+			return
+		}
+	}
+}
+
+// Match 实现ip.MatchEngine
+func (e *sliceEngine) Match(addr net.IP) bool {
+	for _, n := range e.nets {
+		if n.Contains(addr) {
+			return true
+		}
+	}
+	return false
+}
+
+// sameNet 判断两个网络是否相同
+func sameNet(a, b *net.IPNet) bool {
+	return a.IP.Equal(b.IP) && a.Mask.String() == b.Mask.String()
+}
@@ -0,0 +1,81 @@
+package config
+
+import (
+	"bytes"
+	"net"
+	"sort"
+	"strings"
+)
+
+// WithSorted 按规范顺序保存条目，并去除完全相同的重复条目
+//
+// 规范顺序为: IP和CIDR在前，按地址族（IPv4在前）、网络地址和前缀长度排序；
+// 其他条目（如域名）在后，按字符串排序。同一份列表无论添加的顺序如何，保存的结果都相同，
+// 便于用diff比较不同版本的列表。
+//
+// 与WithPreserveComments同时使用时，条目的行内注释和紧挨在上方的注释随条目移动，
+// 与条目之间隔着空行的注释（如分节标题）不再保留。
+func WithSorted() SaveOption {
+	return func(o *SaveOptions) { o.Sorted = true }
+}
+
+// canonicalSort 返回按规范顺序排列并去重后的条目，不修改lines
+func canonicalSort(lines []string) []string {
+	type entry struct {
+		line   string
+		ip     net.IP
+		prefix int
+	}
+	entries := make([]entry, 0, len(lines))
+	seen := make(map[string]struct{}, len(lines))
+	for _, line := range lines {
+		if _, ok := seen[line]; ok {
+			continue
+		}
+		seen[line] = struct{}{}
+		e := entry{line: line}
+		if _, network, err := net.ParseCIDR(line); err == nil {
+			e.ip = network.IP.To16()
+			e.prefix, _ = network.Mask.Size()
+		} else if addr := net.ParseIP(line); addr != nil {
+			// 单个IP与全长前缀的网段相同，排在包含它的网段之后
+			e.ip, e.prefix = addr.To16(), 8*len(addr)
+			if addr.To4() != nil {
+				e.prefix = 32
+			}
+		}
+		entries = append(entries, e)
+	}
+
+	family := func(e entry) int {
+		switch {
+		case e.ip == nil:
+			return 2
+		case e.ip.To4() != nil:
+			return 0
+		default:
+			return 1
+		}
+	}
+	sort.SliceStable(entries, func(i, j int) bool {
+		a, b := entries[i], entries[j]
+		if fa, fb := family(a), family(b); fa != fb {
+			return fa < fb
+		}
+		if a.ip != nil {
+			if c := bytes.Compare(a.ip, b.ip); c != 0 {
+				return c < 0
+			}
+			if a.prefix != b.prefix {
+				return a.prefix < b.prefix
+			}
+		}
+		return strings.Compare(a.line, b.line) < 0
+	})
+
+	sorted := make([]string, len(entries))
+	for i, e := range entries {
+		sorted[i] = e.line
+	}
+	return sorted
+}
@@ -0,0 +1,73 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// TestCanonicalSort 测试规范顺序
+func TestCanonicalSort(t *testing.T) {
+	tests := []struct {
+		name  string
+		lines []string
+		want  []string
+	}{
+		{
+			name:  "IPv4按地址和前缀排序",
+			lines: []string{"192.0.2.1", "10.0.0.0/8", "10.0.0.0", "9.255.255.255", "10.0.0.0/16"},
+			want:  []string{"9.255.255.255", "10.0.0.0/8", "10.0.0.0/16", "10.0.0.0", "192.0.2.1"},
+		},
+		{
+			name:  "IPv4在IPv6之前，域名在最后",
+			lines: []string{"example.com", "2001:db8::/32", "!ads.example.com", "198.51.100.0/24", "::1"},
+			want:  []string{"198.51.100.0/24", "::1", "2001:db8::/32", "!ads.example.com", "example.com"},
+		},
+		{
+			name:  "去除完全相同的条目",
+			lines: []string{"b.com", "a.com", "b.com", "10.0.0.1", "10.0.0.1"},
+			want:  []string{"10.0.0.1", "a.com", "b.com"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := canonicalSort(tt.lines); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("canonicalSort() = %v, 期望 %v", got, tt.want)
+			}
+		})
+	}
+}
+
+// TestSaveLinesSorted 测试排序保存与添加顺序无关，并保留附加在条目上的注释
+func TestSaveLinesSorted(t *testing.T) {
+	dir := t.TempDir()
+	a, b := filepath.Join(dir, "a.txt"), filepath.Join(dir, "b.txt")
+	if err := SaveLines(a, []string{"10.0.0.2", "10.0.0.1"}, WithSorted()); err != nil {
+		t.Fatalf("SaveLines() 返回错误: %v", err)
+	}
+	if err := SaveLines(b, []string{"10.0.0.1", "10.0.0.2", "10.0.0.1"}, WithSorted()); err != nil {
+		t.Fatalf("SaveLines() 返回错误: %v", err)
+	}
+	linesA, _ := ReadLines(a)
+	linesB, _ := ReadLines(b)
+	if !reflect.DeepEqual(linesA, []string{"10.0.0.1", "10.0.0.2"}) || !reflect.DeepEqual(linesA, linesB) {
+		t.Errorf("ReadLines() = %v 和 %v, 期望相同的规范顺序", linesA, linesB)
+	}
+
+	if err := os.WriteFile(a, []byte("# 分节\n\n# SEC-1\n10.0.0.9\n10.0.0.3 # OPS-2\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := SaveLines(a, []string{"10.0.0.9", "10.0.0.3", "10.0.0.5"}, WithOverwrite(true), WithSorted(), WithPreserveComments(true)); err != nil {
+		t.Fatalf("SaveLines() 返回错误: %v", err)
+	}
+	data, _ := os.ReadFile(a)
+	want := "10.0.0.3 # OPS-2\n10.0.0.5\n# SEC-1\n10.0.0.9\n"
+	if !strings.HasSuffix(string(data), "\n"+want) {
+		t.Errorf("文件内容 =\n%s\n期望以\n%s结尾", data, want)
+	}
+	if strings.Contains(string(data), "分节") {
+		t.Errorf("排序时不应保留分节注释:\n%s", data)
+	}
+}
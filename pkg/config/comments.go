@@ -0,0 +1,271 @@
+package config
+
+import (
+	"bufio"
+	"bytes"
+	"io"
+	"os"
+	"sort"
+	"strings"
+)
+
+// EntryComment 是列表文件中附加在一个条目上的注释
+//
+// 字段说明:
+//   - Above: 紧挨在条目上方的注释行（去掉"#"和首尾空白），与条目之间有空行的注释不属于该条目
+//   - Inline: 条目同一行"#"之后的注释（去掉首尾空白）
+//
+// 运维人员常在条目上记录工单号、封禁原因等信息，例如:
+//
+//	# 撞库攻击，见 SEC-1234
+//	198.51.100.7
+//	203.0.113.0/24   # OPS-42 临时封禁
+type EntryComment struct {
+	Above  []string `json:"above,omitempty"`
+	Inline string   `json:"inline,omitempty"`
+}
+
+// WithPreserveComments 设置覆盖已存在的文件时是否保留其中的注释和条目顺序
+//
+// 启用后SaveLines读取原文件:
+//   - 仍然存在的条目保持原来的顺序，行内注释和紧挨在上方的注释随条目保留
+//   - 被删除的条目连同附加在它上面的注释一起删除
+//   - 与条目之间隔着空行的注释（如分节标题）和空行原样保留
+//   - 新增的条目按lines中的顺序追加在文件末尾
+//   - 文件头中的说明和元数据按本次的选项重新生成
+//
+// 条目按不区分大小写的写法匹配，如"2001:DB8::1"与"2001:db8::1"视为同一条目，写入时使用lines中的写法。
+// 加密保存（WithEncryption）时不保留注释。
+func WithPreserveComments(preserve bool) SaveOption {
+	return func(o *SaveOptions) { o.PreserveComments = preserve }
+}
+
+// ReadComments 读取列表文件中附加在每个条目上的注释
+//
+// 参数:
+//   - filePath: 要读取的文件路径
+//
+// 返回:
+//   - map[string]EntryComment: 条目到注释的映射，键为ReadLines返回的条目，没有注释的条目不在其中
+//   - error: 文件不存在时返回ErrFileNotFound，以及读取错误
+//
+// SaveLines写入的文件头（说明和元数据行）不属于任何条目。
+//
+// 示例:
+//
+//	comments, err := config.ReadComments("./blacklist.txt")
+//	if c, ok := comments["198.51.100.7"]; ok {
+//	    fmt.Println(c.Inline, c.Above)
+//	}
+func ReadComments(filePath string) (map[string]EntryComment, error) {
+	data, err := os.ReadFile(filePath)
+	if os.IsNotExist(err) {
+		return nil, ErrFileNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return ParseComments(bytes.NewReader(data))
+}
+
+// ParseComments 从r中读取附加在每个条目上的注释，规则与ReadComments相同
+//
+// 参数:
+//   - r: 列表数据
+//
+// 返回:
+//   - map[string]EntryComment: 条目到注释的映射
+//   - error: 读取错误
+func ParseComments(r io.Reader) (map[string]EntryComment, error) {
+	lines, err := scanListFile(r)
+	if err != nil {
+		return nil, err
+	}
+
+	comments := make(map[string]EntryComment)
+	var above []string
+	for _, l := range lines {
+		switch {
+		case l.generated:
+		case l.value != "":
+			c := EntryComment{Above: above, Inline: l.inline}
+			if len(c.Above) > 0 || c.Inline != "" {
+				if _, ok := comments[l.value]; !ok {
+					comments[l.value] = c
+				}
+			}
+			above = nil
+		case l.comment:
+			above = append(above, strings.TrimSpace(strings.TrimPrefix(strings.TrimSpace(l.raw), "#")))
+		default:
+			above = nil
+		}
+	}
+	return comments, nil
+}
+
+// listLine 是列表文件中的一行
+type listLine struct {
+	raw string
+	// value 是条目，注释行和空行为空
+	value string
+	// suffix 是条目之后的原文，包括空白和行内注释
+	suffix string
+	inline string
+	// comment 表示整行是注释
+	comment bool
+	// generated 表示该行是SaveLines生成的文件头，重新保存时被替换
+	generated bool
+}
+
+// scanListFile 逐行读取列表文件，并标出SaveLines生成的文件头
+//
+// 第一个条目之前的元数据行属于文件头；存在"# Generated:"行时，第一行注释是说明，同样属于文件头。
+func scanListFile(r io.Reader) ([]listLine, error) {
+	r, err := decompress(r)
+	if err != nil {
+		return nil, err
+	}
+
+	var lines []listLine
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		raw := scanner.Text()
+		trimmed := strings.TrimSpace(raw)
+		l := listLine{raw: raw}
+		switch {
+		case trimmed == "":
+		case strings.HasPrefix(trimmed, "#"):
+			l.comment = true
+		default:
+			value, comment, found := strings.Cut(trimmed, "#")
+			l.value = strings.TrimSpace(value)
+			if found {
+				l.suffix = trimmed[len(l.value):]
+				l.inline = strings.TrimSpace(comment)
+			}
+		}
+		lines = append(lines, l)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	firstComment, generated := -1, false
+	for i := range lines {
+		if lines[i].value != "" {
+			break
+		}
+		if !lines[i].comment {
+			continue
+		}
+		if firstComment < 0 {
+			firstComment = i
+		}
+		comment := strings.TrimSpace(strings.TrimPrefix(strings.TrimSpace(lines[i].raw), "#"))
+		var meta Metadata
+		if parseMetadataLine(&meta, comment) {
+			lines[i].generated = true
+			generated = generated || strings.HasPrefix(comment, metaGenerated+":")
+		}
+	}
+	if generated && firstComment >= 0 {
+		lines[firstComment].generated = true
+	}
+	return lines, nil
+}
+
+// writePreserved 按原文件的顺序和注释写入lines，原文件中没有的条目追加在末尾
+func writePreserved(w *bufio.Writer, original []byte, lines []string) error {
+	scanned, err := scanListFile(bytes.NewReader(original))
+	if err != nil {
+		return err
+	}
+
+	// remaining 记录每个条目尚未写入的写法，同一条目在原文件中出现多次时只保留第一次
+	remaining := make(map[string]string, len(lines))
+	for _, line := range lines {
+		if _, ok := remaining[strings.ToLower(line)]; !ok {
+			remaining[strings.ToLower(line)] = line
+		}
+	}
+
+	var out, pending []string
+	started := false
+	for _, l := range scanned {
+		switch {
+		case l.generated:
+		case l.value != "":
+			key := strings.ToLower(l.value)
+			if value, ok := remaining[key]; ok {
+				out = append(out, pending...)
+				out = append(out, value+l.suffix)
+				delete(remaining, key)
+			}
+			pending = nil
+			started = true
+		case l.comment:
+			pending = append(pending, l.raw)
+			started = true
+		default:
+			// 第一行内容之前的空行不保留，新的文件头之后直接是内容
+			if started {
+				out = append(out, pending...)
+				out = append(out, l.raw)
+			}
+			pending = nil
+		}
+	}
+	out = append(out, pending...)
+
+	for _, line := range lines {
+		key := strings.ToLower(line)
+		if value, ok := remaining[key]; ok && value == line {
+			out = append(out, line)
+			delete(remaining, key)
+		}
+	}
+
+	for _, line := range out {
+		if _, err := w.WriteString(line + "\n"); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// writeAnnotated 按lines的顺序写入条目，附加在原文件中同一条目上的注释随条目写入
+func writeAnnotated(w *bufio.Writer, original []byte, lines []string) error {
+	comments, err := ParseComments(bytes.NewReader(original))
+	if err != nil {
+		return err
+	}
+	// 只有大小写不同的条目按字符串顺序取第一个的注释，保证输出稳定
+	entries := make([]string, 0, len(comments))
+	for entry := range comments {
+		entries = append(entries, entry)
+	}
+	sort.Strings(entries)
+	folded := make(map[string]EntryComment, len(comments))
+	for _, entry := range entries {
+		if _, ok := folded[strings.ToLower(entry)]; !ok {
+			folded[strings.ToLower(entry)] = comments[entry]
+		}
+	}
+
+	for _, line := range lines {
+		c := folded[strings.ToLower(line)]
+		for _, above := range c.Above {
+			if _, err := w.WriteString("# " + above + "\n"); err != nil {
+				return err
+			}
+		}
+		if c.Inline != "" {
+			line += " # " + c.Inline
+		}
+		if _, err := w.WriteString(line + "\n"); err != nil {
+			return err
+		}
+	}
+	return nil
+}
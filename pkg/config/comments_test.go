@@ -0,0 +1,150 @@
+package config
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/cyberspacesec/go-acl/pkg/types"
+)
+
+// annotatedList 是运维人员编辑过的列表文件，条目上记录了工单号
+const annotatedList = `# IP Blacklist
+# Generated: 2024-01-01 00:00:00
+# Type: blacklist
+# Entries: 4
+# Generator: go-acl/v1.0.0
+# 撞库攻击，见 SEC-1234
+198.51.100.7
+
+# --- 合作方 ---
+203.0.113.0/24   # OPS-42 临时封禁
+# 已解封
+192.0.2.1
+2001:DB8::1 # SEC-99
+`
+
+// TestSaveLinesPreserveComments 测试重新保存时保留注释和条目顺序
+func TestSaveLinesPreserveComments(t *testing.T) {
+	clock := types.NewManualClock(time.Date(2024, 2, 1, 0, 0, 0, 0, time.Local))
+
+	tests := []struct {
+		name  string
+		lines []string
+		opts  []SaveOption
+		want  string
+	}{
+		{
+			name:  "删除条目并追加新条目",
+			lines: []string{"10.0.0.0/8", "2001:db8::1", "203.0.113.0/24", "198.51.100.7"},
+			opts:  []SaveOption{WithPreserveComments(true)},
+			want: `# IP Blacklist
+# Generated: 2024-02-01 00:00:00
+# Type: blacklist
+# Entries: 4
+# Generator: go-acl/v1.0.0
+# 撞库攻击，见 SEC-1234
+198.51.100.7
+
+# --- 合作方 ---
+203.0.113.0/24   # OPS-42 临时封禁
+2001:db8::1 # SEC-99
+10.0.0.0/8
+`,
+		},
+		{
+			name:  "未启用时重新生成",
+			lines: []string{"198.51.100.7"},
+			want: `# IP Blacklist
+# Generated: 2024-02-01 00:00:00
+# Type: blacklist
+# Entries: 1
+# Generator: go-acl/v1.0.0
+198.51.100.7
+`,
+		},
+		{
+			name:  "加密保存时不保留",
+			lines: []string{"198.51.100.7"},
+			opts:  []SaveOption{WithPreserveComments(true), WithEncryption(StaticKey(strings.Repeat("k", 32)))},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "ips.txt")
+			if err := os.WriteFile(path, []byte(annotatedList), 0o644); err != nil {
+				t.Fatal(err)
+			}
+			opts := append([]SaveOption{WithHeader("IP Blacklist"), WithListType(types.Blacklist), WithOverwrite(true), WithClock(clock), WithGenerator("go-acl/v1.0.0")}, tt.opts...)
+			if err := SaveLines(path, tt.lines, opts...); err != nil {
+				t.Fatalf("SaveLines() 返回错误: %v", err)
+			}
+			if tt.want == "" {
+				lines, err := ReadIPACLEncrypted(path, StaticKey(strings.Repeat("k", 32)))
+				if err != nil || !reflect.DeepEqual(lines, tt.lines) {
+					t.Errorf("ReadIPACLEncrypted() = %v, %v, 期望 %v", lines, err, tt.lines)
+				}
+				return
+			}
+			data, _ := os.ReadFile(path)
+			if string(data) != tt.want {
+				t.Errorf("文件内容 =\n%s\n期望\n%s", data, tt.want)
+			}
+		})
+	}
+}
+
+// TestSaveLinesPreserveHandwritten 测试手工编写的文件中所有注释都被保留
+func TestSaveLinesPreserveHandwritten(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "ips.txt")
+	if err := os.WriteFile(path, []byte("# 办公网出口\n10.0.0.1\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := SaveLines(path, []string{"10.0.0.1", "10.0.0.2"}, WithOverwrite(true), WithPreserveComments(true)); err != nil {
+		t.Fatalf("SaveLines() 返回错误: %v", err)
+	}
+	comments, err := ReadComments(path)
+	if err != nil {
+		t.Fatalf("ReadComments() 返回错误: %v", err)
+	}
+	if want := (EntryComment{Above: []string{"办公网出口"}}); !reflect.DeepEqual(comments["10.0.0.1"], want) {
+		t.Errorf("10.0.0.1的注释 = %+v, 期望 %+v", comments["10.0.0.1"], want)
+	}
+
+	// 再次保存时新的文件头被替换，不会重复
+	if err := SaveLines(path, []string{"10.0.0.1"}, WithOverwrite(true), WithPreserveComments(true)); err != nil {
+		t.Fatalf("SaveLines() 返回错误: %v", err)
+	}
+	data, _ := os.ReadFile(path)
+	if n := strings.Count(string(data), "# Generated:"); n != 1 {
+		t.Errorf("文件中有%d行生成时间, 期望1行:\n%s", n, data)
+	}
+	if lines, _ := ReadLines(path); !reflect.DeepEqual(lines, []string{"10.0.0.1"}) {
+		t.Errorf("ReadLines() = %v", lines)
+	}
+}
+
+// TestParseComments 测试读取附加在条目上的注释
+func TestParseComments(t *testing.T) {
+	comments, err := ParseComments(strings.NewReader(annotatedList))
+	if err != nil {
+		t.Fatalf("ParseComments() 返回错误: %v", err)
+	}
+	want := map[string]EntryComment{
+		"198.51.100.7":   {Above: []string{"撞库攻击，见 SEC-1234"}},
+		"203.0.113.0/24": {Above: []string{"--- 合作方 ---"}, Inline: "OPS-42 临时封禁"},
+		"192.0.2.1":      {Above: []string{"已解封"}},
+		"2001:DB8::1":    {Inline: "SEC-99"},
+	}
+	if !reflect.DeepEqual(comments, want) {
+		t.Errorf("ParseComments() = %+v, 期望 %+v", comments, want)
+	}
+
+	if _, err := ReadComments(filepath.Join(t.TempDir(), "missing.txt")); !errors.Is(err, ErrFileNotFound) {
+		t.Errorf("ReadComments() 错误 = %v, 期望 %v", err, ErrFileNotFound)
+	}
+}
@@ -0,0 +1,15 @@
+package config
+
+// ReadIPList 等同于ReadIPACL
+//
+// Deprecated: 请改用 ReadIPACL。
+func ReadIPList(filePath string) ([]string, error) {
+	return ReadIPACL(filePath)
+}
+
+// SaveIPList 等同于SaveIPACL
+//
+// Deprecated: 请改用 SaveIPACL。
+func SaveIPList(filePath string, ipList []string, overwrite bool) error {
+	return SaveIPACL(filePath, ipList, overwrite)
+}
@@ -0,0 +1,28 @@
+package config
+
+import (
+	"errors"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+// TestDeprecatedAliases 测试旧版名称与新名称的行为一致
+func TestDeprecatedAliases(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "ips.txt")
+	ips := []string{"192.168.1.1", "10.0.0.0/8"}
+
+	if err := SaveIPList(path, ips, false); err != nil {
+		t.Fatalf("SaveIPList() 返回错误: %v", err)
+	}
+	got, err := ReadIPList(path)
+	if err != nil {
+		t.Fatalf("ReadIPList() 返回错误: %v", err)
+	}
+	if !reflect.DeepEqual(got, ips) {
+		t.Errorf("ReadIPList() = %v, 期望 %v", got, ips)
+	}
+	if err := SaveIPList(path, ips, false); !errors.Is(err, ErrFileExists) {
+		t.Errorf("SaveIPList() 对已存在的文件应返回 ErrFileExists, 实际: %v", err)
+	}
+}
@@ -0,0 +1,118 @@
+package config
+
+import (
+	"bytes"
+	"compress/gzip"
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// TestSaveLinesGzip 测试压缩保存后可以透明地读取
+func TestSaveLinesGzip(t *testing.T) {
+	dir := t.TempDir()
+	lines := []string{"10.0.0.0/8", "192.0.2.1"}
+	for i := 0; i < 1000; i++ {
+		lines = append(lines, "198.51.100.7")
+	}
+	plain, gz := filepath.Join(dir, "list.txt"), filepath.Join(dir, "list.txt.gz")
+	if err := SaveLines(plain, lines); err != nil {
+		t.Fatalf("SaveLines() 返回错误: %v", err)
+	}
+	if err := SaveLines(gz, lines, WithGzip(), WithHeader("压缩"), WithRevision(7)); err != nil {
+		t.Fatalf("SaveLines() 返回错误: %v", err)
+	}
+
+	data, _ := os.ReadFile(gz)
+	if !bytes.HasPrefix(data, gzipMagic) {
+		t.Fatal("文件不是gzip格式")
+	}
+	if info, _ := os.Stat(plain); int64(len(data)) >= info.Size() {
+		t.Errorf("压缩后%d字节, 未压缩%d字节", len(data), info.Size())
+	}
+
+	got, err := ReadLines(gz)
+	if err != nil || !reflect.DeepEqual(got, lines) {
+		t.Errorf("ReadLines() 返回 %d 条, %v", len(got), err)
+	}
+	_, meta, err := ReadIPACLWithMetadata(gz)
+	if err != nil || meta.Header != "压缩" || meta.Revision != 7 || meta.Entries != len(lines) {
+		t.Errorf("ReadIPACLWithMetadata() = %+v, %v", meta, err)
+	}
+
+	// 覆盖压缩文件时可以保留其中的注释
+	if err := SaveLines(gz, []string{"10.0.0.0/8"}, WithGzip(), WithOverwrite(true), WithPreserveComments(true)); err != nil {
+		t.Fatalf("SaveLines() 返回错误: %v", err)
+	}
+	if got, _ := ReadLines(gz); !reflect.DeepEqual(got, []string{"10.0.0.0/8"}) {
+		t.Errorf("ReadLines() = %v", got)
+	}
+}
+
+// TestSaveLinesGzipEncrypted 测试先压缩再加密
+func TestSaveLinesGzipEncrypted(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "list.enc")
+	keys := StaticKey(bytes.Repeat([]byte{0x42}, 32))
+	if err := SaveLines(path, []string{"192.0.2.1"}, WithGzip(), WithEncryption(keys)); err != nil {
+		t.Fatalf("SaveLines() 返回错误: %v", err)
+	}
+	if got, err := ReadIPACLEncrypted(path, keys); err != nil || !reflect.DeepEqual(got, []string{"192.0.2.1"}) {
+		t.Errorf("ReadIPACLEncrypted() = %v, %v", got, err)
+	}
+}
+
+// TestParseLinesGzip 测试ParseLines只在WithGunzip时解压，无效的压缩数据返回错误
+func TestParseLinesGzip(t *testing.T) {
+	var buf bytes.Buffer
+	zw := gzip.NewWriter(&buf)
+	zw.Write([]byte("# 订阅源\n203.0.113.0/24\n"))
+	zw.Close()
+	data := buf.Bytes()
+
+	if got, err := ParseLines(bytes.NewReader(data), WithGunzip()); err != nil || !reflect.DeepEqual(got, []string{"203.0.113.0/24"}) {
+		t.Errorf("ParseLines(WithGunzip) = %v, %v", got, err)
+	}
+	if got, _ := ParseLines(bytes.NewReader(data)); reflect.DeepEqual(got, []string{"203.0.113.0/24"}) {
+		t.Error("ParseLines() 默认不应解压")
+	}
+	if _, err := ParseLines(strings.NewReader("\x1f\x8b不是gzip"), WithGunzip()); err == nil {
+		t.Error("ParseLines() 对无效的压缩数据应返回错误")
+	}
+}
+
+// TestParseLinesMaxBytes 测试WithMaxBytes同时限制压缩数据和解压后的数据
+func TestParseLinesMaxBytes(t *testing.T) {
+	text := "203.0.113.0/24\n" + strings.Repeat("#\n", 1<<16)
+	var buf bytes.Buffer
+	zw := gzip.NewWriter(&buf)
+	zw.Write([]byte(text))
+	zw.Close()
+	compressed := buf.Bytes()
+
+	tests := []struct {
+		name    string
+		data    []byte
+		opts    []ParseOption
+		wantErr error
+	}{
+		{"恰好达到上限", []byte(text), []ParseOption{WithMaxBytes(int64(len(text)))}, nil},
+		{"超过上限", []byte(text), []ParseOption{WithMaxBytes(int64(len(text)) - 1)}, ErrInputTooLarge},
+		{"压缩数据超过上限", compressed, []ParseOption{WithGunzip(), WithMaxBytes(int64(len(compressed)) - 1)}, ErrInputTooLarge},
+		{"解压后超过上限", compressed, []ParseOption{WithGunzip(), WithMaxBytes(int64(len(compressed)))}, ErrInputTooLarge},
+		{"解压后未超过上限", compressed, []ParseOption{WithGunzip(), WithMaxBytes(int64(len(text)))}, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParseLines(bytes.NewReader(tt.data), tt.opts...)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("ParseLines() 错误 = %v, 期望 %v", err, tt.wantErr)
+			}
+			if err == nil && !reflect.DeepEqual(got, []string{"203.0.113.0/24"}) {
+				t.Errorf("ParseLines() = %v", got)
+			}
+		})
+	}
+}
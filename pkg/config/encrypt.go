@@ -0,0 +1,176 @@
+package config
+
+import (
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+)
+
+// 加密文件相关的错误
+var (
+	// ErrInvalidKey 表示密钥长度不是AES要求的16、24或32字节
+	ErrInvalidKey = errors.New("无效的加密密钥")
+	// ErrDecrypt 表示文件不是有效的加密文件，或密钥错误、内容被篡改
+	ErrDecrypt = errors.New("文件解密失败")
+)
+
+// encryptedMagic 是加密文件的文件头，用于与普通文本列表区分
+const encryptedMagic = "GO-ACL-ENC\x01"
+
+// KeyProvider 为加密的ACL文件提供密钥
+//
+// 加密时使用CurrentKey返回的密钥，并把密钥ID以明文写入文件头；
+// 解密时按文件头中的ID调用Key。这样轮换密钥后，用旧密钥加密的文件仍然可以读取。
+// 实现者可以从环境变量、KMS、Vault等位置获取密钥。
+type KeyProvider interface {
+	// CurrentKey 返回加密新文件使用的密钥ID和AES密钥（16、24或32字节）
+	CurrentKey() (id string, key []byte, err error)
+	// Key 返回指定ID的AES密钥
+	Key(id string) ([]byte, error)
+}
+
+// StaticKey 是只有一个密钥的KeyProvider，密钥ID为空
+//
+// 示例:
+//
+//	key, _ := hex.DecodeString(os.Getenv("ACL_FILE_KEY")) // 32字节，AES-256
+//	err := config.SaveIPACLEncrypted("./targets.enc", ips, config.StaticKey(key), true)
+type StaticKey []byte
+
+// CurrentKey 返回空的密钥ID和密钥本身
+func (k StaticKey) CurrentKey() (string, []byte, error) {
+	return "", k, nil
+}
+
+// Key 忽略ID，返回密钥本身
+func (k StaticKey) Key(string) ([]byte, error) {
+	return k, nil
+}
+
+// SaveIPACLEncrypted 将IP/CIDR列表以AES-GCM加密后保存到文件
+//
+// 参数:
+//   - filePath: 要保存的文件路径
+//   - ipList: 要保存的IP/CIDR列表
+//   - keys: 提供加密密钥的KeyProvider
+//   - overwrite: 是否覆盖已存在的文件
+//
+// 返回:
+//   - error: 可能的错误:
+//   - ErrFileExists: 文件已存在且overwrite=false
+//   - ErrFilePermission: 无权限写入文件
+//   - ErrInvalidKey: 密钥长度无效或密钥ID超过255字节
+//   - KeyProvider返回的错误
+//
+// 加密前的内容与SaveIPACL写入的文本完全相同。文件权限为0600，
+// 文件头（包括密钥ID）作为附加认证数据，任何篡改都会导致读取失败。
+// 适用于黑名单本身就是敏感信息的场景，如客户名单、调查目标。
+//
+// 示例:
+//
+//	err := config.SaveIPACLEncrypted("./targets.enc", ips, config.StaticKey(key), true)
+//	if err != nil {
+//	    log.Printf("保存加密列表失败: %v", err)
+//	}
+func SaveIPACLEncrypted(filePath string, ipList []string, keys KeyProvider, overwrite bool) error {
+	return SaveLines(filePath, ipList, WithHeader("IP Access Control List"), WithOverwrite(overwrite), WithEncryption(keys))
+}
+
+// encrypt 使用keys的当前密钥加密plain，返回文件内容：文件头、随机数和密文
+func encrypt(plain []byte, keys KeyProvider) ([]byte, error) {
+	id, key, err := keys.CurrentKey()
+	if err != nil {
+		return nil, err
+	}
+	if len(id) > 255 {
+		return nil, fmt.Errorf("%w: 密钥ID过长", ErrInvalidKey)
+	}
+	aead, err := newGCM(key)
+	if err != nil {
+		return nil, err
+	}
+
+	header := append([]byte(encryptedMagic), byte(len(id)))
+	header = append(header, id...)
+	nonce := make([]byte, aead.NonceSize())
+	if _, err := io.ReadFull(rand.Reader, nonce); err != nil {
+		return nil, err
+	}
+
+	data := append(header, nonce...)
+	return aead.Seal(data, nonce, plain, header), nil
+}
+
+// ReadIPACLEncrypted 读取SaveIPACLEncrypted保存的加密IP/CIDR列表
+//
+// 参数:
+//   - filePath: 要读取的文件路径
+//   - keys: 提供解密密钥的KeyProvider，按文件头中的密钥ID查找密钥
+//
+// 返回:
+//   - []string: 解密后的IP/CIDR列表，注释规则与ReadIPACL相同
+//   - error: 可能的错误:
+//   - ErrFileNotFound: 文件不存在
+//   - ErrEmptyFile: 解密后的列表为空
+//   - ErrDecrypt: 文件不是加密文件、密钥错误或内容被篡改
+//   - ErrInvalidKey: 密钥长度无效
+//   - KeyProvider返回的错误
+//
+// 示例:
+//
+//	ips, err := config.ReadIPACLEncrypted("./targets.enc", config.StaticKey(key))
+//	if errors.Is(err, config.ErrDecrypt) {
+//	    log.Println("密钥错误或文件已被篡改")
+//	}
+func ReadIPACLEncrypted(filePath string, keys KeyProvider) ([]string, error) {
+	data, err := os.ReadFile(filePath)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, ErrFileNotFound
+		}
+		return nil, err
+	}
+
+	if len(data) < len(encryptedMagic)+1 || string(data[:len(encryptedMagic)]) != encryptedMagic {
+		return nil, fmt.Errorf("%w: 不是加密的ACL文件", ErrDecrypt)
+	}
+	idEnd := len(encryptedMagic) + 1 + int(data[len(encryptedMagic)])
+	if len(data) < idEnd {
+		return nil, fmt.Errorf("%w: 文件头不完整", ErrDecrypt)
+	}
+	header, id := data[:idEnd], string(data[len(encryptedMagic)+1:idEnd])
+
+	key, err := keys.Key(id)
+	if err != nil {
+		return nil, err
+	}
+	aead, err := newGCM(key)
+	if err != nil {
+		return nil, err
+	}
+
+	rest := data[idEnd:]
+	if len(rest) < aead.NonceSize() {
+		return nil, fmt.Errorf("%w: 文件内容不完整", ErrDecrypt)
+	}
+	plain, err := aead.Open(nil, rest[:aead.NonceSize()], rest[aead.NonceSize():], header)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrDecrypt, err)
+	}
+
+	return ParseLines(bytes.NewReader(plain), WithGunzip())
+}
+
+// newGCM 使用密钥创建AES-GCM
+func newGCM(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidKey, err)
+	}
+	return cipher.NewGCM(block)
+}
@@ -0,0 +1,121 @@
+package config
+
+import (
+	"bytes"
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+// rotatingKeys 是测试用的多密钥KeyProvider
+type rotatingKeys struct {
+	current string
+	keys    map[string][]byte
+}
+
+func (k rotatingKeys) CurrentKey() (string, []byte, error) {
+	return k.current, k.keys[k.current], nil
+}
+
+func (k rotatingKeys) Key(id string) ([]byte, error) {
+	key, ok := k.keys[id]
+	if !ok {
+		return nil, errors.New("未知的密钥ID: " + id)
+	}
+	return key, nil
+}
+
+// TestEncryptedIPACL 测试加密保存和读取IP列表
+func TestEncryptedIPACL(t *testing.T) {
+	dir := t.TempDir()
+	key := StaticKey(bytes.Repeat([]byte{0x42}, 32))
+	ips := []string{"192.0.2.1", "10.0.0.0/8", "2001:db8::/32"}
+
+	path := filepath.Join(dir, "targets.enc")
+	if err := SaveIPACLEncrypted(path, ips, key, false); err != nil {
+		t.Fatalf("SaveIPACLEncrypted() 返回错误: %v", err)
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if bytes.Contains(data, []byte("192.0.2.1")) {
+		t.Error("加密文件中不应出现明文IP")
+	}
+	if info, _ := os.Stat(path); info.Mode().Perm() != 0o600 {
+		t.Errorf("文件权限 = %v, 期望 0600", info.Mode().Perm())
+	}
+
+	got, err := ReadIPACLEncrypted(path, key)
+	if err != nil {
+		t.Fatalf("ReadIPACLEncrypted() 返回错误: %v", err)
+	}
+	if !reflect.DeepEqual(got, ips) {
+		t.Errorf("ReadIPACLEncrypted() = %v, 期望 %v", got, ips)
+	}
+
+	if err := SaveIPACLEncrypted(path, ips, key, false); !errors.Is(err, ErrFileExists) {
+		t.Errorf("不允许覆盖时 SaveIPACLEncrypted() 错误 = %v, 期望 ErrFileExists", err)
+	}
+
+	tampered := filepath.Join(dir, "tampered.enc")
+	data[len(data)-1] ^= 0xff
+	if err := os.WriteFile(tampered, data, 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	plain := filepath.Join(dir, "plain.txt")
+	if err := SaveIPACL(plain, ips, false); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name    string
+		path    string
+		keys    KeyProvider
+		wantErr error
+	}{
+		{"密钥错误", path, StaticKey(bytes.Repeat([]byte{0x24}, 32)), ErrDecrypt},
+		{"内容被篡改", tampered, key, ErrDecrypt},
+		{"普通文本文件", plain, key, ErrDecrypt},
+		{"密钥长度无效", path, StaticKey([]byte("short")), ErrInvalidKey},
+		{"文件不存在", filepath.Join(dir, "missing.enc"), key, ErrFileNotFound},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := ReadIPACLEncrypted(tt.path, tt.keys); !errors.Is(err, tt.wantErr) {
+				t.Errorf("ReadIPACLEncrypted() 错误 = %v, 期望 %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+// TestEncryptedIPACLKeyRotation 测试轮换密钥后仍能读取旧文件
+func TestEncryptedIPACLKeyRotation(t *testing.T) {
+	dir := t.TempDir()
+	keys := rotatingKeys{current: "2023", keys: map[string][]byte{
+		"2023": bytes.Repeat([]byte{1}, 16),
+		"2024": bytes.Repeat([]byte{2}, 16),
+	}}
+
+	old := filepath.Join(dir, "old.enc")
+	if err := SaveIPACLEncrypted(old, []string{"192.0.2.1"}, keys, false); err != nil {
+		t.Fatalf("SaveIPACLEncrypted() 返回错误: %v", err)
+	}
+
+	keys.current = "2024"
+	current := filepath.Join(dir, "new.enc")
+	if err := SaveIPACLEncrypted(current, []string{"198.51.100.1"}, keys, false); err != nil {
+		t.Fatalf("SaveIPACLEncrypted() 返回错误: %v", err)
+	}
+
+	for path, want := range map[string]string{old: "192.0.2.1", current: "198.51.100.1"} {
+		got, err := ReadIPACLEncrypted(path, keys)
+		if err != nil || len(got) != 1 || got[0] != want {
+			t.Errorf("ReadIPACLEncrypted(%s) = %v, %v, 期望 [%s]", filepath.Base(path), got, err, want)
+		}
+	}
+}
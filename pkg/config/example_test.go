@@ -0,0 +1,59 @@
+package config_test
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/cyberspacesec/go-acl/pkg/config"
+	"github.com/cyberspacesec/go-acl/pkg/types"
+)
+
+func ExampleParseLines() {
+	feed := `# 威胁情报
+203.0.113.0/24
+198.51.100.7   # 工单 SEC-42
+
+2001:db8::/32
+`
+	lines, err := config.ParseLines(strings.NewReader(feed))
+	if err != nil {
+		fmt.Println("解析失败:", err)
+		return
+	}
+	fmt.Println(lines)
+	// Output:
+	// [203.0.113.0/24 198.51.100.7 2001:db8::/32]
+}
+
+func ExampleSaveLines() {
+	dir, err := os.MkdirTemp("", "go-acl-example-")
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "blacklist.txt")
+
+	err = config.SaveLines(path, []string{"203.0.113.0/24", "198.51.100.7"},
+		config.WithListType(types.Blacklist),
+		config.WithRevision(42),
+		config.WithAtomic(),
+	)
+	if err != nil {
+		fmt.Println("保存失败:", err)
+		return
+	}
+
+	ips, meta, err := config.ReadIPACLWithMetadata(path)
+	if err != nil {
+		fmt.Println("读取失败:", err)
+		return
+	}
+	fmt.Println(ips)
+	fmt.Println(meta.ListType, meta.Entries, meta.Revision)
+	// Output:
+	// [203.0.113.0/24 198.51.100.7]
+	// blacklist 2 42
+}
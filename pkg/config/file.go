@@ -3,9 +3,9 @@ package config
 import (
 	"bufio"
 	"errors"
+	"io"
 	"os"
 	"strings"
-	"time"
 )
 
 // 标准错误定义
@@ -18,6 +18,8 @@ var (
 	ErrFileExists = errors.New("文件已存在")
 	// ErrFilePermission 表示无权限操作文件
 	ErrFilePermission = errors.New("文件权限错误")
+	// ErrInputTooLarge 表示读取的数据超过了WithMaxBytes设置的上限
+	ErrInputTooLarge = errors.New("数据超过读取上限")
 )
 
 // ReadIPACL 从文件中读取IP/CIDR列表
@@ -68,6 +70,24 @@ var (
 //	    fmt.Println(ip)
 //	}
 func ReadIPACL(filePath string) ([]string, error) {
+	return ReadLines(filePath)
+}
+
+// ReadLines 从文件中读取有效行，忽略空行和注释
+//
+// 参数:
+//   - filePath: 要读取的文件路径
+//
+// 返回:
+//   - []string: 去除注释和首尾空白后的有效行
+//   - error: 可能的错误:
+//   - ErrFileNotFound: 文件不存在
+//   - ErrEmptyFile: 文件为空或只包含注释
+//   - 其他系统错误: 如权限错误、I/O错误等
+//
+// 注释规则与ReadIPACL相同：#开头的行和行内#后的内容都会被忽略。
+// 此函数是ReadIPACL的通用版本，可用于读取域名列表、规则表达式等按行组织的文件。
+func ReadLines(filePath string) ([]string, error) {
 	// 检查文件是否存在
 	if _, err := os.Stat(filePath); os.IsNotExist(err) {
 		return nil, ErrFileNotFound
@@ -80,8 +100,45 @@ func ReadIPACL(filePath string) ([]string, error) {
 	}
 	defer file.Close()
 
-	var ips []string
-	scanner := bufio.NewScanner(file)
+	return ParseLines(file, WithGunzip())
+}
+
+// ParseLines 从r中读取有效行，规则与ReadLines相同
+//
+// 参数:
+//   - r: 数据来源，如HTTP响应体
+//   - opts: 读取选项，如WithGunzip、WithMaxBytes
+//
+// 返回:
+//   - []string: 去除注释和首尾空白后的有效行
+//   - error: 读取错误，数据超过WithMaxBytes的上限时返回ErrInputTooLarge，没有有效行时返回ErrEmptyFile
+//
+// 适用于不在本地文件中的列表，如从网络下载的规则源。与ReadLines不同，默认不解压gzip数据，
+// 读取不可信的来源时应同时使用WithMaxBytes，避免解压炸弹耗尽内存。
+//
+// 示例:
+//
+//	lines, err := config.ParseLines(resp.Body, config.WithGunzip(), config.WithMaxBytes(64<<20))
+func ParseLines(r io.Reader, opts ...ParseOption) ([]string, error) {
+	var options ParseOptions
+	for _, opt := range opts {
+		opt(&options)
+	}
+	if options.MaxBytes > 0 {
+		r = limitReader(r, options.MaxBytes)
+	}
+	if options.Gunzip {
+		var err error
+		if r, err = decompress(r); err != nil {
+			return nil, err
+		}
+		if options.MaxBytes > 0 {
+			r = limitReader(r, options.MaxBytes)
+		}
+	}
+
+	var lines []string
+	scanner := bufio.NewScanner(r)
 
 	for scanner.Scan() {
 		line := scanner.Text()
@@ -101,7 +158,7 @@ func ReadIPACL(filePath string) ([]string, error) {
 
 		// 如果处理后的行不为空，则添加到列表中
 		if line != "" {
-			ips = append(ips, line)
+			lines = append(lines, line)
 		}
 	}
 
@@ -111,11 +168,11 @@ func ReadIPACL(filePath string) ([]string, error) {
 	}
 
 	// 检查是否为空列表
-	if len(ips) == 0 {
+	if len(lines) == 0 {
 		return nil, ErrEmptyFile
 	}
 
-	return ips, nil
+	return lines, nil
 }
 
 // SaveIPACLWithHeader 将IP/CIDR列表保存到文件
@@ -139,7 +196,7 @@ func ReadIPACL(filePath string) ([]string, error) {
 //
 // 生成的文件格式:
 //   - 第一行是提供的header（如有）
-//   - 第二行是生成时间
+//   - 之后是生成时间、条目数量和生成器等元数据注释行，见Metadata
 //   - 之后每行一个IP/CIDR
 //
 // 示例:
@@ -162,42 +219,31 @@ func ReadIPACL(filePath string) ([]string, error) {
 //	}
 //	fmt.Println("IP列表已成功保存")
 func SaveIPACLWithHeader(filePath string, ipList []string, header string, overwrite bool) error {
-	// 检查文件是否已存在
-	if _, err := os.Stat(filePath); err == nil && !overwrite {
-		return ErrFileExists
-	} else if err != nil && !os.IsNotExist(err) {
-		// 其他非"不存在"的错误
-		return err
-	}
+	return SaveLines(filePath, ipList, WithHeader(header), WithOverwrite(overwrite))
+}
 
-	// 创建或打开文件
-	file, err := os.Create(filePath)
-	if err != nil {
-		if os.IsPermission(err) {
-			return ErrFilePermission
-		}
+// writeLines 将文件头（说明、生成时间和元数据）和列表内容写入w
+//
+// original不为nil时按其中的顺序和注释写入（排序时只保留附加在条目上的注释），见WithPreserveComments。
+func writeLines(w io.Writer, lines []string, o SaveOptions, original []byte) error {
+	writer := bufio.NewWriter(w)
+	if err := writeMetadata(writer, len(lines), o); err != nil {
 		return err
 	}
-	defer file.Close()
-
-	writer := bufio.NewWriter(file)
-
-	// 写入头部信息
-	if header != "" {
-		if _, err := writer.WriteString("# " + header + "\n"); err != nil {
+	if original != nil {
+		write := writePreserved
+		if o.Sorted {
+			write = writeAnnotated
+		}
+		if err := write(writer, original, lines); err != nil {
 			return err
 		}
-	}
-
-	// 写入生成时间
-	generatedTime := time.Now().Format("2006-01-02 15:04:05")
-	if _, err := writer.WriteString("# Generated: " + generatedTime + "\n"); err != nil {
-		return err
+		return writer.Flush()
 	}
 
 	// 写入IP列表
-	for _, ip := range ipList {
-		if _, err := writer.WriteString(ip + "\n"); err != nil {
+	for _, line := range lines {
+		if _, err := writer.WriteString(line + "\n"); err != nil {
 			return err
 		}
 	}
@@ -207,7 +253,8 @@ func SaveIPACLWithHeader(filePath string, ipList []string, header string, overwr
 
 // SaveIPACL 将IP/CIDR列表保存到文件，使用默认头部
 //
-// 这是SaveIPACLWithHeader的简化版本，使用默认的头部信息
+// 这是SaveIPACLWithHeader的简化版本，使用默认的头部信息。
+// 需要备份、原子写入等功能时使用SaveLines。
 //
 // 参数:
 //   - filePath: 要保存的文件路径
@@ -8,6 +8,9 @@ import (
 	"runtime"
 	"strings"
 	"testing"
+	"time"
+
+	"github.com/cyberspacesec/go-acl/pkg/types"
 )
 
 // 测试目录和文件路径
@@ -513,3 +516,57 @@ func TestSaveIPACLErrorHandling(t *testing.T) {
 		t.Errorf("写入只读目录应返回错误")
 	}
 }
+
+// TestSaveLinesClock 测试文件头中的生成时间和生成器来自WithClock和WithGenerator
+func TestSaveLinesClock(t *testing.T) {
+	clock := types.NewManualClock(time.Date(2024, 1, 2, 3, 4, 5, 0, time.Local))
+
+	path := filepath.Join(t.TempDir(), "ips.txt")
+	if err := SaveLines(path, []string{"10.0.0.0/8"}, WithHeader("测试"), WithClock(clock), WithGenerator("go-acl/v1.0.0")); err != nil {
+		t.Fatalf("SaveLines() 返回错误: %v", err)
+	}
+
+	content, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := "# 测试\n# Generated: 2024-01-02 03:04:05\n# Entries: 1\n# Generator: go-acl/v1.0.0\n10.0.0.0/8\n"
+	if string(content) != want {
+		t.Errorf("文件内容 = %q, 期望 %q", content, want)
+	}
+
+	// 未设置时使用系统时间和默认的生成器
+	if err := SaveLines(path, []string{"10.0.0.0/8"}, WithOverwrite(true)); err != nil {
+		t.Fatalf("SaveLines() 返回错误: %v", err)
+	}
+	_, meta, err := ReadIPACLWithMetadata(path)
+	if err != nil || meta.Generator != defaultGeneratorName || time.Since(meta.Generated) > time.Minute {
+		t.Errorf("ReadIPACLWithMetadata() = %+v, %v", meta, err)
+	}
+}
+
+// TestParseLines 测试从io.Reader读取有效行
+func TestParseLines(t *testing.T) {
+	tests := []struct {
+		name    string
+		input   string
+		want    []string
+		wantErr error
+	}{
+		{"注释和空行", "# 头部\n\n10.0.0.0/8\n192.168.1.1 # 行内注释\n", []string{"10.0.0.0/8", "192.168.1.1"}, nil},
+		{"只有注释", "# 空列表\n", nil, ErrEmptyFile},
+		{"空输入", "", nil, ErrEmptyFile},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParseLines(strings.NewReader(tt.input))
+			if err != tt.wantErr {
+				t.Fatalf("ParseLines() 错误 = %v, 期望 %v", err, tt.wantErr)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("ParseLines() = %v, 期望 %v", got, tt.want)
+			}
+		})
+	}
+}
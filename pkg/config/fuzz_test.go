@@ -0,0 +1,94 @@
+package config
+
+import (
+	"bytes"
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// FuzzParseLines 检查列表文件的解析不会panic，且保存后重新读取得到相同的条目
+//
+// ReadIPACL和ReadLines都基于ParseLines。同时检查保留注释的重新保存不会丢失或重复条目。
+//
+// 运行: go test ./pkg/config -run '^$' -fuzz FuzzParseLines
+func FuzzParseLines(f *testing.F) {
+	for _, seed := range []string{
+		"192.168.1.1\n10.0.0.0/8 # CIDR\n",
+		"# IP Blacklist\n# Generated: 2024-01-01 00:00:00\n# Entries: 1\n192.0.2.1\n",
+		"# 撞库攻击\n198.51.100.7\n\n# 分节\nA.example # x\na.example\n",
+		"\r\n  \t# only comments\n",
+		"a#b#c\n#\n##\n",
+	} {
+		f.Add(seed)
+	}
+	f.Fuzz(func(t *testing.T, data string) {
+		lines, err := ParseLines(strings.NewReader(data))
+		if err != nil {
+			if !errors.Is(err, ErrEmptyFile) {
+				// 过长的行等读取错误
+				return
+			}
+		}
+		for _, line := range lines {
+			if line == "" || line != strings.TrimSpace(line) || strings.ContainsAny(line, "#\n") {
+				t.Fatalf("ParseLines(%q) 返回了无效的条目 %q", data, line)
+			}
+		}
+		if _, err := ParseMetadata(strings.NewReader(data)); err != nil {
+			return
+		}
+		comments, err := ParseComments(strings.NewReader(data))
+		if err != nil {
+			return
+		}
+		for entry := range comments {
+			if !contains(lines, entry) {
+				t.Fatalf("ParseComments(%q) 返回了不存在的条目 %q", data, entry)
+			}
+		}
+		if len(lines) == 0 {
+			return
+		}
+
+		var buf bytes.Buffer
+		if err := writeLines(&buf, lines, SaveOptions{Header: "fuzz"}, nil); err != nil {
+			t.Fatal(err)
+		}
+		if again, err := ParseLines(&buf); err != nil || !reflect.DeepEqual(again, lines) {
+			t.Fatalf("保存后重新读取 = %q, %v, 期望 %q", again, err, lines)
+		}
+
+		buf.Reset()
+		if err := writeLines(&buf, lines, SaveOptions{}, []byte(data)); err != nil {
+			t.Fatal(err)
+		}
+		if again, err := ParseLines(&buf); err != nil || !reflect.DeepEqual(again, uniqueFold(lines)) {
+			t.Fatalf("保留注释保存后重新读取 = %q, %v, 期望 %q", again, err, uniqueFold(lines))
+		}
+	})
+}
+
+// contains 判断lines中是否有s
+func contains(lines []string, s string) bool {
+	for _, line := range lines {
+		if line == s {
+			return true
+		}
+	}
+	return false
+}
+
+// uniqueFold 按不区分大小写去重，保留第一次出现的写法
+func uniqueFold(lines []string) []string {
+	seen := make(map[string]bool, len(lines))
+	var result []string
+	for _, line := range lines {
+		if key := strings.ToLower(line); !seen[key] {
+			seen[key] = true
+			result = append(result, line)
+		}
+	}
+	return result
+}
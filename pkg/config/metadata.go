@@ -0,0 +1,259 @@
+package config
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"runtime/debug"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/cyberspacesec/go-acl/pkg/types"
+)
+
+// modulePath 是本模块的路径，用于从构建信息中查找版本
+const modulePath = "github.com/cyberspacesec/go-acl"
+
+// generatedLayout 是文件头"# Generated:"使用的时间格式（本地时间）
+const generatedLayout = "2006-01-02 15:04:05"
+
+// 文件头中元数据行的键，每行的格式为"# 键: 值"
+const (
+	metaGenerated = "Generated"
+	metaType      = "Type"
+	metaEntries   = "Entries"
+	metaRevision  = "Revision"
+	metaGenerator = "Generator"
+)
+
+// Metadata 是列表文件头中的元数据，由SaveLines写入，由ReadIPACLWithMetadata读取
+//
+// 字段说明:
+//   - Header: 第一行的说明（WithHeader），没有时为空
+//   - Generated: 生成时间（本地时间，精确到秒），没有时为零值
+//   - ListType: "blacklist"或"whitelist"（WithListType），未知时为空
+//   - Entries: 保存时的条目数量，没有记录时为-1；与读取到的条目数量不同说明文件在保存后被编辑或被截断
+//   - Revision: 保存方提供的版本号（WithRevision），没有时为0
+//   - Generator: 生成文件的程序和版本，见WithGenerator
+//
+// 生成的文件头示例:
+//
+//	# IP Blacklist
+//	# Generated: 2024-01-02 03:04:05
+//	# Type: blacklist
+//	# Entries: 3
+//	# Revision: 42
+//	# Generator: go-acl/v1.4.0
+type Metadata struct {
+	Header    string    `json:"header,omitempty"`
+	Generated time.Time `json:"generated,omitempty"`
+	ListType  string    `json:"list_type,omitempty"`
+	Entries   int       `json:"entries"`
+	Revision  uint64    `json:"revision,omitempty"`
+	Generator string    `json:"generator,omitempty"`
+}
+
+// WithListType 在文件头中记录列表类型
+func WithListType(listType types.ListType) SaveOption {
+	return func(o *SaveOptions) { o.ListType = listType.String() }
+}
+
+// WithRevision 在文件头中记录版本号，0表示不记录
+//
+// 版本号的含义由保存方决定，如配置仓库的提交序号或每次发布递增的计数。
+func WithRevision(revision uint64) SaveOption {
+	return func(o *SaveOptions) { o.Revision = revision }
+}
+
+// WithClock 设置文件头"# Generated:"时间使用的时间源，nil表示types.SystemClock
+//
+// 需要生成内容稳定的文件（如测试中与期望文件逐字节比较）时使用types.ManualClock。
+func WithClock(clock types.Clock) SaveOption {
+	return func(o *SaveOptions) { o.Clock = clock }
+}
+
+// WithGenerator 设置文件头"# Generator:"中的生成器名称和版本
+//
+// 默认为"go-acl"加构建信息中本模块的版本，如"go-acl/v1.4.0"，无法取得版本时为"go-acl"。
+// 应用可以设置为自己的名称，便于在共享的文件中区分来源。
+func WithGenerator(generator string) SaveOption {
+	return func(o *SaveOptions) { o.Generator = generator }
+}
+
+// ReadIPACLWithMetadata 与ReadIPACL相同，同时返回文件头中的元数据
+//
+// 参数:
+//   - filePath: 要读取的文件路径
+//
+// 返回:
+//   - []string: 与ReadIPACL相同
+//   - Metadata: 文件头中的元数据，没有元数据的文件（如手工编写的文件）各字段为零值，Entries为-1
+//   - error: 与ReadIPACL相同
+//
+// 元数据只从第一个条目之前的注释行中读取，无法识别的注释行被忽略，值无效的元数据行同样被忽略。
+// 也可以用于读取域名列表等SaveLines保存的其他文件。
+//
+// 示例:
+//
+//	ips, meta, err := config.ReadIPACLWithMetadata("./shared/blacklist.txt")
+//	if err == nil && meta.Entries >= 0 && meta.Entries != len(ips) {
+//	    log.Printf("文件在保存后被修改: 记录%d条，实际%d条", meta.Entries, len(ips))
+//	}
+func ReadIPACLWithMetadata(filePath string) ([]string, Metadata, error) {
+	data, err := os.ReadFile(filePath)
+	if os.IsNotExist(err) {
+		return nil, Metadata{Entries: -1}, ErrFileNotFound
+	}
+	if err != nil {
+		return nil, Metadata{Entries: -1}, err
+	}
+
+	meta, err := ParseMetadata(bytes.NewReader(data))
+	if err != nil {
+		return nil, meta, err
+	}
+	lines, err := ParseLines(bytes.NewReader(data), WithGunzip())
+	return lines, meta, err
+}
+
+// ParseMetadata 从r开头的注释行中读取元数据，规则与ReadIPACLWithMetadata相同
+//
+// 参数:
+//   - r: 列表数据
+//
+// 返回:
+//   - Metadata: 文件头中的元数据
+//   - error: 读取错误
+func ParseMetadata(r io.Reader) (Metadata, error) {
+	meta := Metadata{Entries: -1}
+	r, err := decompress(r)
+	if err != nil {
+		return meta, err
+	}
+	scanner := bufio.NewScanner(r)
+	first := true
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" {
+			continue
+		}
+		if !strings.HasPrefix(line, "#") {
+			break
+		}
+		comment := strings.TrimSpace(strings.TrimPrefix(line, "#"))
+		if !parseMetadataLine(&meta, comment) && first {
+			meta.Header = comment
+		}
+		first = false
+	}
+	return meta, scanner.Err()
+}
+
+// parseMetadataLine 解析"键: 值"形式的元数据行，不是元数据行时返回false
+func parseMetadataLine(meta *Metadata, comment string) bool {
+	key, value, ok := strings.Cut(comment, ":")
+	if !ok {
+		return false
+	}
+	value = strings.TrimSpace(value)
+
+	switch key {
+	case metaGenerated:
+		if t, err := time.ParseInLocation(generatedLayout, value, time.Local); err == nil {
+			meta.Generated = t
+		}
+	case metaType:
+		if listType, err := types.ParseListType(value); err == nil {
+			meta.ListType = listType.String()
+		}
+	case metaEntries:
+		if n, err := strconv.Atoi(value); err == nil && n >= 0 {
+			meta.Entries = n
+		}
+	case metaRevision:
+		if n, err := strconv.ParseUint(value, 10, 64); err == nil {
+			meta.Revision = n
+		}
+	case metaGenerator:
+		meta.Generator = value
+	default:
+		return false
+	}
+	return true
+}
+
+// writeMetadata 写入文件头：说明、生成时间和元数据行
+func writeMetadata(w *bufio.Writer, entries int, o SaveOptions) error {
+	var header []string
+	if o.Header != "" {
+		header = append(header, o.Header)
+	}
+	clock := o.Clock
+	if clock == nil {
+		clock = types.SystemClock
+	}
+	header = append(header, metaLine(metaGenerated, clock.Now().Format(generatedLayout)))
+	if o.ListType != "" {
+		header = append(header, metaLine(metaType, o.ListType))
+	}
+	header = append(header, metaLine(metaEntries, strconv.Itoa(entries)))
+	if o.Revision > 0 {
+		header = append(header, metaLine(metaRevision, strconv.FormatUint(o.Revision, 10)))
+	}
+	generator := o.Generator
+	if generator == "" {
+		generator = defaultGeneratorName
+	}
+	header = append(header, metaLine(metaGenerator, generator))
+
+	for _, line := range header {
+		if _, err := w.WriteString("# " + line + "\n"); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// metaLine 返回"键: 值"形式的元数据行
+func metaLine(key, value string) string {
+	return fmt.Sprintf("%s: %s", key, value)
+}
+
+// defaultGeneratorName 是未设置WithGenerator时写入文件头的生成器名称和版本
+var defaultGeneratorName = defaultGenerator()
+
+// defaultGenerator 根据构建信息返回默认的生成器名称和版本
+func defaultGenerator() string {
+	version := ModuleVersion()
+	if version == "" {
+		return "go-acl"
+	}
+	return "go-acl/" + version
+}
+
+// ModuleVersion 返回构建信息中本模块的版本
+//
+// 返回:
+//   - string: 如"v1.4.0"；本模块是主模块且未打标签（"(devel)"）或没有构建信息时为空
+func ModuleVersion() string {
+	info, ok := debug.ReadBuildInfo()
+	if !ok {
+		return ""
+	}
+	version := ""
+	if info.Main.Path == modulePath {
+		version = info.Main.Version
+	}
+	for _, dep := range info.Deps {
+		if dep.Path == modulePath {
+			version = dep.Version
+		}
+	}
+	if version == "(devel)" {
+		return ""
+	}
+	return version
+}
@@ -0,0 +1,110 @@
+package config
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/cyberspacesec/go-acl/pkg/types"
+)
+
+// TestReadIPACLWithMetadata 测试保存的元数据可以被读回
+func TestReadIPACLWithMetadata(t *testing.T) {
+	generated := time.Date(2024, 1, 2, 3, 4, 5, 0, time.Local)
+	clock := types.NewManualClock(generated)
+
+	ips := []string{"10.0.0.0/8", "192.0.2.1"}
+	tests := []struct {
+		name string
+		opts []SaveOption
+		want Metadata
+	}{
+		{
+			name: "完整的元数据",
+			opts: []SaveOption{WithHeader("IP Blacklist"), WithListType(types.Whitelist), WithRevision(42)},
+			want: Metadata{Header: "IP Blacklist", Generated: generated, ListType: "whitelist", Entries: 2, Revision: 42, Generator: "go-acl/v1.0.0"},
+		},
+		{
+			name: "没有说明和类型",
+			want: Metadata{Generated: generated, Entries: 2, Generator: "go-acl/v1.0.0"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "ips.txt")
+			opts := append([]SaveOption{WithClock(clock), WithGenerator("go-acl/v1.0.0")}, tt.opts...)
+			if err := SaveLines(path, ips, opts...); err != nil {
+				t.Fatalf("SaveLines() 返回错误: %v", err)
+			}
+			lines, meta, err := ReadIPACLWithMetadata(path)
+			if err != nil {
+				t.Fatalf("ReadIPACLWithMetadata() 返回错误: %v", err)
+			}
+			if !reflect.DeepEqual(lines, ips) {
+				t.Errorf("条目 = %v, 期望 %v", lines, ips)
+			}
+			if !meta.Generated.Equal(tt.want.Generated) {
+				t.Errorf("Generated = %v, 期望 %v", meta.Generated, tt.want.Generated)
+			}
+			meta.Generated, tt.want.Generated = time.Time{}, time.Time{}
+			if meta != tt.want {
+				t.Errorf("元数据 = %+v, 期望 %+v", meta, tt.want)
+			}
+		})
+	}
+}
+
+// TestParseMetadata 测试从手工编写或被编辑过的文件中读取元数据
+func TestParseMetadata(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+		want  Metadata
+	}{
+		{"没有元数据", "10.0.0.1\n", Metadata{Entries: -1}},
+		{"只有说明", "# 办公网出口\n\n10.0.0.1\n", Metadata{Header: "办公网出口", Entries: -1}},
+		{
+			"无效的值被忽略",
+			"# Type: greylist\n# Entries: many\n# Revision: -1\n# Generator: ops-script\n10.0.0.1\n",
+			Metadata{Entries: -1, Generator: "ops-script"},
+		},
+		{
+			"条目之后的注释不是元数据",
+			"# Entries: 1\n10.0.0.1\n# Entries: 5\n# Type: blacklist\n",
+			Metadata{Entries: 1},
+		},
+		{"第一行之后的普通注释不是说明", "# Type: blacklist\n# 备注\n", Metadata{ListType: "blacklist", Entries: -1}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			meta, err := ParseMetadata(strings.NewReader(tt.input))
+			if err != nil {
+				t.Fatalf("ParseMetadata() 返回错误: %v", err)
+			}
+			if meta != tt.want {
+				t.Errorf("ParseMetadata() = %+v, 期望 %+v", meta, tt.want)
+			}
+		})
+	}
+}
+
+// TestReadIPACLWithMetadataErrors 测试与ReadIPACL相同的错误
+func TestReadIPACLWithMetadataErrors(t *testing.T) {
+	dir := t.TempDir()
+	if _, _, err := ReadIPACLWithMetadata(filepath.Join(dir, "missing.txt")); !errors.Is(err, ErrFileNotFound) {
+		t.Errorf("文件不存在时错误 = %v, 期望 %v", err, ErrFileNotFound)
+	}
+
+	path := filepath.Join(dir, "empty.txt")
+	if err := os.WriteFile(path, []byte("# Entries: 0\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	_, meta, err := ReadIPACLWithMetadata(path)
+	if !errors.Is(err, ErrEmptyFile) || meta.Entries != 0 {
+		t.Errorf("空列表时 = %+v, %v, 期望元数据和 %v", meta, err, ErrEmptyFile)
+	}
+}
@@ -0,0 +1,270 @@
+package config
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+
+	"github.com/cyberspacesec/go-acl/pkg/types"
+)
+
+// BackupSuffix 是WithBackup保存原文件时在文件名后追加的后缀
+const BackupSuffix = ".bak"
+
+// SaveOptions 是保存列表文件的选项，通常通过SaveOption函数设置
+//
+// 字段说明:
+//   - Overwrite: 是否覆盖已存在的文件，false时文件已存在返回ErrFileExists
+//   - Header: 写在文件顶部的说明，为空时不写说明，只写生成时间
+//   - Backup: 覆盖前把原文件复制为文件名加BackupSuffix的备份，已有的备份会被替换
+//   - Atomic: 先写入同目录下的临时文件再重命名，读取方不会看到写了一半的文件
+//   - Keys: 不为nil时以AES-GCM加密保存，格式与SaveIPACLEncrypted相同，文件权限为0600
+//   - ListType: 写入文件头"# Type:"的列表类型，为空时不写，通常通过WithListType设置
+//   - Revision: 写入文件头"# Revision:"的版本号，0表示不写
+//   - PreserveComments: 覆盖已存在的文件时保留其中的注释和条目顺序，见WithPreserveComments
+//   - Sorted: 按规范顺序保存并去除重复条目，见WithSorted
+//   - Gzip: 以gzip压缩保存，见WithGzip
+//   - Clock: 写入文件头"# Generated:"时间使用的时间源，nil表示types.SystemClock，见WithClock
+//   - Generator: 写入文件头"# Generator:"的生成器，为空时使用默认值，见WithGenerator
+//
+// 新的保存功能以新字段和对应的SaveOption加入，不再增加方法的变体或布尔参数。
+type SaveOptions struct {
+	Overwrite        bool
+	Header           string
+	Backup           bool
+	Atomic           bool
+	Keys             KeyProvider
+	ListType         string
+	Revision         uint64
+	PreserveComments bool
+	Sorted           bool
+	Gzip             bool
+	Clock            types.Clock
+	Generator        string
+}
+
+// SaveOption 修改SaveOptions中的一项设置
+type SaveOption func(*SaveOptions)
+
+// WithOverwrite 设置是否覆盖已存在的文件
+func WithOverwrite(overwrite bool) SaveOption {
+	return func(o *SaveOptions) { o.Overwrite = overwrite }
+}
+
+// WithHeader 设置文件顶部的说明
+func WithHeader(header string) SaveOption {
+	return func(o *SaveOptions) { o.Header = header }
+}
+
+// WithBackup 覆盖前保留原文件的备份，同时允许覆盖已存在的文件
+func WithBackup() SaveOption {
+	return func(o *SaveOptions) { o.Backup, o.Overwrite = true, true }
+}
+
+// WithAtomic 通过临时文件加重命名的方式原子地写入
+func WithAtomic() SaveOption {
+	return func(o *SaveOptions) { o.Atomic = true }
+}
+
+// WithEncryption 使用keys提供的密钥加密保存，读取时使用ReadIPACLEncrypted
+func WithEncryption(keys KeyProvider) SaveOption {
+	return func(o *SaveOptions) { o.Keys = keys }
+}
+
+// WithSaveOptions 一次设置所有选项，之前的SaveOption被覆盖
+//
+// 适用于选项来自配置文件等已经组装好SaveOptions的场景。
+func WithSaveOptions(opts SaveOptions) SaveOption {
+	return func(o *SaveOptions) { *o = opts }
+}
+
+// NewSaveOptions 按顺序应用opts，返回最终的选项，后面的选项覆盖前面的同一项设置
+func NewSaveOptions(opts ...SaveOption) SaveOptions {
+	var o SaveOptions
+	for _, opt := range opts {
+		if opt != nil {
+			opt(&o)
+		}
+	}
+	return o
+}
+
+// SaveLines 将按行组织的列表保存到文件
+//
+// 参数:
+//   - filePath: 要保存的文件路径
+//   - lines: 要保存的行，如IP/CIDR或域名
+//   - opts: 保存选项，默认不覆盖已存在的文件、不写说明、直接写入、不加密
+//
+// 返回:
+//   - error: 可能的错误:
+//   - ErrFileExists: 文件已存在且未允许覆盖
+//   - ErrFilePermission: 无权限写入文件
+//   - ErrInvalidKey: 加密密钥无效
+//   - 其他系统错误: 如路径不存在、I/O错误等
+//
+// 文件格式与SaveIPACLWithHeader相同，可以用ReadLines读取（加密时用ReadIPACLEncrypted），
+// 文件头中的元数据可以用ReadIPACLWithMetadata读取。
+// SaveIPACL、SaveIPACLWithHeader和SaveIPACLEncrypted都基于此函数实现。
+//
+// 示例:
+//
+//	// 原子地替换列表文件，并保留上一版本
+//	err := config.SaveLines("./blacklist.txt", ips,
+//	    config.WithHeader("IP Blacklist"),
+//	    config.WithBackup(),
+//	    config.WithAtomic(),
+//	)
+func SaveLines(filePath string, lines []string, opts ...SaveOption) error {
+	o := NewSaveOptions(opts...)
+
+	info, err := os.Stat(filePath)
+	exists := err == nil
+	if exists && !o.Overwrite {
+		return ErrFileExists
+	} else if err != nil && !os.IsNotExist(err) {
+		return err
+	}
+
+	if o.Sorted {
+		lines = canonicalSort(lines)
+	}
+	var original []byte
+	if exists && o.PreserveComments && o.Keys == nil {
+		if original, err = os.ReadFile(filePath); err != nil {
+			return permissionError(err)
+		}
+	}
+
+	var buf bytes.Buffer
+	if err := writeLines(&buf, lines, o, original); err != nil {
+		return err
+	}
+	data := buf.Bytes()
+	if o.Gzip {
+		if data, err = compress(data); err != nil {
+			return err
+		}
+	}
+	mode := os.FileMode(0o666)
+	if o.Keys != nil {
+		if data, err = encrypt(data, o.Keys); err != nil {
+			return err
+		}
+		mode = 0o600
+	}
+
+	if exists && o.Backup {
+		if err := backupFile(filePath, info.Mode().Perm()); err != nil {
+			return permissionError(err)
+		}
+	}
+	if o.Atomic {
+		// 临时文件的权限需要显式设置：沿用原文件的权限，新文件为0644
+		perm := os.FileMode(0o644)
+		if o.Keys != nil {
+			perm = mode
+		} else if exists {
+			perm = info.Mode().Perm()
+		}
+		return permissionError(writeAtomic(filePath, data, perm))
+	}
+	if err := os.WriteFile(filePath, data, mode); err != nil {
+		return permissionError(err)
+	}
+	if o.Keys != nil && exists {
+		// WriteFile不修改已存在文件的权限
+		return permissionError(os.Chmod(filePath, mode))
+	}
+	return nil
+}
+
+// backupFile 将文件复制为文件名加BackupSuffix的备份
+func backupFile(filePath string, mode os.FileMode) error {
+	data, err := os.ReadFile(filePath)
+	if err != nil {
+		return err
+	}
+	return writeAtomic(filePath+BackupSuffix, data, mode)
+}
+
+// writeAtomic 先写入同目录下的临时文件再重命名
+func writeAtomic(filePath string, data []byte, mode os.FileMode) error {
+	tmp, err := os.CreateTemp(filepath.Dir(filePath), "."+filepath.Base(filePath)+".tmp-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Sync(); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	if err := os.Chmod(tmp.Name(), mode); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), filePath)
+}
+
+// permissionError 将权限错误转换为ErrFilePermission
+func permissionError(err error) error {
+	if err != nil && os.IsPermission(err) {
+		return ErrFilePermission
+	}
+	return err
+}
+
+// ParseOptions 是ParseLines的选项，通常通过ParseOption函数设置
+//
+// 字段说明:
+//   - Gunzip: 内容以gzip标识开头时解压，见WithGunzip
+//   - MaxBytes: 最多读取的字节数，同时限制压缩数据和解压后的数据，0表示不限制
+type ParseOptions struct {
+	Gunzip   bool
+	MaxBytes int64
+}
+
+// ParseOption 修改ParseOptions中的一项设置
+type ParseOption func(*ParseOptions)
+
+// WithGunzip 按内容开头的gzip标识自动解压，ReadLines等读取本地文件的函数总是解压
+func WithGunzip() ParseOption {
+	return func(o *ParseOptions) { o.Gunzip = true }
+}
+
+// WithMaxBytes 设置最多读取的字节数，超过时返回ErrInputTooLarge
+//
+// 与WithGunzip同时使用时，解压前和解压后的数据分别受此限制。
+func WithMaxBytes(n int64) ParseOption {
+	return func(o *ParseOptions) { o.MaxBytes = n }
+}
+
+// cappedReader 在读到超过上限的数据时返回ErrInputTooLarge
+type cappedReader struct {
+	r         io.Reader
+	max       int64
+	remaining int64
+}
+
+// limitReader 用io.LimitReader多读一个字节，以区分恰好达到上限和超过上限
+func limitReader(r io.Reader, max int64) io.Reader {
+	return &cappedReader{r: io.LimitReader(r, max+1), max: max, remaining: max}
+}
+
+func (c *cappedReader) Read(p []byte) (int, error) {
+	n, err := c.r.Read(p)
+	c.remaining -= int64(n)
+	if c.remaining < 0 {
+		return n, fmt.Errorf("%w: 超过%d字节", ErrInputTooLarge, c.max)
+	}
+	return n, err
+}
@@ -0,0 +1,105 @@
+package config
+
+import (
+	"bytes"
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// TestNewSaveOptions 测试选项的组合顺序
+func TestNewSaveOptions(t *testing.T) {
+	keys := StaticKey(bytes.Repeat([]byte{0x42}, 16))
+	tests := []struct {
+		name string
+		opts []SaveOption
+		want SaveOptions
+	}{
+		{"默认", nil, SaveOptions{}},
+		{"备份隐含覆盖", []SaveOption{WithBackup()}, SaveOptions{Overwrite: true, Backup: true}},
+		{"后面的选项覆盖前面的", []SaveOption{WithHeader("a"), WithOverwrite(true), WithHeader("b"), WithOverwrite(false)}, SaveOptions{Header: "b"}},
+		{"整体设置", []SaveOption{WithAtomic(), WithSaveOptions(SaveOptions{Keys: keys}), nil}, SaveOptions{Keys: keys}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := NewSaveOptions(tt.opts...); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("NewSaveOptions() = %+v, 期望 %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+// TestSaveLines 测试按选项保存列表文件
+func TestSaveLines(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "list.txt")
+
+	if err := SaveLines(path, []string{"192.0.2.1"}, WithHeader("第一版")); err != nil {
+		t.Fatalf("SaveLines() 返回错误: %v", err)
+	}
+	if err := SaveLines(path, []string{"192.0.2.2"}); !errors.Is(err, ErrFileExists) {
+		t.Errorf("未允许覆盖时 SaveLines() = %v, 期望 ErrFileExists", err)
+	}
+
+	// 备份原文件并原子地替换
+	if err := SaveLines(path, []string{"192.0.2.2"}, WithBackup(), WithAtomic()); err != nil {
+		t.Fatalf("SaveLines() 返回错误: %v", err)
+	}
+	if lines, err := ReadLines(path); err != nil || !reflect.DeepEqual(lines, []string{"192.0.2.2"}) {
+		t.Errorf("ReadLines() = %v, %v; 期望新内容", lines, err)
+	}
+	backup, err := os.ReadFile(path + BackupSuffix)
+	if err != nil {
+		t.Fatalf("读取备份失败: %v", err)
+	}
+	if !strings.HasPrefix(string(backup), "# 第一版\n") || !strings.Contains(string(backup), "192.0.2.1") {
+		t.Errorf("备份内容 = %q, 期望为第一版", backup)
+	}
+	entries, _ := os.ReadDir(dir)
+	if len(entries) != 2 {
+		t.Errorf("目录中有%d个文件, 期望只有列表和备份（临时文件已删除）", len(entries))
+	}
+
+	// 没有原文件时不生成备份
+	fresh := filepath.Join(dir, "fresh.txt")
+	if err := SaveLines(fresh, []string{"10.0.0.0/8"}, WithBackup()); err != nil {
+		t.Fatalf("SaveLines() 返回错误: %v", err)
+	}
+	if _, err := os.Stat(fresh + BackupSuffix); !os.IsNotExist(err) {
+		t.Error("没有原文件时不应生成备份")
+	}
+}
+
+// TestSaveLinesEncrypted 测试加密选项与原子写入的组合
+func TestSaveLinesEncrypted(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "targets.enc")
+	key := StaticKey(bytes.Repeat([]byte{0x42}, 32))
+	ips := []string{"192.0.2.1", "2001:db8::/32"}
+
+	if err := os.WriteFile(path, []byte("旧内容"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	for _, opts := range [][]SaveOption{
+		{WithOverwrite(true), WithEncryption(key)},
+		{WithOverwrite(true), WithEncryption(key), WithAtomic()},
+	} {
+		if err := SaveLines(path, ips, opts...); err != nil {
+			t.Fatalf("SaveLines() 返回错误: %v", err)
+		}
+		if info, _ := os.Stat(path); info.Mode().Perm() != 0o600 {
+			t.Errorf("文件权限 = %v, 期望 0600", info.Mode().Perm())
+		}
+		got, err := ReadIPACLEncrypted(path, key)
+		if err != nil || !reflect.DeepEqual(got, ips) {
+			t.Errorf("ReadIPACLEncrypted() = %v, %v; 期望 %v", got, err, ips)
+		}
+	}
+
+	if err := SaveLines(path, ips, WithOverwrite(true), WithEncryption(StaticKey("short"))); !errors.Is(err, ErrInvalidKey) {
+		t.Errorf("无效密钥 SaveLines() = %v, 期望 ErrInvalidKey", err)
+	}
+}
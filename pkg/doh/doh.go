@@ -0,0 +1,243 @@
+// Package doh 提供一个由域名ACL控制的DNS-over-HTTPS（RFC 8484）转发器
+//
+// Forwarder对每个DNS查询的域名执行访问控制，被拒绝的查询直接返回NXDOMAIN（或REFUSED），
+// 允许的查询原样转发给上游解析器。应用内使用的域名列表因此可以在整个网络范围内生效，
+// 例如让浏览器、操作系统或内网DNS服务器把它作为DoH上游。
+//
+// 用法示例:
+//
+//	manager := acl.NewManager()
+//	manager.SetDomainACL([]string{"ads.example.com", "tracker.example.net"}, types.Blacklist, true)
+//
+//	forwarder := doh.New(manager, doh.HTTPUpstream{URL: "https://dns.example/dns-query"})
+//	http.Handle("/dns-query", forwarder)
+//	log.Fatal(http.ListenAndServeTLS(":443", "cert.pem", "key.pem", nil))
+package doh
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+
+	"github.com/cyberspacesec/go-acl/pkg/acl"
+	"github.com/cyberspacesec/go-acl/pkg/types"
+)
+
+// 错误定义
+var (
+	// ErrInvalidMessage 表示DNS消息格式无效，或包含的问题数量不是1
+	ErrInvalidMessage = errors.New("无效的DNS消息")
+	// ErrBlocked 表示查询的域名被访问控制拒绝
+	ErrBlocked = errors.New("DNS查询被访问控制拒绝")
+)
+
+// ContentType 是RFC 8484规定的DNS消息媒体类型
+const ContentType = "application/dns-message"
+
+// maxMessageSize 是DNS消息的最大长度
+const maxMessageSize = 65535
+
+// DNS响应码
+const (
+	// RcodeFormatError 表示查询格式错误（FORMERR）
+	RcodeFormatError = 1
+	// RcodeServerFailure 表示服务器失败（SERVFAIL）
+	RcodeServerFailure = 2
+	// RcodeNameError 表示域名不存在（NXDOMAIN）
+	RcodeNameError = 3
+	// RcodeRefused 表示拒绝查询（REFUSED）
+	RcodeRefused = 5
+)
+
+// Upstream 是转发查询的上游解析器
+type Upstream interface {
+	// Exchange 发送DNS查询消息，返回响应消息
+	Exchange(ctx context.Context, query []byte) ([]byte, error)
+}
+
+// HTTPUpstream 是DoH上游解析器，以POST方式发送查询
+//
+// 字段说明:
+//   - URL: DoH地址，如"https://cloudflare-dns.com/dns-query"
+//   - Client: 使用的HTTP客户端，nil表示http.DefaultClient
+type HTTPUpstream struct {
+	URL    string
+	Client *http.Client
+}
+
+// Exchange 向上游发送查询，非2xx的响应视为失败
+func (u HTTPUpstream) Exchange(ctx context.Context, query []byte) ([]byte, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, u.URL, bytes.NewReader(query))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", ContentType)
+	req.Header.Set("Accept", ContentType)
+	client := u.Client
+	if client == nil {
+		client = http.DefaultClient
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return nil, fmt.Errorf("上游 %s 返回 %s", u.URL, resp.Status)
+	}
+	return io.ReadAll(io.LimitReader(resp.Body, maxMessageSize))
+}
+
+// String 返回DoH地址
+func (u HTTPUpstream) String() string {
+	return u.URL
+}
+
+// Forwarder 是执行域名访问控制的DoH转发器，实现了http.Handler
+//
+// 支持RFC 8484的GET（dns参数为base64url编码的消息）和POST（请求体为消息）两种方式。
+// 每个查询的域名通过Manager.CheckDomainDetailed检查:
+//   - 允许: 查询原样转发给Upstream，响应原样返回；上游失败时返回SERVFAIL
+//   - 拒绝: 不访问上游，返回BlockRcode响应码
+//
+// 与guard相同，未设置域名ACL（types.ErrNoACL）时转发所有查询，其他检查错误视为拒绝。
+// 根域名（"."）的查询不检查。问题数量不是1的消息返回FORMERR。
+type Forwarder struct {
+	// Manager 是执行访问控制的ACL管理器
+	Manager *acl.Manager
+	// Upstream 是转发查询的上游解析器
+	Upstream Upstream
+	// BlockRcode 是被拒绝的查询的响应码，0表示RcodeNameError（NXDOMAIN）
+	BlockRcode int
+	// OnBlock 在查询被拒绝时调用，可用于记录日志，可为nil；
+	// types.ReasonOf(err)返回拒绝原因
+	OnBlock func(r *http.Request, name string, err error)
+}
+
+// New 创建一个转发到指定上游的DoH转发器
+//
+// 参数:
+//   - manager: 执行访问控制的ACL管理器
+//   - upstream: 上游解析器，如HTTPUpstream
+//
+// 返回:
+//   - *Forwarder: 可直接作为http.Handler使用的转发器
+func New(manager *acl.Manager, upstream Upstream) *Forwarder {
+	return &Forwarder{Manager: manager, Upstream: upstream}
+}
+
+// ServeHTTP 处理DoH请求
+func (f *Forwarder) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	query, err := readQuery(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	resp, err := f.Resolve(r.Context(), query)
+	if errors.Is(err, ErrBlocked) && f.OnBlock != nil {
+		name, _ := QuestionName(query)
+		f.OnBlock(r, name, err)
+	}
+	if resp == nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	w.Header().Set("Content-Type", ContentType)
+	w.Write(resp)
+}
+
+// Resolve 检查并处理一个DNS查询消息
+//
+// 参数:
+//   - ctx: 上下文，传递给ACL检查和上游
+//   - query: DNS查询消息
+//
+// 返回:
+//   - []byte: 响应消息；消息短于DNS头部时为nil
+//   - error: 查询被拒绝时为包装了ErrBlocked的*types.ReasonError，此时响应为BlockRcode；
+//     消息格式无效时包装ErrInvalidMessage，响应为FORMERR；上游失败时为上游的错误，响应为SERVFAIL
+//
+// 不经过HTTP使用转发器时（如自行实现的UDP服务）可以直接调用此方法。
+func (f *Forwarder) Resolve(ctx context.Context, query []byte) ([]byte, error) {
+	if len(query) < headerSize {
+		return nil, fmt.Errorf("%w: 消息长度为%d字节", ErrInvalidMessage, len(query))
+	}
+	name, err := QuestionName(query)
+	if err != nil {
+		return errorResponse(query, RcodeFormatError), err
+	}
+
+	if name != "" {
+		if err := f.check(ctx, name); err != nil {
+			return errorResponse(query, f.blockRcode()), err
+		}
+	}
+
+	resp, err := f.Upstream.Exchange(ctx, query)
+	if err != nil {
+		return errorResponse(query, RcodeServerFailure), err
+	}
+	return resp, nil
+}
+
+// check 检查查询的域名，未设置的ACL视为允许
+func (f *Forwarder) check(ctx context.Context, name string) error {
+	result, err := f.Manager.CheckDomainDetailed(ctx, name)
+	if err != nil {
+		if errors.Is(err, types.ErrNoACL) {
+			return nil
+		}
+		return &types.ReasonError{Reason: result.Reason, Err: fmt.Errorf("%w: %s: %v", ErrBlocked, name, err)}
+	}
+	if result.Decision == types.Denied {
+		return &types.ReasonError{Reason: result.Reason, Err: fmt.Errorf("%w: %s", ErrBlocked, name)}
+	}
+	return nil
+}
+
+// blockRcode 返回被拒绝的查询的响应码
+func (f *Forwarder) blockRcode() int {
+	if f.BlockRcode == 0 {
+		return RcodeNameError
+	}
+	return f.BlockRcode
+}
+
+// readQuery 按RFC 8484读取请求中的DNS消息
+func readQuery(r *http.Request) ([]byte, error) {
+	switch r.Method {
+	case http.MethodGet:
+		param := r.URL.Query().Get("dns")
+		if param == "" {
+			return nil, fmt.Errorf("%w: 缺少dns参数", ErrInvalidMessage)
+		}
+		// RFC 8484要求不带填充，兼容带填充的客户端
+		query, err := base64.RawURLEncoding.DecodeString(strings.TrimRight(param, "="))
+		if err != nil {
+			return nil, fmt.Errorf("%w: %v", ErrInvalidMessage, err)
+		}
+		return query, nil
+	case http.MethodPost:
+		if ct := r.Header.Get("Content-Type"); ct != ContentType {
+			return nil, fmt.Errorf("%w: 不支持的Content-Type %q", ErrInvalidMessage, ct)
+		}
+		query, err := io.ReadAll(io.LimitReader(r.Body, maxMessageSize+1))
+		if err != nil {
+			return nil, err
+		}
+		if len(query) > maxMessageSize {
+			return nil, fmt.Errorf("%w: 消息过长", ErrInvalidMessage)
+		}
+		return query, nil
+	default:
+		return nil, fmt.Errorf("%w: 不支持的方法 %s", ErrInvalidMessage, r.Method)
+	}
+}
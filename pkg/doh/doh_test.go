@@ -0,0 +1,182 @@
+package doh
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/cyberspacesec/go-acl/pkg/acl"
+	"github.com/cyberspacesec/go-acl/pkg/types"
+)
+
+// recordUpstream 记录收到的查询，返回固定的响应
+type recordUpstream struct {
+	queries []string
+	err     error
+}
+
+func (u *recordUpstream) Exchange(ctx context.Context, query []byte) ([]byte, error) {
+	name, _ := QuestionName(query)
+	u.queries = append(u.queries, name)
+	if u.err != nil {
+		return nil, u.err
+	}
+	resp := append([]byte(nil), query...)
+	resp[2] |= 0x80
+	return resp, nil
+}
+
+func newTestForwarder(t *testing.T) (*Forwarder, *recordUpstream) {
+	t.Helper()
+	manager := acl.NewManager()
+	manager.SetDomainACL([]string{"ads.example.com"}, types.Blacklist, true)
+	upstream := &recordUpstream{}
+	return New(manager, upstream), upstream
+}
+
+// TestResolve 测试查询的检查和转发
+func TestResolve(t *testing.T) {
+	tests := []struct {
+		name      string
+		query     []byte
+		blockCode int
+		wantRcode byte
+		wantErr   error
+		forwarded bool
+	}{
+		{"允许的域名被转发", newQuery(1, "www.example.com", 1), 0, 0, nil, true},
+		{"拒绝的子域名返回NXDOMAIN", newQuery(2, "x.ADS.example.com.", 28), 0, RcodeNameError, ErrBlocked, false},
+		{"拒绝时返回指定的响应码", newQuery(3, "ads.example.com", 1), RcodeRefused, RcodeRefused, ErrBlocked, false},
+		{"根域名不检查", newQuery(4, ".", 2), 0, 0, nil, true},
+		{"格式错误", newQuery(5, "example.com", 1)[:headerSize+3], 0, RcodeFormatError, ErrInvalidMessage, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f, upstream := newTestForwarder(t)
+			f.BlockRcode = tt.blockCode
+			resp, err := f.Resolve(context.Background(), tt.query)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("Resolve() 错误 = %v, 期望 %v", err, tt.wantErr)
+			}
+			if resp[3]&0x0f != tt.wantRcode {
+				t.Errorf("响应码 = %d, 期望 %d", resp[3]&0x0f, tt.wantRcode)
+			}
+			if (len(upstream.queries) == 1) != tt.forwarded {
+				t.Errorf("转发的查询 = %v, 期望转发: %v", upstream.queries, tt.forwarded)
+			}
+		})
+	}
+
+	f, _ := newTestForwarder(t)
+	if _, err := f.Resolve(context.Background(), newQuery(1, "ads.example.com", 1)); types.ReasonOf(err) != types.ReasonMatchedBlacklistDomain {
+		t.Errorf("拒绝原因 = %q, 期望 %q", types.ReasonOf(err), types.ReasonMatchedBlacklistDomain)
+	}
+
+	f.Upstream = &recordUpstream{err: errors.New("连接失败")}
+	if resp, err := f.Resolve(context.Background(), newQuery(1, "example.org", 1)); err == nil || resp[3]&0x0f != RcodeServerFailure {
+		t.Errorf("上游失败时 Resolve() = %v, %v, 期望SERVFAIL", resp, err)
+	}
+
+	noACL := New(acl.NewManager(), &recordUpstream{})
+	if _, err := noACL.Resolve(context.Background(), newQuery(1, "ads.example.com", 1)); err != nil {
+		t.Errorf("未设置域名ACL时 Resolve() 返回错误: %v", err)
+	}
+}
+
+// TestServeHTTP 测试RFC 8484的GET和POST请求
+func TestServeHTTP(t *testing.T) {
+	f, upstream := newTestForwarder(t)
+	var blocked []string
+	f.OnBlock = func(r *http.Request, name string, err error) { blocked = append(blocked, name) }
+	server := httptest.NewServer(f)
+	defer server.Close()
+
+	get := func(query []byte) *http.Response {
+		resp, err := http.Get(server.URL + "?dns=" + base64.RawURLEncoding.EncodeToString(query))
+		if err != nil {
+			t.Fatal(err)
+		}
+		return resp
+	}
+	post := func(query []byte, contentType string) *http.Response {
+		resp, err := http.Post(server.URL, contentType, bytes.NewReader(query))
+		if err != nil {
+			t.Fatal(err)
+		}
+		return resp
+	}
+
+	tests := []struct {
+		name       string
+		resp       *http.Response
+		wantStatus int
+		wantRcode  int
+	}{
+		{"GET允许", get(newQuery(1, "example.com", 1)), http.StatusOK, 0},
+		{"GET拒绝", get(newQuery(2, "ads.example.com", 1)), http.StatusOK, RcodeNameError},
+		{"POST允许", post(newQuery(3, "example.org", 1), ContentType), http.StatusOK, 0},
+		{"POST拒绝", post(newQuery(4, "a.ads.example.com", 1), ContentType), http.StatusOK, RcodeNameError},
+		{"POST的Content-Type错误", post(newQuery(5, "example.org", 1), "text/plain"), http.StatusBadRequest, -1},
+		{"GET缺少参数", func() *http.Response { r, _ := http.Get(server.URL); return r }(), http.StatusBadRequest, -1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			defer tt.resp.Body.Close()
+			if tt.resp.StatusCode != tt.wantStatus {
+				t.Fatalf("状态码 = %d, 期望 %d", tt.resp.StatusCode, tt.wantStatus)
+			}
+			if tt.wantRcode < 0 {
+				return
+			}
+			if ct := tt.resp.Header.Get("Content-Type"); ct != ContentType {
+				t.Errorf("Content-Type = %q, 期望 %q", ct, ContentType)
+			}
+			body, _ := io.ReadAll(tt.resp.Body)
+			if len(body) < headerSize || int(body[3]&0x0f) != tt.wantRcode {
+				t.Errorf("响应 = %v, 期望响应码 %d", body, tt.wantRcode)
+			}
+		})
+	}
+
+	if len(upstream.queries) != 2 {
+		t.Errorf("转发的查询 = %v, 期望 2个", upstream.queries)
+	}
+	if len(blocked) != 2 || blocked[1] != "a.ads.example.com" {
+		t.Errorf("OnBlock 记录 = %v", blocked)
+	}
+}
+
+// TestHTTPUpstream 测试以POST方式向DoH上游发送查询
+func TestHTTPUpstream(t *testing.T) {
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/" {
+			http.NotFound(w, r)
+			return
+		}
+		if r.Method != http.MethodPost || r.Header.Get("Content-Type") != ContentType {
+			http.Error(w, "bad request", http.StatusBadRequest)
+			return
+		}
+		query, _ := io.ReadAll(r.Body)
+		w.Header().Set("Content-Type", ContentType)
+		w.Write(errorResponse(query, 0))
+	}))
+	defer upstream.Close()
+
+	resp, err := HTTPUpstream{URL: upstream.URL}.Exchange(context.Background(), newQuery(9, "example.com", 1))
+	if err != nil {
+		t.Fatalf("Exchange() 返回错误: %v", err)
+	}
+	if name, _ := QuestionName(resp); name != "example.com" || resp[2]&0x80 == 0 {
+		t.Errorf("Exchange() 响应 = %v", resp)
+	}
+
+	if _, err := (HTTPUpstream{URL: upstream.URL + "/missing"}).Exchange(context.Background(), newQuery(9, "example.com", 1)); err == nil {
+		t.Error("上游返回错误状态码时 Exchange() 应返回错误")
+	}
+}
@@ -0,0 +1,91 @@
+package doh
+
+import (
+	"encoding/binary"
+	"fmt"
+	"strings"
+)
+
+// headerSize 是DNS消息头部的长度
+const headerSize = 12
+
+// QuestionName 返回DNS查询消息中问题的域名
+//
+// 参数:
+//   - msg: DNS消息
+//
+// 返回:
+//   - string: 不含末尾点的域名，根域名为空字符串
+//   - error: 消息格式无效、问题数量不是1或域名使用了压缩指针时返回包装了ErrInvalidMessage的错误
+func QuestionName(msg []byte) (string, error) {
+	name, _, err := parseQuestion(msg)
+	return name, err
+}
+
+// parseQuestion 解析唯一的问题，返回域名和问题部分结束的位置
+func parseQuestion(msg []byte) (string, int, error) {
+	if len(msg) < headerSize {
+		return "", 0, fmt.Errorf("%w: 消息长度为%d字节", ErrInvalidMessage, len(msg))
+	}
+	if qd := binary.BigEndian.Uint16(msg[4:]); qd != 1 {
+		return "", 0, fmt.Errorf("%w: 问题数量为%d", ErrInvalidMessage, qd)
+	}
+
+	var labels []string
+	off, size := headerSize, 0
+	for {
+		if off >= len(msg) {
+			return "", 0, fmt.Errorf("%w: 域名被截断", ErrInvalidMessage)
+		}
+		n := int(msg[off])
+		off++
+		if n == 0 {
+			break
+		}
+		// 查询的问题部分没有可以引用的内容，不需要支持压缩指针
+		if n > 63 {
+			return "", 0, fmt.Errorf("%w: 无效的标签长度%d", ErrInvalidMessage, n)
+		}
+		if off+n > len(msg) {
+			return "", 0, fmt.Errorf("%w: 域名被截断", ErrInvalidMessage)
+		}
+		label := string(msg[off : off+n])
+		// 含点的标签拼接后会变成另一个域名，用于绕过检查
+		if strings.ContainsRune(label, '.') {
+			return "", 0, fmt.Errorf("%w: 标签包含点", ErrInvalidMessage)
+		}
+		if size += n + 1; size > 254 {
+			return "", 0, fmt.Errorf("%w: 域名过长", ErrInvalidMessage)
+		}
+		labels = append(labels, label)
+		off += n
+	}
+
+	// QTYPE和QCLASS
+	if off+4 > len(msg) {
+		return "", 0, fmt.Errorf("%w: 问题被截断", ErrInvalidMessage)
+	}
+	return strings.Join(labels, "."), off + 4, nil
+}
+
+// errorResponse 构造只包含响应码的响应，问题部分有效时原样带上问题
+func errorResponse(query []byte, rcode int) []byte {
+	qdCount, end := uint16(1), headerSize
+	if _, e, err := parseQuestion(query); err == nil {
+		end = e
+	} else {
+		qdCount = 0
+	}
+
+	resp := make([]byte, end)
+	copy(resp, query[:end])
+	// QR=1，保留Opcode和RD，其余标志清零
+	resp[2] = 0x80 | query[2]&0x79
+	// RA=1，设置响应码
+	resp[3] = 0x80 | byte(rcode&0x0f)
+	binary.BigEndian.PutUint16(resp[4:], qdCount)
+	binary.BigEndian.PutUint16(resp[6:], 0)
+	binary.BigEndian.PutUint16(resp[8:], 0)
+	binary.BigEndian.PutUint16(resp[10:], 0)
+	return resp
+}
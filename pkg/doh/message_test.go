@@ -0,0 +1,90 @@
+package doh
+
+import (
+	"encoding/binary"
+	"errors"
+	"strings"
+	"testing"
+)
+
+// newQuery 构造一个只有一个问题的DNS查询消息
+func newQuery(id uint16, name string, qtype uint16) []byte {
+	msg := make([]byte, headerSize)
+	binary.BigEndian.PutUint16(msg, id)
+	msg[2] = 0x01 // RD
+	binary.BigEndian.PutUint16(msg[4:], 1)
+	for _, label := range strings.Split(strings.TrimSuffix(name, "."), ".") {
+		if label == "" {
+			continue
+		}
+		msg = append(msg, byte(len(label)))
+		msg = append(msg, label...)
+	}
+	msg = append(msg, 0, byte(qtype>>8), byte(qtype), 0, 1)
+	return msg
+}
+
+// TestQuestionName 测试问题域名的解析
+func TestQuestionName(t *testing.T) {
+	twoQuestions := newQuery(1, "example.com", 1)
+	binary.BigEndian.PutUint16(twoQuestions[4:], 2)
+	dotted := newQuery(1, "x", 1)
+	dotted[headerSize+1] = '.'
+	pointer := append(newQuery(1, "", 1)[:headerSize], 0xc0, 0x0c, 0, 1, 0, 1)
+
+	tests := []struct {
+		name    string
+		msg     []byte
+		want    string
+		wantErr bool
+	}{
+		{"普通域名", newQuery(1, "www.Example.com.", 1), "www.Example.com", false},
+		{"根域名", newQuery(1, ".", 2), "", false},
+		{"消息过短", []byte{0, 1, 2}, "", true},
+		{"两个问题", twoQuestions, "", true},
+		{"域名被截断", newQuery(1, "example.com", 1)[:headerSize+5], "", true},
+		{"缺少类型", newQuery(1, "example.com", 1)[:headerSize+13], "", true},
+		{"标签包含点", dotted, "", true},
+		{"压缩指针", pointer, "", true},
+		{"域名过长", newQuery(1, strings.Repeat(strings.Repeat("a", 63)+".", 4), 1), "", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := QuestionName(tt.msg)
+			if tt.wantErr {
+				if !errors.Is(err, ErrInvalidMessage) {
+					t.Errorf("QuestionName() 错误 = %v, 期望 ErrInvalidMessage", err)
+				}
+				return
+			}
+			if err != nil || got != tt.want {
+				t.Errorf("QuestionName() = %q, %v, 期望 %q", got, err, tt.want)
+			}
+		})
+	}
+}
+
+// TestErrorResponse 测试错误响应的头部和问题部分
+func TestErrorResponse(t *testing.T) {
+	query := append(newQuery(0xbeef, "ads.example.com", 1), 0, 0, 41) // 附加部分被丢弃
+	binary.BigEndian.PutUint16(query[10:], 1)
+
+	resp := errorResponse(query, RcodeNameError)
+	if id := binary.BigEndian.Uint16(resp); id != 0xbeef {
+		t.Errorf("ID = %#x, 期望 0xbeef", id)
+	}
+	if resp[2] != 0x81 || resp[3] != 0x80|RcodeNameError {
+		t.Errorf("标志 = %#x %#x, 期望 0x81 0x83", resp[2], resp[3])
+	}
+	if qd, ar := binary.BigEndian.Uint16(resp[4:]), binary.BigEndian.Uint16(resp[10:]); qd != 1 || ar != 0 {
+		t.Errorf("QDCOUNT = %d, ARCOUNT = %d, 期望 1, 0", qd, ar)
+	}
+	if name, err := QuestionName(resp); err != nil || name != "ads.example.com" {
+		t.Errorf("响应的问题 = %q, %v", name, err)
+	}
+
+	formErr := errorResponse(newQuery(7, "x", 1)[:headerSize+1], RcodeFormatError)
+	if len(formErr) != headerSize || binary.BigEndian.Uint16(formErr[4:]) != 0 || formErr[3]&0x0f != RcodeFormatError {
+		t.Errorf("格式错误的响应 = %v, 期望只有头部且响应码为FORMERR", formErr)
+	}
+}
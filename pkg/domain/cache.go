@@ -0,0 +1,120 @@
+package domain
+
+import "sync"
+
+// negativeCache 记录最近未匹配列表的域名（已标准化）
+//
+// 容量固定，写满后按先进先出淘汰最早的条目。
+// Check可能在多个goroutine中并发调用，因此缓存使用独立的互斥锁。
+type negativeCache struct {
+	mu      sync.Mutex
+	entries map[string]struct{}
+	order   []string
+	next    int
+	hits    uint64
+}
+
+// NegativeCacheStats 否定缓存的统计信息
+//
+// 字段说明:
+//   - Size: 缓存容量
+//   - Entries: 当前缓存的域名数量
+//   - Hits: 命中缓存（跳过列表扫描）的次数
+type NegativeCacheStats struct {
+	Size    int
+	Entries int
+	Hits    uint64
+}
+
+// newNegativeCache 创建指定容量的否定缓存
+func newNegativeCache(size int) *negativeCache {
+	return &negativeCache{
+		entries: make(map[string]struct{}, size),
+		order:   make([]string, size),
+	}
+}
+
+// contains 判断域名是否在缓存中
+func (c *negativeCache) contains(domain string) bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	_, ok := c.entries[domain]
+	if ok {
+		c.hits++
+	}
+	return ok
+}
+
+// add 将未匹配的域名加入缓存，缓存已满时淘汰最早加入的域名
+func (c *negativeCache) add(domain string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if _, ok := c.entries[domain]; ok {
+		return
+	}
+	if old := c.order[c.next]; old != "" {
+		delete(c.entries, old)
+	}
+	c.order[c.next] = domain
+	c.entries[domain] = struct{}{}
+	c.next = (c.next + 1) % len(c.order)
+}
+
+// clear 清空缓存
+func (c *negativeCache) clear() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.entries = make(map[string]struct{}, len(c.order))
+	for i := range c.order {
+		c.order[i] = ""
+	}
+	c.next = 0
+}
+
+// EnableNegativeCache 启用或关闭未匹配结果的缓存
+//
+// 参数:
+//   - size: 缓存容量（域名数量），小于等于0表示关闭缓存
+//
+// 高流量的服务常常反复检查相同的、不在列表中的域名，每次都要扫描整个列表。
+// 启用后，未匹配列表的域名（按标准化后的形式）会被缓存，再次检查时跳过扫描。
+// 调用Add或Remove修改列表时缓存会被清空，因此缓存不会改变检查结果。
+//
+// 注意: 此方法与Add、Remove一样会修改列表状态，不能与Check并发调用。
+//
+// 示例:
+//
+//	acl := domain.NewDomainACL(blocklist, types.Blacklist, true)
+//	acl.EnableNegativeCache(1024)
+func (d *DomainACL) EnableNegativeCache(size int) {
+	if size <= 0 {
+		d.negCache = nil
+		return
+	}
+	d.negCache = newNegativeCache(size)
+}
+
+// NegativeCacheStats 返回否定缓存的统计信息
+//
+// 返回:
+//   - NegativeCacheStats: 缓存统计，未启用缓存时为零值
+func (d *DomainACL) NegativeCacheStats() NegativeCacheStats {
+	c := d.negCache
+	if c == nil {
+		return NegativeCacheStats{}
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return NegativeCacheStats{Size: len(c.order), Entries: len(c.entries), Hits: c.hits}
+}
+
+// invalidateCache 在列表被修改后清空否定缓存
+func (d *DomainACL) invalidateCache() {
+	if d.negCache != nil {
+		d.negCache.clear()
+	}
+}
@@ -0,0 +1,86 @@
+package domain
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/cyberspacesec/go-acl/pkg/types"
+)
+
+// TestNegativeCache 测试未匹配结果的缓存
+func TestNegativeCache(t *testing.T) {
+	acl := NewDomainACL([]string{"example.com"}, types.Blacklist, true)
+	acl.EnableNegativeCache(2)
+
+	check := func(domain string, want types.Permission) {
+		t.Helper()
+		got, err := acl.Check(domain)
+		if err != nil {
+			t.Fatalf("Check(%s) 返回错误: %v", domain, err)
+		}
+		if got != want {
+			t.Errorf("Check(%s) = %v, 期望 %v", domain, got, want)
+		}
+	}
+
+	// 第一次检查写入缓存，第二次命中
+	check("other.com", types.Allowed)
+	check("OTHER.com", types.Allowed)
+	if stats := acl.NegativeCacheStats(); stats.Hits != 1 || stats.Entries != 1 || stats.Size != 2 {
+		t.Errorf("NegativeCacheStats() = %+v", stats)
+	}
+
+	// 匹配的域名不会被缓存
+	check("api.example.com", types.Denied)
+	if stats := acl.NegativeCacheStats(); stats.Entries != 1 {
+		t.Errorf("匹配的域名不应进入缓存: %+v", stats)
+	}
+
+	// 容量满后淘汰最早的条目
+	check("a.net", types.Allowed)
+	check("b.net", types.Allowed)
+	if stats := acl.NegativeCacheStats(); stats.Entries != 2 {
+		t.Errorf("缓存条目数 = %d, 期望 2", stats.Entries)
+	}
+
+	// 修改列表后缓存失效，新规则立即生效
+	acl.Add("b.net")
+	if stats := acl.NegativeCacheStats(); stats.Entries != 0 {
+		t.Errorf("Add() 后缓存应被清空: %+v", stats)
+	}
+	check("b.net", types.Denied)
+
+	check("c.net", types.Allowed)
+	if err := acl.Remove("b.net"); err != nil {
+		t.Fatalf("Remove() 返回错误: %v", err)
+	}
+	if stats := acl.NegativeCacheStats(); stats.Entries != 0 {
+		t.Errorf("Remove() 后缓存应被清空: %+v", stats)
+	}
+
+	// 关闭缓存
+	acl.EnableNegativeCache(0)
+	if stats := acl.NegativeCacheStats(); stats != (NegativeCacheStats{}) {
+		t.Errorf("关闭后 NegativeCacheStats() = %+v", stats)
+	}
+	check("other.com", types.Allowed)
+}
+
+// BenchmarkCheckNegativeCache 比较启用否定缓存前后反复检查未列出域名的耗时
+func BenchmarkCheckNegativeCache(b *testing.B) {
+	domains := make([]string, 10000)
+	for i := range domains {
+		domains[i] = fmt.Sprintf("blocked%d.example", i)
+	}
+
+	for _, size := range []int{0, 1024} {
+		b.Run(fmt.Sprintf("cache=%d", size), func(b *testing.B) {
+			acl := NewDomainACL(domains, types.Blacklist, true)
+			acl.EnableNegativeCache(size)
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				acl.Check("www.unlisted.com")
+			}
+		})
+	}
+}
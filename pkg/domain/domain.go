@@ -2,7 +2,10 @@ package domain
 
 import (
 	"errors"
+	"fmt"
+	"regexp"
 	"strings"
+	"unsafe"
 
 	"github.com/cyberspacesec/go-acl/pkg/types"
 )
@@ -13,6 +16,8 @@ var (
 	ErrDomainNotFound = errors.New("域名不在列表中")
 	// ErrInvalidDomain 表示提供的域名格式无效
 	ErrInvalidDomain = errors.New("无效的域名格式")
+	// ErrInvalidHostname 表示主机部分不是语法上有效的DNS名称或IP地址，见ValidateHostname
+	ErrInvalidHostname = errors.New("无效的主机名")
 )
 
 // DomainACL 实现了域名访问控制
@@ -40,8 +45,23 @@ type DomainACL struct {
 	listType types.ListType
 	// includeSubdomains 标识是否检查子域名
 	includeSubdomains bool
+	// exceptions 存储从列表中排除的例外域名
+	exceptions []string
+	// policies 存储附加在域名树节点上的策略，最具体的匹配优先
+	policies map[string]types.Permission
+	// negCache 缓存未匹配列表的域名，为nil表示未启用
+	negCache *negativeCache
+	// regexes 是"regex:"规则编译后的匹配器，键为正则表达式
+	regexes map[string]*regexp.Regexp
+	// matchAny 表示列表匹配所有域名，由SetMatchAnyDomain显式启用，命中时不再查询引擎或逐条比较
+	matchAny bool
+	// engine 是匹配规则使用的引擎，为nil时逐条比较domains，见SetMatchEngine
+	engine MatchEngine
 }
 
+// 确保*DomainACL实现types.ACL
+var _ types.ACL = (*DomainACL)(nil)
+
 // NewDomainACL 创建一个新的域名访问控制列表
 //
 // 参数:
@@ -103,13 +123,19 @@ func NewDomainACL(domains []string, listType types.ListType, includeSubdomains b
 //   - 移除端口号和路径
 //   - 转换为小写
 //
-// 空域名或重复域名会被忽略，不会导致错误。
+// 规则可以带有匹配方式前缀（"exact:"、"suffix:"、"regex:"），语义见ParseRule，
+// 前缀之后的域名同样会被标准化。
+//
+// 空域名、重复域名或无效的规则（如无法编译的正则表达式）会被忽略，不会导致错误。
 //
 // 示例:
 //
 //	// 添加单个域名
 //	acl.Add("example.com")
 //
+//	// 在同一个列表中混用不同的匹配方式
+//	acl.Add("exact:login.example.com", "suffix:.cdn.net", `regex:^a[0-9]+\.b\.com$`)
+//
 //	// 添加多个域名，包含各种格式
 //	acl.Add(
 //	    "https://www.domain.org",  // 会被标准化为 "domain.org"
@@ -117,25 +143,65 @@ func NewDomainACL(domains []string, listType types.ListType, includeSubdomains b
 //	    "blog.site.com:8080/path", // 会被标准化为 "blog.site.com"
 //	)
 func (d *DomainACL) Add(domains ...string) {
+	existing := make(map[string]struct{}, len(d.domains)+len(domains))
+	for _, domain := range d.domains {
+		existing[domain] = struct{}{}
+	}
+
 	for _, domain := range domains {
-		normalizedDomain := normalizeDomain(domain)
-		if normalizedDomain == "" {
+		rule, err := ParseRule(domain)
+		if err != nil {
 			continue
 		}
+		normalizedDomain := rule.String()
 
 		// 检查是否已存在
-		exists := false
-		for _, existingDomain := range d.domains {
-			if existingDomain == normalizedDomain {
-				exists = true
-				break
+		if _, exists := existing[normalizedDomain]; !exists {
+			existing[normalizedDomain] = struct{}{}
+			d.domains = append(d.domains, normalizedDomain)
+			if d.engine != nil {
+				d.engine.Insert(d.engineRule(normalizedDomain))
 			}
 		}
+	}
+	d.compileRegexes()
+	d.invalidateCache()
+}
 
-		if !exists {
-			d.domains = append(d.domains, normalizedDomain)
-		}
+// AddWithOptions 添加一个域名，并单独指定它是否匹配子域名，不受列表的includeSubdomains影响
+//
+// 参数:
+//   - domain: 不带匹配方式前缀的域名，与Add相同地标准化
+//   - includeSubdomains: true时匹配域名本身及其所有子域名，false时只匹配域名本身
+//
+// 返回:
+//   - string: 列表中保存的规则，即GetDomains中的写法，移除时使用
+//   - error: 域名为空、无效或带有匹配方式前缀时返回包装了ErrInvalidDomain的错误
+//
+// 域名分别保存为"suffix:"或"exact:"规则，因此同一个列表中可以同时有只封禁ads.example.com本身
+// 和封禁evil.com及其所有子域名的条目。
+//
+// 示例:
+//
+//	acl := domain.NewDomainACL(nil, types.Blacklist, false)
+//	acl.AddWithOptions("ads.example.com", false) // 保存为"exact:ads.example.com"
+//	acl.AddWithOptions("evil.com", true)         // 保存为"suffix:evil.com"
+//	acl.Check("x.ads.example.com")               // Allowed
+//	acl.Check("x.evil.com")                      // Denied
+func (d *DomainACL) AddWithOptions(domain string, includeSubdomains bool) (string, error) {
+	rule, err := ParseRule(domain)
+	if err != nil {
+		return "", err
+	}
+	if rule.Kind != MatchDefault {
+		return "", fmt.Errorf("%w: %s: 已带有匹配方式", ErrInvalidDomain, domain)
 	}
+	rule.Kind = MatchExact
+	if includeSubdomains {
+		rule.Kind = MatchSuffix
+	}
+	d.Add(rule.String())
+	return rule.String(), nil
 }
 
 // Remove 从访问控制列表移除一个或多个域名
@@ -148,7 +214,8 @@ func (d *DomainACL) Add(domains ...string) {
 //   - error: 如果任何一个域名不在列表中，返回ErrDomainNotFound
 //     如果找到部分域名，仍会移除这些域名，但仍返回错误
 //
-// 域名在移除前会被自动标准化，与Add方法使用相同的标准化规则。
+// 域名在移除前会被自动标准化，与Add方法使用相同的标准化规则，
+// 带有匹配方式前缀的规则需要以相同的前缀移除。
 //
 // 示例:
 //
@@ -167,31 +234,33 @@ func (d *DomainACL) Remove(domains ...string) error {
 	var notFoundErr error
 	var newDomains []string
 
-	for _, existingDomain := range d.domains {
-		keep := true
-
-		for _, domainToRemove := range domains {
-			normalizedToRemove := normalizeDomain(domainToRemove)
-			if normalizedToRemove == "" {
-				continue
-			}
-
-			if existingDomain == normalizedToRemove {
-				keep = false
-				break
-			}
+	// 要移除的域名只标准化一次
+	remove := make(map[string]struct{}, len(domains))
+	for _, domainToRemove := range domains {
+		rule, err := ParseRule(domainToRemove)
+		if err != nil {
+			continue
 		}
+		remove[rule.String()] = struct{}{}
+	}
 
-		if keep {
+	var removed []string
+	for _, existingDomain := range d.domains {
+		if _, ok := remove[existingDomain]; ok {
+			removed = append(removed, existingDomain)
+		} else {
 			newDomains = append(newDomains, existingDomain)
 		}
 	}
 
 	// 检查是否所有要移除的域名都找到了
-	if len(newDomains) == len(d.domains) {
+	if len(removed) == 0 {
 		notFoundErr = ErrDomainNotFound
 	} else {
+		d.removeFromEngine(removed, newDomains)
 		d.domains = newDomains
+		d.compileRegexes()
+		d.invalidateCache()
 	}
 
 	return notFoundErr
@@ -251,12 +320,14 @@ func (d *DomainACL) GetListType() types.ListType {
 //   - types.Permission: 访问权限
 //   - types.Allowed: 允许访问
 //   - types.Denied: 拒绝访问
-//   - error: 如果提供的域名格式无效，返回ErrInvalidDomain
+//   - error: 如果提供的域名格式无效，返回ErrInvalidDomain；d为nil时返回types.ErrNoACL
 //
 // 域名在检查前会被自动标准化。
 // 如果设置了includeSubdomains=true，将检查子域名匹配。
 //
 // 权限决定逻辑:
+//   - 节点策略: 如果通过SetPolicy附加的策略匹配，最具体的节点决定结果
+//   - 例外: 命中AddException添加的例外的域名视为不在列表中
 //   - 黑名单模式: 默认返回Allowed，除非域名在列表中
 //   - 白名单模式: 默认返回Denied，除非域名在列表中
 //
@@ -277,10 +348,23 @@ func (d *DomainACL) GetListType() types.ListType {
 //	    // 处理拒绝的情况...
 //	}
 func (d *DomainACL) Check(domain string) (types.Permission, error) {
+	if d == nil {
+		return types.Denied, types.ErrNoACL
+	}
+
 	normalizedDomain := normalizeDomain(domain)
 	if normalizedDomain == "" {
 		return types.Denied, ErrInvalidDomain
 	}
+	return d.checkNormalized(normalizedDomain)
+}
+
+// checkNormalized 检查已标准化的域名，Check和CheckNormalized的共同部分
+func (d *DomainACL) checkNormalized(normalizedDomain string) (types.Permission, error) {
+	// 节点策略优先于列表类型的常规判断
+	if _, permission, ok := d.matchPolicy(normalizedDomain); ok {
+		return permission, nil
+	}
 
 	matched := d.matchDomain(normalizedDomain)
 
@@ -311,28 +395,60 @@ func (d *DomainACL) Check(domain string) (types.Permission, error) {
 // 则"sub.example.com"和"api.sub.example.com"都会匹配。
 //
 // 如果includeSubdomains=false，则只有完全相同的域名才会匹配。
+// 带有匹配方式前缀的规则按ParseRule描述的语义匹配，不受includeSubdomains影响。
+// 启用了SetMatchAnyDomain时直接命中；否则设置了匹配引擎时由引擎判断是否命中规则，否则逐条比较。
 func (d *DomainACL) matchDomain(domain string) bool {
 	if domain == "" {
 		return false
 	}
 
-	for _, aclDomain := range d.domains {
-		// 完全匹配
-		if domain == aclDomain {
-			return true
-		}
+	if d.negCache != nil && d.negCache.contains(domain) {
+		return false
+	}
 
-		// 如果启用了子域名匹配，检查是否是受控域名的子域名
-		if d.includeSubdomains {
-			if strings.HasSuffix(domain, "."+aclDomain) {
-				return true
+	matched := false
+	if d.matchAny {
+		matched = true
+	} else if d.engine != nil {
+		matched = d.engine.Match(domain)
+	} else {
+		for _, aclDomain := range d.domains {
+			// 完全匹配、子域名匹配（启用时）或带前缀规则的匹配
+			if ok, _ := d.ruleMatches(aclDomain, domain); ok {
+				matched = true
+				break
 			}
 		}
 	}
 
+	// 命中例外的域名视为未匹配
+	if matched {
+		if _, excepted := d.matchException(domain); !excepted {
+			return true
+		}
+	}
+
+	if d.negCache != nil {
+		d.negCache.add(domain)
+	}
 	return false
 }
 
+// Normalize 按Check使用的规则标准化域名或URL
+//
+// 参数:
+//   - domain: 要标准化的域名或URL
+//
+// 返回:
+//   - string: 标准化后的主机部分，输入无效时为空字符串
+//
+// 示例:
+//
+//	domain.Normalize("https://www.Example.COM:8080/path") // 返回 "example.com"
+func Normalize(domain string) string {
+	return normalizeDomain(domain)
+}
+
 // normalizeDomain 标准化域名，删除不必要的部分
 //
 // 参数:
@@ -346,9 +462,10 @@ func (d *DomainACL) matchDomain(domain string) bool {
 // 标准化过程包括:
 //   - 移除协议前缀 (http://, https://)
 //   - 移除"www."前缀
+//   - 移除末尾的点
+//   - 移除路径、查询参数和片段标识符（包括浏览器视同"/"的"\"）
 //   - 移除用户名和密码部分
-//   - 移除端口号
-//   - 移除路径、查询参数和片段标识符
+//   - 移除端口号（保留IPv6地址）
 //   - 转换为小写
 //   - 移除首尾空白
 //
@@ -360,50 +477,105 @@ func (d *DomainACL) matchDomain(domain string) bool {
 //	normalizeDomain("sub.DOMAIN.org") // 返回 "sub.domain.org"
 //	normalizeDomain("user:pass@site.net") // 返回 "site.net"
 func normalizeDomain(domain string) string {
+	return normalizeDomainSteps(domain, nil)
+}
+
+// normalizeDomainSteps 执行域名标准化，并在每一步改变了输入时调用record
+//
+// 参数:
+//   - domain: 要标准化的域名
+//   - record: 记录变换步骤的回调，参数为步骤名称和该步骤后的结果，可为nil
+//
+// 返回:
+//   - string: 标准化后的域名，与normalizeDomain相同
+func normalizeDomainSteps(domain string, record func(step, result string)) string {
+	apply := func(step, result string) string {
+		if record != nil && result != domain {
+			record(step, result)
+		}
+		return result
+	}
+
 	// 转小写并去除首尾空格
-	domain = strings.TrimSpace(strings.ToLower(domain))
+	domain = apply("lowercase", strings.TrimSpace(strings.ToLower(domain)))
 	if domain == "" {
 		return ""
 	}
 
 	// 处理特殊的双斜杠开头格式 (//example.com)
-	domain = strings.TrimPrefix(domain, "//")
+	domain = apply("strip_scheme", strings.TrimPrefix(domain, "//"))
 
 	// 移除协议前缀
-	domain = strings.TrimPrefix(domain, "http://")
-	domain = strings.TrimPrefix(domain, "https://")
-
-	// 移除用户名和密码部分
-	if atIndex := strings.Index(domain, "@"); atIndex != -1 {
-		domain = domain[atIndex+1:]
-	}
+	domain = apply("strip_scheme", strings.TrimPrefix(domain, "http://"))
+	domain = apply("strip_scheme", strings.TrimPrefix(domain, "https://"))
 
 	// 移除路径、查询参数和片段标识符
-	for _, sep := range []string{"/", "?", "#"} {
+	// 必须先于用户信息处理：按URL规范，"/"、"?"、"#"之后的"@"不是用户信息的分隔符，
+	// 浏览器还会把"\"当作"/"，例如"evil.com#@good.com"的主机是evil.com而不是good.com
+	for _, sep := range []string{"/", "\\", "?", "#"} {
 		if sepIndex := strings.Index(domain, sep); sepIndex != -1 {
-			domain = domain[:sepIndex]
+			domain = apply("strip_path", domain[:sepIndex])
 		}
 	}
 
+	// 移除用户名和密码部分，与URL规范一致以最后一个"@"为分隔
+	if atIndex := strings.LastIndex(domain, "@"); atIndex != -1 {
+		domain = apply("strip_userinfo", domain[atIndex+1:])
+	}
+
 	// 移除端口号，但要注意IPv6地址的格式
-	// 在IPv6中，地址部分可能包含冒号并被方括号包围，如 [2001:db8::1]:8080
-	var portIndex int
-	if strings.HasPrefix(domain, "[") && strings.Contains(domain, "]:") {
-		// 是IPv6地址加端口
-		portIndex = strings.Index(domain, "]:")
-		if portIndex != -1 {
-			domain = domain[:portIndex+1] // 保留IPv6地址部分，包含右括号
-		}
-	} else {
-		// 普通域名或IPv4地址加端口
-		portIndex = strings.LastIndex(domain, ":")
-		if portIndex != -1 {
-			domain = domain[:portIndex]
+	if strings.HasPrefix(domain, "[") {
+		// 方括号中的IPv6地址，可能带端口，如 [2001:db8::1]:8080
+		if end := strings.Index(domain, "]"); end != -1 {
+			domain = apply("strip_port", domain[:end+1]) // 保留IPv6地址部分，包含右括号
 		}
+	} else if strings.Count(domain, ":") == 1 {
+		// 普通域名或IPv4地址加端口；多个冒号表示不带方括号的IPv6地址，没有端口
+		domain = apply("strip_port", domain[:strings.Index(domain, ":")])
 	}
 
 	// 移除www前缀
-	domain = strings.TrimPrefix(domain, "www.")
+	domain = apply("strip_www", strings.TrimPrefix(domain, "www."))
+
+	// 移除末尾的点，"example.com."是完全限定形式，与"example.com"是同一个域名
+	domain = apply("strip_trailing_dot", strings.TrimSuffix(domain, "."))
 
 	return domain
 }
+
+// mapEntryOverhead 是估算map每个条目额外开销（哈希桶、tophash等）使用的字节数
+const mapEntryOverhead = 16
+
+// MemoryUsage 返回域名访问控制列表占用的近似字节数
+//
+// 返回:
+//   - int: 域名、例外、节点策略和否定缓存占用的字节数之和
+//
+// 结果是估算值，不包含Go运行时的分配开销，适合用于容量规划和监控规则规模的突变。
+func (d *DomainACL) MemoryUsage() int {
+	const stringHeader = int(unsafe.Sizeof(""))
+
+	bytes := int(unsafe.Sizeof(*d))
+	for _, domain := range d.domains {
+		bytes += stringHeader + len(domain)
+	}
+	for _, exception := range d.exceptions {
+		bytes += stringHeader + len(exception)
+	}
+	for node := range d.policies {
+		bytes += stringHeader + len(node) + int(unsafe.Sizeof(types.Permission(0))) + mapEntryOverhead
+	}
+	for pattern := range d.regexes {
+		// 不包含编译后的正则表达式程序
+		bytes += stringHeader + len(pattern) + int(unsafe.Sizeof(regexp.Regexp{})) + mapEntryOverhead
+	}
+	if c := d.negCache; c != nil {
+		c.mu.Lock()
+		bytes += int(unsafe.Sizeof(*c)) + cap(c.order)*stringHeader
+		for domain := range c.entries {
+			bytes += stringHeader + len(domain) + mapEntryOverhead
+		}
+		c.mu.Unlock()
+	}
+	return bytes
+}
@@ -312,6 +312,58 @@ func TestDomainACL_Remove(t *testing.T) {
 	}
 }
 
+// TestDomainACL_AddWithOptions 测试逐条指定是否匹配子域名
+func TestDomainACL_AddWithOptions(t *testing.T) {
+	for _, listIncludeSubdomains := range []bool{false, true} {
+		acl := NewDomainACL(nil, types.Blacklist, listIncludeSubdomains)
+		adds := []struct {
+			domain            string
+			includeSubdomains bool
+			want              string
+		}{
+			{"ads.example.com", false, "exact:ads.example.com"},
+			{"https://www.Evil.com/path", true, "suffix:evil.com"},
+		}
+		for _, a := range adds {
+			got, err := acl.AddWithOptions(a.domain, a.includeSubdomains)
+			if err != nil || got != a.want {
+				t.Errorf("AddWithOptions(%q, %v) = %q, %v, 期望 %q", a.domain, a.includeSubdomains, got, err, a.want)
+			}
+		}
+
+		// 结果与列表的includeSubdomains无关
+		checks := []struct {
+			domain string
+			want   types.Permission
+		}{
+			{"ads.example.com", types.Denied},
+			{"x.ads.example.com", types.Allowed},
+			{"evil.com", types.Denied},
+			{"a.b.evil.com", types.Denied},
+		}
+		for _, c := range checks {
+			if perm, _ := acl.Check(c.domain); perm != c.want {
+				t.Errorf("includeSubdomains=%v: Check(%q) = %v, 期望 %v", listIncludeSubdomains, c.domain, perm, c.want)
+			}
+		}
+
+		if err := acl.Remove("exact:ads.example.com"); err != nil {
+			t.Errorf("移除保存的规则返回错误: %v", err)
+		}
+	}
+
+	invalid := []string{"", "exact:ads.example.com", "regex:^a$"}
+	acl := NewDomainACL(nil, types.Blacklist, false)
+	for _, domain := range invalid {
+		if _, err := acl.AddWithOptions(domain, true); !errors.Is(err, ErrInvalidDomain) {
+			t.Errorf("AddWithOptions(%q) 错误 = %v, 期望 ErrInvalidDomain", domain, err)
+		}
+	}
+	if got := acl.GetDomains(); len(got) != 0 {
+		t.Errorf("无效的域名被加入列表: %v", got)
+	}
+}
+
 // TestDomainACL_GetDomains 测试获取域名列表
 func TestDomainACL_GetDomains(t *testing.T) {
 	tests := []struct {
@@ -1116,3 +1168,53 @@ func TestPortParsingEdgeCases(t *testing.T) {
 		})
 	}
 }
+
+// TestMemoryUsage 测试内存占用估算包含域名、例外、节点策略和否定缓存
+func TestMemoryUsage(t *testing.T) {
+	acl := NewDomainACL([]string{"example.com"}, types.Blacklist, true)
+	base := acl.MemoryUsage()
+	if base <= len("example.com") {
+		t.Fatalf("MemoryUsage() = %d, 应大于域名本身的长度", base)
+	}
+
+	steps := []struct {
+		name   string
+		modify func()
+	}{
+		{"添加域名", func() { acl.Add("another-example.org") }},
+		{"添加例外", func() { acl.AddException("status.example.com") }},
+		{"设置节点策略", func() { acl.SetPolicy("example.com", types.Allowed) }},
+		{"启用否定缓存", func() { acl.EnableNegativeCache(16) }},
+		{"缓存未匹配的域名", func() { _, _ = acl.Check("unrelated.net") }},
+	}
+	for _, step := range steps {
+		t.Run(step.name, func(t *testing.T) {
+			before := acl.MemoryUsage()
+			step.modify()
+			if after := acl.MemoryUsage(); after <= before {
+				t.Errorf("MemoryUsage() = %d, 期望大于修改前的 %d", after, before)
+			}
+		})
+	}
+}
+
+// TestDomainACL_ZeroValue 测试nil和零值DomainACL不会panic
+func TestDomainACL_ZeroValue(t *testing.T) {
+	var nilACL *DomainACL
+	if perm, err := nilACL.Check("example.com"); perm != types.Denied || !errors.Is(err, types.ErrNoACL) {
+		t.Errorf("nil DomainACL Check() = %v, %v; 期望 Denied, ErrNoACL", perm, err)
+	}
+
+	acl := &DomainACL{}
+	if perm, err := acl.Check("example.com"); err != nil || perm != types.Allowed {
+		t.Errorf("零值 Check() = %v, %v; 期望按空黑名单允许", perm, err)
+	}
+	acl.Add("example.com")
+	acl.AddException("status.example.com")
+	if err := acl.SetPolicy("internal.example.com", types.Allowed); err != nil {
+		t.Fatalf("零值 SetPolicy() 返回错误: %v", err)
+	}
+	if perm, err := acl.Check("example.com"); err != nil || perm != types.Denied {
+		t.Errorf("Add后 Check() = %v, %v; 期望 Denied", perm, err)
+	}
+}
@@ -0,0 +1,76 @@
+package domain
+
+import (
+	"bufio"
+	"io"
+	"sort"
+	"strings"
+
+	"github.com/cyberspacesec/go-acl/pkg/types"
+)
+
+// String 返回域名访问控制列表的简短摘要，实现fmt.Stringer
+//
+// 返回:
+//   - string: 例如"blacklist, 1,204 domains, subdomains=on"，
+//     存在例外或节点策略时追加其数量，如", 3 exceptions, 1 policy"
+//
+// 示例:
+//
+//	log.Printf("域名ACL: %s", acl) // 域名ACL: whitelist, 2 domains, subdomains=off
+func (d *DomainACL) String() string {
+	subdomains := "off"
+	if d.includeSubdomains {
+		subdomains = "on"
+	}
+	parts := []string{
+		d.listType.String(),
+		types.FormatCount(len(d.domains), "domain", "domains"),
+		"subdomains=" + subdomains,
+	}
+	if len(d.exceptions) > 0 {
+		parts = append(parts, types.FormatCount(len(d.exceptions), "exception", "exceptions"))
+	}
+	if len(d.policies) > 0 {
+		parts = append(parts, types.FormatCount(len(d.policies), "policy", "policies"))
+	}
+	return strings.Join(parts, ", ")
+}
+
+// Dump 将摘要和全部规则写入w，用于调试
+//
+// 参数:
+//   - w: 输出目标
+//
+// 返回:
+//   - error: 写入过程中的错误
+//
+// 第一行是String()的摘要，之后每行一条规则（缩进两个空格）: 先列出域名，
+// 再列出以"!"开头的例外，最后按节点名称排序列出"policy 节点: 结果"形式的节点策略。
+//
+// 示例:
+//
+//	acl.Dump(os.Stderr)
+//	// blacklist, 1 domain, subdomains=on, 1 exception
+//	//   example.com
+//	//   !status.example.com
+func (d *DomainACL) Dump(w io.Writer) error {
+	writer := bufio.NewWriter(w)
+	writer.WriteString(d.String() + "\n")
+	for _, domain := range d.domains {
+		writer.WriteString("  " + domain + "\n")
+	}
+	for _, exception := range d.exceptions {
+		writer.WriteString("  !" + exception + "\n")
+	}
+
+	nodes := make([]string, 0, len(d.policies))
+	for node := range d.policies {
+		nodes = append(nodes, node)
+	}
+	sort.Strings(nodes)
+	for _, node := range nodes {
+		writer.WriteString("  policy " + node + ": " + d.policies[node].String() + "\n")
+	}
+	return writer.Flush()
+}
@@ -0,0 +1,78 @@
+package domain
+
+import (
+	"bytes"
+	"fmt"
+	"testing"
+
+	"github.com/cyberspacesec/go-acl/pkg/types"
+)
+
+// TestDomainACLString 测试域名访问控制列表的摘要
+func TestDomainACLString(t *testing.T) {
+	many := make([]string, 1204)
+	for i := range many {
+		many[i] = fmt.Sprintf("host%d.example.com", i)
+	}
+
+	tests := []struct {
+		name string
+		acl  func() *DomainACL
+		want string
+	}{
+		{
+			name: "单个域名不含子域名",
+			acl:  func() *DomainACL { return NewDomainACL([]string{"example.com"}, types.Whitelist, false) },
+			want: "whitelist, 1 domain, subdomains=off",
+		},
+		{
+			name: "千位分隔符",
+			acl:  func() *DomainACL { return NewDomainACL(many, types.Blacklist, true) },
+			want: "blacklist, 1,204 domains, subdomains=on",
+		},
+		{
+			name: "例外和节点策略",
+			acl: func() *DomainACL {
+				acl := NewDomainACL([]string{"example.com"}, types.Blacklist, true)
+				acl.AddException("a.example.com", "b.example.com")
+				acl.SetPolicy("example.com", types.Allowed)
+				return acl
+			},
+			want: "blacklist, 1 domain, subdomains=on, 2 exceptions, 1 policy",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			acl := tt.acl()
+			if got := acl.String(); got != tt.want {
+				t.Errorf("String() = %q, 期望 %q", got, tt.want)
+			}
+			if got := fmt.Sprint(acl); got != tt.want {
+				t.Errorf("fmt.Sprint() = %q, 期望 %q", got, tt.want)
+			}
+		})
+	}
+}
+
+// TestDomainACLDump 测试输出完整的规则列表
+func TestDomainACLDump(t *testing.T) {
+	acl := NewDomainACL([]string{"example.com", "ads.net"}, types.Blacklist, true)
+	acl.AddException("status.example.com")
+	acl.SetPolicy("sub.ads.net", types.Allowed)
+	acl.SetPolicy("example.com", types.Denied)
+
+	var buf bytes.Buffer
+	if err := acl.Dump(&buf); err != nil {
+		t.Fatalf("Dump() 返回错误: %v", err)
+	}
+	want := "blacklist, 2 domains, subdomains=on, 1 exception, 2 policies\n" +
+		"  example.com\n" +
+		"  ads.net\n" +
+		"  !status.example.com\n" +
+		"  policy example.com: denied\n" +
+		"  policy sub.ads.net: allowed\n"
+	if buf.String() != want {
+		t.Errorf("Dump() 输出 = %q, 期望 %q", buf.String(), want)
+	}
+}
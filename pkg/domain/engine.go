@@ -0,0 +1,179 @@
+package domain
+
+import (
+	"regexp"
+	"strings"
+)
+
+// MatchEngine 是DomainACL判断域名是否匹配列表规则的引擎
+//
+// DomainACL负责标准化、去重、例外、节点策略、否定缓存和列表类型的语义，
+// 引擎只维护规则集合并回答"已标准化的域名是否命中某条规则"，因此可以替换为其他数据结构
+// （如按标签索引的哈希表、布隆过滤器加精确集合），而不改变DomainACL的公开API。
+//
+// 方法说明:
+//   - Insert: 加入一条规则，已存在时不做任何事
+//   - Remove: 移除一条规则，不存在时不做任何事
+//   - Match: 判断已标准化的域名是否命中任意一条规则
+//
+// 传给引擎的规则的Kind只会是MatchExact、MatchSuffix或MatchRegex:
+// 没有前缀的普通域名按列表的includeSubdomains设置转换为MatchSuffix（包含子域名）或MatchExact。
+// 引擎不需要自行加锁，DomainACL的并发约定同样适用于引擎。
+type MatchEngine interface {
+	Insert(rule Rule)
+	Remove(rule Rule)
+	Match(domain string) bool
+}
+
+// suffixEngine 按域名索引规则的哈希表引擎
+//
+// exact和suffix保存MatchExact和MatchSuffix规则的域名，subdomains保存只匹配子域名的
+// MatchSuffix规则（去掉开头的"."），regexes保存编译后的正则表达式规则。
+type suffixEngine struct {
+	exact      map[string]struct{}
+	suffix     map[string]struct{}
+	subdomains map[string]struct{}
+	regexes    map[string]*regexp.Regexp
+}
+
+// NewSuffixEngine 创建按域名索引规则的哈希表引擎
+//
+// 返回:
+//   - MatchEngine: 查找时对域名的每一级父域名做一次哈希查找的引擎
+//
+// 默认的匹配方式逐条比较列表中的规则，耗时与规则数量成正比；
+// 使用该引擎后，查找耗时只与域名的标签数量（和正则表达式规则的数量）相关，
+// 适合包含数十万条域名的威胁情报列表。
+//
+// 示例:
+//
+//	acl := domain.NewDomainACL(feed, types.Blacklist, true)
+//	acl.SetMatchEngine(domain.NewSuffixEngine())
+func NewSuffixEngine() MatchEngine {
+	return &suffixEngine{
+		exact:      make(map[string]struct{}),
+		suffix:     make(map[string]struct{}),
+		subdomains: make(map[string]struct{}),
+	}
+}
+
+// Insert 实现MatchEngine
+func (e *suffixEngine) Insert(rule Rule) {
+	switch rule.Kind {
+	case MatchRegex:
+		re, err := regexp.Compile(rule.Value)
+		if err != nil {
+			return
+		}
+		if e.regexes == nil {
+			e.regexes = make(map[string]*regexp.Regexp)
+		}
+		e.regexes[rule.Value] = re
+	case MatchSuffix:
+		if strings.HasPrefix(rule.Value, ".") {
+			e.subdomains[rule.Value[1:]] = struct{}{}
+		} else {
+			e.suffix[rule.Value] = struct{}{}
+		}
+	default:
+		e.exact[rule.Value] = struct{}{}
+	}
+}
+
+// Remove 实现MatchEngine
+func (e *suffixEngine) Remove(rule Rule) {
+	switch rule.Kind {
+	case MatchRegex:
+		delete(e.regexes, rule.Value)
+	case MatchSuffix:
+		if strings.HasPrefix(rule.Value, ".") {
+			delete(e.subdomains, rule.Value[1:])
+		} else {
+			delete(e.suffix, rule.Value)
+		}
+	default:
+		delete(e.exact, rule.Value)
+	}
+}
+
+// Match 实现MatchEngine
+func (e *suffixEngine) Match(domain string) bool {
+	if _, ok := e.exact[domain]; ok {
+		return true
+	}
+	if _, ok := e.suffix[domain]; ok {
+		return true
+	}
+	// 逐级检查父域名
+	for parent := domain; ; {
+		i := strings.IndexByte(parent, '.')
+		if i < 0 {
+			break
+		}
+		parent = parent[i+1:]
+		if _, ok := e.suffix[parent]; ok {
+			return true
+		}
+		if _, ok := e.subdomains[parent]; ok {
+			return true
+		}
+	}
+	for _, re := range e.regexes {
+		if re.MatchString(domain) {
+			return true
+		}
+	}
+	return false
+}
+
+// SetMatchEngine 替换匹配规则使用的引擎
+//
+// 参数:
+//   - engine: 新的空引擎，当前列表中的所有规则会被加入其中；为nil时恢复默认的逐条比较
+//
+// 替换引擎不改变检查结果，只改变匹配的数据结构。Match、Explain等诊断方法不使用引擎。
+// 与Add、Remove相同，不能与检查并发调用。
+//
+// 示例:
+//
+//	acl.SetMatchEngine(domain.NewSuffixEngine())
+func (d *DomainACL) SetMatchEngine(engine MatchEngine) {
+	if engine != nil {
+		for _, rule := range d.domains {
+			engine.Insert(d.engineRule(rule))
+		}
+	}
+	d.engine = engine
+	d.invalidateCache()
+}
+
+// engineRule 把列表中已规范化的规则转换为传给引擎的规则，普通域名按includeSubdomains转换
+func (d *DomainACL) engineRule(rule string) Rule {
+	r := parseStoredRule(rule)
+	if r.Kind == MatchDefault {
+		r.Kind = MatchExact
+		if d.includeSubdomains {
+			r.Kind = MatchSuffix
+		}
+	}
+	return r
+}
+
+// removeFromEngine 从引擎中移除removed中的规则，remaining中仍有规则转换为相同的引擎规则时保留
+//
+// 例如包含子域名的列表中，"example.com"和"suffix:example.com"对应同一条引擎规则。
+func (d *DomainACL) removeFromEngine(removed, remaining []string) {
+	if d.engine == nil {
+		return
+	}
+	kept := make(map[Rule]struct{}, len(remaining))
+	for _, rule := range remaining {
+		kept[d.engineRule(rule)] = struct{}{}
+	}
+	for _, rule := range removed {
+		r := d.engineRule(rule)
+		if _, ok := kept[r]; !ok {
+			d.engine.Remove(r)
+		}
+	}
+}
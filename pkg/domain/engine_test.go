@@ -0,0 +1,77 @@
+package domain
+
+import (
+	"testing"
+
+	"github.com/cyberspacesec/go-acl/pkg/types"
+)
+
+// TestSuffixEngine 测试哈希表引擎与默认的逐条比较结果一致
+func TestSuffixEngine(t *testing.T) {
+	rules := []string{
+		"example.com",
+		"exact:login.corp.net",
+		"suffix:cdn.net",
+		"suffix:.static.org",
+		`regex:^img[0-9]+\.media\.io$`,
+		"www.shop.example",
+	}
+	targets := []string{
+		"example.com", "api.example.com", "example.com.evil.net",
+		"login.corp.net", "a.login.corp.net", "corp.net",
+		"cdn.net", "x.y.cdn.net", "notcdn.net",
+		"static.org", "img.static.org",
+		"img12.media.io", "img.media.io",
+		"shop.example", "a.shop.example",
+	}
+
+	for _, includeSubdomains := range []bool{false, true} {
+		linear := NewDomainACL(rules, types.Blacklist, includeSubdomains)
+		indexed := NewDomainACL(rules, types.Blacklist, includeSubdomains)
+		indexed.SetMatchEngine(NewSuffixEngine())
+		indexed.AddException("status.example.com")
+
+		// 修改列表后引擎同步更新
+		for _, acl := range []*DomainACL{linear, indexed} {
+			acl.Add("new.example.org")
+			if err := acl.Remove("www.shop.example"); err != nil {
+				t.Fatalf("Remove() 返回错误: %v", err)
+			}
+		}
+		linear.AddException("status.example.com")
+
+		for _, target := range append(targets, "new.example.org", "a.new.example.org", "status.example.com") {
+			want, _ := linear.Check(target)
+			got, err := indexed.Check(target)
+			if err != nil || got != want {
+				t.Errorf("includeSubdomains=%v Check(%q) = %v, %v, 逐条比较的结果为 %v", includeSubdomains, target, got, err, want)
+			}
+		}
+	}
+}
+
+// TestSetMatchEngineRemove 测试转换为相同引擎规则的另一条规则仍在列表中时不从引擎移除
+func TestSetMatchEngineRemove(t *testing.T) {
+	acl := NewDomainACL([]string{"example.com", "suffix:example.com"}, types.Blacklist, true)
+	acl.SetMatchEngine(NewSuffixEngine())
+
+	if err := acl.Remove("example.com"); err != nil {
+		t.Fatalf("Remove() 返回错误: %v", err)
+	}
+	if perm, _ := acl.Check("api.example.com"); perm != types.Denied {
+		t.Errorf("Check(api.example.com) = %v, 期望 Denied（suffix:example.com仍在列表中）", perm)
+	}
+	if err := acl.Remove("suffix:example.com"); err != nil {
+		t.Fatalf("Remove() 返回错误: %v", err)
+	}
+	if perm, _ := acl.Check("api.example.com"); perm != types.Allowed {
+		t.Errorf("Check(api.example.com) = %v, 期望 Allowed", perm)
+	}
+
+	// 恢复默认的逐条比较
+	acl.Add("example.net")
+	acl.SetMatchEngine(nil)
+	if perm, _ := acl.Check("a.example.net"); perm != types.Denied {
+		t.Errorf("Check(a.example.net) = %v, 期望 Denied", perm)
+	}
+}
@@ -0,0 +1,285 @@
+package domain
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+
+	"github.com/cyberspacesec/go-acl/pkg/types"
+)
+
+// ErrUnsupportedEnvoyConfig 表示Envoy配置片段中包含无法转换的内容
+var ErrUnsupportedEnvoyConfig = errors.New("不支持的Envoy配置")
+
+// envoyVirtualHost 对应Envoy路由配置中的virtual_host片段
+type envoyVirtualHost struct {
+	Name    string   `json:"name"`
+	Domains []string `json:"domains"`
+}
+
+// envoyStringMatch 对应Envoy的StringMatcher
+type envoyStringMatch struct {
+	Exact  string `json:"exact,omitempty"`
+	Suffix string `json:"suffix,omitempty"`
+}
+
+// envoyHeaderMatch 对应Envoy的HeaderMatcher
+type envoyHeaderMatch struct {
+	Name        string           `json:"name"`
+	StringMatch envoyStringMatch `json:"string_match"`
+}
+
+// envoyPermission 对应Envoy RBAC的Permission
+type envoyPermission struct {
+	Header  *envoyHeaderMatch `json:"header,omitempty"`
+	OrRules *struct {
+		Rules []envoyPermission `json:"rules"`
+	} `json:"or_rules,omitempty"`
+}
+
+// envoyPrincipal 对应Envoy RBAC的Principal
+type envoyPrincipal struct {
+	Any bool `json:"any"`
+}
+
+// envoyPolicy 对应Envoy RBAC的Policy
+type envoyPolicy struct {
+	Permissions []envoyPermission `json:"permissions"`
+	Principals  []envoyPrincipal  `json:"principals"`
+}
+
+// envoyRBAC 对应envoy.extensions.filters.http.rbac.v3.RBAC配置
+type envoyRBAC struct {
+	Rules struct {
+		Action   string                 `json:"action"`
+		Policies map[string]envoyPolicy `json:"policies"`
+	} `json:"rules"`
+}
+
+// ExportEnvoyVirtualHost 将域名列表导出为Envoy路由配置的virtual_host片段（JSON）
+//
+// 参数:
+//   - w: 输出目标
+//   - acl: 要导出的域名访问控制列表
+//   - name: virtual_host的名称
+//
+// 返回:
+//   - error: 编码或写入过程中的错误
+//
+// 每个域名导出为domains中的一项；启用子域名匹配时额外导出"*.域名"通配项。
+// "exact:"、"suffix:"规则按各自的语义导出，正则表达式规则返回ErrUnsupportedRule。
+// 启用了SetMatchAnyDomain的列表额外导出Envoy的通配项"*"。
+// virtual_host只描述路由匹配的域名，通常用于白名单场景：
+// 只有列表中的域名会被路由，其他域名由Envoy返回404。
+//
+// 示例输出:
+//
+//	{"name":"allowed","domains":["example.com","*.example.com"]}
+func ExportEnvoyVirtualHost(w io.Writer, acl *DomainACL, name string) error {
+	vhost := envoyVirtualHost{Name: name, Domains: []string{}}
+	if acl.matchAny {
+		vhost.Domains = append(vhost.Domains, AnyDomain)
+	}
+	for _, domain := range acl.domains {
+		exact, suffix, err := envoyMatches(acl, domain)
+		if err != nil {
+			return err
+		}
+		if exact != "" {
+			vhost.Domains = append(vhost.Domains, exact)
+		}
+		if suffix != "" {
+			vhost.Domains = append(vhost.Domains, "*"+suffix)
+		}
+	}
+	return json.NewEncoder(w).Encode(vhost)
+}
+
+// ImportEnvoyVirtualHost 从Envoy virtual_host片段（JSON）创建域名访问控制列表
+//
+// 参数:
+//   - r: virtual_host的JSON内容，至少包含domains字段
+//   - listType: 列表类型（黑名单或白名单）
+//
+// 返回:
+//   - *DomainACL: 创建的域名访问控制列表
+//   - error: 可能的错误:
+//   - ErrUnsupportedEnvoyConfig: 包含"*"或中间带通配符等无法转换的域名
+//   - JSON解码错误
+//
+// "*.example.com"形式的通配项会启用子域名匹配，与ImportSquid相同，
+// 子域名匹配是整个列表共享的设置。
+func ImportEnvoyVirtualHost(r io.Reader, listType types.ListType) (*DomainACL, error) {
+	var vhost envoyVirtualHost
+	if err := json.NewDecoder(r).Decode(&vhost); err != nil {
+		return nil, err
+	}
+
+	var domains []string
+	includeSubdomains := false
+	for _, d := range vhost.Domains {
+		if strings.HasPrefix(d, "*.") {
+			includeSubdomains = true
+			d = strings.TrimPrefix(d, "*.")
+		}
+		if strings.Contains(d, "*") {
+			return nil, ErrUnsupportedEnvoyConfig
+		}
+		domains = append(domains, d)
+	}
+
+	return NewDomainACL(domains, listType, includeSubdomains), nil
+}
+
+// ExportEnvoyRBAC 将域名访问控制列表导出为Envoy HTTP RBAC过滤器配置（JSON）
+//
+// 参数:
+//   - w: 输出目标
+//   - acl: 要导出的域名访问控制列表
+//   - policyName: RBAC策略名称
+//
+// 返回:
+//   - error: 编码或写入过程中的错误
+//
+// 黑名单导出为action=DENY，白名单导出为action=ALLOW。
+// 启用了SetMatchAnyDomain的列表返回ErrUnsupportedRule。
+// 每个域名生成一条":authority"头部的精确匹配，启用子域名匹配时
+// 额外生成一条".域名"后缀匹配。"exact:"、"suffix:"规则按各自的语义导出，
+// 正则表达式规则返回ErrUnsupportedRule。
+func ExportEnvoyRBAC(w io.Writer, acl *DomainACL, policyName string) error {
+	if acl.matchAny {
+		return fmt.Errorf("%w: %s", ErrUnsupportedRule, AnyDomain)
+	}
+	var rules []envoyPermission
+	for _, domain := range acl.domains {
+		exact, suffix, err := envoyMatches(acl, domain)
+		if err != nil {
+			return err
+		}
+		if exact != "" {
+			rules = append(rules, envoyPermission{Header: &envoyHeaderMatch{
+				Name:        ":authority",
+				StringMatch: envoyStringMatch{Exact: exact},
+			}})
+		}
+		if suffix != "" {
+			rules = append(rules, envoyPermission{Header: &envoyHeaderMatch{
+				Name:        ":authority",
+				StringMatch: envoyStringMatch{Suffix: suffix},
+			}})
+		}
+	}
+
+	permission := envoyPermission{OrRules: &struct {
+		Rules []envoyPermission `json:"rules"`
+	}{Rules: rules}}
+
+	var config envoyRBAC
+	config.Rules.Action = "DENY"
+	if acl.listType == types.Whitelist {
+		config.Rules.Action = "ALLOW"
+	}
+	config.Rules.Policies = map[string]envoyPolicy{
+		policyName: {
+			Permissions: []envoyPermission{permission},
+			Principals:  []envoyPrincipal{{Any: true}},
+		},
+	}
+
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(config)
+}
+
+// envoyMatches 返回规则对应的精确匹配和以"."开头的后缀匹配，不需要的一项为空
+//
+// 正则表达式规则返回包装了ErrUnsupportedRule的错误。
+func envoyMatches(acl *DomainACL, domain string) (exact, suffix string, err error) {
+	rule := parseStoredRule(domain)
+	switch rule.Kind {
+	case MatchExact:
+		return rule.Value, "", nil
+	case MatchSuffix:
+		if strings.HasPrefix(rule.Value, ".") {
+			return "", rule.Value, nil
+		}
+		return rule.Value, "." + rule.Value, nil
+	case MatchRegex:
+		return "", "", fmt.Errorf("%w: %s", ErrUnsupportedRule, domain)
+	default:
+		if acl.includeSubdomains {
+			return rule.Value, "." + rule.Value, nil
+		}
+		return rule.Value, "", nil
+	}
+}
+
+// ImportEnvoyRBAC 从Envoy HTTP RBAC过滤器配置（JSON）创建域名访问控制列表
+//
+// 参数:
+//   - r: RBAC配置的JSON内容
+//
+// 返回:
+//   - *DomainACL: 创建的域名访问控制列表，action=DENY为黑名单，ALLOW为白名单
+//   - error: 可能的错误:
+//   - ErrUnsupportedEnvoyConfig: action不是ALLOW/DENY
+//   - JSON解码错误
+//
+// 只转换":authority"或"host"头部的exact和suffix匹配，其他权限条件会被忽略。
+// 后缀匹配".example.com"转换为启用子域名匹配的"example.com"。
+func ImportEnvoyRBAC(r io.Reader) (*DomainACL, error) {
+	var config envoyRBAC
+	if err := json.NewDecoder(r).Decode(&config); err != nil {
+		return nil, err
+	}
+
+	var listType types.ListType
+	switch strings.ToUpper(config.Rules.Action) {
+	case "DENY":
+		listType = types.Blacklist
+	case "ALLOW", "":
+		listType = types.Whitelist
+	default:
+		return nil, ErrUnsupportedEnvoyConfig
+	}
+
+	var domains []string
+	includeSubdomains := false
+
+	var collect func(perms []envoyPermission)
+	collect = func(perms []envoyPermission) {
+		for _, perm := range perms {
+			if perm.OrRules != nil {
+				collect(perm.OrRules.Rules)
+			}
+			if perm.Header == nil {
+				continue
+			}
+			name := strings.ToLower(perm.Header.Name)
+			if name != ":authority" && name != "host" {
+				continue
+			}
+			if exact := perm.Header.StringMatch.Exact; exact != "" {
+				domains = append(domains, exact)
+			}
+			if suffix := perm.Header.StringMatch.Suffix; suffix != "" {
+				includeSubdomains = true
+				domains = append(domains, strings.TrimPrefix(suffix, "."))
+			}
+		}
+	}
+	// 按策略名称排序，保证导入结果的顺序稳定
+	names := make([]string, 0, len(config.Rules.Policies))
+	for name := range config.Rules.Policies {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		collect(config.Rules.Policies[name].Permissions)
+	}
+
+	return NewDomainACL(domains, listType, includeSubdomains), nil
+}
@@ -0,0 +1,90 @@
+package domain
+
+import (
+	"bytes"
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/cyberspacesec/go-acl/pkg/types"
+)
+
+// TestExportImportEnvoyVirtualHost 测试Envoy virtual_host片段的导入导出
+func TestExportImportEnvoyVirtualHost(t *testing.T) {
+	acl := NewDomainACL([]string{"example.com"}, types.Whitelist, true)
+
+	var buf bytes.Buffer
+	if err := ExportEnvoyVirtualHost(&buf, acl, "allowed"); err != nil {
+		t.Fatalf("ExportEnvoyVirtualHost() 返回错误: %v", err)
+	}
+	want := `{"name":"allowed","domains":["example.com","*.example.com"]}` + "\n"
+	if buf.String() != want {
+		t.Errorf("ExportEnvoyVirtualHost() 输出 = %q, 期望 %q", buf.String(), want)
+	}
+
+	imported, err := ImportEnvoyVirtualHost(&buf, types.Whitelist)
+	if err != nil {
+		t.Fatalf("ImportEnvoyVirtualHost() 返回错误: %v", err)
+	}
+	if !reflect.DeepEqual(imported.GetDomains(), []string{"example.com"}) {
+		t.Errorf("ImportEnvoyVirtualHost() 域名 = %v", imported.GetDomains())
+	}
+	if perm, _ := imported.Check("api.example.com"); perm != types.Allowed {
+		t.Errorf("导入后子域名检查 = %v, 期望 allowed", perm)
+	}
+
+	if _, err := ImportEnvoyVirtualHost(strings.NewReader(`{"domains":["*"]}`), types.Whitelist); !errors.Is(err, ErrUnsupportedEnvoyConfig) {
+		t.Errorf("ImportEnvoyVirtualHost(\"*\") 错误 = %v, 期望 ErrUnsupportedEnvoyConfig", err)
+	}
+	if _, err := ImportEnvoyVirtualHost(strings.NewReader(`not json`), types.Whitelist); err == nil {
+		t.Error("ImportEnvoyVirtualHost() 对于无效JSON应返回错误")
+	}
+}
+
+// TestExportImportEnvoyRBAC 测试Envoy RBAC配置的导入导出
+func TestExportImportEnvoyRBAC(t *testing.T) {
+	acl := NewDomainACL([]string{"evil.com", "bad.org"}, types.Blacklist, true)
+
+	var buf bytes.Buffer
+	if err := ExportEnvoyRBAC(&buf, acl, "go-acl"); err != nil {
+		t.Fatalf("ExportEnvoyRBAC() 返回错误: %v", err)
+	}
+	out := buf.String()
+	for _, s := range []string{`"action": "DENY"`, `"exact": "evil.com"`, `"suffix": ".bad.org"`, `":authority"`} {
+		if !strings.Contains(out, s) {
+			t.Errorf("ExportEnvoyRBAC() 输出缺少 %s:\n%s", s, out)
+		}
+	}
+
+	imported, err := ImportEnvoyRBAC(&buf)
+	if err != nil {
+		t.Fatalf("ImportEnvoyRBAC() 返回错误: %v", err)
+	}
+	if imported.GetListType() != types.Blacklist {
+		t.Errorf("ImportEnvoyRBAC() 列表类型 = %v, 期望 blacklist", imported.GetListType())
+	}
+	if !reflect.DeepEqual(imported.GetDomains(), acl.GetDomains()) {
+		t.Errorf("ImportEnvoyRBAC() 域名 = %v, 期望 %v", imported.GetDomains(), acl.GetDomains())
+	}
+
+	whitelist := NewDomainACL([]string{"example.com"}, types.Whitelist, false)
+	buf.Reset()
+	if err := ExportEnvoyRBAC(&buf, whitelist, "allow"); err != nil {
+		t.Fatalf("ExportEnvoyRBAC() 返回错误: %v", err)
+	}
+	imported, err = ImportEnvoyRBAC(&buf)
+	if err != nil {
+		t.Fatalf("ImportEnvoyRBAC() 返回错误: %v", err)
+	}
+	if imported.GetListType() != types.Whitelist {
+		t.Errorf("ImportEnvoyRBAC() 列表类型 = %v, 期望 whitelist", imported.GetListType())
+	}
+	if perm, _ := imported.Check("sub.example.com"); perm != types.Denied {
+		t.Errorf("精确匹配导入后子域名检查 = %v, 期望 denied", perm)
+	}
+
+	if _, err := ImportEnvoyRBAC(strings.NewReader(`{"rules":{"action":"LOG"}}`)); !errors.Is(err, ErrUnsupportedEnvoyConfig) {
+		t.Errorf("ImportEnvoyRBAC(LOG) 错误 = %v, 期望 ErrUnsupportedEnvoyConfig", err)
+	}
+}
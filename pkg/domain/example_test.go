@@ -0,0 +1,112 @@
+package domain_test
+
+import (
+	"fmt"
+
+	"github.com/cyberspacesec/go-acl/pkg/domain"
+	"github.com/cyberspacesec/go-acl/pkg/types"
+)
+
+func ExampleNewDomainACL() {
+	// 白名单，包含子域名
+	whitelist := domain.NewDomainACL([]string{"example.com", "trusted.org"}, types.Whitelist, true)
+	for _, d := range []string{"example.com", "api.trusted.org", "malicious.com"} {
+		perm, _ := whitelist.Check(d)
+		fmt.Println(d, perm)
+	}
+
+	// 黑名单，不包含子域名
+	blacklist := domain.NewDomainACL([]string{"evil.com"}, types.Blacklist, false)
+	for _, d := range []string{"evil.com", "sub.evil.com"} {
+		perm, _ := blacklist.Check(d)
+		fmt.Println(d, perm)
+	}
+	// Output:
+	// example.com allowed
+	// api.trusted.org allowed
+	// malicious.com denied
+	// evil.com denied
+	// sub.evil.com allowed
+}
+
+func ExampleDomainACL_Add() {
+	acl := domain.NewDomainACL(nil, types.Blacklist, true)
+	acl.Add("badsite.com", "malware.net")
+	fmt.Println(acl.GetDomains())
+
+	perm, _ := acl.Check("sub.badsite.com")
+	fmt.Println("sub.badsite.com", perm)
+
+	acl.Remove("badsite.com")
+	perm, _ = acl.Check("sub.badsite.com")
+	fmt.Println("移除后", perm)
+
+	fmt.Println(acl.Remove("notexist.com"))
+	// Output:
+	// [badsite.com malware.net]
+	// sub.badsite.com denied
+	// 移除后 allowed
+	// 域名不在列表中
+}
+
+func ExampleDomainACL_Check() {
+	// 检查前会去掉协议、www前缀、端口和路径并转换为小写
+	acl := domain.NewDomainACL([]string{"example.com"}, types.Blacklist, false)
+	for _, d := range []string{"EXAMPLE.COM", "https://www.example.com/path?q=1", "example.com:8443"} {
+		perm, _ := acl.Check(d)
+		fmt.Println(d, perm)
+	}
+	// Output:
+	// EXAMPLE.COM denied
+	// https://www.example.com/path?q=1 denied
+	// example.com:8443 denied
+}
+
+func ExampleParseRule() {
+	for _, s := range []string{"Example.com", "exact:login.example.com", "suffix:*.CDN.net", "regex:^a[0-9]+\\.b\\.com$"} {
+		rule, err := domain.ParseRule(s)
+		if err != nil {
+			fmt.Println("无效的规则:", err)
+			continue
+		}
+		fmt.Println(rule.Kind, rule.Value)
+	}
+	// Output:
+	// default example.com
+	// exact login.example.com
+	// suffix .cdn.net
+	// regex ^a[0-9]+\.b\.com$
+}
+
+func ExampleDomainACL_CheckNormalized() {
+	ads := domain.NewDomainACL([]string{"ads.example.com"}, types.Blacklist, true)
+	partners := domain.NewDomainACL([]string{"example.com"}, types.Whitelist, true)
+
+	// 同一个域名要经过多个列表检查时只标准化一次
+	name, err := domain.NormalizeDomain("HTTPS://Tracker.Ads.Example.com/pixel.gif")
+	if err != nil {
+		fmt.Println("无效的域名:", err)
+		return
+	}
+	fmt.Println(name)
+	for _, acl := range []*domain.DomainACL{ads, partners} {
+		perm, _ := acl.CheckNormalized(name)
+		fmt.Println(perm)
+	}
+	// Output:
+	// tracker.ads.example.com
+	// denied
+	// allowed
+}
+
+func ExampleDomainACL_AddException() {
+	acl := domain.NewDomainACL([]string{"example.com"}, types.Blacklist, true)
+	acl.AddException("status.example.com")
+	for _, d := range []string{"www2.example.com", "status.example.com"} {
+		perm, _ := acl.Check(d)
+		fmt.Println(d, perm)
+	}
+	// Output:
+	// www2.example.com denied
+	// status.example.com allowed
+}
@@ -0,0 +1,112 @@
+package domain
+
+import "strings"
+
+// AddException 添加一个或多个例外域名
+//
+// 参数:
+//   - domains: 要从列表中排除的域名，会先进行标准化
+//
+// 例外用于从列表中"挖掉"一部分域名：域名被列表匹配、同时又匹配某个例外时，
+// 视为未匹配列表。启用子域名匹配时，例外同样覆盖其子域名。
+// 例外对黑名单和白名单的作用相同：黑名单中的例外被允许，白名单中的例外被拒绝。
+// 节点策略（SetPolicy）优先于列表和例外。
+//
+// 示例:
+//
+//	// 阻止example.com的所有子域名，但放行状态页
+//	acl := domain.NewDomainACL([]string{"example.com"}, types.Blacklist, true)
+//	acl.AddException("status.example.com")
+//
+//	acl.Check("api.example.com")    // Denied
+//	acl.Check("status.example.com") // Allowed
+func (d *DomainACL) AddException(domains ...string) {
+	for _, domain := range domains {
+		normalizedDomain := normalizeDomain(domain)
+		if normalizedDomain == "" {
+			continue
+		}
+
+		exists := false
+		for _, existing := range d.exceptions {
+			if existing == normalizedDomain {
+				exists = true
+				break
+			}
+		}
+		if !exists {
+			d.exceptions = append(d.exceptions, normalizedDomain)
+		}
+	}
+	d.invalidateCache()
+}
+
+// RemoveException 移除一个或多个例外域名
+//
+// 参数:
+//   - domains: 要移除的例外域名
+//
+// 返回:
+//   - error: 如果没有找到任何一个例外，返回ErrDomainNotFound
+func (d *DomainACL) RemoveException(domains ...string) error {
+	var kept []string
+	for _, existing := range d.exceptions {
+		keep := true
+		for _, domain := range domains {
+			if existing == normalizeDomain(domain) {
+				keep = false
+				break
+			}
+		}
+		if keep {
+			kept = append(kept, existing)
+		}
+	}
+
+	if len(kept) == len(d.exceptions) {
+		return ErrDomainNotFound
+	}
+	d.exceptions = kept
+	d.invalidateCache()
+	return nil
+}
+
+// GetExceptions 获取所有例外域名
+//
+// 返回:
+//   - []string: 标准化后的例外域名列表的副本
+func (d *DomainACL) GetExceptions() []string {
+	result := make([]string, len(d.exceptions))
+	copy(result, d.exceptions)
+	return result
+}
+
+// MatchException 返回域名命中的例外
+//
+// 参数:
+//   - domain: 要匹配的域名，会先进行标准化
+//
+// 返回:
+//   - string: 命中的例外，未命中时为空
+//   - bool: 是否命中
+//
+// 与Match一样只用于诊断，不考虑域名是否被列表匹配。
+func (d *DomainACL) MatchException(domain string) (string, bool) {
+	return d.matchException(normalizeDomain(domain))
+}
+
+// matchException 检查已标准化的域名是否命中例外
+func (d *DomainACL) matchException(domain string) (string, bool) {
+	if domain == "" {
+		return "", false
+	}
+	for _, exception := range d.exceptions {
+		if domain == exception {
+			return exception, true
+		}
+		if d.includeSubdomains && strings.HasSuffix(domain, "."+exception) {
+			return exception, true
+		}
+	}
+	return "", false
+}
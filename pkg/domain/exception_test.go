@@ -0,0 +1,86 @@
+package domain
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/cyberspacesec/go-acl/pkg/types"
+)
+
+// TestExceptions 测试例外域名对黑白名单的作用
+func TestExceptions(t *testing.T) {
+	tests := []struct {
+		name              string
+		listType          types.ListType
+		includeSubdomains bool
+		domain            string
+		want              types.Permission
+	}{
+		{"黑名单-列表中的子域名", types.Blacklist, true, "api.example.com", types.Denied},
+		{"黑名单-例外", types.Blacklist, true, "status.example.com", types.Allowed},
+		{"黑名单-例外的子域名", types.Blacklist, true, "eu.status.example.com", types.Allowed},
+		{"黑名单-不含子域名时只排除例外本身", types.Blacklist, false, "status.example.com", types.Allowed},
+		{"白名单-例外被拒绝", types.Whitelist, true, "status.example.com", types.Denied},
+		{"白名单-其他子域名被允许", types.Whitelist, true, "api.example.com", types.Allowed},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			acl := NewDomainACL([]string{"example.com", "status.example.com"}, tt.listType, tt.includeSubdomains)
+			acl.AddException("Status.Example.com")
+			acl.EnableNegativeCache(16)
+
+			// 检查两次，确保否定缓存不改变结果
+			for i := 0; i < 2; i++ {
+				got, err := acl.Check(tt.domain)
+				if err != nil {
+					t.Fatalf("Check() 返回错误: %v", err)
+				}
+				if got != tt.want {
+					t.Errorf("Check(%s) = %v, 期望 %v", tt.domain, got, tt.want)
+				}
+			}
+		})
+	}
+}
+
+// TestExceptionManagement 测试例外的添加、移除和诊断
+func TestExceptionManagement(t *testing.T) {
+	acl := NewDomainACL([]string{"example.com"}, types.Blacklist, true)
+	acl.EnableNegativeCache(16)
+	acl.AddException("status.example.com", "https://www.status.example.com", "")
+
+	if got := acl.GetExceptions(); !reflect.DeepEqual(got, []string{"status.example.com"}) {
+		t.Errorf("GetExceptions() = %v", got)
+	}
+	if exception, ok := acl.MatchException("eu.status.example.com"); !ok || exception != "status.example.com" {
+		t.Errorf("MatchException() = %s, %v", exception, ok)
+	}
+	if got, _ := acl.Check("status.example.com"); got != types.Allowed {
+		t.Errorf("Check(status.example.com) = %v, 期望 Allowed", got)
+	}
+
+	// 移除例外后缓存被清空，域名重新被列表匹配
+	if err := acl.RemoveException("status.example.com"); err != nil {
+		t.Fatalf("RemoveException() 返回错误: %v", err)
+	}
+	if got, _ := acl.Check("status.example.com"); got != types.Denied {
+		t.Errorf("移除例外后 Check(status.example.com) = %v, 期望 Denied", got)
+	}
+	if err := acl.RemoveException("status.example.com"); !errors.Is(err, ErrDomainNotFound) {
+		t.Errorf("移除不存在的例外应返回 ErrDomainNotFound, 实际: %v", err)
+	}
+
+	// 节点策略优先于例外
+	acl.AddException("status.example.com")
+	acl.SetPolicy("status.example.com", types.Denied)
+	if got, _ := acl.Check("status.example.com"); got != types.Denied {
+		t.Errorf("节点策略应优先于例外, Check() = %v", got)
+	}
+
+	restored := NewDomainACLFromSnapshot(acl.Snapshot())
+	if !reflect.DeepEqual(restored.GetExceptions(), acl.GetExceptions()) {
+		t.Errorf("快照恢复的例外 = %v, 期望 %v", restored.GetExceptions(), acl.GetExceptions())
+	}
+}
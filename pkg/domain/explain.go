@@ -0,0 +1,200 @@
+package domain
+
+import (
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/cyberspacesec/go-acl/pkg/types"
+)
+
+// NormalizationStep 记录域名标准化过程中的一次变换
+//
+// 字段说明:
+//   - Step: 步骤名称，如"lowercase"、"strip_scheme"、"strip_userinfo"、
+//     "strip_path"、"strip_port"、"strip_www"、"strip_trailing_dot"
+//   - Result: 该步骤执行后的结果
+type NormalizationStep struct {
+	Step   string
+	Result string
+}
+
+// NormalizeSteps 标准化域名并返回每一个实际改变了输入的步骤
+//
+// 参数:
+//   - domain: 要标准化的域名或URL
+//
+// 返回:
+//   - string: 标准化后的域名，与Check内部使用的结果一致
+//   - []NormalizationStep: 依次执行的变换步骤，输入无需变换时为空
+//
+// 示例:
+//
+//	normalized, steps := domain.NormalizeSteps("HTTPS://WWW.Example.com:443/x")
+//	// normalized == "example.com"
+//	// steps: lowercase, strip_scheme, strip_path, strip_port, strip_www
+func NormalizeSteps(domain string) (string, []NormalizationStep) {
+	var steps []NormalizationStep
+	normalized := normalizeDomainSteps(domain, func(step, result string) {
+		steps = append(steps, NormalizationStep{Step: step, Result: result})
+	})
+	return normalized, steps
+}
+
+// Match 返回域名匹配到的列表条目
+//
+// 参数:
+//   - domain: 要匹配的域名，会先进行标准化
+//
+// 返回:
+//   - string: 匹配到的列表条目，未匹配时为空
+//   - bool: 是否匹配
+//
+// 完全匹配优先于子域名匹配和后缀、正则表达式规则。Match只反映列表本身，不考虑SetPolicy设置的节点策略，
+// 也不根据列表类型给出允许或拒绝的结论，主要用于诊断和Manager.Explain。
+func (d *DomainACL) Match(domain string) (string, bool) {
+	normalized := normalizeDomain(domain)
+	if normalized == "" {
+		return "", false
+	}
+
+	first := ""
+	for _, aclDomain := range d.domains {
+		matched, exact := d.ruleMatches(aclDomain, normalized)
+		if exact {
+			return aclDomain, true
+		}
+		if matched && first == "" {
+			first = aclDomain
+		}
+	}
+	if first == "" && d.matchAny {
+		return AnyDomain, true
+	}
+	return first, first != ""
+}
+
+// MatchAll 返回域名匹配到的所有列表条目
+//
+// 参数:
+//   - domain: 要匹配的域名，会先进行标准化
+//
+// 返回:
+//   - []string: 匹配到的条目，按加入列表的顺序排列，未匹配时为nil
+//
+// 与Match相同，MatchAll只反映列表本身，不考虑节点策略和例外。
+func (d *DomainACL) MatchAll(domain string) []string {
+	normalized := normalizeDomain(domain)
+	if normalized == "" {
+		return nil
+	}
+
+	var matched []string
+	for _, aclDomain := range d.domains {
+		if ok, _ := d.ruleMatches(aclDomain, normalized); ok {
+			matched = append(matched, aclDomain)
+		}
+	}
+	if d.matchAny {
+		matched = append(matched, AnyDomain)
+	}
+	return matched
+}
+
+// MatchPolicy 返回域名命中的最具体的节点策略
+//
+// 参数:
+//   - domain: 要匹配的域名，会先进行标准化
+//
+// 返回:
+//   - string: 命中的策略节点，未命中时为空
+//   - types.Permission: 该节点的策略
+//   - bool: 是否命中
+func (d *DomainACL) MatchPolicy(domain string) (string, types.Permission, bool) {
+	return d.matchPolicy(normalizeDomain(domain))
+}
+
+// IncludesSubdomains 返回列表是否启用了子域名匹配
+func (d *DomainACL) IncludesSubdomains() bool {
+	return d.includeSubdomains
+}
+
+// AnyDomain 是启用SetMatchAnyDomain的列表在Match、MatchAll等结果中报告的规则
+const AnyDomain = "*"
+
+// SetMatchAnyDomain 设置列表是否匹配所有域名
+//
+// 参数:
+//   - enabled: true时所有域名都命中列表（白名单放行一切、黑名单拒绝一切），false恢复按列表中的规则匹配
+//
+// 用于有意不做限制、但仍希望经过ACL记录审计日志的环境。只能通过本方法显式启用，
+// 列表中的"*"条目只是一个普通的域名，不会匹配所有域名。
+// 启用后例外和节点策略仍然生效，命中时Match返回AnyDomain。
+func (d *DomainACL) SetMatchAnyDomain(enabled bool) {
+	d.matchAny = enabled
+	d.invalidateCache()
+}
+
+// MatchesAnyDomain 返回列表是否启用了SetMatchAnyDomain
+func (d *DomainACL) MatchesAnyDomain() bool {
+	return d.matchAny
+}
+
+// CheckDetailed 检查域名是否允许访问，并返回决定结果的规则
+//
+// 参数:
+//   - domain: 要检查的域名
+//
+// 返回:
+//   - types.CheckResult: Kind为"domain"、Source为"domain_acl"的检查结果，RuleID为:
+//   - "policy:节点": 命中SetPolicy设置的节点策略
+//   - "!例外": 匹配了规则但命中例外
+//   - Match返回的规则: 匹配了列表中的规则
+//   - error: 与Check相同的错误
+//
+// 结果与Check相同，RuleID的写法与Manager.Explain的MatchedRule一致。
+func (d *DomainACL) CheckDetailed(domain string) (types.CheckResult, error) {
+	start := time.Now()
+	result := types.CheckResult{Target: domain, Kind: "domain", Source: "domain_acl"}
+
+	var err error
+	result.Decision, err = d.Check(domain)
+	switch {
+	case errors.Is(err, ErrInvalidDomain):
+		result.Reason = types.ReasonInvalidInput
+	case errors.Is(err, types.ErrNoACL):
+		result.Reason = types.ReasonNoACL
+	case err != nil:
+		result.Reason = types.ReasonCheckFailed
+	default:
+		result.RuleID = d.ruleID(normalizeDomain(domain))
+		if result.Decision == types.Denied {
+			result.Reason = d.denyReason(result.RuleID)
+		}
+	}
+	result.Latency = time.Since(start)
+	return result, err
+}
+
+// denyReason 返回拒绝的原因，ruleID为ruleID的结果
+func (d *DomainACL) denyReason(ruleID string) types.Reason {
+	if strings.HasPrefix(ruleID, "policy:") {
+		return types.ReasonDomainPolicy
+	}
+	return types.DenyReason("domain", d.listType)
+}
+
+// ruleID 返回已标准化的域名在Check中命中的规则，见CheckDetailed
+func (d *DomainACL) ruleID(domain string) string {
+	if node, _, ok := d.matchPolicy(domain); ok {
+		return "policy:" + node
+	}
+	rule, matched := d.Match(domain)
+	if !matched {
+		return ""
+	}
+	if exception, ok := d.matchException(domain); ok {
+		return "!" + exception
+	}
+	return rule
+}
@@ -0,0 +1,193 @@
+package domain
+
+import (
+	"bytes"
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/cyberspacesec/go-acl/pkg/types"
+)
+
+// TestNormalizeSteps 测试标准化步骤的记录
+func TestNormalizeSteps(t *testing.T) {
+	tests := []struct {
+		input      string
+		normalized string
+		steps      []string
+	}{
+		{"example.com", "example.com", nil},
+		{"HTTPS://WWW.Example.com:443/x", "example.com", []string{"lowercase", "strip_scheme", "strip_path", "strip_port", "strip_www"}},
+		{"user:pass@site.net", "site.net", []string{"strip_userinfo"}},
+		{"evil.com#@good.com", "evil.com", []string{"strip_path"}},
+		{`evil.com\@good.com`, "evil.com", []string{"strip_path"}},
+		{"a@b@evil.com", "evil.com", []string{"strip_userinfo"}},
+		{"[::1]", "[::1]", nil},
+		{"::ffff:127.0.0.1", "::ffff:127.0.0.1", nil},
+		{"www.example.com.", "example.com", []string{"strip_www", "strip_trailing_dot"}},
+		{"  ", "", []string{"lowercase"}},
+	}
+
+	for _, tt := range tests {
+		normalized, steps := NormalizeSteps(tt.input)
+		if normalized != tt.normalized || normalized != normalizeDomain(tt.input) {
+			t.Errorf("NormalizeSteps(%q) = %q, 期望 %q", tt.input, normalized, tt.normalized)
+		}
+		if len(steps) != len(tt.steps) {
+			t.Fatalf("NormalizeSteps(%q) 步骤 = %+v, 期望 %v", tt.input, steps, tt.steps)
+		}
+		for i, step := range steps {
+			if step.Step != tt.steps[i] {
+				t.Errorf("NormalizeSteps(%q) 第%d步 = %s, 期望 %s", tt.input, i+1, step.Step, tt.steps[i])
+			}
+		}
+	}
+}
+
+// TestDomainACLMatch 测试返回匹配的条目
+func TestDomainACLMatch(t *testing.T) {
+	acl := NewDomainACL([]string{"example.com", "api.example.com"}, types.Blacklist, true)
+
+	tests := []struct {
+		domain  string
+		rule    string
+		matched bool
+	}{
+		{"api.example.com", "api.example.com", true},
+		{"v1.api.example.com", "example.com", true},
+		{"https://www.example.com/", "example.com", true},
+		{"example.org", "", false},
+		{"", "", false},
+	}
+
+	for _, tt := range tests {
+		rule, matched := acl.Match(tt.domain)
+		if rule != tt.rule || matched != tt.matched {
+			t.Errorf("Match(%q) = %q, %v; 期望 %q, %v", tt.domain, rule, matched, tt.rule, tt.matched)
+		}
+	}
+
+	if err := acl.SetPolicy("api.example.com", types.Allowed); err != nil {
+		t.Fatalf("SetPolicy() 返回错误: %v", err)
+	}
+	if node, perm, ok := acl.MatchPolicy("v1.api.example.com"); !ok || node != "api.example.com" || perm != types.Allowed {
+		t.Errorf("MatchPolicy() = %q, %v, %v", node, perm, ok)
+	}
+	if !acl.IncludesSubdomains() {
+		t.Error("IncludesSubdomains() = false, 期望 true")
+	}
+}
+
+// TestDomainACLMatchAll 测试返回所有匹配的条目
+func TestDomainACLMatchAll(t *testing.T) {
+	acl := NewDomainACL([]string{"example.com", "api.example.com", "example.org"}, types.Blacklist, true)
+	acl.AddException("v1.api.example.com")
+
+	tests := []struct {
+		domain string
+		want   []string
+	}{
+		{"v2.api.example.com", []string{"example.com", "api.example.com"}},
+		// 例外不影响MatchAll
+		{"v1.api.example.com", []string{"example.com", "api.example.com"}},
+		{"www.example.org", []string{"example.org"}},
+		{"example.net", nil},
+		{"", nil},
+	}
+
+	for _, tt := range tests {
+		if got := acl.MatchAll(tt.domain); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("MatchAll(%q) = %v; 期望 %v", tt.domain, got, tt.want)
+		}
+	}
+}
+
+// TestDomainACLCheckDetailed 测试详细检查结果中的规则
+func TestDomainACLCheckDetailed(t *testing.T) {
+	acl := NewDomainACL([]string{"example.com", "regex:^ads[0-9]+\\.net$"}, types.Blacklist, true)
+	acl.AddException("status.example.com")
+	if err := acl.SetPolicy("internal.example.com", types.Allowed); err != nil {
+		t.Fatalf("SetPolicy() 返回错误: %v", err)
+	}
+
+	tests := []struct {
+		domain   string
+		decision types.Permission
+		ruleID   string
+	}{
+		{"api.example.com", types.Denied, "example.com"},
+		{"ads12.net", types.Denied, "regex:^ads[0-9]+\\.net$"},
+		{"status.example.com", types.Allowed, "!status.example.com"},
+		{"db.internal.example.com", types.Allowed, "policy:internal.example.com"},
+		{"example.org", types.Allowed, ""},
+	}
+
+	for _, tt := range tests {
+		result, err := acl.CheckDetailed(tt.domain)
+		if err != nil {
+			t.Fatalf("CheckDetailed(%q) 返回错误: %v", tt.domain, err)
+		}
+		if result.Decision != tt.decision || result.RuleID != tt.ruleID {
+			t.Errorf("CheckDetailed(%q) = %v, %q; 期望 %v, %q", tt.domain, result.Decision, result.RuleID, tt.decision, tt.ruleID)
+		}
+		if result.Kind != "domain" || result.Source != "domain_acl" {
+			t.Errorf("CheckDetailed(%q) = %+v", tt.domain, result)
+		}
+	}
+
+	if _, err := acl.CheckDetailed(""); err != ErrInvalidDomain {
+		t.Errorf("CheckDetailed(\"\") 错误 = %v, 期望 ErrInvalidDomain", err)
+	}
+}
+
+// TestSetMatchAnyDomain 测试显式启用后匹配所有域名、例外仍然生效，而列表中的"*"只是普通条目
+func TestSetMatchAnyDomain(t *testing.T) {
+	literal := NewDomainACL([]string{"*"}, types.Whitelist, true)
+	if perm, _ := literal.Check("example.org"); perm != types.Denied {
+		t.Errorf("列表中的\"*\" Check() = %v, 期望不匹配所有域名", perm)
+	}
+
+	for _, engine := range []MatchEngine{nil, NewSuffixEngine()} {
+		acl := NewDomainACL([]string{"example.com"}, types.Whitelist, false)
+		acl.SetMatchEngine(engine)
+		acl.AddException("blocked.example.org")
+		acl.SetMatchAnyDomain(true)
+
+		tests := []struct {
+			domain string
+			want   types.Permission
+		}{
+			{"example.com", types.Allowed},
+			{"anything.example.net", types.Allowed},
+			{"https://Other.ORG/path", types.Allowed},
+			{"blocked.example.org", types.Denied},
+		}
+		for _, tt := range tests {
+			if perm, _ := acl.Check(tt.domain); perm != tt.want {
+				t.Errorf("Check(%q) = %v, 期望 %v（引擎: %v）", tt.domain, perm, tt.want, engine != nil)
+			}
+		}
+		if rule, ok := acl.Match("other.org"); !ok || rule != AnyDomain {
+			t.Errorf("Match() = %q, %v, 期望 AnyDomain", rule, ok)
+		}
+		if rule, _ := acl.Match("example.com"); rule != "example.com" {
+			t.Errorf("Match(\"example.com\") = %q, 期望列表中的规则优先", rule)
+		}
+
+		restored := NewDomainACLFromSnapshot(acl.Snapshot())
+		if !restored.MatchesAnyDomain() {
+			t.Error("快照没有保留SetMatchAnyDomain设置")
+		}
+
+		acl.SetMatchAnyDomain(false)
+		if perm, _ := acl.Check("other.org"); perm != types.Denied {
+			t.Errorf("关闭后 Check() = %v, 期望拒绝（引擎: %v）", perm, engine != nil)
+		}
+	}
+
+	acl := NewDomainACL(nil, types.Blacklist, false)
+	acl.SetMatchAnyDomain(true)
+	if err := ExportSquid(&bytes.Buffer{}, acl); !errors.Is(err, ErrUnsupportedRule) {
+		t.Errorf("ExportSquid() 错误 = %v, 期望 ErrUnsupportedRule", err)
+	}
+}
@@ -0,0 +1,174 @@
+package domain
+
+import (
+	"strings"
+
+	"github.com/cyberspacesec/go-acl/pkg/config"
+	"github.com/cyberspacesec/go-acl/pkg/types"
+)
+
+// NewDomainACLFromFile 从指定文件创建域名访问控制列表
+//
+// 参数:
+//   - filePath: 包含域名列表的文件路径
+//   - listType: 列表类型（黑名单或白名单）
+//   - includeSubdomains: 是否包含子域名匹配
+//
+// 返回:
+//   - *DomainACL: 创建的域名访问控制列表
+//   - error: 可能的错误:
+//   - config.ErrFileNotFound: 文件不存在
+//   - config.ErrEmptyFile: 文件为空或只包含注释
+//   - ErrInvalidDomain: 文件中包含无效的行（如单独的"!"）
+//
+// 文件格式:
+//   - 每行一个域名，注释规则与IP列表文件相同
+//   - "!"开头的行是例外，见AddException
+//   - "*."开头的行与去掉"*."的域名相同，子域名是否匹配由includeSubdomains决定
+//
+// 示例文件内容:
+//
+//	# 阻止example.com的所有子域名
+//	*.example.com
+//	# 但放行状态页
+//	!status.example.com
+//
+// 示例:
+//
+//	acl, err := domain.NewDomainACLFromFile("./blocked_domains.txt", types.Blacklist, true)
+//	if err != nil {
+//	    log.Fatalf("加载域名列表失败: %v", err)
+//	}
+func NewDomainACLFromFile(filePath string, listType types.ListType, includeSubdomains bool) (*DomainACL, error) {
+	acl := &DomainACL{
+		listType:          listType,
+		includeSubdomains: includeSubdomains,
+	}
+	if err := acl.AddFromFile(filePath); err != nil {
+		return nil, err
+	}
+	return acl, nil
+}
+
+// AddFromFile 从文件添加域名和例外到现有的访问控制列表
+//
+// 参数:
+//   - filePath: 包含域名列表的文件路径，格式与NewDomainACLFromFile相同
+//
+// 返回:
+//   - error: 与NewDomainACLFromFile相同的错误，出错时列表保持不变
+func (d *DomainACL) AddFromFile(filePath string) error {
+	lines, err := config.ReadLines(filePath)
+	if err != nil {
+		return err
+	}
+
+	domains, exceptions, err := parseDomainLines(lines)
+	if err != nil {
+		return err
+	}
+	d.Add(domains...)
+	d.AddException(exceptions...)
+	return nil
+}
+
+// SaveToFile 将域名和例外保存到文件
+//
+// 参数:
+//   - filePath: 要保存的文件路径
+//   - form: 域名的书写形式
+//     domain.FormAsIs: 保持添加时的形式
+//     domain.FormASCII: Punycode形式，适合DNS服务器、代理等只接受ASCII的系统
+//     domain.FormUnicode: Unicode形式，便于人工阅读和审核
+//   - overwrite: 是否覆盖已存在的文件
+//
+// 返回:
+//   - error: 可能的错误:
+//   - config.ErrFileExists: 文件已存在且overwrite=false
+//   - config.ErrFilePermission: 无权限写入文件
+//   - ErrInvalidDomain: 某个域名无法转换为指定的书写形式
+//
+// 文件格式与NewDomainACLFromFile相同，例外以"!"开头写在域名之后，因此保存的文件可以直接重新加载。
+// 覆盖已存在的文件时不保留其中的注释，需要保留时使用Save和config.WithPreserveComments(true)。
+//
+// 示例:
+//
+//	acl := domain.NewDomainACL([]string{"例子.com"}, types.Blacklist, true)
+//	err := acl.SaveToFile("./blocked_domains.txt", domain.FormASCII, true)
+//	// 文件内容: xn--fsqu00a.com
+func (d *DomainACL) SaveToFile(filePath string, form Form, overwrite bool) error {
+	return d.Save(filePath, form, config.WithOverwrite(overwrite))
+}
+
+// Save 按选项将域名访问控制列表保存到文件
+//
+// 参数:
+//   - filePath: 要保存的文件路径
+//   - form: 域名的书写形式，见SaveToFile
+//   - opts: 保存选项，见config.SaveOptions；默认使用与SaveToFile相同的标题，可用config.WithHeader替换；
+//     文件头中总是记录列表类型，可以用config.ReadIPACLWithMetadata读取；
+//     覆盖已存在的文件时保留其中的注释和条目顺序需要config.WithPreserveComments(true)
+//
+// 返回:
+//   - error: 可能的错误，见config.SaveLines；某个域名无法转换为指定的书写形式时返回ErrInvalidDomain
+//
+// 示例:
+//
+//	err := acl.Save("./blocked_domains.txt", domain.FormASCII, config.WithOverwrite(true), config.WithAtomic())
+func (d *DomainACL) Save(filePath string, form Form, opts ...config.SaveOption) error {
+	return SaveDomains(filePath, d.domains, d.exceptions, d.listType, form, opts...)
+}
+
+// SaveDomains 按与DomainACL.Save相同的格式保存一组域名和例外
+//
+// 参数:
+//   - filePath: 要保存的文件路径
+//   - domains: 要保存的域名
+//   - exceptions: 要保存的例外，写在域名之后，以"!"开头
+//   - listType: 写入文件头的列表类型，同时决定默认的标题
+//   - form: 域名的书写形式，见SaveToFile
+//   - opts: 保存选项，与DomainACL.Save相同
+//
+// 返回:
+//   - error: 与DomainACL.Save相同
+//
+// 用于保存在锁内复制出的列表内容（如GetDomains和GetExceptions的结果），写文件期间不必持有锁。
+func SaveDomains(filePath string, domains, exceptions []string, listType types.ListType, form Form, opts ...config.SaveOption) error {
+	lines, err := convertAll(domains, form)
+	if err != nil {
+		return err
+	}
+	converted, err := convertAll(exceptions, form)
+	if err != nil {
+		return err
+	}
+	for _, exception := range converted {
+		lines = append(lines, "!"+exception)
+	}
+
+	var header string
+	if listType == types.Blacklist {
+		header = "Domain Blacklist - domains in this list will be denied access"
+	} else {
+		header = "Domain Whitelist - Only domains in this list will be allowed access"
+	}
+	return config.SaveLines(filePath, lines, append([]config.SaveOption{config.WithHeader(header), config.WithListType(listType)}, opts...)...)
+}
+
+// parseDomainLines 将文件中的行分为域名和例外
+func parseDomainLines(lines []string) (domains, exceptions []string, err error) {
+	for _, line := range lines {
+		isException := strings.HasPrefix(line, "!")
+		line = strings.TrimPrefix(strings.TrimSpace(strings.TrimPrefix(line, "!")), "*.")
+		if normalizeDomain(line) == "" {
+			return nil, nil, ErrInvalidDomain
+		}
+
+		if isException {
+			exceptions = append(exceptions, line)
+		} else {
+			domains = append(domains, line)
+		}
+	}
+	return domains, exceptions, nil
+}
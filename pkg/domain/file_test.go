@@ -0,0 +1,82 @@
+package domain
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/cyberspacesec/go-acl/pkg/config"
+	"github.com/cyberspacesec/go-acl/pkg/types"
+)
+
+// writeDomainFile 在临时目录中写入域名文件
+func writeDomainFile(t *testing.T, content string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "domains.txt")
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatalf("写入文件失败: %v", err)
+	}
+	return path
+}
+
+// TestNewDomainACLFromFile 测试从文件加载域名和例外
+func TestNewDomainACLFromFile(t *testing.T) {
+	path := writeDomainFile(t, `# 阻止example.com的所有子域名
+*.example.com
+malware.org   # 恶意域名
+# 但放行状态页
+!status.example.com
+! www.docs.example.com
+`)
+
+	acl, err := NewDomainACLFromFile(path, types.Blacklist, true)
+	if err != nil {
+		t.Fatalf("NewDomainACLFromFile() 返回错误: %v", err)
+	}
+
+	if got := acl.GetDomains(); !reflect.DeepEqual(got, []string{"example.com", "malware.org"}) {
+		t.Errorf("GetDomains() = %v", got)
+	}
+	if got := acl.GetExceptions(); !reflect.DeepEqual(got, []string{"status.example.com", "docs.example.com"}) {
+		t.Errorf("GetExceptions() = %v", got)
+	}
+
+	tests := []struct {
+		domain string
+		want   types.Permission
+	}{
+		{"api.example.com", types.Denied},
+		{"status.example.com", types.Allowed},
+		{"docs.example.com", types.Allowed},
+		{"malware.org", types.Denied},
+		{"other.com", types.Allowed},
+	}
+	for _, tt := range tests {
+		if got, _ := acl.Check(tt.domain); got != tt.want {
+			t.Errorf("Check(%s) = %v, 期望 %v", tt.domain, got, tt.want)
+		}
+	}
+}
+
+// TestDomainFileErrors 测试无效的域名文件
+func TestDomainFileErrors(t *testing.T) {
+	if _, err := NewDomainACLFromFile(filepath.Join(t.TempDir(), "missing.txt"), types.Blacklist, true); !errors.Is(err, config.ErrFileNotFound) {
+		t.Errorf("文件不存在时应返回 ErrFileNotFound, 实际: %v", err)
+	}
+
+	path := writeDomainFile(t, "example.com\n!\n")
+	if _, err := NewDomainACLFromFile(path, types.Blacklist, true); !errors.Is(err, ErrInvalidDomain) {
+		t.Errorf("单独的'!'应返回 ErrInvalidDomain, 实际: %v", err)
+	}
+
+	// AddFromFile出错时列表保持不变
+	acl := NewDomainACL([]string{"a.com"}, types.Blacklist, true)
+	if err := acl.AddFromFile(path); err == nil {
+		t.Error("AddFromFile() 应返回错误")
+	}
+	if got := acl.GetDomains(); !reflect.DeepEqual(got, []string{"a.com"}) {
+		t.Errorf("出错后 GetDomains() = %v", got)
+	}
+}
@@ -0,0 +1,48 @@
+package domain
+
+import (
+	"net/url"
+	"strings"
+	"testing"
+)
+
+// FuzzNormalize 检查从URL中提取主机的结果与net/url一致
+//
+// 主机提取与HTTP客户端不一致是绕过访问控制的常见原因，例如"evil.com#@good.com"。
+// 只比较net/url能够解析、主机名语法有效的输入；百分号编码、"www."前缀和不带方括号的IPv6地址
+// 的处理与net/url不同，不参与比较。
+//
+// 运行: go test ./pkg/domain -run '^$' -fuzz FuzzNormalize
+func FuzzNormalize(f *testing.F) {
+	for _, seed := range []string{
+		"example.com", "Example.COM:8080/path?q=1", "user:pass@site.net", "evil.com#@good.com",
+		"evil.com\\@good.com", "evil.com?@good.com", "a@b@c.com", "[2001:db8::1]:443/", "example.com./",
+		"http://example.com", "//example.com", "xn--fsqu00a.com",
+	} {
+		f.Add(seed)
+	}
+	f.Fuzz(func(t *testing.T, s string) {
+		got := Normalize("http://" + s)
+		// 含空白等非法字符的输入由SetStrictHostnames拒绝，不要求标准化结果稳定
+		if ValidateHostname("http://"+s) == nil && Normalize(got) != got && !strings.HasPrefix(got, "www.") {
+			t.Fatalf("Normalize(%q) = %q 不是幂等的", "http://"+s, got)
+		}
+
+		u, err := url.Parse("http://" + s)
+		if err != nil || strings.Contains(s, "%") {
+			return
+		}
+		host := u.Hostname()
+		if host == "" || host != strings.TrimSpace(host) || strings.HasPrefix(strings.ToLower(host), "www.") || ValidateHostname(host) != nil {
+			return
+		}
+		// 不带方括号的IPv6地址不是有效的URL主机，net/url把最后一个冒号之后的部分当作端口
+		if strings.Contains(host, ":") && !strings.HasPrefix(u.Host, "[") {
+			return
+		}
+		want := strings.TrimSuffix(strings.ToLower(host), ".")
+		if strings.Trim(got, "[]") != want {
+			t.Fatalf("Normalize(%q) = %q, net/url的主机为 %q", "http://"+s, got, want)
+		}
+	})
+}
@@ -0,0 +1,87 @@
+package domain
+
+import (
+	"fmt"
+	"net"
+	"strings"
+	"unicode"
+)
+
+// maxHostnameLength 是DNS名称（不含末尾的点）的最大长度
+const maxHostnameLength = 253
+
+// maxLabelLength 是DNS标签的最大长度
+const maxLabelLength = 63
+
+// ValidateHostname 检查URL或主机名的主机部分是否为语法上有效的DNS名称或IP地址
+//
+// 参数:
+//   - host: 主机名、IP或URL，主机部分按Normalize的规则提取
+//
+// 返回:
+//   - error: 无效时返回包装了ErrInvalidHostname的错误
+//
+// 标准化只负责提取主机部分，不拒绝任何字符，"localhost%00.evil.com"会被当作evil.com的子域名检查，
+// 而实际发出请求的组件可能把它解码为localhost。ValidateHostname在标准化之后要求:
+//   - 不包含控制字符和空白（首尾空白除外）
+//   - 方括号中或包含冒号的主机是有效的IPv6地址（不允许区域标识）
+//   - 否则是RFC 1123的主机名: 总长度不超过253，每个标签1到63个字符，
+//     只包含字母、数字和连字符，且不以连字符开头或结尾；非ASCII标签按Punycode编码后检查
+//
+// IPv4地址（包括十进制、八进制等混淆写法）都满足主机名的语法，由CheckHost按IP处理。
+// 下划线不是有效的主机名字符，会被拒绝。
+//
+// 示例:
+//
+//	domain.ValidateHostname("https://api.example.com/x") // nil
+//	domain.ValidateHostname("http://localhost%00.evil.com/") // ErrInvalidHostname
+func ValidateHostname(host string) error {
+	for _, r := range strings.TrimSpace(host) {
+		if unicode.IsControl(r) || unicode.IsSpace(r) {
+			return fmt.Errorf("%w: %q 包含控制字符或空白", ErrInvalidHostname, host)
+		}
+	}
+
+	name := normalizeDomain(host)
+	if name == "" {
+		return fmt.Errorf("%w: %q 的主机部分为空", ErrInvalidHostname, host)
+	}
+	if strings.HasPrefix(name, "[") || strings.Contains(name, ":") {
+		literal := strings.TrimSuffix(strings.TrimPrefix(name, "["), "]")
+		if net.ParseIP(literal) == nil {
+			return fmt.Errorf("%w: %q 不是有效的IPv6地址", ErrInvalidHostname, name)
+		}
+		return nil
+	}
+
+	ascii, err := ToASCII(name)
+	if err != nil {
+		return fmt.Errorf("%w: %q: %v", ErrInvalidHostname, name, err)
+	}
+	if len(ascii) > maxHostnameLength {
+		return fmt.Errorf("%w: %q 超过%d个字符", ErrInvalidHostname, name, maxHostnameLength)
+	}
+	for _, label := range strings.Split(ascii, ".") {
+		if err := validateLabel(label); err != nil {
+			return fmt.Errorf("%w: %q: %v", ErrInvalidHostname, name, err)
+		}
+	}
+	return nil
+}
+
+// validateLabel 检查一个ASCII标签是否符合RFC 1123
+func validateLabel(label string) error {
+	if label == "" || len(label) > maxLabelLength {
+		return fmt.Errorf("标签长度必须在1到%d之间", maxLabelLength)
+	}
+	if label[0] == '-' || label[len(label)-1] == '-' {
+		return fmt.Errorf("标签 %q 不能以连字符开头或结尾", label)
+	}
+	for i := 0; i < len(label); i++ {
+		c := label[i]
+		if (c < 'a' || c > 'z') && (c < '0' || c > '9') && c != '-' {
+			return fmt.Errorf("标签 %q 包含非法字符 %q", label, c)
+		}
+	}
+	return nil
+}
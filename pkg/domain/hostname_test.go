@@ -0,0 +1,51 @@
+package domain
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+// TestValidateHostname 测试主机名的严格语法校验
+func TestValidateHostname(t *testing.T) {
+	tests := []struct {
+		name    string
+		host    string
+		wantErr bool
+	}{
+		{"普通域名", "api.example.com", false},
+		{"URL", "https://user@API.Example.com:8443/path?q=1", false},
+		{"首尾空白", "  example.com\n", false},
+		{"末尾的点", "example.com.", false},
+		{"IPv4", "http://10.0.0.1/", false},
+		{"混淆的IPv4", "http://0x7f.0.0.1/", false},
+		{"IPv6", "http://[2001:db8::1]:8080/", false},
+		{"不带方括号的IPv6", "::1", false},
+		{"国际化域名", "münchen.de", false},
+		{"百分号编码", "http://localhost%00.evil.com/", true},
+		{"NUL字符", "localhost\x00.evil.com", true},
+		{"内嵌空白", "http://evil .com/", true},
+		{"制表符", "evil\t.com", true},
+		{"下划线", "my_host.example.com", true},
+		{"空标签", "a..example.com", true},
+		{"连字符开头", "-a.example.com", true},
+		{"连字符结尾", "a-.example.com", true},
+		{"标签过长", strings.Repeat("a", 64) + ".com", true},
+		{"名称过长", strings.Repeat("a.", 127) + "com", true},
+		{"IPv6区域标识", "[fe80::1%25eth0]", true},
+		{"无效IPv6", "[2001:db8::g]", true},
+		{"空主机", "http:///path", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := ValidateHostname(tt.host)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ValidateHostname(%q) = %v, 期望错误: %v", tt.host, err, tt.wantErr)
+			}
+			if err != nil && !errors.Is(err, ErrInvalidHostname) {
+				t.Errorf("错误应包装 ErrInvalidHostname: %v", err)
+			}
+		})
+	}
+}
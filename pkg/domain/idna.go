@@ -0,0 +1,415 @@
+package domain
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// acePrefix 是IDNA中表示Punycode编码标签的前缀
+const acePrefix = "xn--"
+
+// Form 表示导出域名时使用的书写形式
+type Form int
+
+const (
+	// FormAsIs 保持添加时的形式（标准化后）
+	FormAsIs Form = iota
+	// FormASCII 使用ASCII兼容形式，非ASCII标签转换为Punycode（"xn--"开头）
+	FormASCII
+	// FormUnicode 使用Unicode显示形式，Punycode标签还原为Unicode
+	FormUnicode
+)
+
+// String 返回书写形式的名称
+func (f Form) String() string {
+	switch f {
+	case FormASCII:
+		return "ascii"
+	case FormUnicode:
+		return "unicode"
+	default:
+		return "as-is"
+	}
+}
+
+// ToASCII 将域名转换为ASCII兼容形式
+//
+// 参数:
+//   - domain: 域名，例如"例子.com"
+//
+// 返回:
+//   - string: 每个非ASCII标签都被编码为Punycode的域名，例如"xn--fsqu00a.com"
+//   - error: 标签无法编码时返回包装了ErrInvalidDomain的错误
+//
+// 只做RFC 3492的Punycode编码和小写转换，不执行完整的IDNA映射（如全角字符折叠）。
+//
+// 示例:
+//
+//	ascii, _ := domain.ToASCII("münchen.de") // "xn--mnchen-3ya.de"
+func ToASCII(domain string) (string, error) {
+	labels := strings.Split(strings.ToLower(domain), ".")
+	for i, label := range labels {
+		if isASCII(label) {
+			continue
+		}
+		encoded, err := punycodeEncode(label)
+		if err != nil {
+			return "", fmt.Errorf("%w: %s: %v", ErrInvalidDomain, domain, err)
+		}
+		labels[i] = acePrefix + encoded
+	}
+	return strings.Join(labels, "."), nil
+}
+
+// ToUnicode 将域名转换为Unicode显示形式
+//
+// 参数:
+//   - domain: 域名，例如"xn--fsqu00a.com"
+//
+// 返回:
+//   - string: 每个"xn--"开头的标签都被解码的域名，例如"例子.com"
+//   - error: 标签不是有效的Punycode时返回包装了ErrInvalidDomain的错误
+//
+// 示例:
+//
+//	unicode, _ := domain.ToUnicode("xn--mnchen-3ya.de") // "münchen.de"
+func ToUnicode(domain string) (string, error) {
+	labels := strings.Split(domain, ".")
+	for i, label := range labels {
+		if len(label) < len(acePrefix) || !strings.EqualFold(label[:len(acePrefix)], acePrefix) {
+			continue
+		}
+		decoded, err := decodeLabel(label[len(acePrefix):])
+		if err != nil {
+			return "", fmt.Errorf("%w: %s: %v", ErrInvalidDomain, domain, err)
+		}
+		labels[i] = strings.ToLower(decoded)
+	}
+	return strings.Join(labels, "."), nil
+}
+
+// decodeLabel 解码Punycode标签并检查结果是否为规范的国际化标签
+//
+// 解码结果必须包含非ASCII字符、只包含可显示字符，且重新编码后与输入相同，
+// 避免同一个Unicode标签对应多种ASCII写法。
+func decodeLabel(encoded string) (string, error) {
+	decoded, err := punycodeDecode(encoded)
+	if err != nil {
+		return "", err
+	}
+	if isASCII(decoded) {
+		return "", errors.New("解码结果不包含非ASCII字符")
+	}
+	for _, r := range decoded {
+		if !unicode.IsGraphic(r) {
+			return "", fmt.Errorf("包含不可显示的字符%U", r)
+		}
+	}
+	if reencoded, err := punycodeEncode(decoded); err != nil || reencoded != strings.ToLower(encoded) {
+		return "", errors.New("不是规范的Punycode编码")
+	}
+	return decoded, nil
+}
+
+// RuleDetail 是域名规则的详细视图
+//
+// 字段说明:
+//   - Domain: 添加时的形式（标准化后）
+//   - ASCII: ASCII兼容形式（Punycode），无法转换时与Domain相同
+//   - Unicode: Unicode显示形式，无法转换时与Domain相同
+//   - Exception: 是否为例外（见AddException）
+type RuleDetail struct {
+	Domain    string `json:"domain"`
+	ASCII     string `json:"ascii"`
+	Unicode   string `json:"unicode"`
+	Exception bool   `json:"exception,omitempty"`
+}
+
+// Details 返回所有域名和例外的详细视图，同时包含ASCII和Unicode两种形式
+//
+// 返回:
+//   - []RuleDetail: 先列出域名，再列出例外，顺序与GetDomains、GetExceptions相同
+//
+// 示例:
+//
+//	acl := domain.NewDomainACL([]string{"例子.com"}, types.Blacklist, true)
+//	for _, r := range acl.Details() {
+//	    fmt.Println(r.Unicode, r.ASCII) // 例子.com xn--fsqu00a.com
+//	}
+func (d *DomainACL) Details() []RuleDetail {
+	details := make([]RuleDetail, 0, len(d.domains)+len(d.exceptions))
+	for _, domain := range d.domains {
+		details = append(details, newRuleDetail(domain, false))
+	}
+	for _, exception := range d.exceptions {
+		details = append(details, newRuleDetail(exception, true))
+	}
+	return details
+}
+
+// DomainsIn 以指定的书写形式返回所有域名
+//
+// 参数:
+//   - form: 书写形式，FormAsIs与GetDomains相同
+//
+// 返回:
+//   - []string: 转换后的域名列表
+//   - error: 某个域名无法转换时返回包装了ErrInvalidDomain的错误
+//
+// 示例:
+//
+//	// 提供给只接受ASCII域名的系统（如DNS服务器、Squid）
+//	domains, err := acl.DomainsIn(domain.FormASCII)
+func (d *DomainACL) DomainsIn(form Form) ([]string, error) {
+	return convertAll(d.domains, form)
+}
+
+// newRuleDetail 创建域名的详细视图，无法转换的形式保留原样
+func newRuleDetail(domain string, exception bool) RuleDetail {
+	detail := RuleDetail{Domain: domain, ASCII: domain, Unicode: domain, Exception: exception}
+	if ascii, err := toForm(domain, FormASCII); err == nil {
+		detail.ASCII = ascii
+	}
+	if unicode, err := toForm(domain, FormUnicode); err == nil {
+		detail.Unicode = unicode
+	}
+	return detail
+}
+
+// convertAll 将域名列表转换为指定的书写形式
+func convertAll(domains []string, form Form) ([]string, error) {
+	result := make([]string, len(domains))
+	for i, domain := range domains {
+		converted, err := toForm(domain, form)
+		if err != nil {
+			return nil, err
+		}
+		result[i] = converted
+	}
+	return result, nil
+}
+
+// toForm 将域名转换为指定的书写形式
+//
+// 带有匹配方式前缀的规则只转换前缀之后的域名，正则表达式规则保持不变。
+func toForm(domain string, form Form) (string, error) {
+	if rule := parseStoredRule(domain); rule.Kind != MatchDefault {
+		if rule.Kind == MatchRegex || form == FormAsIs {
+			return domain, nil
+		}
+		value, dot := rule.Value, ""
+		if rule.Kind == MatchSuffix && strings.HasPrefix(value, ".") {
+			value, dot = value[1:], "."
+		}
+		converted, err := toForm(value, form)
+		if err != nil {
+			return "", err
+		}
+		rule.Value = dot + converted
+		return rule.String(), nil
+	}
+
+	switch form {
+	case FormASCII:
+		return ToASCII(domain)
+	case FormUnicode:
+		return ToUnicode(domain)
+	default:
+		return domain, nil
+	}
+}
+
+// isASCII 判断字符串是否只包含ASCII字符
+func isASCII(s string) bool {
+	for i := 0; i < len(s); i++ {
+		if s[i] >= utf8.RuneSelf {
+			return false
+		}
+	}
+	return true
+}
+
+// Punycode参数，见RFC 3492第5节
+const (
+	punyBase        = 36
+	punyTMin        = 1
+	punyTMax        = 26
+	punySkew        = 38
+	punyDamp        = 700
+	punyInitialBias = 72
+	punyInitialN    = 128
+)
+
+// punycodeEncode 按RFC 3492将标签编码为Punycode（不含"xn--"前缀）
+func punycodeEncode(label string) (string, error) {
+	if !utf8.ValidString(label) {
+		return "", errors.New("无效的UTF-8")
+	}
+	runes := []rune(label)
+
+	var out []byte
+	for _, r := range runes {
+		if r < utf8.RuneSelf {
+			out = append(out, byte(r))
+		}
+	}
+	basic := len(out)
+	handled := basic
+	if basic > 0 {
+		out = append(out, '-')
+	}
+
+	n, delta, bias := rune(punyInitialN), 0, punyInitialBias
+	for handled < len(runes) {
+		m := rune(math.MaxInt32)
+		for _, r := range runes {
+			if r >= n && r < m {
+				m = r
+			}
+		}
+		if int(m-n) > (math.MaxInt32-delta)/(handled+1) {
+			return "", errors.New("编码溢出")
+		}
+		delta += int(m-n) * (handled + 1)
+		n = m
+
+		for _, r := range runes {
+			if r < n {
+				delta++
+			}
+			if r != n {
+				continue
+			}
+			q := delta
+			for k := punyBase; ; k += punyBase {
+				t := punyThreshold(k, bias)
+				if q < t {
+					break
+				}
+				out = append(out, punyDigit(t+(q-t)%(punyBase-t)))
+				q = (q - t) / (punyBase - t)
+			}
+			out = append(out, punyDigit(q))
+			bias = punyAdapt(delta, handled+1, handled == basic)
+			delta = 0
+			handled++
+		}
+		delta++
+		n++
+	}
+	return string(out), nil
+}
+
+// punycodeDecode 按RFC 3492解码Punycode（不含"xn--"前缀）
+func punycodeDecode(encoded string) (string, error) {
+	var output []rune
+	pos := 0
+	if i := strings.LastIndexByte(encoded, '-'); i >= 0 {
+		for j := 0; j < i; j++ {
+			if encoded[j] >= utf8.RuneSelf {
+				return "", errors.New("基本字符部分包含非ASCII字符")
+			}
+			output = append(output, rune(encoded[j]))
+		}
+		pos = i + 1
+	}
+
+	n, i, bias := rune(punyInitialN), 0, punyInitialBias
+	for pos < len(encoded) {
+		oldi, w := i, 1
+		for k := punyBase; ; k += punyBase {
+			if pos == len(encoded) {
+				return "", errors.New("编码被截断")
+			}
+			digit, ok := punyDigitValue(encoded[pos])
+			pos++
+			if !ok {
+				return "", fmt.Errorf("无效的字符%q", encoded[pos-1])
+			}
+			if digit > (math.MaxInt32-i)/w {
+				return "", errors.New("解码溢出")
+			}
+			i += digit * w
+			t := punyThreshold(k, bias)
+			if digit < t {
+				break
+			}
+			if w > math.MaxInt32/(punyBase-t) {
+				return "", errors.New("解码溢出")
+			}
+			w *= punyBase - t
+		}
+
+		count := len(output) + 1
+		bias = punyAdapt(i-oldi, count, oldi == 0)
+		if i/count > utf8.MaxRune-int(n) {
+			return "", errors.New("解码溢出")
+		}
+		n += rune(i / count)
+		i %= count
+		if !utf8.ValidRune(n) {
+			return "", fmt.Errorf("无效的码点%U", n)
+		}
+
+		output = append(output, 0)
+		copy(output[i+1:], output[i:])
+		output[i] = n
+		i++
+	}
+	return string(output), nil
+}
+
+// punyThreshold 返回位置k的阈值t，限制在[tmin, tmax]之间
+func punyThreshold(k, bias int) int {
+	switch {
+	case k <= bias:
+		return punyTMin
+	case k >= bias+punyTMax:
+		return punyTMax
+	default:
+		return k - bias
+	}
+}
+
+// punyAdapt 是RFC 3492第6.1节的偏差调整函数
+func punyAdapt(delta, numPoints int, first bool) int {
+	if first {
+		delta /= punyDamp
+	} else {
+		delta /= 2
+	}
+	delta += delta / numPoints
+
+	k := 0
+	for delta > ((punyBase-punyTMin)*punyTMax)/2 {
+		delta /= punyBase - punyTMin
+		k += punyBase
+	}
+	return k + (punyBase-punyTMin+1)*delta/(delta+punySkew)
+}
+
+// punyDigit 返回数值d对应的Punycode字符
+func punyDigit(d int) byte {
+	if d < 26 {
+		return byte('a' + d)
+	}
+	return byte('0' + d - 26)
+}
+
+// punyDigitValue 返回Punycode字符对应的数值
+func punyDigitValue(c byte) (int, bool) {
+	switch {
+	case c >= 'a' && c <= 'z':
+		return int(c - 'a'), true
+	case c >= 'A' && c <= 'Z':
+		return int(c - 'A'), true
+	case c >= '0' && c <= '9':
+		return int(c-'0') + 26, true
+	default:
+		return 0, false
+	}
+}
@@ -0,0 +1,139 @@
+package domain
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/cyberspacesec/go-acl/pkg/types"
+)
+
+// TestPunycode 测试RFC 3492中的示例和常见域名标签
+func TestPunycode(t *testing.T) {
+	tests := []struct {
+		name    string
+		unicode string
+		encoded string
+	}{
+		{"阿拉伯文", "ليهمابتكلموشعربي؟", "egbpdaj6bu4bxfgehfvwxn"},
+		{"简体中文", "他们为什么不说中文", "ihqwcrb4cv8a8dqg056pqjye"},
+		{"中文域名", "例子", "fsqu00a"},
+		{"混合基本字符", "münchen", "mnchen-3ya"},
+		{"基本字符在后", "bücher", "bcher-kva"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			encoded, err := punycodeEncode(tt.unicode)
+			if err != nil || encoded != tt.encoded {
+				t.Errorf("punycodeEncode(%q) = %q, %v, 期望 %q", tt.unicode, encoded, err, tt.encoded)
+			}
+			decoded, err := punycodeDecode(tt.encoded)
+			if err != nil || decoded != tt.unicode {
+				t.Errorf("punycodeDecode(%q) = %q, %v, 期望 %q", tt.encoded, decoded, err, tt.unicode)
+			}
+		})
+	}
+}
+
+// TestToASCIIAndToUnicode 测试域名在两种书写形式之间的转换
+func TestToASCIIAndToUnicode(t *testing.T) {
+	tests := []struct {
+		name    string
+		unicode string
+		ascii   string
+	}{
+		{"纯ASCII", "example.com", "example.com"},
+		{"中文标签", "例子.com", "xn--fsqu00a.com"},
+		{"多个非ASCII标签", "bücher.münchen.de", "xn--bcher-kva.xn--mnchen-3ya.de"},
+		{"中文顶级域", "例子.中国", "xn--fsqu00a.xn--fiqs8s"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got, err := ToASCII(tt.unicode); err != nil || got != tt.ascii {
+				t.Errorf("ToASCII(%q) = %q, %v, 期望 %q", tt.unicode, got, err, tt.ascii)
+			}
+			if got, err := ToUnicode(tt.ascii); err != nil || got != tt.unicode {
+				t.Errorf("ToUnicode(%q) = %q, %v, 期望 %q", tt.ascii, got, err, tt.unicode)
+			}
+		})
+	}
+
+	for _, invalid := range []string{"xn--a.com", "xn--ab!c.com", "xn--99999999999999999999.com", "xn--abc-.com"} {
+		if _, err := ToUnicode(invalid); !errors.Is(err, ErrInvalidDomain) {
+			t.Errorf("ToUnicode(%q) 错误 = %v, 期望 ErrInvalidDomain", invalid, err)
+		}
+	}
+}
+
+// TestDetails 测试规则详细视图同时包含两种形式
+func TestDetails(t *testing.T) {
+	acl := NewDomainACL([]string{"例子.com", "xn--mnchen-3ya.de"}, types.Blacklist, true)
+	acl.AddException("status.例子.com")
+
+	want := []RuleDetail{
+		{Domain: "例子.com", ASCII: "xn--fsqu00a.com", Unicode: "例子.com"},
+		{Domain: "xn--mnchen-3ya.de", ASCII: "xn--mnchen-3ya.de", Unicode: "münchen.de"},
+		{Domain: "status.例子.com", ASCII: "status.xn--fsqu00a.com", Unicode: "status.例子.com", Exception: true},
+	}
+	if got := acl.Details(); !reflect.DeepEqual(got, want) {
+		t.Errorf("Details() = %+v, 期望 %+v", got, want)
+	}
+
+	domains, err := acl.DomainsIn(FormUnicode)
+	if err != nil {
+		t.Fatalf("DomainsIn() 返回错误: %v", err)
+	}
+	if want := []string{"例子.com", "münchen.de"}; !reflect.DeepEqual(domains, want) {
+		t.Errorf("DomainsIn(FormUnicode) = %v, 期望 %v", domains, want)
+	}
+}
+
+// TestSaveToFile 测试按指定书写形式保存并重新加载
+func TestSaveToFile(t *testing.T) {
+	acl := NewDomainACL([]string{"例子.com"}, types.Blacklist, true)
+	acl.AddException("status.例子.com")
+	dir := t.TempDir()
+
+	tests := []struct {
+		name string
+		form Form
+		want []string
+	}{
+		{"ASCII形式", FormASCII, []string{"xn--fsqu00a.com", "!status.xn--fsqu00a.com"}},
+		{"Unicode形式", FormUnicode, []string{"例子.com", "!status.例子.com"}},
+		{"原样", FormAsIs, []string{"例子.com", "!status.例子.com"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(dir, tt.form.String()+".txt")
+			if err := acl.SaveToFile(path, tt.form, false); err != nil {
+				t.Fatalf("SaveToFile() 返回错误: %v", err)
+			}
+
+			loaded, err := NewDomainACLFromFile(path, types.Blacklist, true)
+			if err != nil {
+				t.Fatalf("NewDomainACLFromFile() 返回错误: %v", err)
+			}
+			got := loaded.GetDomains()
+			for _, e := range loaded.GetExceptions() {
+				got = append(got, "!"+e)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("重新加载的规则 = %v, 期望 %v", got, tt.want)
+			}
+		})
+	}
+
+	path := filepath.Join(dir, "exists.txt")
+	if err := os.WriteFile(path, nil, 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := acl.SaveToFile(path, FormASCII, false); err == nil {
+		t.Error("文件已存在且不允许覆盖时应返回错误")
+	}
+}
@@ -0,0 +1,68 @@
+package domain
+
+import "github.com/cyberspacesec/go-acl/pkg/types"
+
+// NormalizedDomain 是已按Check的规则标准化的域名
+//
+// 只能通过NormalizeDomain得到，零值表示无效的域名。标准化需要去除协议、端口、路径、
+// "www."并转换大小写，是域名检查中开销最大的部分之一；对同一个主机检查多个列表时，
+// 可以只标准化一次，再用CheckNormalized检查每个DomainACL。
+type NormalizedDomain struct {
+	name string
+}
+
+// NormalizeDomain 按Check使用的规则标准化域名或URL
+//
+// 参数:
+//   - domain: 要标准化的域名或URL，如"https://www.Example.COM:8080/path"
+//
+// 返回:
+//   - NormalizedDomain: 标准化后的域名，String()与Normalize(domain)相同
+//     （标准化不是幂等的，如"www.www.example.com"只去除一个"www."，因此不应再把String()的结果传给Check）
+//   - error: 标准化后为空时返回ErrInvalidDomain
+//
+// 示例:
+//
+//	host, err := domain.NormalizeDomain(r.Host)
+//	if err != nil {
+//	    return err
+//	}
+//	perm, _ := blocklist.CheckNormalized(host)
+//	if perm == types.Allowed {
+//	    perm, _ = allowlist.CheckNormalized(host)
+//	}
+func NormalizeDomain(domain string) (NormalizedDomain, error) {
+	name := normalizeDomain(domain)
+	if name == "" {
+		return NormalizedDomain{}, ErrInvalidDomain
+	}
+	return NormalizedDomain{name: name}, nil
+}
+
+// IsZero 判断n是否为零值（不是由NormalizeDomain得到的域名）
+func (n NormalizedDomain) IsZero() bool {
+	return n.name == ""
+}
+
+// String 返回标准化后的域名，如"example.com"，零值返回空字符串
+func (n NormalizedDomain) String() string {
+	return n.name
+}
+
+// CheckNormalized 检查已标准化的域名是否允许访问
+//
+// 参数:
+//   - name: NormalizeDomain返回的域名
+//
+// 返回:
+//   - types.Permission: 与用标准化前的输入调用Check相同的结果
+//   - error: name为零值时返回ErrInvalidDomain；d为nil时返回types.ErrNoACL
+func (d *DomainACL) CheckNormalized(name NormalizedDomain) (types.Permission, error) {
+	if d == nil {
+		return types.Denied, types.ErrNoACL
+	}
+	if name.name == "" {
+		return types.Denied, ErrInvalidDomain
+	}
+	return d.checkNormalized(name.name)
+}
@@ -0,0 +1,85 @@
+package domain
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/cyberspacesec/go-acl/pkg/types"
+)
+
+// TestNormalizedDomain 测试标准化后的域名类型
+func TestNormalizedDomain(t *testing.T) {
+	tests := []struct {
+		input   string
+		want    string
+		wantErr bool
+	}{
+		{"https://www.Example.COM:8080/path", "example.com", false},
+		{"sub.example.org.", "sub.example.org", false},
+		{"www.www.example.com", "www.example.com", false},
+		{"   ", "", true},
+		{"https://", "", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			got, err := NormalizeDomain(tt.input)
+			if tt.wantErr {
+				if !errors.Is(err, ErrInvalidDomain) || !got.IsZero() {
+					t.Errorf("NormalizeDomain() = %q, %v, 期望 ErrInvalidDomain", got, err)
+				}
+				return
+			}
+			if err != nil || got.String() != tt.want || got.String() != Normalize(tt.input) {
+				t.Errorf("NormalizeDomain() = %q, %v, 期望 %q", got, err, tt.want)
+			}
+		})
+	}
+}
+
+// TestCheckNormalized 测试CheckNormalized与Check的结果相同
+func TestCheckNormalized(t *testing.T) {
+	acl := NewDomainACL([]string{"example.com", "exact:api.test.org", "www.example.net"}, types.Blacklist, true)
+	acl.AddException("safe.example.com")
+
+	for _, input := range []string{
+		"example.com", "HTTPS://x.Example.com/p", "safe.example.com", "api.test.org",
+		"v1.api.test.org", "other.org", "www.www.example.net",
+	} {
+		name, err := NormalizeDomain(input)
+		if err != nil {
+			t.Fatalf("NormalizeDomain(%s) 返回错误: %v", input, err)
+		}
+		want, _ := acl.Check(input)
+		if got, err := acl.CheckNormalized(name); err != nil || got != want {
+			t.Errorf("CheckNormalized(%s) = %v, %v, 期望 %v", input, got, err, want)
+		}
+	}
+
+	if _, err := acl.CheckNormalized(NormalizedDomain{}); !errors.Is(err, ErrInvalidDomain) {
+		t.Errorf("CheckNormalized(零值) 错误 = %v, 期望 ErrInvalidDomain", err)
+	}
+	var nilACL *DomainACL
+	if _, err := nilACL.CheckNormalized(NormalizedDomain{name: "example.com"}); !errors.Is(err, types.ErrNoACL) {
+		t.Errorf("nil.CheckNormalized() 错误 = %v, 期望 types.ErrNoACL", err)
+	}
+}
+
+// BenchmarkCheckNormalized 比较每次检查都标准化和只标准化一次的耗时
+func BenchmarkCheckNormalized(b *testing.B) {
+	acl := NewDomainACL([]string{"ads.example.com", "tracker.example.net"}, types.Blacklist, true)
+	acl.SetMatchEngine(NewSuffixEngine())
+	const input = "HTTPS://WWW.Static.Example.ORG:443/app.js"
+
+	b.Run("Check", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			acl.Check(input)
+		}
+	})
+	b.Run("CheckNormalized", func(b *testing.B) {
+		name, _ := NormalizeDomain(input)
+		b.ResetTimer()
+		for i := 0; i < b.N; i++ {
+			acl.CheckNormalized(name)
+		}
+	})
+}
@@ -0,0 +1,100 @@
+package domain
+
+import (
+	"strings"
+
+	"github.com/cyberspacesec/go-acl/pkg/types"
+)
+
+// SetPolicy 在域名树的某个节点上附加访问策略
+//
+// 参数:
+//   - domain: 策略所附加的域名节点
+//     例如: "example.com", "internal.example.com"
+//   - permission: 该节点及其所有子域名的访问结果
+//     可用值: types.Allowed 或 types.Denied
+//
+// 返回:
+//   - error: 如果域名格式无效，返回ErrInvalidDomain
+//
+// 节点策略采用"最具体匹配优先"的语义：检查域名时，从完整域名开始
+// 逐级向上查找父域名，第一个附加了策略的节点决定结果。
+// 只有当没有任何节点策略匹配时，才使用列表类型（黑/白名单）的常规判断。
+//
+// 示例:
+//
+//	acl := domain.NewDomainACL(nil, types.Whitelist, true)
+//	acl.SetPolicy("example.com", types.Allowed)
+//	acl.SetPolicy("internal.example.com", types.Denied)
+//
+//	acl.Check("www.example.com")         // Allowed（匹配example.com）
+//	acl.Check("db.internal.example.com") // Denied（internal.example.com更具体）
+//	acl.Check("other.org")               // Denied（白名单默认拒绝）
+func (d *DomainACL) SetPolicy(domain string, permission types.Permission) error {
+	normalizedDomain := normalizeDomain(domain)
+	if normalizedDomain == "" {
+		return ErrInvalidDomain
+	}
+
+	if d.policies == nil {
+		d.policies = make(map[string]types.Permission)
+	}
+	d.policies[normalizedDomain] = permission
+	return nil
+}
+
+// RemovePolicy 移除域名节点上附加的访问策略
+//
+// 参数:
+//   - domain: 要移除策略的域名节点
+//
+// 返回:
+//   - error: 如果该节点没有附加策略，返回ErrDomainNotFound
+func (d *DomainACL) RemovePolicy(domain string) error {
+	normalizedDomain := normalizeDomain(domain)
+	if _, ok := d.policies[normalizedDomain]; !ok {
+		return ErrDomainNotFound
+	}
+	delete(d.policies, normalizedDomain)
+	return nil
+}
+
+// GetPolicies 获取所有域名节点策略
+//
+// 返回:
+//   - map[string]types.Permission: 节点域名到访问结果的映射副本
+func (d *DomainACL) GetPolicies() map[string]types.Permission {
+	result := make(map[string]types.Permission, len(d.policies))
+	for domain, permission := range d.policies {
+		result[domain] = permission
+	}
+	return result
+}
+
+// matchPolicy 查找与域名最具体匹配的节点策略
+//
+// 参数:
+//   - domain: 已标准化的域名
+//
+// 返回:
+//   - string: 匹配的节点域名
+//   - types.Permission: 节点策略的访问结果
+//   - bool: 是否找到匹配的节点策略
+func (d *DomainACL) matchPolicy(domain string) (string, types.Permission, bool) {
+	if len(d.policies) == 0 {
+		return "", types.Denied, false
+	}
+
+	for node := domain; node != ""; {
+		if permission, ok := d.policies[node]; ok {
+			return node, permission, true
+		}
+
+		dot := strings.Index(node, ".")
+		if dot == -1 {
+			break
+		}
+		node = node[dot+1:]
+	}
+	return "", types.Denied, false
+}
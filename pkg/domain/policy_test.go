@@ -0,0 +1,87 @@
+package domain
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/cyberspacesec/go-acl/pkg/types"
+)
+
+// TestDomainACL_SetPolicy 测试域名树节点策略的最具体匹配语义
+func TestDomainACL_SetPolicy(t *testing.T) {
+	acl := NewDomainACL([]string{"blocked.org"}, types.Blacklist, true)
+
+	if err := acl.SetPolicy("example.com", types.Allowed); err != nil {
+		t.Fatalf("SetPolicy() 返回错误: %v", err)
+	}
+	if err := acl.SetPolicy("internal.example.com", types.Denied); err != nil {
+		t.Fatalf("SetPolicy() 返回错误: %v", err)
+	}
+	if err := acl.SetPolicy("public.internal.example.com", types.Allowed); err != nil {
+		t.Fatalf("SetPolicy() 返回错误: %v", err)
+	}
+
+	tests := []struct {
+		name   string
+		domain string
+		want   types.Permission
+	}{
+		{"节点本身", "example.com", types.Allowed},
+		{"继承父节点策略", "api.example.com", types.Allowed},
+		{"更具体的节点优先", "internal.example.com", types.Denied},
+		{"继承更具体节点的策略", "db.internal.example.com", types.Denied},
+		{"最具体的节点优先", "www2.public.internal.example.com", types.Allowed},
+		{"无节点策略时使用列表类型-命中", "sub.blocked.org", types.Denied},
+		{"无节点策略时使用列表类型-未命中", "other.net", types.Allowed},
+		{"后缀不是节点边界", "notexample.com", types.Allowed},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := acl.Check(tt.domain)
+			if err != nil {
+				t.Fatalf("Check(%q) 返回错误: %v", tt.domain, err)
+			}
+			if got != tt.want {
+				t.Errorf("Check(%q) = %v, 期望 %v", tt.domain, got, tt.want)
+			}
+		})
+	}
+}
+
+// TestDomainACL_PolicyManagement 测试节点策略的增删查
+func TestDomainACL_PolicyManagement(t *testing.T) {
+	acl := NewDomainACL(nil, types.Whitelist, false)
+
+	if err := acl.SetPolicy("", types.Allowed); !errors.Is(err, ErrInvalidDomain) {
+		t.Errorf("SetPolicy(\"\") 错误 = %v, 期望 ErrInvalidDomain", err)
+	}
+
+	if err := acl.SetPolicy("https://www.Example.com/path", types.Allowed); err != nil {
+		t.Fatalf("SetPolicy() 返回错误: %v", err)
+	}
+	policies := acl.GetPolicies()
+	if len(policies) != 1 || policies["example.com"] != types.Allowed {
+		t.Errorf("GetPolicies() = %v, 期望 map[example.com:allowed]", policies)
+	}
+
+	// 修改副本不影响原始策略
+	policies["other.com"] = types.Allowed
+	if len(acl.GetPolicies()) != 1 {
+		t.Error("修改GetPolicies()返回值不应影响原始策略")
+	}
+
+	if perm, _ := acl.Check("a.example.com"); perm != types.Allowed {
+		t.Errorf("Check() = %v, 期望 allowed", perm)
+	}
+
+	if err := acl.RemovePolicy("example.com"); err != nil {
+		t.Errorf("RemovePolicy() 返回错误: %v", err)
+	}
+	if err := acl.RemovePolicy("example.com"); !errors.Is(err, ErrDomainNotFound) {
+		t.Errorf("重复RemovePolicy() 错误 = %v, 期望 ErrDomainNotFound", err)
+	}
+	if perm, _ := acl.Check("a.example.com"); perm != types.Denied {
+		t.Errorf("移除策略后 Check() = %v, 期望 denied", perm)
+	}
+}
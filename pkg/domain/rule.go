@@ -0,0 +1,276 @@
+package domain
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// ErrUnsupportedRule 表示导出格式无法表达某条规则（如正则表达式规则）
+var ErrUnsupportedRule = errors.New("导出格式不支持该规则")
+
+// 规则前缀，见ParseRule
+const (
+	exactPrefix  = "exact:"
+	suffixPrefix = "suffix:"
+	regexPrefix  = "regex:"
+)
+
+// MatchKind 表示规则的匹配方式
+type MatchKind int
+
+const (
+	// MatchDefault 没有前缀的普通域名，是否匹配子域名由列表的includeSubdomains决定
+	MatchDefault MatchKind = iota
+	// MatchExact "exact:"前缀，只匹配完全相同的域名，不受includeSubdomains影响
+	MatchExact
+	// MatchSuffix "suffix:"前缀，按标签边界匹配后缀，不受includeSubdomains影响:
+	// "suffix:cdn.net"匹配cdn.net及其子域名，"suffix:.cdn.net"只匹配子域名
+	MatchSuffix
+	// MatchRegex "regex:"前缀，用Go正则表达式匹配标准化后的域名
+	MatchRegex
+)
+
+// String 返回匹配方式的名称
+func (k MatchKind) String() string {
+	switch k {
+	case MatchExact:
+		return "exact"
+	case MatchSuffix:
+		return "suffix"
+	case MatchRegex:
+		return "regex"
+	default:
+		return "default"
+	}
+}
+
+// Rule 是解析后的域名规则
+//
+// 字段说明:
+//   - Kind: 匹配方式
+//   - Value: 标准化后的域名；MatchSuffix以"."开头时表示只匹配子域名；MatchRegex为正则表达式
+type Rule struct {
+	Kind  MatchKind
+	Value string
+}
+
+// String 返回规则的规范写法，即GetDomains中的形式，如"exact:login.example.com"
+func (r Rule) String() string {
+	switch r.Kind {
+	case MatchExact:
+		return exactPrefix + r.Value
+	case MatchSuffix:
+		return suffixPrefix + r.Value
+	case MatchRegex:
+		return regexPrefix + r.Value
+	default:
+		return r.Value
+	}
+}
+
+// ParseRule 解析带有可选匹配方式前缀的规则
+//
+// 参数:
+//   - rule: 规则字符串，支持的写法:
+//   - "example.com": 普通域名，按列表的includeSubdomains设置匹配
+//   - "exact:login.example.com": 只匹配login.example.com
+//   - "suffix:cdn.net": 匹配cdn.net及其子域名
+//   - "suffix:.cdn.net"或"suffix:*.cdn.net": 只匹配cdn.net的子域名
+//   - "regex:^a[0-9]+\.b\.com$": 正则表达式，匹配标准化后（小写、去掉www.）的域名
+//
+// 返回:
+//   - Rule: 解析后的规则，域名部分已标准化
+//   - error: 域名部分为空或正则表达式无效时返回包装了ErrInvalidDomain的错误
+//
+// 前缀不区分大小写。正则表达式不会被自动锚定，通常应使用^和$匹配整个域名。
+// 同一个列表中可以混用各种写法，因此可以在一个文件中维护匹配方式不同的规则。
+//
+// 示例:
+//
+//	rule, _ := domain.ParseRule("suffix:.CDN.net")
+//	fmt.Println(rule.Kind, rule.Value) // suffix .cdn.net
+func ParseRule(rule string) (Rule, error) {
+	trimmed := strings.TrimSpace(rule)
+	kind, value := splitRulePrefix(trimmed)
+
+	switch kind {
+	case MatchRegex:
+		if value == "" {
+			return Rule{}, ErrInvalidDomain
+		}
+		if _, err := regexp.Compile(value); err != nil {
+			return Rule{}, fmt.Errorf("%w: %s: %v", ErrInvalidDomain, rule, err)
+		}
+		return Rule{Kind: kind, Value: value}, nil
+	case MatchSuffix:
+		subdomainsOnly := strings.HasPrefix(value, ".") || strings.HasPrefix(value, "*.")
+		normalized := normalizeDomain(strings.TrimPrefix(strings.TrimPrefix(value, "*"), "."))
+		if normalized == "" {
+			return Rule{}, ErrInvalidDomain
+		}
+		if subdomainsOnly {
+			normalized = "." + normalized
+		}
+		return Rule{Kind: kind, Value: normalized}, nil
+	default:
+		normalized := normalizeDomain(value)
+		if normalized == "" {
+			return Rule{}, ErrInvalidDomain
+		}
+		return Rule{Kind: kind, Value: normalized}, nil
+	}
+}
+
+// splitRulePrefix 分离规则的匹配方式前缀，前缀不区分大小写
+func splitRulePrefix(rule string) (MatchKind, string) {
+	for _, p := range []struct {
+		prefix string
+		kind   MatchKind
+	}{
+		{exactPrefix, MatchExact},
+		{suffixPrefix, MatchSuffix},
+		{regexPrefix, MatchRegex},
+	} {
+		if len(rule) >= len(p.prefix) && strings.EqualFold(rule[:len(p.prefix)], p.prefix) {
+			return p.kind, strings.TrimSpace(rule[len(p.prefix):])
+		}
+	}
+	return MatchDefault, rule
+}
+
+// parseStoredRule 解析列表中已规范化的规则，不再做标准化
+func parseStoredRule(rule string) Rule {
+	// 普通域名和IPv6地址中的冒号之前不会是前缀，先用冒号快速排除
+	if i := strings.IndexByte(rule, ':'); i < 0 || rule[0] == '[' {
+		return Rule{Kind: MatchDefault, Value: rule}
+	}
+	kind, value := splitRulePrefix(rule)
+	return Rule{Kind: kind, Value: value}
+}
+
+// ruleMatches 判断已标准化的域名是否匹配列表中的规则
+//
+// 返回的exact表示是否为完全相同的匹配，用于Match中完全匹配优先的判断。
+func (d *DomainACL) ruleMatches(rule, domain string) (matched, exact bool) {
+	r := parseStoredRule(rule)
+	return r.matches(domain, d.includeSubdomains, d.regexes[r.Value])
+}
+
+// matches 判断已标准化的域名是否匹配规则，re是MatchRegex规则编译后的正则表达式
+func (r Rule) matches(domain string, includeSubdomains bool, re *regexp.Regexp) (matched, exact bool) {
+	switch r.Kind {
+	case MatchExact:
+		return domain == r.Value, domain == r.Value
+	case MatchSuffix:
+		if strings.HasPrefix(r.Value, ".") {
+			return strings.HasSuffix(domain, r.Value), false
+		}
+		if domain == r.Value {
+			return true, true
+		}
+		return strings.HasSuffix(domain, "."+r.Value), false
+	case MatchRegex:
+		return re != nil && re.MatchString(domain), false
+	default:
+		if domain == r.Value {
+			return true, true
+		}
+		return includeSubdomains && strings.HasSuffix(domain, "."+r.Value), false
+	}
+}
+
+// Matches 判断目标域名是否匹配一条规则，与列表检查使用相同的语义
+//
+// 参数:
+//   - rule: 规则，支持ParseRule中的前缀
+//   - target: 目标域名或URL，会先进行标准化
+//
+// 返回:
+//   - bool: 是否匹配；规则或目标无效时返回false
+//
+// 没有前缀的普通域名规则只做完全匹配，相当于includeSubdomains=false的列表；
+// 需要按某个列表的设置判断子域名时使用DomainACL.WouldMatchSubdomain。
+// 用于外部校验工具和界面中的规则预览，不涉及例外和节点策略。
+//
+// 示例:
+//
+//	domain.Matches("suffix:.cdn.net", "img.cdn.net")  // true
+//	domain.Matches("suffix:.cdn.net", "cdn.net")      // false
+//	domain.Matches("regex:^a[0-9]+\\.b\\.com$", "a1.b.com") // true
+func Matches(rule, target string) bool {
+	r, err := ParseRule(rule)
+	if err != nil {
+		return false
+	}
+	normalized := normalizeDomain(target)
+	if normalized == "" {
+		return false
+	}
+
+	var re *regexp.Regexp
+	if r.Kind == MatchRegex {
+		// ParseRule已经验证过正则表达式
+		re = regexp.MustCompile(r.Value)
+	}
+	matched, _ := r.matches(normalized, false, re)
+	return matched
+}
+
+// WouldMatchSubdomain 判断列表中的规则parent是否会把candidate作为子域名匹配
+//
+// 参数:
+//   - parent: 规则，支持ParseRule中的前缀，不要求已在列表中
+//   - candidate: 候选域名或URL，会先进行标准化
+//
+// 返回:
+//   - bool: candidate是parent所写域名的真子域名，且按本列表的设置会被parent匹配时返回true
+//
+// 普通域名规则取决于列表的includeSubdomains设置，"exact:"规则总是返回false，
+// "suffix:"规则不受列表设置影响。正则表达式规则没有父域名的概念，总是返回false。
+// 与域名相同的候选（包括标准化后去掉www.的情况）不是子域名，返回false。
+// 结果不考虑例外和节点策略。
+//
+// 示例:
+//
+//	acl := domain.NewDomainACL(nil, types.Blacklist, true)
+//	acl.WouldMatchSubdomain("example.com", "api.example.com")       // true
+//	acl.WouldMatchSubdomain("exact:example.com", "api.example.com") // false
+func (d *DomainACL) WouldMatchSubdomain(parent, candidate string) bool {
+	r, err := ParseRule(parent)
+	if err != nil || r.Kind == MatchRegex {
+		return false
+	}
+	normalized := normalizeDomain(candidate)
+	if !strings.HasSuffix(normalized, "."+strings.TrimPrefix(r.Value, ".")) {
+		return false
+	}
+
+	includeSubdomains := false
+	if d != nil {
+		includeSubdomains = d.includeSubdomains
+	}
+	matched, _ := r.matches(normalized, includeSubdomains, nil)
+	return matched
+}
+
+// compileRegexes 为列表中的正则表达式规则编译匹配器
+//
+// 在列表内容改变后调用。Check可能被并发调用，因此不能在匹配时延迟编译。
+func (d *DomainACL) compileRegexes() {
+	var regexes map[string]*regexp.Regexp
+	for _, rule := range d.domains {
+		r := parseStoredRule(rule)
+		if r.Kind != MatchRegex {
+			continue
+		}
+		if regexes == nil {
+			regexes = make(map[string]*regexp.Regexp)
+		}
+		if re, err := regexp.Compile(r.Value); err == nil {
+			regexes[r.Value] = re
+		}
+	}
+	d.regexes = regexes
+}
@@ -0,0 +1,225 @@
+package domain
+
+import (
+	"bytes"
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/cyberspacesec/go-acl/pkg/types"
+)
+
+// TestParseRule 测试带有匹配方式前缀的规则解析
+func TestParseRule(t *testing.T) {
+	tests := []struct {
+		name    string
+		rule    string
+		want    Rule
+		wantErr bool
+	}{
+		{"普通域名", "WWW.Example.com", Rule{MatchDefault, "example.com"}, false},
+		{"完全匹配", "exact:Login.Example.com", Rule{MatchExact, "login.example.com"}, false},
+		{"前缀不区分大小写", "EXACT: login.example.com", Rule{MatchExact, "login.example.com"}, false},
+		{"后缀包含自身", "suffix:cdn.net", Rule{MatchSuffix, "cdn.net"}, false},
+		{"后缀只含子域名", "suffix:.CDN.net", Rule{MatchSuffix, ".cdn.net"}, false},
+		{"通配符后缀", "suffix:*.cdn.net", Rule{MatchSuffix, ".cdn.net"}, false},
+		{"正则表达式", `regex:^a[0-9]+\.b\.com$`, Rule{MatchRegex, `^a[0-9]+\.b\.com$`}, false},
+		{"无效的正则表达式", "regex:a(b", Rule{}, true},
+		{"空的正则表达式", "regex:", Rule{}, true},
+		{"空的后缀", "suffix:.", Rule{}, true},
+		{"空的完全匹配", "exact:", Rule{}, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParseRule(tt.rule)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ParseRule(%q) 错误 = %v, wantErr %v", tt.rule, err, tt.wantErr)
+			}
+			if err != nil && !errors.Is(err, ErrInvalidDomain) {
+				t.Errorf("ParseRule(%q) 错误 = %v, 期望包装 ErrInvalidDomain", tt.rule, err)
+			}
+			if got != tt.want {
+				t.Errorf("ParseRule(%q) = %+v, 期望 %+v", tt.rule, got, tt.want)
+			}
+		})
+	}
+}
+
+// TestPrefixedRules 测试在同一个列表中混用不同匹配方式的规则
+func TestPrefixedRules(t *testing.T) {
+	acl := NewDomainACL([]string{
+		"plain.com",
+		"exact:login.example.com",
+		"suffix:.cdn.net",
+		"suffix:static.org",
+		`regex:^a[0-9]+\.b\.com$`,
+		"regex:a(b", // 无效，被忽略
+	}, types.Blacklist, false)
+
+	wantDomains := []string{"plain.com", "exact:login.example.com", "suffix:.cdn.net", "suffix:static.org", `regex:^a[0-9]+\.b\.com$`}
+	if got := acl.GetDomains(); !reflect.DeepEqual(got, wantDomains) {
+		t.Fatalf("GetDomains() = %v, 期望 %v", got, wantDomains)
+	}
+
+	tests := []struct {
+		domain    string
+		want      types.Permission
+		wantMatch string
+	}{
+		{"plain.com", types.Denied, "plain.com"},
+		{"sub.plain.com", types.Allowed, ""},
+		{"login.example.com", types.Denied, "exact:login.example.com"},
+		{"https://Login.Example.com/path", types.Denied, "exact:login.example.com"},
+		{"x.login.example.com", types.Allowed, ""},
+		{"img.cdn.net", types.Denied, "suffix:.cdn.net"},
+		{"cdn.net", types.Allowed, ""},
+		{"evilcdn.net", types.Allowed, ""},
+		{"static.org", types.Denied, "suffix:static.org"},
+		{"a.b.static.org", types.Denied, "suffix:static.org"},
+		{"a123.b.com", types.Denied, `regex:^a[0-9]+\.b\.com$`},
+		{"A7.B.com", types.Denied, `regex:^a[0-9]+\.b\.com$`},
+		{"ab.b.com", types.Allowed, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.domain, func(t *testing.T) {
+			got, err := acl.Check(tt.domain)
+			if err != nil {
+				t.Fatalf("Check() 返回错误: %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("Check(%q) = %v, 期望 %v", tt.domain, got, tt.want)
+			}
+			if rule, _ := acl.Match(tt.domain); rule != tt.wantMatch {
+				t.Errorf("Match(%q) = %q, 期望 %q", tt.domain, rule, tt.wantMatch)
+			}
+		})
+	}
+
+	// 带前缀的规则需要以相同的前缀移除
+	if err := acl.Remove("login.example.com"); !errors.Is(err, ErrDomainNotFound) {
+		t.Errorf("Remove() 不带前缀时错误 = %v, 期望 ErrDomainNotFound", err)
+	}
+	if err := acl.Remove("exact:login.example.com", `regex:^a[0-9]+\.b\.com$`); err != nil {
+		t.Fatalf("Remove() 返回错误: %v", err)
+	}
+	if perm, _ := acl.Check("a123.b.com"); perm != types.Allowed {
+		t.Errorf("移除正则规则后 Check() = %v, 期望 %v", perm, types.Allowed)
+	}
+
+	// 快照恢复后正则表达式规则仍然生效
+	acl.Add(`regex:^tracker[0-9]*\.`)
+	restored := NewDomainACLFromSnapshot(acl.Snapshot())
+	if perm, _ := restored.Check("tracker42.example.com"); perm != types.Denied {
+		t.Errorf("快照恢复后 Check() = %v, 期望 %v", perm, types.Denied)
+	}
+}
+
+// TestPrefixedRulesExport 测试带前缀的规则在各种导出格式中的表示
+func TestPrefixedRulesExport(t *testing.T) {
+	acl := NewDomainACL([]string{"exact:login.example.com", "suffix:static.org", "suffix:.cdn.net"}, types.Whitelist, false)
+
+	var buf bytes.Buffer
+	if err := ExportEnvoyVirtualHost(&buf, acl, "allowed"); err != nil {
+		t.Fatalf("ExportEnvoyVirtualHost() 返回错误: %v", err)
+	}
+	want := `{"name":"allowed","domains":["login.example.com","static.org","*.static.org","*.cdn.net"]}` + "\n"
+	if buf.String() != want {
+		t.Errorf("ExportEnvoyVirtualHost() = %q, 期望 %q", buf.String(), want)
+	}
+
+	buf.Reset()
+	if err := ExportSquid(&buf, acl); !errors.Is(err, ErrUnsupportedRule) {
+		t.Errorf("ExportSquid() 错误 = %v, 期望 ErrUnsupportedRule（suffix:.cdn.net无法表达）", err)
+	}
+
+	regex := NewDomainACL([]string{`regex:^a\.b$`}, types.Blacklist, false)
+	tests := []struct {
+		name   string
+		export func() error
+	}{
+		{"Squid", func() error { return ExportSquid(&bytes.Buffer{}, regex) }},
+		{"Envoy virtual_host", func() error { return ExportEnvoyVirtualHost(&bytes.Buffer{}, regex, "x") }},
+		{"Envoy RBAC", func() error { return ExportEnvoyRBAC(&bytes.Buffer{}, regex, "x") }},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := tt.export(); !errors.Is(err, ErrUnsupportedRule) {
+				t.Errorf("导出正则表达式规则的错误 = %v, 期望 ErrUnsupportedRule", err)
+			}
+		})
+	}
+
+	idn := NewDomainACL([]string{"exact:例子.com", "suffix:.münchen.de", `regex:^例`}, types.Blacklist, false)
+	ascii, err := idn.DomainsIn(FormASCII)
+	if err != nil {
+		t.Fatalf("DomainsIn() 返回错误: %v", err)
+	}
+	wantASCII := []string{"exact:xn--fsqu00a.com", "suffix:.xn--mnchen-3ya.de", `regex:^例`}
+	if !reflect.DeepEqual(ascii, wantASCII) {
+		t.Errorf("DomainsIn(FormASCII) = %v, 期望 %v", ascii, wantASCII)
+	}
+}
+
+// TestMatches 测试单条规则的匹配语义
+func TestMatches(t *testing.T) {
+	tests := []struct {
+		name   string
+		rule   string
+		target string
+		want   bool
+	}{
+		{"普通域名完全匹配", "Example.com", "https://www.example.com/path", true},
+		{"普通域名不匹配子域名", "example.com", "api.example.com", false},
+		{"exact规则", "exact:login.example.com", "login.example.com", true},
+		{"suffix规则匹配自身", "suffix:cdn.net", "cdn.net", true},
+		{"suffix规则匹配子域名", "suffix:cdn.net", "img.cdn.net", true},
+		{"只匹配子域名的suffix规则", "suffix:.cdn.net", "cdn.net", false},
+		{"suffix规则按标签边界匹配", "suffix:cdn.net", "evilcdn.net", false},
+		{"正则表达式", `regex:^a[0-9]+\.b\.com$`, "a12.b.com", true},
+		{"无效规则", "regex:(", "a.com", false},
+		{"无效目标", "example.com", "", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Matches(tt.rule, tt.target); got != tt.want {
+				t.Errorf("Matches(%q, %q) = %v, 期望 %v", tt.rule, tt.target, got, tt.want)
+			}
+		})
+	}
+}
+
+// TestWouldMatchSubdomain 测试按列表设置判断子域名匹配
+func TestWouldMatchSubdomain(t *testing.T) {
+	withSubdomains := NewDomainACL(nil, types.Blacklist, true)
+	exactOnly := NewDomainACL(nil, types.Blacklist, false)
+
+	tests := []struct {
+		name      string
+		acl       *DomainACL
+		parent    string
+		candidate string
+		want      bool
+	}{
+		{"启用子域名匹配", withSubdomains, "example.com", "api.example.com", true},
+		{"多级子域名", withSubdomains, "example.com", "a.b.example.com", true},
+		{"未启用子域名匹配", exactOnly, "example.com", "api.example.com", false},
+		{"相同域名不是子域名", withSubdomains, "example.com", "example.com", false},
+		{"www前缀标准化后相同", withSubdomains, "example.com", "www.example.com", false},
+		{"不在父域名下", withSubdomains, "example.com", "notexample.com", false},
+		{"exact规则", withSubdomains, "exact:example.com", "api.example.com", false},
+		{"suffix规则不受列表设置影响", exactOnly, "suffix:cdn.net", "img.cdn.net", true},
+		{"只匹配子域名的suffix规则", exactOnly, "suffix:.cdn.net", "img.cdn.net", true},
+		{"正则表达式规则", withSubdomains, `regex:.*\.example\.com$`, "api.example.com", false},
+		{"nil列表", nil, "example.com", "api.example.com", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.acl.WouldMatchSubdomain(tt.parent, tt.candidate); got != tt.want {
+				t.Errorf("WouldMatchSubdomain(%q, %q) = %v, 期望 %v", tt.parent, tt.candidate, got, tt.want)
+			}
+		})
+	}
+}
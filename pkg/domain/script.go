@@ -0,0 +1,166 @@
+package domain
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"unicode"
+)
+
+// ErrMixedScript 表示域名的标签混用了多种文字（如拉丁字母中夹杂西里尔字母"а"），
+// 常见于同形异义字（homograph）攻击
+var ErrMixedScript = errors.New("域名标签混用了多种文字")
+
+// allowedScriptSets 是允许在同一个标签中组合使用的文字，
+// 与Unicode技术标准#39（UTS #39）的"Highly Restrictive"级别相同
+var allowedScriptSets = [][]string{
+	{"Latin", "Han", "Hiragana", "Katakana"}, // 日文
+	{"Latin", "Han", "Bopomofo"},             // 中文注音
+	{"Latin", "Han", "Hangul"},               // 韩文
+}
+
+// LabelScripts 返回标签使用的文字
+//
+// 参数:
+//   - label: 域名标签，可以是Unicode形式或"xn--"开头的Punycode形式
+//
+// 返回:
+//   - []string: 按名称排序的Unicode文字名称，如[]string{"Cyrillic", "Latin"}；
+//     数字、连字符等通用字符（Common）和组合符号（Inherited）不计入
+//
+// 示例:
+//
+//	domain.LabelScripts("pаypal") // []string{"Cyrillic", "Latin"}，其中的"а"是西里尔字母
+func LabelScripts(label string) []string {
+	label = unicodeLabel(label)
+
+	seen := make(map[string]struct{})
+	for _, r := range label {
+		if script := runeScript(r); script != "" {
+			seen[script] = struct{}{}
+		}
+	}
+	scripts := make([]string, 0, len(seen))
+	for script := range seen {
+		scripts = append(scripts, script)
+	}
+	sort.Strings(scripts)
+	return scripts
+}
+
+// CheckMixedScript 检查域名的每个标签是否只使用一种文字
+//
+// 参数:
+//   - host: 域名、主机名或URL，主机部分按Normalize的规则提取，Punycode标签先解码
+//   - allowedZones: 合法使用多种文字的区域，区域本身及其子域名不检查
+//
+// 返回:
+//   - error: 某个标签混用了多种文字时返回包装了ErrMixedScript的错误，否则返回nil
+//
+// 日文、中文注音和韩文与拉丁字母、汉字的组合是常规写法，不视为混用（UTS #39的"Highly Restrictive"）。
+// 检查以标签为单位，"пример.com"这样不同标签使用不同文字的域名不受影响。
+// 无法解码的Punycode标签按ASCII处理，由Check等方法按无效域名处理。
+//
+// 示例:
+//
+//	domain.CheckMixedScript("pаypal.com")                // ErrMixedScript
+//	domain.CheckMixedScript("xn--e1afmkfd.com")          // nil，"пример"只使用西里尔字母
+//	domain.CheckMixedScript("pаypal.example", "example") // nil，区域在允许列表中
+func CheckMixedScript(host string, allowedZones ...string) error {
+	name := normalizeDomain(host)
+	if name == "" {
+		return nil
+	}
+	labels := strings.Split(name, ".")
+	for i := range labels {
+		labels[i] = unicodeLabel(labels[i])
+	}
+	if inZones(labels, allowedZones) {
+		return nil
+	}
+
+	for _, label := range labels {
+		if scripts := LabelScripts(label); !allowedScripts(scripts) {
+			return fmt.Errorf("%w: 标签 %q 使用了%s", ErrMixedScript, label, strings.Join(scripts, "、"))
+		}
+	}
+	return nil
+}
+
+// unicodeLabel 返回标签的Unicode形式，不是有效的Punycode标签时原样返回
+func unicodeLabel(label string) string {
+	if len(label) < len(acePrefix) || !strings.EqualFold(label[:len(acePrefix)], acePrefix) {
+		return label
+	}
+	decoded, err := decodeLabel(label[len(acePrefix):])
+	if err != nil {
+		return label
+	}
+	return strings.ToLower(decoded)
+}
+
+// runeScript 返回字符所属的文字，通用字符和组合符号返回空字符串
+func runeScript(r rune) string {
+	switch {
+	case r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z':
+		return "Latin"
+	case r < 0x80:
+		return ""
+	}
+	for name, table := range unicode.Scripts {
+		if name == "Common" || name == "Inherited" {
+			continue
+		}
+		if unicode.Is(table, r) {
+			return name
+		}
+	}
+	return ""
+}
+
+// allowedScripts 判断一组文字能否出现在同一个标签中
+func allowedScripts(scripts []string) bool {
+	if len(scripts) <= 1 {
+		return true
+	}
+	for _, set := range allowedScriptSets {
+		if containsAll(set, scripts) {
+			return true
+		}
+	}
+	return false
+}
+
+// containsAll 判断set是否包含values中的所有元素
+func containsAll(set, values []string) bool {
+	for _, v := range values {
+		found := false
+		for _, s := range set {
+			if s == v {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+	return true
+}
+
+// inZones 判断Unicode形式的标签组成的域名是否属于某个区域
+func inZones(labels []string, zones []string) bool {
+	name := strings.Join(labels, ".")
+	for _, zone := range zones {
+		zoneLabels := strings.Split(normalizeDomain(zone), ".")
+		for i := range zoneLabels {
+			zoneLabels[i] = unicodeLabel(zoneLabels[i])
+		}
+		z := strings.Join(zoneLabels, ".")
+		if z != "" && (name == z || strings.HasSuffix(name, "."+z)) {
+			return true
+		}
+	}
+	return false
+}
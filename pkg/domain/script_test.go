@@ -0,0 +1,61 @@
+package domain
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+)
+
+// TestLabelScripts 测试识别标签使用的文字
+func TestLabelScripts(t *testing.T) {
+	tests := []struct {
+		label string
+		want  []string
+	}{
+		{"paypal", []string{"Latin"}},
+		{"pаypal", []string{"Cyrillic", "Latin"}},
+		{"xn--pypal-4ve", []string{"Cyrillic", "Latin"}},
+		{"пример", []string{"Cyrillic"}},
+		{"123-456", []string{}},
+		{"例子", []string{"Han"}},
+	}
+
+	for _, tt := range tests {
+		if got := LabelScripts(tt.label); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("LabelScripts(%q) = %v, 期望 %v", tt.label, got, tt.want)
+		}
+	}
+}
+
+// TestCheckMixedScript 测试混用文字的域名检查
+func TestCheckMixedScript(t *testing.T) {
+	tests := []struct {
+		name  string
+		host  string
+		zones []string
+		want  error
+	}{
+		{"纯拉丁字母", "paypal.com", nil, nil},
+		{"拉丁字母中夹杂西里尔字母", "pаypal.com", nil, ErrMixedScript},
+		{"Punycode形式", "https://xn--pypal-4ve.com/login", nil, ErrMixedScript},
+		{"希腊字母", "gοogle.com", nil, ErrMixedScript},
+		{"不同标签使用不同文字", "пример.com", nil, nil},
+		{"日文的常规组合", "例えばテスト.jp", nil, nil},
+		{"韩文与拉丁字母", "abc한국.kr", nil, nil},
+		{"西里尔字母与汉字", "例子пр.com", nil, ErrMixedScript},
+		{"带数字和连字符", "shop-24.пример.рф", nil, nil},
+		{"允许的区域", "pаypal.example.org", []string{"example.org"}, nil},
+		{"允许的Punycode区域", "pаypal.xn--e1afmkfd", []string{"пример"}, nil},
+		{"区域外", "pаypal.example.net", []string{"example.org"}, ErrMixedScript},
+		{"空输入", "", nil, nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := CheckMixedScript(tt.host, tt.zones...)
+			if !errors.Is(err, tt.want) || (tt.want == nil && err != nil) {
+				t.Errorf("CheckMixedScript(%q) = %v, 期望 %v", tt.host, err, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,57 @@
+package domain
+
+import "github.com/cyberspacesec/go-acl/pkg/types"
+
+// DomainACLSnapshot 是域名访问控制列表的可序列化快照
+//
+// 快照中的域名已经过标准化和去重，恢复时直接使用，
+// 不必像NewDomainACL那样逐个标准化并检查重复。
+type DomainACLSnapshot struct {
+	ListType          types.ListType
+	IncludeSubdomains bool
+	Domains           []string
+	Exceptions        []string
+	Policies          map[string]types.Permission
+	// MatchAnyDomain 是SetMatchAnyDomain的设置
+	MatchAnyDomain bool
+}
+
+// Snapshot 返回域名访问控制列表的快照
+//
+// 返回:
+//   - DomainACLSnapshot: 列表类型、子域名设置、域名列表、例外、节点策略和SetMatchAnyDomain设置的副本
+func (d *DomainACL) Snapshot() DomainACLSnapshot {
+	return DomainACLSnapshot{
+		ListType:          d.listType,
+		IncludeSubdomains: d.includeSubdomains,
+		Domains:           d.GetDomains(),
+		Exceptions:        d.GetExceptions(),
+		Policies:          d.GetPolicies(),
+		MatchAnyDomain:    d.matchAny,
+	}
+}
+
+// NewDomainACLFromSnapshot 从快照恢复域名访问控制列表
+//
+// 参数:
+//   - s: 由Snapshot生成的快照
+//
+// 返回:
+//   - *DomainACL: 恢复的域名访问控制列表
+func NewDomainACLFromSnapshot(s DomainACLSnapshot) *DomainACL {
+	acl := &DomainACL{
+		domains:           append([]string(nil), s.Domains...),
+		exceptions:        append([]string(nil), s.Exceptions...),
+		listType:          s.ListType,
+		includeSubdomains: s.IncludeSubdomains,
+		matchAny:          s.MatchAnyDomain,
+	}
+	acl.compileRegexes()
+	if len(s.Policies) > 0 {
+		acl.policies = make(map[string]types.Permission, len(s.Policies))
+		for node, perm := range s.Policies {
+			acl.policies[node] = perm
+		}
+	}
+	return acl
+}
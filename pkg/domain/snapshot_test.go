@@ -0,0 +1,36 @@
+package domain
+
+import (
+	"testing"
+
+	"github.com/cyberspacesec/go-acl/pkg/types"
+)
+
+// TestSnapshotRoundTrip 测试快照的生成和恢复
+func TestSnapshotRoundTrip(t *testing.T) {
+	original := NewDomainACL([]string{"Example.com", "www.test.org"}, types.Whitelist, true)
+	if err := original.SetPolicy("internal.example.com", types.Denied); err != nil {
+		t.Fatalf("SetPolicy() 返回错误: %v", err)
+	}
+
+	restored := NewDomainACLFromSnapshot(original.Snapshot())
+
+	if restored.GetListType() != types.Whitelist {
+		t.Errorf("列表类型 = %v, 期望 Whitelist", restored.GetListType())
+	}
+	tests := []string{"example.com", "api.example.com", "db.internal.example.com", "test.org", "other.net"}
+	for _, d := range tests {
+		p1, _ := original.Check(d)
+		p2, _ := restored.Check(d)
+		if p1 != p2 {
+			t.Errorf("Check(%s) = %v, 原始列表为 %v", d, p2, p1)
+		}
+	}
+
+	// 恢复的列表与快照互不影响
+	s := original.Snapshot()
+	s.Domains[0] = "changed.com"
+	if original.GetDomains()[0] == "changed.com" {
+		t.Error("修改快照不应影响原始列表")
+	}
+}
@@ -0,0 +1,109 @@
+package domain
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/cyberspacesec/go-acl/pkg/types"
+)
+
+// ExportSquid 将域名访问控制列表导出为Squid dstdomain ACL文件格式
+//
+// 参数:
+//   - w: 输出目标
+//   - acl: 要导出的域名访问控制列表
+//
+// 返回:
+//   - error: 写入过程中的错误，或包装了ErrUnsupportedRule的错误
+//
+// 输出格式为每行一个域名，可直接被Squid通过 acl name dstdomain "/path/file" 引用。
+// 当acl启用了子域名匹配时，每个域名带有前导"."（Squid中表示域名本身及其所有子域名）。
+// 列表类型（黑/白名单）不属于dstdomain文件的一部分，需在squid.conf的
+// http_access allow/deny 规则中体现，因此仅以注释形式写在文件头部。
+// "exact:"规则导出为不带"."的域名，"suffix:域名"导出为带"."的域名；
+// 正则表达式规则、"suffix:.域名"和SetMatchAnyDomain无法用dstdomain表达，此时返回ErrUnsupportedRule。
+//
+// 示例:
+//
+//	acl := domain.NewDomainACL([]string{"ads.example.com"}, types.Blacklist, true)
+//	var buf bytes.Buffer
+//	if err := domain.ExportSquid(&buf, acl); err != nil {
+//	    log.Fatal(err)
+//	}
+//	// 输出:
+//	// # go-acl domain blacklist
+//	// .ads.example.com
+func ExportSquid(w io.Writer, acl *DomainACL) error {
+	if acl.matchAny {
+		return fmt.Errorf("%w: %s", ErrUnsupportedRule, AnyDomain)
+	}
+	writer := bufio.NewWriter(w)
+
+	if _, err := writer.WriteString("# go-acl domain " + acl.listType.String() + "\n"); err != nil {
+		return err
+	}
+
+	for _, domain := range acl.domains {
+		rule := parseStoredRule(domain)
+		switch {
+		case rule.Kind == MatchExact:
+			domain = rule.Value
+		case rule.Kind == MatchSuffix && !strings.HasPrefix(rule.Value, "."):
+			domain = "." + rule.Value
+		case rule.Kind != MatchDefault:
+			// dstdomain无法表达正则表达式和只匹配子域名的规则
+			return fmt.Errorf("%w: %s", ErrUnsupportedRule, domain)
+		case acl.includeSubdomains:
+			domain = "." + domain
+		}
+		if _, err := writer.WriteString(domain + "\n"); err != nil {
+			return err
+		}
+	}
+
+	return writer.Flush()
+}
+
+// ImportSquid 从Squid dstdomain ACL文件内容创建域名访问控制列表
+//
+// 参数:
+//   - r: Squid dstdomain文件内容
+//   - listType: 列表类型（黑名单或白名单）
+//
+// 返回:
+//   - *DomainACL: 创建的域名访问控制列表
+//   - error: 读取过程中的错误
+//
+// 解析规则:
+//   - #开头的行和空行被忽略
+//   - 带前导"."的条目（如".example.com"）表示包含子域名
+//   - 只要存在一个带前导"."的条目，整个列表就启用子域名匹配
+//
+// 注意: DomainACL的子域名匹配是整个列表共享的设置，因此当文件混合了
+// 精确匹配和子域名匹配条目时，精确匹配的条目在导入后也会匹配其子域名。
+// 导入结果适合作为迁移的起点，导入后应检查并按需调整。
+func ImportSquid(r io.Reader, listType types.ListType) (*DomainACL, error) {
+	var domains []string
+	includeSubdomains := false
+
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		if strings.HasPrefix(line, ".") {
+			includeSubdomains = true
+			line = strings.TrimPrefix(line, ".")
+		}
+		domains = append(domains, line)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	return NewDomainACL(domains, listType, includeSubdomains), nil
+}
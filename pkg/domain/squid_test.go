@@ -0,0 +1,49 @@
+package domain
+
+import (
+	"bytes"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/cyberspacesec/go-acl/pkg/types"
+)
+
+// TestExportImportSquid 测试Squid dstdomain格式的导入导出
+func TestExportImportSquid(t *testing.T) {
+	acl := NewDomainACL([]string{"ads.example.com", "tracker.net"}, types.Blacklist, true)
+
+	var buf bytes.Buffer
+	if err := ExportSquid(&buf, acl); err != nil {
+		t.Fatalf("ExportSquid() 返回错误: %v", err)
+	}
+	want := "# go-acl domain blacklist\n.ads.example.com\n.tracker.net\n"
+	if buf.String() != want {
+		t.Errorf("ExportSquid() 输出 = %q, 期望 %q", buf.String(), want)
+	}
+
+	imported, err := ImportSquid(&buf, types.Blacklist)
+	if err != nil {
+		t.Fatalf("ImportSquid() 返回错误: %v", err)
+	}
+	if !reflect.DeepEqual(imported.GetDomains(), acl.GetDomains()) {
+		t.Errorf("ImportSquid() 域名 = %v, 期望 %v", imported.GetDomains(), acl.GetDomains())
+	}
+	if perm, _ := imported.Check("x.ads.example.com"); perm != types.Denied {
+		t.Errorf("导入后子域名检查 = %v, 期望 denied", perm)
+	}
+
+	// 精确匹配条目
+	exact := NewDomainACL([]string{"example.com"}, types.Whitelist, false)
+	buf.Reset()
+	if err := ExportSquid(&buf, exact); err != nil {
+		t.Fatalf("ExportSquid() 返回错误: %v", err)
+	}
+	imported, err = ImportSquid(strings.NewReader(buf.String()), types.Whitelist)
+	if err != nil {
+		t.Fatalf("ImportSquid() 返回错误: %v", err)
+	}
+	if perm, _ := imported.Check("sub.example.com"); perm != types.Denied {
+		t.Errorf("精确匹配导入后子域名检查 = %v, 期望 denied", perm)
+	}
+}
@@ -0,0 +1,45 @@
+package expr_test
+
+import (
+	"fmt"
+
+	"github.com/cyberspacesec/go-acl/pkg/expr"
+)
+
+func ExampleCompile() {
+	rule, err := expr.Compile("ip in private_networks && port != 443 -> deny")
+	if err != nil {
+		fmt.Println("编译失败:", err)
+		return
+	}
+	fmt.Println(rule.Source)
+
+	_, err = expr.Compile("port == -> deny")
+	fmt.Println(err != nil)
+	// Output:
+	// ip in private_networks && port != 443 -> deny
+	// true
+}
+
+func ExampleRuleSet_Evaluate() {
+	rules, _ := expr.CompileAll([]string{
+		"domain in [example.com] -> allow",
+		"port < 1024 && !(ip in 10.0.0.0/8) -> deny",
+	})
+	for _, req := range []expr.Request{
+		{IP: "198.51.100.1", Port: 22},
+		{IP: "198.51.100.1", Port: 22, Domain: "example.com"},
+		{IP: "198.51.100.1", Port: 8080},
+	} {
+		perm, rule, matched := rules.Evaluate(req)
+		if !matched {
+			fmt.Println(req, "没有匹配的规则")
+			continue
+		}
+		fmt.Println(req, perm, "<-", rule.Source)
+	}
+	// Output:
+	// ip=198.51.100.1 port=22 denied <- port < 1024 && !(ip in 10.0.0.0/8) -> deny
+	// ip=198.51.100.1 domain=example.com port=22 allowed <- domain in [example.com] -> allow
+	// ip=198.51.100.1 port=8080 没有匹配的规则
+}
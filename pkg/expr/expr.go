@@ -0,0 +1,248 @@
+// Package expr 提供一个小型的策略表达式语言
+//
+// 表达式规则可以从配置文件加载，编译一次后对每个请求求值，
+// 让高级用户无需编写Go代码即可表达条件逻辑。
+//
+// 规则格式:
+//
+//	<条件> -> allow|deny|log
+//
+// 动作说明:
+//   - allow/deny: 条件满足时允许/拒绝，后续规则不再求值
+//   - allow log/deny log: 记录后再允许/拒绝
+//   - log: 只记录，不决定结果，继续求值后续规则和ACL。
+//     适合在正式拦截之前，先观察可疑范围的流量
+//
+// 条件支持的字段:
+//   - ip: 请求的IP地址，支持 in（IP/CIDR/预定义集合名称或列表）、==、!=
+//   - domain: 请求的域名，支持 in（域名本身及其子域名）、==、!=（完全匹配）
+//   - port: 请求的端口，支持 in（端口列表）、==、!=、<、<=、>、>=
+//
+// 条件可以使用 &&、||、! 和括号组合。
+//
+// 示例:
+//
+//	ip in private_networks && port != 443 -> deny
+//	domain in [example.com, "example.org"] -> allow
+//	!(ip in 10.0.0.0/8) && port < 1024 -> deny
+//	ip in 198.51.100.0/24 -> log
+package expr
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/cyberspacesec/go-acl/pkg/config"
+	"github.com/cyberspacesec/go-acl/pkg/types"
+)
+
+// ErrSyntax 表示规则表达式存在语法错误
+var ErrSyntax = errors.New("规则表达式语法错误")
+
+// Request 表示规则求值时的请求上下文
+//
+// 字段为空（IP和Domain为空字符串、Port为0）时，
+// 涉及该字段的比较结果均为false。
+type Request struct {
+	IP     string
+	Domain string
+	Port   int
+}
+
+// String 以"ip=... domain=... port=..."的形式返回请求，省略为空的字段
+func (r Request) String() string {
+	var parts []string
+	if r.IP != "" {
+		parts = append(parts, "ip="+r.IP)
+	}
+	if r.Domain != "" {
+		parts = append(parts, "domain="+r.Domain)
+	}
+	if r.Port != 0 {
+		parts = append(parts, "port="+strconv.Itoa(r.Port))
+	}
+	return strings.Join(parts, " ")
+}
+
+// Rule 表示一条编译后的规则
+type Rule struct {
+	// Source 是规则的原始文本
+	Source string
+	// Action 是条件满足时的访问结果，LogOnly为true时无意义
+	Action types.Permission
+	// Log 表示条件满足时需要记录日志
+	Log bool
+	// LogOnly 表示规则只记录日志，不决定访问结果（动作为log）
+	LogOnly bool
+	// Group 是规则所属的规则组，空表示不属于任何组，参见acl.Manager.DisableGroup
+	Group string
+
+	cond node
+}
+
+// Compile 编译一条规则表达式
+//
+// 参数:
+//   - src: 规则文本，例如 "ip in private_networks && port != 443 -> deny"
+//
+// 返回:
+//   - *Rule: 编译后的规则，可被并发地重复求值
+//   - error: 语法错误时返回包装了ErrSyntax的错误
+//
+// 示例:
+//
+//	rule, err := expr.Compile("ip in private_networks && port != 443 -> deny")
+//	if err != nil {
+//	    log.Fatalf("规则编译失败: %v", err)
+//	}
+//	if rule.Match(expr.Request{IP: "10.0.0.1", Port: 80}) {
+//	    // 执行rule.Action
+//	}
+func Compile(src string) (*Rule, error) {
+	tokens, err := lex(src)
+	if err != nil {
+		return nil, err
+	}
+
+	p := &parser{tokens: tokens}
+	cond, action, err := p.parseRule()
+	if err != nil {
+		return nil, err
+	}
+
+	return &Rule{
+		Source:  strings.TrimSpace(src),
+		Action:  action.permission,
+		Log:     action.log,
+		LogOnly: action.logOnly,
+		cond:    cond,
+	}, nil
+}
+
+// MustCompile 与Compile相同，但在编译失败时panic
+// 适用于在程序初始化时编译硬编码的规则
+func MustCompile(src string) *Rule {
+	rule, err := Compile(src)
+	if err != nil {
+		panic(err)
+	}
+	return rule
+}
+
+// Match 判断请求是否满足规则条件
+//
+// nil规则和未经Compile创建的规则（没有条件）不匹配任何请求。
+func (r *Rule) Match(req Request) bool {
+	return r != nil && r.cond != nil && r.cond.eval(&req)
+}
+
+// String 返回规则的原始文本
+func (r *Rule) String() string {
+	if r == nil {
+		return ""
+	}
+	return r.Source
+}
+
+// RuleSet 表示按顺序求值的一组规则
+type RuleSet []*Rule
+
+// CompileAll 按顺序编译多条规则
+//
+// 参数:
+//   - sources: 规则文本列表
+//
+// 返回:
+//   - RuleSet: 编译后的规则集
+//   - error: 第一条编译失败的规则的错误，包含规则序号
+func CompileAll(sources []string) (RuleSet, error) {
+	rules := make(RuleSet, 0, len(sources))
+	for i, src := range sources {
+		rule, err := Compile(src)
+		if err != nil {
+			return nil, fmt.Errorf("第%d条规则: %w", i+1, err)
+		}
+		rules = append(rules, rule)
+	}
+	return rules, nil
+}
+
+// LoadFile 从文件加载并编译规则
+//
+// 参数:
+//   - filePath: 规则文件路径
+//
+// 返回:
+//   - RuleSet: 编译后的规则集
+//   - error: 读取或编译失败的错误
+//
+// 文件格式与IP列表文件相同：每行一条规则，#开头的行和行内#后的内容为注释。
+//
+// 示例文件内容:
+//
+//	# 内网只允许HTTPS
+//	ip in private_networks && port != 443 -> deny
+//	domain in example.com -> allow
+func LoadFile(filePath string) (RuleSet, error) {
+	lines, err := config.ReadLines(filePath)
+	if err != nil {
+		return nil, err
+	}
+	return CompileAll(lines)
+}
+
+// Evaluate 按顺序对请求求值，返回第一条匹配规则的结果
+//
+// 参数:
+//   - req: 请求上下文
+//
+// 返回:
+//   - types.Permission: 匹配规则的动作
+//   - *Rule: 匹配的规则，没有规则匹配时为nil
+//   - bool: 是否有规则匹配
+//
+// 动作为log的规则不决定结果，求值时会被跳过。nil规则不匹配任何请求，也会被跳过。
+//
+// 示例:
+//
+//	rules, _ := expr.LoadFile("./rules.txt")
+//	perm, rule, ok := rules.Evaluate(expr.Request{IP: "10.0.0.1", Port: 80})
+//	if ok {
+//	    log.Printf("规则 %q 决定: %v", rule, perm)
+//	}
+func (rs RuleSet) Evaluate(req Request) (types.Permission, *Rule, bool) {
+	return rs.EvaluateWithLog(req, nil)
+}
+
+// EvaluateWithLog 与Evaluate相同，并对求值过程中匹配的每条需要记录的规则调用logf
+//
+// 参数:
+//   - req: 请求上下文
+//   - logf: 记录函数，按匹配顺序接收Log为true的规则，为nil时不记录
+//
+// 返回:
+//   - 与Evaluate相同
+//
+// 决定结果的规则之后的规则不会被求值，因此也不会被记录。
+//
+// 示例:
+//
+//	perm, rule, ok := rules.EvaluateWithLog(req, func(r *expr.Rule) {
+//	    log.Printf("规则 %q 匹配请求 %s", r, req)
+//	})
+func (rs RuleSet) EvaluateWithLog(req Request, logf func(rule *Rule)) (types.Permission, *Rule, bool) {
+	for _, rule := range rs {
+		if !rule.Match(req) {
+			continue
+		}
+		if rule.Log && logf != nil {
+			logf(rule)
+		}
+		if !rule.LogOnly {
+			return rule.Action, rule, true
+		}
+	}
+	return types.Denied, nil, false
+}
@@ -0,0 +1,266 @@
+package expr
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/cyberspacesec/go-acl/pkg/types"
+)
+
+// TestCompile_Match 测试规则编译与匹配
+func TestCompile_Match(t *testing.T) {
+	tests := []struct {
+		name string
+		rule string
+		req  Request
+		want bool
+	}{
+		{"预定义集合-命中", "ip in private_networks -> deny", Request{IP: "10.1.2.3"}, true},
+		{"预定义集合-未命中", "ip in private_networks -> deny", Request{IP: "8.8.8.8"}, false},
+		{"CIDR字面量", "ip in 203.0.113.0/24 -> deny", Request{IP: "203.0.113.9"}, true},
+		{"IP列表", "ip in [1.1.1.1, 2001:db8::/32] -> allow", Request{IP: "2001:db8::5"}, true},
+		{"IP相等", "ip == 1.1.1.1 -> allow", Request{IP: "1.1.1.1"}, true},
+		{"IP不等", "ip != 1.1.1.1 -> allow", Request{IP: "1.1.1.2"}, true},
+		{"缺少IP", "ip in private_networks -> deny", Request{Port: 80}, false},
+		{"与运算-满足", "ip in private_networks && port != 443 -> deny", Request{IP: "10.0.0.1", Port: 80}, true},
+		{"与运算-不满足", "ip in private_networks && port != 443 -> deny", Request{IP: "10.0.0.1", Port: 443}, false},
+		{"或运算", "port == 22 || port == 23 -> deny", Request{Port: 23}, true},
+		{"取反", "!(ip in 10.0.0.0/8) -> deny", Request{IP: "8.8.8.8"}, true},
+		{"端口比较", "port < 1024 -> deny", Request{Port: 80}, true},
+		{"端口比较-大于等于", "port >= 1024 -> allow", Request{Port: 1024}, true},
+		{"端口列表", "port in [80, 443] -> allow", Request{Port: 443}, true},
+		{"缺少端口", "port != 443 -> deny", Request{IP: "1.1.1.1"}, false},
+		{"域名包含子域名", "domain in example.com -> allow", Request{Domain: "api.example.com"}, true},
+		{"域名列表与字符串", `domain in ["example.org", example.com] -> allow`, Request{Domain: "example.org"}, true},
+		{"域名完全匹配", `domain == "example.com" -> allow`, Request{Domain: "WWW.Example.com"}, true},
+		{"域名完全匹配-子域名不匹配", "domain == example.com -> allow", Request{Domain: "api.example.com"}, false},
+		{"域名不等", "domain != example.com -> deny", Request{Domain: "evil.com"}, true},
+		{"优先级-与高于或", "port == 1 || port == 2 && port == 3 -> deny", Request{Port: 1}, true},
+		{"括号", "(port == 1 || port == 2) && ip in 10.0.0.0/8 -> deny", Request{IP: "8.8.8.8", Port: 1}, false},
+		{"无空格箭头", "port==22->deny", Request{Port: 22}, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rule, err := Compile(tt.rule)
+			if err != nil {
+				t.Fatalf("Compile(%q) 返回错误: %v", tt.rule, err)
+			}
+			if got := rule.Match(tt.req); got != tt.want {
+				t.Errorf("Match(%+v) = %v, 期望 %v", tt.req, got, tt.want)
+			}
+		})
+	}
+}
+
+// TestCompile_Action 测试规则动作解析
+func TestCompile_Action(t *testing.T) {
+	rule := MustCompile("port == 80 -> ALLOW")
+	if rule.Action != types.Allowed {
+		t.Errorf("Action = %v, 期望 allowed", rule.Action)
+	}
+	if rule.String() != "port == 80 -> ALLOW" {
+		t.Errorf("String() = %q", rule.String())
+	}
+
+	rule = MustCompile("port == 80 -> deny")
+	if rule.Action != types.Denied {
+		t.Errorf("Action = %v, 期望 denied", rule.Action)
+	}
+
+	logTests := []struct {
+		src         string
+		wantAction  types.Permission
+		wantLog     bool
+		wantLogOnly bool
+	}{
+		{"port == 80 -> log", types.Denied, true, true},
+		{"port == 80 -> deny log", types.Denied, true, false},
+		{"port == 80 -> Allow LOG", types.Allowed, true, false},
+	}
+	for _, tt := range logTests {
+		rule := MustCompile(tt.src)
+		if rule.Action != tt.wantAction || rule.Log != tt.wantLog || rule.LogOnly != tt.wantLogOnly {
+			t.Errorf("Compile(%q) = Action %v, Log %v, LogOnly %v, 期望 %v, %v, %v",
+				tt.src, rule.Action, rule.Log, rule.LogOnly, tt.wantAction, tt.wantLog, tt.wantLogOnly)
+		}
+	}
+}
+
+// TestRuleSet_EvaluateWithLog 测试log规则的求值和记录
+func TestRuleSet_EvaluateWithLog(t *testing.T) {
+	rules, err := CompileAll([]string{
+		"ip in 198.51.100.0/24 -> log",
+		"port == 22 -> deny log",
+		"port == 22 -> allow log",
+		"port == 80 -> allow",
+	})
+	if err != nil {
+		t.Fatalf("CompileAll() 返回错误: %v", err)
+	}
+
+	tests := []struct {
+		name       string
+		req        Request
+		wantPerm   types.Permission
+		wantOK     bool
+		wantLogged []int
+	}{
+		{"只记录不决定结果", Request{IP: "198.51.100.1", Port: 443}, types.Denied, false, []int{0}},
+		{"记录后由后续规则决定", Request{IP: "198.51.100.1", Port: 80}, types.Allowed, true, []int{0}},
+		{"记录并拒绝后停止求值", Request{IP: "198.51.100.1", Port: 22}, types.Denied, true, []int{0, 1}},
+		{"不匹配log规则", Request{IP: "10.0.0.1", Port: 80}, types.Allowed, true, nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var logged []*Rule
+			perm, _, ok := rules.EvaluateWithLog(tt.req, func(r *Rule) { logged = append(logged, r) })
+			if perm != tt.wantPerm || ok != tt.wantOK {
+				t.Errorf("EvaluateWithLog() = %v, %v, 期望 %v, %v", perm, ok, tt.wantPerm, tt.wantOK)
+			}
+			if len(logged) != len(tt.wantLogged) {
+				t.Fatalf("记录了 %d 条规则, 期望 %d", len(logged), len(tt.wantLogged))
+			}
+			for i, idx := range tt.wantLogged {
+				if logged[i] != rules[idx] {
+					t.Errorf("第%d条记录 = %v, 期望 %v", i+1, logged[i], rules[idx])
+				}
+			}
+
+			if perm2, _, ok2 := rules.Evaluate(tt.req); perm2 != perm || ok2 != ok {
+				t.Errorf("Evaluate() = %v, %v, 应与EvaluateWithLog()一致", perm2, ok2)
+			}
+		})
+	}
+
+	if got := (Request{IP: "10.0.0.1", Domain: "example.com", Port: 80}).String(); got != "ip=10.0.0.1 domain=example.com port=80" {
+		t.Errorf("Request.String() = %q", got)
+	}
+}
+
+// TestCompile_Errors 测试语法错误
+func TestCompile_Errors(t *testing.T) {
+	invalid := []string{
+		"",
+		"ip in private_networks",
+		"ip in private_networks -> block",
+		"ip in private_networks -> deny extra",
+		"ip in private_networks -> log log",
+		"ip in private_networks -> log deny",
+		"ip in private_networks -> deny log log",
+		"host == a -> deny",
+		"ip < 1.1.1.1 -> deny",
+		"ip == not-an-ip -> deny",
+		"ip in no_such_set -> deny",
+		"port == 70000 -> deny",
+		"port == [80] -> deny",
+		"port in [80 443] -> deny",
+		"(port == 80 -> deny",
+		`domain == "unterminated -> deny`,
+		"domain < example.com -> deny",
+		"port == 80 $ -> deny",
+		"&& -> deny",
+	}
+
+	for _, src := range invalid {
+		if _, err := Compile(src); !errors.Is(err, ErrSyntax) {
+			t.Errorf("Compile(%q) 错误 = %v, 期望 ErrSyntax", src, err)
+		}
+	}
+
+	defer func() {
+		if recover() == nil {
+			t.Error("MustCompile() 对于无效规则应panic")
+		}
+	}()
+	MustCompile("invalid")
+}
+
+// TestRuleSet_Evaluate 测试规则集按顺序求值
+func TestRuleSet_Evaluate(t *testing.T) {
+	rules, err := CompileAll([]string{
+		"ip in 10.0.0.5 -> allow",
+		"ip in private_networks && port != 443 -> deny",
+		"domain in example.com -> allow",
+	})
+	if err != nil {
+		t.Fatalf("CompileAll() 返回错误: %v", err)
+	}
+
+	tests := []struct {
+		name     string
+		req      Request
+		wantPerm types.Permission
+		wantRule int
+		wantOK   bool
+	}{
+		{"第一条规则优先", Request{IP: "10.0.0.5", Port: 80}, types.Allowed, 0, true},
+		{"第二条规则", Request{IP: "10.0.0.6", Port: 80}, types.Denied, 1, true},
+		{"第三条规则", Request{IP: "10.0.0.6", Port: 443, Domain: "example.com"}, types.Allowed, 2, true},
+		{"无规则匹配", Request{IP: "8.8.8.8"}, types.Denied, -1, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			perm, rule, ok := rules.Evaluate(tt.req)
+			if ok != tt.wantOK || perm != tt.wantPerm {
+				t.Errorf("Evaluate() = %v, %v, 期望 %v, %v", perm, ok, tt.wantPerm, tt.wantOK)
+			}
+			if tt.wantRule >= 0 && rule != rules[tt.wantRule] {
+				t.Errorf("Evaluate() 匹配规则 = %v, 期望 %v", rule, rules[tt.wantRule])
+			}
+			if tt.wantRule < 0 && rule != nil {
+				t.Errorf("Evaluate() 匹配规则 = %v, 期望 nil", rule)
+			}
+		})
+	}
+
+	if _, err := CompileAll([]string{"port == 1 -> deny", "bad"}); !errors.Is(err, ErrSyntax) {
+		t.Errorf("CompileAll() 错误 = %v, 期望 ErrSyntax", err)
+	}
+}
+
+// TestLoadFile 测试从文件加载规则
+func TestLoadFile(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "rules.txt")
+	content := "# 规则文件\nip in private_networks && port != 443 -> deny\n\ndomain in example.com -> allow # 行内注释\n"
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatalf("写入测试文件失败: %v", err)
+	}
+
+	rules, err := LoadFile(path)
+	if err != nil {
+		t.Fatalf("LoadFile() 返回错误: %v", err)
+	}
+	if len(rules) != 2 {
+		t.Fatalf("LoadFile() 加载了 %d 条规则, 期望 2", len(rules))
+	}
+	if rules[1].Source != "domain in example.com -> allow" {
+		t.Errorf("rules[1].Source = %q", rules[1].Source)
+	}
+
+	if _, err := LoadFile(filepath.Join(dir, "missing.txt")); err == nil {
+		t.Error("LoadFile() 对于不存在的文件应返回错误")
+	}
+}
+
+// TestRuleSet_NilRules 测试nil规则和未编译的规则不匹配任何请求
+func TestRuleSet_NilRules(t *testing.T) {
+	var nilRule *Rule
+	if nilRule.Match(Request{IP: "10.0.0.1"}) || nilRule.String() != "" {
+		t.Error("nil规则不应匹配请求")
+	}
+	if (&Rule{Action: types.Allowed}).Match(Request{IP: "10.0.0.1"}) {
+		t.Error("没有条件的规则不应匹配请求")
+	}
+
+	rules := RuleSet{nil, &Rule{}, MustCompile("port == 22 -> deny")}
+	perm, rule, ok := rules.Evaluate(Request{Port: 22})
+	if !ok || perm != types.Denied || rule != rules[2] {
+		t.Errorf("Evaluate() = %v, %v, %v; 期望由第3条规则拒绝", perm, rule, ok)
+	}
+}
@@ -0,0 +1,128 @@
+package expr
+
+import (
+	"fmt"
+	"strings"
+	"unicode"
+)
+
+// tokenKind 表示词法单元的类型
+type tokenKind int
+
+const (
+	tokEOF    tokenKind = iota
+	tokWord             // 标识符、数字、IP/CIDR、域名等
+	tokString           // 双引号包围的字符串
+	tokLParen           // (
+	tokRParen           // )
+	tokLBrack           // [
+	tokRBrack           // ]
+	tokComma            // ,
+	tokAnd              // &&
+	tokOr               // ||
+	tokNot              // !
+	tokEq               // ==
+	tokNe               // !=
+	tokLt               // <
+	tokLe               // <=
+	tokGt               // >
+	tokGe               // >=
+	tokArrow            // ->
+)
+
+// token 表示一个词法单元
+type token struct {
+	kind tokenKind
+	text string
+	pos  int
+}
+
+// lex 将表达式源码切分为词法单元
+//
+// 单词字符包括字母、数字以及 . / : - _ *，
+// 因此IP、CIDR、IPv6地址和域名都可以不加引号直接书写。
+func lex(src string) ([]token, error) {
+	var tokens []token
+	i := 0
+
+	for i < len(src) {
+		c := src[i]
+
+		if unicode.IsSpace(rune(c)) {
+			i++
+			continue
+		}
+
+		start := i
+		switch {
+		case c == '(':
+			tokens = append(tokens, token{tokLParen, "(", start})
+			i++
+		case c == ')':
+			tokens = append(tokens, token{tokRParen, ")", start})
+			i++
+		case c == '[':
+			tokens = append(tokens, token{tokLBrack, "[", start})
+			i++
+		case c == ']':
+			tokens = append(tokens, token{tokRBrack, "]", start})
+			i++
+		case c == ',':
+			tokens = append(tokens, token{tokComma, ",", start})
+			i++
+		case strings.HasPrefix(src[i:], "&&"):
+			tokens = append(tokens, token{tokAnd, "&&", start})
+			i += 2
+		case strings.HasPrefix(src[i:], "||"):
+			tokens = append(tokens, token{tokOr, "||", start})
+			i += 2
+		case strings.HasPrefix(src[i:], "!="):
+			tokens = append(tokens, token{tokNe, "!=", start})
+			i += 2
+		case strings.HasPrefix(src[i:], "=="):
+			tokens = append(tokens, token{tokEq, "==", start})
+			i += 2
+		case strings.HasPrefix(src[i:], "<="):
+			tokens = append(tokens, token{tokLe, "<=", start})
+			i += 2
+		case strings.HasPrefix(src[i:], ">="):
+			tokens = append(tokens, token{tokGe, ">=", start})
+			i += 2
+		case strings.HasPrefix(src[i:], "->"):
+			tokens = append(tokens, token{tokArrow, "->", start})
+			i += 2
+		case c == '!':
+			tokens = append(tokens, token{tokNot, "!", start})
+			i++
+		case c == '<':
+			tokens = append(tokens, token{tokLt, "<", start})
+			i++
+		case c == '>':
+			tokens = append(tokens, token{tokGt, ">", start})
+			i++
+		case c == '"':
+			end := strings.IndexByte(src[i+1:], '"')
+			if end == -1 {
+				return nil, fmt.Errorf("%w: 位置%d的字符串缺少结束引号", ErrSyntax, start)
+			}
+			tokens = append(tokens, token{tokString, src[i+1 : i+1+end], start})
+			i += end + 2
+		case isWordChar(c):
+			for i < len(src) && isWordChar(src[i]) && !strings.HasPrefix(src[i:], "->") {
+				i++
+			}
+			tokens = append(tokens, token{tokWord, src[start:i], start})
+		default:
+			return nil, fmt.Errorf("%w: 位置%d存在无法识别的字符%q", ErrSyntax, start, c)
+		}
+	}
+
+	tokens = append(tokens, token{tokEOF, "", len(src)})
+	return tokens, nil
+}
+
+// isWordChar 判断字符是否可以出现在单词中
+func isWordChar(c byte) bool {
+	return c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c >= '0' && c <= '9' ||
+		c == '.' || c == '/' || c == ':' || c == '-' || c == '_' || c == '*'
+}
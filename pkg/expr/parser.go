@@ -0,0 +1,377 @@
+package expr
+
+import (
+	"fmt"
+	"net"
+	"strconv"
+	"strings"
+
+	"github.com/cyberspacesec/go-acl/pkg/domain"
+	"github.com/cyberspacesec/go-acl/pkg/ip"
+	"github.com/cyberspacesec/go-acl/pkg/types"
+)
+
+// node 是编译后的条件表达式节点
+type node interface {
+	eval(req *Request) bool
+}
+
+type andNode struct{ left, right node }
+
+func (n andNode) eval(req *Request) bool { return n.left.eval(req) && n.right.eval(req) }
+
+type orNode struct{ left, right node }
+
+func (n orNode) eval(req *Request) bool { return n.left.eval(req) || n.right.eval(req) }
+
+type notNode struct{ x node }
+
+func (n notNode) eval(req *Request) bool { return !n.x.eval(req) }
+
+// ipInNode 判断请求IP是否属于一组IP/CIDR/预定义集合
+type ipInNode struct{ set *ip.IPACL }
+
+func (n ipInNode) eval(req *Request) bool {
+	if req.IP == "" {
+		return false
+	}
+	perm, err := n.set.Check(req.IP)
+	return err == nil && perm == types.Allowed
+}
+
+// ipEqNode 判断请求IP是否等于指定IP
+type ipEqNode struct {
+	value net.IP
+	equal bool
+}
+
+func (n ipEqNode) eval(req *Request) bool {
+	parsed := net.ParseIP(strings.TrimSpace(req.IP))
+	if parsed == nil {
+		return false
+	}
+	return parsed.Equal(n.value) == n.equal
+}
+
+// domainInNode 判断请求域名是否为一组域名本身或其子域名
+type domainInNode struct{ set *domain.DomainACL }
+
+func (n domainInNode) eval(req *Request) bool {
+	if req.Domain == "" {
+		return false
+	}
+	perm, err := n.set.Check(req.Domain)
+	return err == nil && perm == types.Allowed
+}
+
+// domainEqNode 判断请求域名是否与指定域名完全相同
+type domainEqNode struct {
+	set   *domain.DomainACL
+	equal bool
+}
+
+func (n domainEqNode) eval(req *Request) bool {
+	if req.Domain == "" {
+		return false
+	}
+	perm, err := n.set.Check(req.Domain)
+	if err != nil {
+		return false
+	}
+	return (perm == types.Allowed) == n.equal
+}
+
+// portCmpNode 比较请求端口与常量
+type portCmpNode struct {
+	op    tokenKind
+	value int
+}
+
+func (n portCmpNode) eval(req *Request) bool {
+	if req.Port == 0 {
+		return false
+	}
+	switch n.op {
+	case tokEq:
+		return req.Port == n.value
+	case tokNe:
+		return req.Port != n.value
+	case tokLt:
+		return req.Port < n.value
+	case tokLe:
+		return req.Port <= n.value
+	case tokGt:
+		return req.Port > n.value
+	case tokGe:
+		return req.Port >= n.value
+	}
+	return false
+}
+
+// parser 是递归下降语法分析器
+//
+// 语法:
+//
+//	rule    := or '->' (('allow' | 'deny') ['log'] | 'log')
+//	or      := and ('||' and)*
+//	and     := unary ('&&' unary)*
+//	unary   := '!' unary | '(' or ')' | compare
+//	compare := field op value
+//	field   := 'ip' | 'domain' | 'port'
+//	op      := 'in' | '==' | '!=' | '<' | '<=' | '>' | '>='
+//	value   := word | string | '[' value (',' value)* ']'
+type parser struct {
+	tokens []token
+	pos    int
+}
+
+func (p *parser) peek() token {
+	return p.tokens[p.pos]
+}
+
+func (p *parser) next() token {
+	t := p.tokens[p.pos]
+	if t.kind != tokEOF {
+		p.pos++
+	}
+	return t
+}
+
+func (p *parser) errorf(t token, format string, args ...interface{}) error {
+	return fmt.Errorf("%w: 位置%d: %s", ErrSyntax, t.pos, fmt.Sprintf(format, args...))
+}
+
+// ruleAction 是规则箭头之后的动作部分
+type ruleAction struct {
+	permission types.Permission
+	log        bool
+	logOnly    bool
+}
+
+func (p *parser) parseRule() (node, ruleAction, error) {
+	cond, err := p.parseOr()
+	if err != nil {
+		return nil, ruleAction{}, err
+	}
+
+	if t := p.next(); t.kind != tokArrow {
+		return nil, ruleAction{}, p.errorf(t, "期望'->'，实际为%q", t.text)
+	}
+
+	t := p.next()
+	var action ruleAction
+	switch strings.ToLower(t.text) {
+	case "allow":
+		action.permission = types.Allowed
+	case "deny":
+		action.permission = types.Denied
+	case "log":
+		action = ruleAction{permission: types.Denied, log: true, logOnly: true}
+	default:
+		return nil, ruleAction{}, p.errorf(t, "未知的动作%q，可用值为allow、deny或log", t.text)
+	}
+
+	// allow和deny之后可以跟随log，表示记录后再允许或拒绝
+	if t := p.peek(); !action.logOnly && t.kind == tokWord && strings.ToLower(t.text) == "log" {
+		p.next()
+		action.log = true
+	}
+
+	if t := p.next(); t.kind != tokEOF {
+		return nil, ruleAction{}, p.errorf(t, "动作之后存在多余内容%q", t.text)
+	}
+	return cond, action, nil
+}
+
+func (p *parser) parseOr() (node, error) {
+	left, err := p.parseAnd()
+	if err != nil {
+		return nil, err
+	}
+	for p.peek().kind == tokOr {
+		p.next()
+		right, err := p.parseAnd()
+		if err != nil {
+			return nil, err
+		}
+		left = orNode{left, right}
+	}
+	return left, nil
+}
+
+func (p *parser) parseAnd() (node, error) {
+	left, err := p.parseUnary()
+	if err != nil {
+		return nil, err
+	}
+	for p.peek().kind == tokAnd {
+		p.next()
+		right, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		left = andNode{left, right}
+	}
+	return left, nil
+}
+
+func (p *parser) parseUnary() (node, error) {
+	switch p.peek().kind {
+	case tokNot:
+		p.next()
+		x, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		return notNode{x}, nil
+	case tokLParen:
+		p.next()
+		x, err := p.parseOr()
+		if err != nil {
+			return nil, err
+		}
+		if t := p.next(); t.kind != tokRParen {
+			return nil, p.errorf(t, "期望')'，实际为%q", t.text)
+		}
+		return x, nil
+	}
+	return p.parseCompare()
+}
+
+func (p *parser) parseCompare() (node, error) {
+	field := p.next()
+	if field.kind != tokWord {
+		return nil, p.errorf(field, "期望字段名(ip/domain/port)，实际为%q", field.text)
+	}
+
+	opTok := p.next()
+	op := opTok.kind
+	isIn := opTok.kind == tokWord && strings.ToLower(opTok.text) == "in"
+	if !isIn && op != tokEq && op != tokNe && op != tokLt && op != tokLe && op != tokGt && op != tokGe {
+		return nil, p.errorf(opTok, "期望比较运算符，实际为%q", opTok.text)
+	}
+
+	values, err := p.parseValues(isIn)
+	if err != nil {
+		return nil, err
+	}
+
+	switch strings.ToLower(field.text) {
+	case "ip":
+		return p.compileIP(opTok, isIn, values)
+	case "domain":
+		return p.compileDomain(opTok, isIn, values)
+	case "port":
+		return p.compilePort(opTok, isIn, values)
+	}
+	return nil, p.errorf(field, "未知的字段%q", field.text)
+}
+
+// parseValues 解析比较运算符右侧的值，只有in运算符允许使用列表
+func (p *parser) parseValues(allowList bool) ([]string, error) {
+	t := p.next()
+	switch t.kind {
+	case tokWord, tokString:
+		return []string{t.text}, nil
+	case tokLBrack:
+		if !allowList {
+			return nil, p.errorf(t, "只有in运算符支持列表")
+		}
+		var values []string
+		for {
+			v := p.next()
+			if v.kind != tokWord && v.kind != tokString {
+				return nil, p.errorf(v, "期望列表元素，实际为%q", v.text)
+			}
+			values = append(values, v.text)
+
+			sep := p.next()
+			if sep.kind == tokRBrack {
+				return values, nil
+			}
+			if sep.kind != tokComma {
+				return nil, p.errorf(sep, "期望','或']'，实际为%q", sep.text)
+			}
+		}
+	}
+	return nil, p.errorf(t, "期望值，实际为%q", t.text)
+}
+
+func (p *parser) compileIP(opTok token, isIn bool, values []string) (node, error) {
+	if isIn {
+		set, err := ip.NewIPACL(nil, types.Whitelist)
+		if err != nil {
+			return nil, err
+		}
+		for _, v := range values {
+			// 优先作为预定义集合名称解析
+			if ip.GetPredefinedIPRanges(ip.PredefinedSet(v)) != nil {
+				err = set.AddPredefinedSet(ip.PredefinedSet(v), true)
+			} else {
+				err = set.Add(v)
+			}
+			if err != nil {
+				return nil, p.errorf(opTok, "无效的IP集合%q: %v", v, err)
+			}
+		}
+		return ipInNode{set}, nil
+	}
+
+	if opTok.kind != tokEq && opTok.kind != tokNe {
+		return nil, p.errorf(opTok, "ip字段不支持运算符%q", opTok.text)
+	}
+	value := net.ParseIP(values[0])
+	if value == nil {
+		return nil, p.errorf(opTok, "无效的IP地址%q", values[0])
+	}
+	return ipEqNode{value: value, equal: opTok.kind == tokEq}, nil
+}
+
+func (p *parser) compileDomain(opTok token, isIn bool, values []string) (node, error) {
+	if isIn {
+		return domainInNode{domain.NewDomainACL(values, types.Whitelist, true)}, nil
+	}
+
+	if opTok.kind != tokEq && opTok.kind != tokNe {
+		return nil, p.errorf(opTok, "domain字段不支持运算符%q", opTok.text)
+	}
+	set := domain.NewDomainACL(values, types.Whitelist, false)
+	if len(set.GetDomains()) == 0 {
+		return nil, p.errorf(opTok, "无效的域名%q", values[0])
+	}
+	return domainEqNode{set: set, equal: opTok.kind == tokEq}, nil
+}
+
+func (p *parser) compilePort(opTok token, isIn bool, values []string) (node, error) {
+	if isIn {
+		var n node
+		for _, v := range values {
+			port, err := parsePort(v)
+			if err != nil {
+				return nil, p.errorf(opTok, "%v", err)
+			}
+			var eq node = portCmpNode{op: tokEq, value: port}
+			if n == nil {
+				n = eq
+			} else {
+				n = orNode{n, eq}
+			}
+		}
+		return n, nil
+	}
+
+	port, err := parsePort(values[0])
+	if err != nil {
+		return nil, p.errorf(opTok, "%v", err)
+	}
+	return portCmpNode{op: opTok.kind, value: port}, nil
+}
+
+// parsePort 解析端口号，范围为1-65535
+func parsePort(s string) (int, error) {
+	port, err := strconv.Atoi(s)
+	if err != nil || port < 1 || port > 65535 {
+		return 0, fmt.Errorf("无效的端口%q", s)
+	}
+	return port, nil
+}
@@ -0,0 +1,361 @@
+// Package gateway 提供一个由ACL管理器控制的最小化正向代理
+//
+// Proxy同时支持CONNECT隧道（HTTPS等）和普通HTTP代理请求，
+// 对每个出站请求的目标主机和实际连接的IP执行访问控制，
+// 使go-acl可以作为独立的出口流量过滤器部署，而不仅是嵌入到应用中。
+//
+// 用法示例:
+//
+//	manager := acl.NewManager()
+//	manager.SetDomainACL([]string{"api.example.com"}, types.Whitelist, true)
+//	manager.SetIPACLWithDefaults(nil, types.Blacklist,
+//	    []ip.PredefinedSet{ip.PrivateNetworks, ip.CloudMetadata}, false)
+//
+//	proxy := gateway.New(manager)
+//	log.Fatal(http.ListenAndServe(":3128", proxy))
+package gateway
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/cyberspacesec/go-acl/pkg/acl"
+	"github.com/cyberspacesec/go-acl/pkg/expr"
+	"github.com/cyberspacesec/go-acl/pkg/ip"
+	"github.com/cyberspacesec/go-acl/pkg/types"
+)
+
+// ErrForbidden 表示出站请求被访问控制拒绝
+var ErrForbidden = errors.New("出站请求被访问控制拒绝")
+
+// ErrInvalidTarget 表示代理请求的目标无法解析
+var ErrInvalidTarget = errors.New("无效的代理目标")
+
+// ReasonHeader 是拒绝响应中携带拒绝原因（types.Reason）的头部
+const ReasonHeader = "X-ACL-Reason"
+
+// hopHeaders 是不应被代理转发的逐跳头部
+var hopHeaders = []string{
+	"Connection",
+	"Proxy-Connection",
+	"Keep-Alive",
+	"Proxy-Authenticate",
+	"Proxy-Authorization",
+	"Te",
+	"Trailer",
+	"Transfer-Encoding",
+	"Upgrade",
+}
+
+// Proxy 是一个执行访问控制的正向代理，实现了http.Handler
+//
+// 访问控制分两个阶段:
+//  1. 请求阶段: 目标主机（域名或IP）和端口通过Manager.CheckRequest检查，
+//     因此SetRules设置的规则表达式同样生效
+//  2. 连接阶段: 建立TCP连接前，用实际要连接的IP再次调用Manager.CheckIP，
+//     防止域名解析到被禁止的地址（如内网、云元数据地址）
+//
+// 未设置的ACL会被跳过（与CheckRequest一致），其他检查错误一律拒绝。
+type Proxy struct {
+	// Manager 是执行访问控制的ACL管理器
+	Manager *acl.Manager
+	// Dialer 用于建立出站连接，为nil时使用默认的net.Dialer
+	Dialer *net.Dialer
+	// Transport 用于转发普通HTTP请求，为nil时使用基于Dialer创建的http.Transport
+	Transport http.RoundTripper
+	// OnDeny 在请求被拒绝时调用，可用于记录日志，可为nil；
+	// types.ReasonOf(err)返回拒绝原因
+	OnDeny func(r *http.Request, target string, err error)
+	// RequireConsistentHosts 为true时拒绝请求URL中的主机与Host头不一致的请求，
+	// 防止被放行的主机名掩护发往上游的另一个Host头；拒绝原因为types.ReasonHostMismatch。
+	// 客户端到代理的TLS连接中的SNI是代理自身的名称，不参与比较
+	RequireConsistentHosts bool
+
+	once      sync.Once
+	transport http.RoundTripper
+}
+
+// New 创建一个由指定管理器控制的正向代理
+//
+// 参数:
+//   - manager: 执行访问控制的ACL管理器
+//
+// 返回:
+//   - *Proxy: 可直接作为http.Handler使用的代理
+func New(manager *acl.Manager) *Proxy {
+	return &Proxy{Manager: manager}
+}
+
+// ServeHTTP 处理代理请求，CONNECT请求建立隧道，其他请求按普通HTTP代理转发
+func (p *Proxy) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method == http.MethodConnect {
+		p.serveConnect(w, r)
+		return
+	}
+	p.serveHTTP(w, r)
+}
+
+// Authorize 检查代理是否允许访问指定的目标
+//
+// 参数:
+//   - host: 目标主机，可以是域名或IP（IPv6可带方括号）
+//   - port: 目标端口，0表示未知
+//
+// 返回:
+//   - error: 允许时为nil；拒绝时为包装了ErrForbidden的错误；
+//     检查失败时为包装了对应检查错误的错误。两种错误都是*types.ReasonError，
+//     可以用types.ReasonOf取得拒绝原因
+func (p *Proxy) Authorize(host string, port int) error {
+	req := expr.Request{Port: port}
+	if parsed, ok := ip.CanonicalizeIP(host); ok {
+		// 混淆的IP写法（如"2130706433"）按实际地址检查
+		req.IP = parsed.String()
+	} else {
+		req.Domain = host
+	}
+
+	result, err := p.Manager.CheckRequestDetailed(context.Background(), req)
+	if err != nil {
+		if errors.Is(err, types.ErrNoACL) {
+			return nil
+		}
+		return &types.ReasonError{Reason: result.Reason, Err: err}
+	}
+	if result.Decision == types.Denied {
+		return &types.ReasonError{Reason: result.Reason, Err: fmt.Errorf("%w: %s", ErrForbidden, host)}
+	}
+	return nil
+}
+
+// AuthorizeRequest 检查代理是否允许转发指定的代理请求
+//
+// 参数:
+//   - r: 代理收到的请求，CONNECT请求的目标取r.Host，其他请求取r.URL.Host
+//
+// 返回:
+//   - error: 允许时为nil；目标无法解析时为包装了ErrInvalidTarget的错误；
+//     启用RequireConsistentHosts且主机不一致时为包装了acl.ErrHostMismatch的*types.ReasonError；
+//     其他情况与Authorize相同
+func (p *Proxy) AuthorizeRequest(r *http.Request) error {
+	if p.RequireConsistentHosts {
+		if err := acl.HostConsistency(r.URL.Host, r.Host, ""); err != nil {
+			return err
+		}
+	}
+
+	target, defaultPort := r.URL.Host, 80
+	switch {
+	case r.Method == http.MethodConnect:
+		target, defaultPort = r.Host, 443
+	case r.URL.Scheme == "https":
+		defaultPort = 443
+	}
+	host, port, err := splitHostPort(target, defaultPort)
+	if err != nil {
+		return err
+	}
+	return p.Authorize(host, port)
+}
+
+// serveConnect 处理CONNECT隧道请求
+func (p *Proxy) serveConnect(w http.ResponseWriter, r *http.Request) {
+	if err := p.AuthorizeRequest(r); err != nil {
+		p.reject(w, r, r.Host, err)
+		return
+	}
+
+	upstream, err := p.dialContext(r.Context(), "tcp", r.Host)
+	if err != nil {
+		p.dialError(w, r, r.Host, err)
+		return
+	}
+
+	hijacker, ok := w.(http.Hijacker)
+	if !ok {
+		upstream.Close()
+		http.Error(w, "不支持连接劫持", http.StatusInternalServerError)
+		return
+	}
+	client, buf, err := hijacker.Hijack()
+	if err != nil {
+		upstream.Close()
+		return
+	}
+
+	if _, err := client.Write([]byte("HTTP/1.1 200 Connection Established\r\n\r\n")); err != nil {
+		client.Close()
+		upstream.Close()
+		return
+	}
+
+	// 转发劫持前已被读入缓冲区的数据
+	if n := buf.Reader.Buffered(); n > 0 {
+		data, _ := buf.Reader.Peek(n)
+		if _, err := upstream.Write(data); err != nil {
+			client.Close()
+			upstream.Close()
+			return
+		}
+	}
+
+	tunnel(client, upstream)
+}
+
+// serveHTTP 转发普通HTTP代理请求
+func (p *Proxy) serveHTTP(w http.ResponseWriter, r *http.Request) {
+	if !r.URL.IsAbs() {
+		http.Error(w, "代理请求必须使用绝对URL", http.StatusBadRequest)
+		return
+	}
+
+	if err := p.AuthorizeRequest(r); err != nil {
+		p.reject(w, r, r.URL.Host, err)
+		return
+	}
+
+	out := r.Clone(r.Context())
+	out.RequestURI = ""
+	removeHopHeaders(out.Header)
+
+	resp, err := p.roundTripper().RoundTrip(out)
+	if err != nil {
+		p.dialError(w, r, r.URL.Host, err)
+		return
+	}
+	defer resp.Body.Close()
+
+	removeHopHeaders(resp.Header)
+	for key, values := range resp.Header {
+		for _, v := range values {
+			w.Header().Add(key, v)
+		}
+	}
+	w.WriteHeader(resp.StatusCode)
+	io.Copy(w, resp.Body)
+}
+
+// roundTripper 返回转发普通HTTP请求使用的RoundTripper
+func (p *Proxy) roundTripper() http.RoundTripper {
+	if p.Transport != nil {
+		return p.Transport
+	}
+	p.once.Do(func() {
+		p.transport = &http.Transport{
+			DialContext:           p.dialContext,
+			MaxIdleConns:          100,
+			IdleConnTimeout:       90 * time.Second,
+			TLSHandshakeTimeout:   10 * time.Second,
+			ExpectContinueTimeout: time.Second,
+		}
+	})
+	return p.transport
+}
+
+// dialContext 建立出站连接，并在连接前检查实际要连接的IP
+func (p *Proxy) dialContext(ctx context.Context, network, address string) (net.Conn, error) {
+	dialer := net.Dialer{Timeout: 30 * time.Second}
+	if p.Dialer != nil {
+		dialer = *p.Dialer
+	}
+
+	control := dialer.Control
+	dialer.Control = func(network, address string, c syscall.RawConn) error {
+		host, _, err := net.SplitHostPort(address)
+		if err != nil {
+			return err
+		}
+		result, err := p.Manager.CheckIPDetailed(ctx, host)
+		if err != nil && !errors.Is(err, types.ErrNoACL) {
+			return &types.ReasonError{Reason: result.Reason, Err: err}
+		}
+		if err == nil && result.Decision == types.Denied {
+			return &types.ReasonError{Reason: result.Reason, Err: fmt.Errorf("%w: %s", ErrForbidden, host)}
+		}
+		if control != nil {
+			return control(network, address, c)
+		}
+		return nil
+	}
+	return dialer.DialContext(ctx, network, address)
+}
+
+// reject 处理AuthorizeRequest返回的错误，目标无法解析时返回400，其他错误交给deny
+func (p *Proxy) reject(w http.ResponseWriter, r *http.Request, target string, err error) {
+	if errors.Is(err, ErrInvalidTarget) {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	p.deny(w, r, target, err)
+}
+
+// deny 返回403并调用OnDeny回调，拒绝原因写入ReasonHeader
+func (p *Proxy) deny(w http.ResponseWriter, r *http.Request, target string, err error) {
+	if p.OnDeny != nil {
+		p.OnDeny(r, target, err)
+	}
+	if reason := types.ReasonOf(err); reason != "" {
+		w.Header().Set(ReasonHeader, reason.String())
+	}
+	http.Error(w, "Forbidden by ACL", http.StatusForbidden)
+}
+
+// dialError 处理连接或转发失败，被连接阶段的检查拒绝时返回403，否则返回502
+func (p *Proxy) dialError(w http.ResponseWriter, r *http.Request, target string, err error) {
+	if errors.Is(err, ErrForbidden) {
+		p.deny(w, r, target, err)
+		return
+	}
+	http.Error(w, err.Error(), http.StatusBadGateway)
+}
+
+// splitHostPort 拆分主机和端口，没有端口时使用defaultPort
+func splitHostPort(hostport string, defaultPort int) (string, int, error) {
+	host, portStr, err := net.SplitHostPort(hostport)
+	if err != nil {
+		// 没有端口
+		return strings.TrimSuffix(strings.TrimPrefix(hostport, "["), "]"), defaultPort, nil
+	}
+	port, err := strconv.Atoi(portStr)
+	if err != nil || port <= 0 || port > 65535 {
+		return "", 0, fmt.Errorf("%w: 无效的端口: %s", ErrInvalidTarget, portStr)
+	}
+	return host, port, nil
+}
+
+// removeHopHeaders 删除逐跳头部，包括Connection头部中列出的头部
+func removeHopHeaders(header http.Header) {
+	for _, value := range header.Values("Connection") {
+		for _, name := range strings.Split(value, ",") {
+			if name = strings.TrimSpace(name); name != "" {
+				header.Del(name)
+			}
+		}
+	}
+	for _, name := range hopHeaders {
+		header.Del(name)
+	}
+}
+
+// tunnel 在两个连接之间双向复制数据，任一方向结束后关闭两个连接
+func tunnel(a, b net.Conn) {
+	done := make(chan struct{}, 2)
+	copyConn := func(dst, src net.Conn) {
+		io.Copy(dst, src)
+		done <- struct{}{}
+	}
+	go copyConn(a, b)
+	go copyConn(b, a)
+	<-done
+	a.Close()
+	b.Close()
+	<-done
+}
@@ -0,0 +1,296 @@
+package gateway
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/cyberspacesec/go-acl/pkg/acl"
+	"github.com/cyberspacesec/go-acl/pkg/types"
+)
+
+// newUpstream 创建一个返回固定内容的上游服务器
+func newUpstream(t *testing.T) *httptest.Server {
+	t.Helper()
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Proxy-Authorization") != "" {
+			t.Error("Proxy-Authorization 头部不应被转发")
+		}
+		fmt.Fprint(w, "upstream ok")
+	}))
+}
+
+// proxyClient 创建通过代理访问的HTTP客户端
+func proxyClient(t *testing.T, proxyURL string) *http.Client {
+	t.Helper()
+	u, err := url.Parse(proxyURL)
+	if err != nil {
+		t.Fatalf("解析代理地址失败: %v", err)
+	}
+	return &http.Client{Transport: &http.Transport{Proxy: http.ProxyURL(u)}}
+}
+
+// TestProxyHTTP 测试普通HTTP代理请求的访问控制
+func TestProxyHTTP(t *testing.T) {
+	upstream := newUpstream(t)
+	defer upstream.Close()
+	_, port, _ := net.SplitHostPort(upstream.Listener.Addr().String())
+
+	tests := []struct {
+		name       string
+		setup      func(m *acl.Manager)
+		url        string
+		wantStatus int
+		wantDenied bool
+		wantReason types.Reason
+	}{
+		{
+			name:       "未配置ACL时放行",
+			setup:      func(m *acl.Manager) {},
+			url:        upstream.URL,
+			wantStatus: http.StatusOK,
+		},
+		{
+			name: "IP不在黑名单中",
+			setup: func(m *acl.Manager) {
+				m.SetIPACL([]string{"10.0.0.0/8"}, types.Blacklist)
+			},
+			url:        upstream.URL,
+			wantStatus: http.StatusOK,
+		},
+		{
+			name: "目标IP在黑名单中",
+			setup: func(m *acl.Manager) {
+				m.SetIPACL([]string{"127.0.0.0/8"}, types.Blacklist)
+			},
+			url:        upstream.URL,
+			wantStatus: http.StatusForbidden,
+			wantDenied: true,
+			wantReason: types.ReasonMatchedBlacklistIP,
+		},
+		{
+			name: "目标域名在黑名单中",
+			setup: func(m *acl.Manager) {
+				m.SetDomainACL([]string{"blocked.test"}, types.Blacklist, true)
+			},
+			url:        "http://api.blocked.test/",
+			wantStatus: http.StatusForbidden,
+			wantDenied: true,
+			wantReason: types.ReasonMatchedBlacklistDomain,
+		},
+		{
+			name: "域名解析到被禁止的IP",
+			setup: func(m *acl.Manager) {
+				m.SetIPACL([]string{"127.0.0.0/8", "::1"}, types.Blacklist)
+			},
+			url:        "http://localhost:" + port + "/",
+			wantStatus: http.StatusForbidden,
+			wantDenied: true,
+			wantReason: types.ReasonMatchedBlacklistIP,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			manager := acl.NewManager()
+			tt.setup(manager)
+
+			denied := false
+			proxy := New(manager)
+			proxy.OnDeny = func(r *http.Request, target string, err error) {
+				denied = true
+				if !errors.Is(err, ErrForbidden) {
+					t.Errorf("OnDeny 收到的错误 = %v, 期望 ErrForbidden", err)
+				}
+			}
+			server := httptest.NewServer(proxy)
+			defer server.Close()
+
+			req, _ := http.NewRequest(http.MethodGet, tt.url, nil)
+			req.Header.Set("Proxy-Authorization", "Basic dGVzdDp0ZXN0")
+			resp, err := proxyClient(t, server.URL).Do(req)
+			if err != nil {
+				t.Fatalf("请求失败: %v", err)
+			}
+			body, _ := io.ReadAll(resp.Body)
+			resp.Body.Close()
+
+			if resp.StatusCode != tt.wantStatus {
+				t.Errorf("状态码 = %d, 期望 %d (%s)", resp.StatusCode, tt.wantStatus, body)
+			}
+			if tt.wantStatus == http.StatusOK && string(body) != "upstream ok" {
+				t.Errorf("响应内容 = %q", body)
+			}
+			if denied != tt.wantDenied {
+				t.Errorf("OnDeny 调用 = %v, 期望 %v", denied, tt.wantDenied)
+			}
+			if got := resp.Header.Get(ReasonHeader); got != tt.wantReason.String() {
+				t.Errorf("%s = %q, 期望 %q", ReasonHeader, got, tt.wantReason)
+			}
+		})
+	}
+}
+
+// connect 通过代理建立CONNECT隧道，返回隧道连接和代理的响应状态码
+func connect(t *testing.T, proxyAddr, target string) (net.Conn, *bufio.Reader, int) {
+	t.Helper()
+	conn, err := net.Dial("tcp", proxyAddr)
+	if err != nil {
+		t.Fatalf("连接代理失败: %v", err)
+	}
+	fmt.Fprintf(conn, "CONNECT %s HTTP/1.1\r\nHost: %s\r\n\r\n", target, target)
+
+	reader := bufio.NewReader(conn)
+	resp, err := http.ReadResponse(reader, &http.Request{Method: http.MethodConnect})
+	if err != nil {
+		conn.Close()
+		t.Fatalf("读取CONNECT响应失败: %v", err)
+	}
+	return conn, reader, resp.StatusCode
+}
+
+// TestProxyConnect 测试CONNECT隧道的访问控制
+func TestProxyConnect(t *testing.T) {
+	upstream := newUpstream(t)
+	defer upstream.Close()
+	target := upstream.Listener.Addr().String()
+
+	manager := acl.NewManager()
+	manager.SetDomainACL([]string{"blocked.test"}, types.Blacklist, true)
+	server := httptest.NewServer(New(manager))
+	defer server.Close()
+	proxyAddr := server.Listener.Addr().String()
+
+	// 允许的隧道
+	conn, reader, status := connect(t, proxyAddr, target)
+	if status != http.StatusOK {
+		t.Fatalf("CONNECT 状态码 = %d, 期望 200", status)
+	}
+	fmt.Fprintf(conn, "GET / HTTP/1.1\r\nHost: %s\r\nConnection: close\r\n\r\n", target)
+	resp, err := http.ReadResponse(reader, nil)
+	if err != nil {
+		t.Fatalf("通过隧道读取响应失败: %v", err)
+	}
+	body, _ := io.ReadAll(resp.Body)
+	if string(body) != "upstream ok" {
+		t.Errorf("隧道响应内容 = %q", body)
+	}
+	conn.Close()
+
+	// 被拒绝的域名
+	conn, _, status = connect(t, proxyAddr, "blocked.test:443")
+	conn.Close()
+	if status != http.StatusForbidden {
+		t.Errorf("被拒绝域名的 CONNECT 状态码 = %d, 期望 403", status)
+	}
+
+	// 连接阶段被拒绝的IP
+	if err := manager.SetIPACL([]string{"127.0.0.1"}, types.Blacklist); err != nil {
+		t.Fatalf("SetIPACL() 返回错误: %v", err)
+	}
+	conn, _, status = connect(t, proxyAddr, target)
+	conn.Close()
+	if status != http.StatusForbidden {
+		t.Errorf("被拒绝IP的 CONNECT 状态码 = %d, 期望 403", status)
+	}
+}
+
+// TestAuthorize 测试目标检查
+func TestAuthorize(t *testing.T) {
+	manager := acl.NewManager()
+	manager.SetDomainACL([]string{"example.com"}, types.Whitelist, true)
+	manager.SetIPACL([]string{"2001:db8::/32", "127.0.0.0/8"}, types.Blacklist)
+	proxy := New(manager)
+
+	tests := []struct {
+		host   string
+		reason types.Reason
+	}{
+		{"api.example.com", ""},
+		{"other.com", types.ReasonNotInWhitelistDomain},
+		{"[2001:db8::1]", types.ReasonMatchedBlacklistIP},
+		{"2001:db9::1", ""},
+		{"2130706433", types.ReasonMatchedBlacklistIP},
+		{"0x7f.0.0.1", types.ReasonMatchedBlacklistIP},
+	}
+	for _, tt := range tests {
+		err := proxy.Authorize(tt.host, 443)
+		if wantErr := tt.reason != ""; (err != nil) != wantErr {
+			t.Errorf("Authorize(%s) = %v, 期望错误: %v", tt.host, err, wantErr)
+		}
+		if err != nil && !errors.Is(err, ErrForbidden) {
+			t.Errorf("Authorize(%s) 错误应包装 ErrForbidden: %v", tt.host, err)
+		}
+		if got := types.ReasonOf(err); got != tt.reason {
+			t.Errorf("Authorize(%s) 的拒绝原因 = %q, 期望 %q", tt.host, got, tt.reason)
+		}
+	}
+
+	if host, port, err := splitHostPort("example.com", 80); err != nil || host != "example.com" || port != 80 {
+		t.Errorf("splitHostPort() = %s, %d, %v", host, port, err)
+	}
+	if _, _, err := splitHostPort("example.com:abc", 80); err == nil || !strings.Contains(err.Error(), "端口") {
+		t.Errorf("splitHostPort() 对无效端口应返回错误: %v", err)
+	}
+}
+
+// TestAuthorizeRequest 测试代理请求的检查，包括可选的主机一致性检查
+func TestAuthorizeRequest(t *testing.T) {
+	manager := acl.NewManager()
+	manager.SetDomainACL([]string{"example.com"}, types.Whitelist, true)
+	proxy := New(manager)
+	strict := New(manager)
+	strict.RequireConsistentHosts = true
+
+	tests := []struct {
+		name    string
+		proxy   *Proxy
+		method  string
+		target  string
+		host    string
+		wantErr error
+		reason  types.Reason
+	}{
+		{"允许", strict, http.MethodGet, "http://api.example.com/", "api.example.com", nil, ""},
+		{"主机一致时忽略大小写和端口", strict, http.MethodGet, "http://api.example.com:8080/", "API.example.com.", nil, ""},
+		{"默认不检查主机一致性", proxy, http.MethodGet, "http://api.example.com/", "internal.corp", nil, ""},
+		{"主机不一致", strict, http.MethodGet, "http://api.example.com/", "internal.corp", acl.ErrHostMismatch, types.ReasonHostMismatch},
+		{"CONNECT目标被拒绝", strict, http.MethodConnect, "other.com:443", "other.com:443", ErrForbidden, types.ReasonNotInWhitelistDomain},
+		{"无效的端口", strict, http.MethodConnect, "api.example.com:abc", "api.example.com:abc", ErrInvalidTarget, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := &http.Request{Method: tt.method, URL: &url.URL{Host: tt.target}, Host: tt.host}
+			if tt.method != http.MethodConnect {
+				u, err := url.Parse(tt.target)
+				if err != nil {
+					t.Fatal(err)
+				}
+				r.URL = u
+			}
+			err := tt.proxy.AuthorizeRequest(r)
+			if tt.wantErr == nil && err != nil || tt.wantErr != nil && !errors.Is(err, tt.wantErr) {
+				t.Errorf("AuthorizeRequest() = %v, 期望 %v", err, tt.wantErr)
+			}
+			if got := types.ReasonOf(err); got != tt.reason {
+				t.Errorf("拒绝原因 = %q, 期望 %q", got, tt.reason)
+			}
+		})
+	}
+
+	// 通过ServeHTTP转发时，不一致的请求在到达上游之前被拒绝
+	r := httptest.NewRequest(http.MethodGet, "http://api.example.com/", nil)
+	r.Host = "internal.corp"
+	w := httptest.NewRecorder()
+	strict.ServeHTTP(w, r)
+	if w.Code != http.StatusForbidden || w.Header().Get(ReasonHeader) != types.ReasonHostMismatch.String() {
+		t.Errorf("响应 = %d %v, 期望403且 %s 为 %s", w.Code, w.Header(), ReasonHeader, types.ReasonHostMismatch)
+	}
+}
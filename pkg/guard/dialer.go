@@ -0,0 +1,217 @@
+package guard
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/cyberspacesec/go-acl/pkg/acl"
+	"github.com/cyberspacesec/go-acl/pkg/ip"
+	"github.com/cyberspacesec/go-acl/pkg/types"
+)
+
+// Resolver 解析主机名得到IP地址，*net.Resolver实现了此接口
+type Resolver interface {
+	LookupIPAddr(ctx context.Context, host string) ([]net.IPAddr, error)
+}
+
+// Dialer 是在连接前检查目标IP的拨号器
+//
+// 目标是主机名时，Dialer自行解析得到全部A/AAAA记录，逐个用Manager.CheckIP检查，
+// 只连接被允许的地址（按解析结果的顺序依次尝试，直到某个地址连接成功）。
+// 只要有一个地址被允许就不会因为其他地址被拒绝而失败；全部被拒绝时返回ErrDenied。
+//
+// 与Go的Happy Eyeballs（RFC 6555）的关系:
+// net.Dialer收到主机名时会自行解析，并让IPv4和IPv6地址并行竞速。
+// 如果把主机名直接交给net.Dialer，它连接的地址可能不是我们检查过的地址。
+// 因此Dialer只把已检查的IP字面量交给底层的net.Dialer，此时net.Dialer不会再解析，
+// 也不会进行双栈竞速；地址之间的回退由Dialer按顺序完成，
+// net.Dialer.FallbackDelay对Dialer没有作用。
+//
+// 防御DNS重绑定和缓存投毒:
+// 每次连接都重新解析和检查，不缓存解析结果，因此解析结果在两次请求（或重定向的两跳）之间
+// 变为被拒绝的地址时，后一次连接会被拒绝。此外，Dialer在套接字即将连接时（net.Dialer.Control）
+// 再次核对实际连接的地址：不是本次检查通过的地址时重新用Manager检查，被拒绝则中止连接。
+// 底层拨号器已设置的Control在此检查之后调用；不应设置ControlContext，否则Control不会被调用。
+type Dialer struct {
+	// Manager 是执行访问控制的ACL管理器
+	Manager *acl.Manager
+	// Resolver 用于解析主机名，为nil时使用net.DefaultResolver
+	Resolver Resolver
+	// Dialer 是实际建立连接的拨号器，为nil时使用30秒超时的net.Dialer
+	Dialer *net.Dialer
+}
+
+// DialContext 解析并检查目标地址，只连接被允许的IP
+//
+// 参数:
+//   - ctx: 上下文
+//   - network: 网络类型，如"tcp"、"tcp4"、"tcp6"
+//   - address: 目标地址，格式为"host:port"
+//
+// 返回:
+//   - net.Conn: 建立的连接
+//   - error: 所有地址都被拒绝时返回包装了ErrDenied的*types.ReasonError（原因为最后一个被拒绝的地址的原因），
+//     否则返回最后一次连接失败的错误
+func (d *Dialer) DialContext(ctx context.Context, network, address string) (net.Conn, error) {
+	host, port, err := net.SplitHostPort(address)
+	if err != nil {
+		return nil, err
+	}
+
+	candidates, err := d.resolve(ctx, network, host)
+	if err != nil {
+		return nil, err
+	}
+
+	var allowed []net.IP
+	var reason types.Reason
+	for _, addr := range candidates {
+		if err := checkIP(d.Manager, addr); err == nil {
+			allowed = append(allowed, addr)
+		} else if errors.Is(err, ErrDenied) {
+			reason = types.ReasonOf(err)
+		} else {
+			return nil, err
+		}
+	}
+	if len(allowed) == 0 {
+		return nil, &types.ReasonError{Reason: reason, Err: fmt.Errorf("%w: %s 的所有地址均被拒绝", ErrDenied, host)}
+	}
+
+	dialer := d.Dialer
+	if dialer == nil {
+		dialer = &net.Dialer{Timeout: 30 * time.Second}
+	}
+
+	connect := *dialer
+	connect.Control = d.control(allowed, dialer.Control)
+
+	var lastErr error
+	for _, addr := range allowed {
+		conn, err := connect.DialContext(ctx, network, net.JoinHostPort(addr.String(), port))
+		if err == nil {
+			return conn, nil
+		}
+		lastErr = err
+		if ctx.Err() != nil {
+			break
+		}
+	}
+	return nil, lastErr
+}
+
+// control 返回在连接前核对实际地址的Control函数，next为底层拨号器原有的Control
+func (d *Dialer) control(allowed []net.IP, next func(network, address string, c syscall.RawConn) error) func(network, address string, c syscall.RawConn) error {
+	return func(network, address string, c syscall.RawConn) error {
+		if err := d.checkConnect(allowed, address); err != nil {
+			return err
+		}
+		if next != nil {
+			return next(network, address, c)
+		}
+		return nil
+	}
+}
+
+// checkConnect 核对实际连接的地址，不在allowed中时重新检查
+func (d *Dialer) checkConnect(allowed []net.IP, address string) error {
+	host, _, err := net.SplitHostPort(address)
+	if err != nil {
+		return err
+	}
+	if i := strings.IndexByte(host, '%'); i >= 0 {
+		host = host[:i]
+	}
+	addr := net.ParseIP(host)
+	if addr == nil {
+		return &types.ReasonError{Reason: types.ReasonInvalidInput, Err: fmt.Errorf("%w: 无法识别连接地址 %s", ErrDenied, address)}
+	}
+	for _, checked := range allowed {
+		if checked.Equal(addr) {
+			return nil
+		}
+	}
+	return checkIP(d.Manager, addr)
+}
+
+// resolve 返回主机对应的候选IP，IP字面量（包括混淆写法）不经过解析
+//
+// 解析受Manager的检查预算限制（见acl.Manager.SetCheckBudget），没有地址就无法连接，
+// 因此即使预算配置为fail-open，超时也返回包装了acl.ErrBudgetExceeded的错误。
+func (d *Dialer) resolve(ctx context.Context, network, host string) ([]net.IP, error) {
+	if parsed, ok := ip.CanonicalizeIP(host); ok {
+		return []net.IP{parsed}, nil
+	}
+
+	resolver := d.Resolver
+	if resolver == nil {
+		resolver = net.DefaultResolver
+	}
+	lookupCtx, cancel := d.Manager.BudgetContext(ctx)
+	defer cancel()
+	addrs, err := resolver.LookupIPAddr(lookupCtx, host)
+	if err != nil {
+		if ctx.Err() == nil && lookupCtx.Err() == context.DeadlineExceeded {
+			return nil, fmt.Errorf("%w: 解析 %s: %v", acl.ErrBudgetExceeded, host, err)
+		}
+		return nil, err
+	}
+
+	var ips []net.IP
+	for _, addr := range addrs {
+		is4 := addr.IP.To4() != nil
+		if (network == "tcp4" || network == "udp4") && !is4 {
+			continue
+		}
+		if (network == "tcp6" || network == "udp6") && is4 {
+			continue
+		}
+		ips = append(ips, addr.IP)
+	}
+	if len(ips) == 0 {
+		return nil, &net.DNSError{Err: "no suitable address found", Name: host, IsNotFound: true}
+	}
+	return ips, nil
+}
+
+// NewTransport 创建使用受保护拨号器的http.Transport
+//
+// 参数:
+//   - manager: 执行访问控制的ACL管理器
+//
+// 返回:
+//   - *http.Transport: 连接参数与http.DefaultTransport相同，但每次连接都经过Dialer检查
+//
+// 注意: 返回的Transport不使用环境变量中的代理设置，否则实际连接的是代理而不是目标。
+func NewTransport(manager *acl.Manager) *http.Transport {
+	dialer := &Dialer{Manager: manager}
+	return &http.Transport{
+		DialContext:           dialer.DialContext,
+		ForceAttemptHTTP2:     true,
+		MaxIdleConns:          100,
+		IdleConnTimeout:       90 * time.Second,
+		TLSHandshakeTimeout:   10 * time.Second,
+		ExpectContinueTimeout: time.Second,
+	}
+}
+
+// checkIP 检查IP是否允许连接，拒绝时返回包装了ErrDenied的*types.ReasonError
+func checkIP(manager *acl.Manager, addr net.IP) error {
+	result, err := manager.CheckIPDetailed(context.Background(), addr.String())
+	if err != nil {
+		if errors.Is(err, types.ErrNoACL) {
+			return nil
+		}
+		return err
+	}
+	if result.Decision == types.Denied {
+		return &types.ReasonError{Reason: result.Reason, Err: fmt.Errorf("%w: %s", ErrDenied, addr)}
+	}
+	return nil
+}
@@ -0,0 +1,273 @@
+package guard
+
+import (
+	"context"
+	"errors"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/cyberspacesec/go-acl/pkg/acl"
+	"github.com/cyberspacesec/go-acl/pkg/types"
+)
+
+// fakeResolver 返回预设解析结果的解析器
+type fakeResolver map[string][]string
+
+func (r fakeResolver) LookupIPAddr(ctx context.Context, host string) ([]net.IPAddr, error) {
+	ips, ok := r[host]
+	if !ok {
+		return nil, &net.DNSError{Err: "no such host", Name: host, IsNotFound: true}
+	}
+	addrs := make([]net.IPAddr, len(ips))
+	for i, s := range ips {
+		addrs[i] = net.IPAddr{IP: net.ParseIP(s)}
+	}
+	return addrs, nil
+}
+
+// TestDialerValidatesAllAddresses 测试解析得到的每个地址都被检查
+func TestDialerValidatesAllAddresses(t *testing.T) {
+	listener, err := net.Listen("tcp4", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("监听失败: %v", err)
+	}
+	defer listener.Close()
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			conn.Close()
+		}
+	}()
+	_, port, _ := net.SplitHostPort(listener.Addr().String())
+
+	manager := acl.NewManager()
+	if err := manager.SetIPACL([]string{"169.254.169.254", "127.0.0.2", "fd00::/8"}, types.Blacklist); err != nil {
+		t.Fatalf("SetIPACL() 返回错误: %v", err)
+	}
+
+	dialer := &Dialer{
+		Manager: manager,
+		Resolver: fakeResolver{
+			"mixed.test":    {"169.254.169.254", "127.0.0.1"},
+			"denied.test":   {"169.254.169.254", "127.0.0.2", "fd00::1"},
+			"fallback.test": {"127.0.0.3", "127.0.0.1"},
+			"v6first.test":  {"fd00::1", "127.0.0.1"},
+		},
+	}
+
+	tests := []struct {
+		name     string
+		network  string
+		host     string
+		wantErr  error
+		wantPeer string
+	}{
+		{"跳过被拒绝的地址", "tcp", "mixed.test", nil, "127.0.0.1"},
+		{"所有地址都被拒绝", "tcp", "denied.test", ErrDenied, ""},
+		{"允许的地址连接失败后回退", "tcp", "fallback.test", nil, "127.0.0.1"},
+		{"tcp4只使用IPv4地址", "tcp4", "v6first.test", nil, "127.0.0.1"},
+		{"IP字面量被拒绝", "tcp", "169.254.169.254", ErrDenied, ""},
+		{"混淆写法的IP字面量", "tcp", "2130706433", nil, "127.0.0.1"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			conn, err := dialer.DialContext(context.Background(), tt.network, net.JoinHostPort(tt.host, port))
+			if tt.wantErr != nil {
+				if !errors.Is(err, tt.wantErr) {
+					t.Errorf("DialContext() 错误 = %v, 期望 %v", err, tt.wantErr)
+				}
+				if got := types.ReasonOf(err); got != types.ReasonMatchedBlacklistIP {
+					t.Errorf("拒绝原因 = %q, 期望 %q", got, types.ReasonMatchedBlacklistIP)
+				}
+				if conn != nil {
+					conn.Close()
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("DialContext() 返回错误: %v", err)
+			}
+			defer conn.Close()
+			if host, _, _ := net.SplitHostPort(conn.RemoteAddr().String()); host != tt.wantPeer {
+				t.Errorf("连接的地址 = %s, 期望 %s", host, tt.wantPeer)
+			}
+		})
+	}
+
+	// 解析失败
+	if _, err := dialer.DialContext(context.Background(), "tcp", "unknown.test:80"); err == nil {
+		t.Error("DialContext() 对无法解析的主机应返回错误")
+	}
+}
+
+// slowResolver 在ctx结束前不返回的解析器
+type slowResolver struct{}
+
+func (slowResolver) LookupIPAddr(ctx context.Context, host string) ([]net.IPAddr, error) {
+	<-ctx.Done()
+	return nil, ctx.Err()
+}
+
+// TestDialerResolveBudget 测试DNS解析受检查预算限制
+func TestDialerResolveBudget(t *testing.T) {
+	manager := acl.NewManager()
+	manager.SetCheckBudget(&acl.BudgetConfig{Timeout: 5 * time.Millisecond, FailOpen: true})
+	dialer := &Dialer{Manager: manager, Resolver: slowResolver{}}
+
+	_, err := dialer.DialContext(context.Background(), "tcp", "slow.test:80")
+	if !errors.Is(err, acl.ErrBudgetExceeded) {
+		t.Errorf("DialContext() 错误 = %v, 期望 acl.ErrBudgetExceeded", err)
+	}
+}
+
+// rebindingResolver 模拟DNS重绑定：每次解析依次返回answers中的下一个结果，用完后重复最后一个
+type rebindingResolver struct {
+	mu      sync.Mutex
+	answers map[string][]string
+	calls   map[string]int
+}
+
+func (r *rebindingResolver) LookupIPAddr(ctx context.Context, host string) ([]net.IPAddr, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	answers, ok := r.answers[host]
+	if !ok {
+		return nil, &net.DNSError{Err: "no such host", Name: host, IsNotFound: true}
+	}
+	i := r.calls[host]
+	if i >= len(answers) {
+		i = len(answers) - 1
+	}
+	r.calls[host]++
+	return []net.IPAddr{{IP: net.ParseIP(answers[i])}}, nil
+}
+
+// TestDialerRebinding 测试解析结果在两次请求或重定向的两跳之间变化时，每次连接都按新的结果检查
+func TestDialerRebinding(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/redirect" {
+			http.Redirect(w, r, "http://"+net.JoinHostPort("rebind.test", r.URL.Query().Get("port"))+"/", http.StatusFound)
+			return
+		}
+		w.Write([]byte("ok"))
+	}))
+	defer server.Close()
+	_, port, _ := net.SplitHostPort(server.Listener.Addr().String())
+
+	manager := acl.NewManager()
+	if err := manager.SetIPACL([]string{"127.0.0.2"}, types.Blacklist); err != nil {
+		t.Fatalf("SetIPACL() 返回错误: %v", err)
+	}
+	resolver := &rebindingResolver{
+		answers: map[string][]string{
+			"start.test":  {"127.0.0.1"},
+			"rebind.test": {"127.0.0.1", "127.0.0.2"},
+		},
+		calls: make(map[string]int),
+	}
+	dialer := &Dialer{Manager: manager, Resolver: resolver}
+	// 只在连接层面检查，URL层面的检查无法识别这类攻击
+	client := &http.Client{Transport: &http.Transport{DialContext: dialer.DialContext, DisableKeepAlives: true}}
+
+	resp, err := client.Get("http://" + net.JoinHostPort("rebind.test", port) + "/")
+	if err != nil {
+		t.Fatalf("第一次请求返回错误: %v", err)
+	}
+	resp.Body.Close()
+
+	// 第二次解析得到被拒绝的地址
+	_, err = client.Get("http://" + net.JoinHostPort("rebind.test", port) + "/")
+	if !errors.Is(err, ErrDenied) {
+		t.Errorf("重绑定后的请求错误 = %v, 期望 ErrDenied", err)
+	}
+
+	// 重定向的目标在连接时解析并检查
+	_, err = client.Get("http://" + net.JoinHostPort("start.test", port) + "/redirect?port=" + port)
+	if !errors.Is(err, ErrDenied) {
+		t.Errorf("重定向到重绑定主机的错误 = %v, 期望 ErrDenied", err)
+	}
+	if got := resolver.calls["rebind.test"]; got != 3 {
+		t.Errorf("rebind.test 被解析 %d 次, 期望每次连接解析一次（3次）", got)
+	}
+}
+
+// TestDialerCheckConnect 测试连接时核对实际地址，并保留底层拨号器的Control
+func TestDialerCheckConnect(t *testing.T) {
+	manager := acl.NewManager()
+	if err := manager.SetIPACL([]string{"169.254.169.254"}, types.Blacklist); err != nil {
+		t.Fatalf("SetIPACL() 返回错误: %v", err)
+	}
+	dialer := &Dialer{Manager: manager}
+	allowed := []net.IP{net.ParseIP("127.0.0.1")}
+
+	tests := []struct {
+		name    string
+		address string
+		wantErr error
+	}{
+		{"检查过的地址", "127.0.0.1:80", nil},
+		{"未检查但允许的地址", "127.0.0.3:80", nil},
+		{"未检查且被拒绝的地址", "169.254.169.254:80", ErrDenied},
+		{"带区域的IPv6地址", "[fe80::1%eth0]:80", nil},
+		{"无法识别的地址", "example.com:80", ErrDenied},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := dialer.checkConnect(allowed, tt.address)
+			if tt.wantErr == nil && err != nil {
+				t.Errorf("checkConnect(%q) 返回错误: %v", tt.address, err)
+			}
+			if tt.wantErr != nil && !errors.Is(err, tt.wantErr) {
+				t.Errorf("checkConnect(%q) 错误 = %v, 期望 %v", tt.address, err, tt.wantErr)
+			}
+		})
+	}
+
+	// 底层拨号器的Control在检查通过后被调用，被拒绝时不调用
+	var called []string
+	control := dialer.control(allowed, func(network, address string, c syscall.RawConn) error {
+		called = append(called, address)
+		return nil
+	})
+	control("tcp4", "127.0.0.1:80", nil)
+	if err := control("tcp4", "169.254.169.254:80", nil); !errors.Is(err, ErrDenied) {
+		t.Errorf("control() 错误 = %v, 期望 ErrDenied", err)
+	}
+	if len(called) != 1 || called[0] != "127.0.0.1:80" {
+		t.Errorf("底层Control的调用 = %v, 期望只调用一次", called)
+	}
+
+	// 实际连接时底层Control仍然生效
+	listener, err := net.Listen("tcp4", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("监听失败: %v", err)
+	}
+	defer listener.Close()
+	go func() {
+		if conn, err := listener.Accept(); err == nil {
+			conn.Close()
+		}
+	}()
+	called = nil
+	dialer.Dialer = &net.Dialer{Control: func(network, address string, c syscall.RawConn) error {
+		called = append(called, address)
+		return nil
+	}}
+	conn, err := dialer.DialContext(context.Background(), "tcp4", listener.Addr().String())
+	if err != nil {
+		t.Fatalf("DialContext() 返回错误: %v", err)
+	}
+	conn.Close()
+	if len(called) != 1 {
+		t.Errorf("底层Control被调用 %d 次, 期望 1", len(called))
+	}
+}
@@ -0,0 +1,198 @@
+// Package guard 提供防御SSRF的HTTP客户端组件
+//
+// 服务端代为请求用户提供的URL（Webhook、图片抓取、URL预览等）时，
+// 攻击者可以通过内网地址、云元数据地址、混淆的IP写法或重定向等手段
+// 访问内部资源。guard在发出请求前用ACL管理器检查目标，并对重定向的
+// 每一跳重新检查；域名解析得到的每个IP在连接前也会被检查。
+//
+// 用法示例:
+//
+//	manager := acl.NewManager()
+//	manager.SetIPACLWithDefaults(nil, types.Blacklist,
+//	    []ip.PredefinedSet{ip.PrivateNetworks, ip.LoopbackNetworks, ip.CloudMetadata}, false)
+//
+//	client := guard.NewClient(manager, guard.Options{MaxRedirects: 3})
+//	resp, err := client.Get(userProvidedURL)
+//	if errors.Is(err, guard.ErrDenied) {
+//	    // 目标被拒绝，types.ReasonOf(err)返回拒绝原因
+//	}
+package guard
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/cyberspacesec/go-acl/pkg/acl"
+	"github.com/cyberspacesec/go-acl/pkg/types"
+)
+
+// 错误定义
+var (
+	// ErrDenied 表示请求目标被访问控制拒绝
+	ErrDenied = errors.New("请求目标被访问控制拒绝")
+	// ErrTooManyRedirects 表示重定向次数超过了Options.MaxRedirects
+	ErrTooManyRedirects = errors.New("重定向次数过多")
+	// ErrSchemeDowngrade 表示重定向从https降级到了http
+	ErrSchemeDowngrade = errors.New("禁止从https重定向到http")
+)
+
+// DefaultMaxRedirects 是Options.MaxRedirects为0时使用的最大重定向次数，与net/http的上限数值相同
+const DefaultMaxRedirects = 10
+
+// Options 受保护HTTP客户端的选项
+//
+// 字段说明:
+//   - MaxRedirects: 最多跟随的重定向次数，如3表示最多跟随3次重定向（共发出4个请求）；
+//     0表示使用DefaultMaxRedirects，负数表示不跟随重定向（直接返回重定向响应）
+//   - AllowSchemeDowngrade: 是否允许从https重定向到http，默认禁止
+//   - AllowHostMismatch: 是否允许Host头或SNI与URL主机不一致，默认禁止，见Transport
+type Options struct {
+	MaxRedirects         int
+	AllowSchemeDowngrade bool
+	AllowHostMismatch    bool
+}
+
+// Transport 是在发送请求前检查目标的http.RoundTripper
+//
+// 每个请求（包括http.Client跟随重定向时发出的每一跳）的URL主机都会
+// 通过Manager.CheckHost检查，混淆的IP写法会先转换为标准形式。
+// 未设置的ACL会被跳过，其他检查错误一律拒绝。
+//
+// 只有URL主机经过检查，因此默认拒绝Host头（req.Host）或Base的TLS配置中的
+// ServerName（SNI）与URL主机不一致的请求，避免请求被发往允许的地址后，
+// 再由对方按Host头路由到内部虚拟主机。此时返回包装了acl.ErrHostMismatch的*types.ReasonError，
+// 原因为types.ReasonHostMismatch。
+type Transport struct {
+	// Manager 是执行访问控制的ACL管理器
+	Manager *acl.Manager
+	// Base 是实际发送请求的RoundTripper，为nil时使用http.DefaultTransport
+	Base http.RoundTripper
+	// AllowHostMismatch 为true时不检查Host头和SNI是否与URL主机一致
+	AllowHostMismatch bool
+}
+
+// RoundTrip 检查请求目标，允许时交给Base发送
+func (t *Transport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if err := t.check(req); err != nil {
+		if req.Body != nil {
+			req.Body.Close()
+		}
+		return nil, err
+	}
+
+	base := t.Base
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	return base.RoundTrip(req)
+}
+
+// check 检查请求的主机一致性和URL主机
+func (t *Transport) check(req *http.Request) error {
+	if !t.AllowHostMismatch {
+		if err := acl.HostConsistency(req.URL.Host, req.Host, t.serverName(req)); err != nil {
+			return err
+		}
+	}
+	return checkHost(t.Manager, req.URL.Host)
+}
+
+// serverName 返回https请求将使用的显式SNI，未显式设置时为空
+func (t *Transport) serverName(req *http.Request) string {
+	if req.URL.Scheme != "https" {
+		return ""
+	}
+	if base, ok := t.Base.(*http.Transport); ok && base.TLSClientConfig != nil {
+		return base.TLSClientConfig.ServerName
+	}
+	return ""
+}
+
+// NewClient 创建一个防御SSRF的HTTP客户端
+//
+// 参数:
+//   - manager: 执行访问控制的ACL管理器
+//   - opts: 重定向策略等选项
+//
+// 返回:
+//   - *http.Client: 每个请求和每一跳重定向都经过访问控制检查的客户端
+//
+// 客户端在URL层面检查每个请求的主机，并使用NewTransport创建的Transport，
+// 在连接层面检查域名解析得到的每个IP。
+//
+// 重定向处理:
+//   - 超过MaxRedirects时返回ErrTooManyRedirects
+//   - 未设置AllowSchemeDowngrade时，https到http的重定向返回ErrSchemeDowngrade
+//   - 重定向目标被拒绝时返回ErrDenied，不会向该目标发出任何请求
+//   - 未设置AllowHostMismatch时，Host头与URL主机不一致返回acl.ErrHostMismatch
+//
+// 返回的错误被http.Client包装在*url.Error中，可以使用errors.Is判断。
+//
+// 示例:
+//
+//	client := guard.NewClient(manager, guard.Options{MaxRedirects: 3})
+//	resp, err := client.Get("http://example.com/webhook")
+func NewClient(manager *acl.Manager, opts Options) *http.Client {
+	return &http.Client{
+		Transport: &Transport{
+			Manager:           manager,
+			Base:              NewTransport(manager),
+			AllowHostMismatch: opts.AllowHostMismatch,
+		},
+		CheckRedirect: RedirectPolicy(manager, opts),
+	}
+}
+
+// RedirectPolicy 返回可用于http.Client.CheckRedirect的重定向策略
+//
+// 参数:
+//   - manager: 执行访问控制的ACL管理器
+//   - opts: 重定向选项
+//
+// 返回:
+//   - func: 按opts限制重定向次数和协议降级，并检查每一跳目标的CheckRedirect函数
+//
+// 已有http.Client时，可以只替换其CheckRedirect。
+func RedirectPolicy(manager *acl.Manager, opts Options) func(req *http.Request, via []*http.Request) error {
+	maxRedirects := opts.MaxRedirects
+	if maxRedirects == 0 {
+		maxRedirects = DefaultMaxRedirects
+	}
+
+	return func(req *http.Request, via []*http.Request) error {
+		if maxRedirects < 0 {
+			// 不跟随重定向，直接返回重定向响应
+			return http.ErrUseLastResponse
+		}
+		// via包含已经发出的请求，len(via)即为本次将要跟随的重定向是第几次
+		if len(via) > maxRedirects {
+			return fmt.Errorf("%w: 已重定向%d次", ErrTooManyRedirects, len(via))
+		}
+
+		if !opts.AllowSchemeDowngrade && len(via) > 0 {
+			prev := via[len(via)-1]
+			if prev.URL.Scheme == "https" && req.URL.Scheme == "http" {
+				return fmt.Errorf("%w: %s", ErrSchemeDowngrade, req.URL)
+			}
+		}
+
+		return checkHost(manager, req.URL.Host)
+	}
+}
+
+// checkHost 检查主机是否允许访问，拒绝时返回包装了ErrDenied的*types.ReasonError
+func checkHost(manager *acl.Manager, host string) error {
+	result, err := manager.CheckHostDetailed(context.Background(), host)
+	if err != nil {
+		if errors.Is(err, types.ErrNoACL) {
+			return nil
+		}
+		return err
+	}
+	if result.Decision == types.Denied {
+		return &types.ReasonError{Reason: result.Reason, Err: fmt.Errorf("%w: %s", ErrDenied, host)}
+	}
+	return nil
+}
@@ -0,0 +1,219 @@
+package guard
+
+import (
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+
+	"github.com/cyberspacesec/go-acl/pkg/acl"
+	"github.com/cyberspacesec/go-acl/pkg/types"
+)
+
+// newRedirectServer 创建一个测试服务器:
+// /redirect?to=URL 重定向到指定地址，/hops?n=N 连续重定向N次，其他路径返回"ok"
+func newRedirectServer(tls bool) *httptest.Server {
+	var server *httptest.Server
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/redirect":
+			http.Redirect(w, r, r.URL.Query().Get("to"), http.StatusFound)
+		case "/hops":
+			n, _ := strconv.Atoi(r.URL.Query().Get("n"))
+			if n > 0 {
+				http.Redirect(w, r, fmt.Sprintf("%s/hops?n=%d", server.URL, n-1), http.StatusFound)
+				return
+			}
+			fmt.Fprint(w, "ok")
+		default:
+			fmt.Fprint(w, "ok")
+		}
+	})
+	if tls {
+		server = httptest.NewTLSServer(handler)
+	} else {
+		server = httptest.NewServer(handler)
+	}
+	return server
+}
+
+// newTestManager 创建拒绝云元数据地址、127.0.0.2和evil.test的管理器
+func newTestManager(t *testing.T) *acl.Manager {
+	t.Helper()
+	manager := acl.NewManager()
+	if err := manager.SetIPACL([]string{"169.254.169.254", "127.0.0.2"}, types.Blacklist); err != nil {
+		t.Fatalf("SetIPACL() 返回错误: %v", err)
+	}
+	manager.SetDomainACL([]string{"evil.test"}, types.Blacklist, true)
+	return manager
+}
+
+// TestClientRedirects 测试重定向的每一跳都被重新检查
+func TestClientRedirects(t *testing.T) {
+	server := newRedirectServer(false)
+	defer server.Close()
+	client := NewClient(newTestManager(t), Options{MaxRedirects: 3})
+
+	tests := []struct {
+		name    string
+		url     string
+		wantErr error
+	}{
+		{"直接访问允许的目标", server.URL + "/", nil},
+		{"直接访问被拒绝的目标", "http://169.254.169.254/latest/meta-data", ErrDenied},
+		{"混淆写法的被拒绝目标", "http://0x7f000002/", ErrDenied},
+		{"重定向到云元数据地址", server.URL + "/redirect?to=http://169.254.169.254/latest/meta-data", ErrDenied},
+		{"重定向到十进制IP", server.URL + "/redirect?to=http://2130706434/", ErrDenied},
+		{"重定向到被拒绝的域名", server.URL + "/redirect?to=http://api.evil.test/", ErrDenied},
+		{"重定向次数达到限制", server.URL + "/hops?n=3", nil},
+		{"重定向次数超过限制", server.URL + "/hops?n=4", ErrTooManyRedirects},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resp, err := client.Get(tt.url)
+			if tt.wantErr == nil {
+				if err != nil {
+					t.Fatalf("Get() 返回错误: %v", err)
+				}
+				body, _ := io.ReadAll(resp.Body)
+				resp.Body.Close()
+				if string(body) != "ok" {
+					t.Errorf("响应内容 = %q", body)
+				}
+				return
+			}
+			if resp != nil {
+				resp.Body.Close()
+			}
+			if !errors.Is(err, tt.wantErr) {
+				t.Errorf("Get() 错误 = %v, 期望 %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+// TestClientDefaultRedirects 测试MaxRedirects为0时最多跟随DefaultMaxRedirects次重定向
+func TestClientDefaultRedirects(t *testing.T) {
+	server := newRedirectServer(false)
+	defer server.Close()
+	client := NewClient(newTestManager(t), Options{})
+
+	for _, hops := range []int{DefaultMaxRedirects, DefaultMaxRedirects + 1} {
+		resp, err := client.Get(fmt.Sprintf("%s/hops?n=%d", server.URL, hops))
+		if resp != nil {
+			resp.Body.Close()
+		}
+		if want := hops > DefaultMaxRedirects; want != errors.Is(err, ErrTooManyRedirects) {
+			t.Errorf("%d次重定向: Get() 错误 = %v, 期望ErrTooManyRedirects = %v", hops, err, want)
+		}
+	}
+}
+
+// TestClientSchemeDowngrade 测试https到http的降级重定向
+func TestClientSchemeDowngrade(t *testing.T) {
+	plain := newRedirectServer(false)
+	defer plain.Close()
+	secure := newRedirectServer(true)
+	defer secure.Close()
+
+	manager := newTestManager(t)
+	target := secure.URL + "/redirect?to=" + plain.URL + "/"
+
+	tests := []struct {
+		name    string
+		opts    Options
+		wantErr error
+	}{
+		{"默认禁止降级", Options{}, ErrSchemeDowngrade},
+		{"允许降级", Options{AllowSchemeDowngrade: true}, nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client := NewClient(manager, tt.opts)
+			client.Transport = &Transport{Manager: manager, Base: secure.Client().Transport}
+
+			resp, err := client.Get(target)
+			if resp != nil {
+				resp.Body.Close()
+			}
+			if tt.wantErr == nil && err != nil {
+				t.Errorf("Get() 返回错误: %v", err)
+			}
+			if tt.wantErr != nil && !errors.Is(err, tt.wantErr) {
+				t.Errorf("Get() 错误 = %v, 期望 %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+// TestClientNoRedirects 测试不跟随重定向
+func TestClientNoRedirects(t *testing.T) {
+	server := newRedirectServer(false)
+	defer server.Close()
+
+	client := NewClient(newTestManager(t), Options{MaxRedirects: -1})
+	resp, err := client.Get(server.URL + "/hops?n=1")
+	if err != nil {
+		t.Fatalf("Get() 返回错误: %v", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusFound {
+		t.Errorf("状态码 = %d, 期望 302", resp.StatusCode)
+	}
+}
+
+// TestClientHostMismatch 测试Host头与URL主机不一致的请求
+func TestClientHostMismatch(t *testing.T) {
+	server := newRedirectServer(false)
+	defer server.Close()
+
+	tests := []struct {
+		name    string
+		host    string
+		opts    Options
+		wantErr bool
+	}{
+		{"未覆盖Host头", "", Options{}, false},
+		{"Host头与URL主机相同（端口不同）", "127.0.0.1:9999", Options{}, false},
+		{"Host头指向其他主机", "internal.example.com", Options{}, true},
+		{"显式允许不一致", "internal.example.com", Options{AllowHostMismatch: true}, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client := NewClient(newTestManager(t), tt.opts)
+			req, _ := http.NewRequest(http.MethodGet, server.URL, nil)
+			req.Host = tt.host
+
+			resp, err := client.Do(req)
+			if err == nil {
+				resp.Body.Close()
+			}
+			if tt.wantErr {
+				if !errors.Is(err, acl.ErrHostMismatch) || types.ReasonOf(err) != types.ReasonHostMismatch {
+					t.Errorf("Do() 错误 = %v, 期望 ErrHostMismatch, 原因 %q", err, types.ReasonHostMismatch)
+				}
+			} else if err != nil {
+				t.Errorf("Do() 返回错误: %v", err)
+			}
+		})
+	}
+}
+
+// TestTransportSNIMismatch 测试显式SNI与URL主机不一致的请求
+func TestTransportSNIMismatch(t *testing.T) {
+	base := NewTransport(acl.NewManager())
+	base.TLSClientConfig = &tls.Config{ServerName: "other.example.com"}
+	transport := &Transport{Manager: acl.NewManager(), Base: base}
+
+	req, _ := http.NewRequest(http.MethodGet, "https://api.example.com/", nil)
+	if _, err := transport.RoundTrip(req); !errors.Is(err, acl.ErrHostMismatch) {
+		t.Errorf("RoundTrip() 错误 = %v, 期望 ErrHostMismatch", err)
+	}
+}
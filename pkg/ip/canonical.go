@@ -0,0 +1,104 @@
+package ip
+
+import (
+	"net"
+	"strconv"
+	"strings"
+)
+
+// CanonicalizeIP 将各种IP表示法解析为标准的IP地址
+//
+// 参数:
+//   - host: 主机字符串，可以带IPv6方括号
+//
+// 返回:
+//   - net.IP: 解析后的IP地址
+//   - bool: host是否表示一个IP地址
+//
+// 除标准的IPv4/IPv6格式外，还支持inet_aton风格的IPv4表示法，
+// 这些写法常被用来绕过简单的SSRF过滤（浏览器、curl等工具都会接受）:
+//   - 十进制整数: "2130706433" → 127.0.0.1
+//   - 八进制: "0177.0.0.1" → 127.0.0.1
+//   - 十六进制: "0x7f.0.0.1"、"0x7f000001" → 127.0.0.1
+//   - 省略部分: "127.1" → 127.0.0.1，"10.1.258" → 10.1.1.2
+//   - 混合写法: "0x7f.0.1" → 127.0.0.1
+//
+// 在对URL或主机名做访问控制前应先调用此函数，确保混淆写法按实际地址检查。
+//
+// 示例:
+//
+//	ip, ok := ip.CanonicalizeIP("0x7f.0.0.1")
+//	// ip.String() == "127.0.0.1", ok == true
+//
+//	_, ok = ip.CanonicalizeIP("example.com")
+//	// ok == false
+func CanonicalizeIP(host string) (net.IP, bool) {
+	host = strings.TrimSpace(host)
+	host = strings.TrimSuffix(strings.TrimPrefix(host, "["), "]")
+	if host == "" {
+		return nil, false
+	}
+
+	if parsed := net.ParseIP(host); parsed != nil {
+		return parsed, true
+	}
+
+	parts := strings.Split(host, ".")
+	if len(parts) > 4 {
+		return nil, false
+	}
+
+	var value uint64
+	for i, part := range parts {
+		n, ok := parseIPv4Part(part)
+		if !ok {
+			return nil, false
+		}
+
+		if i < len(parts)-1 {
+			// 前面的部分各占一个字节
+			if n > 0xff {
+				return nil, false
+			}
+			value = value<<8 | n
+			continue
+		}
+
+		// 最后一部分填充剩余的所有字节
+		remaining := uint(4 - i)
+		if n >= 1<<(8*remaining) {
+			return nil, false
+		}
+		value = value<<(8*remaining) | n
+	}
+
+	return net.IPv4(byte(value>>24), byte(value>>16), byte(value>>8), byte(value)), true
+}
+
+// parseIPv4Part 按inet_aton规则解析IPv4地址的一个部分
+// "0x"前缀为十六进制，以"0"开头的多位数为八进制，其他为十进制
+func parseIPv4Part(part string) (uint64, bool) {
+	if part == "" {
+		return 0, false
+	}
+
+	base := 10
+	digits := part
+	switch {
+	case strings.HasPrefix(part, "0x") || strings.HasPrefix(part, "0X"):
+		base = 16
+		digits = part[2:]
+	case len(part) > 1 && part[0] == '0':
+		base = 8
+		digits = part[1:]
+	}
+	if digits == "" {
+		return 0, false
+	}
+
+	n, err := strconv.ParseUint(digits, base, 32)
+	if err != nil {
+		return 0, false
+	}
+	return n, true
+}
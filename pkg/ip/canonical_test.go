@@ -0,0 +1,50 @@
+package ip
+
+import "testing"
+
+// TestCanonicalizeIP 测试混淆IP写法的解析
+func TestCanonicalizeIP(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+		want  string
+		ok    bool
+	}{
+		{"标准IPv4", "127.0.0.1", "127.0.0.1", true},
+		{"标准IPv6", "::1", "::1", true},
+		{"带方括号的IPv6", "[2001:db8::1]", "2001:db8::1", true},
+		{"十进制整数", "2130706433", "127.0.0.1", true},
+		{"八进制", "0177.0.0.1", "127.0.0.1", true},
+		{"十六进制", "0x7f.0.0.1", "127.0.0.1", true},
+		{"十六进制整数", "0x7f000001", "127.0.0.1", true},
+		{"大写十六进制前缀", "0X7F.0.0.1", "127.0.0.1", true},
+		{"省略部分", "127.1", "127.0.0.1", true},
+		{"三部分", "10.1.258", "10.1.1.2", true},
+		{"混合写法", "0x7f.0.01", "127.0.0.1", true},
+		{"云元数据地址", "0xa9fea9fe", "169.254.169.254", true},
+		{"零", "0", "0.0.0.0", true},
+		{"域名", "example.com", "", false},
+		{"数字开头的域名", "1.example.com", "", false},
+		{"部分超出范围", "256.0.0.1", "", false},
+		{"最后部分超出范围", "127.0.0.256", "", false},
+		{"整数超出范围", "4294967296", "", false},
+		{"无效八进制", "08.0.0.1", "", false},
+		{"空的十六进制", "0x.0.0.1", "", false},
+		{"空部分", "127..0.1", "", false},
+		{"部分过多", "1.2.3.4.5", "", false},
+		{"负数", "-1", "", false},
+		{"空字符串", "", "", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := CanonicalizeIP(tt.input)
+			if ok != tt.ok {
+				t.Fatalf("CanonicalizeIP(%q) ok = %v, 期望 %v", tt.input, ok, tt.ok)
+			}
+			if ok && got.String() != tt.want {
+				t.Errorf("CanonicalizeIP(%q) = %s, 期望 %s", tt.input, got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,133 @@
+package ip
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/cyberspacesec/go-acl/pkg/types"
+)
+
+// ErrPartialOverlap 表示CheckCIDR检查的网段只有一部分地址在列表中
+var ErrPartialOverlap = errors.New("网段与列表部分重叠")
+
+// CheckCIDR 检查整个网段的访问权限
+//
+// 参数:
+//   - cidr: 要检查的网段，如"10.1.0.0/16"；单个IP视为/32或/128的网段
+//
+// 返回:
+//   - types.Permission: 网段内所有地址共同的访问权限
+//   - error: 可能的错误:
+//   - types.ErrNoACL: a为nil
+//   - ErrInvalidCIDR: 提供了无效的网段格式
+//   - ErrFamilyNotAllowed: 网段的地址族不被允许
+//   - ErrPartialOverlap: 网段内一部分地址在列表中、一部分不在，此时权限为types.Denied
+//
+// 网段被列表中的范围完全包含（可以由多个较小的范围拼接而成，如两个/25包含一个/24）时，
+// 结果与Check检查网段内任一地址相同；与列表中的所有范围都不相交时结果相反。
+// 部分重叠时网段内的地址有的允许、有的拒绝，不能整体放行，因此返回拒绝和ErrPartialOverlap，
+// 调用方可以用errors.Is区分这种情况，例如在校验出站规则时提示用户拆分网段。
+//
+// 耗时与列表中落在网段内的前缀数量成正比，检查很大的网段（如"0.0.0.0/0"）时会遍历整个列表。
+//
+// 示例:
+//
+//	deny, _ := ip.NewIPACL([]string{"10.0.0.0/8", "169.254.169.254"}, types.Blacklist)
+//
+//	perm, err := deny.CheckCIDR("10.1.0.0/16")    // types.Denied, nil
+//	perm, err = deny.CheckCIDR("192.0.2.0/24")    // types.Allowed, nil
+//	perm, err = deny.CheckCIDR("169.254.0.0/16")  // types.Denied, ErrPartialOverlap
+//	if errors.Is(err, ip.ErrPartialOverlap) {
+//	    log.Printf("出站规则 %s 包含被禁止的地址", "169.254.0.0/16")
+//	}
+func (a *IPACL) CheckCIDR(cidr string) (types.Permission, error) {
+	if a == nil {
+		return types.Denied, types.ErrNoACL
+	}
+	r, err := parseIPRange(cidr)
+	if err != nil {
+		return types.Denied, ErrInvalidCIDR
+	}
+	if !a.family.Contains(r.IPNet.IP) {
+		return types.Denied, ErrFamilyNotAllowed
+	}
+
+	key, bits, root := netKey(r.IPNet)
+	var matched bool
+	switch a.trie().overlap(root, key, bits) {
+	case overlapPartial:
+		return types.Denied, fmt.Errorf("%w: %s", ErrPartialOverlap, r.IPNet)
+	case overlapFull:
+		matched = true
+	}
+	if matched == (a.listType == types.Blacklist) {
+		return types.Denied, nil
+	}
+	return types.Allowed, nil
+}
+
+// overlap 表示网段与基数树中前缀的重叠程度
+type overlap int
+
+const (
+	overlapNone overlap = iota
+	overlapPartial
+	overlapFull
+)
+
+// overlap 返回给定前缀与基数树中所有前缀的并集的重叠程度，t为nil时视为空树
+func (t *ipTrie) overlap(root uint32, key [16]byte, bits uint8) overlap {
+	if t == nil {
+		return overlapNone
+	}
+	cur := root
+	for {
+		n := t.node(cur)
+		if n.bits >= bits {
+			// 节点位于网段内，由其子树决定
+			if commonBits(n.key, key, bits) != bits {
+				return overlapNone
+			}
+			return t.region(cur, bits)
+		}
+		if commonBits(n.key, key, n.bits) != n.bits {
+			return overlapNone
+		}
+		if n.terminal {
+			// 更短的前缀包含整个网段
+			return overlapFull
+		}
+		cur = n.children[bitAt(key, n.bits)]
+		if cur == nilNode {
+			return overlapNone
+		}
+	}
+}
+
+// region 返回前缀长度为bits的网段被节点idx的子树覆盖的程度，节点的前缀必须位于网段内
+func (t *ipTrie) region(idx uint32, bits uint8) overlap {
+	n := t.node(idx)
+	var covered overlap
+	if n.terminal {
+		covered = overlapFull
+	} else {
+		// 压缩路径的节点没有终止标记时是分叉点，两半都被完全覆盖时节点的前缀才被完全覆盖
+		var halves [2]overlap
+		for i, child := range n.children {
+			if child != nilNode {
+				halves[i] = t.region(child, n.bits+1)
+			}
+		}
+		switch {
+		case halves[0] == overlapFull && halves[1] == overlapFull:
+			covered = overlapFull
+		case halves[0] != overlapNone || halves[1] != overlapNone:
+			covered = overlapPartial
+		}
+	}
+	// 节点的前缀比网段长，只覆盖网段的一部分
+	if covered == overlapFull && n.bits > bits {
+		return overlapPartial
+	}
+	return covered
+}
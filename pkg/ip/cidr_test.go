@@ -0,0 +1,105 @@
+package ip
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/cyberspacesec/go-acl/pkg/types"
+)
+
+// TestCheckCIDR 测试网段被完全包含、不相交和部分重叠时的结果
+func TestCheckCIDR(t *testing.T) {
+	ranges := []string{
+		"10.0.0.0/8",
+		"192.0.2.0/25",
+		"192.0.2.128/25",
+		"198.51.100.0/25",
+		"169.254.169.254",
+		"2001:db8::/32",
+	}
+
+	tests := []struct {
+		name      string
+		cidr      string
+		blacklist types.Permission
+		whitelist types.Permission
+		wantErr   error
+	}{
+		{"被较大的范围包含", "10.1.0.0/16", types.Denied, types.Allowed, nil},
+		{"与范围相同", "10.0.0.0/8", types.Denied, types.Allowed, nil},
+		{"由两个较小的范围拼接包含", "192.0.2.0/24", types.Denied, types.Allowed, nil},
+		{"不相交", "203.0.113.0/24", types.Allowed, types.Denied, nil},
+		{"只有一半被包含", "198.51.100.0/24", types.Denied, types.Denied, ErrPartialOverlap},
+		{"包含单个IP的规则", "169.254.0.0/16", types.Denied, types.Denied, ErrPartialOverlap},
+		{"比范围大", "10.0.0.0/7", types.Denied, types.Denied, ErrPartialOverlap},
+		{"所有地址", "0.0.0.0/0", types.Denied, types.Denied, ErrPartialOverlap},
+		{"单个IP", "10.9.9.9", types.Denied, types.Allowed, nil},
+		{"IPv6", "2001:db8:1::/48", types.Denied, types.Allowed, nil},
+		{"IPv4映射的IPv6网段", "::ffff:10.0.0.0/104", types.Denied, types.Allowed, nil},
+		{"无效的网段", "10.0.0.0/33", types.Denied, types.Denied, ErrInvalidCIDR},
+	}
+
+	for _, compress := range []bool{true, false} {
+		blacklist, err := NewIPACLWithOptions(ranges, types.Blacklist, MatcherOptions{CompressPaths: compress})
+		if err != nil {
+			t.Fatalf("NewIPACLWithOptions() 返回错误: %v", err)
+		}
+		whitelist, err := NewIPACLWithOptions(ranges, types.Whitelist, MatcherOptions{CompressPaths: compress})
+		if err != nil {
+			t.Fatalf("NewIPACLWithOptions() 返回错误: %v", err)
+		}
+		for _, tt := range tests {
+			t.Run(tt.name, func(t *testing.T) {
+				for _, c := range []struct {
+					acl  *IPACL
+					want types.Permission
+				}{{blacklist, tt.blacklist}, {whitelist, tt.whitelist}} {
+					perm, err := c.acl.CheckCIDR(tt.cidr)
+					if !errors.Is(err, tt.wantErr) || (tt.wantErr == nil && err != nil) {
+						t.Errorf("%s CheckCIDR(%q) 错误 = %v, 期望 %v（压缩路径: %v）", c.acl.GetListType(), tt.cidr, err, tt.wantErr, compress)
+					}
+					if perm != c.want {
+						t.Errorf("%s CheckCIDR(%q) = %v, 期望 %v（压缩路径: %v）", c.acl.GetListType(), tt.cidr, perm, c.want, compress)
+					}
+				}
+			})
+		}
+	}
+}
+
+// TestCheckCIDREdgeCases 测试空列表、nil列表、地址族限制和其他匹配引擎
+func TestCheckCIDREdgeCases(t *testing.T) {
+	var nilACL *IPACL
+	if _, err := nilACL.CheckCIDR("10.0.0.0/8"); !errors.Is(err, types.ErrNoACL) {
+		t.Errorf("nil列表 CheckCIDR() 错误 = %v, 期望 types.ErrNoACL", err)
+	}
+
+	empty, _ := NewIPACL(nil, types.Blacklist)
+	if perm, err := empty.CheckCIDR("0.0.0.0/0"); err != nil || perm != types.Allowed {
+		t.Errorf("空黑名单 CheckCIDR() = %v, %v, 期望允许", perm, err)
+	}
+
+	v4, _ := NewIPACL([]string{"10.0.0.0/8"}, types.Blacklist)
+	v4.SetFamily(FamilyIPv4)
+	if _, err := v4.CheckCIDR("2001:db8::/32"); !errors.Is(err, ErrFamilyNotAllowed) {
+		t.Errorf("地址族不被允许时错误 = %v, 期望 ErrFamilyNotAllowed", err)
+	}
+
+	hashed, _ := NewIPACL([]string{"10.0.0.0/9", "10.128.0.0/9"}, types.Blacklist)
+	hashed.SetMatchEngine(NewHashEngine())
+	if perm, err := hashed.CheckCIDR("10.0.0.0/8"); err != nil || perm != types.Denied {
+		t.Errorf("哈希匹配引擎 CheckCIDR() = %v, %v, 期望拒绝", perm, err)
+	}
+
+	// 移除规则后基数树保留不再终止的节点，不应计入重叠
+	removed, _ := NewIPACL([]string{"10.0.0.0/9", "10.128.0.0/9"}, types.Blacklist)
+	if err := removed.Remove("10.128.0.0/9"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := removed.CheckCIDR("10.0.0.0/8"); !errors.Is(err, ErrPartialOverlap) {
+		t.Errorf("移除一半后错误 = %v, 期望 ErrPartialOverlap", err)
+	}
+	if perm, err := removed.CheckCIDR("10.128.0.0/10"); err != nil || perm != types.Allowed {
+		t.Errorf("已移除的网段 CheckCIDR() = %v, %v, 期望允许", perm, err)
+	}
+}
@@ -0,0 +1,92 @@
+package ip
+
+// Coverage 表示列表B中一条被列表A覆盖的规则
+//
+// 字段说明:
+//   - Rule: 列表B中的规则（原始输入的IP/CIDR字符串）
+//   - CoveredBy: 列表A中覆盖该规则的规则，存在多条时为范围最大的一条
+type Coverage struct {
+	Rule      string
+	CoveredBy string
+}
+
+// Covers 报告列表B中哪些规则已被列表A完全覆盖
+//
+// 参数:
+//   - a: 覆盖方，例如人工维护的主列表
+//   - b: 被检查的列表，例如第三方威胁情报源
+//
+// 返回:
+//   - []Coverage: B中被A覆盖的规则，按B中的顺序排列；没有被覆盖的规则时返回nil
+//
+// 规则X覆盖规则Y是指Y包含的每个地址都在X中，即X的前缀长度不大于Y且网络地址相同。
+// IPv4映射的IPv6网络与对应的IPv4网络视为相同。查找使用A的基数树，
+// 总耗时与B的规则数量成正比，与A的大小基本无关。
+//
+// 合并有重叠的第三方列表时，可以先用Covers找出已被主列表覆盖的条目再跳过，
+// 使主列表保持最小。注意Covers(a, a)会报告每条规则被自身覆盖。
+//
+// 示例:
+//
+//	master, _ := ip.NewIPACL([]string{"10.0.0.0/8"}, types.Blacklist)
+//	feed, _ := ip.NewIPACL([]string{"10.1.0.0/16", "192.0.2.1"}, types.Blacklist)
+//
+//	for _, c := range ip.Covers(master, feed) {
+//	    fmt.Printf("%s 已被 %s 覆盖\n", c.Rule, c.CoveredBy) // 10.1.0.0/16 已被 10.0.0.0/8 覆盖
+//	}
+func Covers(a, b *IPACL) []Coverage {
+	if a == nil || b == nil || len(a.ranges) == 0 {
+		return nil
+	}
+
+	// 基数树只记录前缀，需要通过前缀找回A中的原始规则
+	type prefix struct {
+		key  [16]byte
+		bits uint8
+		root uint32
+	}
+	origins := make(map[prefix]string, len(a.ranges))
+	for _, r := range a.ranges {
+		key, bits, root := netKey(r.IPNet)
+		p := prefix{key, bits, root}
+		if _, ok := origins[p]; !ok {
+			origins[p] = r.Original
+		}
+	}
+
+	var covered []Coverage
+	for _, r := range b.ranges {
+		key, bits, root := netKey(r.IPNet)
+		if n := a.trie().covering(root, key, bits); n != nil {
+			covered = append(covered, Coverage{
+				Rule:      r.Original,
+				CoveredBy: origins[prefix{n.key, n.bits, root}],
+			})
+		}
+	}
+	return covered
+}
+
+// covering 返回基数树中包含给定前缀的最短前缀节点，不存在时返回nil
+func (t *ipTrie) covering(root uint32, key [16]byte, bits uint8) *trieNode {
+	if t == nil {
+		return nil
+	}
+	cur := root
+	for {
+		n := t.node(cur)
+		if n.bits > bits || commonBits(n.key, key, n.bits) != n.bits {
+			return nil
+		}
+		if n.terminal {
+			return n
+		}
+		if n.bits == bits {
+			return nil
+		}
+		cur = n.children[bitAt(key, n.bits)]
+		if cur == nilNode {
+			return nil
+		}
+	}
+}
@@ -0,0 +1,74 @@
+package ip
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/cyberspacesec/go-acl/pkg/types"
+)
+
+// TestCovers 测试列表之间的CIDR覆盖关系
+func TestCovers(t *testing.T) {
+	master, err := NewIPACL([]string{
+		"10.0.0.0/8",
+		"10.1.0.0/16",
+		"192.0.2.1",
+		"2001:db8::/32",
+	}, types.Blacklist)
+	if err != nil {
+		t.Fatalf("NewIPACL() 返回错误: %v", err)
+	}
+
+	tests := []struct {
+		name string
+		feed []string
+		want []Coverage
+	}{
+		{
+			name: "子网被覆盖时报告范围最大的规则",
+			feed: []string{"10.1.2.0/24", "172.16.0.0/12"},
+			want: []Coverage{{Rule: "10.1.2.0/24", CoveredBy: "10.0.0.0/8"}},
+		},
+		{
+			name: "相同的规则",
+			feed: []string{"10.0.0.0/8", "192.0.2.1"},
+			want: []Coverage{
+				{Rule: "10.0.0.0/8", CoveredBy: "10.0.0.0/8"},
+				{Rule: "192.0.2.1", CoveredBy: "192.0.2.1"},
+			},
+		},
+		{
+			name: "更大的范围不被覆盖",
+			feed: []string{"10.0.0.0/7", "192.0.2.0/24"},
+			want: nil,
+		},
+		{
+			name: "IPv6和IPv4映射地址",
+			feed: []string{"2001:db8:1::/48", "2001:db9::/32", "::ffff:10.9.9.9"},
+			want: []Coverage{
+				{Rule: "2001:db8:1::/48", CoveredBy: "2001:db8::/32"},
+				{Rule: "::ffff:10.9.9.9", CoveredBy: "10.0.0.0/8"},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			feed, err := NewIPACL(tt.feed, types.Blacklist)
+			if err != nil {
+				t.Fatalf("NewIPACL() 返回错误: %v", err)
+			}
+			if got := Covers(master, feed); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Covers() = %v, 期望 %v", got, tt.want)
+			}
+		})
+	}
+
+	empty, _ := NewIPACL(nil, types.Blacklist)
+	if got := Covers(empty, master); got != nil {
+		t.Errorf("空列表不应覆盖任何规则: %v", got)
+	}
+	if got := Covers(nil, master); got != nil {
+		t.Errorf("nil列表不应覆盖任何规则: %v", got)
+	}
+}
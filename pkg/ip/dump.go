@@ -0,0 +1,50 @@
+package ip
+
+import (
+	"bufio"
+	"io"
+	"strings"
+
+	"github.com/cyberspacesec/go-acl/pkg/types"
+)
+
+// String 返回IP访问控制列表的简短摘要，实现fmt.Stringer
+//
+// 返回:
+//   - string: 例如"blacklist, 1,204 ranges"，限制了地址族时追加", family=ipv4"
+//
+// 示例:
+//
+//	log.Printf("IP ACL: %s", acl) // IP ACL: whitelist, 12 ranges
+func (a *IPACL) String() string {
+	parts := []string{a.listType.String(), types.FormatCount(len(a.ranges), "range", "ranges")}
+	if a.family != FamilyAny {
+		parts = append(parts, "family="+a.family.String())
+	}
+	return strings.Join(parts, ", ")
+}
+
+// Dump 将摘要和全部IP/CIDR写入w，用于调试
+//
+// 参数:
+//   - w: 输出目标
+//
+// 返回:
+//   - error: 写入过程中的错误
+//
+// 第一行是String()的摘要，之后每行一条规则（缩进两个空格），顺序与GetIPRanges相同。
+//
+// 示例:
+//
+//	acl.Dump(os.Stderr)
+//	// blacklist, 2 ranges
+//	//   10.0.0.0/8
+//	//   192.168.1.1
+func (a *IPACL) Dump(w io.Writer) error {
+	writer := bufio.NewWriter(w)
+	writer.WriteString(a.String() + "\n")
+	for _, r := range a.ranges {
+		writer.WriteString("  " + r.Original + "\n")
+	}
+	return writer.Flush()
+}
@@ -0,0 +1,75 @@
+package ip
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/cyberspacesec/go-acl/pkg/types"
+)
+
+// TestIPACLString 测试IP访问控制列表的摘要
+func TestIPACLString(t *testing.T) {
+	many := make([]string, 1204)
+	for i := range many {
+		many[i] = fmt.Sprintf("10.%d.%d.0/24", i/256, i%256)
+	}
+
+	tests := []struct {
+		name   string
+		ranges []string
+		family Family
+		want   string
+	}{
+		{"空列表", nil, FamilyAny, "blacklist, 0 ranges"},
+		{"单条规则", []string{"10.0.0.0/8"}, FamilyAny, "blacklist, 1 range"},
+		{"千位分隔符", many, FamilyAny, "blacklist, 1,204 ranges"},
+		{"限制地址族", []string{"10.0.0.0/8"}, FamilyIPv4, "blacklist, 1 range, family=ipv4"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			acl, err := NewIPACL(tt.ranges, types.Blacklist)
+			if err != nil {
+				t.Fatalf("NewIPACL() 返回错误: %v", err)
+			}
+			if tt.family != FamilyAny {
+				if err := acl.SetFamily(tt.family); err != nil {
+					t.Fatalf("SetFamily() 返回错误: %v", err)
+				}
+			}
+			if got := acl.String(); got != tt.want {
+				t.Errorf("String() = %q, 期望 %q", got, tt.want)
+			}
+			if got := fmt.Sprint(acl); got != tt.want {
+				t.Errorf("fmt.Sprint() = %q, 期望 %q", got, tt.want)
+			}
+		})
+	}
+}
+
+// errWriter 总是返回错误的io.Writer
+type errWriter struct{}
+
+func (errWriter) Write([]byte) (int, error) {
+	return 0, errors.New("写入失败")
+}
+
+// TestIPACLDump 测试输出完整的规则列表
+func TestIPACLDump(t *testing.T) {
+	acl, _ := NewIPACL([]string{"10.0.0.0/8", "192.168.1.1"}, types.Whitelist)
+
+	var buf bytes.Buffer
+	if err := acl.Dump(&buf); err != nil {
+		t.Fatalf("Dump() 返回错误: %v", err)
+	}
+	want := "whitelist, 2 ranges\n  10.0.0.0/8\n  192.168.1.1\n"
+	if buf.String() != want {
+		t.Errorf("Dump() 输出 = %q, 期望 %q", buf.String(), want)
+	}
+
+	if err := acl.Dump(errWriter{}); err == nil {
+		t.Error("写入失败时 Dump() 应返回错误")
+	}
+}
@@ -0,0 +1,212 @@
+package ip
+
+import (
+	"net"
+	"sort"
+	"unsafe"
+)
+
+// MatchEngine 是IPACL判断IP是否匹配列表的引擎
+//
+// IPACL负责解析、去重、地址族限制和列表类型的语义，引擎只维护网络集合并回答"IP是否落在某个网络中"，
+// 因此可以替换为其他数据结构（如按前缀长度分桶的哈希表，或同步到外部eBPF映射的实现），
+// 而不改变IPACL的公开API。内置的引擎见NewTrieEngine和NewHashEngine。
+//
+// 方法说明:
+//   - Insert: 加入一个网络，已存在时不做任何事
+//   - Remove: 移除一个网络，不存在时不做任何事
+//   - Match: 判断IP是否落在任意一个网络中
+//
+// IPv4映射的IPv6地址和网络应与对应的IPv4地址和网络视为相同，与net.IPNet.Contains一致。
+// 引擎不需要自行加锁，IPACL的并发约定（检查可以并发，修改需要与检查互斥）同样适用于引擎。
+type MatchEngine interface {
+	Insert(network *net.IPNet)
+	Remove(network *net.IPNet)
+	Match(ip net.IP) bool
+}
+
+// engineStats 是可以报告内存统计的引擎，用于MatcherStats
+type engineStats interface {
+	stats() MatcherStats
+}
+
+// NewTrieEngine 创建基数树引擎，这是IPACL默认使用的引擎
+//
+// 参数:
+//   - opts: 基数树的调优选项，见MatcherOptions
+//
+// 返回:
+//   - MatchEngine: 查找耗时只与地址位数相关的引擎；移除的前缀占用的节点不回收，
+//     大量移除后可通过SetMatcherOptions重建
+func NewTrieEngine(opts MatcherOptions) MatchEngine {
+	return newIPTrie(opts)
+}
+
+// Insert 实现MatchEngine
+func (t *ipTrie) Insert(network *net.IPNet) {
+	t.insertNet(network)
+}
+
+// Remove 实现MatchEngine，只取消前缀的标记，不回收节点
+func (t *ipTrie) Remove(network *net.IPNet) {
+	key, bits, root := netKey(network)
+	cur := root
+	for {
+		n := t.node(cur)
+		if n.bits > bits || commonBits(n.key, key, n.bits) != n.bits {
+			return
+		}
+		if n.bits == bits {
+			if n.terminal {
+				n.terminal = false
+				t.prefixes--
+			}
+			return
+		}
+		cur = n.children[bitAt(key, n.bits)]
+		if cur == nilNode {
+			return
+		}
+	}
+}
+
+// Match 实现MatchEngine
+func (t *ipTrie) Match(ip net.IP) bool {
+	return t.contains(ip)
+}
+
+// hashEntryOverhead 是估算哈希表每个条目额外开销（哈希桶、tophash等）使用的字节数
+const hashEntryOverhead = 16
+
+// hashEngine 按前缀长度分桶的哈希表引擎
+//
+// buckets[root][bits]保存前缀长度为bits的网络，root与基数树相同（0为IPv4，1为IPv6）；
+// lengths[root]是存在网络的前缀长度，从长到短排列。
+type hashEngine struct {
+	buckets [2]map[uint8]map[[16]byte]struct{}
+	lengths [2][]uint8
+	count   int
+}
+
+// NewHashEngine 创建按前缀长度分桶的哈希表引擎
+//
+// 返回:
+//   - MatchEngine: 查找时对每种出现过的前缀长度做一次哈希查找的引擎
+//
+// 列表中的前缀长度种类很少时（例如只有单个IP的封禁列表，或只有/24和/32的威胁情报），
+// 一次查找只需要一两次哈希查找，比基数树逐位遍历更快，移除也会立即释放内存；
+// 前缀长度分散时查找次数随种类增加，此时应使用默认的基数树。
+//
+// 示例:
+//
+//	acl, _ := ip.NewIPACL(blockedIPs, types.Blacklist)
+//	acl.SetMatchEngine(ip.NewHashEngine())
+func NewHashEngine() MatchEngine {
+	return &hashEngine{}
+}
+
+// Insert 实现MatchEngine
+func (e *hashEngine) Insert(network *net.IPNet) {
+	key, bits, root := netKey(network)
+	if e.buckets[root] == nil {
+		e.buckets[root] = make(map[uint8]map[[16]byte]struct{})
+	}
+	bucket, ok := e.buckets[root][bits]
+	if !ok {
+		bucket = make(map[[16]byte]struct{})
+		e.buckets[root][bits] = bucket
+		e.lengths[root] = append(e.lengths[root], bits)
+		sort.Slice(e.lengths[root], func(i, j int) bool { return e.lengths[root][i] > e.lengths[root][j] })
+	}
+	if _, exists := bucket[key]; !exists {
+		bucket[key] = struct{}{}
+		e.count++
+	}
+}
+
+// Remove 实现MatchEngine
+func (e *hashEngine) Remove(network *net.IPNet) {
+	key, bits, root := netKey(network)
+	bucket, ok := e.buckets[root][bits]
+	if !ok {
+		return
+	}
+	if _, exists := bucket[key]; !exists {
+		return
+	}
+	delete(bucket, key)
+	e.count--
+	if len(bucket) > 0 {
+		return
+	}
+	delete(e.buckets[root], bits)
+	lengths := e.lengths[root][:0]
+	for _, l := range e.lengths[root] {
+		if l != bits {
+			lengths = append(lengths, l)
+		}
+	}
+	e.lengths[root] = lengths
+}
+
+// Match 实现MatchEngine
+func (e *hashEngine) Match(ip net.IP) bool {
+	var key [16]byte
+	root := 0
+	if ip4 := ip.To4(); ip4 != nil {
+		copy(key[:], ip4)
+	} else if ip16 := ip.To16(); ip16 != nil {
+		copy(key[:], ip16)
+		root = 1
+	} else {
+		return false
+	}
+	for _, bits := range e.lengths[root] {
+		if _, ok := e.buckets[root][bits][maskKey(key, bits)]; ok {
+			return true
+		}
+	}
+	return false
+}
+
+// stats 返回哈希表的内存统计信息，哈希表没有节点，Nodes和AllocatedNodes为0
+func (e *hashEngine) stats() MatcherStats {
+	return MatcherStats{
+		Prefixes: e.count,
+		Bytes:    int(unsafe.Sizeof(*e)) + e.count*(int(unsafe.Sizeof([16]byte{}))+hashEntryOverhead),
+	}
+}
+
+// SetMatchEngine 替换匹配IP使用的引擎
+//
+// 参数:
+//   - engine: 新的空引擎，当前列表中的所有范围会被加入其中；为nil时恢复默认的基数树
+//
+// 替换引擎不改变检查结果，只改变匹配的数据结构。Match、MatchAll、Covers等诊断方法不使用引擎，
+// Snapshot只在使用基数树时附带匹配器数据。与Add、Remove相同，不能与检查并发调用。
+//
+// 示例:
+//
+//	acl.SetMatchEngine(ip.NewHashEngine())
+func (a *IPACL) SetMatchEngine(engine MatchEngine) {
+	if engine == nil {
+		a.rebuildMatcher()
+		return
+	}
+	for _, r := range a.ranges {
+		engine.Insert(r.IPNet)
+	}
+	a.engine = engine
+}
+
+// trie 返回用于Covers等结构性查询的基数树，使用其他引擎时按当前的范围临时构建
+func (a *IPACL) trie() *ipTrie {
+	if t, ok := a.engine.(*ipTrie); ok {
+		return t
+	}
+	t := newIPTrie(a.opts)
+	for _, r := range a.ranges {
+		t.insertNet(r.IPNet)
+	}
+	return t
+}
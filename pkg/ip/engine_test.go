@@ -0,0 +1,150 @@
+package ip
+
+import (
+	"encoding/binary"
+	"math/rand"
+	"net"
+	"testing"
+
+	"github.com/cyberspacesec/go-acl/pkg/types"
+)
+
+// recordingEngine 记录调用并以线性扫描匹配的引擎，用于验证IPACL与引擎的交互
+type recordingEngine struct {
+	nets    map[string]*net.IPNet
+	removed []string
+}
+
+func (e *recordingEngine) Insert(n *net.IPNet) {
+	if e.nets == nil {
+		e.nets = make(map[string]*net.IPNet)
+	}
+	e.nets[n.String()] = n
+}
+
+func (e *recordingEngine) Remove(n *net.IPNet) {
+	delete(e.nets, n.String())
+	e.removed = append(e.removed, n.String())
+}
+
+func (e *recordingEngine) Match(ip net.IP) bool {
+	for _, n := range e.nets {
+		if n.Contains(ip) {
+			return true
+		}
+	}
+	return false
+}
+
+// TestMatchEngines 测试内置引擎在插入和移除后与线性扫描的结果一致
+func TestMatchEngines(t *testing.T) {
+	engines := map[string]func() MatchEngine{
+		"基数树": func() MatchEngine { return NewTrieEngine(DefaultMatcherOptions) },
+		"哈希表": NewHashEngine,
+	}
+
+	for name, newEngine := range engines {
+		t.Run(name, func(t *testing.T) {
+			r := rand.New(rand.NewSource(2))
+			engine := newEngine()
+			var nets []*net.IPNet
+			seen := make(map[string]bool)
+			for _, c := range randomCIDRs(r, 400) {
+				_, n, _ := net.ParseCIDR(c)
+				if ones, _ := n.Mask.Size(); ones < 8 || seen[n.String()] {
+					continue
+				}
+				seen[n.String()] = true
+				nets = append(nets, n)
+				engine.Insert(n)
+			}
+			// 移除一半的网络
+			for _, n := range nets[:len(nets)/2] {
+				engine.Remove(n)
+			}
+			remaining := nets[len(nets)/2:]
+			// 移除不存在的网络不影响结果
+			engine.Remove(&net.IPNet{IP: net.IP{203, 0, 113, 0}, Mask: net.CIDRMask(24, 32)})
+
+			for i := 0; i < 5000; i++ {
+				var target net.IP
+				if i%2 == 0 {
+					n := nets[r.Intn(len(nets))]
+					target = make(net.IP, len(n.IP))
+					copy(target, n.IP)
+					target[len(target)-1] ^= byte(r.Intn(4))
+				} else {
+					target = make(net.IP, net.IPv4len)
+					binary.BigEndian.PutUint32(target, r.Uint32())
+				}
+				if got, want := engine.Match(target), linearContains(remaining, target); got != want {
+					t.Fatalf("Match(%s) = %v, 线性扫描结果为 %v", target, got, want)
+				}
+			}
+		})
+	}
+}
+
+// TestSetMatchEngine 测试替换引擎不改变检查结果，且Add、Remove同步到引擎
+func TestSetMatchEngine(t *testing.T) {
+	acl, err := NewIPACL([]string{"10.0.0.0/8", "10.1.2.3/8", "192.0.2.1", "::ffff:198.51.100.0/120"}, types.Blacklist)
+	if err != nil {
+		t.Fatalf("NewIPACL() 返回错误: %v", err)
+	}
+	engine := &recordingEngine{}
+	acl.SetMatchEngine(engine)
+
+	if err := acl.Add("2001:db8::/32"); err != nil {
+		t.Fatalf("Add() 返回错误: %v", err)
+	}
+	// 同一网络的另一种写法仍在列表中，不应从引擎移除
+	if err := acl.Remove("10.1.2.3/8", "192.0.2.1"); err != nil {
+		t.Fatalf("Remove() 返回错误: %v", err)
+	}
+	if len(engine.removed) != 1 || engine.removed[0] != "192.0.2.1/32" {
+		t.Errorf("引擎移除的网络 = %v, 期望 [192.0.2.1/32]", engine.removed)
+	}
+
+	tests := []struct {
+		ip   string
+		want types.Permission
+	}{
+		{"10.9.9.9", types.Denied},
+		{"192.0.2.1", types.Allowed},
+		{"198.51.100.7", types.Denied},
+		{"2001:db8::1", types.Denied},
+		{"203.0.113.1", types.Allowed},
+	}
+	for _, hash := range []bool{false, true} {
+		if hash {
+			acl.SetMatchEngine(NewHashEngine())
+		}
+		for _, tt := range tests {
+			if got, err := acl.Check(tt.ip); err != nil || got != tt.want {
+				t.Errorf("哈希表=%v Check(%s) = %v, %v, 期望 %v", hash, tt.ip, got, err, tt.want)
+			}
+		}
+	}
+	if stats := acl.MatcherStats(); stats.Prefixes != 3 || stats.Nodes != 0 {
+		t.Errorf("哈希表引擎的 MatcherStats() = %+v, 期望3个前缀、没有节点", stats)
+	}
+
+	// Covers和Snapshot不依赖引擎
+	other, _ := NewIPACL([]string{"10.5.0.0/16"}, types.Blacklist)
+	if covered := Covers(acl, other); len(covered) != 1 || covered[0].CoveredBy != "10.0.0.0/8" {
+		t.Errorf("Covers() = %v, 期望被10.0.0.0/8覆盖", covered)
+	}
+	restored, err := NewIPACLFromSnapshot(acl.Snapshot())
+	if err != nil {
+		t.Fatalf("NewIPACLFromSnapshot() 返回错误: %v", err)
+	}
+	if perm, _ := restored.Check("198.51.100.7"); perm != types.Denied {
+		t.Errorf("恢复后 Check(198.51.100.7) = %v, 期望 Denied", perm)
+	}
+
+	// 恢复默认的基数树
+	acl.SetMatchEngine(nil)
+	if stats := acl.MatcherStats(); stats.Nodes == 0 {
+		t.Errorf("恢复基数树后 MatcherStats() = %+v, 期望有节点", stats)
+	}
+}
@@ -0,0 +1,144 @@
+package ip_test
+
+import (
+	"errors"
+	"fmt"
+	"os"
+
+	"github.com/cyberspacesec/go-acl/pkg/ip"
+	"github.com/cyberspacesec/go-acl/pkg/types"
+)
+
+func ExampleNewIPACL() {
+	blacklist, err := ip.NewIPACL([]string{"192.168.1.100", "10.0.0.0/8", "2001:db8::/32"}, types.Blacklist)
+	if err != nil {
+		fmt.Println("创建失败:", err)
+		return
+	}
+	for _, addr := range []string{"10.1.2.3", "192.168.1.100", "8.8.8.8", "2001:db8::1"} {
+		perm, _ := blacklist.Check(addr)
+		fmt.Println(addr, perm)
+	}
+	// Output:
+	// 10.1.2.3 denied
+	// 192.168.1.100 denied
+	// 8.8.8.8 allowed
+	// 2001:db8::1 denied
+}
+
+func ExampleIPACL_Add() {
+	acl, _ := ip.NewIPACL(nil, types.Blacklist)
+	if err := acl.Add("203.0.113.0/24", "198.51.100.7"); err != nil {
+		fmt.Println("添加失败:", err)
+		return
+	}
+	fmt.Println(acl.GetIPRanges())
+
+	acl.Remove("203.0.113.0/24")
+	perm, _ := acl.Check("203.0.113.9")
+	fmt.Println("移除后:", perm)
+
+	err := acl.Add("not-an-ip")
+	fmt.Println("无效输入:", err)
+	// Output:
+	// [203.0.113.0/24 198.51.100.7]
+	// 移除后: allowed
+	// 无效输入: 无效的IP地址格式
+}
+
+func ExampleIPACL_AddPredefinedSet() {
+	// SSRF防护: 拒绝内网、本机和云元数据地址
+	blacklist, _ := ip.NewIPACL(nil, types.Blacklist)
+	for _, set := range []ip.PredefinedSet{ip.PrivateNetworks, ip.LoopbackNetworks, ip.CloudMetadata} {
+		if err := blacklist.AddPredefinedSet(set, false); err != nil {
+			fmt.Println("添加失败:", err)
+			return
+		}
+	}
+	for _, addr := range []string{"169.254.169.254", "127.0.0.1", "172.16.5.4", "93.184.216.34"} {
+		perm, _ := blacklist.Check(addr)
+		fmt.Println(addr, perm)
+	}
+
+	err := blacklist.AddPredefinedSet("no_such_set", false)
+	fmt.Println(err)
+	// Output:
+	// 169.254.169.254 denied
+	// 127.0.0.1 denied
+	// 172.16.5.4 denied
+	// 93.184.216.34 allowed
+	// 无效的预定义IP集合
+}
+
+func ExampleNewIPACLWithDefaults() {
+	// 白名单中允许公共DNS服务器以及列出的地址
+	whitelist, _ := ip.NewIPACLWithDefaults([]string{"203.0.113.10"}, types.Whitelist, []ip.PredefinedSet{ip.PublicDNS}, true)
+	for _, addr := range []string{"8.8.8.8", "203.0.113.10", "203.0.113.11"} {
+		perm, _ := whitelist.Check(addr)
+		fmt.Println(addr, perm)
+	}
+	// Output:
+	// 8.8.8.8 allowed
+	// 203.0.113.10 allowed
+	// 203.0.113.11 denied
+}
+
+func ExampleIPACL_CheckNormalized() {
+	acl, _ := ip.NewIPACL([]string{"198.51.100.0/24"}, types.Blacklist)
+
+	// 同一个地址要经过多个列表检查时只解析一次
+	addr, err := ip.NormalizeIP(" 198.51.100.7 ")
+	if err != nil {
+		fmt.Println("无效的IP:", err)
+		return
+	}
+	perm, _ := acl.CheckNormalized(addr)
+	fmt.Println(addr, perm)
+	// Output:
+	// 198.51.100.7 denied
+}
+
+func ExampleIPACL_CheckCIDR() {
+	deny, _ := ip.NewIPACL([]string{"10.0.0.0/8", "169.254.169.254"}, types.Blacklist)
+
+	// 校验出站规则时检查整个网段，而不是其中的某个地址
+	for _, cidr := range []string{"10.1.0.0/16", "192.0.2.0/24", "169.254.0.0/16"} {
+		perm, err := deny.CheckCIDR(cidr)
+		fmt.Println(cidr, perm, errors.Is(err, ip.ErrPartialOverlap))
+	}
+	// Output:
+	// 10.1.0.0/16 denied false
+	// 192.0.2.0/24 allowed false
+	// 169.254.0.0/16 denied true
+}
+
+func ExampleValidateRange() {
+	for _, line := range []string{"10.0.0.0/8", "2001:db8::1", "10.0.0.0/33", "example.com"} {
+		fmt.Println(line, ip.ValidateRange(line) == nil)
+	}
+	// Output:
+	// 10.0.0.0/8 true
+	// 2001:db8::1 true
+	// 10.0.0.0/33 false
+	// example.com false
+}
+
+func ExampleExportNftables() {
+	acl, _ := ip.NewIPACL([]string{"203.0.113.0/24", "203.0.113.7", "2001:db8::1"}, types.Blacklist)
+	// 被203.0.113.0/24包含的203.0.113.7不输出
+	if err := ip.ExportNftables(os.Stdout, acl, ip.DefaultNftSets("filter", "deny")); err != nil {
+		fmt.Println("导出失败:", err)
+	}
+	// Output:
+	// {"nftables":[{"add":{"set":{"family":"inet","table":"filter","name":"deny","type":"ipv4_addr","flags":["interval"]}}},{"flush":{"set":{"family":"inet","table":"filter","name":"deny"}}},{"add":{"element":{"family":"inet","table":"filter","name":"deny","elem":[{"prefix":{"addr":"203.0.113.0","len":24}}]}}},{"add":{"set":{"family":"inet","table":"filter","name":"deny6","type":"ipv6_addr","flags":["interval"]}}},{"flush":{"set":{"family":"inet","table":"filter","name":"deny6"}}},{"add":{"element":{"family":"inet","table":"filter","name":"deny6","elem":["2001:db8::1"]}}}]}
+}
+
+func ExampleValidatePredefinedSet() {
+	for _, issue := range ip.ValidatePredefinedSet("corp", []string{"10.8.1.0/16", "10.8.4.0/24", "vpn.example.com"}) {
+		fmt.Println(issue)
+	}
+	// Output:
+	// corp: 10.8.1.0/16: 主机位不为0，应写作10.8.0.0/16
+	// corp: vpn.example.com: 不是有效的IP或CIDR
+	// corp: 10.8.4.0/24: 已被10.8.1.0/16包含
+}
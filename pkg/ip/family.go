@@ -0,0 +1,102 @@
+package ip
+
+import (
+	"errors"
+	"net"
+)
+
+// ErrFamilyNotAllowed 表示IP地址族不被当前访问控制列表允许
+// 例如向仅IPv4的列表添加IPv6地址，或检查IPv6地址
+var ErrFamilyNotAllowed = errors.New("IP地址族不被允许")
+
+// Family 表示IP地址族
+type Family int
+
+const (
+	// FamilyAny 不限制地址族，同时支持IPv4和IPv6（默认）
+	FamilyAny Family = iota
+	// FamilyIPv4 仅IPv4地址
+	FamilyIPv4
+	// FamilyIPv6 仅IPv6地址
+	FamilyIPv6
+)
+
+// String 返回Family的字符串表示
+//
+// 返回值:
+//   - "any": 不限制地址族
+//   - "ipv4": 仅IPv4
+//   - "ipv6": 仅IPv6
+//   - "unknown": 未知的地址族
+func (f Family) String() string {
+	switch f {
+	case FamilyAny:
+		return "any"
+	case FamilyIPv4:
+		return "ipv4"
+	case FamilyIPv6:
+		return "ipv6"
+	default:
+		return "unknown"
+	}
+}
+
+// Contains 判断IP地址是否属于该地址族
+//
+// 参数:
+//   - ip: 要判断的IP地址，IPv4映射的IPv6地址（如::ffff:1.2.3.4）视为IPv4
+//
+// 返回:
+//   - bool: FamilyAny对任何有效IP都返回true
+func (f Family) Contains(ip net.IP) bool {
+	switch f {
+	case FamilyAny:
+		return ip != nil
+	case FamilyIPv4:
+		return ip.To4() != nil
+	case FamilyIPv6:
+		return ip != nil && ip.To4() == nil
+	default:
+		return false
+	}
+}
+
+// SetFamily 将访问控制列表限制为指定的地址族
+//
+// 参数:
+//   - family: 允许的地址族
+//     可用值: ip.FamilyAny, ip.FamilyIPv4, ip.FamilyIPv6
+//
+// 返回:
+//   - error: 如果列表中已存在其他地址族的规则，返回ErrFamilyNotAllowed，
+//     此时限制不会生效
+//
+// 限制生效后:
+//   - Add添加其他地址族的IP/CIDR时返回ErrFamilyNotAllowed
+//   - Check检查其他地址族的IP时返回types.Denied和ErrFamilyNotAllowed
+//
+// 示例:
+//
+//	acl, _ := ip.NewIPACL([]string{"10.0.0.0/8"}, types.Whitelist)
+//	if err := acl.SetFamily(ip.FamilyIPv4); err != nil {
+//	    log.Printf("限制地址族失败: %v", err)
+//	}
+//
+//	err := acl.Add("2001:db8::/32") // 返回 ip.ErrFamilyNotAllowed
+func (a *IPACL) SetFamily(family Family) error {
+	for _, ipRange := range a.ranges {
+		if !family.Contains(ipRange.IP) {
+			return ErrFamilyNotAllowed
+		}
+	}
+	a.family = family
+	return nil
+}
+
+// GetFamily 获取访问控制列表允许的地址族
+//
+// 返回:
+//   - Family: 当前允许的地址族，默认为FamilyAny
+func (a *IPACL) GetFamily() Family {
+	return a.family
+}
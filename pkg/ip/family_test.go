@@ -0,0 +1,99 @@
+package ip
+
+import (
+	"errors"
+	"net"
+	"testing"
+
+	"github.com/cyberspacesec/go-acl/pkg/types"
+)
+
+// TestFamily_String 测试Family的String方法
+func TestFamily_String(t *testing.T) {
+	tests := []struct {
+		family Family
+		want   string
+	}{
+		{FamilyAny, "any"},
+		{FamilyIPv4, "ipv4"},
+		{FamilyIPv6, "ipv6"},
+		{Family(99), "unknown"},
+	}
+
+	for _, tt := range tests {
+		if got := tt.family.String(); got != tt.want {
+			t.Errorf("Family(%d).String() = %v, 期望 %v", tt.family, got, tt.want)
+		}
+	}
+}
+
+// TestFamily_Contains 测试地址族判断
+func TestFamily_Contains(t *testing.T) {
+	tests := []struct {
+		name   string
+		family Family
+		ip     string
+		want   bool
+	}{
+		{"任意-IPv4", FamilyAny, "1.2.3.4", true},
+		{"任意-IPv6", FamilyAny, "::1", true},
+		{"IPv4-IPv4", FamilyIPv4, "1.2.3.4", true},
+		{"IPv4-IPv6", FamilyIPv4, "2001:db8::1", false},
+		{"IPv4-映射地址", FamilyIPv4, "::ffff:1.2.3.4", true},
+		{"IPv6-IPv6", FamilyIPv6, "2001:db8::1", true},
+		{"IPv6-IPv4", FamilyIPv6, "1.2.3.4", false},
+		{"未知地址族", Family(99), "1.2.3.4", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.family.Contains(net.ParseIP(tt.ip)); got != tt.want {
+				t.Errorf("%v.Contains(%s) = %v, 期望 %v", tt.family, tt.ip, got, tt.want)
+			}
+		})
+	}
+
+	if FamilyAny.Contains(nil) {
+		t.Error("FamilyAny.Contains(nil) 应返回false")
+	}
+}
+
+// TestIPACL_SetFamily 测试限制IPACL的地址族
+func TestIPACL_SetFamily(t *testing.T) {
+	acl, err := NewIPACL([]string{"10.0.0.0/8", "2001:db8::/32"}, types.Whitelist)
+	if err != nil {
+		t.Fatalf("NewIPACL() 返回错误: %v", err)
+	}
+
+	// 已存在IPv6规则，不能限制为仅IPv4
+	if err := acl.SetFamily(FamilyIPv4); !errors.Is(err, ErrFamilyNotAllowed) {
+		t.Errorf("SetFamily(FamilyIPv4) 错误 = %v, 期望 ErrFamilyNotAllowed", err)
+	}
+	if acl.GetFamily() != FamilyAny {
+		t.Errorf("限制失败后 GetFamily() = %v, 期望 any", acl.GetFamily())
+	}
+
+	if err := acl.Remove("2001:db8::/32"); err != nil {
+		t.Fatalf("Remove() 返回错误: %v", err)
+	}
+	if err := acl.SetFamily(FamilyIPv4); err != nil {
+		t.Fatalf("SetFamily(FamilyIPv4) 返回错误: %v", err)
+	}
+
+	// 拒绝添加IPv6
+	if err := acl.Add("2001:db8::/32"); !errors.Is(err, ErrFamilyNotAllowed) {
+		t.Errorf("Add(IPv6) 错误 = %v, 期望 ErrFamilyNotAllowed", err)
+	}
+	if err := acl.Add("192.168.0.0/16"); err != nil {
+		t.Errorf("Add(IPv4) 返回错误: %v", err)
+	}
+
+	// 拒绝匹配IPv6
+	perm, err := acl.Check("2001:db8::1")
+	if !errors.Is(err, ErrFamilyNotAllowed) || perm != types.Denied {
+		t.Errorf("Check(IPv6) = %v, %v, 期望 denied, ErrFamilyNotAllowed", perm, err)
+	}
+	if perm, err := acl.Check("10.1.2.3"); err != nil || perm != types.Allowed {
+		t.Errorf("Check(IPv4) = %v, %v, 期望 allowed, nil", perm, err)
+	}
+}
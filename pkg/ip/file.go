@@ -22,7 +22,7 @@ import (
 //   - ErrInvalidCIDR: 文件中包含无效的CIDR格式
 //   - 其他系统错误: 如权限错误、I/O错误等
 //
-// 文件格式要求与config.ReadIPList相同:
+// 文件格式要求与config.ReadIPACL相同:
 //   - 每行一个IP/CIDR
 //   - #开头的行被视为注释，将被忽略
 //   - 行内#后的内容被视为注释，将被忽略
@@ -87,6 +87,8 @@ func NewIPACLFromFile(filePath string, listType types.ListType) (*IPACL, error)
 //   - 第二行是生成时间
 //   - 之后每行一个IP/CIDR
 //
+// 覆盖已存在的文件时不保留其中的注释，需要保留注释（如工单号）时使用Save和config.WithPreserveComments(true)。
+//
 // 默认标题格式:
 //   - 黑名单: "IP Blacklist - IPs in this list will be denied access"
 //   - 白名单: "IP Whitelist - Only IPs in this list will be allowed access"
@@ -114,20 +116,58 @@ func NewIPACLFromFile(filePath string, listType types.ListType) (*IPACL, error)
 //	    log.Println("备份文件已存在，未覆盖")
 //	}
 func (a *IPACL) SaveToFile(filePath string, overwrite bool) error {
+	return a.Save(filePath, config.WithOverwrite(overwrite))
+}
+
+// Save 按选项将IP访问控制列表保存到文件
+//
+// 参数:
+//   - filePath: 要保存的文件路径
+//   - opts: 保存选项，见config.SaveOptions；默认使用与SaveToFile相同的标题，可用config.WithHeader替换；
+//     文件头中总是记录列表类型，可以用config.ReadIPACLWithMetadata读取；
+//     覆盖已存在的文件时保留其中的注释和条目顺序需要config.WithPreserveComments(true)
+//
+// 返回:
+//   - error: 可能的错误，见config.SaveLines
+//
+// 示例:
+//
+//	// 原子地替换列表文件，并保留上一版本
+//	err := ipACL.Save("./blacklist.txt", config.WithBackup(), config.WithAtomic())
+//
+//	// 重新保存时保留条目上的注释
+//	err = ipACL.Save("./blacklist.txt", config.WithOverwrite(true), config.WithPreserveComments(true))
+func (a *IPACL) Save(filePath string, opts ...config.SaveOption) error {
+	return SaveRanges(filePath, a.GetIPRanges(), a.listType, opts...)
+}
+
+// SaveRanges 按与IPACL.Save相同的格式保存一组IP范围
+//
+// 参数:
+//   - filePath: 要保存的文件路径
+//   - ranges: 要保存的IP或CIDR，不做校验
+//   - listType: 写入文件头的列表类型，同时决定默认的标题
+//   - opts: 保存选项，与IPACL.Save相同
+//
+// 返回:
+//   - error: 可能的错误，见config.SaveLines
+//
+// 用于保存在锁内复制出的列表内容：复制之后即可释放锁，写文件期间不阻塞对列表的修改和检查。
+func SaveRanges(filePath string, ranges []string, listType types.ListType, opts ...config.SaveOption) error {
 	// 根据列表类型生成适当的标题
 	var header string
-	if a.listType == types.Blacklist {
+	if listType == types.Blacklist {
 		header = "IP Blacklist - IPs in this list will be denied access"
 	} else {
 		header = "IP Whitelist - Only IPs in this list will be allowed access"
 	}
 
-	// 保存到文件
-	return config.SaveIPACLWithHeader(filePath, a.GetIPRanges(), header, overwrite)
+	return config.SaveLines(filePath, ranges, append([]config.SaveOption{config.WithHeader(header), config.WithListType(listType)}, opts...)...)
 }
 
 // SaveToFileWithOverwrite 兼容旧版API，默认覆盖已存在的文件
-// 已废弃：请改用 SaveToFile
+//
+// Deprecated: 请改用 SaveToFile(filePath, true)。
 func (a *IPACL) SaveToFileWithOverwrite(filePath string) error {
 	return a.SaveToFile(filePath, true)
 }
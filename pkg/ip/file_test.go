@@ -254,7 +254,7 @@ func TestIPACL_SaveToFile(t *testing.T) {
 			// 如果文件应该存在且没有错误，验证内容
 			if !tt.wantErr && tt.expectedFile {
 				// 读取保存的文件
-				ips, err := config.ReadIPACL(tt.filePath)
+				ips, meta, err := config.ReadIPACLWithMetadata(tt.filePath)
 				if err != nil {
 					t.Errorf("Failed to read saved file: %v", err)
 					return
@@ -264,6 +264,10 @@ func TestIPACL_SaveToFile(t *testing.T) {
 				if !reflect.DeepEqual(ips, tt.ipRanges) {
 					t.Errorf("Saved IPs = %v, want %v", ips, tt.ipRanges)
 				}
+				// 文件头记录列表类型和条目数量
+				if meta.ListType != tt.listType.String() || meta.Entries != len(tt.ipRanges) {
+					t.Errorf("Metadata = %+v, want type %s and %d entries", meta, tt.listType, len(tt.ipRanges))
+				}
 			}
 		})
 	}
@@ -603,3 +607,51 @@ func TestSaveIPACL(t *testing.T) {
 		t.Error("SaveIPACL() should return error when file exists and overwrite=false")
 	}
 }
+
+// TestIPACL_SavePreservesComments 测试启用WithPreserveComments时重新保存保留条目上的注释，默认不保留
+func TestIPACL_SavePreservesComments(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "blacklist.txt")
+	content := "# SEC-1234 撞库攻击\n192.168.1.1\n10.0.0.0/8 # OPS-42\n"
+	if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	acl, err := NewIPACLFromFile(path, types.Blacklist)
+	if err != nil {
+		t.Fatalf("NewIPACLFromFile() 返回错误: %v", err)
+	}
+	if err := acl.Add("172.16.0.0/12"); err != nil {
+		t.Fatalf("Add() 返回错误: %v", err)
+	}
+
+	// SaveToFile按列表内容重写整个文件
+	plain := filepath.Join(t.TempDir(), "plain.txt")
+	if err := os.WriteFile(plain, []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := acl.SaveToFile(plain, true); err != nil {
+		t.Fatalf("SaveToFile() 返回错误: %v", err)
+	}
+	if comments, err := config.ReadComments(plain); err != nil || len(comments) != 0 {
+		t.Errorf("SaveToFile() 后 ReadComments() = %+v, %v, 期望没有注释", comments, err)
+	}
+
+	if err := acl.Save(path, config.WithOverwrite(true), config.WithPreserveComments(true)); err != nil {
+		t.Fatalf("Save() 返回错误: %v", err)
+	}
+
+	comments, err := config.ReadComments(path)
+	if err != nil {
+		t.Fatalf("ReadComments() 返回错误: %v", err)
+	}
+	want := map[string]config.EntryComment{
+		"192.168.1.1": {Above: []string{"SEC-1234 撞库攻击"}},
+		"10.0.0.0/8":  {Inline: "OPS-42"},
+	}
+	if !reflect.DeepEqual(comments, want) {
+		t.Errorf("ReadComments() = %+v, 期望 %+v", comments, want)
+	}
+	if lines, _ := config.ReadIPACL(path); !reflect.DeepEqual(lines, []string{"192.168.1.1", "10.0.0.0/8", "172.16.0.0/12"}) {
+		t.Errorf("ReadIPACL() = %v", lines)
+	}
+}
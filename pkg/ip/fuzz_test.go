@@ -0,0 +1,58 @@
+package ip
+
+import (
+	"errors"
+	"testing"
+)
+
+// FuzzParseIPRange 检查IP/CIDR解析不会panic，解析结果与其规范形式一致
+//
+// 运行: go test ./pkg/ip -run '^$' -fuzz FuzzParseIPRange
+func FuzzParseIPRange(f *testing.F) {
+	for _, seed := range []string{
+		"192.168.1.1", "10.0.0.0/8", "2001:db8::/32", "::ffff:192.0.2.1", "::ffff:192.0.2.0/120",
+		" 192.0.2.1 ", "192.0.2.1/33", "192.0.2.1/-1", "0x7f.1", "fe80::1%eth0", "1.2.3.4.5", "",
+	} {
+		f.Add(seed)
+	}
+	f.Fuzz(func(t *testing.T, s string) {
+		r, err := parseIPRange(s)
+		if err != nil {
+			if !errors.Is(err, ErrInvalidIP) {
+				t.Fatalf("parseIPRange(%q) 错误 = %v, 期望 ErrInvalidIP", s, err)
+			}
+			return
+		}
+		// net.IPNet.Contains不认为"::ffff:0.0.0.0/0"包含其中的IPv4映射地址，因此按掩码比较
+		if !r.IP.Mask(r.IPNet.Mask).Equal(r.IPNet.IP) {
+			t.Fatalf("parseIPRange(%q): 网段 %s 不包含 %s", s, r.IPNet, r.IP)
+		}
+		again, err := parseIPRange(r.IPNet.String())
+		if err != nil {
+			t.Fatalf("parseIPRange(%q) 的规范形式 %s 无法解析: %v", s, r.IPNet, err)
+		}
+		if again.IPNet.String() != r.IPNet.String() {
+			t.Fatalf("parseIPRange(%q) = %s, 重新解析为 %s", s, r.IPNet, again.IPNet)
+		}
+	})
+}
+
+// FuzzCanonicalizeIP 检查主机部分的IP识别是稳定的：识别结果的规范形式被识别为同一地址
+func FuzzCanonicalizeIP(f *testing.F) {
+	for _, seed := range []string{
+		"127.0.0.1", "0x7f.0.0.1", "2130706433", "0177.0.0.01", "127.1", "[::1]", "[::ffff:127.0.0.1]",
+		"0x100000000", "1.2.3.4.5", "08.0.0.1", "",
+	} {
+		f.Add(seed)
+	}
+	f.Fuzz(func(t *testing.T, s string) {
+		parsed, ok := CanonicalizeIP(s)
+		if !ok {
+			return
+		}
+		again, ok := CanonicalizeIP(parsed.String())
+		if !ok || !again.Equal(parsed) {
+			t.Fatalf("CanonicalizeIP(%q) = %s, 重新识别为 %v, %v", s, parsed, again, ok)
+		}
+	})
+}
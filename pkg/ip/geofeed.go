@@ -0,0 +1,178 @@
+package ip
+
+import (
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// ErrInvalidGeofeed 表示geofeed（RFC 8805）数据无效
+var ErrInvalidGeofeed = errors.New("无效的geofeed数据")
+
+// GeofeedEntry 是geofeed（RFC 8805）中的一条记录
+//
+// 字段说明:
+//   - Prefix: IP或CIDR
+//   - Country: ISO 3166-1 alpha-2国家代码（大写），如"US"；为空表示发布者不希望对该前缀做地理定位
+//   - Region: ISO 3166-2地区代码（大写），如"US-CA"，可为空
+//   - City: 城市名称，可为空
+//   - PostalCode: 邮政编码，RFC 8805已不推荐使用，可为空
+type GeofeedEntry struct {
+	Prefix     string
+	Country    string
+	Region     string
+	City       string
+	PostalCode string
+}
+
+// Tags 返回记录的地理标签：非空的国家代码和地区代码
+//
+// 返回:
+//   - []string: 例如[]string{"US", "US-CA"}；国家代码不含"-"，地区代码总是含有"-"，两者不会混淆
+func (e GeofeedEntry) Tags() []string {
+	var tags []string
+	if e.Country != "" {
+		tags = append(tags, e.Country)
+	}
+	if e.Region != "" {
+		tags = append(tags, e.Region)
+	}
+	return tags
+}
+
+// ParseGeofeed 解析geofeed（RFC 8805）格式的CSV数据
+//
+// 参数:
+//   - r: geofeed数据，每行为"前缀,国家,地区,城市,邮政编码"，末尾的空字段可以省略
+//
+// 返回:
+//   - []GeofeedEntry: 按文件顺序排列的记录
+//   - error: 读取错误，或包装了ErrInvalidGeofeed的错误（附带行号）
+//
+// 空行和#开头的行被忽略，字段两端的空白被去除。国家代码和地区代码转换为大写；
+// 同时给出两者时，地区代码必须属于该国家（"US-CA"属于"US"）。
+// 前缀的格式与NewIPACL相同，无效时返回错误。
+//
+// 示例:
+//
+//	f, _ := os.Open("geofeed.csv")
+//	defer f.Close()
+//	entries, err := ip.ParseGeofeed(f)
+//	byTag := ip.GeofeedPrefixes(entries)
+//	acl, _ := ip.NewIPACL(byTag["US-CA"], types.Whitelist)
+func ParseGeofeed(r io.Reader) ([]GeofeedEntry, error) {
+	reader := csv.NewReader(r)
+	reader.Comment = '#'
+	reader.FieldsPerRecord = -1
+	reader.TrimLeadingSpace = true
+
+	var entries []GeofeedEntry
+	for {
+		record, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("%w: %v", ErrInvalidGeofeed, err)
+		}
+		line, _ := reader.FieldPos(0)
+
+		var fields [5]string
+		if len(record) > len(fields) {
+			return nil, fmt.Errorf("%w: 第%d行: 字段过多", ErrInvalidGeofeed, line)
+		}
+		for i, field := range record {
+			fields[i] = strings.TrimSpace(field)
+		}
+		if fields[0] == "" {
+			// 只有空白的行
+			if len(record) == 1 {
+				continue
+			}
+			return nil, fmt.Errorf("%w: 第%d行: 缺少前缀", ErrInvalidGeofeed, line)
+		}
+
+		entry := GeofeedEntry{
+			Prefix:     fields[0],
+			Country:    strings.ToUpper(fields[1]),
+			Region:     strings.ToUpper(fields[2]),
+			City:       fields[3],
+			PostalCode: fields[4],
+		}
+		if _, err := parseIPRange(entry.Prefix); err != nil {
+			return nil, fmt.Errorf("第%d行: %w", line, err)
+		}
+		if err := validateGeofeedCodes(entry.Country, entry.Region); err != nil {
+			return nil, fmt.Errorf("%w: 第%d行: %v", ErrInvalidGeofeed, line, err)
+		}
+		entries = append(entries, entry)
+	}
+	return entries, nil
+}
+
+// GeofeedPrefixes 按地理标签对记录中的前缀分组
+//
+// 参数:
+//   - entries: ParseGeofeed返回的记录
+//
+// 返回:
+//   - map[string][]string: 标签（国家代码或地区代码，见GeofeedEntry.Tags）到前缀的映射，
+//     前缀按文件顺序排列；没有国家代码的记录不属于任何标签
+//
+// 示例:
+//
+//	byTag := ip.GeofeedPrefixes(entries)
+//	byTag["US"]    // 所有位于美国的前缀
+//	byTag["US-CA"] // 其中位于加利福尼亚州的前缀
+func GeofeedPrefixes(entries []GeofeedEntry) map[string][]string {
+	byTag := make(map[string][]string)
+	for _, e := range entries {
+		for _, tag := range e.Tags() {
+			byTag[tag] = append(byTag[tag], e.Prefix)
+		}
+	}
+	return byTag
+}
+
+// validateGeofeedCodes 校验大写的国家代码和地区代码的格式
+func validateGeofeedCodes(country, region string) error {
+	if country != "" && !isAlpha(country, 2) {
+		return fmt.Errorf("无效的国家代码 %q", country)
+	}
+	if region == "" {
+		return nil
+	}
+	cc, sub, ok := strings.Cut(region, "-")
+	if !ok || !isAlpha(cc, 2) || sub == "" || len(sub) > 3 || !isAlnum(sub) {
+		return fmt.Errorf("无效的地区代码 %q", region)
+	}
+	if country != "" && cc != country {
+		return fmt.Errorf("地区代码 %s 不属于国家 %s", region, country)
+	}
+	return nil
+}
+
+// isAlpha 判断s是否由n个大写字母组成
+func isAlpha(s string, n int) bool {
+	if len(s) != n {
+		return false
+	}
+	for i := 0; i < len(s); i++ {
+		if s[i] < 'A' || s[i] > 'Z' {
+			return false
+		}
+	}
+	return true
+}
+
+// isAlnum 判断s是否只由大写字母和数字组成
+func isAlnum(s string) bool {
+	for i := 0; i < len(s); i++ {
+		if (s[i] < 'A' || s[i] > 'Z') && (s[i] < '0' || s[i] > '9') {
+			return false
+		}
+	}
+	return true
+}
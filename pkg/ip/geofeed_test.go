@@ -0,0 +1,80 @@
+package ip
+
+import (
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// TestParseGeofeed 测试解析geofeed（RFC 8805）数据
+func TestParseGeofeed(t *testing.T) {
+	input := `# 示例geofeed
+192.0.2.0/25,US,US-CA,Mountain View,
+192.0.2.128/25, us , us-wa ,Seattle
+198.51.100.0/24,DE
+"2001:db8::/32",GB,GB-ENG,London,EC1A
+
+203.0.113.0/24,,,
+`
+	entries, err := ParseGeofeed(strings.NewReader(input))
+	if err != nil {
+		t.Fatalf("ParseGeofeed() 返回错误: %v", err)
+	}
+	want := []GeofeedEntry{
+		{Prefix: "192.0.2.0/25", Country: "US", Region: "US-CA", City: "Mountain View"},
+		{Prefix: "192.0.2.128/25", Country: "US", Region: "US-WA", City: "Seattle"},
+		{Prefix: "198.51.100.0/24", Country: "DE"},
+		{Prefix: "2001:db8::/32", Country: "GB", Region: "GB-ENG", City: "London", PostalCode: "EC1A"},
+		{Prefix: "203.0.113.0/24"},
+	}
+	if !reflect.DeepEqual(entries, want) {
+		t.Fatalf("ParseGeofeed() = %+v, 期望 %+v", entries, want)
+	}
+
+	byTag := GeofeedPrefixes(entries)
+	wantTags := map[string][]string{
+		"US":     {"192.0.2.0/25", "192.0.2.128/25"},
+		"US-CA":  {"192.0.2.0/25"},
+		"US-WA":  {"192.0.2.128/25"},
+		"DE":     {"198.51.100.0/24"},
+		"GB":     {"2001:db8::/32"},
+		"GB-ENG": {"2001:db8::/32"},
+	}
+	if !reflect.DeepEqual(byTag, wantTags) {
+		t.Errorf("GeofeedPrefixes() = %v, 期望 %v", byTag, wantTags)
+	}
+}
+
+// TestParseGeofeedErrors 测试无效的geofeed数据
+func TestParseGeofeedErrors(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    string
+		sentinel bool
+	}{
+		{"无效的前缀", "10.0.0.300/8,US\n", false},
+		{"缺少前缀", ",US,US-CA\n", true},
+		{"无效的国家代码", "10.0.0.0/8,USA\n", true},
+		{"无效的地区代码", "10.0.0.0/8,US,California\n", true},
+		{"地区不属于国家", "10.0.0.0/8,US,DE-BE\n", true},
+		{"字段过多", "10.0.0.0/8,US,US-CA,a,b,c\n", true},
+		{"引号不匹配", "\"10.0.0.0/8,US\n", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := ParseGeofeed(strings.NewReader(tt.input))
+			if err == nil {
+				t.Fatal("ParseGeofeed() 应返回错误")
+			}
+			if tt.sentinel && !errors.Is(err, ErrInvalidGeofeed) {
+				t.Errorf("ParseGeofeed() 错误 = %v, 期望 ErrInvalidGeofeed", err)
+			}
+		})
+	}
+
+	// 没有国家代码的地区代码是允许的
+	if _, err := ParseGeofeed(strings.NewReader("10.0.0.0/8,,US-CA\n")); err != nil {
+		t.Errorf("ParseGeofeed() 返回错误: %v", err)
+	}
+}
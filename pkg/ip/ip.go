@@ -59,8 +59,15 @@ type IPRange struct {
 type IPACL struct {
 	ranges   []IPRange
 	listType types.ListType
+	family   Family
+	// engine 是ranges对应的匹配引擎，默认为基数树，见SetMatchEngine
+	engine MatchEngine
+	opts   MatcherOptions
 }
 
+// 确保*IPACL实现types.ACL
+var _ types.ACL = (*IPACL)(nil)
+
 // NewIPACL 创建一个新的IP访问控制列表
 //
 // 参数:
@@ -100,8 +107,32 @@ type IPACL struct {
 //	    types.Whitelist
 //	)
 func NewIPACL(ipRanges []string, listType types.ListType) (*IPACL, error) {
+	return NewIPACLWithOptions(ipRanges, listType, DefaultMatcherOptions)
+}
+
+// NewIPACLWithOptions 使用指定的匹配器选项创建IP访问控制列表
+//
+// 参数:
+//   - ipRanges: 要控制的IP或CIDR列表
+//   - listType: 列表类型（黑名单或白名单）
+//   - opts: 内部匹配器的调优选项，参见MatcherOptions
+//
+// 返回:
+//   - *IPACL: 创建的IP访问控制列表
+//   - error: 与NewIPACL相同的错误
+//
+// 示例:
+//
+//	// 内存受限的环境中使用较小的节点池
+//	acl, err := ip.NewIPACLWithOptions(cidrs, types.Blacklist, ip.MatcherOptions{
+//	    NodePoolSize:  256,
+//	    CompressPaths: true,
+//	})
+func NewIPACLWithOptions(ipRanges []string, listType types.ListType, opts MatcherOptions) (*IPACL, error) {
 	acl := &IPACL{
 		listType: listType,
+		opts:     opts,
+		engine:   newIPTrie(opts),
 	}
 
 	// 如果没有输入IP，返回空ACL
@@ -122,6 +153,7 @@ func NewIPACL(ipRanges []string, listType types.ListType) (*IPACL, error) {
 		}
 
 		acl.ranges = append(acl.ranges, *ipRange)
+		acl.engine.Insert(ipRange.IPNet)
 	}
 
 	return acl, nil
@@ -137,6 +169,7 @@ func NewIPACL(ipRanges []string, listType types.ListType) (*IPACL, error) {
 //   - error: 可能的错误:
 //   - ErrInvalidIP: 提供了无效的IP地址格式
 //   - ErrInvalidCIDR: 提供了无效的CIDR格式
+//   - ErrFamilyNotAllowed: 地址族不符合SetFamily设置的限制
 //
 // 该方法允许向现有访问控制列表添加更多IP或CIDR。空字符串将被忽略，不会导致错误。
 // 重复添加相同的IP/CIDR不会产生错误，但IP只会被添加一次。
@@ -163,6 +196,17 @@ func (a *IPACL) Add(ipRanges ...string) error {
 		return nil
 	}
 
+	// 零值IPACL没有匹配引擎，先按当前的IP范围建立基数树
+	if a.engine == nil {
+		a.rebuildMatcher()
+	}
+
+	// 已有的规则，用于去重，大列表上逐条比较的开销与列表长度成正比
+	existing := make(map[string]struct{}, len(a.ranges)+len(ipRanges))
+	for _, r := range a.ranges {
+		existing[r.Original] = struct{}{}
+	}
+
 	// 解析和验证每个IP或CIDR
 	for _, ipStr := range ipRanges {
 		// 忽略空字符串
@@ -176,18 +220,16 @@ func (a *IPACL) Add(ipRanges ...string) error {
 			return err
 		}
 
-		// 检查是否已存在
-		exists := false
-		for _, existingRange := range a.ranges {
-			if existingRange.Original == ipRange.Original {
-				exists = true
-				break
-			}
+		// 检查地址族限制
+		if !a.family.Contains(ipRange.IP) {
+			return ErrFamilyNotAllowed
 		}
 
 		// 添加新的IP/CIDR
-		if !exists {
+		if _, exists := existing[ipRange.Original]; !exists {
+			existing[ipRange.Original] = struct{}{}
 			a.ranges = append(a.ranges, *ipRange)
+			a.engine.Insert(ipRange.IPNet)
 		}
 	}
 
@@ -246,33 +288,51 @@ func (a *IPACL) Remove(ipRanges ...string) error {
 	// 创建新的IP范围列表，排除要移除的
 	var newRanges []IPRange
 	for _, existingRange := range a.ranges {
-		keep := true
-		for ipStr := range found {
-			if existingRange.Original == ipStr {
-				found[ipStr] = true
-				keep = false
-				break
-			}
-		}
-		if keep {
-			newRanges = append(newRanges, existingRange)
+		if _, ok := found[existingRange.Original]; ok {
+			found[existingRange.Original] = true
+			continue
 		}
+		newRanges = append(newRanges, existingRange)
 	}
 
+	// 即使有未找到的IP，也更新列表
+	a.removeFromEngine(newRanges)
+	a.ranges = newRanges
+
 	// 检查是否所有IP都找到了
 	for ipStr, wasFound := range found {
 		if !wasFound && strings.TrimSpace(ipStr) != "" {
-			// 虽然有未找到的IP，但仍更新列表
-			a.ranges = newRanges
 			return ErrIPNotFound
 		}
 	}
-
-	// 更新IPACL使用新的范围
-	a.ranges = newRanges
 	return nil
 }
 
+// removeFromEngine 从匹配引擎中移除不在remaining中的网络
+//
+// 不同写法的条目（如"10.0.0.0/8"和"10.1.2.3/8"）对应同一个网络，只有没有剩余条目使用时才移除。
+func (a *IPACL) removeFromEngine(remaining []IPRange) {
+	if a.engine == nil {
+		return
+	}
+	type prefix struct {
+		key  [16]byte
+		bits uint8
+		root uint32
+	}
+	kept := make(map[prefix]struct{}, len(remaining))
+	for _, r := range remaining {
+		key, bits, root := netKey(r.IPNet)
+		kept[prefix{key, bits, root}] = struct{}{}
+	}
+	for _, r := range a.ranges {
+		key, bits, root := netKey(r.IPNet)
+		if _, ok := kept[prefix{key, bits, root}]; !ok {
+			a.engine.Remove(r.IPNet)
+		}
+	}
+}
+
 // Check 检查指定的IP是否允许访问
 //
 // 参数:
@@ -285,6 +345,8 @@ func (a *IPACL) Remove(ipRanges ...string) error {
 //   - types.Denied: 拒绝访问
 //   - error: 可能的错误:
 //   - ErrInvalidIP: 提供了无效的IP地址格式
+//   - ErrFamilyNotAllowed: 地址族不符合SetFamily设置的限制
+//   - types.ErrNoACL: a为nil
 //
 // 检查逻辑:
 // - 对于黑名单: 如果IP匹配列表中的任何IP或CIDR范围，返回types.Denied，否则返回types.Allowed
@@ -324,11 +386,24 @@ func (a *IPACL) Remove(ipRanges ...string) error {
 //	    log.Println("IP不在白名单中，拒绝访问")
 //	}
 func (a *IPACL) Check(ip string) (types.Permission, error) {
+	if a == nil {
+		return types.Denied, types.ErrNoACL
+	}
+
 	// 解析IP地址
 	parsedIP := net.ParseIP(strings.TrimSpace(ip))
 	if parsedIP == nil {
 		return types.Denied, ErrInvalidIP
 	}
+	return a.checkParsed(parsedIP)
+}
+
+// checkParsed 检查已解析的IP地址，Check和CheckNormalized的共同部分
+func (a *IPACL) checkParsed(parsedIP net.IP) (types.Permission, error) {
+	// 检查地址族限制
+	if !a.family.Contains(parsedIP) {
+		return types.Denied, ErrFamilyNotAllowed
+	}
 
 	// 检查IP是否匹配列表中的任何范围
 	matched := a.matchIP(parsedIP)
@@ -456,20 +531,27 @@ func (a *IPACL) AddPredefinedSet(setName PredefinedSet, allowSet bool) error {
 // 返回:
 //   - bool: 如果IP匹配列表中的任何IP或CIDR范围，返回true
 //
-// 这是一个内部辅助方法，用于检查IP是否在控制列表的任何范围内。
+// 这是一个内部辅助方法，通过匹配引擎（默认为基数树，耗时与规则数量无关）
+// 检查IP是否在控制列表的任何范围内。
 func (a *IPACL) matchIP(ip net.IP) bool {
-	for _, ipRange := range a.ranges {
-		// 对于单个IP地址的精确匹配
-		if ipRange.IP != nil && ipRange.IPNet == nil && ipRange.IP.Equal(ip) {
-			return true
-		}
-
-		// 对于CIDR范围的匹配
-		if ipRange.IPNet != nil && ipRange.IPNet.Contains(ip) {
-			return true
-		}
+	if a.engine == nil {
+		return false
 	}
-	return false
+	return a.engine.Match(ip)
+}
+
+// ValidateRange 检查字符串是否是NewIPACL接受的IP或CIDR
+//
+// 参数:
+//   - ipRange: 要检查的IP或CIDR，如"192.0.2.1"、"10.0.0.0/8"
+//
+// 返回:
+//   - error: 格式无效时返回ErrInvalidIP，有效时返回nil
+//
+// 用于在加载前逐行筛选来源不可靠的列表，无需为每一行创建IPACL。
+func ValidateRange(ipRange string) error {
+	_, err := parseIPRange(ipRange)
+	return err
 }
 
 // parseIPRange 解析IP字符串为IPRange对象
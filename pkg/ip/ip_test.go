@@ -122,6 +122,33 @@ func TestNewIPACL(t *testing.T) {
 	}
 }
 
+// TestValidateRange 测试检查IP或CIDR的格式
+func TestValidateRange(t *testing.T) {
+	tests := []struct {
+		input string
+		valid bool
+	}{
+		{"192.0.2.1", true},
+		{" 10.0.0.0/8 ", true},
+		{"2001:db8::/32", true},
+		{"10.0.0.0/33", false},
+		{"256.0.0.1", false},
+		{"example.com", false},
+		{"", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			err := ValidateRange(tt.input)
+			if tt.valid != (err == nil) {
+				t.Errorf("ValidateRange(%q) = %v, 期望有效 %v", tt.input, err, tt.valid)
+			}
+			if err != nil && !errors.Is(err, ErrInvalidIP) {
+				t.Errorf("ValidateRange(%q) 错误 = %v, 期望 ErrInvalidIP", tt.input, err)
+			}
+		})
+	}
+}
+
 // TestIPACL_Add 测试添加IP到访问控制列表
 func TestIPACL_Add(t *testing.T) {
 	// 创建一个初始ACL
@@ -754,3 +781,25 @@ func TestIPACL_matchIP(t *testing.T) {
 		})
 	}
 }
+
+// TestIPACL_ZeroValue 测试nil和零值IPACL不会panic
+func TestIPACL_ZeroValue(t *testing.T) {
+	var nilACL *IPACL
+	if perm, err := nilACL.Check("10.0.0.1"); perm != types.Denied || !errors.Is(err, types.ErrNoACL) {
+		t.Errorf("nil IPACL Check() = %v, %v; 期望 Denied, ErrNoACL", perm, err)
+	}
+
+	acl := &IPACL{}
+	if perm, err := acl.Check("10.0.0.1"); err != nil || perm != types.Allowed {
+		t.Errorf("零值 Check() = %v, %v; 期望按空黑名单允许", perm, err)
+	}
+	if stats := acl.MatcherStats(); stats.Prefixes != 0 {
+		t.Errorf("零值 MatcherStats() = %+v", stats)
+	}
+	if err := acl.Add("10.0.0.0/8"); err != nil {
+		t.Fatalf("零值 Add() 返回错误: %v", err)
+	}
+	if perm, err := acl.Check("10.0.0.1"); err != nil || perm != types.Denied {
+		t.Errorf("Add后 Check() = %v, %v; 期望 Denied", perm, err)
+	}
+}
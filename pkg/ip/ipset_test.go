@@ -0,0 +1,127 @@
+package ip
+
+import (
+	"bytes"
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/cyberspacesec/go-acl/pkg/types"
+)
+
+// TestExportIPSet 测试导出为ipset save格式
+func TestExportIPSet(t *testing.T) {
+	acl, err := NewIPACL([]string{"203.0.113.0/24", "198.51.100.7", "2001:db8::/32", "::ffff:192.0.2.1", "2001:db8:1::1"}, types.Blacklist)
+	if err != nil {
+		t.Fatalf("NewIPACL() 返回错误: %v", err)
+	}
+
+	var buf bytes.Buffer
+	if err := ExportIPSet(&buf, acl, DefaultIPSetNames("deny")); err != nil {
+		t.Fatalf("ExportIPSet() 返回错误: %v", err)
+	}
+	want := `# go-acl ip blacklist
+create deny hash:net family inet hashsize 1024 maxelem 65536
+add deny 203.0.113.0/24
+add deny 198.51.100.7
+add deny 192.0.2.1
+create deny6 hash:net family inet6 hashsize 1024 maxelem 65536
+add deny6 2001:db8::/32
+add deny6 2001:db8:1::1
+`
+	if buf.String() != want {
+		t.Errorf("ExportIPSet() 输出:\n%s\n期望:\n%s", buf.String(), want)
+	}
+
+	// 导出结果可以导入并还原相同的匹配结果
+	sets, err := ImportIPSet(&buf)
+	if err != nil {
+		t.Fatalf("ImportIPSet() 返回错误: %v", err)
+	}
+	if len(sets) != 2 || sets[0].Family != FamilyIPv4 || sets[1].Family != FamilyIPv6 {
+		t.Fatalf("ImportIPSet() = %+v", sets)
+	}
+	restored, err := NewIPACL(append(sets[0].Entries, sets[1].Entries...), types.Blacklist)
+	if err != nil {
+		t.Fatalf("NewIPACL() 返回错误: %v", err)
+	}
+	for _, addr := range []string{"203.0.113.9", "192.0.2.1", "2001:db8:ffff::1", "8.8.8.8"} {
+		want, _ := acl.Check(addr)
+		if got, _ := restored.Check(addr); got != want {
+			t.Errorf("还原后 Check(%s) = %v, 期望 %v", addr, got, want)
+		}
+	}
+}
+
+// TestExportIPSetErrors 测试无法导出的列表
+func TestExportIPSetErrors(t *testing.T) {
+	tests := []struct {
+		name   string
+		ranges []string
+		names  IPSetNames
+	}{
+		{"缺少IPv6集合名称", []string{"2001:db8::/32"}, IPSetNames{IPv4: "deny"}},
+		{"前缀长度为0", []string{"0.0.0.0/0"}, DefaultIPSetNames("deny")},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			acl, _ := NewIPACL(tt.ranges, types.Blacklist)
+			if err := ExportIPSet(&bytes.Buffer{}, acl, tt.names); !errors.Is(err, ErrInvalidIPSet) {
+				t.Errorf("ExportIPSet() 错误 = %v, 期望 ErrInvalidIPSet", err)
+			}
+		})
+	}
+
+	// 没有规则的地址族不需要集合名称
+	acl, _ := NewIPACL([]string{"10.0.0.0/8"}, types.Blacklist)
+	if err := ExportIPSet(&bytes.Buffer{}, acl, IPSetNames{IPv4: "deny"}); err != nil {
+		t.Errorf("ExportIPSet() 返回错误: %v", err)
+	}
+}
+
+// TestImportIPSet 测试解析ipset save的输出
+func TestImportIPSet(t *testing.T) {
+	input := `create scanners hash:ip family inet hashsize 1024 maxelem 65536 timeout 3600
+add scanners 198.51.100.1 timeout 3000
+add scanners 198.51.100.2 comment "masscan"
+
+create bogons6 hash:net family inet6 hashsize 1024 maxelem 65536
+add bogons6 2001:db8::/32
+create legacy bitmap:ip range 192.0.2.0/24
+add legacy 192.0.2.10
+`
+	sets, err := ImportIPSet(strings.NewReader(input))
+	if err != nil {
+		t.Fatalf("ImportIPSet() 返回错误: %v", err)
+	}
+	want := []IPSet{
+		{Name: "scanners", Family: FamilyIPv4, Entries: []string{"198.51.100.1", "198.51.100.2"}},
+		{Name: "bogons6", Family: FamilyIPv6, Entries: []string{"2001:db8::/32"}},
+		{Name: "legacy", Family: FamilyIPv4, Entries: []string{"192.0.2.10"}},
+	}
+	if !reflect.DeepEqual(sets, want) {
+		t.Errorf("ImportIPSet() = %+v, 期望 %+v", sets, want)
+	}
+
+	errorTests := []struct {
+		name  string
+		input string
+	}{
+		{"不支持的类型", "create web hash:ip,port family inet\n"},
+		{"不支持的地址族", "create x hash:net family bridge\n"},
+		{"集合不存在", "add missing 10.0.0.1\n"},
+		{"地址族不符", "create x hash:net family inet\nadd x 2001:db8::1\n"},
+		{"nomatch", "create x hash:net family inet\nadd x 10.0.0.0/8 nomatch\n"},
+		{"无效的地址", "create x hash:net family inet\nadd x 10.0.0.300\n"},
+		{"重复创建", "create x hash:net\ncreate x hash:net\n"},
+		{"未知命令", "flush x\n"},
+	}
+	for _, tt := range errorTests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := ImportIPSet(strings.NewReader(tt.input)); err == nil {
+				t.Error("ImportIPSet() 应返回错误")
+			}
+		})
+	}
+}
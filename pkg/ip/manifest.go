@@ -0,0 +1,243 @@
+package ip
+
+import "sort"
+
+// PredefinedSetInfo 描述一个预定义IP集合，用于生成文档或在界面中展示
+//
+// 字段说明:
+//   - Name: 集合名称，可直接用于AddPredefinedSet和策略文件的predefined字段
+//   - Description: 集合的用途说明
+//   - References: 定义这些地址的标准或文档，如"RFC 1918"
+//   - Ranges: 集合中的IP范围，与GetPredefinedIPRanges返回的内容和顺序相同
+type PredefinedSetInfo struct {
+	Name        PredefinedSet     `json:"name"`
+	Description string            `json:"description"`
+	References  []string          `json:"references,omitempty"`
+	Ranges      []PredefinedRange `json:"ranges"`
+}
+
+// PredefinedRange 描述预定义集合中的一个IP范围
+//
+// 字段说明:
+//   - CIDR: IP范围
+//   - Description: 该范围的说明，没有说明时为空
+//   - Reference: 定义该范围的标准或文档，没有时为空
+type PredefinedRange struct {
+	CIDR        string `json:"cidr"`
+	Description string `json:"description,omitempty"`
+	Reference   string `json:"reference,omitempty"`
+}
+
+// predefinedSetDoc 是预定义集合的说明
+type predefinedSetDoc struct {
+	description string
+	references  []string
+	// ranges 是各IP范围的说明和参考标准
+	ranges map[string]PredefinedRange
+}
+
+// predefinedSetOrder 是预定义集合在清单中的顺序，与常量的声明顺序相同
+var predefinedSetOrder = []PredefinedSet{
+	PrivateNetworks,
+	LoopbackNetworks,
+	LinkLocalNetworks,
+	CloudMetadata,
+	DockerNetworks,
+	PublicDNS,
+	BroadcastAddresses,
+	MulticastAddresses,
+	ReservedAddresses,
+	TestNetworks,
+	K8sServiceAddresses,
+	CarrierGradeNAT,
+	UniqueLocalAddresses,
+	AllSpecialNetworks,
+}
+
+// predefinedSetDocs 是各预定义集合的说明，IP范围本身以PredefinedSets为准
+var predefinedSetDocs = map[PredefinedSet]predefinedSetDoc{
+	PrivateNetworks: {
+		description: "RFC 1918定义的私有网络地址，通常用于阻止内网访问，防止SSRF攻击",
+		references:  []string{"RFC 1918"},
+		ranges: map[string]PredefinedRange{
+			"10.0.0.0/8":     {Description: "10.0.0.0 - 10.255.255.255", Reference: "RFC 1918"},
+			"172.16.0.0/12":  {Description: "172.16.0.0 - 172.31.255.255", Reference: "RFC 1918"},
+			"192.168.0.0/16": {Description: "192.168.0.0 - 192.168.255.255", Reference: "RFC 1918"},
+		},
+	},
+	LoopbackNetworks: {
+		description: "本地回环地址，通常用于阻止对本机服务的访问",
+		references:  []string{"RFC 1122", "RFC 4291"},
+		ranges: map[string]PredefinedRange{
+			"127.0.0.0/8": {Description: "IPv4回环地址", Reference: "RFC 1122"},
+			"::1/128":     {Description: "IPv6回环地址", Reference: "RFC 4291"},
+		},
+	},
+	LinkLocalNetworks: {
+		description: "链路本地地址，用于同一网段内的通信，没有路由器参与",
+		references:  []string{"RFC 3927", "RFC 4291"},
+		ranges: map[string]PredefinedRange{
+			"169.254.0.0/16": {Description: "IPv4链路本地地址", Reference: "RFC 3927"},
+			"fe80::/10":      {Description: "IPv6链路本地地址", Reference: "RFC 4291"},
+		},
+	},
+	CloudMetadata: {
+		description: "各大云服务商的元数据服务地址，阻止对这些地址的访问可以防止云环境中的SSRF攻击",
+		ranges: map[string]PredefinedRange{
+			"169.254.169.254/32": {Description: "AWS/GCP/OpenStack 元数据服务"},
+			"169.254.170.2/32":   {Description: "Azure IMDS 服务主要地址"},
+			"fd00:ec2::254/128":  {Description: "AWS IPv6 元数据服务"},
+			"192.0.0.192/32":     {Description: "Oracle Cloud 元数据服务"},
+			"100.100.100.200/32": {Description: "阿里云 元数据服务"},
+		},
+	},
+	DockerNetworks: {
+		description: "Docker默认网络",
+		ranges: map[string]PredefinedRange{
+			"172.17.0.0/16": {Description: "Docker默认网桥"},
+		},
+	},
+	PublicDNS: {
+		description: "常用的公共DNS服务器，适用于需要显式允许这些DNS服务的白名单场景",
+		ranges: map[string]PredefinedRange{
+			"8.8.8.8/32":               {Description: "Google DNS"},
+			"8.8.4.4/32":               {Description: "Google DNS"},
+			"1.1.1.1/32":               {Description: "Cloudflare DNS"},
+			"1.0.0.1/32":               {Description: "Cloudflare DNS"},
+			"9.9.9.9/32":               {Description: "Quad9 DNS"},
+			"149.112.112.112/32":       {Description: "Quad9 DNS"},
+			"208.67.222.222/32":        {Description: "OpenDNS"},
+			"208.67.220.220/32":        {Description: "OpenDNS"},
+			"2001:4860:4860::8888/128": {Description: "Google DNS IPv6"},
+			"2001:4860:4860::8844/128": {Description: "Google DNS IPv6"},
+			"2606:4700:4700::1111/128": {Description: "Cloudflare DNS IPv6"},
+			"2606:4700:4700::1001/128": {Description: "Cloudflare DNS IPv6"},
+		},
+	},
+	BroadcastAddresses: {
+		description: "广播地址，用于向整个网络广播消息",
+		references:  []string{"RFC 919", "RFC 5771"},
+		ranges: map[string]PredefinedRange{
+			"255.255.255.255/32": {Description: "IPv4 限制广播地址", Reference: "RFC 919"},
+			"224.0.0.1/32":       {Description: "所有主机组播地址", Reference: "RFC 5771"},
+		},
+	},
+	MulticastAddresses: {
+		description: "组播地址，用于将消息发送到订阅特定组播组的多个主机",
+		references:  []string{"RFC 5771", "RFC 4291"},
+		ranges: map[string]PredefinedRange{
+			"224.0.0.0/4": {Description: "IPv4 多播地址范围", Reference: "RFC 5771"},
+			"ff00::/8":    {Description: "IPv6 多播地址范围", Reference: "RFC 4291"},
+		},
+	},
+	ReservedAddresses: {
+		description: "IANA保留的特殊用途地址",
+		references:  []string{"RFC 6890"},
+		ranges: map[string]PredefinedRange{
+			"0.0.0.0/8":       {Description: "当前网络", Reference: "RFC 1122"},
+			"192.0.0.0/24":    {Description: "IETF协议分配", Reference: "RFC 6890"},
+			"192.0.2.0/24":    {Description: "TEST-NET-1", Reference: "RFC 5737"},
+			"192.88.99.0/24":  {Description: "IPv6转IPv4中继", Reference: "RFC 3068"},
+			"198.18.0.0/15":   {Description: "网络设备基准测试", Reference: "RFC 2544"},
+			"198.51.100.0/24": {Description: "TEST-NET-2", Reference: "RFC 5737"},
+			"203.0.113.0/24":  {Description: "TEST-NET-3", Reference: "RFC 5737"},
+			"240.0.0.0/4":     {Description: "保留用于未来使用", Reference: "RFC 1112"},
+		},
+	},
+	TestNetworks: {
+		description: "用于测试和文档的网络范围",
+		references:  []string{"RFC 5737", "RFC 3849"},
+		ranges: map[string]PredefinedRange{
+			"192.0.2.0/24":    {Description: "TEST-NET-1", Reference: "RFC 5737"},
+			"198.51.100.0/24": {Description: "TEST-NET-2", Reference: "RFC 5737"},
+			"203.0.113.0/24":  {Description: "TEST-NET-3", Reference: "RFC 5737"},
+			"2001:db8::/32":   {Description: "IPv6文档前缀", Reference: "RFC 3849"},
+		},
+	},
+	K8sServiceAddresses: {
+		description: "Kubernetes服务和常见CNI插件的默认地址范围",
+		ranges: map[string]PredefinedRange{
+			"10.96.0.0/12":   {Description: "Kubernetes默认服务CIDR"},
+			"10.244.0.0/16":  {Description: "Flannel默认pod CIDR"},
+			"192.168.0.0/16": {Description: "Calico默认pod CIDR"},
+		},
+	},
+	CarrierGradeNAT: {
+		description: "运营商级NAT使用的共享地址空间",
+		references:  []string{"RFC 6598"},
+		ranges: map[string]PredefinedRange{
+			"100.64.0.0/10": {Description: "共享地址空间", Reference: "RFC 6598"},
+		},
+	},
+	UniqueLocalAddresses: {
+		description: "IPv6的唯一本地地址",
+		references:  []string{"RFC 4193"},
+		ranges: map[string]PredefinedRange{
+			"fc00::/7": {Description: "IPv6唯一本地地址", Reference: "RFC 4193"},
+		},
+	},
+	AllSpecialNetworks: {
+		description: "以上所有集合的并集（去重），提供最全面的保护；包含公共DNS，不能用于判断地址是否为公网地址",
+	},
+}
+
+// PredefinedSetsManifest 返回所有预定义集合的结构化清单
+//
+// 返回:
+//   - []PredefinedSetInfo: 按常量声明顺序排列的集合，运行时加入PredefinedSets的集合按名称排在最后
+//
+// IP范围取自PredefinedSets，清单总是与实际生效的内容一致。文档、命令行工具和管理界面
+// 可以据此生成集合目录，而不必手工维护一份副本。AllSpecialNetworks中的范围使用
+// 所属的第一个集合中的说明。
+//
+// 示例:
+//
+//	for _, set := range ip.PredefinedSetsManifest() {
+//	    fmt.Printf("%s: %s（%d个范围）\n", set.Name, set.Description, len(set.Ranges))
+//	}
+func PredefinedSetsManifest() []PredefinedSetInfo {
+	names := append([]PredefinedSet(nil), predefinedSetOrder...)
+	var extra []PredefinedSet
+	for name := range PredefinedSets {
+		if _, ok := predefinedSetDocs[name]; !ok {
+			extra = append(extra, name)
+		}
+	}
+	sort.Slice(extra, func(i, j int) bool { return extra[i] < extra[j] })
+	names = append(names, extra...)
+
+	manifest := make([]PredefinedSetInfo, 0, len(names))
+	for _, name := range names {
+		ranges, ok := PredefinedSets[name]
+		if !ok {
+			continue
+		}
+		doc := predefinedSetDocs[name]
+		info := PredefinedSetInfo{
+			Name:        name,
+			Description: doc.description,
+			References:  append([]string(nil), doc.references...),
+			Ranges:      make([]PredefinedRange, len(ranges)),
+		}
+		for i, cidr := range ranges {
+			info.Ranges[i] = describeRange(name, cidr)
+		}
+		manifest = append(manifest, info)
+	}
+	return manifest
+}
+
+// describeRange 返回集合中IP范围的说明，集合本身没有说明时按清单顺序查找其他集合
+func describeRange(name PredefinedSet, cidr string) PredefinedRange {
+	if r, ok := predefinedSetDocs[name].ranges[cidr]; ok {
+		r.CIDR = cidr
+		return r
+	}
+	for _, other := range predefinedSetOrder {
+		if r, ok := predefinedSetDocs[other].ranges[cidr]; ok {
+			r.CIDR = cidr
+			return r
+		}
+	}
+	return PredefinedRange{CIDR: cidr}
+}
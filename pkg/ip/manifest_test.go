@@ -0,0 +1,60 @@
+package ip
+
+import (
+	"reflect"
+	"testing"
+)
+
+// TestPredefinedSetsManifest 测试预定义集合清单与PredefinedSets一致
+func TestPredefinedSetsManifest(t *testing.T) {
+	manifest := PredefinedSetsManifest()
+	if len(manifest) != len(PredefinedSets) {
+		t.Fatalf("清单包含%d个集合, 期望%d个", len(manifest), len(PredefinedSets))
+	}
+	if manifest[0].Name != PrivateNetworks || manifest[len(manifest)-1].Name != AllSpecialNetworks {
+		t.Errorf("清单顺序 = %s ... %s, 期望按常量声明顺序", manifest[0].Name, manifest[len(manifest)-1].Name)
+	}
+
+	for _, set := range manifest {
+		if set.Description == "" {
+			t.Errorf("集合 %s 没有说明", set.Name)
+		}
+		var cidrs []string
+		for _, r := range set.Ranges {
+			cidrs = append(cidrs, r.CIDR)
+			// 所有内置的范围都有说明，包括AllSpecialNetworks中的范围
+			if r.Description == "" {
+				t.Errorf("集合 %s 的范围 %s 没有说明", set.Name, r.CIDR)
+			}
+		}
+		if !reflect.DeepEqual(cidrs, GetPredefinedIPRanges(set.Name)) {
+			t.Errorf("集合 %s 的范围 = %v, 期望与GetPredefinedIPRanges一致", set.Name, cidrs)
+		}
+	}
+
+	private := manifest[0]
+	if !reflect.DeepEqual(private.References, []string{"RFC 1918"}) || private.Ranges[0] != (PredefinedRange{CIDR: "10.0.0.0/8", Description: "10.0.0.0 - 10.255.255.255", Reference: "RFC 1918"}) {
+		t.Errorf("PrivateNetworks = %+v", private)
+	}
+}
+
+// TestPredefinedSetsManifestCustomSet 测试运行时加入的集合出现在清单末尾
+func TestPredefinedSetsManifestCustomSet(t *testing.T) {
+	custom := PredefinedSet("office_networks")
+	PredefinedSets[custom] = []string{"198.51.100.0/24", "192.0.2.128/25"}
+	defer delete(PredefinedSets, custom)
+
+	manifest := PredefinedSetsManifest()
+	last := manifest[len(manifest)-1]
+	want := PredefinedSetInfo{
+		Name: custom,
+		Ranges: []PredefinedRange{
+			// 其他集合中已有说明的范围沿用该说明
+			{CIDR: "198.51.100.0/24", Description: "TEST-NET-2", Reference: "RFC 5737"},
+			{CIDR: "192.0.2.128/25"},
+		},
+	}
+	if !reflect.DeepEqual(last, want) {
+		t.Errorf("清单的最后一项 = %+v, 期望 %+v", last, want)
+	}
+}
@@ -0,0 +1,140 @@
+package ip
+
+import (
+	"errors"
+	"net"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/cyberspacesec/go-acl/pkg/types"
+)
+
+// Match 返回IP匹配到的列表条目
+//
+// 参数:
+//   - ip: 要匹配的IP地址
+//
+// 返回:
+//   - string: 匹配到的条目（原始输入的IP/CIDR字符串），未匹配时为空
+//   - bool: 是否匹配
+//   - error: IP格式无效时返回ErrInvalidIP
+//
+// 存在多个匹配条目时返回前缀最长（最具体）的条目。Match按顺序扫描所有条目，
+// 不使用基数树，主要用于诊断和Manager.Explain，不应在请求路径上使用。
+//
+// 示例:
+//
+//	acl, _ := ip.NewIPACL([]string{"10.0.0.0/8", "10.1.0.0/16"}, types.Blacklist)
+//	rule, matched, _ := acl.Match("10.1.2.3") // "10.1.0.0/16", true
+func (a *IPACL) Match(ip string) (string, bool, error) {
+	parsedIP := net.ParseIP(strings.TrimSpace(ip))
+	if parsedIP == nil {
+		return "", false, ErrInvalidIP
+	}
+
+	best := -1
+	bestOnes := -1
+	for i, ipRange := range a.ranges {
+		if ipRange.IPNet == nil || !ipRange.IPNet.Contains(parsedIP) {
+			continue
+		}
+		// 使用基数树的前缀长度，使IPv4映射网络与IPv4网络的掩码长度可比较
+		if _, ones, _ := netKey(ipRange.IPNet); int(ones) > bestOnes {
+			best, bestOnes = i, int(ones)
+		}
+	}
+	if best == -1 {
+		return "", false, nil
+	}
+	return a.ranges[best].Original, true, nil
+}
+
+// MatchAll 返回IP匹配到的所有列表条目
+//
+// 参数:
+//   - ip: 要匹配的IP地址
+//
+// 返回:
+//   - []string: 匹配到的条目，前缀最长（最具体）的在前，前缀长度相同时按加入列表的顺序排列；
+//     第一个条目与Match返回的相同，未匹配时为nil
+//   - error: IP格式无效时返回ErrInvalidIP
+//
+// 与Match相同，MatchAll按顺序扫描所有条目，不应在请求路径上频繁使用。
+//
+// 示例:
+//
+//	acl, _ := ip.NewIPACL([]string{"10.0.0.0/8", "10.1.0.0/16"}, types.Blacklist)
+//	rules, _ := acl.MatchAll("10.1.2.3") // ["10.1.0.0/16", "10.0.0.0/8"]
+func (a *IPACL) MatchAll(ip string) ([]string, error) {
+	parsedIP := net.ParseIP(strings.TrimSpace(ip))
+	if parsedIP == nil {
+		return nil, ErrInvalidIP
+	}
+
+	var matched []IPRange
+	for _, ipRange := range a.ranges {
+		if ipRange.IPNet != nil && ipRange.IPNet.Contains(parsedIP) {
+			matched = append(matched, ipRange)
+		}
+	}
+	if len(matched) == 0 {
+		return nil, nil
+	}
+
+	// 与Match相同，使用基数树的前缀长度比较
+	sort.SliceStable(matched, func(i, j int) bool {
+		_, onesI, _ := netKey(matched[i].IPNet)
+		_, onesJ, _ := netKey(matched[j].IPNet)
+		return onesI > onesJ
+	})
+	rules := make([]string, len(matched))
+	for i, r := range matched {
+		rules[i] = r.Original
+	}
+	return rules, nil
+}
+
+// CheckDetailed 检查IP是否允许访问，并返回决定结果的规则
+//
+// 参数:
+//   - ip: 要检查的IP地址
+//
+// 返回:
+//   - types.CheckResult: Kind为"ip"、Source为"ip_acl"的检查结果，RuleID为Match返回的条目，
+//     Matches为MatchAll返回的所有条目
+//   - error: 与Check相同的错误
+//
+// 结果与Check相同。RuleID和Matches由MatchAll计算，开销高于Check，适合日志、审计等需要规则信息的场景。
+//
+// 示例:
+//
+//	result, _ := acl.CheckDetailed("10.1.2.3")
+//	log.Printf("%s -> %s（规则 %s，耗时%s）", result.Target, result.Decision, result.RuleID, result.Latency)
+func (a *IPACL) CheckDetailed(ip string) (types.CheckResult, error) {
+	start := time.Now()
+	result := types.CheckResult{Target: ip, Kind: "ip", Source: "ip_acl"}
+
+	var err error
+	result.Decision, err = a.Check(ip)
+	switch {
+	case errors.Is(err, ErrFamilyNotAllowed):
+		result.Reason = types.ReasonDeniedFamily
+	case errors.Is(err, ErrInvalidIP):
+		result.Reason = types.ReasonInvalidInput
+	case errors.Is(err, types.ErrNoACL):
+		result.Reason = types.ReasonNoACL
+	case err != nil:
+		result.Reason = types.ReasonCheckFailed
+	default:
+		result.Matches, _ = a.MatchAll(ip)
+		if len(result.Matches) > 0 {
+			result.RuleID = result.Matches[0]
+		}
+		if result.Decision == types.Denied {
+			result.Reason = types.DenyReason("ip", a.listType)
+		}
+	}
+	result.Latency = time.Since(start)
+	return result, err
+}
@@ -0,0 +1,106 @@
+package ip
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/cyberspacesec/go-acl/pkg/types"
+)
+
+// TestIPACLMatch 测试返回匹配的条目
+func TestIPACLMatch(t *testing.T) {
+	acl, err := NewIPACL([]string{"10.0.0.0/8", "10.1.0.0/16", "10.1.2.3", "2001:db8::/32"}, types.Whitelist)
+	if err != nil {
+		t.Fatalf("NewIPACL() 返回错误: %v", err)
+	}
+
+	tests := []struct {
+		ip      string
+		rule    string
+		matched bool
+		wantErr error
+	}{
+		{"10.1.2.3", "10.1.2.3", true, nil},
+		{"10.1.9.9", "10.1.0.0/16", true, nil},
+		{"10.9.9.9", "10.0.0.0/8", true, nil},
+		{"2001:db8::1", "2001:db8::/32", true, nil},
+		{"8.8.8.8", "", false, nil},
+		{"invalid", "", false, ErrInvalidIP},
+	}
+
+	for _, tt := range tests {
+		rule, matched, err := acl.Match(tt.ip)
+		if rule != tt.rule || matched != tt.matched || err != tt.wantErr {
+			t.Errorf("Match(%s) = %q, %v, %v; 期望 %q, %v, %v", tt.ip, rule, matched, err, tt.rule, tt.matched, tt.wantErr)
+		}
+	}
+}
+
+// TestIPACLMatchAll 测试返回所有匹配的条目
+func TestIPACLMatchAll(t *testing.T) {
+	acl, err := NewIPACL([]string{"10.0.0.0/8", "192.0.2.1", "10.1.0.0/16"}, types.Blacklist)
+	if err != nil {
+		t.Fatalf("NewIPACL() 返回错误: %v", err)
+	}
+
+	tests := []struct {
+		ip      string
+		want    []string
+		wantErr error
+	}{
+		// 最具体的在前
+		{"10.1.2.3", []string{"10.1.0.0/16", "10.0.0.0/8"}, nil},
+		{"10.9.9.9", []string{"10.0.0.0/8"}, nil},
+		{"8.8.8.8", nil, nil},
+		{"invalid", nil, ErrInvalidIP},
+	}
+
+	for _, tt := range tests {
+		got, err := acl.MatchAll(tt.ip)
+		if !reflect.DeepEqual(got, tt.want) || err != tt.wantErr {
+			t.Errorf("MatchAll(%s) = %v, %v; 期望 %v, %v", tt.ip, got, err, tt.want, tt.wantErr)
+		}
+	}
+}
+
+// TestIPACLCheckDetailed 测试详细检查结果与Check和Match一致
+func TestIPACLCheckDetailed(t *testing.T) {
+	acl, err := NewIPACL([]string{"10.0.0.0/8", "10.1.0.0/16"}, types.Blacklist)
+	if err != nil {
+		t.Fatalf("NewIPACL() 返回错误: %v", err)
+	}
+
+	tests := []struct {
+		ip       string
+		decision types.Permission
+		ruleID   string
+		wantErr  error
+	}{
+		{"10.1.2.3", types.Denied, "10.1.0.0/16", nil},
+		{"10.9.9.9", types.Denied, "10.0.0.0/8", nil},
+		{"8.8.8.8", types.Allowed, "", nil},
+		{"invalid", types.Denied, "", ErrInvalidIP},
+	}
+
+	for _, tt := range tests {
+		result, err := acl.CheckDetailed(tt.ip)
+		if err != tt.wantErr {
+			t.Errorf("CheckDetailed(%s) 错误 = %v, 期望 %v", tt.ip, err, tt.wantErr)
+		}
+		if result.Decision != tt.decision || result.RuleID != tt.ruleID {
+			t.Errorf("CheckDetailed(%s) = %v, %q; 期望 %v, %q", tt.ip, result.Decision, result.RuleID, tt.decision, tt.ruleID)
+		}
+		if result.Target != tt.ip || result.Kind != "ip" || result.Source != "ip_acl" {
+			t.Errorf("CheckDetailed(%s) = %+v", tt.ip, result)
+		}
+	}
+
+	// 重叠的范围全部列出，最具体的在前
+	result, _ := acl.CheckDetailed("10.1.2.3")
+	if want := []string{"10.1.0.0/16", "10.0.0.0/8"}; !reflect.DeepEqual(result.Matches, want) {
+		t.Errorf("CheckDetailed().Matches = %v, 期望 %v", result.Matches, want)
+	}
+	if result, _ := acl.CheckDetailed("8.8.8.8"); result.Matches != nil {
+		t.Errorf("未匹配时 Matches = %v, 期望 nil", result.Matches)
+	}
+}
@@ -0,0 +1,70 @@
+package ip
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+
+	"github.com/cyberspacesec/go-acl/pkg/types"
+)
+
+// TestExportNftables 测试导出为nft JSON格式的集合更新
+func TestExportNftables(t *testing.T) {
+	acl, err := NewIPACL([]string{"10.1.0.0/16", "203.0.113.0/24", "198.51.100.7", "10.0.0.0/8", "2001:db8::/32", "::ffff:192.0.2.1"}, types.Blacklist)
+	if err != nil {
+		t.Fatalf("NewIPACL() 返回错误: %v", err)
+	}
+
+	var buf bytes.Buffer
+	if err := ExportNftables(&buf, acl, DefaultNftSets("filter", "deny")); err != nil {
+		t.Fatalf("ExportNftables() 返回错误: %v", err)
+	}
+	want := `{"nftables":[` +
+		`{"add":{"set":{"family":"inet","table":"filter","name":"deny","type":"ipv4_addr","flags":["interval"]}}},` +
+		`{"flush":{"set":{"family":"inet","table":"filter","name":"deny"}}},` +
+		`{"add":{"element":{"family":"inet","table":"filter","name":"deny","elem":[` +
+		`{"prefix":{"addr":"203.0.113.0","len":24}},"198.51.100.7",{"prefix":{"addr":"10.0.0.0","len":8}},"192.0.2.1"]}}},` +
+		`{"add":{"set":{"family":"inet","table":"filter","name":"deny6","type":"ipv6_addr","flags":["interval"]}}},` +
+		`{"flush":{"set":{"family":"inet","table":"filter","name":"deny6"}}},` +
+		`{"add":{"element":{"family":"inet","table":"filter","name":"deny6","elem":[{"prefix":{"addr":"2001:db8::","len":32}}]}}}` +
+		"]}\n"
+	if buf.String() != want {
+		t.Errorf("ExportNftables() 输出:\n%s\n期望:\n%s", buf.String(), want)
+	}
+}
+
+// TestExportNftablesEmpty 测试空列表仍然清空集合
+func TestExportNftablesEmpty(t *testing.T) {
+	acl, _ := NewIPACL(nil, types.Blacklist)
+	var buf bytes.Buffer
+	if err := ExportNftables(&buf, acl, NftSets{Family: "inet", Table: "filter", IPv4: "deny"}); err != nil {
+		t.Fatalf("ExportNftables() 返回错误: %v", err)
+	}
+	want := `{"nftables":[` +
+		`{"add":{"set":{"family":"inet","table":"filter","name":"deny","type":"ipv4_addr","flags":["interval"]}}},` +
+		`{"flush":{"set":{"family":"inet","table":"filter","name":"deny"}}}` +
+		"]}\n"
+	if buf.String() != want {
+		t.Errorf("ExportNftables() 输出:\n%s\n期望:\n%s", buf.String(), want)
+	}
+}
+
+// TestExportNftablesErrors 测试无法导出的配置
+func TestExportNftablesErrors(t *testing.T) {
+	tests := []struct {
+		name   string
+		ranges []string
+		sets   NftSets
+	}{
+		{"缺少表名称", []string{"10.0.0.0/8"}, NftSets{Family: "inet", IPv4: "deny"}},
+		{"缺少IPv6集合名称", []string{"2001:db8::/32"}, NftSets{Family: "inet", Table: "filter", IPv4: "deny"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			acl, _ := NewIPACL(tt.ranges, types.Blacklist)
+			if err := ExportNftables(&bytes.Buffer{}, acl, tt.sets); !errors.Is(err, ErrInvalidNftSets) {
+				t.Errorf("ExportNftables() 错误 = %v, 期望 ErrInvalidNftSets", err)
+			}
+		})
+	}
+}
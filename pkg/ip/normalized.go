@@ -0,0 +1,98 @@
+package ip
+
+import (
+	"net"
+	"strings"
+
+	"github.com/cyberspacesec/go-acl/pkg/types"
+)
+
+// NormalizedIP 是已解析为标准形式的IP地址
+//
+// 只能通过NormalizeIP或NormalizeNetIP得到，零值表示无效的地址。
+// 高吞吐量的调用方（如对同一个连接的每个请求检查多个列表）可以只解析一次，
+// 再用CheckNormalized检查任意多个IPACL，省去每次检查时的解析。
+type NormalizedIP struct {
+	ip net.IP
+}
+
+// NormalizeIP 按Check使用的规则解析IP地址
+//
+// 参数:
+//   - addr: IP地址，首尾空白被忽略，如"8.8.8.8"、"2001:db8::1"
+//
+// 返回:
+//   - NormalizedIP: 解析后的地址
+//   - error: 格式无效时返回ErrInvalidIP
+//
+// 与CanonicalizeIP不同，不接受"2130706433"等inet_aton风格的写法，与Check的行为一致。
+//
+// 示例:
+//
+//	addr, err := ip.NormalizeIP(clientIP)
+//	if err != nil {
+//	    return err
+//	}
+//	for _, acl := range acls {
+//	    if perm, _ := acl.CheckNormalized(addr); perm == types.Denied {
+//	        return errForbidden
+//	    }
+//	}
+func NormalizeIP(addr string) (NormalizedIP, error) {
+	parsed := net.ParseIP(strings.TrimSpace(addr))
+	if parsed == nil {
+		return NormalizedIP{}, ErrInvalidIP
+	}
+	return NormalizedIP{ip: parsed}, nil
+}
+
+// NormalizeNetIP 把已解析的net.IP转换为NormalizedIP
+//
+// 参数:
+//   - addr: 4字节或16字节的IP地址，如net.TCPAddr的IP字段
+//
+// 返回:
+//   - NormalizedIP: 地址的副本，之后修改addr不影响结果
+//   - error: 长度无效时返回ErrInvalidIP
+func NormalizeNetIP(addr net.IP) (NormalizedIP, error) {
+	if len(addr) != net.IPv4len && len(addr) != net.IPv6len {
+		return NormalizedIP{}, ErrInvalidIP
+	}
+	return NormalizedIP{ip: append(net.IP(nil), addr...)}, nil
+}
+
+// IsZero 判断n是否为零值（不是由NormalizeIP得到的地址）
+func (n NormalizedIP) IsZero() bool {
+	return n.ip == nil
+}
+
+// IP 返回地址的副本
+func (n NormalizedIP) IP() net.IP {
+	return append(net.IP(nil), n.ip...)
+}
+
+// String 返回地址的标准写法，如"2001:db8::1"，零值返回空字符串
+func (n NormalizedIP) String() string {
+	if n.ip == nil {
+		return ""
+	}
+	return n.ip.String()
+}
+
+// CheckNormalized 检查已解析的IP地址是否允许访问
+//
+// 参数:
+//   - addr: NormalizeIP返回的地址
+//
+// 返回:
+//   - types.Permission: 与Check(addr.String())相同的结果
+//   - error: addr为零值时返回ErrInvalidIP，其他错误与Check相同
+func (a *IPACL) CheckNormalized(addr NormalizedIP) (types.Permission, error) {
+	if a == nil {
+		return types.Denied, types.ErrNoACL
+	}
+	if addr.ip == nil {
+		return types.Denied, ErrInvalidIP
+	}
+	return a.checkParsed(addr.ip)
+}
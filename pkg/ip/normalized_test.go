@@ -0,0 +1,78 @@
+package ip
+
+import (
+	"errors"
+	"net"
+	"testing"
+
+	"github.com/cyberspacesec/go-acl/pkg/types"
+)
+
+// TestNormalizedIP 测试解析后的IP地址类型
+func TestNormalizedIP(t *testing.T) {
+	tests := []struct {
+		input   string
+		want    string
+		wantErr bool
+	}{
+		{" 192.168.1.1 ", "192.168.1.1", false},
+		{"2001:DB8:0:0::1", "2001:db8::1", false},
+		{"::ffff:10.0.0.1", "10.0.0.1", false},
+		{"2130706433", "", true},
+		{"10.0.0.0/8", "", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			got, err := NormalizeIP(tt.input)
+			if tt.wantErr {
+				if !errors.Is(err, ErrInvalidIP) || !got.IsZero() {
+					t.Errorf("NormalizeIP() = %q, %v, 期望 ErrInvalidIP", got, err)
+				}
+				return
+			}
+			if err != nil || got.String() != tt.want {
+				t.Errorf("NormalizeIP() = %q, %v, 期望 %q", got, err, tt.want)
+			}
+		})
+	}
+
+	raw := net.IPv4(203, 0, 113, 7)
+	addr, err := NormalizeNetIP(raw)
+	if err != nil || addr.String() != "203.0.113.7" {
+		t.Fatalf("NormalizeNetIP() = %q, %v", addr, err)
+	}
+	raw[15] = 8
+	addr.IP()[15] = 9
+	if addr.String() != "203.0.113.7" {
+		t.Errorf("修改原切片或IP()的结果后地址变为 %s", addr)
+	}
+	if _, err := NormalizeNetIP(net.IP{1, 2, 3}); !errors.Is(err, ErrInvalidIP) {
+		t.Errorf("NormalizeNetIP(3字节) 错误 = %v, 期望 ErrInvalidIP", err)
+	}
+}
+
+// TestCheckNormalized 测试CheckNormalized与Check的结果相同
+func TestCheckNormalized(t *testing.T) {
+	acl, _ := NewIPACL([]string{"10.0.0.0/8", "2001:db8::/32"}, types.Blacklist)
+	acl.SetFamily(FamilyIPv4)
+
+	for _, input := range []string{"10.1.2.3", "192.0.2.1", "::ffff:10.0.0.1", "2001:db8::1"} {
+		addr, err := NormalizeIP(input)
+		if err != nil {
+			t.Fatalf("NormalizeIP(%s) 返回错误: %v", input, err)
+		}
+		want, wantErr := acl.Check(input)
+		if got, err := acl.CheckNormalized(addr); got != want || !errors.Is(err, wantErr) {
+			t.Errorf("CheckNormalized(%s) = %v, %v, 期望 %v, %v", input, got, err, want, wantErr)
+		}
+	}
+
+	if _, err := acl.CheckNormalized(NormalizedIP{}); !errors.Is(err, ErrInvalidIP) {
+		t.Errorf("CheckNormalized(零值) 错误 = %v, 期望 ErrInvalidIP", err)
+	}
+	var nilACL *IPACL
+	addr, _ := NormalizeIP("10.0.0.1")
+	if _, err := nilACL.CheckNormalized(addr); !errors.Is(err, types.ErrNoACL) {
+		t.Errorf("nil.CheckNormalized() 错误 = %v, 期望 types.ErrNoACL", err)
+	}
+}
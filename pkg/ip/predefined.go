@@ -175,9 +175,10 @@ func init() {
 	// 创建所有特殊网络的集合
 	var allNetworks []string
 
-	for set, networks := range PredefinedSets {
+	// 按声明顺序合并，使AllSpecialNetworks的内容顺序固定
+	for _, set := range predefinedSetOrder {
 		if set != AllSpecialNetworks { // 避免自引用
-			allNetworks = append(allNetworks, networks...)
+			allNetworks = append(allNetworks, PredefinedSets[set]...)
 		}
 	}
 
@@ -0,0 +1,122 @@
+package ip
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/cyberspacesec/go-acl/pkg/types"
+)
+
+// SetIssue 是ValidatePredefinedSet在集合中发现的一个问题
+//
+// 字段说明:
+//   - Set: 集合名称
+//   - Range: 有问题的范围，集合本身的问题（如名称为空）时为空
+//   - Problem: 问题的说明
+type SetIssue struct {
+	Set     PredefinedSet
+	Range   string
+	Problem string
+}
+
+// String 返回"集合: 范围: 说明"形式的描述
+func (i SetIssue) String() string {
+	if i.Range == "" {
+		return fmt.Sprintf("%s: %s", i.Set, i.Problem)
+	}
+	return fmt.Sprintf("%s: %s: %s", i.Set, i.Range, i.Problem)
+}
+
+// ValidatePredefinedSet 检查一个IP集合的内容，内置集合的测试使用相同的检查
+//
+// 参数:
+//   - name: 集合名称
+//   - ranges: 集合中的IP或CIDR
+//
+// 返回:
+//   - []SetIssue: 发现的问题，按范围在集合中的顺序排列；没有问题时返回nil
+//
+// 检查的内容:
+//   - 名称非空，集合非空
+//   - 每个范围都是有效的IP或CIDR
+//   - CIDR的主机位为0（如"10.0.0.1/8"应写作"10.0.0.0/8"），避免误以为只包含一个地址
+//   - 没有重复的范围（包括写法不同的同一网络，如"8.8.8.8"和"8.8.8.8/32"）
+//   - 没有被集合中其他范围包含的范围
+//
+// 集合之间的重叠不在检查范围内：自定义集合常常有意地是内置集合的子集（如公司网段之于PrivateNetworks），
+// 需要时可以用Covers比较两个集合。
+func ValidatePredefinedSet(name PredefinedSet, ranges []string) []SetIssue {
+	var issues []SetIssue
+	add := func(r, format string, args ...interface{}) {
+		issues = append(issues, SetIssue{Set: name, Range: r, Problem: fmt.Sprintf(format, args...)})
+	}
+	if strings.TrimSpace(string(name)) == "" {
+		add("", "集合名称为空")
+	}
+	if len(ranges) == 0 {
+		add("", "集合为空")
+	}
+
+	seen := make(map[string]string, len(ranges))
+	var unique []string
+	for _, r := range ranges {
+		parsed, err := parseIPRange(r)
+		if err != nil {
+			add(r, "不是有效的IP或CIDR")
+			continue
+		}
+		network := parsed.IPNet.String()
+		if strings.Contains(r, "/") && !parsed.IP.Equal(parsed.IPNet.IP) {
+			add(r, "主机位不为0，应写作%s", network)
+		}
+		if first, ok := seen[network]; ok {
+			add(r, "与%s重复", first)
+			continue
+		}
+		seen[network] = r
+		unique = append(unique, r)
+	}
+
+	// 每个范围至少被自身覆盖，覆盖者是其他范围时说明它是多余的
+	acl, _ := NewIPACL(unique, types.Blacklist)
+	for _, c := range Covers(acl, acl) {
+		if c.CoveredBy != c.Rule {
+			add(c.Rule, "已被%s包含", c.CoveredBy)
+		}
+	}
+	return issues
+}
+
+// RegisterPredefinedSet 检查并注册自定义的IP集合，之后可以像内置集合一样按名称使用
+//
+// 参数:
+//   - name: 集合名称，不能与内置集合同名；已注册的自定义集合会被替换
+//   - ranges: 集合中的IP或CIDR，会被复制
+//
+// 返回:
+//   - error: 名称与内置集合相同，或ValidatePredefinedSet发现问题时返回包装了ErrInvalidPredefinedSet的错误，
+//     错误信息包含第一个问题和问题总数
+//
+// 注册后的集合可用于AddPredefinedSet、NewIPACLWithDefaults和策略文件的predefined字段，
+// 并出现在PredefinedSetsManifest中，但不会加入AllSpecialNetworks。
+// PredefinedSets没有加锁，注册应在init或启动时、开始检查之前完成。
+//
+// 示例:
+//
+//	func init() {
+//	    if err := ip.RegisterPredefinedSet("corp_vpn", []string{"10.8.0.0/16", "fd12:3456::/48"}); err != nil {
+//	        panic(err)
+//	    }
+//	}
+//
+//	acl.AddPredefinedSet("corp_vpn", true)
+func RegisterPredefinedSet(name PredefinedSet, ranges []string) error {
+	if _, builtin := predefinedSetDocs[name]; builtin {
+		return fmt.Errorf("%w: %s是内置集合，不能替换", ErrInvalidPredefinedSet, name)
+	}
+	if issues := ValidatePredefinedSet(name, ranges); len(issues) > 0 {
+		return fmt.Errorf("%w: %s（共%d个问题）", ErrInvalidPredefinedSet, issues[0], len(issues))
+	}
+	PredefinedSets[name] = append([]string(nil), ranges...)
+	return nil
+}
@@ -0,0 +1,232 @@
+package ip
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/cyberspacesec/go-acl/pkg/types"
+)
+
+// intendedOverlaps 是内置集合之间有意的重叠，键为按predefinedSetOrder排列的集合对
+//
+// 新增或修改内置集合后出现未列出的重叠时，TestPredefinedSetOverlaps会失败：
+// 确认重叠是有意的（如元数据地址属于链路本地地址）再加入此表。
+var intendedOverlaps = map[[2]PredefinedSet]string{
+	{PrivateNetworks, DockerNetworks}:        "Docker默认网桥位于172.16.0.0/12",
+	{PrivateNetworks, K8sServiceAddresses}:   "Kubernetes默认的服务和pod网段取自私有地址",
+	{LinkLocalNetworks, CloudMetadata}:       "大多数云平台的元数据服务使用链路本地地址",
+	{CloudMetadata, ReservedAddresses}:       "Oracle Cloud的元数据地址位于192.0.0.0/24",
+	{CloudMetadata, CarrierGradeNAT}:         "阿里云的元数据地址位于共享地址空间",
+	{CloudMetadata, UniqueLocalAddresses}:    "AWS的IPv6元数据地址是唯一本地地址",
+	{BroadcastAddresses, MulticastAddresses}: "224.0.0.1是所有主机组播地址",
+	{BroadcastAddresses, ReservedAddresses}:  "受限广播地址位于240.0.0.0/4",
+	{ReservedAddresses, TestNetworks}:        "文档用地址同时是IANA特殊用途地址",
+}
+
+// TestPredefinedSetsValid 测试每个内置集合都通过ValidatePredefinedSet的检查，且都有清单说明
+func TestPredefinedSetsValid(t *testing.T) {
+	for name, ranges := range PredefinedSets {
+		name, ranges := name, ranges
+		t.Run(string(name), func(t *testing.T) {
+			if _, ok := predefinedSetDocs[name]; !ok {
+				t.Errorf("集合 %s 没有清单说明", name)
+			}
+			if name == AllSpecialNetworks {
+				// 汇总集合中的重叠来自各集合之间有意的重叠，只检查格式
+				for _, r := range ranges {
+					if err := ValidateRange(r); err != nil {
+						t.Errorf("%s 不是有效的IP或CIDR", r)
+					}
+				}
+				return
+			}
+			for _, issue := range ValidatePredefinedSet(name, ranges) {
+				t.Error(issue)
+			}
+		})
+	}
+	for _, name := range predefinedSetOrder {
+		if _, ok := PredefinedSets[name]; !ok {
+			t.Errorf("清单中的集合 %s 不存在", name)
+		}
+	}
+}
+
+// TestPredefinedSetOverlaps 测试内置集合之间只有intendedOverlaps中列出的重叠
+func TestPredefinedSetOverlaps(t *testing.T) {
+	var sets []PredefinedSet
+	for _, name := range predefinedSetOrder {
+		if name != AllSpecialNetworks {
+			sets = append(sets, name)
+		}
+	}
+
+	found := make(map[[2]PredefinedSet][]string)
+	for i, a := range sets {
+		aclA, _ := NewIPACL(PredefinedSets[a], types.Blacklist)
+		for _, b := range sets[i+1:] {
+			aclB, _ := NewIPACL(PredefinedSets[b], types.Blacklist)
+			var overlaps []string
+			for _, c := range Covers(aclA, aclB) {
+				overlaps = append(overlaps, fmt.Sprintf("%s⊇%s", c.CoveredBy, c.Rule))
+			}
+			for _, c := range Covers(aclB, aclA) {
+				overlaps = append(overlaps, fmt.Sprintf("%s⊆%s", c.Rule, c.CoveredBy))
+			}
+			if len(overlaps) > 0 {
+				sort.Strings(overlaps)
+				found[[2]PredefinedSet{a, b}] = overlaps
+			}
+		}
+	}
+
+	for pair, overlaps := range found {
+		if _, ok := intendedOverlaps[pair]; !ok {
+			t.Errorf("%s 与 %s 有未声明的重叠: %s", pair[0], pair[1], strings.Join(overlaps, ", "))
+		}
+	}
+	for pair := range intendedOverlaps {
+		if _, ok := found[pair]; !ok {
+			t.Errorf("声明的重叠 %s/%s 已不存在，请从intendedOverlaps中删除", pair[0], pair[1])
+		}
+	}
+}
+
+// TestPredefinedSetProbes 测试各集合包含和不包含的代表性地址
+func TestPredefinedSetProbes(t *testing.T) {
+	tests := []struct {
+		set PredefinedSet
+		in  []string
+		out []string
+	}{
+		{PrivateNetworks, []string{"10.0.0.1", "10.255.255.255", "172.16.0.1", "172.31.255.254", "192.168.0.1"},
+			[]string{"9.255.255.255", "11.0.0.0", "172.15.255.255", "172.32.0.0", "192.169.0.1", "8.8.8.8"}},
+		{LoopbackNetworks, []string{"127.0.0.1", "127.255.255.254", "::1"},
+			[]string{"128.0.0.1", "::2", "10.0.0.1"}},
+		{LinkLocalNetworks, []string{"169.254.0.1", "169.254.255.254", "fe80::1", "febf::1"},
+			[]string{"169.253.255.255", "169.255.0.0", "fec0::1"}},
+		{CloudMetadata, []string{"169.254.169.254", "169.254.170.2", "fd00:ec2::254", "192.0.0.192", "100.100.100.200"},
+			[]string{"169.254.169.253", "169.254.170.3", "fd00:ec2::253", "100.100.100.201"}},
+		{DockerNetworks, []string{"172.17.0.1", "172.17.255.254"},
+			[]string{"172.16.0.1", "172.18.0.1"}},
+		{PublicDNS, []string{"8.8.8.8", "1.1.1.1", "9.9.9.9", "2606:4700:4700::1111"},
+			[]string{"8.8.8.9", "1.1.1.2", "2606:4700:4700::1112"}},
+		{BroadcastAddresses, []string{"255.255.255.255", "224.0.0.1"},
+			[]string{"255.255.255.254", "224.0.0.2"}},
+		{MulticastAddresses, []string{"224.0.0.0", "239.255.255.255", "ff02::1"},
+			[]string{"223.255.255.255", "240.0.0.0", "fe80::1"}},
+		{ReservedAddresses, []string{"0.0.0.0", "192.0.2.1", "198.18.0.1", "198.19.255.255", "240.0.0.1"},
+			[]string{"1.0.0.0", "198.20.0.0", "239.255.255.255"}},
+		{TestNetworks, []string{"192.0.2.1", "198.51.100.1", "203.0.113.255", "2001:db8::1"},
+			[]string{"192.0.3.1", "203.0.114.1", "2001:db9::1"}},
+		{K8sServiceAddresses, []string{"10.96.0.1", "10.111.255.254", "10.244.1.1", "192.168.10.1"},
+			[]string{"10.95.255.255", "10.112.0.0", "10.245.0.1"}},
+		{CarrierGradeNAT, []string{"100.64.0.1", "100.127.255.254"},
+			[]string{"100.63.255.255", "100.128.0.0"}},
+		{UniqueLocalAddresses, []string{"fc00::1", "fd12:3456::1"},
+			[]string{"fe00::1", "fbff::1", "10.0.0.1"}},
+		{AllSpecialNetworks, []string{"10.0.0.1", "127.0.0.1", "169.254.169.254", "224.0.0.5", "100.64.0.1", "fc00::1", "8.8.8.8"},
+			[]string{"93.184.216.34", "2606:2800:220:1::1"}},
+	}
+	for _, tt := range tests {
+		t.Run(string(tt.set), func(t *testing.T) {
+			acl, err := NewIPACL(PredefinedSets[tt.set], types.Blacklist)
+			if err != nil {
+				t.Fatalf("NewIPACL() 返回错误: %v", err)
+			}
+			for _, addr := range tt.in {
+				if perm, err := acl.Check(addr); err != nil || perm != types.Denied {
+					t.Errorf("%s 应在集合中: %v, %v", addr, perm, err)
+				}
+			}
+			for _, addr := range tt.out {
+				if perm, err := acl.Check(addr); err != nil || perm != types.Allowed {
+					t.Errorf("%s 不应在集合中: %v, %v", addr, perm, err)
+				}
+			}
+		})
+	}
+	for _, name := range predefinedSetOrder {
+		found := false
+		for _, tt := range tests {
+			found = found || tt.set == name
+		}
+		if !found {
+			t.Errorf("集合 %s 没有探测地址", name)
+		}
+	}
+}
+
+// TestValidatePredefinedSet 测试自定义集合的各类问题
+func TestValidatePredefinedSet(t *testing.T) {
+	tests := []struct {
+		name   string
+		set    PredefinedSet
+		ranges []string
+		want   []string
+	}{
+		{"有效", "corp", []string{"10.8.0.0/16", "fd12:3456::/48", "192.0.2.1"}, nil},
+		{"名称为空", " ", []string{"10.8.0.0/16"}, []string{" : 集合名称为空"}},
+		{"集合为空", "corp", nil, []string{"corp: 集合为空"}},
+		{"无效的范围", "corp", []string{"10.8.0.0/33", "corp.example.com"},
+			[]string{"corp: 10.8.0.0/33: 不是有效的IP或CIDR", "corp: corp.example.com: 不是有效的IP或CIDR"}},
+		{"主机位不为0", "corp", []string{"10.8.1.0/16"}, []string{"corp: 10.8.1.0/16: 主机位不为0，应写作10.8.0.0/16"}},
+		{"重复", "corp", []string{"8.8.8.8", "8.8.8.8/32"}, []string{"corp: 8.8.8.8/32: 与8.8.8.8重复"}},
+		{"被包含", "corp", []string{"10.8.1.0/24", "10.8.0.0/16"}, []string{"corp: 10.8.1.0/24: 已被10.8.0.0/16包含"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got []string
+			for _, issue := range ValidatePredefinedSet(tt.set, tt.ranges) {
+				got = append(got, issue.String())
+			}
+			if strings.Join(got, "\n") != strings.Join(tt.want, "\n") {
+				t.Errorf("ValidatePredefinedSet() = %q, 期望 %q", got, tt.want)
+			}
+		})
+	}
+}
+
+// TestRegisterPredefinedSet 测试注册的集合可以按名称使用，无效的集合和内置名称被拒绝
+func TestRegisterPredefinedSet(t *testing.T) {
+	const name PredefinedSet = "test_corp_vpn"
+	defer delete(PredefinedSets, name)
+
+	ranges := []string{"10.8.0.0/16"}
+	if err := RegisterPredefinedSet(name, ranges); err != nil {
+		t.Fatalf("RegisterPredefinedSet() 返回错误: %v", err)
+	}
+	ranges[0] = "192.0.2.0/24"
+	acl, err := NewIPACLWithDefaults(nil, types.Whitelist, []PredefinedSet{name}, true)
+	if err != nil {
+		t.Fatalf("NewIPACLWithDefaults() 返回错误: %v", err)
+	}
+	if perm, _ := acl.Check("10.8.3.4"); perm != types.Allowed {
+		t.Errorf("Check(10.8.3.4) = %v, 期望注册的集合被允许", perm)
+	}
+	if perm, _ := acl.Check("192.0.2.1"); perm != types.Denied {
+		t.Errorf("Check(192.0.2.1) = %v, 注册时应复制范围", perm)
+	}
+
+	tests := []struct {
+		name   string
+		set    PredefinedSet
+		ranges []string
+	}{
+		{"内置名称", PrivateNetworks, []string{"10.0.0.0/8"}},
+		{"无效的范围", name, []string{"10.8.0.0/16", "not-an-ip"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := RegisterPredefinedSet(tt.set, tt.ranges); !errors.Is(err, ErrInvalidPredefinedSet) {
+				t.Errorf("RegisterPredefinedSet() = %v, 期望 ErrInvalidPredefinedSet", err)
+			}
+		})
+	}
+	if got := PredefinedSets[name]; len(got) != 1 || got[0] != "10.8.0.0/16" {
+		t.Errorf("注册失败后集合 = %v, 期望保持不变", got)
+	}
+}
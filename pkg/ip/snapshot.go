@@ -0,0 +1,275 @@
+package ip
+
+import (
+	"encoding/binary"
+	"hash/fnv"
+	"net"
+
+	"github.com/cyberspacesec/go-acl/pkg/types"
+)
+
+// IPACLSnapshot 是IP访问控制列表的可序列化快照
+//
+// 快照保存的是已解析的二进制前缀，恢复时无需重新解析字符串。
+// 所有前缀紧凑地存放在一个字节切片中，用encoding/gob等格式编码时
+// 不会为每个范围产生单独的结构体开销，适合保存几十万条规则的大型列表。
+//
+// 字段说明:
+//   - ListType: 列表类型
+//   - Family: 地址族限制
+//   - Originals: 每个范围原始输入的IP/CIDR字符串
+//   - Prefixes: 与Originals一一对应的前缀，每个前缀依次为
+//     地址长度（4或16）、地址字节和前缀长度各1项
+//   - Matcher: 编译好的基数树，恢复时直接加载而不必逐条插入重建；
+//     为空（如旧版本生成的快照）、已损坏或与Prefixes不对应时，恢复时重新构建
+type IPACLSnapshot struct {
+	ListType  types.ListType
+	Family    Family
+	Originals []string
+	Prefixes  []byte
+	Matcher   []byte
+}
+
+// Snapshot 返回访问控制列表的快照
+//
+// 返回:
+//   - IPACLSnapshot: 列表类型、地址族限制、所有IP范围和编译好的匹配器的副本
+//
+// 匹配器以紧凑的二进制形式保存，每个节点只保存前缀长度覆盖的地址字节，
+// 通常使快照增大一倍左右，换来恢复时省去百万级列表的构建耗时。
+func (a *IPACL) Snapshot() IPACLSnapshot {
+	s := IPACLSnapshot{
+		ListType:  a.listType,
+		Family:    a.family,
+		Originals: make([]string, len(a.ranges)),
+		Prefixes:  make([]byte, 0, len(a.ranges)*(net.IPv4len+2)),
+	}
+	for i, r := range a.ranges {
+		s.Originals[i] = r.Original
+		addr := r.IP
+		if ip4 := addr.To4(); ip4 != nil && len(r.IPNet.Mask) == net.IPv4len {
+			addr = ip4
+		}
+		ones, _ := r.IPNet.Mask.Size()
+		s.Prefixes = append(s.Prefixes, byte(len(addr)))
+		s.Prefixes = append(s.Prefixes, addr...)
+		s.Prefixes = append(s.Prefixes, byte(ones))
+	}
+	if t, ok := a.engine.(*ipTrie); ok {
+		s.Matcher = t.encode(s.Prefixes)
+	}
+	return s
+}
+
+// NewIPACLFromSnapshot 从快照恢复IP访问控制列表
+//
+// 参数:
+//   - s: 由Snapshot生成的快照
+//
+// 返回:
+//   - *IPACL: 恢复的IP访问控制列表，使用DefaultMatcherOptions
+//   - error: 快照中的前缀数据损坏时返回ErrInvalidIP
+//
+// 恢复过程不解析字符串，只校验二进制前缀，因此比NewIPACL快。
+// 快照带有与前缀对应的匹配器时直接加载，否则重新构建匹配器，对于百万级的列表这是恢复耗时的主要部分。
+func NewIPACLFromSnapshot(s IPACLSnapshot) (*IPACL, error) {
+	acl := &IPACL{
+		listType: s.ListType,
+		family:   s.Family,
+		opts:     DefaultMatcherOptions,
+		engine:   newIPTrie(DefaultMatcherOptions),
+		ranges:   make([]IPRange, len(s.Originals)),
+	}
+
+	// 一次性复制前缀数据并批量分配网络结构，避免为每个范围单独分配内存
+	data := append([]byte(nil), s.Prefixes...)
+	networks := make([]byte, len(data))
+	nets := make([]net.IPNet, len(s.Originals))
+	var masks [2][net.IPv6len*8 + 1]net.IPMask
+
+	for i, original := range s.Originals {
+		if len(data) == 0 {
+			return nil, ErrInvalidIP
+		}
+		addrLen := int(data[0])
+		if !validAddrLen(addrLen) || len(data) < addrLen+2 {
+			return nil, ErrInvalidIP
+		}
+		ones := int(data[addrLen+1])
+		if ones > addrLen*8 {
+			return nil, ErrInvalidIP
+		}
+
+		// 相同长度的掩码只读共享
+		family := addrLen / net.IPv6len
+		mask := masks[family][ones]
+		if mask == nil {
+			mask = net.CIDRMask(ones, addrLen*8)
+			masks[family][ones] = mask
+		}
+
+		addr := net.IP(data[1 : addrLen+1 : addrLen+1])
+		network := net.IP(networks[1 : addrLen+1 : addrLen+1])
+		for j := range network {
+			network[j] = addr[j] & mask[j]
+		}
+		nets[i] = net.IPNet{IP: network, Mask: mask}
+		acl.ranges[i] = IPRange{Original: original, IP: addr, IPNet: &nets[i]}
+
+		data = data[addrLen+2:]
+		networks = networks[addrLen+2:]
+	}
+	if len(data) != 0 {
+		return nil, ErrInvalidIP
+	}
+
+	if trie, ok := decodeTrie(s.Matcher, s.Prefixes, acl.opts); ok {
+		acl.engine = trie
+	} else {
+		for i := range nets {
+			acl.engine.Insert(&nets[i])
+		}
+	}
+	return acl, nil
+}
+
+// validAddrLen 判断长度是否为有效的IPv4或IPv6地址长度
+func validAddrLen(n int) bool {
+	return n == net.IPv4len || n == net.IPv6len
+}
+
+// 序列化的匹配器格式:
+//
+//	版本(1) 标志(1) 节点数(4) 前缀数(4)
+//	每个节点: 前缀长度(1) 是否为完整前缀(1) 地址字节(前缀长度/8向上取整) 子节点下标(4+4)
+//	校验和(8): 快照前缀数据与以上内容的FNV-64a
+//
+// 整数使用小端序。校验和包含前缀数据，因此匹配器与快照中的前缀不对应时不会被使用。
+const (
+	matcherCacheVersion  = 1
+	matcherCacheHeader   = 10
+	matcherCacheChecksum = 8
+	// matcherCacheCompress 标志表示匹配器使用了路径压缩
+	matcherCacheCompress = 1
+)
+
+// encode 将基数树序列化为字节，prefixes是对应的快照前缀数据，t为nil时返回nil
+func (t *ipTrie) encode(prefixes []byte) []byte {
+	if t == nil {
+		return nil
+	}
+	buf := make([]byte, matcherCacheHeader, matcherCacheHeader+t.size*12+matcherCacheChecksum)
+	buf[0] = matcherCacheVersion
+	if t.opts.CompressPaths {
+		buf[1] = matcherCacheCompress
+	}
+	binary.LittleEndian.PutUint32(buf[2:], uint32(t.size))
+	binary.LittleEndian.PutUint32(buf[6:], uint32(t.prefixes))
+
+	var word [4]byte
+	for i := 0; i < t.size; i++ {
+		n := t.node(uint32(i))
+		terminal := byte(0)
+		if n.terminal {
+			terminal = 1
+		}
+		buf = append(buf, n.bits, terminal)
+		buf = append(buf, n.key[:keyBytes(n.bits)]...)
+		for _, child := range n.children {
+			binary.LittleEndian.PutUint32(word[:], child)
+			buf = append(buf, word[:]...)
+		}
+	}
+
+	var sum [matcherCacheChecksum]byte
+	binary.LittleEndian.PutUint64(sum[:], matcherChecksum(prefixes, buf))
+	return append(buf, sum[:]...)
+}
+
+// decodeTrie 加载encode生成的基数树
+//
+// 数据为空、版本或路径压缩选项不一致、校验和不匹配或结构无效时返回false，由调用方重新构建。
+func decodeTrie(data, prefixes []byte, opts MatcherOptions) (*ipTrie, bool) {
+	if len(data) < matcherCacheHeader+matcherCacheChecksum || data[0] != matcherCacheVersion {
+		return nil, false
+	}
+	if (data[1] == matcherCacheCompress) != opts.CompressPaths {
+		return nil, false
+	}
+	body := data[:len(data)-matcherCacheChecksum]
+	if binary.LittleEndian.Uint64(data[len(body):]) != matcherChecksum(prefixes, body) {
+		return nil, false
+	}
+
+	size := int(binary.LittleEndian.Uint32(body[2:]))
+	// 每个节点至少占10字节，先检查长度再分配节点
+	if size < 2 || size > (len(body)-matcherCacheHeader)/10 {
+		return nil, false
+	}
+	t := newIPTrie(opts)
+	t.prefixes = int(binary.LittleEndian.Uint32(body[6:]))
+	for t.size < size {
+		t.alloc()
+	}
+
+	rest := body[matcherCacheHeader:]
+	terminals := 0
+	for i := 0; i < size; i++ {
+		if len(rest) < 2 {
+			return nil, false
+		}
+		n := t.node(uint32(i))
+		n.bits, n.terminal = rest[0], rest[1] == 1
+		if n.bits > net.IPv6len*8 || rest[1] > 1 {
+			return nil, false
+		}
+		k := keyBytes(n.bits)
+		if len(rest) < 2+k+8 {
+			return nil, false
+		}
+		copy(n.key[:], rest[2:2+k])
+		n.key = maskKey(n.key, n.bits)
+		n.children[0] = binary.LittleEndian.Uint32(rest[2+k:])
+		n.children[1] = binary.LittleEndian.Uint32(rest[6+k:])
+		rest = rest[10+k:]
+		if n.terminal {
+			terminals++
+		}
+	}
+	if len(rest) != 0 || terminals != t.prefixes || t.node(0).bits != 0 || t.node(1).bits != 0 {
+		return nil, false
+	}
+
+	// 子节点必须位于父节点之下且前缀更长，保证查找总能结束
+	for i := 0; i < size; i++ {
+		parent := t.node(uint32(i))
+		for dir, idx := range parent.children {
+			if idx == nilNode {
+				continue
+			}
+			if idx == 1 || int(idx) >= size {
+				return nil, false
+			}
+			child := t.node(idx)
+			if child.bits <= parent.bits ||
+				commonBits(child.key, parent.key, parent.bits) != parent.bits ||
+				bitAt(child.key, parent.bits) != dir {
+				return nil, false
+			}
+		}
+	}
+	return t, true
+}
+
+// keyBytes 返回前缀长度为bits的键需要保存的字节数
+func keyBytes(bits uint8) int {
+	return (int(bits) + 7) / 8
+}
+
+// matcherChecksum 计算快照前缀数据和序列化的匹配器的校验和
+func matcherChecksum(prefixes, matcher []byte) uint64 {
+	h := fnv.New64a()
+	h.Write(prefixes)
+	h.Write(matcher)
+	return h.Sum64()
+}
@@ -0,0 +1,195 @@
+package ip
+
+import (
+	"errors"
+	"math/rand"
+	"net"
+	"testing"
+
+	"github.com/cyberspacesec/go-acl/pkg/types"
+)
+
+// TestSnapshotRoundTrip 测试快照的生成和恢复
+func TestSnapshotRoundTrip(t *testing.T) {
+	original, err := NewIPACL([]string{"192.168.1.0/24", "10.0.0.1", "2001:db8::/32", "::ffff:172.16.0.0/108"}, types.Blacklist)
+	if err != nil {
+		t.Fatalf("NewIPACL() 返回错误: %v", err)
+	}
+
+	restored, err := NewIPACLFromSnapshot(original.Snapshot())
+	if err != nil {
+		t.Fatalf("NewIPACLFromSnapshot() 返回错误: %v", err)
+	}
+
+	if restored.GetListType() != original.GetListType() {
+		t.Errorf("列表类型 = %v, 期望 %v", restored.GetListType(), original.GetListType())
+	}
+	got, want := restored.GetIPRanges(), original.GetIPRanges()
+	if len(got) != len(want) {
+		t.Fatalf("IP范围数量 = %d, 期望 %d", len(got), len(want))
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("IP范围[%d] = %s, 期望 %s", i, got[i], want[i])
+		}
+	}
+
+	for _, addr := range []string{"192.168.1.77", "10.0.0.1", "10.0.0.2", "2001:db8::1", "8.8.8.8", "172.16.3.4", "172.17.0.1"} {
+		p1, _ := original.Check(addr)
+		p2, _ := restored.Check(addr)
+		if p1 != p2 {
+			t.Errorf("Check(%s) = %v, 原始列表为 %v", addr, p2, p1)
+		}
+	}
+}
+
+// TestSnapshotInvalid 测试损坏的快照
+func TestSnapshotInvalid(t *testing.T) {
+	tests := []struct {
+		name      string
+		originals []string
+		prefixes  []byte
+	}{
+		{"地址长度无效", []string{"x"}, []byte{3, 1, 2, 3, 24}},
+		{"前缀长度超出范围", []string{"x"}, []byte{4, 1, 2, 3, 4, 33}},
+		{"数据被截断", []string{"x"}, []byte{4, 1, 2}},
+		{"前缀数量少于原始字符串", []string{"x", "y"}, []byte{4, 1, 2, 3, 4, 32}},
+		{"存在多余数据", []string{"x"}, []byte{4, 1, 2, 3, 4, 32, 4}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := NewIPACLFromSnapshot(IPACLSnapshot{Originals: tt.originals, Prefixes: tt.prefixes})
+			if !errors.Is(err, ErrInvalidIP) {
+				t.Errorf("NewIPACLFromSnapshot() 错误 = %v, 期望 ErrInvalidIP", err)
+			}
+		})
+	}
+}
+
+// TestSnapshotMatcher 测试快照中的匹配器被直接加载，且与重新构建的结果一致
+func TestSnapshotMatcher(t *testing.T) {
+	r := rand.New(rand.NewSource(7))
+	original, err := NewIPACL(randomCIDRs(r, 500), types.Blacklist)
+	if err != nil {
+		t.Fatalf("NewIPACL() 返回错误: %v", err)
+	}
+	snapshot := original.Snapshot()
+	if len(snapshot.Matcher) == 0 {
+		t.Fatal("快照没有包含匹配器")
+	}
+
+	loaded, ok := decodeTrie(snapshot.Matcher, snapshot.Prefixes, original.opts)
+	if !ok {
+		t.Fatal("decodeTrie() 无法加载快照中的匹配器")
+	}
+	got, want := loaded.stats(), original.MatcherStats()
+	if got.Prefixes != want.Prefixes || got.Nodes != want.Nodes {
+		t.Errorf("加载的匹配器 = %+v, 期望 %+v", got, want)
+	}
+
+	restored, err := NewIPACLFromSnapshot(snapshot)
+	if err != nil {
+		t.Fatalf("NewIPACLFromSnapshot() 返回错误: %v", err)
+	}
+	for i := 0; i < 2000; i++ {
+		addr := make(net.IP, net.IPv4len)
+		if i%4 == 0 {
+			addr = make(net.IP, net.IPv6len)
+		}
+		r.Read(addr)
+		p1, _ := original.Check(addr.String())
+		p2, _ := restored.Check(addr.String())
+		if p1 != p2 {
+			t.Fatalf("Check(%s) = %v, 原始列表为 %v", addr, p2, p1)
+		}
+	}
+
+	// 恢复的列表可以继续修改
+	if err := restored.Add("198.51.100.0/24"); err != nil {
+		t.Fatalf("Add() 返回错误: %v", err)
+	}
+	if p, _ := restored.Check("198.51.100.9"); p != types.Denied {
+		t.Errorf("Add()后 Check(198.51.100.9) = %v, 期望 %v", p, types.Denied)
+	}
+}
+
+// TestSnapshotMatcherFallback 测试匹配器无效时重新构建
+func TestSnapshotMatcherFallback(t *testing.T) {
+	original, err := NewIPACL([]string{"192.168.1.0/24", "10.0.0.1", "2001:db8::/32"}, types.Blacklist)
+	if err != nil {
+		t.Fatalf("NewIPACL() 返回错误: %v", err)
+	}
+	other, _ := NewIPACL([]string{"192.168.2.0/24", "10.0.0.2", "2001:db9::/32"}, types.Blacklist)
+
+	tamper := func(s IPACLSnapshot) IPACLSnapshot {
+		s.Matcher = append([]byte(nil), s.Matcher...)
+		s.Matcher[matcherCacheHeader] ^= 0xff
+		return s
+	}
+	stale := original.Snapshot()
+	stale.Matcher = other.Snapshot().Matcher
+	version := original.Snapshot()
+	version.Matcher = append([]byte(nil), version.Matcher...)
+	version.Matcher[0]++
+
+	tests := []struct {
+		name     string
+		snapshot IPACLSnapshot
+	}{
+		{"没有匹配器", IPACLSnapshot{ListType: types.Blacklist, Originals: original.Snapshot().Originals, Prefixes: original.Snapshot().Prefixes}},
+		{"匹配器被篡改", tamper(original.Snapshot())},
+		{"匹配器与前缀不对应", stale},
+		{"版本不一致", version},
+		{"数据被截断", IPACLSnapshot{Originals: original.Snapshot().Originals, Prefixes: original.Snapshot().Prefixes, Matcher: original.Snapshot().Matcher[:5]}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, ok := decodeTrie(tt.snapshot.Matcher, tt.snapshot.Prefixes, DefaultMatcherOptions); ok {
+				t.Error("decodeTrie() 加载了无效的匹配器")
+			}
+			restored, err := NewIPACLFromSnapshot(tt.snapshot)
+			if err != nil {
+				t.Fatalf("NewIPACLFromSnapshot() 返回错误: %v", err)
+			}
+			for addr, want := range map[string]types.Permission{
+				"192.168.1.7": types.Denied, "10.0.0.1": types.Denied, "2001:db8::1": types.Denied,
+				"192.168.2.7": types.Allowed, "10.0.0.2": types.Allowed, "2001:db9::1": types.Allowed,
+			} {
+				if got, _ := restored.Check(addr); got != want {
+					t.Errorf("Check(%s) = %v, 期望 %v", addr, got, want)
+				}
+			}
+		})
+	}
+}
+
+// BenchmarkSnapshotRestore1M 比较从快照恢复100万个CIDR时加载匹配器和重新构建的耗时
+//
+// 运行: go test ./pkg/ip -run ^$ -bench SnapshotRestore1M -benchmem
+func BenchmarkSnapshotRestore1M(b *testing.B) {
+	nets := benchmarkCIDRs(1000000)
+	cidrs := make([]string, len(nets))
+	for i, n := range nets {
+		cidrs[i] = n.String()
+	}
+	acl, err := NewIPACL(cidrs, types.Blacklist)
+	if err != nil {
+		b.Fatal(err)
+	}
+	snapshot := acl.Snapshot()
+	rebuild := snapshot
+	rebuild.Matcher = nil
+
+	for _, bc := range []struct {
+		name     string
+		snapshot IPACLSnapshot
+	}{{"加载匹配器", snapshot}, {"重新构建", rebuild}} {
+		b.Run(bc.name, func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				if _, err := NewIPACLFromSnapshot(bc.snapshot); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
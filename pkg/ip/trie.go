@@ -0,0 +1,331 @@
+package ip
+
+import (
+	"net"
+	"unsafe"
+)
+
+// MatcherOptions IP匹配器的调优选项
+//
+// IPACL内部使用基数树（radix tree）存储IP/CIDR前缀，查找时间只与地址位数相关，
+// 与规则数量无关。在内存受限的环境（如嵌入式Agent）中，可以通过以下选项
+// 在内存占用与构建速度之间权衡。
+//
+// 字段说明:
+//   - NodePoolSize: 节点池每次预分配的节点数量。节点按块分配，块越大分配次数越少、
+//     构建越快，但最后一块中未使用的节点会浪费内存。小于等于0时使用默认值
+//   - CompressPaths: 是否压缩单子节点分支（Patricia树）。启用后每个前缀最多新增
+//     两个节点；关闭后每一位都对应一个节点，内存占用显著增加，仅用于对比测试
+type MatcherOptions struct {
+	NodePoolSize  int
+	CompressPaths bool
+}
+
+// DefaultMatcherOptions 默认的匹配器选项
+var DefaultMatcherOptions = MatcherOptions{
+	NodePoolSize:  1024,
+	CompressPaths: true,
+}
+
+// MatcherStats 匹配器的内存统计信息
+//
+// 字段说明:
+//   - Prefixes: 匹配器中的前缀数量
+//   - Nodes: 已使用的节点数量
+//   - AllocatedNodes: 节点池中已分配的节点数量（包含未使用的预分配节点）
+//   - Bytes: 节点池占用的近似字节数
+type MatcherStats struct {
+	Prefixes       int
+	Nodes          int
+	AllocatedNodes int
+	Bytes          int
+}
+
+// trieNode 基数树节点
+// 使用节点池中的下标代替指针引用子节点，减少内存占用和GC扫描开销
+type trieNode struct {
+	key      [16]byte
+	children [2]uint32
+	bits     uint8
+	terminal bool
+}
+
+// nilNode 表示不存在的子节点，下标0保留给IPv4根节点，不会作为子节点出现
+const nilNode = 0
+
+// ipTrie 存储IPv4和IPv6前缀的基数树
+//
+// 节点0和节点1分别是IPv4和IPv6的根节点（前缀长度为0）。
+type ipTrie struct {
+	chunks   [][]trieNode
+	size     int
+	opts     MatcherOptions
+	prefixes int
+}
+
+// newIPTrie 创建一个空的基数树
+func newIPTrie(opts MatcherOptions) *ipTrie {
+	if opts.NodePoolSize <= 0 {
+		opts.NodePoolSize = DefaultMatcherOptions.NodePoolSize
+	}
+	t := &ipTrie{opts: opts}
+	t.alloc() // IPv4根节点
+	t.alloc() // IPv6根节点
+	return t
+}
+
+// alloc 从节点池中分配一个节点，返回其下标
+func (t *ipTrie) alloc() uint32 {
+	chunk := t.size / t.opts.NodePoolSize
+	if chunk == len(t.chunks) {
+		t.chunks = append(t.chunks, make([]trieNode, t.opts.NodePoolSize))
+	}
+	idx := t.size
+	t.size++
+	return uint32(idx)
+}
+
+// node 根据下标返回节点
+func (t *ipTrie) node(idx uint32) *trieNode {
+	return &t.chunks[int(idx)/t.opts.NodePoolSize][int(idx)%t.opts.NodePoolSize]
+}
+
+// insertNet 将网络范围插入基数树
+func (t *ipTrie) insertNet(ipNet *net.IPNet) {
+	key, bits, root := netKey(ipNet)
+	if t.insert(root, key, bits) {
+		t.prefixes++
+	}
+}
+
+// insert 插入前缀，前缀已存在时返回false
+func (t *ipTrie) insert(root uint32, key [16]byte, bits uint8) bool {
+	if !t.opts.CompressPaths {
+		// 不压缩路径时，为每一位都创建节点
+		for b := uint8(1); b < bits; b++ {
+			t.insertNode(root, maskKey(key, b), b, false)
+		}
+	}
+	return t.insertNode(root, key, bits, true)
+}
+
+// insertNode 在基数树中插入一个节点，terminal表示该节点对应一个完整前缀
+func (t *ipTrie) insertNode(root uint32, key [16]byte, bits uint8, terminal bool) bool {
+	cur := root
+	for {
+		n := t.node(cur)
+		if n.bits == bits {
+			if terminal && !n.terminal {
+				n.terminal = true
+				return true
+			}
+			return false
+		}
+
+		dir := bitAt(key, n.bits)
+		childIdx := n.children[dir]
+		if childIdx == nilNode {
+			leaf := t.alloc()
+			*t.node(leaf) = trieNode{key: key, bits: bits, terminal: terminal}
+			t.node(cur).children[dir] = leaf
+			return terminal
+		}
+
+		child := t.node(childIdx)
+		limit := child.bits
+		if bits < limit {
+			limit = bits
+		}
+		common := commonBits(child.key, key, limit)
+
+		if common == child.bits {
+			// 子节点是新前缀的祖先，继续向下
+			cur = childIdx
+			continue
+		}
+
+		if common == bits {
+			// 新前缀是子节点的祖先，插入到两者之间
+			mid := t.alloc()
+			m := t.node(mid)
+			*m = trieNode{key: key, bits: bits, terminal: terminal}
+			m.children[bitAt(t.node(childIdx).key, bits)] = childIdx
+			t.node(cur).children[dir] = mid
+			return terminal
+		}
+
+		// 在公共前缀处分叉
+		fork := t.alloc()
+		leaf := t.alloc()
+		*t.node(fork) = trieNode{key: maskKey(key, common), bits: common}
+		*t.node(leaf) = trieNode{key: key, bits: bits, terminal: terminal}
+		f := t.node(fork)
+		f.children[bitAt(key, common)] = leaf
+		f.children[bitAt(t.node(childIdx).key, common)] = childIdx
+		t.node(cur).children[dir] = fork
+		return terminal
+	}
+}
+
+// contains 判断IP是否被基数树中的任意前缀包含，t为nil（零值IPACL）时视为空树
+func (t *ipTrie) contains(ip net.IP) bool {
+	if t == nil {
+		return false
+	}
+	var key [16]byte
+	var maxBits uint8
+	cur := uint32(0)
+	if ip4 := ip.To4(); ip4 != nil {
+		copy(key[:], ip4)
+		maxBits = 32
+	} else if ip16 := ip.To16(); ip16 != nil {
+		copy(key[:], ip16)
+		maxBits = 128
+		cur = 1
+	} else {
+		return false
+	}
+
+	for {
+		n := t.node(cur)
+		if commonBits(n.key, key, n.bits) != n.bits {
+			return false
+		}
+		if n.terminal {
+			return true
+		}
+		if n.bits >= maxBits {
+			return false
+		}
+		cur = n.children[bitAt(key, n.bits)]
+		if cur == nilNode {
+			return false
+		}
+	}
+}
+
+// stats 返回基数树的内存统计信息，t为nil时返回零值
+func (t *ipTrie) stats() MatcherStats {
+	if t == nil {
+		return MatcherStats{}
+	}
+	allocated := len(t.chunks) * t.opts.NodePoolSize
+	return MatcherStats{
+		Prefixes:       t.prefixes,
+		Nodes:          t.size,
+		AllocatedNodes: allocated,
+		Bytes:          allocated * int(unsafe.Sizeof(trieNode{})),
+	}
+}
+
+// netKey 将网络范围转换为基数树的键、前缀长度和根节点下标
+// IPv4（包括IPv4映射的IPv6网络）使用IPv4根节点，与net.IPNet.Contains的行为一致
+func netKey(ipNet *net.IPNet) ([16]byte, uint8, uint32) {
+	var key [16]byte
+	mask := ipNet.Mask
+	if ip4 := ipNet.IP.To4(); ip4 != nil {
+		if len(mask) == net.IPv6len {
+			mask = mask[12:]
+		}
+		ones, _ := mask.Size()
+		copy(key[:], ip4)
+		return maskKey(key, uint8(ones)), uint8(ones), 0
+	}
+	ones, _ := mask.Size()
+	copy(key[:], ipNet.IP.To16())
+	return maskKey(key, uint8(ones)), uint8(ones), 1
+}
+
+// bitAt 返回键的第i位（从最高位开始计数）
+func bitAt(key [16]byte, i uint8) int {
+	return int(key[i/8]>>(7-i%8)) & 1
+}
+
+// commonBits 返回两个键在前limit位中的公共前缀长度
+func commonBits(a, b [16]byte, limit uint8) uint8 {
+	var n uint8
+	for i := 0; n < limit; i++ {
+		x := a[i] ^ b[i]
+		if x == 0 {
+			n += 8
+			continue
+		}
+		for x&0x80 == 0 {
+			n++
+			x <<= 1
+		}
+		break
+	}
+	if n > limit {
+		return limit
+	}
+	return n
+}
+
+// maskKey 只保留键的前bits位
+func maskKey(key [16]byte, bits uint8) [16]byte {
+	var out [16]byte
+	full := int(bits / 8)
+	copy(out[:full], key[:full])
+	if rem := bits % 8; rem != 0 {
+		out[full] = key[full] & (0xff << (8 - rem))
+	}
+	return out
+}
+
+// SetMatcherOptions 修改匹配器选项并重建内部匹配器
+//
+// 使用SetMatchEngine设置了其他引擎时，重建后恢复为基数树。
+//
+// 参数:
+//   - opts: 新的匹配器选项
+//
+// 示例:
+//
+//	acl.SetMatcherOptions(ip.MatcherOptions{NodePoolSize: 64, CompressPaths: true})
+func (a *IPACL) SetMatcherOptions(opts MatcherOptions) {
+	a.opts = opts
+	a.rebuildMatcher()
+}
+
+// MatcherStats 返回内部匹配器的内存统计信息
+//
+// 返回:
+//   - MatcherStats: 前缀数量、节点数量及近似内存占用；自定义的引擎没有统计信息，返回零值
+//
+// 示例:
+//
+//	stats := acl.MatcherStats()
+//	log.Printf("%d个前缀占用约%dKB", stats.Prefixes, stats.Bytes/1024)
+func (a *IPACL) MatcherStats() MatcherStats {
+	if e, ok := a.engine.(engineStats); ok {
+		return e.stats()
+	}
+	return MatcherStats{}
+}
+
+// MemoryUsage 返回IP访问控制列表占用的近似字节数
+//
+// 返回:
+//   - int: 匹配器节点池与已解析的IP范围（包括原始字符串）占用的字节数之和
+//
+// 结果是估算值，不包含Go运行时的分配开销，适合用于容量规划和监控规则规模的突变。
+func (a *IPACL) MemoryUsage() int {
+	bytes := int(unsafe.Sizeof(*a)) + a.MatcherStats().Bytes
+	for _, r := range a.ranges {
+		bytes += int(unsafe.Sizeof(r)) + len(r.Original) + len(r.IP)
+		if r.IPNet != nil {
+			bytes += int(unsafe.Sizeof(*r.IPNet)) + len(r.IPNet.IP) + len(r.IPNet.Mask)
+		}
+	}
+	return bytes
+}
+
+// rebuildMatcher 根据当前的IP范围重建基数树，并用它替换当前的匹配引擎
+func (a *IPACL) rebuildMatcher() {
+	trie := newIPTrie(a.opts)
+	for _, ipRange := range a.ranges {
+		trie.insertNet(ipRange.IPNet)
+	}
+	a.engine = trie
+}
@@ -0,0 +1,247 @@
+package ip
+
+import (
+	"encoding/binary"
+	"fmt"
+	"math/rand"
+	"net"
+	"runtime"
+	"testing"
+
+	"github.com/cyberspacesec/go-acl/pkg/types"
+)
+
+// linearContains 以线性扫描的方式判断IP是否在网络列表中，作为基数树的参照实现
+func linearContains(nets []*net.IPNet, ip net.IP) bool {
+	for _, n := range nets {
+		if n.Contains(ip) {
+			return true
+		}
+	}
+	return false
+}
+
+// randomCIDRs 生成count个随机的IPv4/IPv6网络
+func randomCIDRs(r *rand.Rand, count int) []string {
+	cidrs := make([]string, count)
+	for i := range cidrs {
+		if r.Intn(4) == 0 {
+			ip := make(net.IP, net.IPv6len)
+			r.Read(ip)
+			cidrs[i] = fmt.Sprintf("%s/%d", ip, r.Intn(129))
+		} else {
+			ip := make(net.IP, net.IPv4len)
+			binary.BigEndian.PutUint32(ip, r.Uint32())
+			cidrs[i] = fmt.Sprintf("%s/%d", ip, r.Intn(33))
+		}
+	}
+	return cidrs
+}
+
+// TestTrieMatchesLinearScan 测试基数树与线性扫描的结果一致
+func TestTrieMatchesLinearScan(t *testing.T) {
+	options := []MatcherOptions{
+		DefaultMatcherOptions,
+		{NodePoolSize: 1, CompressPaths: true},
+		{NodePoolSize: 7, CompressPaths: false},
+	}
+
+	r := rand.New(rand.NewSource(1))
+	for _, opts := range options {
+		t.Run(fmt.Sprintf("%+v", opts), func(t *testing.T) {
+			cidrs := randomCIDRs(r, 300)
+			var nets []*net.IPNet
+			trie := newIPTrie(opts)
+			for _, c := range cidrs {
+				// 限制较短的前缀，避免几乎所有IP都被匹配
+				_, n, _ := net.ParseCIDR(c)
+				if ones, _ := n.Mask.Size(); ones < 8 {
+					continue
+				}
+				nets = append(nets, n)
+				trie.insertNet(n)
+			}
+
+			for i := 0; i < 5000; i++ {
+				var target net.IP
+				if i%2 == 0 {
+					// 从已有网络中取地址，保证有足够的命中
+					n := nets[r.Intn(len(nets))]
+					target = make(net.IP, len(n.IP))
+					copy(target, n.IP)
+					target[len(target)-1] ^= byte(r.Intn(4))
+				} else if i%3 == 0 {
+					target = make(net.IP, net.IPv6len)
+					r.Read(target)
+				} else {
+					target = make(net.IP, net.IPv4len)
+					binary.BigEndian.PutUint32(target, r.Uint32())
+				}
+
+				if got, want := trie.contains(target), linearContains(nets, target); got != want {
+					t.Fatalf("contains(%s) = %v, 线性扫描结果为 %v", target, got, want)
+				}
+			}
+		})
+	}
+}
+
+// TestMatcherOptions 测试匹配器选项和内存统计
+func TestMatcherOptions(t *testing.T) {
+	ranges := []string{"10.0.0.0/8", "10.1.0.0/16", "192.168.1.1", "2001:db8::/32", "::ffff:172.16.0.0/108"}
+
+	compressed, err := NewIPACLWithOptions(ranges, types.Blacklist, MatcherOptions{NodePoolSize: 4, CompressPaths: true})
+	if err != nil {
+		t.Fatalf("NewIPACLWithOptions() 返回错误: %v", err)
+	}
+	stats := compressed.MatcherStats()
+	if stats.Prefixes != len(ranges) {
+		t.Errorf("Prefixes = %d, 期望 %d", stats.Prefixes, len(ranges))
+	}
+	if stats.AllocatedNodes%4 != 0 || stats.AllocatedNodes < stats.Nodes || stats.Bytes <= 0 {
+		t.Errorf("节点池统计不正确: %+v", stats)
+	}
+
+	uncompressed, _ := NewIPACLWithOptions(ranges, types.Blacklist, MatcherOptions{CompressPaths: false})
+	if uncompressed.MatcherStats().Nodes <= stats.Nodes {
+		t.Errorf("未压缩节点数 %d 应大于压缩后的 %d", uncompressed.MatcherStats().Nodes, stats.Nodes)
+	}
+
+	tests := []struct {
+		ip   string
+		want types.Permission
+	}{
+		{"10.200.0.1", types.Denied},
+		{"192.168.1.1", types.Denied},
+		{"192.168.1.2", types.Allowed},
+		{"172.16.5.5", types.Denied},
+		{"2001:db8::1", types.Denied},
+		{"2001:db9::1", types.Allowed},
+	}
+	for _, acl := range []*IPACL{compressed, uncompressed} {
+		for _, tt := range tests {
+			if got, _ := acl.Check(tt.ip); got != tt.want {
+				t.Errorf("Check(%s) = %v, 期望 %v", tt.ip, got, tt.want)
+			}
+		}
+	}
+
+	// 修改选项后重建，结果保持不变
+	compressed.SetMatcherOptions(MatcherOptions{NodePoolSize: 2, CompressPaths: false})
+	if compressed.MatcherStats().AllocatedNodes%2 != 0 {
+		t.Errorf("SetMatcherOptions() 后节点池大小未生效: %+v", compressed.MatcherStats())
+	}
+	for _, tt := range tests {
+		if got, _ := compressed.Check(tt.ip); got != tt.want {
+			t.Errorf("SetMatcherOptions() 后 Check(%s) = %v, 期望 %v", tt.ip, got, tt.want)
+		}
+	}
+
+	// 移除后重建
+	if err := compressed.Remove("10.0.0.0/8"); err != nil {
+		t.Fatalf("Remove() 返回错误: %v", err)
+	}
+	if got, _ := compressed.Check("10.200.0.1"); got != types.Allowed {
+		t.Errorf("Remove() 后 Check(10.200.0.1) = %v, 期望 allowed", got)
+	}
+	if got, _ := compressed.Check("10.1.0.1"); got != types.Denied {
+		t.Errorf("Remove() 后 Check(10.1.0.1) = %v, 期望 denied", got)
+	}
+}
+
+// benchmarkCIDRs 生成n个互不相同、分散在整个地址空间中的IPv4 /24网络
+func benchmarkCIDRs(n int) []*net.IPNet {
+	nets := make([]*net.IPNet, n)
+	for i := range nets {
+		// 乘以奇数在2^24范围内是双射，保证网络互不相同
+		ip := make(net.IP, net.IPv4len)
+		binary.BigEndian.PutUint32(ip, (uint32(i)*0x9E3779B1&0xFFFFFF)<<8)
+		nets[i] = &net.IPNet{IP: ip, Mask: net.CIDRMask(24, 32)}
+	}
+	return nets
+}
+
+// BenchmarkTrieMemory1M 测量100万个CIDR在不同选项下的内存占用和构建耗时
+//
+// 运行: go test ./pkg/ip -run ^$ -bench TrieMemory1M -benchmem
+func BenchmarkTrieMemory1M(b *testing.B) {
+	nets := benchmarkCIDRs(1000000)
+	options := []MatcherOptions{
+		{NodePoolSize: 1024, CompressPaths: true},
+		{NodePoolSize: 65536, CompressPaths: true},
+		{NodePoolSize: 1024, CompressPaths: false},
+	}
+
+	for _, opts := range options {
+		b.Run(fmt.Sprintf("pool=%d/compress=%v", opts.NodePoolSize, opts.CompressPaths), func(b *testing.B) {
+			var stats MatcherStats
+			var heap uint64
+			for i := 0; i < b.N; i++ {
+				var before, after runtime.MemStats
+				runtime.GC()
+				runtime.ReadMemStats(&before)
+
+				trie := newIPTrie(opts)
+				for _, n := range nets {
+					trie.insertNet(n)
+				}
+
+				runtime.GC()
+				runtime.ReadMemStats(&after)
+				heap = after.HeapAlloc - before.HeapAlloc
+				stats = trie.stats()
+				runtime.KeepAlive(trie)
+			}
+			b.ReportMetric(float64(heap)/(1<<20), "heap-MB")
+			b.ReportMetric(float64(stats.Nodes), "nodes")
+			b.ReportMetric(float64(heap)/float64(len(nets)), "B/cidr")
+		})
+	}
+}
+
+// BenchmarkTrieLookup1M 测量100万个CIDR时的单次查找耗时
+func BenchmarkTrieLookup1M(b *testing.B) {
+	nets := benchmarkCIDRs(1000000)
+	trie := newIPTrie(DefaultMatcherOptions)
+	for _, n := range nets {
+		trie.insertNet(n)
+	}
+
+	ips := make([]net.IP, 1024)
+	r := rand.New(rand.NewSource(1))
+	for i := range ips {
+		ips[i] = make(net.IP, net.IPv4len)
+		binary.BigEndian.PutUint32(ips[i], r.Uint32())
+	}
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		trie.contains(ips[i%len(ips)])
+	}
+}
+
+// TestMemoryUsage 测试内存占用估算随规则数量增长
+func TestMemoryUsage(t *testing.T) {
+	empty, _ := NewIPACL(nil, types.Blacklist)
+	small, _ := NewIPACL([]string{"10.0.0.0/8"}, types.Blacklist)
+	large, _ := NewIPACL(func() []string {
+		var ranges []string
+		for i := 0; i < 100; i++ {
+			ranges = append(ranges, fmt.Sprintf("10.%d.0.0/16", i))
+		}
+		return ranges
+	}(), types.Blacklist)
+
+	if empty.MemoryUsage() <= 0 {
+		t.Errorf("空列表的 MemoryUsage() = %d, 期望大于0", empty.MemoryUsage())
+	}
+	if small.MemoryUsage() <= empty.MemoryUsage() {
+		t.Errorf("1条规则的占用 %d 应大于空列表的 %d", small.MemoryUsage(), empty.MemoryUsage())
+	}
+	if large.MemoryUsage() <= small.MemoryUsage() {
+		t.Errorf("100条规则的占用 %d 应大于1条规则的 %d", large.MemoryUsage(), small.MemoryUsage())
+	}
+	if large.MemoryUsage() < large.MatcherStats().Bytes {
+		t.Errorf("MemoryUsage() = %d, 不应小于匹配器占用 %d", large.MemoryUsage(), large.MatcherStats().Bytes)
+	}
+}
@@ -0,0 +1,290 @@
+// Package mail 提供SMTP过滤中检查发件主机的组件
+//
+// 邮件服务器收到连接时可以得到三类与发件方有关的信息: 连接的IP、HELO/EHLO命令中的域名，
+// 以及信封发件人（MAIL FROM）的域名。Checker用ACL管理器依次检查连接IP、HELO域名，
+// 并可选地解析发件域名的MX记录，检查每个MX主机及其地址，最终给出一个合并的结论，
+// 适合在milter、SMTP代理等过滤器中使用。
+//
+// 用法示例:
+//
+//	manager := acl.NewManager()
+//	manager.SetIPACLFromFile("./spam-ips.txt", types.Blacklist)
+//	manager.SetDomainACL([]string{"spam.example"}, types.Blacklist, true)
+//
+//	checker := &mail.Checker{Manager: manager, CheckMX: true}
+//	verdict, err := checker.Check(ctx, mail.Sender{IP: remoteIP, HELO: helo, MailFrom: from})
+//	if err != nil {
+//	    return "451 4.4.3 临时错误"
+//	}
+//	if !verdict.Allowed() {
+//	    return "550 5.7.1 " + verdict.Reason.String()
+//	}
+package mail
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"strings"
+
+	"github.com/cyberspacesec/go-acl/pkg/acl"
+	"github.com/cyberspacesec/go-acl/pkg/types"
+)
+
+// ErrDenied 表示发件主机被访问控制拒绝
+var ErrDenied = errors.New("发件主机被访问控制拒绝")
+
+// Resolver 查询MX记录并解析主机名，*net.Resolver实现了此接口
+type Resolver interface {
+	LookupMX(ctx context.Context, name string) ([]*net.MX, error)
+	LookupIPAddr(ctx context.Context, host string) ([]net.IPAddr, error)
+}
+
+// Sender 是SMTP会话中发件方的信息
+//
+// 字段说明:
+//   - IP: 连接的客户端IP
+//   - HELO: HELO/EHLO命令的参数，可以是域名或地址字面量（如"[192.0.2.1]"、"[IPv6:2001:db8::1]"）
+//   - MailFrom: 信封发件人，如"user@example.com"或"<user@example.com>"，空（如退信的"<>"）表示没有发件域名
+//
+// 为空的字段不检查。
+type Sender struct {
+	IP       string
+	HELO     string
+	MailFrom string
+}
+
+// MXResult 是一个MX主机的检查结果
+//
+// 字段说明:
+//   - Host: MX主机名，不含末尾的点
+//   - Pref: MX优先级
+//   - Result: 主机名的检查结果，主机名被允许且设置了ResolveMX时为其地址中第一个被拒绝的结果
+type MXResult struct {
+	Host   string
+	Pref   uint16
+	Result types.CheckResult
+}
+
+// Verdict 是发件主机的合并检查结果
+//
+// 字段说明:
+//   - Decision: 合并的结论，任一项被拒绝即为拒绝
+//   - Source: 做出拒绝决定的检查项，为"ip"、"helo"或"mx:主机名"，允许时为空
+//   - Reason: 拒绝原因，与做出拒绝决定的检查项的原因相同，允许时为空
+//   - IP: 连接IP的检查结果，没有检查时为零值
+//   - HELO: HELO域名的检查结果，没有检查时为零值
+//   - MX: 发件域名的MX主机的检查结果，按MX优先级排列
+type Verdict struct {
+	Decision types.Permission
+	Source   string
+	Reason   types.Reason
+	IP       types.CheckResult
+	HELO     types.CheckResult
+	MX       []MXResult
+}
+
+// Allowed 判断结论是否为允许
+func (v Verdict) Allowed() bool {
+	return v.Decision == types.Allowed
+}
+
+// Err 返回拒绝的错误
+//
+// 返回:
+//   - error: 允许时为nil，拒绝时为包装了ErrDenied的*types.ReasonError
+func (v Verdict) Err() error {
+	if v.Allowed() {
+		return nil
+	}
+	return &types.ReasonError{Reason: v.Reason, Err: fmt.Errorf("%w: %s", ErrDenied, v.Source)}
+}
+
+// Checker 检查SMTP发件主机
+//
+// 依次检查以下各项，任一项被拒绝即停止并返回拒绝:
+//  1. 连接IP: 用Manager.CheckIPDetailed检查
+//  2. HELO域名: 用Manager.CheckHostDetailed检查，地址字面量按IP检查
+//  3. MX（CheckMX为true时）: 查询发件域名（MailFrom的域名，为空时使用HELO域名）的MX记录，
+//     用Manager.CheckHostDetailed检查每个MX主机；ResolveMX为true时再解析每个MX主机，检查得到的每个IP
+//
+// 与guard相同，未设置的ACL（types.ErrNoACL）会被跳过；其他检查错误（如无效的HELO）视为拒绝，
+// 原因为对应的错误原因（如types.ReasonInvalidInput）。
+// 发件域名没有MX记录时不检查MX；RFC 7505的空MX（"."）被忽略。
+// DNS查询受Manager的检查时间预算限制（见acl.Manager.SetCheckBudget）。
+type Checker struct {
+	// Manager 是执行访问控制的ACL管理器
+	Manager *acl.Manager
+	// Resolver 用于查询MX记录和解析MX主机，为nil时使用net.DefaultResolver
+	Resolver Resolver
+	// CheckMX 为true时检查发件域名的MX主机
+	CheckMX bool
+	// ResolveMX 为true时解析每个MX主机并检查其地址，只在CheckMX为true时生效
+	ResolveMX bool
+}
+
+// Check 检查发件主机
+//
+// 参数:
+//   - ctx: 上下文，传递给ACL检查和DNS查询
+//   - s: 发件方的信息
+//
+// 返回:
+//   - Verdict: 合并的检查结果
+//   - error: DNS查询失败（域名不存在除外）时返回错误，调用方通常应回复临时错误（4xx）
+//
+// 示例:
+//
+//	verdict, err := checker.Check(ctx, mail.Sender{IP: "203.0.113.9", HELO: "mx.example.com", MailFrom: "a@example.com"})
+//	if err == nil && !verdict.Allowed() {
+//	    log.Printf("拒绝发件主机: %s (%s)", verdict.Source, verdict.Reason)
+//	}
+func (c *Checker) Check(ctx context.Context, s Sender) (Verdict, error) {
+	v := Verdict{Decision: types.Allowed}
+
+	if s.IP != "" {
+		v.IP = c.check(ctx, s.IP, c.Manager.CheckIPDetailed)
+		if v.deny("ip", v.IP) {
+			return v, nil
+		}
+	}
+
+	helo := heloHost(s.HELO)
+	if helo != "" {
+		v.HELO = c.check(ctx, helo, c.Manager.CheckHostDetailed)
+		if v.deny("helo", v.HELO) {
+			return v, nil
+		}
+	}
+
+	if !c.CheckMX {
+		return v, nil
+	}
+	name := senderDomain(s.MailFrom)
+	if name == "" && net.ParseIP(helo) == nil {
+		name = helo
+	}
+	if name == "" {
+		return v, nil
+	}
+	return c.checkMX(ctx, v, name)
+}
+
+// checkMX 查询并检查域名的MX主机
+func (c *Checker) checkMX(ctx context.Context, v Verdict, name string) (Verdict, error) {
+	resolver := c.resolver()
+	lookupCtx, cancel := c.Manager.BudgetContext(ctx)
+	defer cancel()
+
+	records, err := resolver.LookupMX(lookupCtx, name)
+	if err != nil {
+		var dnsErr *net.DNSError
+		if errors.As(err, &dnsErr) && dnsErr.IsNotFound {
+			return v, nil
+		}
+		return v, lookupError(ctx, lookupCtx, name, err)
+	}
+
+	for _, mx := range records {
+		host := strings.TrimSuffix(mx.Host, ".")
+		if host == "" {
+			continue
+		}
+		r := MXResult{Host: host, Pref: mx.Pref, Result: c.check(ctx, host, c.Manager.CheckHostDetailed)}
+		if r.Result.Decision == types.Allowed && c.ResolveMX {
+			if r.Result, err = c.checkAddrs(ctx, lookupCtx, host); err != nil {
+				return v, err
+			}
+		}
+		v.MX = append(v.MX, r)
+		if v.deny("mx:"+host, r.Result) {
+			return v, nil
+		}
+	}
+	return v, nil
+}
+
+// checkAddrs 解析MX主机并检查每个地址，返回第一个被拒绝的结果，全部允许时返回最后一个结果
+func (c *Checker) checkAddrs(ctx, lookupCtx context.Context, host string) (types.CheckResult, error) {
+	addrs, err := c.resolver().LookupIPAddr(lookupCtx, host)
+	if err != nil {
+		var dnsErr *net.DNSError
+		if errors.As(err, &dnsErr) && dnsErr.IsNotFound {
+			return types.CheckResult{Decision: types.Allowed}, nil
+		}
+		return types.CheckResult{}, lookupError(ctx, lookupCtx, host, err)
+	}
+
+	result := types.CheckResult{Decision: types.Allowed}
+	for _, addr := range addrs {
+		result = c.check(ctx, addr.IP.String(), c.Manager.CheckIPDetailed)
+		if result.Decision == types.Denied {
+			break
+		}
+	}
+	return result, nil
+}
+
+// check 执行一项ACL检查，未设置的ACL视为允许，其他错误视为拒绝
+func (c *Checker) check(ctx context.Context, target string, fn func(context.Context, string) (types.CheckResult, error)) types.CheckResult {
+	result, err := fn(ctx, target)
+	if err == nil {
+		return result
+	}
+	if errors.Is(err, types.ErrNoACL) {
+		return types.CheckResult{Decision: types.Allowed}
+	}
+	result.Decision = types.Denied
+	if result.Reason == "" {
+		result.Reason = types.ReasonCheckFailed
+	}
+	return result
+}
+
+// resolver 返回使用的解析器
+func (c *Checker) resolver() Resolver {
+	if c.Resolver == nil {
+		return net.DefaultResolver
+	}
+	return c.Resolver
+}
+
+// deny 在检查项被拒绝时记录拒绝的来源和原因，返回是否被拒绝
+func (v *Verdict) deny(source string, result types.CheckResult) bool {
+	if result.Decision != types.Denied {
+		return false
+	}
+	v.Decision, v.Source, v.Reason = types.Denied, source, result.Reason
+	return true
+}
+
+// lookupError 包装DNS查询错误，查询因检查预算超时时包装acl.ErrBudgetExceeded
+func lookupError(ctx, lookupCtx context.Context, name string, err error) error {
+	if ctx.Err() == nil && lookupCtx.Err() == context.DeadlineExceeded {
+		return fmt.Errorf("%w: 查询 %s: %v", acl.ErrBudgetExceeded, name, err)
+	}
+	return fmt.Errorf("查询 %s: %w", name, err)
+}
+
+// heloHost 返回HELO参数中的主机，地址字面量转换为IP
+func heloHost(helo string) string {
+	helo = strings.TrimSuffix(strings.TrimSpace(helo), ".")
+	if strings.HasPrefix(helo, "[") && strings.HasSuffix(helo, "]") {
+		helo = helo[1 : len(helo)-1]
+		if len(helo) > 5 && strings.EqualFold(helo[:5], "IPv6:") {
+			helo = helo[5:]
+		}
+	}
+	return helo
+}
+
+// senderDomain 返回信封发件人的域名，没有域名时返回空字符串
+func senderDomain(from string) string {
+	from = strings.TrimSuffix(strings.TrimPrefix(strings.TrimSpace(from), "<"), ">")
+	at := strings.LastIndexByte(from, '@')
+	if at < 0 {
+		return ""
+	}
+	return strings.TrimSuffix(from[at+1:], ".")
+}
@@ -0,0 +1,160 @@
+package mail
+
+import (
+	"context"
+	"errors"
+	"net"
+	"testing"
+
+	"github.com/cyberspacesec/go-acl/pkg/acl"
+	"github.com/cyberspacesec/go-acl/pkg/types"
+)
+
+// fakeResolver 是用于测试的解析器
+type fakeResolver struct {
+	mx    map[string][]*net.MX
+	addrs map[string][]string
+}
+
+func (r fakeResolver) LookupMX(ctx context.Context, name string) ([]*net.MX, error) {
+	if name == "servfail.example" {
+		return nil, &net.DNSError{Err: "server misbehaving", Name: name, IsTemporary: true}
+	}
+	records, ok := r.mx[name]
+	if !ok {
+		return nil, &net.DNSError{Err: "no such host", Name: name, IsNotFound: true}
+	}
+	return records, nil
+}
+
+func (r fakeResolver) LookupIPAddr(ctx context.Context, host string) ([]net.IPAddr, error) {
+	ips, ok := r.addrs[host]
+	if !ok {
+		return nil, &net.DNSError{Err: "no such host", Name: host, IsNotFound: true}
+	}
+	addrs := make([]net.IPAddr, len(ips))
+	for i, s := range ips {
+		addrs[i] = net.IPAddr{IP: net.ParseIP(s)}
+	}
+	return addrs, nil
+}
+
+func newTestChecker(t *testing.T) *Checker {
+	t.Helper()
+	manager := acl.NewManager()
+	if err := manager.SetIPACL([]string{"203.0.113.0/24"}, types.Blacklist); err != nil {
+		t.Fatalf("SetIPACL() 返回错误: %v", err)
+	}
+	manager.SetDomainACL([]string{"spam.example", "bad-mx.example"}, types.Blacklist, true)
+
+	resolver := fakeResolver{
+		mx: map[string][]*net.MX{
+			"good.example":   {{Host: "mx1.good.example.", Pref: 10}, {Host: "mx2.good.example.", Pref: 20}},
+			"shady.example":  {{Host: "mx.bad-mx.example.", Pref: 10}},
+			"hidden.example": {{Host: "mx.hidden.example.", Pref: 10}},
+			"nullmx.example": {{Host: ".", Pref: 0}},
+		},
+		addrs: map[string][]string{
+			"mx1.good.example":  {"198.51.100.1"},
+			"mx2.good.example":  {"198.51.100.2"},
+			"mx.hidden.example": {"198.51.100.3", "203.0.113.7"},
+			"mx.bad-mx.example": {"198.51.100.4"},
+		},
+	}
+	return &Checker{Manager: manager, Resolver: resolver, CheckMX: true, ResolveMX: true}
+}
+
+// TestCheck 测试发件主机的合并检查结果
+func TestCheck(t *testing.T) {
+	checker := newTestChecker(t)
+
+	tests := []struct {
+		name       string
+		sender     Sender
+		wantAllow  bool
+		wantSource string
+		wantReason types.Reason
+		wantMX     int
+	}{
+		{"全部允许", Sender{IP: "192.0.2.1", HELO: "mail.good.example", MailFrom: "<a@good.example>"}, true, "", "", 2},
+		{"连接IP被拒绝", Sender{IP: "203.0.113.5", HELO: "mail.good.example", MailFrom: "a@good.example"}, false, "ip", types.ReasonMatchedBlacklistIP, 0},
+		{"HELO域名被拒绝", Sender{IP: "192.0.2.1", HELO: "relay.spam.example", MailFrom: "a@good.example"}, false, "helo", types.ReasonMatchedBlacklistDomain, 0},
+		{"HELO地址字面量被拒绝", Sender{HELO: "[203.0.113.9]"}, false, "helo", types.ReasonMatchedBlacklistIP, 0},
+		{"HELO的IPv6地址字面量", Sender{HELO: "[IPv6:2001:db8::1]"}, true, "", "", 0},
+		{"MX主机被拒绝", Sender{IP: "192.0.2.1", HELO: "mail.shady.example", MailFrom: "a@shady.example"}, false, "mx:mx.bad-mx.example", types.ReasonMatchedBlacklistDomain, 1},
+		{"MX主机的地址被拒绝", Sender{IP: "192.0.2.1", MailFrom: "a@hidden.example"}, false, "mx:mx.hidden.example", types.ReasonMatchedBlacklistIP, 1},
+		{"没有发件人时使用HELO域名的MX", Sender{HELO: "shady.example", MailFrom: "<>"}, false, "mx:mx.bad-mx.example", types.ReasonMatchedBlacklistDomain, 1},
+		{"没有MX记录", Sender{IP: "192.0.2.1", MailFrom: "a@nomx.example"}, true, "", "", 0},
+		{"空MX被忽略", Sender{IP: "192.0.2.1", MailFrom: "a@nullmx.example"}, true, "", "", 0},
+		{"无效的连接IP", Sender{IP: "not-an-ip"}, false, "ip", types.ReasonInvalidInput, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			v, err := checker.Check(context.Background(), tt.sender)
+			if err != nil {
+				t.Fatalf("Check() 返回错误: %v", err)
+			}
+			if v.Allowed() != tt.wantAllow || v.Source != tt.wantSource || v.Reason != tt.wantReason {
+				t.Errorf("Check() = 允许:%v 来源:%q 原因:%q, 期望 允许:%v 来源:%q 原因:%q",
+					v.Allowed(), v.Source, v.Reason, tt.wantAllow, tt.wantSource, tt.wantReason)
+			}
+			if len(v.MX) != tt.wantMX {
+				t.Errorf("MX结果数量 = %d, 期望 %d", len(v.MX), tt.wantMX)
+			}
+
+			err = v.Err()
+			if tt.wantAllow != (err == nil) {
+				t.Fatalf("Err() = %v", err)
+			}
+			if err != nil && (!errors.Is(err, ErrDenied) || types.ReasonOf(err) != tt.wantReason) {
+				t.Errorf("Err() = %v (原因 %q), 期望包装ErrDenied且原因为 %q", err, types.ReasonOf(err), tt.wantReason)
+			}
+		})
+	}
+}
+
+// TestCheckOptions 测试MX检查的选项、未设置的ACL和DNS错误
+func TestCheckOptions(t *testing.T) {
+	checker := newTestChecker(t)
+
+	checker.ResolveMX = false
+	if v, _ := checker.Check(context.Background(), Sender{MailFrom: "a@hidden.example"}); !v.Allowed() {
+		t.Errorf("ResolveMX为false时 Check() = %+v, 期望允许", v)
+	}
+
+	checker.CheckMX = false
+	if v, _ := checker.Check(context.Background(), Sender{MailFrom: "a@shady.example"}); !v.Allowed() || len(v.MX) != 0 {
+		t.Errorf("CheckMX为false时 Check() = %+v, 期望允许且不检查MX", v)
+	}
+
+	checker.CheckMX = true
+	if _, err := checker.Check(context.Background(), Sender{MailFrom: "a@servfail.example"}); err == nil {
+		t.Error("MX查询失败时 Check() 没有返回错误")
+	}
+
+	empty := &Checker{Manager: acl.NewManager(), Resolver: checker.Resolver}
+	if v, err := empty.Check(context.Background(), Sender{IP: "203.0.113.5", HELO: "spam.example"}); err != nil || !v.Allowed() {
+		t.Errorf("未设置ACL时 Check() = %+v, %v, 期望允许", v, err)
+	}
+}
+
+// TestSenderDomain 测试信封发件人和HELO参数的解析
+func TestSenderDomain(t *testing.T) {
+	tests := []struct {
+		input, want string
+		fn          func(string) string
+	}{
+		{"<user@Example.com>", "Example.com", senderDomain},
+		{"user@example.com.", "example.com", senderDomain},
+		{"<>", "", senderDomain},
+		{"postmaster", "", senderDomain},
+		{"[192.0.2.1]", "192.0.2.1", heloHost},
+		{"[IPv6:2001:db8::1]", "2001:db8::1", heloHost},
+		{" mail.example.com. ", "mail.example.com", heloHost},
+	}
+	for _, tt := range tests {
+		if got := tt.fn(tt.input); got != tt.want {
+			t.Errorf("解析 %q = %q, 期望 %q", tt.input, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,182 @@
+// Package middleware 提供由ACL管理器控制的net/http中间件
+//
+// Middleware从请求中取得客户端IP和Host，按acl.InboundChecker的入站惯例检查，
+// 被拒绝的请求返回403，允许的请求交给下一个处理器，应用不必再为每个服务手写同样的胶水代码。
+//
+// 用法示例:
+//
+//	manager := acl.NewManager()
+//	manager.SetIPACLWithDefaults([]string{"203.0.113.0/24"}, types.Blacklist, nil, false)
+//	manager.SetDomainACL([]string{"api.example.com"}, types.Whitelist, false)
+//
+//	trusted, _ := ip.NewIPACL([]string{"10.0.0.0/8"}, types.Whitelist)
+//	handler := middleware.Middleware(manager, middleware.WithTrustedProxies(trusted))(mux)
+//	log.Fatal(http.ListenAndServe(":8080", handler))
+package middleware
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strings"
+
+	"github.com/cyberspacesec/go-acl/pkg/acl"
+	"github.com/cyberspacesec/go-acl/pkg/ip"
+	"github.com/cyberspacesec/go-acl/pkg/realip"
+	"github.com/cyberspacesec/go-acl/pkg/types"
+)
+
+// HeaderXRealIP 是单个代理写入客户端地址的头部
+const HeaderXRealIP = "X-Real-IP"
+
+// DenyHandler 处理被拒绝的请求，err为检查的错误，单纯被拒绝时为nil
+type DenyHandler func(w http.ResponseWriter, r *http.Request, result types.CheckResult, err error)
+
+// options 是Middleware的配置，通过Option设置
+type options struct {
+	trusted         *ip.IPACL
+	clientIP        func(r *http.Request) string
+	deny            DenyHandler
+	consistentHosts bool
+}
+
+// Option 修改Middleware的一项配置
+type Option func(*options)
+
+// WithTrustedProxies 设置可信代理，来自这些地址的请求才使用X-Forwarded-For和X-Real-IP
+//
+// 参数:
+//   - trusted: 可信代理的地址，列表中的地址就是可信代理（与列表类型无关），nil表示不信任任何代理
+//
+// 请求带有X-Forwarded-For时按realip.ParseForwardedChain从右向左跳过可信代理确定客户端，
+// 转发链无效时客户端IP为空，请求被拒绝；没有X-Forwarded-For且直接连接的对端是可信代理时使用X-Real-IP。
+func WithTrustedProxies(trusted *ip.IPACL) Option {
+	return func(o *options) { o.trusted = trusted }
+}
+
+// WithClientIP 设置取得客户端IP的函数，设置后WithTrustedProxies不再生效
+//
+// 返回的地址可以带端口，为空或无效时请求被拒绝，检查的错误为acl.ErrMissingClientIP。
+func WithClientIP(clientIP func(r *http.Request) string) Option {
+	return func(o *options) { o.clientIP = clientIP }
+}
+
+// WithDenyHandler 设置处理被拒绝请求的函数，默认返回403和"Forbidden"
+//
+// 可用于记录日志、返回自定义页面，或在响应头中返回拒绝原因（result.Reason）。
+func WithDenyHandler(deny DenyHandler) Option {
+	return func(o *options) { o.deny = deny }
+}
+
+// WithConsistentHosts 在访问控制之前拒绝URL主机、Host头与TLS SNI不一致的请求，见acl.CheckHTTPHosts
+//
+// 被拒绝的请求交给DenyHandler，结果的原因为types.ReasonHostMismatch，错误是包装了acl.ErrHostMismatch的
+// *types.ReasonError；默认的DenyHandler对这类请求返回421 Misdirected Request。
+func WithConsistentHosts() Option {
+	return func(o *options) { o.consistentHosts = true }
+}
+
+// Middleware 返回检查每个请求的客户端IP和Host的中间件
+//
+// 参数:
+//   - m: 执行访问控制的ACL管理器
+//   - opts: 取得客户端IP的方式、被拒绝时的处理等配置
+//
+// 返回:
+//   - func(http.Handler) http.Handler: 包装下一个处理器的中间件
+//
+// 检查由acl.InboundChecker完成：客户端IP按IP ACL检查，Host去除端口后按域名ACL检查，
+// 两者连同规则表达式一次求值。默认客户端IP只取自r.RemoteAddr，X-Forwarded-For和X-Real-IP
+// 可以由客户端任意填写，只有设置WithTrustedProxies后才会使用。
+//
+// 检查出错时按拒绝处理（types.ErrNoACL除外，即请求涉及的ACL均未设置时放行，与gateway一致）。
+// 允许的请求的检查结果可以在后续处理器中通过ResultFromContext取得。
+//
+// 示例:
+//
+//	handler := middleware.Middleware(manager,
+//	    middleware.WithDenyHandler(func(w http.ResponseWriter, r *http.Request, result types.CheckResult, err error) {
+//	        log.Printf("拒绝 %s: %s", r.RemoteAddr, result.Reason)
+//	        http.Error(w, "Forbidden", http.StatusForbidden)
+//	    }),
+//	)(mux)
+func Middleware(m *acl.Manager, opts ...Option) func(http.Handler) http.Handler {
+	var o options
+	for _, opt := range opts {
+		if opt != nil {
+			opt(&o)
+		}
+	}
+	if o.deny == nil {
+		o.deny = forbidden
+	}
+	checker := acl.InboundChecker{Manager: m, ClientIP: o.clientIP}
+	if checker.ClientIP == nil {
+		checker.ClientIP = o.trustedClientIP
+	}
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if o.consistentHosts {
+				if err := acl.CheckHTTPHosts(r); err != nil {
+					o.deny(w, r, types.CheckResult{Target: r.Host, Kind: "request", Decision: types.Denied, Reason: types.ReasonOf(err)}, err)
+					return
+				}
+			}
+			result, err := checker.CheckHTTP(r)
+			if err != nil && !errors.Is(err, types.ErrNoACL) {
+				o.deny(w, r, result, err)
+				return
+			}
+			if err == nil && result.Decision == types.Denied {
+				o.deny(w, r, result, nil)
+				return
+			}
+			next.ServeHTTP(w, r.WithContext(context.WithValue(r.Context(), resultKey{}, result)))
+		})
+	}
+}
+
+// resultKey 是检查结果在请求上下文中的键
+type resultKey struct{}
+
+// ResultFromContext 返回Middleware对当前请求的检查结果
+//
+// 返回:
+//   - types.CheckResult: 检查结果
+//   - bool: 请求没有经过Middleware时返回false
+func ResultFromContext(ctx context.Context) (types.CheckResult, bool) {
+	result, ok := ctx.Value(resultKey{}).(types.CheckResult)
+	return result, ok
+}
+
+// trustedClientIP 按可信代理的配置取得客户端IP，未设置可信代理时返回r.RemoteAddr
+func (o *options) trustedClientIP(r *http.Request) string {
+	if o.trusted == nil {
+		return r.RemoteAddr
+	}
+	chain, err := realip.ParseForwardedChain(r, realip.Config{TrustedProxies: o.trusted})
+	if err != nil {
+		return ""
+	}
+	hop, ok := chain.ClientHop()
+	if !ok {
+		return ""
+	}
+	// 只有一跳说明没有X-Forwarded-For，可信代理可能改用X-Real-IP传递客户端地址
+	if len(chain.Hops) == 1 && hop.Trusted {
+		if real := strings.TrimSpace(r.Header.Get(HeaderXRealIP)); real != "" {
+			return real
+		}
+	}
+	return hop.IP.String()
+}
+
+// forbidden 是默认的DenyHandler，返回403，主机不一致时返回421
+func forbidden(w http.ResponseWriter, _ *http.Request, _ types.CheckResult, err error) {
+	if errors.Is(err, acl.ErrHostMismatch) {
+		http.Error(w, http.StatusText(http.StatusMisdirectedRequest), http.StatusMisdirectedRequest)
+		return
+	}
+	http.Error(w, http.StatusText(http.StatusForbidden), http.StatusForbidden)
+}
@@ -0,0 +1,159 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/cyberspacesec/go-acl/pkg/acl"
+	"github.com/cyberspacesec/go-acl/pkg/ip"
+	"github.com/cyberspacesec/go-acl/pkg/types"
+)
+
+func newManager(t *testing.T) *acl.Manager {
+	t.Helper()
+	manager := acl.NewManager()
+	if err := manager.SetIPACL([]string{"203.0.113.0/24"}, types.Blacklist); err != nil {
+		t.Fatalf("SetIPACL() 返回错误: %v", err)
+	}
+	manager.SetDomainACL([]string{"blocked.example.com"}, types.Blacklist, true)
+	return manager
+}
+
+// okHandler 返回200，并在响应体中写入检查结果的目标
+var okHandler = http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+	result, ok := ResultFromContext(r.Context())
+	if !ok {
+		http.Error(w, "缺少检查结果", http.StatusInternalServerError)
+		return
+	}
+	w.Write([]byte(result.Target))
+})
+
+// TestMiddleware 测试按客户端IP和Host放行或拒绝请求
+func TestMiddleware(t *testing.T) {
+	trusted, err := ip.NewIPACL([]string{"10.0.0.0/8"}, types.Whitelist)
+	if err != nil {
+		t.Fatal(err)
+	}
+	handler := Middleware(newManager(t))(okHandler)
+	proxied := Middleware(newManager(t), WithTrustedProxies(trusted))(okHandler)
+
+	tests := []struct {
+		name    string
+		handler http.Handler
+		remote  string
+		host    string
+		headers map[string]string
+		want    int
+	}{
+		{"允许", handler, "198.51.100.7:5000", "api.example.com", nil, http.StatusOK},
+		{"客户端IP被拒绝", handler, "203.0.113.9:5000", "api.example.com", nil, http.StatusForbidden},
+		{"Host被拒绝", handler, "198.51.100.7:5000", "www.blocked.example.com:8080", nil, http.StatusForbidden},
+		{"无效的RemoteAddr", handler, "pipe", "api.example.com", nil, http.StatusForbidden},
+		{"未设置可信代理时忽略转发头部", handler, "198.51.100.7:5000", "api.example.com",
+			map[string]string{"X-Forwarded-For": "203.0.113.9", HeaderXRealIP: "203.0.113.9"}, http.StatusOK},
+		{"可信代理转发的客户端被拒绝", proxied, "10.0.0.2:5000", "api.example.com",
+			map[string]string{"X-Forwarded-For": "203.0.113.9"}, http.StatusForbidden},
+		{"客户端伪造的左侧部分被忽略", proxied, "10.0.0.2:5000", "api.example.com",
+			map[string]string{"X-Forwarded-For": "203.0.113.9, 198.51.100.7"}, http.StatusOK},
+		{"不可信的对端不能伪造X-Forwarded-For", proxied, "203.0.113.9:5000", "api.example.com",
+			map[string]string{"X-Forwarded-For": "198.51.100.7"}, http.StatusForbidden},
+		{"可信代理的X-Real-IP", proxied, "10.0.0.2:5000", "api.example.com",
+			map[string]string{HeaderXRealIP: "203.0.113.9"}, http.StatusForbidden},
+		{"不可信的对端不能伪造X-Real-IP", proxied, "203.0.113.9:5000", "api.example.com",
+			map[string]string{HeaderXRealIP: "198.51.100.7"}, http.StatusForbidden},
+		{"无效的转发链", proxied, "10.0.0.2:5000", "api.example.com",
+			map[string]string{"X-Forwarded-For": "unknown"}, http.StatusForbidden},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := httptest.NewRequest(http.MethodGet, "/", nil)
+			r.RemoteAddr, r.Host = tt.remote, tt.host
+			for k, v := range tt.headers {
+				r.Header.Set(k, v)
+			}
+			w := httptest.NewRecorder()
+			tt.handler.ServeHTTP(w, r)
+			if w.Code != tt.want {
+				t.Errorf("状态码 = %d, 期望 %d: %s", w.Code, tt.want, w.Body.String())
+			}
+		})
+	}
+}
+
+// TestMiddlewareOptions 测试自定义客户端IP、拒绝处理和未设置ACL时放行
+func TestMiddlewareOptions(t *testing.T) {
+	var denied types.CheckResult
+	handler := Middleware(newManager(t),
+		WithClientIP(func(r *http.Request) string { return r.Header.Get("X-Client") }),
+		WithDenyHandler(func(w http.ResponseWriter, r *http.Request, result types.CheckResult, err error) {
+			denied = result
+			w.Header().Set("X-ACL-Reason", string(result.Reason))
+			w.WriteHeader(http.StatusUnavailableForLegalReasons)
+		}),
+	)(okHandler)
+
+	r := httptest.NewRequest(http.MethodGet, "/", nil)
+	r.Header.Set("X-Client", "203.0.113.9")
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, r)
+	if w.Code != http.StatusUnavailableForLegalReasons || w.Header().Get("X-ACL-Reason") != string(types.ReasonMatchedBlacklistIP) {
+		t.Errorf("响应 = %d %v, 期望自定义的拒绝处理", w.Code, w.Header())
+	}
+	if denied.Decision != types.Denied {
+		t.Errorf("拒绝处理收到的结果 = %+v", denied)
+	}
+
+	r = httptest.NewRequest(http.MethodGet, "/", nil)
+	r.Header.Set("X-Client", "198.51.100.7")
+	w = httptest.NewRecorder()
+	handler.ServeHTTP(w, r)
+	if w.Code != http.StatusOK || w.Body.String() == "" {
+		t.Errorf("响应 = %d %q, 期望放行并在上下文中携带检查结果", w.Code, w.Body.String())
+	}
+
+	w = httptest.NewRecorder()
+	Middleware(acl.NewManager())(okHandler).ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/", nil))
+	if w.Code != http.StatusOK {
+		t.Errorf("未设置ACL时状态码 = %d, 期望放行", w.Code)
+	}
+}
+
+// TestMiddlewareConsistentHosts 测试启用主机一致性检查后拒绝URL主机与Host头不一致的请求
+func TestMiddlewareConsistentHosts(t *testing.T) {
+	var denied types.CheckResult
+	var deniedErr error
+	custom := Middleware(newManager(t), WithConsistentHosts(),
+		WithDenyHandler(func(w http.ResponseWriter, r *http.Request, result types.CheckResult, err error) {
+			denied, deniedErr = result, err
+			w.WriteHeader(http.StatusForbidden)
+		}),
+	)(okHandler)
+	tests := []struct {
+		name    string
+		handler http.Handler
+		target  string
+		host    string
+		want    int
+	}{
+		{"默认不检查", Middleware(newManager(t))(okHandler), "http://api.example.com/", "internal.example.com", http.StatusOK},
+		{"主机一致", Middleware(newManager(t), WithConsistentHosts())(okHandler), "http://api.example.com/", "API.example.com.", http.StatusOK},
+		{"主机不一致", Middleware(newManager(t), WithConsistentHosts())(okHandler), "http://api.example.com/", "internal.example.com", http.StatusMisdirectedRequest},
+		{"自定义拒绝处理", custom, "http://api.example.com/", "internal.example.com", http.StatusForbidden},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := httptest.NewRequest(http.MethodGet, tt.target, nil)
+			r.RemoteAddr, r.Host = "198.51.100.7:5000", tt.host
+			w := httptest.NewRecorder()
+			tt.handler.ServeHTTP(w, r)
+			if w.Code != tt.want {
+				t.Errorf("状态码 = %d, 期望 %d: %s", w.Code, tt.want, w.Body.String())
+			}
+		})
+	}
+	if denied.Reason != types.ReasonHostMismatch || types.ReasonOf(deniedErr) != types.ReasonHostMismatch {
+		t.Errorf("拒绝处理收到 %+v, %v, 期望原因为 %s", denied, deniedErr, types.ReasonHostMismatch)
+	}
+}
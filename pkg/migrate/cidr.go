@@ -0,0 +1,75 @@
+package migrate
+
+import (
+	"fmt"
+	"net"
+	"strings"
+
+	"github.com/cyberspacesec/go-acl/pkg/acl"
+	"github.com/cyberspacesec/go-acl/pkg/ip"
+	"github.com/cyberspacesec/go-acl/pkg/types"
+)
+
+// FromCIDRs 将CIDR列表转换为策略
+//
+// 参数:
+//   - cidrs: IP或CIDR列表；元素可以是逗号分隔的多个地址，
+//     与从环境变量（如ALLOWED_CIDRS="10.0.0.0/8, 192.168.0.0/16"）读取的写法相同
+//   - listType: 列表类型，中间件的允许列表对应types.Whitelist，拒绝列表对应types.Blacklist
+//
+// 返回:
+//   - acl.Policy: 只包含IP ACL的策略
+//   - error: 包含无效的IP或CIDR时返回包装了ip.ErrInvalidIP的错误
+//
+// echo、gin等框架没有内置的IP过滤中间件，常见的自定义中间件在配置中保存一个CIDR列表，
+// 对请求IP逐个调用Contains。这类配置可以直接转换。
+//
+// 示例:
+//
+//	policy, err := migrate.FromCIDRs(strings.Split(os.Getenv("ALLOWED_CIDRS"), ","), types.Whitelist)
+//	manager, err := acl.NewManagerFromPolicy(policy)
+func FromCIDRs(cidrs []string, listType types.ListType) (acl.Policy, error) {
+	var ranges []string
+	for _, item := range cidrs {
+		for _, s := range strings.Split(item, ",") {
+			if strings.TrimSpace(s) == "" {
+				continue
+			}
+			r, err := normalizeRange(s)
+			if err != nil {
+				return acl.Policy{}, err
+			}
+			ranges = append(ranges, r)
+		}
+	}
+	return acl.Policy{IP: ipPolicy(listType, ranges)}, nil
+}
+
+// FromIPNets 将已解析的网段列表转换为策略
+//
+// 参数:
+//   - nets: 网段列表，如echo.TrustIPRange或自定义中间件中使用的[]*net.IPNet，nil元素被忽略
+//   - listType: 列表类型
+//
+// 返回:
+//   - acl.Policy: 只包含IP ACL的策略
+//   - error: 网段的掩码无效时返回包装了ip.ErrInvalidCIDR的错误
+//
+// 示例:
+//
+//	// 原中间件: allowed := []*net.IPNet{...}; for _, n := range allowed { if n.Contains(ip) {...} }
+//	policy, err := migrate.FromIPNets(allowed, types.Whitelist)
+func FromIPNets(nets []*net.IPNet, listType types.ListType) (acl.Policy, error) {
+	ranges := make([]string, 0, len(nets))
+	for _, n := range nets {
+		if n == nil {
+			continue
+		}
+		if _, bits := n.Mask.Size(); bits == 0 {
+			// 掩码为空或不是连续的前缀
+			return acl.Policy{}, fmt.Errorf("%w: 网段 %v 的掩码 %v 无效", ip.ErrInvalidCIDR, n.IP, n.Mask)
+		}
+		ranges = append(ranges, n.String())
+	}
+	return acl.Policy{IP: ipPolicy(listType, ranges)}, nil
+}
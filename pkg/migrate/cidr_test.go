@@ -0,0 +1,56 @@
+package migrate
+
+import (
+	"errors"
+	"net"
+	"reflect"
+	"testing"
+
+	"github.com/cyberspacesec/go-acl/pkg/ip"
+	"github.com/cyberspacesec/go-acl/pkg/types"
+)
+
+// TestFromCIDRs 测试CIDR列表的转换
+func TestFromCIDRs(t *testing.T) {
+	tests := []struct {
+		name    string
+		cidrs   []string
+		want    []string
+		wantErr error
+	}{
+		{"逐个列出", []string{"10.0.0.0/8", "2001:db8::1"}, []string{"10.0.0.0/8", "2001:db8::1"}, nil},
+		{"逗号分隔", []string{"10.0.0.0/8, 192.168.0.0/16,", ""}, []string{"10.0.0.0/8", "192.168.0.0/16"}, nil},
+		{"无效的CIDR", []string{"10.0.0.0/33"}, nil, ip.ErrInvalidIP},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			policy, err := FromCIDRs(tt.cidrs, types.Whitelist)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("FromCIDRs() 错误 = %v, 期望 %v", err, tt.wantErr)
+			}
+			if err == nil && (policy.IP.Type != "whitelist" || !reflect.DeepEqual(policy.IP.Ranges, tt.want) || len(policy.Rules) != 0) {
+				t.Errorf("FromCIDRs() = %+v, 期望 whitelist %v", policy.IP, tt.want)
+			}
+		})
+	}
+}
+
+// TestFromIPNets 测试已解析网段列表的转换
+func TestFromIPNets(t *testing.T) {
+	_, v4, _ := net.ParseCIDR("10.0.0.0/8")
+	_, v6, _ := net.ParseCIDR("2001:db8::/32")
+
+	policy, err := FromIPNets([]*net.IPNet{v4, nil, v6}, types.Blacklist)
+	if err != nil {
+		t.Fatalf("FromIPNets() 返回错误: %v", err)
+	}
+	if want := []string{"10.0.0.0/8", "2001:db8::/32"}; policy.IP.Type != "blacklist" || !reflect.DeepEqual(policy.IP.Ranges, want) {
+		t.Errorf("FromIPNets() = %+v, 期望 blacklist %v", policy.IP, want)
+	}
+	checkPolicy(t, policy, map[string]types.Permission{"10.1.2.3": types.Denied, "192.0.2.1": types.Allowed})
+
+	invalid := &net.IPNet{IP: net.ParseIP("10.0.0.0"), Mask: net.IPMask{255, 0, 255, 0}}
+	if _, err := FromIPNets([]*net.IPNet{invalid}, types.Blacklist); !errors.Is(err, ip.ErrInvalidCIDR) {
+		t.Errorf("FromIPNets() 错误 = %v, 期望 %v", err, ip.ErrInvalidCIDR)
+	}
+}
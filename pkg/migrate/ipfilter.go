@@ -0,0 +1,156 @@
+package migrate
+
+import (
+	"fmt"
+	"net"
+
+	"github.com/cyberspacesec/go-acl/pkg/acl"
+	"github.com/cyberspacesec/go-acl/pkg/types"
+)
+
+// IPFilterOptions 对应github.com/jpillora/ipfilter的Options中与访问控制有关的字段
+//
+// 字段说明:
+//   - AllowedIPs: 允许的IP或CIDR
+//   - BlockedIPs: 拒绝的IP或CIDR
+//   - AllowedCountries: 允许的国家代码，go-acl不内置IP地理数据库，非空时无法转换
+//   - BlockedCountries: 拒绝的国家代码，同上
+//   - BlockByDefault: 未命中任何列表时是否拒绝
+//
+// ipfilter的TrustProxy（信任X-Forwarded-For）不属于访问控制策略，
+// 在go-acl中对应realip.ParseForwardedChain，需要配置可信代理列表。
+type IPFilterOptions struct {
+	AllowedIPs       []string
+	BlockedIPs       []string
+	AllowedCountries []string
+	BlockedCountries []string
+	BlockByDefault   bool
+}
+
+// FromIPFilter 将ipfilter的配置转换为策略
+//
+// 参数:
+//   - opts: ipfilter的配置，可以从ipfilter.Options逐字段复制
+//
+// 返回:
+//   - acl.Policy: BlockByDefault时为允许AllowedIPs的白名单，否则为拒绝BlockedIPs的黑名单
+//   - error: 包含无效的IP或CIDR时返回包装了ip.ErrInvalidIP的错误；
+//     设置了国家代码时返回包装了ErrUnsupported的错误
+//
+// ipfilter的优先级为: 单个IP优先于网段，同为网段时允许优先于拒绝。
+// 与默认行为相反的列表只在与另一列表重叠时才有作用，例如黑名单中允许的单个IP落在拒绝的网段内；
+// 这些重叠的条目按上述优先级转换为条件规则，不重叠的条目与默认行为相同，被省略。
+//
+// 国家代码可以改用geofeed实现，见acl.Manager.ImportGeofeed。
+//
+// 示例:
+//
+//	policy, err := migrate.FromIPFilter(migrate.IPFilterOptions{
+//	    AllowedIPs:     []string{"10.0.0.0/8"},
+//	    BlockedIPs:     []string{"10.0.0.13"},
+//	    BlockByDefault: true,
+//	})
+//	// policy.IP: whitelist 10.0.0.0/8
+//	// policy.Rules: ["ip in \"10.0.0.13\" -> deny"]
+func FromIPFilter(opts IPFilterOptions) (acl.Policy, error) {
+	if len(opts.AllowedCountries) > 0 || len(opts.BlockedCountries) > 0 {
+		return acl.Policy{}, fmt.Errorf("%w: ipfilter的国家代码需要IP地理数据库，请改用geofeed导入", ErrUnsupported)
+	}
+	allowed, err := normalizeRanges(opts.AllowedIPs)
+	if err != nil {
+		return acl.Policy{}, err
+	}
+	blocked, err := normalizeRanges(opts.BlockedIPs)
+	if err != nil {
+		return acl.Policy{}, err
+	}
+
+	var policy acl.Policy
+	addRule := func(entries []string, action string) {
+		if len(entries) > 0 {
+			policy.Rules = append(policy.Rules, ipRule(entries, action))
+		}
+	}
+
+	if opts.BlockByDefault {
+		// 单个IP优先于网段：落在允许网段内的拒绝IP需要先于白名单求值
+		policy.IP = ipPolicy(types.Whitelist, allowed)
+		addRule(overlapping(singleIPs(blocked), subnets(allowed)), "deny")
+		return policy, nil
+	}
+
+	// 单个允许IP优先于一切网段；单个拒绝IP优先于允许网段；允许网段优先于拒绝网段
+	policy.IP = ipPolicy(types.Blacklist, blocked)
+	addRule(overlapping(singleIPs(allowed), blocked), "allow")
+	allowedNets := overlapping(subnets(allowed), blocked)
+	addRule(overlapping(singleIPs(blocked), allowedNets), "deny")
+	addRule(allowedNets, "allow")
+	return policy, nil
+}
+
+// normalizeRanges 校验并去除每个IP或CIDR两端的空白
+func normalizeRanges(entries []string) ([]string, error) {
+	ranges := make([]string, 0, len(entries))
+	for _, e := range entries {
+		r, err := normalizeRange(e)
+		if err != nil {
+			return nil, err
+		}
+		ranges = append(ranges, r)
+	}
+	return ranges, nil
+}
+
+// singleIPs 返回entries中的单个IP
+func singleIPs(entries []string) []string {
+	var ips []string
+	for _, e := range entries {
+		if isSingleIP(e) {
+			ips = append(ips, e)
+		}
+	}
+	return ips
+}
+
+// subnets 返回entries中的网段
+func subnets(entries []string) []string {
+	var nets []string
+	for _, e := range entries {
+		if !isSingleIP(e) {
+			nets = append(nets, e)
+		}
+	}
+	return nets
+}
+
+// overlapping 返回entries中与others的任一条目有交集的条目
+func overlapping(entries, others []string) []string {
+	var result []string
+	for _, e := range entries {
+		for _, o := range others {
+			if overlaps(e, o) {
+				result = append(result, e)
+				break
+			}
+		}
+	}
+	return result
+}
+
+// overlaps 判断两个IP或CIDR是否有交集
+func overlaps(a, b string) bool {
+	na, nb := toIPNet(a), toIPNet(b)
+	return na.Contains(nb.IP) || nb.Contains(na.IP)
+}
+
+// toIPNet 将已校验的IP或CIDR转换为网段，单个IP为全长掩码的网段
+func toIPNet(s string) *net.IPNet {
+	if addr := net.ParseIP(s); addr != nil {
+		if v4 := addr.To4(); v4 != nil {
+			return &net.IPNet{IP: v4, Mask: net.CIDRMask(32, 32)}
+		}
+		return &net.IPNet{IP: addr, Mask: net.CIDRMask(128, 128)}
+	}
+	_, n, _ := net.ParseCIDR(s)
+	return n
+}
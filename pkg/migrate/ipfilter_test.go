@@ -0,0 +1,92 @@
+package migrate
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/cyberspacesec/go-acl/pkg/ip"
+	"github.com/cyberspacesec/go-acl/pkg/types"
+)
+
+// TestFromIPFilter 测试ipfilter配置的转换结果与ipfilter的优先级相同
+func TestFromIPFilter(t *testing.T) {
+	tests := []struct {
+		name      string
+		opts      IPFilterOptions
+		wantType  string
+		wantRules []string
+		check     map[string]types.Permission
+	}{
+		{
+			name:     "默认拒绝",
+			opts:     IPFilterOptions{AllowedIPs: []string{"10.0.0.0/8", " 192.0.2.1 "}, BlockedIPs: []string{"10.0.0.13", "10.1.0.0/16", "203.0.113.1"}, BlockByDefault: true},
+			wantType: "whitelist",
+			// 允许网段优先于拒绝网段，只有落在允许网段内的单个IP需要规则
+			wantRules: []string{`ip in "10.0.0.13" -> deny`},
+			check: map[string]types.Permission{
+				"10.0.0.13":    types.Denied,
+				"10.1.0.1":     types.Allowed,
+				"192.0.2.1":    types.Allowed,
+				"203.0.113.1":  types.Denied,
+				"198.51.100.1": types.Denied,
+			},
+		},
+		{
+			name:      "默认允许",
+			opts:      IPFilterOptions{AllowedIPs: []string{"10.0.0.7", "10.1.0.0/16", "192.0.2.0/24"}, BlockedIPs: []string{"10.0.0.0/8", "10.1.0.1"}},
+			wantType:  "blacklist",
+			wantRules: []string{`ip in "10.0.0.7" -> allow`, `ip in "10.1.0.1" -> deny`, `ip in "10.1.0.0/16" -> allow`},
+			check: map[string]types.Permission{
+				"10.0.0.7":  types.Allowed,
+				"10.0.0.8":  types.Denied,
+				"10.1.0.1":  types.Denied,
+				"10.1.0.2":  types.Allowed,
+				"192.0.2.1": types.Allowed,
+			},
+		},
+		{
+			name:     "没有重叠",
+			opts:     IPFilterOptions{AllowedIPs: []string{"192.0.2.1"}, BlockedIPs: []string{"203.0.113.0/24"}},
+			wantType: "blacklist",
+			check: map[string]types.Permission{
+				"203.0.113.5": types.Denied,
+				"192.0.2.1":   types.Allowed,
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			policy, err := FromIPFilter(tt.opts)
+			if err != nil {
+				t.Fatalf("FromIPFilter() 返回错误: %v", err)
+			}
+			if policy.IP.Type != tt.wantType {
+				t.Errorf("IP.Type = %s, 期望 %s", policy.IP.Type, tt.wantType)
+			}
+			if !reflect.DeepEqual(policy.Rules, tt.wantRules) {
+				t.Errorf("Rules = %q, 期望 %q", policy.Rules, tt.wantRules)
+			}
+			checkPolicy(t, policy, tt.check)
+		})
+	}
+}
+
+// TestFromIPFilterErrors 测试无效或无法转换的配置
+func TestFromIPFilterErrors(t *testing.T) {
+	tests := []struct {
+		name    string
+		opts    IPFilterOptions
+		wantErr error
+	}{
+		{"国家代码", IPFilterOptions{BlockedCountries: []string{"CN"}}, ErrUnsupported},
+		{"无效的IP", IPFilterOptions{AllowedIPs: []string{"not-an-ip"}}, ip.ErrInvalidIP},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := FromIPFilter(tt.opts); !errors.Is(err, tt.wantErr) {
+				t.Errorf("FromIPFilter() 错误 = %v, 期望 %v", err, tt.wantErr)
+			}
+		})
+	}
+}
@@ -0,0 +1,85 @@
+// Package migrate 将其他常用Go库和服务器的IP访问控制配置转换为go-acl的策略
+//
+// 从其他方案迁移时，可以先用本包把现有配置转换为acl.Policy，核对后保存为策略文件，
+// 再用acl.LoadPolicyFile或acl.NewManagerFromPolicy加载，不必手工改写规则。
+//
+// 支持的来源:
+//   - FromIPFilter: github.com/jpillora/ipfilter的Options
+//   - FromCIDRs、FromIPNets: echo、gin等框架的中间件中常见的CIDR列表
+//   - ParseNginx: nginx ngx_http_access_module的allow/deny指令
+//
+// 来源的求值顺序无法只用IP ACL表达时（如nginx中先deny一个地址再allow它所在的网段），
+// 转换结果会包含按原顺序排列的条件规则（acl.Policy.Rules）。条件规则只在
+// Manager.CheckRequest中求值，这类策略应通过CheckRequest或InboundChecker检查。
+// RequiresCheckRequest可以判断转换结果是否属于这种情况。
+//
+// 用法示例:
+//
+//	f, _ := os.Open("/etc/nginx/conf.d/admin-allow.conf")
+//	defer f.Close()
+//	policy, err := migrate.ParseNginx(f)
+//	if err != nil {
+//	    log.Fatalf("转换失败: %v", err)
+//	}
+//	data, _ := acl.EncodePolicy(policy, acl.JSONCodec)
+//	os.WriteFile("policy.json", data, 0o644)
+package migrate
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"strings"
+
+	"github.com/cyberspacesec/go-acl/pkg/acl"
+	"github.com/cyberspacesec/go-acl/pkg/ip"
+	"github.com/cyberspacesec/go-acl/pkg/types"
+)
+
+// ErrUnsupported 表示来源配置中包含go-acl无法表达的设置，转换不会静默丢弃它们
+var ErrUnsupported = errors.New("包含无法转换的配置")
+
+// RequiresCheckRequest 判断转换得到的策略是否包含条件规则，需要通过Manager.CheckRequest检查
+//
+// 参数:
+//   - policy: 本包转换得到的策略
+//
+// 返回:
+//   - bool: 包含条件规则时为true；此时CheckIP只使用IP ACL，结果可能与来源配置不同
+func RequiresCheckRequest(policy acl.Policy) bool {
+	return len(policy.Rules) > 0
+}
+
+// ipPolicy 创建指定列表类型的IP策略
+func ipPolicy(listType types.ListType, ranges []string) *acl.IPPolicy {
+	return &acl.IPPolicy{Type: listType.String(), Ranges: ranges}
+}
+
+// ipRule 返回对entries中的地址执行action的条件规则
+func ipRule(entries []string, action string) string {
+	quoted := make([]string, len(entries))
+	for i, e := range entries {
+		quoted[i] = `"` + e + `"`
+	}
+	if len(quoted) == 1 {
+		return fmt.Sprintf("ip in %s -> %s", quoted[0], action)
+	}
+	return fmt.Sprintf("ip in [%s] -> %s", strings.Join(quoted, ", "), action)
+}
+
+// normalizeRange 校验IP或CIDR，返回去除空白后的写法
+func normalizeRange(s string) (string, error) {
+	s = strings.TrimSpace(s)
+	if net.ParseIP(s) != nil {
+		return s, nil
+	}
+	if _, _, err := net.ParseCIDR(s); err == nil {
+		return s, nil
+	}
+	return "", fmt.Errorf("%w: %q", ip.ErrInvalidIP, s)
+}
+
+// isSingleIP 判断规则是否是单个IP
+func isSingleIP(s string) bool {
+	return net.ParseIP(s) != nil
+}
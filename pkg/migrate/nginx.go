@@ -0,0 +1,177 @@
+package migrate
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/cyberspacesec/go-acl/pkg/acl"
+	"github.com/cyberspacesec/go-acl/pkg/types"
+)
+
+// ErrInvalidNginx 表示nginx配置无效或没有allow/deny指令
+var ErrInvalidNginx = errors.New("无效的nginx配置")
+
+// nginxDirective 是一条allow或deny指令
+type nginxDirective struct {
+	action string
+	addr   string
+}
+
+// ParseNginx 将nginx ngx_http_access_module的allow/deny指令转换为策略
+//
+// 参数:
+//   - r: nginx配置片段，如某个location块或被include的文件
+//
+// 返回:
+//   - acl.Policy: 与指令的求值结果相同的策略，见下文
+//   - error: 读取错误，或包装了ErrInvalidNginx、ip.ErrInvalidIP、ErrUnsupported的错误（附带行号）
+//
+// nginx按顺序检查指令，第一条匹配的指令决定结果，都不匹配时允许。转换规则:
+//   - "deny all"使策略成为白名单，"allow all"或没有all时为黑名单；all之后的指令永远不会被检查，被忽略
+//   - 与默认结果相反的指令，在最后一条与默认结果相同的指令之后的部分，成为IP ACL的规则
+//   - 其余指令的顺序会影响结果（如先deny一个地址再allow它所在的网段），按原顺序转换为条件规则
+//
+// 只处理allow和deny指令，其他指令和大括号被忽略，但allow/deny分布在多个配置块中时返回ErrUnsupported，
+// 不同location的规则需要分别转换。unix:地址同样无法转换。
+//
+// 示例:
+//
+//	policy, err := migrate.ParseNginx(strings.NewReader(`
+//	    location /admin {
+//	        deny  192.168.1.1;
+//	        allow 192.168.1.0/24;
+//	        allow 10.1.0.0/16;
+//	        deny  all;
+//	    }
+//	`))
+//	// policy.IP: whitelist 192.168.1.0/24、10.1.0.0/16
+//	// policy.Rules: ["ip in \"192.168.1.1\" -> deny"]
+func ParseNginx(r io.Reader) (acl.Policy, error) {
+	directives, err := scanNginx(r)
+	if err != nil {
+		return acl.Policy{}, err
+	}
+	if len(directives) == 0 {
+		return acl.Policy{}, fmt.Errorf("%w: 没有allow或deny指令", ErrInvalidNginx)
+	}
+
+	// 默认结果由第一条all指令决定，之后的指令不会被检查
+	defaultAction := "allow"
+	for i, d := range directives {
+		if d.addr == "all" {
+			defaultAction = d.action
+			directives = directives[:i]
+			break
+		}
+	}
+
+	// 最后一条与默认结果相同的指令及其之前的指令依赖顺序，转换为条件规则
+	split := 0
+	for i, d := range directives {
+		if d.action == defaultAction {
+			split = i + 1
+		}
+	}
+
+	var policy acl.Policy
+	var group []string
+	for i, d := range directives[:split] {
+		group = append(group, d.addr)
+		if i+1 == split || directives[i+1].action != d.action {
+			policy.Rules = append(policy.Rules, ipRule(group, d.action))
+			group = nil
+		}
+	}
+
+	listType := types.Blacklist
+	if defaultAction == "deny" {
+		listType = types.Whitelist
+	}
+	ranges := make([]string, 0, len(directives)-split)
+	for _, d := range directives[split:] {
+		ranges = append(ranges, d.addr)
+	}
+	policy.IP = ipPolicy(listType, ranges)
+	return policy, nil
+}
+
+// scanNginx 按顺序读取allow和deny指令
+func scanNginx(r io.Reader) ([]nginxDirective, error) {
+	var directives []nginxDirective
+	// block 在每个大括号处加一，用于发现分布在多个配置块中的指令
+	block, directiveBlock := 0, -1
+	var words []string
+	start := 0
+
+	statement := func() error {
+		defer func() { words = nil }()
+		if len(words) == 0 || (words[0] != "allow" && words[0] != "deny") {
+			return nil
+		}
+		if len(words) != 2 {
+			return fmt.Errorf("%w: 第%d行: %s需要一个参数", ErrInvalidNginx, start, words[0])
+		}
+		if directiveBlock >= 0 && directiveBlock != block {
+			return fmt.Errorf("%w: 第%d行: allow/deny分布在多个配置块中，请分别转换", ErrUnsupported, start)
+		}
+		directiveBlock = block
+
+		addr := words[1]
+		if strings.HasPrefix(addr, "unix:") {
+			return fmt.Errorf("%w: 第%d行: unix:地址", ErrUnsupported, start)
+		}
+		if addr != "all" {
+			var err error
+			if addr, err = normalizeRange(addr); err != nil {
+				return fmt.Errorf("第%d行: %w", start, err)
+			}
+		}
+		directives = append(directives, nginxDirective{action: words[0], addr: addr})
+		return nil
+	}
+
+	scanner := bufio.NewScanner(r)
+	for lineNo := 1; scanner.Scan(); lineNo++ {
+		text := scanner.Text()
+		if i := strings.IndexByte(text, '#'); i >= 0 {
+			text = text[:i]
+		}
+		word := ""
+		flush := func() {
+			if word != "" {
+				if len(words) == 0 {
+					start = lineNo
+				}
+				words = append(words, word)
+				word = ""
+			}
+		}
+		for _, c := range text {
+			switch c {
+			case ';', '{', '}':
+				flush()
+				if err := statement(); err != nil {
+					return nil, err
+				}
+				if c != ';' {
+					block++
+				}
+			case ' ', '\t', '\r':
+				flush()
+			default:
+				word += string(c)
+			}
+		}
+		flush()
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	if len(words) > 0 && (words[0] == "allow" || words[0] == "deny") {
+		return nil, fmt.Errorf("%w: 第%d行: 指令缺少分号", ErrInvalidNginx, start)
+	}
+	return directives, nil
+}
@@ -0,0 +1,144 @@
+package migrate
+
+import (
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/cyberspacesec/go-acl/pkg/acl"
+	"github.com/cyberspacesec/go-acl/pkg/expr"
+	"github.com/cyberspacesec/go-acl/pkg/ip"
+	"github.com/cyberspacesec/go-acl/pkg/types"
+)
+
+// checkPolicy 用策略创建Manager，检查每个地址的结果
+func checkPolicy(t *testing.T, policy acl.Policy, want map[string]types.Permission) {
+	t.Helper()
+	manager, err := acl.NewManagerFromPolicy(policy)
+	if err != nil {
+		t.Fatalf("NewManagerFromPolicy() 返回错误: %v", err)
+	}
+	for addr, perm := range want {
+		got, err := manager.CheckRequest(expr.Request{IP: addr})
+		if err != nil {
+			t.Fatalf("CheckRequest(%s) 返回错误: %v", addr, err)
+		}
+		if got != perm {
+			t.Errorf("CheckRequest(%s) = %v, 期望 %v", addr, got, perm)
+		}
+	}
+}
+
+// TestParseNginx 测试allow/deny指令的转换结果与nginx按顺序求值的结果相同
+func TestParseNginx(t *testing.T) {
+	tests := []struct {
+		name      string
+		conf      string
+		wantType  string
+		wantRange []string
+		wantRules []string
+		check     map[string]types.Permission
+	}{
+		{
+			name: "白名单",
+			conf: `
+location /admin {
+    allow 192.168.1.0/24;  # 办公网
+    allow 2001:db8::/32;
+    deny  all;
+}`,
+			wantType:  "whitelist",
+			wantRange: []string{"192.168.1.0/24", "2001:db8::/32"},
+			check: map[string]types.Permission{
+				"192.168.1.7": types.Allowed,
+				"2001:db8::1": types.Allowed,
+				"10.0.0.1":    types.Denied,
+			},
+		},
+		{
+			name:      "黑名单",
+			conf:      "deny 203.0.113.0/24; deny 198.51.100.1;",
+			wantType:  "blacklist",
+			wantRange: []string{"203.0.113.0/24", "198.51.100.1"},
+			check: map[string]types.Permission{
+				"203.0.113.9":  types.Denied,
+				"198.51.100.1": types.Denied,
+				"192.0.2.1":    types.Allowed,
+			},
+		},
+		{
+			name: "顺序相关的指令",
+			conf: `deny  192.168.1.1;
+allow 192.168.1.0/24;
+allow 10.1.0.0/16;
+deny  all;
+allow 203.0.113.1;`,
+			wantType:  "whitelist",
+			wantRange: []string{"192.168.1.0/24", "10.1.0.0/16"},
+			wantRules: []string{`ip in "192.168.1.1" -> deny`},
+			check: map[string]types.Permission{
+				"192.168.1.1": types.Denied,
+				"192.168.1.2": types.Allowed,
+				"10.1.2.3":    types.Allowed,
+				"203.0.113.1": types.Denied,
+			},
+		},
+		{
+			name:      "多条规则分组",
+			conf:      "allow 10.0.0.1; allow 10.0.0.2; deny 10.0.0.0/8; allow 10.1.0.0/16; deny 192.0.2.0/24; allow all;",
+			wantType:  "blacklist",
+			wantRange: []string{"192.0.2.0/24"},
+			wantRules: []string{`ip in ["10.0.0.1", "10.0.0.2"] -> allow`, `ip in "10.0.0.0/8" -> deny`, `ip in "10.1.0.0/16" -> allow`},
+			check: map[string]types.Permission{
+				"10.0.0.2":  types.Allowed,
+				"10.0.0.3":  types.Denied,
+				"10.1.0.1":  types.Denied,
+				"192.0.2.1": types.Denied,
+				"192.0.3.1": types.Allowed,
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			policy, err := ParseNginx(strings.NewReader(tt.conf))
+			if err != nil {
+				t.Fatalf("ParseNginx() 返回错误: %v", err)
+			}
+			if policy.IP.Type != tt.wantType || !reflect.DeepEqual(policy.IP.Ranges, tt.wantRange) {
+				t.Errorf("IP = %+v, 期望 %s %v", policy.IP, tt.wantType, tt.wantRange)
+			}
+			if !reflect.DeepEqual(policy.Rules, tt.wantRules) {
+				t.Errorf("Rules = %q, 期望 %q", policy.Rules, tt.wantRules)
+			}
+			if RequiresCheckRequest(policy) != (len(tt.wantRules) > 0) {
+				t.Errorf("RequiresCheckRequest() = %v", RequiresCheckRequest(policy))
+			}
+			checkPolicy(t, policy, tt.check)
+		})
+	}
+}
+
+// TestParseNginxErrors 测试无效或无法转换的配置
+func TestParseNginxErrors(t *testing.T) {
+	tests := []struct {
+		name    string
+		conf    string
+		wantErr error
+	}{
+		{"没有指令", "server_name example.com;", ErrInvalidNginx},
+		{"缺少参数", "allow;", ErrInvalidNginx},
+		{"多余参数", "deny 10.0.0.1 10.0.0.2;", ErrInvalidNginx},
+		{"缺少分号", "allow 10.0.0.1", ErrInvalidNginx},
+		{"无效的地址", "allow 10.0.0.300;", ip.ErrInvalidIP},
+		{"unix地址", "allow unix:;", ErrUnsupported},
+		{"多个配置块", "location /a { allow 10.0.0.1; }\nlocation /b { deny all; }", ErrUnsupported},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := ParseNginx(strings.NewReader(tt.conf)); !errors.Is(err, tt.wantErr) {
+				t.Errorf("ParseNginx() 错误 = %v, 期望 %v", err, tt.wantErr)
+			}
+		})
+	}
+}
@@ -0,0 +1,170 @@
+// Package nft 将Manager的IP黑名单同步到nftables集合，让主机在包过滤层执行与应用相同的名单
+//
+// Syncer把Manager.DeniedIPRanges()渲染为nft JSON格式的集合更新（见ip.ExportNftables），
+// 并在Manager的IP配置改变时重新应用。应用是可选的：Render只生成JSON，不修改系统；
+// 只有向NewSyncer传入Applier（如Command("nft")）时才会调用nft修改内核规则集。
+//
+// 集合需要被防火墙规则引用才会生效，例如：
+//
+//	table inet filter {
+//	    chain input {
+//	        type filter hook input priority 0;
+//	        ip saddr @go_acl_deny drop
+//	        ip6 saddr @go_acl_deny6 drop
+//	    }
+//	}
+//
+// 用法示例:
+//
+//	syncer := nft.NewSyncer(manager, ip.DefaultNftSets("filter", "go_acl_deny"), nft.Command("nft"))
+//	go syncer.Run(ctx, 5*time.Minute)
+package nft
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"os/exec"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/cyberspacesec/go-acl/pkg/acl"
+	"github.com/cyberspacesec/go-acl/pkg/ip"
+	"github.com/cyberspacesec/go-acl/pkg/types"
+)
+
+// Applier 把nft JSON格式的规则集更新应用到系统
+type Applier func(ctx context.Context, ruleset []byte) error
+
+// Command 返回通过nft命令（nft -j -f -）应用更新的Applier
+//
+// 参数:
+//   - path: nft可执行文件，如"nft"或"/usr/sbin/nft"
+//
+// 返回:
+//   - Applier: 从标准输入把更新传给nft的Applier，nft失败时错误中包含它的标准错误输出
+//
+// 修改规则集需要CAP_NET_ADMIN权限。
+func Command(path string) Applier {
+	return func(ctx context.Context, ruleset []byte) error {
+		var stderr bytes.Buffer
+		cmd := exec.CommandContext(ctx, path, "-j", "-f", "-")
+		cmd.Stdin = bytes.NewReader(ruleset)
+		cmd.Stderr = &stderr
+		if err := cmd.Run(); err != nil {
+			if msg := strings.TrimSpace(stderr.String()); msg != "" {
+				return fmt.Errorf("%w: %s", err, msg)
+			}
+			return err
+		}
+		return nil
+	}
+}
+
+// Syncer 把Manager的IP黑名单同步到nftables集合
+//
+// 每次同步都替换集合的全部内容，内容与上次成功应用的相同时跳过。
+// Syncer可以安全地在多个goroutine中使用，同一时刻只执行一次同步。
+type Syncer struct {
+	manager *acl.Manager
+	sets    ip.NftSets
+	apply   Applier
+
+	mu      sync.Mutex
+	applied []byte
+}
+
+// NewSyncer 创建把manager的IP黑名单同步到sets的Syncer
+//
+// 参数:
+//   - manager: 黑名单的来源，同步的内容为manager.DeniedIPRanges()
+//   - sets: 目标表和集合，见ip.NftSets
+//   - apply: 应用更新的方式；为nil时Sync只渲染而不应用，用于预览
+//
+// 返回:
+//   - *Syncer: 尚未同步的Syncer
+func NewSyncer(manager *acl.Manager, sets ip.NftSets, apply Applier) *Syncer {
+	return &Syncer{manager: manager, sets: sets, apply: apply}
+}
+
+// Render 把当前的黑名单渲染为nft JSON格式的集合更新，不修改系统
+//
+// 返回:
+//   - []byte: 可用nft -j -f导入的JSON
+//   - error: 集合配置无法容纳黑名单时返回的错误，见ip.ExportNftables
+func (s *Syncer) Render() ([]byte, error) {
+	denied, err := ip.NewIPACL(s.manager.DeniedIPRanges(), types.Blacklist)
+	if err != nil {
+		return nil, err
+	}
+	var buf bytes.Buffer
+	if err := ip.ExportNftables(&buf, denied, s.sets); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// Sync 立即把当前的黑名单应用到集合
+//
+// 参数:
+//   - ctx: 传给Applier的上下文
+//
+// 返回:
+//   - bool: 是否调用了Applier；内容与上次成功应用的相同或没有Applier时为false
+//   - error: 渲染或应用失败时返回的错误，失败后下一次同步会重新应用
+func (s *Syncer) Sync(ctx context.Context) (bool, error) {
+	ruleset, err := s.Render()
+	if err != nil {
+		return false, err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.apply == nil || bytes.Equal(ruleset, s.applied) {
+		return false, nil
+	}
+	if err := s.apply(ctx, ruleset); err != nil {
+		s.applied = nil
+		return true, fmt.Errorf("应用nftables集合更新: %w", err)
+	}
+	s.applied = ruleset
+	return true, nil
+}
+
+// Run 同步一次黑名单，之后在Manager的IP配置改变时重新同步，直到ctx被取消
+//
+// 参数:
+//   - ctx: 控制同步循环生命周期的上下文
+//   - resync: 不依赖改变通知的核对间隔，用于覆盖按时间到期的条目；不大于0时只在收到通知时同步
+//
+// 返回:
+//   - error: ctx被取消时返回ctx.Err()
+//
+// 同步失败不会终止循环。核对时内容没有改变则不调用nft，
+// 因此集合被其他程序修改后不会被自动恢复，直到黑名单再次改变。
+func (s *Syncer) Run(ctx context.Context, resync time.Duration) error {
+	changes, stop := s.manager.WatchChanges()
+	defer stop()
+
+	var tick <-chan time.Time
+	if resync > 0 {
+		ticker := time.NewTicker(resync)
+		defer ticker.Stop()
+		tick = ticker.C
+	}
+
+	_, _ = s.Sync(ctx)
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case event := <-changes:
+			if event.Kind == "ip" {
+				_, _ = s.Sync(ctx)
+			}
+		case <-tick:
+			_, _ = s.Sync(ctx)
+		}
+	}
+}
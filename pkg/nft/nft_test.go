@@ -0,0 +1,140 @@
+package nft
+
+import (
+	"context"
+	"errors"
+	"os/exec"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/cyberspacesec/go-acl/pkg/acl"
+	"github.com/cyberspacesec/go-acl/pkg/ip"
+	"github.com/cyberspacesec/go-acl/pkg/types"
+)
+
+// recorder 记录每次应用的规则集，err非nil时应用失败
+type recorder struct {
+	mu       sync.Mutex
+	rulesets []string
+	err      error
+}
+
+func (r *recorder) apply(_ context.Context, ruleset []byte) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.rulesets = append(r.rulesets, string(ruleset))
+	return r.err
+}
+
+func (r *recorder) last() (string, int) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if len(r.rulesets) == 0 {
+		return "", 0
+	}
+	return r.rulesets[len(r.rulesets)-1], len(r.rulesets)
+}
+
+// TestSyncerSync 测试只在黑名单改变或上次应用失败时调用Applier
+func TestSyncerSync(t *testing.T) {
+	manager := acl.NewManager()
+	if err := manager.SetIPACL([]string{"198.51.100.7"}, types.Blacklist); err != nil {
+		t.Fatalf("SetIPACL() 返回错误: %v", err)
+	}
+	if err := manager.SetNamedIPList("partners", []string{"203.0.113.0/24"}, types.Whitelist, 10); err != nil {
+		t.Fatalf("SetNamedIPList() 返回错误: %v", err)
+	}
+	r := &recorder{}
+	syncer := NewSyncer(manager, ip.DefaultNftSets("filter", "deny"), r.apply)
+	ctx := context.Background()
+
+	if applied, err := syncer.Sync(ctx); !applied || err != nil {
+		t.Fatalf("Sync() = %v, %v, 期望应用", applied, err)
+	}
+	ruleset, _ := r.last()
+	if !strings.Contains(ruleset, `"198.51.100.7"`) || strings.Contains(ruleset, "203.0.113.0") {
+		t.Errorf("规则集 = %s, 期望只包含黑名单", ruleset)
+	}
+	if applied, _ := syncer.Sync(ctx); applied {
+		t.Error("黑名单没有改变时 Sync() 不应调用Applier")
+	}
+
+	manager.AddIP("192.0.2.0/24")
+	r.err = errors.New("Permission denied")
+	if applied, err := syncer.Sync(ctx); !applied || err == nil || !strings.Contains(err.Error(), "Permission denied") {
+		t.Errorf("Sync() = %v, %v, 期望返回应用错误", applied, err)
+	}
+	r.err = nil
+	if applied, err := syncer.Sync(ctx); !applied || err != nil {
+		t.Errorf("失败后 Sync() = %v, %v, 期望重新应用", applied, err)
+	}
+	if ruleset, n := r.last(); n != 3 || !strings.Contains(ruleset, `"addr":"192.0.2.0","len":24`) {
+		t.Errorf("第%d次应用的规则集 = %s", n, ruleset)
+	}
+}
+
+// TestSyncerPreview 测试没有Applier时只渲染
+func TestSyncerPreview(t *testing.T) {
+	manager := acl.NewManager()
+	manager.SetIPACL([]string{"2001:db8::/32"}, types.Blacklist)
+	syncer := NewSyncer(manager, ip.NftSets{Family: "inet", Table: "filter", IPv4: "deny"}, nil)
+
+	if _, err := syncer.Render(); !errors.Is(err, ip.ErrInvalidNftSets) {
+		t.Errorf("Render() 错误 = %v, 期望 ip.ErrInvalidNftSets", err)
+	}
+	syncer = NewSyncer(manager, ip.DefaultNftSets("filter", "deny"), nil)
+	ruleset, err := syncer.Render()
+	if err != nil || !strings.Contains(string(ruleset), `"addr":"2001:db8::","len":32`) {
+		t.Errorf("Render() = %s, %v", ruleset, err)
+	}
+	if applied, err := syncer.Sync(context.Background()); applied || err != nil {
+		t.Errorf("Sync() = %v, %v, 没有Applier时不应应用", applied, err)
+	}
+}
+
+// TestSyncerRun 测试Run在Manager改变后重新应用
+func TestSyncerRun(t *testing.T) {
+	manager := acl.NewManager()
+	manager.SetIPACL([]string{"198.51.100.7"}, types.Blacklist)
+	r := &recorder{}
+	syncer := NewSyncer(manager, ip.DefaultNftSets("filter", "deny"), r.apply)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error, 1)
+	go func() { done <- syncer.Run(ctx, 0) }()
+
+	waitFor := func(substr string) {
+		t.Helper()
+		deadline := time.Now().Add(2 * time.Second)
+		for {
+			if ruleset, _ := r.last(); strings.Contains(ruleset, substr) {
+				return
+			}
+			if time.Now().After(deadline) {
+				ruleset, _ := r.last()
+				t.Fatalf("规则集 = %s, 期望包含 %s", ruleset, substr)
+			}
+			time.Sleep(time.Millisecond)
+		}
+	}
+	waitFor(`"198.51.100.7"`)
+	manager.AddIP("192.0.2.1")
+	waitFor(`"192.0.2.1"`)
+
+	cancel()
+	if err := <-done; !errors.Is(err, context.Canceled) {
+		t.Errorf("Run() = %v, 期望 context.Canceled", err)
+	}
+}
+
+// TestCommand 测试nft命令失败时返回错误
+func TestCommand(t *testing.T) {
+	if _, err := exec.LookPath("false"); err != nil {
+		t.Skip("找不到false命令")
+	}
+	if err := Command("false")(context.Background(), []byte(`{"nftables":[]}`)); err == nil {
+		t.Error("命令失败时 Applier 应返回错误")
+	}
+}
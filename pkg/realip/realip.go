@@ -0,0 +1,248 @@
+// Package realip 解析和校验X-Forwarded-For转发链，确定真实的客户端IP
+//
+// 部署在反向代理、负载均衡或CDN之后时，r.RemoteAddr是最近一跳代理的地址，
+// 客户端地址需要从X-Forwarded-For中取得。但X-Forwarded-For的左侧部分由客户端任意填写，
+// 只取第一个地址、只读取第一个头部或把无法解析的地址当作普通字符串处理，都是常见的伪造来源。
+//
+// ParseForwardedChain按以下规则解析完整的转发链:
+//   - 合并所有X-Forwarded-For头部，按出现顺序拼接，最后加上RemoteAddr
+//   - 从右向左（从离服务最近的一跳开始）跳过可信代理，第一个不可信的地址就是客户端；
+//     它左侧的地址都由客户端提供，无法验证
+//   - 每一跳都按可信代理列表分类，已验证的各跳再用ACL检查，便于记录和审计
+//
+// 用法示例:
+//
+//	trusted, _ := ip.NewIPACL([]string{"10.0.0.0/8"}, types.Whitelist)
+//	chain, err := realip.ParseForwardedChain(r, realip.Config{TrustedProxies: trusted, Manager: manager})
+//	if err != nil {
+//	    http.Error(w, "无效的X-Forwarded-For", http.StatusBadRequest)
+//	    return
+//	}
+//	if hop, denied := chain.Denied(); denied {
+//	    log.Printf("拒绝客户端 %s: %s", hop.Addr, hop.Result.Reason)
+//	}
+package realip
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"strings"
+
+	"github.com/cyberspacesec/go-acl/pkg/acl"
+	"github.com/cyberspacesec/go-acl/pkg/ip"
+	"github.com/cyberspacesec/go-acl/pkg/types"
+)
+
+// HeaderXForwardedFor 是Config.Header为空时读取的头部
+const HeaderXForwardedFor = "X-Forwarded-For"
+
+// DefaultMaxHops 是Config.MaxHops为0时保留的最大跳数（包括RemoteAddr）
+const DefaultMaxHops = 32
+
+// 转发链中每一跳的来源
+const (
+	// SourceHeader 表示地址来自转发头部
+	SourceHeader = "header"
+	// SourceRemoteAddr 表示地址来自r.RemoteAddr，即直接连接的对端
+	SourceRemoteAddr = "remote_addr"
+)
+
+// ErrInvalidHop 表示转发链中有无法解析为IP的地址，且它位于需要验证的部分，无法确定客户端
+var ErrInvalidHop = errors.New("转发链中的地址无效")
+
+// Config 是解析转发链的配置
+//
+// 字段说明:
+//   - TrustedProxies: 可信代理的地址，列表中的地址就是可信代理（与列表类型无关），nil表示不信任任何代理
+//   - Manager: 对每一跳执行访问控制的ACL管理器，nil表示不检查
+//   - Header: 转发头部的名称，为空时使用HeaderXForwardedFor
+//   - MaxHops: 最多保留的跳数，超出时丢弃最左侧（由客户端提供）的部分，0表示DefaultMaxHops
+type Config struct {
+	TrustedProxies *ip.IPACL
+	Manager        *acl.Manager
+	Header         string
+	MaxHops        int
+}
+
+// Hop 是转发链中的一跳
+//
+// 字段说明:
+//   - Addr: 原始的地址文本
+//   - IP: 解析后的IP，地址无效时为nil
+//   - Source: 地址的来源，为SourceHeader或SourceRemoteAddr
+//   - Trusted: 是否为可信代理
+//   - Verified: 是否位于可以验证的部分，即客户端及其右侧的各跳；其余各跳由客户端填写，可能是伪造的
+//   - Checked: 是否执行了ACL检查，只检查已验证的各跳，未设置Manager或IP ACL时为false
+//   - Result: Manager的检查结果，Checked为false时为零值
+//   - Err: 地址无效时为ErrInvalidHop，检查失败时为检查的错误（未设置的ACL不视为错误）
+type Hop struct {
+	Addr     string
+	IP       net.IP
+	Source   string
+	Trusted  bool
+	Verified bool
+	Checked  bool
+	Result   types.CheckResult
+	Err      error
+}
+
+// Chain 是解析后的转发链
+//
+// 字段说明:
+//   - Hops: 从左到右排列的各跳，最后一跳总是RemoteAddr
+//   - Client: 客户端在Hops中的下标，无法确定时为-1
+//   - Truncated: 是否因超过MaxHops丢弃了最左侧的部分
+type Chain struct {
+	Hops      []Hop
+	Client    int
+	Truncated bool
+}
+
+// ClientIP 返回客户端IP，无法确定时返回nil
+func (c Chain) ClientIP() net.IP {
+	if hop, ok := c.ClientHop(); ok {
+		return hop.IP
+	}
+	return nil
+}
+
+// ClientHop 返回客户端所在的一跳
+//
+// 返回:
+//   - Hop: 客户端所在的一跳
+//   - bool: 无法确定客户端时返回false
+func (c Chain) ClientHop() (Hop, bool) {
+	if c.Client < 0 || c.Client >= len(c.Hops) {
+		return Hop{}, false
+	}
+	return c.Hops[c.Client], true
+}
+
+// Denied 返回已验证的部分中第一个被ACL拒绝的一跳
+//
+// 返回:
+//   - Hop: 从客户端开始向右第一个被拒绝（或检查失败）的一跳
+//   - bool: 没有被拒绝的一跳时返回false
+//
+// 未验证的部分（客户端左侧的各跳）可以被任意伪造，不参与判断。
+func (c Chain) Denied() (Hop, bool) {
+	for _, hop := range c.Hops {
+		if hop.Verified && hop.IP != nil && (hop.Err != nil || hop.Checked && hop.Result.Decision == types.Denied) {
+			return hop, true
+		}
+	}
+	return Hop{}, false
+}
+
+// ParseForwardedChain 解析请求的完整转发链
+//
+// 参数:
+//   - r: HTTP请求，使用其中的转发头部和RemoteAddr
+//   - cfg: 可信代理、ACL和解析选项
+//
+// 返回:
+//   - Chain: 解析后的转发链，出错时也包含已解析的各跳
+//   - error: 从右向左查找客户端时遇到无效的地址（包括无效的RemoteAddr），
+//     返回包装了ErrInvalidHop的错误，此时Chain.Client为-1
+//
+// 每个头部按逗号分隔，地址两侧的空白被忽略，带端口的地址（如"192.0.2.1:1234"、"[2001:db8::1]:443"）
+// 会去掉端口。地址只接受标准的IP写法，"unknown"、混淆的写法（如"0x7f.1"）等都视为无效。
+// 所有地址都是可信代理时，客户端为最左侧的一跳。
+func ParseForwardedChain(r *http.Request, cfg Config) (Chain, error) {
+	header := cfg.Header
+	if header == "" {
+		header = HeaderXForwardedFor
+	}
+	maxHops := cfg.MaxHops
+	if maxHops <= 0 {
+		maxHops = DefaultMaxHops
+	}
+
+	var hops []Hop
+	for _, value := range r.Header.Values(header) {
+		for _, addr := range strings.Split(value, ",") {
+			if addr = strings.TrimSpace(addr); addr != "" {
+				hops = append(hops, Hop{Addr: addr, Source: SourceHeader})
+			}
+		}
+	}
+	hops = append(hops, Hop{Addr: r.RemoteAddr, Source: SourceRemoteAddr})
+
+	chain := Chain{Client: -1}
+	if len(hops) > maxHops {
+		hops = hops[len(hops)-maxHops:]
+		chain.Truncated = true
+	}
+	chain.Hops = hops
+
+	for i := range hops {
+		hop := &hops[i]
+		hop.IP = parseHop(hop.Addr)
+		if hop.IP == nil {
+			hop.Err = ErrInvalidHop
+			continue
+		}
+		hop.Trusted = isTrusted(cfg.TrustedProxies, hop.IP)
+	}
+
+	for i := len(hops) - 1; i >= 0; i-- {
+		hop := &hops[i]
+		hop.Verified = true
+		if hop.IP == nil {
+			return chain, fmt.Errorf("%w: 第%d跳 %q", ErrInvalidHop, i+1, hop.Addr)
+		}
+		if !hop.Trusted || i == 0 {
+			chain.Client = i
+			break
+		}
+	}
+
+	if cfg.Manager != nil {
+		for i := range hops {
+			hop := &hops[i]
+			// 未验证的部分可以任意伪造，检查它们只会产生无意义的统计和审计事件
+			if !hop.Verified {
+				continue
+			}
+			result, err := cfg.Manager.CheckIPDetailed(r.Context(), hop.IP.String())
+			if errors.Is(err, types.ErrNoACL) {
+				continue
+			}
+			hop.Checked, hop.Result, hop.Err = true, result, err
+		}
+	}
+	return chain, nil
+}
+
+// parseHop 解析一跳的地址，可以带端口，无效时返回nil
+func parseHop(addr string) net.IP {
+	if host, _, err := net.SplitHostPort(addr); err == nil {
+		addr = host
+	} else if strings.HasPrefix(addr, "[") && strings.HasSuffix(addr, "]") {
+		addr = addr[1 : len(addr)-1]
+	}
+	// 带区域的IPv6地址（如"fe80::1%eth0"）只在本机有意义
+	if strings.Contains(addr, "%") {
+		return nil
+	}
+	parsed := net.ParseIP(addr)
+	if v4 := parsed.To4(); v4 != nil {
+		return v4
+	}
+	return parsed
+}
+
+// isTrusted 判断地址是否在可信代理列表中
+func isTrusted(trusted *ip.IPACL, addr net.IP) bool {
+	if trusted == nil {
+		return false
+	}
+	perm, err := trusted.Check(addr.String())
+	if err != nil {
+		return false
+	}
+	// 白名单命中时允许，黑名单命中时拒绝
+	return (perm == types.Allowed) == (trusted.GetListType() == types.Whitelist)
+}
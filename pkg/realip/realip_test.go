@@ -0,0 +1,132 @@
+package realip
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/cyberspacesec/go-acl/pkg/acl"
+	"github.com/cyberspacesec/go-acl/pkg/ip"
+	"github.com/cyberspacesec/go-acl/pkg/types"
+)
+
+func newRequest(remoteAddr string, xff ...string) *http.Request {
+	r := httptest.NewRequest(http.MethodGet, "/", nil)
+	r.RemoteAddr = remoteAddr
+	for _, v := range xff {
+		r.Header.Add(HeaderXForwardedFor, v)
+	}
+	return r
+}
+
+// TestParseForwardedChain 测试客户端的确定
+func TestParseForwardedChain(t *testing.T) {
+	trusted, err := ip.NewIPACL([]string{"10.0.0.0/8", "2001:db8:ffff::/48"}, types.Whitelist)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name       string
+		req        *http.Request
+		wantClient string
+		wantHops   int
+		wantErr    error
+	}{
+		{"没有转发头部", newRequest("198.51.100.7:5000"), "198.51.100.7", 1, nil},
+		{"直连的对端不可信时忽略转发头部", newRequest("198.51.100.7:5000", "1.2.3.4"), "198.51.100.7", 2, nil},
+		{"跳过可信代理", newRequest("10.0.0.2:5000", "203.0.113.9, 10.0.0.1"), "203.0.113.9", 3, nil},
+		{"客户端伪造的左侧部分被忽略", newRequest("10.0.0.2:5000", "127.0.0.1, 203.0.113.9"), "203.0.113.9", 3, nil},
+		{"合并多个头部", newRequest("10.0.0.2:5000", "192.0.2.1", "10.0.0.3"), "192.0.2.1", 3, nil},
+		{"带端口的地址", newRequest("[2001:db8:ffff::1]:443", "[2001:db8::5]:8080, 10.1.1.1:80"), "2001:db8::5", 3, nil},
+		{"全部是可信代理", newRequest("10.0.0.2:5000", "10.0.0.5"), "10.0.0.5", 2, nil},
+		{"IPv4映射地址", newRequest("10.0.0.2:5000", "::ffff:203.0.113.9"), "203.0.113.9", 2, nil},
+		{"未验证部分的无效地址被忽略", newRequest("10.0.0.2:5000", "unknown, 203.0.113.9"), "203.0.113.9", 3, nil},
+		{"已验证部分的无效地址", newRequest("10.0.0.2:5000", "203.0.113.9, unknown"), "", 3, ErrInvalidHop},
+		{"混淆的IP写法", newRequest("10.0.0.2:5000", "0x7f.1"), "", 2, ErrInvalidHop},
+		{"带区域的IPv6地址", newRequest("10.0.0.2:5000", "fe80::1%eth0"), "", 2, ErrInvalidHop},
+		{"无效的RemoteAddr", newRequest("pipe"), "", 1, ErrInvalidHop},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			chain, err := ParseForwardedChain(tt.req, Config{TrustedProxies: trusted})
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("ParseForwardedChain() 错误 = %v, 期望 %v", err, tt.wantErr)
+			}
+			if len(chain.Hops) != tt.wantHops {
+				t.Errorf("跳数 = %d, 期望 %d", len(chain.Hops), tt.wantHops)
+			}
+			got := ""
+			if client := chain.ClientIP(); client != nil {
+				got = client.String()
+			}
+			if got != tt.wantClient {
+				t.Errorf("ClientIP() = %q, 期望 %q", got, tt.wantClient)
+			}
+			if last := chain.Hops[len(chain.Hops)-1]; last.Source != SourceRemoteAddr {
+				t.Errorf("最后一跳的来源 = %q, 期望 %q", last.Source, SourceRemoteAddr)
+			}
+		})
+	}
+}
+
+// TestParseForwardedChainOptions 测试可信代理列表的类型、自定义头部和最大跳数
+func TestParseForwardedChainOptions(t *testing.T) {
+	// 黑名单类型的列表同样按列表中的地址判断可信代理
+	blacklist, _ := ip.NewIPACL([]string{"10.0.0.0/8"}, types.Blacklist)
+	chain, err := ParseForwardedChain(newRequest("10.0.0.2:1", "192.0.2.1"), Config{TrustedProxies: blacklist})
+	if err != nil || chain.ClientIP().String() != "192.0.2.1" {
+		t.Errorf("黑名单类型的可信代理列表: ClientIP() = %v, %v", chain.ClientIP(), err)
+	}
+	chain, _ = ParseForwardedChain(newRequest("198.51.100.1:1", "192.0.2.1"), Config{TrustedProxies: blacklist})
+	if chain.ClientIP().String() != "198.51.100.1" {
+		t.Errorf("不在列表中的对端被信任: ClientIP() = %v", chain.ClientIP())
+	}
+
+	r := newRequest("10.0.0.2:1")
+	r.Header.Set("X-Original-Forwarded-For", "192.0.2.8")
+	chain, _ = ParseForwardedChain(r, Config{TrustedProxies: blacklist, Header: "X-Original-Forwarded-For"})
+	if chain.ClientIP().String() != "192.0.2.8" {
+		t.Errorf("自定义头部: ClientIP() = %v", chain.ClientIP())
+	}
+
+	chain, _ = ParseForwardedChain(newRequest("10.0.0.2:1", "192.0.2.1, 10.0.0.3, 10.0.0.4"), Config{TrustedProxies: blacklist, MaxHops: 2})
+	if !chain.Truncated || len(chain.Hops) != 2 || chain.ClientIP().String() != "10.0.0.4" {
+		t.Errorf("MaxHops: Truncated = %v, 跳数 = %d, ClientIP() = %v", chain.Truncated, len(chain.Hops), chain.ClientIP())
+	}
+}
+
+// TestChainDenied 测试各跳的ACL分类
+func TestChainDenied(t *testing.T) {
+	trusted, _ := ip.NewIPACL([]string{"10.0.0.0/8"}, types.Whitelist)
+	manager := acl.NewManager()
+	if err := manager.SetIPACL([]string{"203.0.113.0/24"}, types.Blacklist); err != nil {
+		t.Fatal(err)
+	}
+	cfg := Config{TrustedProxies: trusted, Manager: manager}
+
+	chain, err := ParseForwardedChain(newRequest("10.0.0.2:1", "192.0.2.1, 203.0.113.9, 10.0.0.3"), cfg)
+	if err != nil {
+		t.Fatalf("ParseForwardedChain() 返回错误: %v", err)
+	}
+	hop, denied := chain.Denied()
+	if !denied || hop.Addr != "203.0.113.9" || hop.Result.Reason != types.ReasonMatchedBlacklistIP {
+		t.Errorf("Denied() = %+v, %v, 期望客户端203.0.113.9被拒绝", hop, denied)
+	}
+	if chain.Hops[0].Verified || chain.Hops[0].Checked {
+		t.Errorf("未验证的一跳 = %+v, 期望不检查", chain.Hops[0])
+	}
+
+	// 伪造的左侧部分即使命中黑名单也不影响结果
+	chain, _ = ParseForwardedChain(newRequest("10.0.0.2:1", "203.0.113.9, 192.0.2.1"), cfg)
+	if hop, denied := chain.Denied(); denied {
+		t.Errorf("伪造的地址影响了结果: Denied() = %+v", hop)
+	}
+
+	// 未设置IP ACL时不视为错误
+	chain, _ = ParseForwardedChain(newRequest("10.0.0.2:1", "192.0.2.1"), Config{TrustedProxies: trusted, Manager: acl.NewManager()})
+	if hop, denied := chain.Denied(); denied {
+		t.Errorf("未设置ACL时 Denied() = %+v", hop)
+	}
+}
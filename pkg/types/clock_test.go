@@ -0,0 +1,41 @@
+package types
+
+import (
+	"testing"
+	"time"
+)
+
+// TestManualClock 测试手动时钟的设置和拨动
+func TestManualClock(t *testing.T) {
+	start := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	clock := NewManualClock(start)
+
+	tests := []struct {
+		name string
+		step func()
+		want time.Time
+	}{
+		{"初始时间", func() {}, start},
+		{"向前拨动", func() { clock.Advance(time.Hour) }, start.Add(time.Hour)},
+		{"向后拨动", func() { clock.Advance(-30 * time.Minute) }, start.Add(30 * time.Minute)},
+		{"直接设置", func() { clock.Set(start.AddDate(1, 0, 0)) }, start.AddDate(1, 0, 0)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.step()
+			if got := clock.Now(); !got.Equal(tt.want) {
+				t.Errorf("Now() = %v, 期望 %v", got, tt.want)
+			}
+		})
+	}
+}
+
+// TestSystemClock 测试系统时钟返回真实时间
+func TestSystemClock(t *testing.T) {
+	before := time.Now()
+	got := SystemClock.Now()
+	if got.Before(before) || got.After(time.Now()) {
+		t.Errorf("SystemClock.Now() = %v, 不在调用前后的时间范围内", got)
+	}
+}
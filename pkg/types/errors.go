@@ -7,7 +7,9 @@ import "errors"
 // 这些错误在ACL操作过程中可能发生，应该被适当处理
 var (
 	// ErrNoACL 表示没有配置对应的访问控制列表
-	// 当试图在Manager中使用某个ACL功能，但该ACL尚未配置时返回此错误
+	// 当试图在Manager中使用某个ACL功能，但该ACL尚未配置时返回此错误。
+	// 这是库中唯一表示"未配置ACL"的错误：ip、domain、acl以及guard、gateway等包返回的都是此值
+	// （或包装了此值的错误），应使用errors.Is判断
 	//
 	// 示例:
 	//    err := manager.CheckIP("192.168.1.1")
@@ -16,6 +18,11 @@ var (
 	//    }
 	ErrNoACL = errors.New("no ACL configured")
 
+	// ErrNoAcl 与ErrNoACL是同一个值，errors.Is对两者的结果相同
+	//
+	// Deprecated: 请改用 ErrNoACL。
+	ErrNoAcl = ErrNoACL
+
 	// 其他可能的错误可以在此处添加
 	// 例如：权限错误、配置错误等
 )
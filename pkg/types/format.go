@@ -0,0 +1,31 @@
+package types
+
+import (
+	"strconv"
+)
+
+// FormatCount 返回带千位分隔符的数量和对应的名词，用于String等摘要输出
+//
+// 参数:
+//   - n: 数量
+//   - singular: n为1时使用的名词，如"domain"
+//   - plural: 其他情况使用的名词，如"domains"
+//
+// 返回:
+//   - string: 例如"1 domain"、"1,204 domains"
+func FormatCount(n int, singular, plural string) string {
+	noun := plural
+	if n == 1 {
+		noun = singular
+	}
+
+	digits := strconv.Itoa(n)
+	sign := ""
+	if n < 0 {
+		sign, digits = "-", digits[1:]
+	}
+	for i := len(digits) - 3; i > 0; i -= 3 {
+		digits = digits[:i] + "," + digits[i:]
+	}
+	return sign + digits + " " + noun
+}
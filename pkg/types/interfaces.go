@@ -3,7 +3,9 @@ package types
 
 // ACL 是所有访问控制列表实现的接口
 // 该接口定义了访问控制列表的核心功能 - 检查访问权限
-// 库中所有的ACL实现（如IP ACL、域名ACL等）都必须实现此接口
+// 库中所有的ACL实现（如IP ACL、域名ACL等）都必须实现此接口，
+// *ip.IPACL和*domain.DomainACL的Check直接返回Permission，无需适配即可作为ACL使用，
+// 通用的组合工具（链式检查、缓存、指标包装等）应以此接口为参数
 //
 // 接口实现示例:
 //
@@ -2,6 +2,15 @@
 // 该包是整个访问控制列表(ACL)系统的类型基础
 package types
 
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// ErrInvalidListType 表示无法识别的列表类型名称
+var ErrInvalidListType = errors.New("无效的列表类型")
+
 // ListType 表示访问控制列表的类型：黑名单或白名单
 // 在ACL系统中，列表类型决定了默认的访问策略和规则的解释方式
 type ListType int
@@ -35,3 +44,24 @@ func (lt ListType) String() string {
 		return "unknown"
 	}
 }
+
+// ParseListType 解析列表类型名称，是String的逆操作
+//
+// 参数:
+//   - s: "blacklist"或"whitelist"，不区分大小写
+//
+// 返回:
+//   - ListType: 对应的列表类型
+//   - error: 无法识别时返回包装了ErrInvalidListType的错误
+//
+// 主要用于解析策略文件和命令行参数。
+func ParseListType(s string) (ListType, error) {
+	switch strings.ToLower(strings.TrimSpace(s)) {
+	case "blacklist":
+		return Blacklist, nil
+	case "whitelist":
+		return Whitelist, nil
+	default:
+		return Blacklist, fmt.Errorf("%w: %q", ErrInvalidListType, s)
+	}
+}
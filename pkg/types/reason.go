@@ -0,0 +1,120 @@
+package types
+
+import "errors"
+
+// Reason 是机器可读的拒绝原因
+//
+// 检查结果（CheckResult）、审计事件、Manager.Explain以及guard、gateway返回的拒绝错误
+// 使用同一组原因，调用方可以按原因分支处理，而不必解析错误信息。
+// 值为稳定的snake_case字符串，可直接写入日志、指标标签和HTTP头部。
+// 允许访问的结果没有原因，为空字符串。
+type Reason string
+
+const (
+	// ReasonMatchedBlacklistIP IP匹配了黑名单中的规则
+	ReasonMatchedBlacklistIP Reason = "matched_blacklist_ip"
+	// ReasonNotInWhitelistIP IP不在白名单中
+	ReasonNotInWhitelistIP Reason = "not_in_whitelist_ip"
+	// ReasonMatchedBlacklistDomain 域名匹配了黑名单中的规则
+	ReasonMatchedBlacklistDomain Reason = "matched_blacklist_domain"
+	// ReasonNotInWhitelistDomain 域名不在白名单中（或命中了白名单的例外）
+	ReasonNotInWhitelistDomain Reason = "not_in_whitelist_domain"
+	// ReasonDomainPolicy 域名命中了拒绝的节点策略
+	ReasonDomainPolicy Reason = "domain_policy"
+	// ReasonDeniedFamily IP所属的地址族被整体拒绝或不被列表接受
+	ReasonDeniedFamily Reason = "denied_family"
+	// ReasonMatchedRule 请求匹配了拒绝的规则表达式
+	ReasonMatchedRule Reason = "matched_rule"
+	// ReasonDefaultDeny 没有配置主列表，命名列表均未命中，按默认行为拒绝
+	ReasonDefaultDeny Reason = "default_deny"
+	// ReasonNoACL 没有配置对应的ACL（ErrNoACL）
+	ReasonNoACL Reason = "no_acl"
+	// ReasonInvalidInput 无效的IP、域名或请求
+	ReasonInvalidInput Reason = "invalid_input"
+	// ReasonBudgetExceeded 检查超出时间预算，使用了fail-closed的兜底结果
+	ReasonBudgetExceeded Reason = "budget_exceeded"
+	// ReasonExpiredRuleGrace 命中的规则已过期，但仍在宽限期内生效
+	ReasonExpiredRuleGrace Reason = "expired_rule_grace"
+	// ReasonMixedScript 域名的标签混用了多种文字，可能是同形异义字攻击
+	ReasonMixedScript Reason = "mixed_script"
+	// ReasonSchemeNotAllowed 出站请求的协议不在允许的范围内
+	ReasonSchemeNotAllowed Reason = "scheme_not_allowed"
+	// ReasonPortNotAllowed 请求的端口不在IP所在范围允许的端口中
+	ReasonPortNotAllowed Reason = "port_not_allowed"
+	// ReasonOverrideDenyAll 管理器处于全部拒绝的紧急模式，所有检查都被拒绝
+	ReasonOverrideDenyAll Reason = "override_deny_all"
+	// ReasonExternalAuthorizer 由外部授权组件（如自定义检查器、远程授权服务）拒绝
+	ReasonExternalAuthorizer Reason = "external_authorizer"
+	// ReasonHostMismatch 请求的URL主机、Host头与TLS SNI不一致，可能是域前置或请求走私
+	ReasonHostMismatch Reason = "host_mismatch"
+	// ReasonCheckFailed 检查因其他错误失败（如故障注入、panic）
+	ReasonCheckFailed Reason = "check_failed"
+)
+
+// String 返回原因的字符串值
+func (r Reason) String() string {
+	return string(r)
+}
+
+// DenyReason 返回列表拒绝目标时的原因
+//
+// 参数:
+//   - kind: 检查类型，"ip"或"domain"
+//   - listType: 做出拒绝决定的列表的类型
+//
+// 返回:
+//   - Reason: 黑名单为ReasonMatchedBlacklistIP/ReasonMatchedBlacklistDomain，
+//     白名单为ReasonNotInWhitelistIP/ReasonNotInWhitelistDomain
+func DenyReason(kind string, listType ListType) Reason {
+	switch {
+	case kind == "ip" && listType == Whitelist:
+		return ReasonNotInWhitelistIP
+	case kind == "ip":
+		return ReasonMatchedBlacklistIP
+	case listType == Whitelist:
+		return ReasonNotInWhitelistDomain
+	default:
+		return ReasonMatchedBlacklistDomain
+	}
+}
+
+// ReasonError 是携带拒绝原因的错误
+//
+// guard和gateway返回的拒绝错误是*ReasonError，Err仍然包装了各自的哨兵错误
+// （如guard.ErrDenied），errors.Is的判断不受影响。
+type ReasonError struct {
+	Reason Reason
+	Err    error
+}
+
+// Error 返回被包装的错误的信息
+func (e *ReasonError) Error() string {
+	return e.Err.Error()
+}
+
+// Unwrap 返回被包装的错误
+func (e *ReasonError) Unwrap() error {
+	return e.Err
+}
+
+// ReasonOf 返回错误链中携带的拒绝原因
+//
+// 参数:
+//   - err: 检查或请求返回的错误
+//
+// 返回:
+//   - Reason: 错误链中第一个ReasonError的原因，没有时为空字符串
+//
+// 示例:
+//
+//	resp, err := client.Get(url)
+//	if errors.Is(err, guard.ErrDenied) {
+//	    metrics.Inc("ssrf_blocked", types.ReasonOf(err).String())
+//	}
+func ReasonOf(err error) Reason {
+	var re *ReasonError
+	if errors.As(err, &re) {
+		return re.Reason
+	}
+	return ""
+}
@@ -0,0 +1,86 @@
+package types
+
+import (
+	"errors"
+	"fmt"
+	"runtime/debug"
+)
+
+// ErrPanic 表示检查器或回调函数在执行过程中发生了panic
+var ErrPanic = errors.New("检查过程中发生panic")
+
+// PanicError 是由panic转换而来的错误，可以用errors.Is(err, ErrPanic)判断
+//
+// 字段说明:
+//   - Value: 传给panic的值
+//   - Stack: 发生panic时的调用栈
+type PanicError struct {
+	Value interface{}
+	Stack []byte
+}
+
+// Error 返回panic的值
+func (e *PanicError) Error() string {
+	return fmt.Sprintf("%v: %v", ErrPanic, e.Value)
+}
+
+// Unwrap 返回ErrPanic
+func (e *PanicError) Unwrap() error {
+	return ErrPanic
+}
+
+// CatchPanic 把当前goroutine中的panic转换为*PanicError写入err，必须直接用defer调用
+//
+// 参数:
+//   - err: 接收错误的变量，通常是函数的命名返回值
+//
+// 没有发生panic时err保持不变。
+//
+// 示例:
+//
+//	func (c *myChecker) Check(value string) (perm types.Permission, err error) {
+//	    defer types.CatchPanic(&err)
+//	    return c.lookup(value)
+//	}
+func CatchPanic(err *error) {
+	if r := recover(); r != nil {
+		*err = &PanicError{Value: r, Stack: debug.Stack()}
+	}
+}
+
+// RecoverACL 返回一个把acl.Check中的panic转换为错误的ACL
+//
+// 参数:
+//   - acl: 被包装的ACL，通常是应用自己实现的检查器
+//
+// 返回:
+//   - ACL: 发生panic时Check返回Denied和*PanicError，其他情况与acl相同
+//
+// 库本身的ACL实现不会因为输入格式错误而panic，此包装主要用于保护请求路径上的自定义检查器，
+// 避免其中的缺陷导致整个服务崩溃。发生panic时结果总是Denied（失败时拒绝）。
+//
+// 示例:
+//
+//	checker := types.RecoverACL(customChecker)
+//	perm, err := checker.Check(value)
+//	if errors.Is(err, types.ErrPanic) {
+//	    log.Printf("检查器异常: %v", err)
+//	}
+func RecoverACL(acl ACL) ACL {
+	return recoverACL{acl: acl}
+}
+
+// recoverACL 是RecoverACL返回的ACL
+type recoverACL struct {
+	acl ACL
+}
+
+// Check 调用被包装ACL的Check，发生panic时返回Denied和*PanicError
+func (r recoverACL) Check(value string) (perm Permission, err error) {
+	defer func() {
+		if v := recover(); v != nil {
+			perm, err = Denied, &PanicError{Value: v, Stack: debug.Stack()}
+		}
+	}()
+	return r.acl.Check(value)
+}
@@ -0,0 +1,64 @@
+package types
+
+import (
+	"time"
+)
+
+// CheckResult 是各模块详细检查接口共用的结果类型
+//
+// ip、domain和acl.Manager的CheckDetailed/Check*Detailed方法都返回此类型，
+// 日志和指标代码只需处理一种结构，不必为每个模块分别适配。
+//
+// 字段说明:
+//   - Target: 被检查的IP、域名，或请求的描述（如"ip=10.0.0.1 port=80"）
+//   - Kind: 检查类型，"ip"、"domain"或"request"
+//   - Decision: 检查结果，出错时为Denied
+//   - RuleID: 决定结果的规则，如"10.0.0.0/8"、"example.com"、"!api.example.com"（例外）、
+//     "policy:example.com"（节点策略）或规则表达式原文；没有规则匹配、按列表类型的默认行为得出结果时为空
+//   - Source: 做出决定的组件，如"ip_acl"、"ip_list:名称"、"domain_acl"、"domain_list:名称"、
+//     "rule"（规则表达式）、"family"（被拒绝的地址族）、"default"（命名列表均未命中时的默认结果）、
+//     "mixed_script"（混用多种文字的域名）、"scheme"（出站请求的协议不被允许）、"override"（紧急模式直接得出的结果）、
+//     "scoped"或"scoped:名称"（上下文中的临时例外）、"scope:名称"（Manager.Scope子视图的规则）、"ip_ports"（端口不在IP范围允许的端口中）、
+//     "dev_mode"（开发模式放行的私有地址，RuleID仍为本应拒绝的规则）、"authorizers"（外部授权来源拒绝了本地允许的请求）
+//     或"budget"（超出检查预算时的兜底结果）
+//   - Matches: 做出决定的IP列表中匹配目标的所有范围，最具体（前缀最长）的在前，第一个即RuleID；
+//     用于审计重叠的列表，只有IP检查填写，单个范围匹配时也只有一项
+//   - Reason: 拒绝或出错的原因，允许访问时为空，见Reason
+//   - Latency: 检查耗时
+//   - Authorizers: 询问过外部授权来源（见acl.Manager.SetAuthorizers）时各来源的决定，按询问的来源排列
+type CheckResult struct {
+	Target   string        `json:"target"`
+	Kind     string        `json:"kind"`
+	Decision Permission    `json:"decision"`
+	RuleID   string        `json:"rule_id,omitempty"`
+	Matches  []string      `json:"matches,omitempty"`
+	Source   string        `json:"source,omitempty"`
+	Reason   Reason        `json:"reason,omitempty"`
+	Latency  time.Duration `json:"latency"`
+
+	Authorizers []AuthorizerVerdict `json:"authorizers,omitempty"`
+}
+
+// AuthorizerVerdict 是一个外部授权来源对请求的决定
+//
+// 字段说明:
+//   - Name: 来源名称
+//   - Decision: 来源的决定，Error不为空时为Denied，实际按配置计为允许或拒绝
+//   - Error: 询问出错、超时或被取消（其他来源已经拒绝）时的错误信息，来源给出决定时为空
+//   - Latency: 询问的耗时
+type AuthorizerVerdict struct {
+	Name     string        `json:"name"`
+	Decision Permission    `json:"decision"`
+	Error    string        `json:"error,omitempty"`
+	Latency  time.Duration `json:"latency"`
+}
+
+// Allowed 判断检查结果是否为允许访问
+func (r CheckResult) Allowed() bool {
+	return r.Decision == Allowed
+}
+
+// Matched 判断结果是否由某条规则决定，而不是列表类型的默认行为
+func (r CheckResult) Matched() bool {
+	return r.RuleID != ""
+}
@@ -1,6 +1,10 @@
 package types
 
-import "testing"
+import (
+	"errors"
+	"fmt"
+	"testing"
+)
 
 // TestListType_String 测试ListType的String方法
 func TestListType_String(t *testing.T) {
@@ -69,3 +73,158 @@ func TestPermission_String(t *testing.T) {
 		})
 	}
 }
+
+// TestFormatCount 测试带千位分隔符的数量格式化
+func TestFormatCount(t *testing.T) {
+	tests := []struct {
+		name string
+		n    int
+		want string
+	}{
+		{"零", 0, "0 domains"},
+		{"单数", 1, "1 domain"},
+		{"三位数", 999, "999 domains"},
+		{"四位数", 1204, "1,204 domains"},
+		{"七位数", 1234567, "1,234,567 domains"},
+		{"负数", -1234, "-1,234 domains"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := FormatCount(tt.n, "domain", "domains"); got != tt.want {
+				t.Errorf("FormatCount(%d) = %q, want %q", tt.n, got, tt.want)
+			}
+		})
+	}
+}
+
+// TestCheckResult 测试CheckResult的辅助方法
+func TestCheckResult(t *testing.T) {
+	tests := []struct {
+		name        string
+		result      CheckResult
+		wantAllowed bool
+		wantMatched bool
+	}{
+		{"命中规则拒绝", CheckResult{Decision: Denied, RuleID: "10.0.0.0/8"}, false, true},
+		{"默认行为允许", CheckResult{Decision: Allowed}, true, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.result.Allowed(); got != tt.wantAllowed {
+				t.Errorf("Allowed() = %v, want %v", got, tt.wantAllowed)
+			}
+			if got := tt.result.Matched(); got != tt.wantMatched {
+				t.Errorf("Matched() = %v, want %v", got, tt.wantMatched)
+			}
+		})
+	}
+}
+
+// panicACL 在Check中panic的ACL
+type panicACL struct{}
+
+func (panicACL) Check(string) (Permission, error) {
+	panic("查询表为nil")
+}
+
+// allowACL 总是允许访问的ACL
+type allowACL struct{}
+
+func (allowACL) Check(string) (Permission, error) {
+	return Allowed, nil
+}
+
+// TestRecoverACL 测试把Check中的panic转换为错误
+func TestRecoverACL(t *testing.T) {
+	perm, err := RecoverACL(panicACL{}).Check("10.0.0.1")
+	if perm != Denied || !errors.Is(err, ErrPanic) {
+		t.Fatalf("Check() = %v, %v; 期望 Denied, ErrPanic", perm, err)
+	}
+	var panicErr *PanicError
+	if !errors.As(err, &panicErr) || panicErr.Value != "查询表为nil" || len(panicErr.Stack) == 0 {
+		t.Errorf("PanicError = %+v", panicErr)
+	}
+
+	if perm, err := RecoverACL(allowACL{}).Check("10.0.0.1"); perm != Allowed || err != nil {
+		t.Errorf("没有panic时 Check() = %v, %v; 期望 Allowed, nil", perm, err)
+	}
+}
+
+// TestCatchPanic 测试没有panic时不改变错误
+func TestCatchPanic(t *testing.T) {
+	run := func(f func()) (err error) {
+		defer CatchPanic(&err)
+		f()
+		return nil
+	}
+	if err := run(func() {}); err != nil {
+		t.Errorf("没有panic时返回 %v", err)
+	}
+	if err := run(func() { panic(42) }); !errors.Is(err, ErrPanic) || err.Error() != ErrPanic.Error()+": 42" {
+		t.Errorf("panic时返回 %v", err)
+	}
+}
+
+// TestParseListType 测试列表类型名称的解析
+func TestParseListType(t *testing.T) {
+	tests := []struct {
+		input   string
+		want    ListType
+		wantErr bool
+	}{
+		{"blacklist", Blacklist, false},
+		{" Whitelist ", Whitelist, false},
+		{"allowlist", Blacklist, true},
+		{"", Blacklist, true},
+	}
+
+	for _, tt := range tests {
+		got, err := ParseListType(tt.input)
+		if (err != nil) != tt.wantErr || got != tt.want {
+			t.Errorf("ParseListType(%q) = %v, %v; want %v, wantErr %v", tt.input, got, err, tt.want, tt.wantErr)
+		}
+		if err != nil && !errors.Is(err, ErrInvalidListType) {
+			t.Errorf("ParseListType(%q) 错误 = %v, 期望 ErrInvalidListType", tt.input, err)
+		}
+	}
+}
+
+// TestDenyReason 测试列表拒绝原因
+func TestDenyReason(t *testing.T) {
+	tests := []struct {
+		kind     string
+		listType ListType
+		want     Reason
+	}{
+		{"ip", Blacklist, ReasonMatchedBlacklistIP},
+		{"ip", Whitelist, ReasonNotInWhitelistIP},
+		{"domain", Blacklist, ReasonMatchedBlacklistDomain},
+		{"domain", Whitelist, ReasonNotInWhitelistDomain},
+	}
+	for _, tt := range tests {
+		if got := DenyReason(tt.kind, tt.listType); got != tt.want {
+			t.Errorf("DenyReason(%s, %v) = %q, 期望 %q", tt.kind, tt.listType, got, tt.want)
+		}
+	}
+}
+
+// TestReasonOf 测试从错误链中取得拒绝原因
+func TestReasonOf(t *testing.T) {
+	denied := errors.New("denied")
+	err := fmt.Errorf("请求失败: %w", &ReasonError{Reason: ReasonMatchedBlacklistIP, Err: denied})
+
+	if got := ReasonOf(err); got != ReasonMatchedBlacklistIP {
+		t.Errorf("ReasonOf() = %q, 期望 %q", got, ReasonMatchedBlacklistIP)
+	}
+	if !errors.Is(err, denied) {
+		t.Error("ReasonError应包装原始错误")
+	}
+	if err.Error() != "请求失败: denied" {
+		t.Errorf("Error() = %q", err.Error())
+	}
+	if got := ReasonOf(denied); got != "" {
+		t.Errorf("没有原因的错误 ReasonOf() = %q, 期望空", got)
+	}
+}
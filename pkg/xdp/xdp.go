@@ -0,0 +1,220 @@
+// Package xdp 将Manager的IP黑名单同步到eBPF映射，供内核中的XDP程序直接丢弃数据包
+//
+// 用户态的检查只能在连接建立、请求到达之后拒绝；被封禁的IP发起大量连接时，
+// 在网卡驱动层用XDP丢弃它们的数据包开销最低。Exporter把Manager.DeniedIPRanges()
+// 写入一个LPM trie类型的eBPF映射，并在Manager的配置改变时（见acl.Manager.WatchChanges）
+// 增量更新：只写入新增的前缀、删除移除的前缀，不重建整个映射。
+//
+// 映射的键是Key（与C中的struct { __u32 prefixlen; __u8 addr[16]; }布局相同），值是uint32(1)。
+// IPv4前缀按IPv4映射的IPv6地址存储（::ffff:a.b.c.d，前缀长度加96），
+// XDP程序查找IPv4源地址时应使用同样的形式，这样一个映射可以同时容纳两种地址族。
+//
+// Exporter只依赖Map接口，*ebpf.Map（github.com/cilium/ebpf）直接实现了该接口。
+// 创建和打开映射的辅助函数依赖该库，位于独立的模块github.com/cyberspacesec/go-acl/ebpfmap中，
+// 使核心模块不依赖第三方库。
+//
+// 用法示例:
+//
+//	m, err := ebpfmap.LoadPinnedMap("/sys/fs/bpf/xdp_deny")
+//	if err != nil {
+//	    log.Fatal(err)
+//	}
+//	exporter := xdp.NewExporter(manager, m)
+//	go exporter.Run(ctx, 5*time.Minute)
+package xdp
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/cyberspacesec/go-acl/pkg/acl"
+	"github.com/cyberspacesec/go-acl/pkg/ip"
+)
+
+// Key 是LPM trie映射的键
+//
+// 字段说明:
+//   - PrefixLen: 前缀长度，IPv4前缀为96加上IPv4的前缀长度
+//   - Addr: IPv6地址，IPv4地址使用IPv4映射的IPv6形式
+type Key struct {
+	PrefixLen uint32
+	Addr      [16]byte
+}
+
+// String 返回键对应的CIDR，IPv4前缀还原为IPv4的写法
+func (k Key) String() string {
+	addr := net.IP(k.Addr[:])
+	if addr.To4() != nil && k.PrefixLen >= 96 {
+		return fmt.Sprintf("%s/%d", addr.To4(), k.PrefixLen-96)
+	}
+	return fmt.Sprintf("%s/%d", addr, k.PrefixLen)
+}
+
+// NewKey 把IP或CIDR转换为映射的键
+//
+// 参数:
+//   - ipRange: IP或CIDR，如"198.51.100.7"、"10.0.0.0/8"、"2001:db8::/32"
+//
+// 返回:
+//   - Key: 映射的键，主机位被清零
+//   - error: 格式无效时返回包装了ip.ErrInvalidIP的错误
+func NewKey(ipRange string) (Key, error) {
+	s := strings.TrimSpace(ipRange)
+	var network *net.IPNet
+	if _, n, err := net.ParseCIDR(s); err == nil {
+		network = n
+	} else if addr := net.ParseIP(s); addr != nil {
+		bits := 128
+		if addr.To4() != nil && !strings.Contains(s, ":") {
+			bits = 32
+		}
+		network = &net.IPNet{IP: addr.Mask(net.CIDRMask(bits, bits)), Mask: net.CIDRMask(bits, bits)}
+	} else {
+		return Key{}, fmt.Errorf("%w: %s", ip.ErrInvalidIP, ipRange)
+	}
+
+	var k Key
+	ones, bits := network.Mask.Size()
+	copy(k.Addr[:], network.IP.To16())
+	k.PrefixLen = uint32(ones)
+	if bits == 32 {
+		k.PrefixLen += 96
+	}
+	return k, nil
+}
+
+// Map 是Exporter写入的eBPF映射，*ebpf.Map实现了此接口
+type Map interface {
+	Put(key, value interface{}) error
+	Delete(key interface{}) error
+}
+
+// SyncResult 是一次同步的结果
+//
+// 字段说明:
+//   - Added: 写入映射的前缀数量
+//   - Removed: 从映射删除的前缀数量
+//   - Total: 同步后映射中由Exporter写入的前缀数量
+type SyncResult struct {
+	Added   int
+	Removed int
+	Total   int
+}
+
+// Exporter 把Manager的IP黑名单同步到eBPF映射
+//
+// Exporter记录自己写入的前缀，每次同步只写入差异，映射中原有的其他条目不受影响。
+// 因此应使用新建或清空的映射；Exporter重启后不知道上次写入的条目，
+// 之后被移出黑名单的前缀会留在映射中。
+// Exporter可以安全地在多个goroutine中使用，同一时刻只执行一次同步。
+type Exporter struct {
+	manager *acl.Manager
+	m       Map
+
+	mu       sync.Mutex
+	exported map[Key]struct{}
+}
+
+// NewExporter 创建把manager的IP黑名单同步到m的Exporter
+//
+// 参数:
+//   - manager: 黑名单的来源，同步的内容为manager.DeniedIPRanges()
+//   - m: LPM trie类型的eBPF映射，键为Key（20字节），值为uint32（4字节）
+//
+// 返回:
+//   - *Exporter: 尚未同步的Exporter，调用Sync或Run开始同步
+func NewExporter(manager *acl.Manager, m Map) *Exporter {
+	return &Exporter{manager: manager, m: m, exported: make(map[Key]struct{})}
+}
+
+// Sync 立即把当前的黑名单同步到映射
+//
+// 返回:
+//   - SyncResult: 本次写入和删除的前缀数量
+//   - error: 写入或删除映射条目失败时返回第一个错误；失败的条目在下次同步时重试
+func (e *Exporter) Sync() (SyncResult, error) {
+	desired := make(map[Key]struct{})
+	for _, r := range e.manager.DeniedIPRanges() {
+		k, err := NewKey(r)
+		if err != nil {
+			continue
+		}
+		desired[k] = struct{}{}
+	}
+
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	var result SyncResult
+	var firstErr error
+	for k := range e.exported {
+		if _, ok := desired[k]; ok {
+			continue
+		}
+		if err := e.m.Delete(k); err != nil {
+			if firstErr == nil {
+				firstErr = fmt.Errorf("删除 %s: %w", k, err)
+			}
+			continue
+		}
+		delete(e.exported, k)
+		result.Removed++
+	}
+	for k := range desired {
+		if _, ok := e.exported[k]; ok {
+			continue
+		}
+		if err := e.m.Put(k, uint32(1)); err != nil {
+			if firstErr == nil {
+				firstErr = fmt.Errorf("写入 %s: %w", k, err)
+			}
+			continue
+		}
+		e.exported[k] = struct{}{}
+		result.Added++
+	}
+	result.Total = len(e.exported)
+	return result, firstErr
+}
+
+// Run 同步一次黑名单，之后在Manager的IP配置改变时增量同步，直到ctx被取消
+//
+// 参数:
+//   - ctx: 控制同步循环生命周期的上下文
+//   - resync: 不依赖改变通知的全量核对间隔，用于覆盖按时间到期的条目等不产生通知的改变；
+//     不大于0时只在收到通知时同步
+//
+// 返回:
+//   - error: ctx被取消时返回ctx.Err()
+//
+// 同步失败不会终止循环，失败的条目在下一次通知或核对时重试。
+// 此方法会阻塞，通常在单独的goroutine中调用。
+func (e *Exporter) Run(ctx context.Context, resync time.Duration) error {
+	changes, stop := e.manager.WatchChanges()
+	defer stop()
+
+	var tick <-chan time.Time
+	if resync > 0 {
+		ticker := time.NewTicker(resync)
+		defer ticker.Stop()
+		tick = ticker.C
+	}
+
+	_, _ = e.Sync()
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case event := <-changes:
+			if event.Kind == "ip" {
+				_, _ = e.Sync()
+			}
+		case <-tick:
+			_, _ = e.Sync()
+		}
+	}
+}
@@ -0,0 +1,188 @@
+package xdp
+
+import (
+	"context"
+	"errors"
+	"sort"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/cyberspacesec/go-acl/pkg/acl"
+	"github.com/cyberspacesec/go-acl/pkg/ip"
+	"github.com/cyberspacesec/go-acl/pkg/types"
+)
+
+// fakeMap 在内存中模拟eBPF映射，fail中的键写入时失败
+type fakeMap struct {
+	mu      sync.Mutex
+	entries map[Key]interface{}
+	fail    map[Key]bool
+	puts    int
+}
+
+func newFakeMap() *fakeMap {
+	return &fakeMap{entries: make(map[Key]interface{}), fail: make(map[Key]bool)}
+}
+
+func (m *fakeMap) Put(key, value interface{}) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	k := key.(Key)
+	if m.fail[k] {
+		return errors.New("映射已满")
+	}
+	m.entries[k] = value
+	m.puts++
+	return nil
+}
+
+func (m *fakeMap) Delete(key interface{}) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	delete(m.entries, key.(Key))
+	return nil
+}
+
+// cidrs 返回映射中的前缀，按字符串排序
+func (m *fakeMap) cidrs() []string {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	var cidrs []string
+	for k := range m.entries {
+		cidrs = append(cidrs, k.String())
+	}
+	sort.Strings(cidrs)
+	return cidrs
+}
+
+// TestNewKey 测试IP和CIDR转换为LPM trie的键
+func TestNewKey(t *testing.T) {
+	tests := []struct {
+		input     string
+		prefixLen uint32
+		want      string
+	}{
+		{"198.51.100.7", 128, "198.51.100.7/32"},
+		{"10.1.2.3/8", 104, "10.0.0.0/8"},
+		{"2001:db8::/32", 32, "2001:db8::/32"},
+		{"::ffff:203.0.113.0/120", 120, "203.0.113.0/24"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			k, err := NewKey(tt.input)
+			if err != nil {
+				t.Fatalf("NewKey() 返回错误: %v", err)
+			}
+			if k.PrefixLen != tt.prefixLen || k.String() != tt.want {
+				t.Errorf("NewKey(%q) = %d %s, 期望 %d %s", tt.input, k.PrefixLen, k, tt.prefixLen, tt.want)
+			}
+		})
+	}
+	if _, err := NewKey("not-an-ip"); !errors.Is(err, ip.ErrInvalidIP) {
+		t.Errorf("NewKey(无效输入) 错误 = %v, 期望 ip.ErrInvalidIP", err)
+	}
+}
+
+// TestExporterSync 测试同步只写入差异，失败的条目在下次同步时重试
+func TestExporterSync(t *testing.T) {
+	manager := acl.NewManager()
+	if err := manager.SetIPACL([]string{"10.0.0.0/8", "198.51.100.7"}, types.Blacklist); err != nil {
+		t.Fatalf("SetIPACL() 返回错误: %v", err)
+	}
+	if err := manager.SetNamedIPList("partners", []string{"203.0.113.0/24"}, types.Whitelist, 10); err != nil {
+		t.Fatalf("SetNamedIPList() 返回错误: %v", err)
+	}
+	if err := manager.SetNamedIPList("bans", []string{"2001:db8::/32", "198.51.100.7"}, types.Blacklist, 20); err != nil {
+		t.Fatalf("SetNamedIPList() 返回错误: %v", err)
+	}
+
+	m := newFakeMap()
+	exporter := NewExporter(manager, m)
+	result, err := exporter.Sync()
+	if err != nil || result != (SyncResult{Added: 3, Total: 3}) {
+		t.Fatalf("Sync() = %+v, %v, 期望写入3个前缀", result, err)
+	}
+	want := []string{"10.0.0.0/8", "198.51.100.7/32", "2001:db8::/32"}
+	if got := m.cidrs(); !equal(got, want) {
+		t.Errorf("映射 = %v, 期望 %v（白名单不导出）", got, want)
+	}
+
+	// 增量更新
+	if err := manager.RemoveIP("10.0.0.0/8"); err != nil {
+		t.Fatalf("RemoveIP() 返回错误: %v", err)
+	}
+	manager.AddIP("192.0.2.0/24")
+	key, _ := NewKey("192.0.2.0/24")
+	m.fail[key] = true
+	result, err = exporter.Sync()
+	if err == nil || result.Removed != 1 || result.Added != 0 {
+		t.Errorf("Sync() = %+v, %v, 期望删除1个前缀并返回写入错误", result, err)
+	}
+	puts := m.puts
+	delete(m.fail, key)
+	if result, err := exporter.Sync(); err != nil || result != (SyncResult{Added: 1, Total: 3}) {
+		t.Errorf("重试 Sync() = %+v, %v, 期望只写入失败的前缀", result, err)
+	}
+	if m.puts != puts+1 {
+		t.Errorf("重试写入了 %d 个条目, 期望 1", m.puts-puts)
+	}
+
+	// 停用规则组后其中的列表不再导出
+	manager.SetNamedIPListGroup("bans", "holiday")
+	manager.DisableGroup("holiday")
+	exporter.Sync()
+	want = []string{"192.0.2.0/24", "198.51.100.7/32"}
+	if got := m.cidrs(); !equal(got, want) {
+		t.Errorf("停用规则组后映射 = %v, 期望 %v", got, want)
+	}
+}
+
+// TestExporterRun 测试Run在Manager改变后同步映射
+func TestExporterRun(t *testing.T) {
+	manager := acl.NewManager()
+	if err := manager.SetIPACL([]string{"198.51.100.7"}, types.Blacklist); err != nil {
+		t.Fatalf("SetIPACL() 返回错误: %v", err)
+	}
+	m := newFakeMap()
+	exporter := NewExporter(manager, m)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error, 1)
+	go func() { done <- exporter.Run(ctx, 0) }()
+
+	waitFor := func(want []string) {
+		t.Helper()
+		deadline := time.Now().Add(2 * time.Second)
+		for !equal(m.cidrs(), want) {
+			if time.Now().After(deadline) {
+				t.Fatalf("映射 = %v, 期望 %v", m.cidrs(), want)
+			}
+			time.Sleep(time.Millisecond)
+		}
+	}
+	waitFor([]string{"198.51.100.7/32"})
+
+	manager.AddIP("192.0.2.1")
+	waitFor([]string{"192.0.2.1/32", "198.51.100.7/32"})
+	manager.Reset()
+	waitFor(nil)
+
+	cancel()
+	if err := <-done; !errors.Is(err, context.Canceled) {
+		t.Errorf("Run() = %v, 期望 context.Canceled", err)
+	}
+}
+
+// equal 比较两个字符串切片
+func equal(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,10 @@
+module github.com/cyberspacesec/go-acl/yaml
+
+go 1.18
+
+require (
+	github.com/cyberspacesec/go-acl v0.0.0
+	gopkg.in/yaml.v3 v3.0.1
+)
+
+replace github.com/cyberspacesec/go-acl => ../
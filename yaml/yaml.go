@@ -0,0 +1,100 @@
+// Package yaml 为go-acl的策略文件提供YAML格式支持
+//
+// YAML支持位于独立的模块中，使go-acl的核心模块不依赖任何第三方库。
+// 导入本包后，acl.LoadPolicyFile会按扩展名读取.yaml和.yml策略文件:
+//
+//	import (
+//	    "github.com/cyberspacesec/go-acl/pkg/acl"
+//	    _ "github.com/cyberspacesec/go-acl/yaml"
+//	)
+//
+//	manager, err := acl.LoadPolicyFile("./policy.yaml")
+//
+// 示例文件:
+//
+//	ip:
+//	  type: blacklist
+//	  ranges: [203.0.113.0/24]
+//	  predefined: [private_networks, cloud_metadata]
+//	domain:
+//	  type: blacklist
+//	  domains: [ads.example.com]
+//	  include_subdomains: true
+//	rules:
+//	  - port == 22 -> deny
+package yaml
+
+import (
+	"bytes"
+	"errors"
+	"io"
+
+	"github.com/cyberspacesec/go-acl/pkg/acl"
+	yamlv3 "gopkg.in/yaml.v3"
+)
+
+// Codec 是YAML格式的策略编码，Unmarshal将未知字段视为错误
+var Codec acl.PolicyCodec = codec{}
+
+func init() {
+	acl.RegisterPolicyCodec(".yaml", Codec)
+	acl.RegisterPolicyCodec(".yml", Codec)
+}
+
+type codec struct{}
+
+func (codec) Marshal(v interface{}) ([]byte, error) {
+	var buf bytes.Buffer
+	enc := yamlv3.NewEncoder(&buf)
+	enc.SetIndent(2)
+	if err := enc.Encode(v); err != nil {
+		return nil, err
+	}
+	if err := enc.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+func (codec) Unmarshal(data []byte, v interface{}) error {
+	dec := yamlv3.NewDecoder(bytes.NewReader(data))
+	dec.KnownFields(true)
+	// 空文档表示空策略，与空的JSON对象一致
+	if err := dec.Decode(v); err != nil && !errors.Is(err, io.EOF) {
+		return err
+	}
+	return nil
+}
+
+// ReadPolicy 从r中读取YAML策略
+//
+// 参数:
+//   - r: YAML策略数据
+//
+// 返回:
+//   - acl.Policy: 解析后的策略
+//   - error: YAML格式错误或包含未知字段时返回错误
+func ReadPolicy(r io.Reader) (acl.Policy, error) {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return acl.Policy{}, err
+	}
+	return acl.DecodePolicy(data, Codec)
+}
+
+// WritePolicy 将策略以YAML格式写入w
+//
+// 参数:
+//   - w: 输出目标
+//   - policy: 策略
+//
+// 返回:
+//   - error: 编码或写入过程中的错误
+func WritePolicy(w io.Writer, policy acl.Policy) error {
+	data, err := acl.EncodePolicy(policy, Codec)
+	if err != nil {
+		return err
+	}
+	_, err = w.Write(data)
+	return err
+}
@@ -0,0 +1,144 @@
+package yaml
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/cyberspacesec/go-acl/pkg/acl"
+	"github.com/cyberspacesec/go-acl/pkg/ip"
+	"github.com/cyberspacesec/go-acl/pkg/types"
+)
+
+const testPolicy = `
+ip:
+  type: blacklist
+  ranges: [203.0.113.0/24]
+  predefined: [cloud_metadata]
+domain:
+  type: whitelist
+  domains: [example.com]
+  include_subdomains: true
+rules:
+  - port == 22 -> deny
+`
+
+// TestLoadPolicyFile 测试导入本包后按扩展名读取YAML策略文件
+func TestLoadPolicyFile(t *testing.T) {
+	for _, name := range []string{"policy.yaml", "policy.YML"} {
+		t.Run(name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), name)
+			if err := os.WriteFile(path, []byte(testPolicy), 0o644); err != nil {
+				t.Fatal(err)
+			}
+			manager, err := acl.LoadPolicyFile(path)
+			if err != nil {
+				t.Fatalf("LoadPolicyFile() 返回错误: %v", err)
+			}
+
+			checks := []struct {
+				name string
+				perm func() (types.Permission, error)
+				want types.Permission
+			}{
+				{"IP范围", func() (types.Permission, error) { return manager.CheckIP("203.0.113.9") }, types.Denied},
+				{"预定义集合", func() (types.Permission, error) { return manager.CheckIP("169.254.169.254") }, types.Denied},
+				{"白名单子域名", func() (types.Permission, error) { return manager.CheckDomain("api.example.com") }, types.Allowed},
+				{"白名单外的域名", func() (types.Permission, error) { return manager.CheckDomain("example.org") }, types.Denied},
+			}
+			for _, c := range checks {
+				if perm, err := c.perm(); err != nil || perm != c.want {
+					t.Errorf("%s: 结果 = %v, %v; 期望 %v", c.name, perm, err, c.want)
+				}
+			}
+		})
+	}
+}
+
+// TestPolicyRoundTrip 测试YAML策略的读写
+func TestPolicyRoundTrip(t *testing.T) {
+	want := acl.Policy{
+		Version: acl.PolicyVersion,
+		IP:      &acl.IPPolicy{Type: "blacklist", Ranges: []string{"203.0.113.0/24"}, Predefined: []ip.PredefinedSet{ip.CloudMetadata}},
+		Domain:  &acl.DomainPolicy{Type: "whitelist", Domains: []string{"example.com"}, IncludeSubdomains: true},
+		Rules:   []string{"port == 22 -> deny"},
+	}
+
+	policy, err := ReadPolicy(strings.NewReader(testPolicy))
+	if err != nil {
+		t.Fatalf("ReadPolicy() 返回错误: %v", err)
+	}
+	if !reflect.DeepEqual(policy, want) {
+		t.Errorf("ReadPolicy() = %+v, 期望 %+v", policy, want)
+	}
+
+	var buf bytes.Buffer
+	if err := WritePolicy(&buf, want); err != nil {
+		t.Fatalf("WritePolicy() 返回错误: %v", err)
+	}
+	if !strings.Contains(buf.String(), "include_subdomains: true") {
+		t.Errorf("WritePolicy() 输出没有使用策略的字段名:\n%s", buf.String())
+	}
+	again, err := ReadPolicy(&buf)
+	if err != nil {
+		t.Fatalf("读取WritePolicy()的输出返回错误: %v", err)
+	}
+	if !reflect.DeepEqual(again, want) {
+		t.Errorf("往返后的策略 = %+v, 期望 %+v", again, want)
+	}
+}
+
+// TestPolicyErrors 测试无效的YAML策略
+func TestPolicyErrors(t *testing.T) {
+	tests := []struct {
+		name string
+		data string
+	}{
+		{"未知字段", "ip:\n  type: blacklist\n  rangez: [10.0.0.0/8]\n"},
+		{"格式错误", "ip: [\n"},
+		{"类型错误", "rules: port == 22\n"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := ReadPolicy(strings.NewReader(tt.data)); err == nil {
+				t.Error("ReadPolicy() 应返回错误")
+			}
+		})
+	}
+
+	if policy, err := ReadPolicy(strings.NewReader("")); err != nil || policy.IP != nil {
+		t.Errorf("空文档 ReadPolicy() = %+v, %v, 期望空策略", policy, err)
+	}
+}
+
+// TestSaveConfig 测试导入本包后Manager可以保存和恢复YAML格式的完整配置
+func TestSaveConfig(t *testing.T) {
+	manager := acl.NewManager()
+	if err := manager.SetIPACL([]string{"203.0.113.0/24"}, types.Blacklist); err != nil {
+		t.Fatal(err)
+	}
+	manager.SetDomainACL([]string{"example.com"}, types.Whitelist, true)
+
+	path := filepath.Join(t.TempDir(), "acl.yaml")
+	if err := manager.SaveConfig(path, acl.ConfigYAML); err != nil {
+		t.Fatalf("SaveConfig() 返回错误: %v", err)
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(data), "include_subdomains: true") {
+		t.Errorf("保存的配置不是YAML:\n%s", data)
+	}
+
+	restored, err := acl.LoadManagerFromConfig(path)
+	if err != nil {
+		t.Fatalf("LoadManagerFromConfig() 返回错误: %v", err)
+	}
+	if !reflect.DeepEqual(restored.Policy(), manager.Policy()) {
+		t.Errorf("恢复的配置 = %+v, 期望 %+v", restored.Policy(), manager.Policy())
+	}
+}